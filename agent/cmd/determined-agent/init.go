@@ -148,4 +148,12 @@ func registerAgentConfig() {
 
 	registerString(flags, name("container-runtime"), defaults.ContainerRuntime,
 		"The container runtime to use")
+
+	// Capacity flags.
+	registerString(flags, name("capacity-type"), defaults.CapacityType,
+		"Kind of capacity the agent runs on (on_demand or spot)")
+	registerString(flags, name("termination-notice-url"), defaults.TerminationNoticeURL,
+		"Instance metadata endpoint to poll for notice that the instance is being reclaimed")
+	registerInt(flags, name("termination-notice-interval"), defaults.TerminationNoticeInterval,
+		"Seconds between polls of the termination notice endpoint")
 }
@@ -115,6 +115,8 @@ bind_port: 9090
 agent_reconnect_attempts: 5
 agent_reconnect_backoff: 5
 container_runtime: docker
+capacity_type: on_demand
+termination_notice_interval: 5
 `,
 			expected: options.DefaultOptions(),
 		},
@@ -173,6 +175,8 @@ bind_port: 9090
 agent_reconnect_attempts: 10
 agent_reconnect_backoff: 11
 container_runtime: docker
+capacity_type: on_demand
+termination_notice_interval: 5
 `,
 			expected: defaultOptions,
 		},
@@ -230,6 +234,8 @@ bind_port: 9090
 agent_reconnect_attempts: 10
 agent_reconnect_backoff: 11
 container_runtime: docker
+capacity_type: on_demand
+termination_notice_interval: 5
 `,
 			expected: defaultAndFlagOptions,
 		},
@@ -25,6 +25,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/cproto"
 	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/syncx/errgroupx"
 	"github.com/determined-ai/determined/master/pkg/ws"
 )
@@ -162,11 +163,27 @@ func (a *Agent) run(ctx context.Context) error {
 		Devices:              devices,
 		ContainersReattached: reattached,
 		ResourcePoolName:     a.opts.ResourcePool,
+		CapacityType:         model.CapacityType(a.opts.CapacityType),
 	}}:
 	case <-ctx.Done():
 		return ctx.Err()
 	}
 
+	if a.opts.TerminationNoticeURL != "" {
+		a.log.Trace("watching for termination notices")
+		watcher := newTerminationNoticeWatcher(
+			a.opts.TerminationNoticeURL,
+			time.Duration(a.opts.TerminationNoticeInterval)*time.Second,
+		)
+		// The watcher stops with the agent, not only when the agent fails.
+		watchCtx, cancelWatch := context.WithCancel(ctx)
+		defer cancelWatch()
+		a.wg.Go(func(context.Context) error {
+			watcher.run(watchCtx, outbox)
+			return nil
+		})
+	}
+
 	a.log.Trace("watching for ws requests and system events")
 	inbox := socket.Inbox
 	for {
@@ -354,6 +371,7 @@ func (a *Agent) reconnectFlow(
 		Devices:              devices,
 		ContainersReattached: reattached,
 		ResourcePoolName:     a.opts.ResourcePool,
+		CapacityType:         model.CapacityType(a.opts.CapacityType),
 	}}:
 	case <-ctx.Done():
 		return nil, nil, ctx.Err()
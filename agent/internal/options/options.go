@@ -10,6 +10,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/model"
 
 	"github.com/pkg/errors"
 )
@@ -36,6 +37,9 @@ func DefaultOptions() *Options {
 		AgentReconnectAttempts: aproto.AgentReconnectAttempts,
 		AgentReconnectBackoff:  int(aproto.AgentReconnectBackoff / time.Second),
 		ContainerRuntime:       DockerContainerRuntime,
+		CapacityType:           string(model.CapacityTypeOnDemand),
+
+		TerminationNoticeInterval: 5,
 	}
 }
 
@@ -84,6 +88,13 @@ type Options struct {
 
 	ContainerAutoRemoveDisabled bool `json:"container_auto_remove_disabled"`
 
+	// CapacityType is whether the agent runs on on-demand or spot capacity.
+	CapacityType string `json:"capacity_type"`
+	// TerminationNoticeURL is the instance metadata endpoint polled, every
+	// TerminationNoticeInterval seconds, for notice that the instance is about to be reclaimed.
+	TerminationNoticeURL      string `json:"termination_notice_url"`
+	TerminationNoticeInterval int    `json:"termination_notice_interval"`
+
 	Hooks HooksOptions `json:"hooks"`
 
 	// The Fluent docker image to use, deprecated.
@@ -113,6 +124,11 @@ func (o Options) Validate() []error {
 		o.validateTLS(),
 		check.In(o.SlotType, []string{"gpu", "cuda", "rocm", "cpu", "auto", "none"}),
 		check.NotEmpty(o.MasterHost, "master host must be provided"),
+		check.In(o.CapacityType, []string{
+			"", string(model.CapacityTypeOnDemand), string(model.CapacityTypeSpot),
+		}),
+		check.GreaterThan(o.TerminationNoticeInterval, 0,
+			"termination notice interval must be greater than 0"),
 	}
 }
 
@@ -72,6 +72,8 @@ bind_port: 9090
 agent_reconnect_attempts: 5
 agent_reconnect_backoff: 5
 container_runtime: docker
+capacity_type: on_demand
+termination_notice_interval: 5
 `,
 			expected: *DefaultOptions(),
 		},
@@ -111,6 +113,9 @@ no_proxy: determined_no_proxy
 agent_reconnect_attempts: 3
 agent_reconnect_backoff: 4
 container_runtime: docker_runtime_env
+capacity_type: spot
+termination_notice_url: http://169.254.169.254/latest/meta-data/spot/instance-action
+termination_notice_interval: 10
 `,
 			expected: Options{
 				ConfigFile: "agent_config",
@@ -149,6 +154,10 @@ container_runtime: docker_runtime_env
 				AgentReconnectAttempts: 3,
 				AgentReconnectBackoff:  4,
 				ContainerRuntime:       "docker_runtime_env",
+				CapacityType:           "spot",
+
+				TerminationNoticeURL:      "http://169.254.169.254/latest/meta-data/spot/instance-action",
+				TerminationNoticeInterval: 10,
 			},
 		},
 	}
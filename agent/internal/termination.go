@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/aproto"
+)
+
+const (
+	// imdsTokenPath and the headers below implement IMDSv2 on AWS, which requires a session token
+	// to read instance metadata.
+	imdsTokenPath      = "/latest/api/token"
+	imdsTokenHeader    = "X-aws-ec2-metadata-token"
+	imdsTokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+	imdsTokenTTL       = "21600"
+	// gcpMetadataHeader is required by the GCP metadata server.
+	gcpMetadataHeader = "Metadata-Flavor"
+	// gcpPreemptionNotice is how long a preempted GCP instance has before it is stopped.
+	gcpPreemptionNotice = 30 * time.Second
+)
+
+// terminationNoticeWatcher polls the instance metadata endpoint of a cloud provider for notice
+// that the instance of the agent is about to be reclaimed. It understands the AWS spot
+// instance-action document, the GCP preempted flag, and any endpoint that answers 404 until
+// there is a notice.
+type terminationNoticeWatcher struct {
+	url      string
+	interval time.Duration
+	client   *http.Client
+	log      *logrus.Entry
+
+	token string
+}
+
+func newTerminationNoticeWatcher(url string, interval time.Duration) *terminationNoticeWatcher {
+	return &terminationNoticeWatcher{
+		url:      url,
+		interval: interval,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		log:      logrus.WithField("component", "termination-notice"),
+	}
+}
+
+// run polls until there is a notice, sends it, and returns.
+func (w *terminationNoticeWatcher) run(ctx context.Context, outbox chan<- *aproto.MasterMessage) {
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		notice, err := w.check(ctx)
+		switch {
+		case err != nil:
+			w.log.WithError(err).Debug("failed to check for a termination notice")
+		case notice != nil:
+			w.log.Warnf("instance will be reclaimed (action: %s) at %s", notice.Action, notice.Time)
+			select {
+			case outbox <- &aproto.MasterMessage{AgentTerminationNotice: notice}:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// check returns the termination notice of the instance, or nil if there is none yet.
+func (w *terminationNoticeWatcher) check(ctx context.Context) (*aproto.AgentTerminationNotice, error) {
+	resp, err := w.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		if err := w.refreshToken(ctx); err != nil {
+			return nil, err
+		}
+		if resp, err = w.get(ctx); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseTerminationNotice(body, time.Now())
+}
+
+func (w *terminationNoticeWatcher) get(ctx context.Context) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(gcpMetadataHeader, "Google")
+	if w.token != "" {
+		req.Header.Set(imdsTokenHeader, w.token)
+	}
+	return w.client.Do(req)
+}
+
+// refreshToken gets a new IMDSv2 session token from the metadata endpoint.
+func (w *terminationNoticeWatcher) refreshToken(ctx context.Context) error {
+	u, err := url.Parse(w.url)
+	if err != nil {
+		return err
+	}
+	u.Path, u.RawQuery = imdsTokenPath, ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(imdsTokenTTLHeader, imdsTokenTTL)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s getting a metadata token", resp.Status)
+	}
+	token, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	w.token = string(token)
+	return nil
+}
+
+// parseTerminationNotice parses the response of a metadata endpoint that has a notice, or might.
+func parseTerminationNotice(body []byte, now time.Time) (*aproto.AgentTerminationNotice, error) {
+	switch strings.TrimSpace(string(body)) {
+	case "FALSE":
+		return nil, nil
+	case "TRUE":
+		return &aproto.AgentTerminationNotice{
+			Action: "stop",
+			Time:   now.Add(gcpPreemptionNotice),
+		}, nil
+	}
+
+	var notice struct {
+		Action string    `json:"action"`
+		Time   time.Time `json:"time"`
+	}
+	if err := json.Unmarshal(body, &notice); err != nil {
+		return nil, fmt.Errorf("parsing termination notice %q: %w", body, err)
+	}
+	if notice.Action == "" {
+		notice.Action = "terminate"
+	}
+	if notice.Time.IsZero() {
+		notice.Time = now
+	}
+	return &aproto.AgentTerminationNotice{Action: notice.Action, Time: notice.Time}, nil
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/aproto"
+)
+
+func TestParseTerminationNotice(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	notice, err := parseTerminationNotice(
+		[]byte(`{"action": "terminate", "time": "2024-01-01T00:02:00Z"}`), now)
+	require.NoError(t, err)
+	require.Equal(t, "terminate", notice.Action)
+	require.Equal(t, now.Add(2*time.Minute), notice.Time.UTC())
+
+	notice, err = parseTerminationNotice([]byte("TRUE\n"), now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(gcpPreemptionNotice), notice.Time)
+
+	notice, err = parseTerminationNotice([]byte("FALSE"), now)
+	require.NoError(t, err)
+	require.Nil(t, notice)
+
+	_, err = parseTerminationNotice([]byte("<html>"), now)
+	require.Error(t, err)
+}
+
+func TestTerminationNoticeWatcher(t *testing.T) {
+	var reclaimed atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc(imdsTokenPath, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, imdsTokenTTL, r.Header.Get(imdsTokenTTLHeader))
+		_, err := w.Write([]byte("token"))
+		require.NoError(t, err)
+	})
+	mux.HandleFunc("/latest/meta-data/spot/instance-action",
+		func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Header.Get(imdsTokenHeader) != "token":
+				w.WriteHeader(http.StatusUnauthorized)
+			case !reclaimed.Load():
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				_, err := w.Write([]byte(`{"action": "stop", "time": "2024-01-01T00:02:00Z"}`))
+				require.NoError(t, err)
+			}
+		})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	w := newTerminationNoticeWatcher(
+		server.URL+"/latest/meta-data/spot/instance-action", 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	notice, err := w.check(ctx)
+	require.NoError(t, err)
+	require.Nil(t, notice)
+	require.Equal(t, "token", w.token)
+
+	outbox := make(chan *aproto.MasterMessage, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.run(ctx, outbox)
+	}()
+	reclaimed.Store(true)
+
+	select {
+	case msg := <-outbox:
+		require.NotNil(t, msg.AgentTerminationNotice)
+		require.Equal(t, "stop", msg.AgentTerminationNotice.Action)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no termination notice was sent")
+	}
+	<-done
+}
@@ -3,6 +3,7 @@ import pathlib
 import pytest
 
 import determined as det
+from determined.common.api import bindings
 from tests import api_utils
 from tests import command as cmd
 from tests import detproc
@@ -41,3 +42,30 @@ def test_open_shell() -> None:
         command = ["det", "shell", "open", shell.task_id, "det", "user", "whoami"]
         output = detproc.check_output(sess, command)
         assert "You are logged in as user" in output
+
+
+def _ssh_ca_enabled(mc: dict) -> bool:
+    ssh = (mc.get("security") or {}).get("ssh") or {}
+    return bool((ssh.get("certificate_authority") or {}).get("enabled"))
+
+
+@pytest.mark.e2e_cpu_rbac
+@api_utils.skipif_unexpected_master_config(
+    _ssh_ca_enabled, reason="the SSH certificate authority is required for this test"
+)
+def test_open_shell_with_ssh_certificate() -> None:
+    sess = api_utils.user_session()
+    with cmd.interactive_command(sess, ["shell", "start", "--detach"]) as shell:
+        assert shell.task_id
+        # Shells that accept certificates never hand out their private key.
+        resp = bindings.get_GetShell(sess, shellId=shell.task_id)
+        assert not resp.shell.privateKey
+
+        command = ["det", "shell", "open", shell.task_id, "det", "user", "whoami"]
+        output = detproc.check_output(sess, command)
+        assert "You are logged in as user" in output
+
+        me = bindings.get_GetMe(sess).user
+        assert me.id is not None
+        certs = bindings.get_GetSSHCertificates(sess, userId=me.id, taskId=shell.task_id)
+        assert len(certs.certificates) == 1
@@ -91,6 +91,22 @@ def open_notebook(args: argparse.Namespace) -> None:
     webbrowser.open(f"{args.master}/{nb_path}")
 
 
+def suspend_notebook(args: argparse.Namespace) -> None:
+    sess = cli.setup_session(args)
+    notebook_id = typing.cast(str, ntsc.expand_uuid_prefixes(sess, args))
+
+    bindings.post_SuspendNotebook(sess, notebookId=notebook_id)
+    print(f"Suspending notebook {notebook_id} once its working directory is saved")
+
+
+def resume_notebook(args: argparse.Namespace) -> None:
+    sess = cli.setup_session(args)
+    notebook_id = typing.cast(str, ntsc.expand_uuid_prefixes(sess, args))
+
+    bindings.post_ResumeNotebook(sess, notebookId=notebook_id)
+    print(f"Resuming notebook {notebook_id} with its saved working directory")
+
+
 args_description: cli.ArgsDescription = [
     cli.Cmd(
         "notebook",
@@ -193,6 +209,18 @@ args_description: cli.ArgsDescription = [
                     cli.Arg("-f", "--force", action="store_true", help="ignore errors"),
                 ],
             ),
+            cli.Cmd(
+                "suspend",
+                suspend_notebook,
+                "suspend a notebook, saving its working directory",
+                [cli.Arg("notebook_id", help="notebook ID")],
+            ),
+            cli.Cmd(
+                "resume",
+                resume_notebook,
+                "resume a suspended notebook",
+                [cli.Arg("notebook_id", help="notebook ID")],
+            ),
             cli.Cmd(
                 "set",
                 None,
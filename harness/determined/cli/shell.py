@@ -128,6 +128,41 @@ def _prepare_key(retention_dir: Union[pathlib.Path, None]) -> Tuple[ContextManag
         return file_closer(), path
 
 
+def _generate_key() -> Tuple[str, str]:
+    """Generate an SSH keypair with ssh-keygen and return its private and public keys."""
+    with tempfile.TemporaryDirectory() as key_dir:
+        path = os.path.join(key_dir, "key")
+        subprocess.run(
+            ["ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "", "-f", path],
+            check=True,
+            stdin=subprocess.DEVNULL,
+        )
+        with open(path) as f:
+            private_key = f.read()
+        with open(path + ".pub") as f:
+            public_key = f.read()
+    return private_key, public_key
+
+
+@contextlib.contextmanager
+def _prepare_certificate(keypath: str, certificate: str, retain: bool) -> Iterator[str]:
+    # ssh looks for the certificate of an identity file next to it, as <identity>-cert.pub.
+    cert_path = keypath + "-cert.pub"
+    with open(cert_path, "w") as f:
+        f.write(certificate)
+    try:
+        yield cert_path
+    finally:
+        if not retain:
+            try:
+                os.remove(cert_path)
+            except Exception as e:
+                print(
+                    termcolor.colored(f"failed to cleanup {cert_path}: {e}", "yellow"),
+                    file=sys.stderr,
+                )
+
+
 def _prepare_cert_bundle(retention_dir: Union[pathlib.Path, None]) -> Union[str, bool, None]:
     cert = cli.cert
     assert cert is not None, "cli.cert was not configured"
@@ -153,15 +188,44 @@ def _open_shell(
         if not cache_dir.exists():
             cache_dir.mkdir(parents=True)
 
+    private_key = shell.get("privateKey")
+    certificate = None
+    if not private_key:
+        # The master acts as an SSH certificate authority, so it signs a key of our own instead of
+        # handing out the key of the shell.
+        private_key, public_key = _generate_key()
+        certificate = bindings.post_PostShellSSHCertificate(
+            sess,
+            body=bindings.v1PostShellSSHCertificateRequest(
+                shellId=shell["id"], publicKey=public_key
+            ),
+            shellId=shell["id"],
+        )
+
     f, keypath = _prepare_key(cache_dir)
-    with f as keyfile:
-        keyfile.write(shell["privateKey"])
+    with contextlib.ExitStack() as stack:
+        keyfile = stack.enter_context(f)
+        keyfile.write(private_key)
         keyfile.flush()
 
         # Use determined.cli.tunnel as a portable script for using the HTTP CONNECT mechanism,
         # similar to `nc -X CONNECT -x ...` but without any dependency on external binaries.
         proxy_cmd = f"{sys.executable} -m determined.cli.tunnel {sess.master} %h"
 
+        cert_opts: List[str] = []
+        if certificate is not None:
+            cert_path = stack.enter_context(
+                _prepare_certificate(keypath, certificate.certificate, retain=bool(cache_dir))
+            )
+            if sys.platform == "win32":
+                cert_path = cert_path.replace("\\", "/")
+            cert_opts = ["-o", f"CertificateFile={cert_path}"]
+            # Shells that accept certificates only proxy connections of users who may still access
+            # them, so the tunnel authenticates as the current user.
+            proxy_cmd += f' --auth --user "{sess.username}"'
+            if retain_keys_and_print:
+                cli.warn(f"The SSH certificate for this shell expires at {certificate.validBefore}")
+
         cert_bundle_path = _prepare_cert_bundle(cache_dir)
         if cert_bundle_path is False:
             proxy_cmd += " --cert-file noverify"
@@ -199,6 +263,7 @@ def _open_shell(
             "IdentitiesOnly=yes",
             "-i",
             unixy_keypath,
+            *cert_opts,
             f"{username}@{shell['id']}",
             *additional_opts,
         ]
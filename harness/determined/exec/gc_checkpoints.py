@@ -128,6 +128,12 @@ def main(argv: List[str]) -> None:
         default=os.getenv("DET_DELETE_TENSORBOARDS", False),
         help="Delete Tensorboards from storage",
     )
+    parser.add_argument(
+        "--untracked",
+        action="store_true",
+        help="The storage IDs to delete are not checkpoints known to the master, such as notebook "
+        "snapshots, so there is nothing to update once they are deleted",
+    )
     parser.add_argument(
         "--dry-run",
         action="store_true",
@@ -156,7 +162,8 @@ def main(argv: List[str]) -> None:
         storage_ids_to_resources = delete_checkpoints(
             manager, storage_ids, globs, dry_run=args.dry_run
         )
-        patch_checkpoints(storage_ids_to_resources)
+        if not args.untracked:
+            patch_checkpoints(storage_ids_to_resources)
 
     if args.delete_tensorboards:
         tb_manager = tensorboard.build(
@@ -208,8 +208,9 @@ class _PyTorchTrialController:
         )
         return metrics
 
-    def _is_best_validation(self, now: float, before: Optional[float]) -> bool:
-        if before is None:
+    def _is_best_validation(self, now: Optional[float], before: Optional[float]) -> bool:
+        # A derived searcher metric is only known to the master, so checkpoint every validation.
+        if now is None or before is None:
             return True
 
         return (now < before) if self.smaller_is_better else (now > before)
@@ -614,6 +615,10 @@ class _PyTorchTrialController:
             self._checkpoint(already_exiting=False)
 
     def _check_searcher_metric(self, val_metrics: Dict) -> Any:
+        assert self.searcher_metric_name
+        if util.is_derived_metric(self.searcher_metric_name):
+            return None
+
         if self.searcher_metric_name not in val_metrics:
             raise RuntimeError(
                 f"Search method is configured to use metric '{self.searcher_metric_name}' but "
@@ -880,7 +885,6 @@ class _PyTorchTrialController:
                         self.searcher_metric_name
                     ), "checkpoint policy 'best' but searcher metric name not defined"
                     searcher_metric = self._check_searcher_metric(metrics)
-                    assert searcher_metric is not None
 
                     if self._is_best_validation(now=searcher_metric, before=best_validation_before):
                         should_checkpoint = True
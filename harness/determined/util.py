@@ -464,6 +464,15 @@ def forward_signals(
             signal.signal(n, old)
 
 
+# Searcher metrics with this prefix are derived metrics of the project of the experiment. The master
+# computes them from the reported validation metrics, so trials never report them themselves.
+DERIVED_METRIC_PREFIX = "derived."
+
+
+def is_derived_metric(name: str) -> bool:
+    return name.startswith(DERIVED_METRIC_PREFIX)
+
+
 def is_numerical_scalar(n: Any) -> bool:
     """
     Check if the argument is a numerical scalar that is writeable to TensorBoard.
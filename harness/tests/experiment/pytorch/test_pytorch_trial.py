@@ -1235,6 +1235,27 @@ class TestPyTorchTrial:
             controller.core_context.train.get_experiment_best_validation.reset_mock()
             controller._checkpoint.reset_mock()
 
+    def test_derived_searcher_metric(self, tmp_path: pathlib.Path):
+        trial, controller = pytorch_utils.create_trial_and_trial_controller(
+            trial_class=pytorch_onevar_model.OneVarTrial,
+            hparams=self.hparams,
+            trial_seed=self.trial_seed,
+            max_batches=100,
+            tensorboard_path=tmp_path.joinpath("tensorboard"),
+        )
+
+        # The master computes derived searcher metrics, so the trial never reports them and
+        # checkpoints every validation under the "best" policy.
+        controller.searcher_metric_name = "derived.score"
+        controller._checkpoint_is_current = mock.MagicMock(return_value=False)
+        controller.core_context.train.get_experiment_best_validation = mock.MagicMock(
+            return_value=sys.maxsize
+        )
+        controller._checkpoint = mock.MagicMock()
+        metrics = controller._validate()
+        assert "derived.score" not in metrics
+        controller._checkpoint.assert_called_once()
+
     @mock.patch.object(det.core.DummyTrainContext, "report_progress")
     def test_searcher_progress_reporting(self, mock_report_progress: mock.MagicMock):
         trial, controller = pytorch_utils.create_trial_and_trial_controller(
@@ -138,6 +138,10 @@ func registerConfig() {
 		defaults.Telemetry.OtelEnabled, "enable otel")
 	registerString(flags, name("telemetry", "otel-endpoint"),
 		defaults.Telemetry.OtelExportedOtlpEndpoint, "set otel endpoint")
+	registerBool(flags, name("telemetry", "otel-trial-metrics-enabled"),
+		defaults.Telemetry.OtelTrialMetricsEnabled, "export trial metrics over otel")
+	registerInt(flags, name("telemetry", "otel-trial-metrics-queue-size"),
+		defaults.Telemetry.OtelTrialMetricsQueueSize, "max trial metrics reports waiting for otel export")
 
 	registerString(flags, name("security", "initial-user-password"),
 		defaults.Security.InitialUserPassword, "initial password for the built-in 'determined' and 'admin' users")
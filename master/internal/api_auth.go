@@ -20,6 +20,8 @@ import (
 	"github.com/determined-ai/determined/master/internal/db"
 	expauth "github.com/determined-ai/determined/master/internal/experiment"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/proxy"
+	"github.com/determined-ai/determined/master/internal/sharelink"
 	"github.com/determined-ai/determined/master/internal/user"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
@@ -114,41 +116,83 @@ func redirectToLogin(c echo.Context) error {
 func processProxyAuthentication(c echo.Context) (done bool, err error) {
 	taskID := model.TaskID(strings.SplitN(c.Param("service"), ":", 2)[0])
 
+	var ctx context.Context
+
+	if c.Request() == nil || c.Request().Context() == nil {
+		ctx = context.TODO()
+	} else {
+		ctx = c.Request().Context()
+	}
+
+	usr, err := proxyRequestUser(c, taskID)
+	if err != nil {
+		return true, err
+	}
+	shareToken := shareLinkTokenFromRequest(c.Request())
+	var authErr error
+	if usr != nil {
+		// Users with access to the task go through as themselves, even with a share link.
+		if authErr = canAccessProxiedTask(ctx, *usr, taskID); authErr == nil {
+			proxy.SetRequestUser(c, usr.Username)
+			if shareToken != "" {
+				stripShareLinkToken(c.Request())
+			}
+			return false, nil
+		}
+	}
+
+	// Share links stand in for the user's access to the task.
+	if shareToken != "" {
+		done, err := processShareLinkAuthentication(c, taskID, shareToken, usr)
+		if !errors.Is(err, sharelink.ErrInvalidToken) {
+			return done, err
+		}
+		// A link that was followed must be valid, but a cookie left from an earlier visit only
+		// falls back to the user's own access.
+		if c.Request().URL.Query().Has(sharelink.TokenParam) {
+			return true, echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		clearShareLinkCookie(c)
+	}
+
+	if usr == nil {
+		return true, redirectToLogin(c)
+	}
+	return true, authErr
+}
+
+// proxyRequestUser returns the user a proxied request is authenticated as, or nil if it is not
+// authenticated as an active user.
+func proxyRequestUser(c echo.Context, taskID model.TaskID) (*model.User, error) {
 	// Notebooks require special auth token passed as a URL parameter.
 	token := extractNotebookTokenFromRequest(c.Request())
 	var usr *model.User
 	var notebookSession *model.NotebookSession
+	var err error
 
 	if token != "" {
 		// Notebooks go through special token param auth.
 		usr, notebookSession, err = user.GetService().UserAndNotebookSessionFromToken(token)
-		if err != nil {
-			return true, err
-		}
-		if notebookSession.TaskID != taskID {
-			return true, fmt.Errorf("invalid notebook session token for task (%v)", taskID)
+		if err == nil && notebookSession.TaskID != taskID {
+			return nil, fmt.Errorf("invalid notebook session token for task (%v)", taskID)
 		}
 	} else {
 		usr, _, err = user.GetService().UserAndSessionFromRequest(c.Request())
 	}
 
 	if errors.Is(err, db.ErrNotFound) {
-		return true, redirectToLogin(c)
+		return nil, nil
 	} else if err != nil {
-		return true, err
+		return nil, err
 	}
 	if !usr.Active {
-		return true, redirectToLogin(c)
-	}
-
-	var ctx context.Context
-
-	if c.Request() == nil || c.Request().Context() == nil {
-		ctx = context.TODO()
-	} else {
-		ctx = c.Request().Context()
+		return nil, nil
 	}
+	return usr, nil
+}
 
+// canAccessProxiedTask checks that the user may access the services of a task.
+func canAccessProxiedTask(ctx context.Context, usr model.User, taskID model.TaskID) error {
 	serviceNotFoundErr := api.NotFoundErrs("service", fmt.Sprint(taskID), false)
 
 	spec, err := command.IdentifyTask(ctx, taskID)
@@ -156,30 +200,30 @@ func processProxyAuthentication(c echo.Context) (done bool, err error) {
 		// Check if it's an experiment.
 		e, err := db.ExperimentByTaskID(ctx, taskID)
 		if errors.Is(err, db.ErrNotFound) || errors.Cause(err) == sql.ErrNoRows {
-			return true, err
+			return err
 		}
 
 		if err != nil {
-			return true, fmt.Errorf("error looking up task experiment: %w", err)
+			return fmt.Errorf("error looking up task experiment: %w", err)
 		}
 
-		err = expauth.AuthZProvider.Get().CanGetExperiment(ctx, *usr, e)
-		return err != nil, authz.SubIfUnauthorized(err, serviceNotFoundErr)
+		err = expauth.AuthZProvider.Get().CanGetExperiment(ctx, usr, e)
+		return authz.SubIfUnauthorized(err, serviceNotFoundErr)
 	}
 
 	if err != nil {
-		return true, fmt.Errorf("error fetching task metadata: %w", err)
+		return fmt.Errorf("error fetching task metadata: %w", err)
 	}
 
 	// Continue NTSC task checks.
 	if spec.TaskType == model.TaskTypeTensorboard {
 		err = command.AuthZProvider.Get().CanGetTensorboard(
-			ctx, *usr, spec.WorkspaceID, spec.ExperimentIDs, spec.TrialIDs)
+			ctx, usr, spec.WorkspaceID, spec.ExperimentIDs, spec.TrialIDs)
 	} else {
 		err = command.AuthZProvider.Get().CanGetNSC(
-			ctx, *usr, spec.WorkspaceID)
+			ctx, usr, spec.WorkspaceID)
 	}
-	return err != nil, authz.SubIfUnauthorized(err, serviceNotFoundErr)
+	return authz.SubIfUnauthorized(err, serviceNotFoundErr)
 }
 
 // extractNotebookTokenFromRequest looks for auth token for Jupyter notebooks
@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/checkpoints"
+	"github.com/determined-ai/determined/master/internal/cluster"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/workspace"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
+)
+
+func (a *apiServer) PostCheckpointRetentionPolicy(
+	ctx context.Context, req *apiv1.PostCheckpointRetentionPolicyRequest,
+) (*apiv1.PostCheckpointRetentionPolicyResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p := &checkpoints.RetentionPolicy{
+		MetricName:      req.MetricName,
+		SmallerIsBetter: req.SmallerIsBetter,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		CreatedBy:       &curUser.ID,
+	}
+	if req.WorkspaceId != nil {
+		p.WorkspaceID = ptrs.Ptr(int(*req.WorkspaceId))
+	}
+	if req.MaxAgeDays != nil {
+		p.MaxAgeDays = ptrs.Ptr(int(*req.MaxAgeDays))
+	}
+	if req.KeepBestPerProject != nil {
+		p.KeepBestPerProject = ptrs.Ptr(int(*req.KeepBestPerProject))
+	}
+	if err := p.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := a.canChangeRetentionPolicies(ctx, curUser, p.WorkspaceID); err != nil {
+		return nil, err
+	}
+
+	if err := checkpoints.AddRetentionPolicy(ctx, p); err != nil {
+		return nil, err
+	}
+	return &apiv1.PostCheckpointRetentionPolicyResponse{Policy: p.Proto()}, nil
+}
+
+func (a *apiServer) GetCheckpointRetentionPolicies(
+	ctx context.Context, req *apiv1.GetCheckpointRetentionPoliciesRequest,
+) (*apiv1.GetCheckpointRetentionPoliciesResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var workspaceID *int
+	if req.WorkspaceId != nil {
+		if _, err := a.GetWorkspaceByID(ctx, *req.WorkspaceId, *curUser, false); err != nil {
+			return nil, err
+		}
+		workspaceID = ptrs.Ptr(int(*req.WorkspaceId))
+	}
+	policies, err := checkpoints.RetentionPolicies(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	canView := map[int]bool{}
+	resp := &apiv1.GetCheckpointRetentionPoliciesResponse{Policies: []*checkpointv1.RetentionPolicy{}}
+	for _, p := range policies {
+		if p.WorkspaceID != nil {
+			ok, seen := canView[*p.WorkspaceID]
+			if !seen {
+				_, err := a.GetWorkspaceByID(ctx, int32(*p.WorkspaceID), *curUser, false)
+				ok = err == nil
+				canView[*p.WorkspaceID] = ok
+			}
+			if !ok {
+				continue
+			}
+		}
+		resp.Policies = append(resp.Policies, p.Proto())
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PatchCheckpointRetentionPolicy(
+	ctx context.Context, req *apiv1.PatchCheckpointRetentionPolicyRequest,
+) (*apiv1.PatchCheckpointRetentionPolicyResponse, error) {
+	p, err := a.getRetentionPolicyAndCheckCanChange(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	p, err = checkpoints.SetRetentionPolicyEnabled(ctx, p.ID, req.Enabled)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, api.NotFoundErrs("checkpoint retention policy", strconv.Itoa(int(req.Id)), true)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error updating checkpoint retention policy %d", req.Id)
+	}
+	return &apiv1.PatchCheckpointRetentionPolicyResponse{Policy: p.Proto()}, nil
+}
+
+func (a *apiServer) DeleteCheckpointRetentionPolicy(
+	ctx context.Context, req *apiv1.DeleteCheckpointRetentionPolicyRequest,
+) (*apiv1.DeleteCheckpointRetentionPolicyResponse, error) {
+	p, err := a.getRetentionPolicyAndCheckCanChange(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkpoints.DeleteRetentionPolicy(ctx, p.ID); errors.Is(err, db.ErrNotFound) {
+		return nil, api.NotFoundErrs("checkpoint retention policy", strconv.Itoa(int(req.Id)), true)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error deleting checkpoint retention policy %d", req.Id)
+	}
+	return &apiv1.DeleteCheckpointRetentionPolicyResponse{}, nil
+}
+
+func (a *apiServer) PreviewCheckpointRetentionPolicy(
+	ctx context.Context, req *apiv1.PreviewCheckpointRetentionPolicyRequest,
+) (*apiv1.PreviewCheckpointRetentionPolicyResponse, error) {
+	// Cluster policies span every workspace, so previewing takes the same permissions as changing.
+	p, err := a.getRetentionPolicyAndCheckCanChange(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := checkpoints.RetentionCandidates(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &apiv1.PreviewCheckpointRetentionPolicyResponse{
+		Candidates: []*checkpointv1.RetentionCandidate{},
+	}
+	for _, c := range candidates {
+		resp.Candidates = append(resp.Candidates, c.Proto())
+		resp.TotalSize += c.Size
+	}
+	return resp, nil
+}
+
+func (a *apiServer) getRetentionPolicyAndCheckCanChange(
+	ctx context.Context, id int,
+) (*checkpoints.RetentionPolicy, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	notFoundErr := api.NotFoundErrs("checkpoint retention policy", strconv.Itoa(id), true)
+	p, err := checkpoints.RetentionPolicyByID(ctx, id)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, notFoundErr
+	} else if err != nil {
+		return nil, err
+	}
+	if p.WorkspaceID != nil {
+		if _, err := a.GetWorkspaceByID(ctx, int32(*p.WorkspaceID), *curUser, false); err != nil {
+			return nil, notFoundErr
+		}
+	}
+	if err := a.canChangeRetentionPolicies(ctx, curUser, p.WorkspaceID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// canChangeRetentionPolicies returns an error unless the user can change the checkpoint storage of
+// a workspace, or the master config for cluster policies.
+func (a *apiServer) canChangeRetentionPolicies(
+	ctx context.Context, curUser *model.User, workspaceID *int,
+) error {
+	if workspaceID == nil {
+		permErr, err := cluster.AuthZProvider.Get().CanUpdateMasterConfig(ctx, curUser)
+		if err != nil {
+			return err
+		}
+		return permErr
+	}
+	_, _, err := a.getWorkspaceAndCheckCanDoActions(ctx, int32(*workspaceID), false,
+		workspace.AuthZProvider.Get().CanSetWorkspacesCheckpointStorageConfig)
+	return err
+}
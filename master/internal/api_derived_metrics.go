@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/derivedmetrics"
+	"github.com/determined-ai/determined/master/internal/project"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/projectv1"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+func (a *apiServer) PostProjectDerivedMetric(
+	ctx context.Context, req *apiv1.PostProjectDerivedMetricRequest,
+) (*apiv1.PostProjectDerivedMetricResponse, error) {
+	_, _, err := a.getProjectAndCheckCanDoActions(ctx, req.ProjectId,
+		project.AuthZProvider.Get().CanSetProjectNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := derivedmetrics.Add(ctx, int(req.ProjectId), req.Name, req.Expression)
+	switch {
+	case errors.Is(err, db.ErrInvalidInput):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, db.ErrDuplicateRecord):
+		return nil, status.Errorf(codes.AlreadyExists,
+			"derived metric %s already exists in project %d", req.Name, req.ProjectId)
+	case err != nil:
+		return nil, errors.Wrapf(err, "error adding derived metric %s", req.Name)
+	}
+	return &apiv1.PostProjectDerivedMetricResponse{DerivedMetric: d.Proto()}, nil
+}
+
+func (a *apiServer) GetProjectDerivedMetrics(
+	ctx context.Context, req *apiv1.GetProjectDerivedMetricsRequest,
+) (*apiv1.GetProjectDerivedMetricsResponse, error) {
+	if _, _, err := a.getProjectAndCheckCanDoActions(ctx, req.ProjectId); err != nil {
+		return nil, err
+	}
+
+	ds, err := derivedmetrics.List(ctx, int(req.ProjectId))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting derived metrics of project %d", req.ProjectId)
+	}
+	resp := &apiv1.GetProjectDerivedMetricsResponse{
+		DerivedMetrics: make([]*projectv1.DerivedMetric, 0, len(ds)),
+	}
+	for _, d := range ds {
+		resp.DerivedMetrics = append(resp.DerivedMetrics, d.Proto())
+	}
+	return resp, nil
+}
+
+func (a *apiServer) DeleteProjectDerivedMetric(
+	ctx context.Context, req *apiv1.DeleteProjectDerivedMetricRequest,
+) (*apiv1.DeleteProjectDerivedMetricResponse, error) {
+	_, _, err := a.getProjectAndCheckCanDoActions(ctx, req.ProjectId,
+		project.AuthZProvider.Get().CanSetProjectNotes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = derivedmetrics.Delete(ctx, int(req.ProjectId), req.Name)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return nil, api.NotFoundErrs("derived metric", req.Name, true)
+	case err != nil:
+		return nil, errors.Wrapf(err, "error deleting derived metric %s", req.Name)
+	}
+	return &apiv1.DeleteProjectDerivedMetricResponse{}, nil
+}
+
+// getDerivedMetricColumns returns the columns of the derived metrics of a project.
+func getDerivedMetricColumns(ctx context.Context, projectID int32) ([]*projectv1.ProjectColumn, error) {
+	ds, err := derivedmetrics.List(ctx, int(projectID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting derived metrics of project %d", projectID)
+	}
+	columns := make([]*projectv1.ProjectColumn, 0, len(ds))
+	for _, d := range ds {
+		columns = append(columns, &projectv1.ProjectColumn{
+			Column:      d.Column(),
+			DisplayName: d.Name,
+			Location:    projectv1.LocationType_LOCATION_TYPE_DERIVED_METRIC,
+			Type:        projectv1.ColumnType_COLUMN_TYPE_NUMBER,
+		})
+	}
+	return columns, nil
+}
+
+// searchDerivedMetrics returns the derived metrics usable in a search, which are those of the
+// searched project, if any.
+func searchDerivedMetrics(ctx context.Context, projectID *int32) (derivedmetrics.Set, error) {
+	if projectID == nil {
+		return nil, nil
+	}
+	s, err := derivedmetrics.ByProject(ctx, int(*projectID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting derived metrics of project %d", *projectID)
+	}
+	return s, nil
+}
+
+// evalDerivedMetrics evaluates derived metrics against the summary metrics and hyperparameters of
+// a run, returning nil if there are no derived metrics.
+func evalDerivedMetrics(
+	derived derivedmetrics.Set, summaryMetrics, hparams *structpb.Struct,
+) (*structpb.Struct, error) {
+	if len(derived) == 0 {
+		return nil, nil
+	}
+	values := derived.Eval(derivedmetrics.SummaryValues{
+		SummaryMetrics: summaryMetrics.AsMap(),
+		Hparams:        hparams.AsMap(),
+	})
+	res, err := structpb.NewStruct(values)
+	if err != nil {
+		return nil, fmt.Errorf("converting derived metrics: %w", err)
+	}
+	return res, nil
+}
+
+// addDerivedSearcherMetric adds the value of the searcher metric to reported validation metrics
+// if the experiment of the trial uses a derived metric as its searcher metric. The value is left
+// out if it is undefined for the reported metrics, as for a missing searcher metric.
+func addDerivedSearcherMetric(ctx context.Context, metrics *trialv1.TrialMetrics) error {
+	avgMetrics := metrics.GetMetrics().GetAvgMetrics()
+	if avgMetrics == nil {
+		return nil
+	}
+	sm, err := derivedmetrics.SearcherMetricOfTrial(ctx, int(metrics.TrialId))
+	if err != nil {
+		return errors.Wrapf(err, "error getting searcher metric of trial %d", metrics.TrialId)
+	}
+	if sm == nil {
+		return nil
+	}
+	if v, ok := sm.Eval(avgMetrics.AsMap()); ok {
+		if avgMetrics.Fields == nil {
+			avgMetrics.Fields = map[string]*structpb.Value{}
+		}
+		avgMetrics.Fields[derivedmetrics.ColumnPrefix+sm.Name] = structpb.NewNumberValue(v)
+	}
+	return nil
+}
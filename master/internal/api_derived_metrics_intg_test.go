@@ -0,0 +1,122 @@
+//go:build integration
+// +build integration
+
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/commonv1"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+	"github.com/determined-ai/determined/proto/pkg/utilv1"
+)
+
+func TestDerivedSearcherMetric(t *testing.T) {
+	mockRM := MockRM()
+	api, curUser, ctx := setupAPITest(t, nil, mockRM)
+	mockRM.On("SmallerValueIsHigherPriority", mock.Anything).Return(true, nil)
+	_, projectID := createProjectAndWorkspace(ctx, t, api)
+
+	_, err := api.PostProjectDerivedMetric(ctx, &apiv1.PostProjectDerivedMetricRequest{
+		ProjectId:  int32(projectID),
+		Name:       "score",
+		Expression: "validation.accuracy - 0.5 * validation.latency_ms",
+	})
+	require.NoError(t, err)
+
+	createReq := func(metric string) *apiv1.CreateExperimentRequest {
+		return &apiv1.CreateExperimentRequest{
+			ModelDefinition: []*utilv1.File{{Content: []byte{1}}},
+			Config: `
+entrypoint: test
+checkpoint_storage:
+  type: shared_fs
+  host_path: /tmp
+searcher:
+  name: single
+  metric: ` + metric + `
+  smaller_is_better: false
+resources:
+  resource_pool: kubernetes`,
+			ProjectId:    int32(projectID),
+			ValidateOnly: true,
+		}
+	}
+
+	// Only derived metrics of the project of the experiment can be searcher metrics.
+	_, err = api.CreateExperiment(ctx, createReq("derived.score"))
+	require.NoError(t, err)
+	_, err = api.CreateExperiment(ctx, createReq("derived.missing"))
+	require.ErrorIs(t, err, db.ErrInvalidInput)
+	otherReq := createReq("derived.score")
+	otherReq.ProjectId = 1
+	_, err = api.CreateExperiment(ctx, otherReq)
+	require.ErrorIs(t, err, db.ErrInvalidInput)
+
+	// The master adds the derived searcher metric to the validation metrics trials report.
+	activeConfig := schemas.WithDefaults(schemas.Merge(expconf.ExperimentConfig{
+		RawSearcher: &expconf.SearcherConfig{
+			RawMetric:          ptrs.Ptr("derived.score"),
+			RawSmallerIsBetter: ptrs.Ptr(false),
+			RawSingleConfig: &expconf.SingleConfig{
+				RawMaxLength: &expconf.Length{Units: 10, Unit: "batches"},
+			},
+		},
+	}, minExpConfig))
+	exp := createTestExpWithActiveConfig(t, api, curUser, projectID, activeConfig)
+
+	requestID := model.NewRequestID(rand.Reader)
+	task := &model.Task{
+		TaskType:   model.TaskTypeTrial,
+		LogVersion: model.TaskLogVersion1,
+		StartTime:  time.Now(),
+		TaskID:     trialTaskID(exp.ID, requestID),
+	}
+	require.NoError(t, db.AddTask(context.TODO(), task))
+	trial := &model.Trial{
+		StartTime:    time.Now(),
+		RequestID:    &requestID,
+		State:        model.PausedState,
+		ExperimentID: exp.ID,
+	}
+	require.NoError(t, db.AddTrial(context.TODO(), trial, task.TaskID))
+
+	metrics, err := structpb.NewStruct(map[string]any{"accuracy": 0.9, "latency_ms": 0.2})
+	require.NoError(t, err)
+	_, err = api.ReportTrialMetrics(ctx, &apiv1.ReportTrialMetricsRequest{
+		Metrics: &trialv1.TrialMetrics{
+			TrialId:        int32(trial.ID),
+			StepsCompleted: 10,
+			Metrics:        &commonv1.Metrics{AvgMetrics: metrics},
+		},
+		Group: model.ValidationMetricGroup.ToString(),
+	})
+	require.NoError(t, err)
+
+	var score float64
+	require.NoError(t, db.Bun().NewSelect().Table("runs").
+		ColumnExpr("(summary_metrics->'validation_metrics'->'derived.score'->>'last')::float8").
+		Where("id = ?", trial.ID).
+		Scan(ctx, &score))
+	require.InDelta(t, 0.8, score, 1e-9)
+
+	var searcherMetric float64
+	require.NoError(t, db.Bun().NewSelect().Table("runs").
+		ColumnExpr("searcher_metric_value").
+		Where("id = ?", trial.ID).
+		Scan(ctx, &searcherMetric))
+	require.InDelta(t, 0.8, searcherMetric, 1e-9)
+}
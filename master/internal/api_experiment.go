@@ -33,6 +33,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/configpolicy"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/db/bunutils"
+	"github.com/determined-ai/determined/master/internal/derivedmetrics"
 	"github.com/determined-ai/determined/master/internal/experiment"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/job/jobservice"
@@ -2099,7 +2100,7 @@ func (a *apiServer) topTrials(
 
 func (a *apiServer) fetchTrialSample(trialID int32, metricName string, metricGroup model.MetricGroup,
 	maxDatapoints int, startBatches int, endBatches int, currentTrials map[int32]bool,
-	trialCursors map[int32]time.Time,
+	trialCursors map[int32]time.Time, method apiv1.DownsampleMethod,
 ) (*apiv1.TrialsSampleResponse_Trial, error) {
 	var endTime time.Time
 	var zeroTime time.Time
@@ -2122,10 +2123,26 @@ func (a *apiServer) fetchTrialSample(trialID int32, metricName string, metricGro
 	if !seenBefore {
 		startTime = zeroTime
 	}
+
+	if method == apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_BUCKETS {
+		if !seenBefore {
+			buckets, err := trials.MetricsBuckets(trialID, []string{metricName},
+				startBatches, endBatches, maxDatapoints, "batches", nil, metricGroup)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error aggregating time series of metrics")
+			}
+			if len(buckets) > 0 {
+				trial.Data = trials.BucketDataPoints(buckets, trialID, metricGroup)
+				trialCursors[trialID] = endTime
+			}
+		}
+		return &trial, nil
+	}
+
 	metricMeasurements, err = trials.MetricsTimeSeries(trialID, startTime,
 		[]string{metricName},
 		startBatches, endBatches, maxDatapoints,
-		"batches", nil, metricGroup)
+		"batches", nil, metricGroup, method)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error fetching time series of metrics")
 	}
@@ -2226,7 +2243,7 @@ func (a *apiServer) TrialsSample(req *apiv1.TrialsSampleRequest,
 		for _, trialID := range trialIDs {
 			var trial *apiv1.TrialsSampleResponse_Trial
 			trial, err = a.fetchTrialSample(trialID, metricName, metricGroup, maxDatapoints,
-				startBatches, endBatches, currentTrials, trialCursors)
+				startBatches, endBatches, currentTrials, trialCursors, req.DownsampleMethod)
 			if err != nil {
 				return err
 			}
@@ -2297,6 +2314,42 @@ func (a *apiServer) GetBestSearcherValidationMetric(
 	}, nil
 }
 
+func (a *apiServer) GetExperimentMetricsAggregate(
+	ctx context.Context, req *apiv1.GetExperimentMetricsAggregateRequest,
+) (*apiv1.GetExperimentMetricsAggregateResponse, error) {
+	if req.MetricName == "" {
+		return nil, status.Error(codes.InvalidArgument, "must specify a metric name")
+	}
+	if req.Group == "" {
+		return nil, status.Error(codes.InvalidArgument, "must specify a metric group")
+	}
+	percentiles := req.Percentiles
+	if len(percentiles) == 0 {
+		percentiles = trials.DefaultAggregatePercentiles
+	}
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"percentile %v is not between 0 and 100", p)
+		}
+	}
+
+	if _, _, err := a.getExperimentAndCheckCanDoActions(ctx, int(req.ExperimentId),
+		experiment.AuthZProvider.Get().CanGetExperimentArtifacts); err != nil {
+		return nil, err
+	}
+
+	series, err := trials.ExperimentMetricsAggregate(ctx, req.ExperimentId, req.MetricName,
+		model.MetricGroup(req.Group), req.AlignBy, percentiles, req.GroupByHparam)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.GetExperimentMetricsAggregateResponse{
+		Series:      series,
+		Percentiles: percentiles,
+	}, nil
+}
+
 func (a *apiServer) GetModelDef(
 	ctx context.Context, req *apiv1.GetModelDefRequest,
 ) (*apiv1.GetModelDefResponse, error) {
@@ -2461,7 +2514,9 @@ func (a *apiServer) GetModelDefFile(
 	return &apiv1.GetModelDefFileResponse{File: file}, nil
 }
 
-func sortExperiments(sortString *string, experimentQuery *bun.SelectQuery) error {
+func sortExperiments(
+	sortString *string, experimentQuery *bun.SelectQuery, derived derivedmetrics.Set,
+) error {
 	if sortString == nil {
 		return nil
 	}
@@ -2515,6 +2570,13 @@ func sortExperiments(sortString *string, experimentQuery *bun.SelectQuery) error
 			hps := strings.ReplaceAll(strings.TrimPrefix(param, "hp."), ".", "'->'")
 			experimentQuery.OrderExpr(
 				fmt.Sprintf("e.config->'hyperparameters'->'%s' %s", hps, sortDirection))
+		case strings.HasPrefix(paramDetail[0], derivedmetrics.ColumnPrefix):
+			expr, err := derived.Column(paramDetail[0])
+			if err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			exprSQL, queryArgs := expr.SQL()
+			experimentQuery.OrderExpr(exprSQL+" ?", append(queryArgs, bun.Safe(sortDirection))...)
 		case strings.Contains(paramDetail[0], "."):
 			metricGroup, metricName, metricQualifier, err := parseMetricsName(paramDetail[0])
 			if err != nil {
@@ -2571,12 +2633,18 @@ func (a *apiServer) SearchExperiments(
 		return nil, err
 	}
 
+	derived, err := searchDerivedMetrics(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.Filter != nil {
 		var efr experimentFilterRoot
 		err := json.Unmarshal([]byte(*req.Filter), &efr)
 		if err != nil {
 			return nil, err
 		}
+		efr.derived = derived
 		experimentQuery = experimentQuery.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
 			_, err = efr.toSQL(q)
 			return q
@@ -2592,7 +2660,7 @@ func (a *apiServer) SearchExperiments(
 	}
 
 	if req.Sort != nil {
-		err = sortExperiments(req.Sort, experimentQuery)
+		err = sortExperiments(req.Sort, experimentQuery, derived)
 		if err != nil {
 			return nil, err
 		}
@@ -2707,9 +2775,19 @@ func (a *apiServer) SearchExperiments(
 			}
 		}
 
+		var derivedMetrics *structpb.Struct
+		if trial != nil {
+			if derivedMetrics, err = evalDerivedMetrics(derived, trial.SummaryMetrics, trial.Hparams); err != nil {
+				return nil, err
+			}
+		}
 		resp.Experiments = append(
 			resp.Experiments,
-			&apiv1.SearchExperimentExperiment{Experiment: experiment, BestTrial: trial},
+			&apiv1.SearchExperimentExperiment{
+				Experiment:     experiment,
+				BestTrial:      trial,
+				DerivedMetrics: derivedMetrics,
+			},
 		)
 	}
 
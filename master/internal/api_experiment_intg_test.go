@@ -1789,6 +1789,15 @@ func TestAuthZGetExperimentAndCanDoActions(t *testing.T) {
 				&apiv1.GetBestSearcherValidationMetricRequest{ExperimentId: int32(id)})
 			return err
 		}},
+		{"CanGetExperimentArtifacts", func(id int) error {
+			_, err := api.GetExperimentMetricsAggregate(ctx,
+				&apiv1.GetExperimentMetricsAggregateRequest{
+					ExperimentId: int32(id),
+					MetricName:   "loss",
+					Group:        model.TrainingMetricGroup.ToString(),
+				})
+			return err
+		}},
 		{"CanGetExperimentArtifacts", func(id int) error {
 			_, err := api.GetModelDef(ctx, &apiv1.GetModelDefRequest{
 				ExperimentId: int32(id),
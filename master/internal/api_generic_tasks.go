@@ -27,6 +27,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/task"
 	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/internal/workspace"
 	"github.com/determined-ai/determined/master/pkg/check"
 	pkgCommand "github.com/determined-ai/determined/master/pkg/command"
 	"github.com/determined-ai/determined/master/pkg/logger"
@@ -214,6 +215,14 @@ func (a *apiServer) canCreateGenericTask(ctx context.Context, projectID int) err
 
 func (a *apiServer) CreateGenericTask(
 	ctx context.Context, req *apiv1.CreateGenericTaskRequest,
+) (*apiv1.CreateGenericTaskResponse, error) {
+	return a.createGenericTask(ctx, req, nil)
+}
+
+// createGenericTask creates a generic task with extra environment variables on top of those
+// of its config.
+func (a *apiServer) createGenericTask(
+	ctx context.Context, req *apiv1.CreateGenericTaskRequest, extraEnvVars map[string]string,
 ) (*apiv1.CreateGenericTaskResponse, error) {
 	var projectID int
 	if req.ProjectId != nil {
@@ -276,6 +285,9 @@ func (a *apiServer) CreateGenericTask(
 	if len(contextDirectoryBytes) == 0 {
 		contextDirectoryBytes = forkedContextDirectory
 	}
+	for k, v := range extraEnvVars {
+		genericTaskSpec.Base.ExtraEnvVars[k] = v
+	}
 
 	if err := check.Validate(genericTaskSpec.GenericTaskConfig); err != nil {
 		return nil, status.Errorf(
@@ -352,6 +364,10 @@ func (a *apiServer) CreateGenericTask(
 	onAllocationExit := getGenericTaskOnAllocationExit(ctx, taskID, jobID, logCtx)
 
 	allocationID := model.AllocationID(fmt.Sprintf("%s.%d", taskID, 1))
+	workspaceName, err := genericTaskWorkspaceName(ctx, genericTaskSpec)
+	if err != nil {
+		return nil, err
+	}
 	isSingleNode := genericTaskSpec.GenericTaskConfig.Resources.IsSingleNode() != nil &&
 		*genericTaskSpec.GenericTaskConfig.Resources.IsSingleNode()
 	err = task.DefaultService.StartAllocation(logCtx, sproto.AllocateRequest{
@@ -361,12 +377,16 @@ func (a *apiServer) CreateGenericTask(
 		JobSubmissionTime: startTime,
 		IsUserVisible:     true,
 		Name:              fmt.Sprintf("Generic Task %s", taskID),
+		Workspace:         workspaceName,
+		Username:          genericTaskSpec.Base.Owner.Username,
 
 		SlotsNeeded:  *genericTaskSpec.GenericTaskConfig.Resources.Slots(),
 		ResourcePool: genericTaskSpec.GenericTaskConfig.Resources.ResourcePool(),
 		FittingRequirements: sproto.FittingRequirements{
 			SingleAgent: isSingleNode,
 		},
+		CapacityType: model.CapacityTypeOf(genericTaskSpec.GenericTaskConfig.Resources.CapacityType()),
+		Watchdog:     sproto.NewWatchdogConfig(genericTaskSpec.GenericTaskConfig.Watchdog),
 
 		Restore: false,
 	}, a.m.db, a.m.rm, genericTaskSpec, onAllocationExit)
@@ -654,6 +674,10 @@ func (a *apiServer) UnpauseGenericTask(
 			return nil, err
 		}
 		resumingAllocationID := model.AllocationID(fmt.Sprintf("%s.%d", resumingTask.TaskID, allocationSpecifier+1))
+		workspaceName, err := genericTaskWorkspaceName(ctx, genericTaskSpec)
+		if err != nil {
+			return nil, err
+		}
 		isSingleNode := genericTaskSpec.GenericTaskConfig.Resources.IsSingleNode() != nil &&
 			*genericTaskSpec.GenericTaskConfig.Resources.IsSingleNode()
 		err = task.DefaultService.StartAllocation(
@@ -665,16 +689,21 @@ func (a *apiServer) UnpauseGenericTask(
 				RequestTime:       time.Now().UTC(),
 				IsUserVisible:     true,
 				Name:              fmt.Sprintf("Generic Task %s", resumingTask.TaskID),
+				Workspace:         workspaceName,
+				Username:          genericTaskSpec.Base.Owner.Username,
 				SlotsNeeded:       *genericTaskSpec.GenericTaskConfig.Resources.Slots(),
 				ResourcePool:      genericTaskSpec.GenericTaskConfig.Resources.ResourcePool(),
 				FittingRequirements: sproto.FittingRequirements{
 					SingleAgent: isSingleNode,
 				},
+				CapacityType: model.CapacityTypeOf(
+					genericTaskSpec.GenericTaskConfig.Resources.CapacityType()),
 				Preemption: sproto.PreemptionConfig{
 					Preemptible:     true,
 					TimeoutDuration: time.Duration(genericTaskSpec.GenericTaskConfig.PreemptionTimeout) * time.Second,
 				},
-				Restore: false,
+				Watchdog: sproto.NewWatchdogConfig(genericTaskSpec.GenericTaskConfig.Watchdog),
+				Restore:  false,
 			}, a.m.db, a.m.rm, genericTaskSpec, onAllocationExit)
 		if err != nil {
 			return nil, err
@@ -732,6 +761,15 @@ func persistGenericTaskSpec(ctx context.Context,
 	return err
 }
 
+// genericTaskWorkspaceName returns the name of the workspace a generic task runs in.
+func genericTaskWorkspaceName(ctx context.Context, spec *tasks.GenericTaskSpec) (string, error) {
+	w, err := workspace.WorkspaceByProjectID(ctx, spec.ProjectID)
+	if err != nil {
+		return "", fmt.Errorf("getting the workspace of project %d: %w", spec.ProjectID, err)
+	}
+	return w.Name, nil
+}
+
 func getGenericTaskSpec(ctx context.Context, taskID model.TaskID,
 ) (string, *tasks.GenericTaskSpec, error) {
 	snapshot := command.CommandSnapshot{}
@@ -13,6 +13,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/internal/api"
@@ -31,6 +32,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
 	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas"
 	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
 	"github.com/determined-ai/determined/master/pkg/tasks"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
@@ -46,8 +48,11 @@ const (
 	jupyterRuntimeDir = "/run/determined/jupyter/runtime"
 	jupyterEntrypoint = "/run/determined/jupyter/notebook-entrypoint.sh"
 	jupyterIdleCheck  = "/run/determined/jupyter/check_idle.py"
-	jupyterCertPath   = "/run/determined/jupyter/jupyterCert.pem"
-	jupyterKeyPath    = "/run/determined/jupyter/jupyterKey.key"
+	jupyterSnapshot   = "/run/determined/jupyter/notebook_snapshot.py"
+	// jupyterSnapshotStorage is the checkpoint storage config of suspendable notebooks.
+	jupyterSnapshotStorage = "/run/determined/jupyter/snapshot_storage.json"
+	jupyterCertPath        = "/run/determined/jupyter/jupyterCert.pem"
+	jupyterKeyPath         = "/run/determined/jupyter/jupyterKey.key"
 	// Agent ports 2600 - 3500 are split between TensorBoards, Notebooks, and Shells.
 	minNotebookPort     = 2900
 	maxNotebookPort     = minNotebookPort + 299
@@ -142,6 +147,81 @@ func (a *apiServer) IdleNotebook(
 	return &apiv1.IdleNotebookResponse{}, nil
 }
 
+func (a *apiServer) SuspendNotebook(
+	ctx context.Context, req *apiv1.SuspendNotebookRequest,
+) (*apiv1.SuspendNotebookResponse, error) {
+	if err := a.validateToKillNotebook(ctx, req.NotebookId); err != nil {
+		return nil, err
+	}
+	cmd, err := command.DefaultCmdService.SuspendNotebook(req.NotebookId)
+	if err != nil {
+		return nil, notebookSuspensionError(err)
+	}
+	return &apiv1.SuspendNotebookResponse{Notebook: cmd.ToV1Notebook()}, nil
+}
+
+func (a *apiServer) ResumeNotebook(
+	ctx context.Context, req *apiv1.ResumeNotebookRequest,
+) (*apiv1.ResumeNotebookResponse, error) {
+	if err := a.validateToKillNotebook(ctx, req.NotebookId); err != nil {
+		return nil, err
+	}
+	cmd, err := command.DefaultCmdService.ResumeNotebook(ctx, req.NotebookId)
+	if err != nil {
+		return nil, notebookSuspensionError(err)
+	}
+	return &apiv1.ResumeNotebookResponse{Notebook: cmd.ToV1Notebook()}, nil
+}
+
+func (a *apiServer) ReportNotebookSnapshot(
+	ctx context.Context, req *apiv1.ReportNotebookSnapshotRequest,
+) (*apiv1.ReportNotebookSnapshotResponse, error) {
+	if err := a.validateToKillNotebook(ctx, req.NotebookId); err != nil {
+		return nil, err
+	}
+	if req.StorageId == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing argument: storage_id")
+	}
+	// Snapshots are deleted from checkpoint storage by their ID once they are no longer needed.
+	if _, err := uuid.Parse(req.StorageId); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid storage_id: %s", err)
+	}
+	err := command.DefaultCmdService.ReportNotebookSnapshot(req.NotebookId, req.StorageId)
+	if err != nil {
+		return nil, notebookSuspensionError(err)
+	}
+	return &apiv1.ReportNotebookSnapshotResponse{}, nil
+}
+
+// notebookSuspensionError maps errors from suspending and resuming notebooks to API errors.
+func notebookSuspensionError(err error) error {
+	switch {
+	case errors.Is(err, command.ErrNotSuspendable),
+		errors.Is(err, command.ErrNotRunning),
+		errors.Is(err, command.ErrNotSuspended):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return err
+	}
+}
+
+// notebookSnapshotStorage returns the checkpoint storage that the notebooks of a workspace save
+// their working directories to when they are suspended.
+func (a *apiServer) notebookSnapshotStorage(
+	ctx context.Context, workspaceID int,
+) (expconf.CheckpointStorageConfig, error) {
+	w := &model.Workspace{}
+	if err := db.Bun().NewSelect().Model(w).
+		Where("id = ?", workspaceID).
+		Column("checkpoint_storage_config").
+		Scan(ctx); err != nil {
+		return expconf.CheckpointStorageConfig{}, err
+	}
+	return schemas.WithDefaults(
+		*schemas.Merge(w.CheckpointStorageConfig, &a.m.config.CheckpointStorage),
+	), nil
+}
+
 func (a *apiServer) KillNotebook(
 	ctx context.Context, req *apiv1.KillNotebookRequest,
 ) (resp *apiv1.KillNotebookResponse, err error) {
@@ -314,6 +394,14 @@ func (a *apiServer) LaunchNotebook(
 		return nil, status.Errorf(codes.InvalidArgument, "invalid notebook config: %s", err.Error())
 	}
 
+	suspension := a.m.config.NotebookSuspension
+	if !suspension.Enabled &&
+		launchReq.Spec.Config.NotebookIdleAction == model.NotebookIdleActionSuspend {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"notebook_idle_action %s requires notebook suspension to be enabled on the master",
+			model.NotebookIdleActionSuspend)
+	}
+
 	launchReq.Spec.AdditionalFiles = archive.Archive{
 		launchReq.Spec.Base.AgentUserGroup.OwnedArchiveItem(jupyterDir, nil, 0o700, tar.TypeDir),
 		launchReq.Spec.Base.AgentUserGroup.OwnedArchiveItem(jupyterConfigDir, nil, 0o700, tar.TypeDir),
@@ -357,6 +445,41 @@ func (a *apiServer) LaunchNotebook(
 		),
 	}
 
+	if suspension.Enabled {
+		storage, err := a.notebookSnapshotStorage(ctx, int(launchReq.Spec.Metadata.WorkspaceID))
+		if err != nil {
+			return nil, fmt.Errorf("getting notebook snapshot storage: %w", err)
+		}
+		storageBytes, err := json.Marshal(storage)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling notebook snapshot storage: %w", err)
+		}
+		if fs, ok := storage.GetUnionMember().(expconf.SharedFSConfig); ok {
+			launchReq.Spec.Config.BindMounts = append(launchReq.Spec.Config.BindMounts,
+				model.ToModelBindMount(schemas.WithDefaults(expconf.BindMount{
+					RawContainerPath: expconf.DefaultSharedFSContainerPath,
+					RawHostPath:      fs.HostPath(),
+					RawPropagation:   ptrs.Ptr(expconf.DefaultSharedFSPropagation),
+				})))
+		}
+		launchReq.Spec.SnapshotTimeout = ptrs.Ptr(suspension.Timeout)
+		launchReq.Spec.SnapshotStorage = &storage
+		launchReq.Spec.AdditionalFiles = append(launchReq.Spec.AdditionalFiles,
+			launchReq.Spec.Base.AgentUserGroup.OwnedArchiveItem(
+				jupyterSnapshot,
+				etc.MustStaticFile(etc.NotebookSnapshotResource),
+				0o700,
+				tar.TypeReg,
+			),
+			launchReq.Spec.Base.AgentUserGroup.OwnedArchiveItem(
+				jupyterSnapshotStorage,
+				storageBytes,
+				0o600,
+				tar.TypeReg,
+			),
+		)
+	}
+
 	// Launch a Notebook.
 	genericCmd, err := command.DefaultCmdService.LaunchNotebookCommand(
 		launchReq,
@@ -38,7 +38,7 @@ func setupNTSCAuthzTest(t *testing.T) (
 	api, curUser, ctx := setupAPITest(t, nil)
 	master := api.m
 
-	cs, _ := command.NewService(master.db, master.rm)
+	cs, _ := command.NewService(master.db, master.rm, nil)
 	command.SetDefaultService(cs)
 
 	jobservice.SetDefaultService(master.rm)
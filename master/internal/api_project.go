@@ -676,11 +676,22 @@ func (a *apiServer) GetProjectColumns(
 		return nil, err
 	}
 
+	var resp *apiv1.GetProjectColumnsResponse
 	if req.TableType != nil && *req.TableType == apiv1.TableType_TABLE_TYPE_RUN {
-		return a.getProjectRunColumnsByID(ctx, req.Id, *curUser)
+		resp, err = a.getProjectRunColumnsByID(ctx, req.Id, *curUser)
+	} else {
+		resp, err = a.getProjectColumnsByID(ctx, req.Id, *curUser)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return a.getProjectColumnsByID(ctx, req.Id, *curUser)
+	derivedColumns, err := getDerivedMetricColumns(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	resp.Columns = append(resp.Columns, derivedColumns...)
+	return resp, nil
 }
 
 func (a *apiServer) GetProjectNumericMetricsRange(
@@ -2,11 +2,17 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/authz"
+	"github.com/determined-ai/determined/master/internal/cluster"
 	"github.com/determined-ai/determined/master/internal/config"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
@@ -282,3 +288,89 @@ func combineWorkspaceIDsAndNames(ctx context.Context, ids []int32, names []strin
 
 	return idSet.ToSlice(), nil
 }
+
+func (a *apiServer) CreateResourcePool(
+	ctx context.Context, req *apiv1.CreateResourcePoolRequest,
+) (*apiv1.CreateResourcePoolResponse, error) {
+	poolManager, err := a.resourcePoolManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := protojson.Marshal(req.Config)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid resource pool config: %s", err)
+	}
+	var poolConfig config.ResourcePoolConfig
+	if err := json.Unmarshal(b, &poolConfig); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid resource pool config: %s", err)
+	}
+
+	// Resource pool names are unique across all resource managers.
+	if err := a.m.rm.ValidateResourcePool(rm.ResourcePoolName(poolConfig.PoolName)); err == nil {
+		return nil, status.Errorf(codes.AlreadyExists,
+			"resource pool %s already exists", poolConfig.PoolName)
+	}
+
+	pool, err := poolManager.CreateResourcePool(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.CreateResourcePoolResponse{ResourcePool: pool}, nil
+}
+
+func (a *apiServer) PatchResourcePool(
+	ctx context.Context, req *apiv1.PatchResourcePoolRequest,
+) (*apiv1.PatchResourcePoolResponse, error) {
+	poolManager, err := a.resourcePoolManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := protojson.Marshal(req.Config)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid resource pool patch: %s", err)
+	}
+	pool, err := poolManager.PatchResourcePool(ctx, rm.ResourcePoolName(req.ResourcePoolName), patch)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PatchResourcePoolResponse{ResourcePool: pool}, nil
+}
+
+func (a *apiServer) DeleteResourcePool(
+	ctx context.Context, req *apiv1.DeleteResourcePoolRequest,
+) (*apiv1.DeleteResourcePoolResponse, error) {
+	poolManager, err := a.resourcePoolManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := poolManager.DeleteResourcePool(ctx, rm.ResourcePoolName(req.ResourcePoolName)); err != nil {
+		return nil, err
+	}
+	return &apiv1.DeleteResourcePoolResponse{}, nil
+}
+
+// resourcePoolManager checks that the current user can manage resource pools, and returns the
+// resource manager whose resource pools can be managed at runtime.
+func (a *apiServer) resourcePoolManager(ctx context.Context) (rm.ResourcePoolManager, error) {
+	u, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	permErr, err := cluster.AuthZProvider.Get().CanUpdateMasterConfig(ctx, u)
+	if err != nil {
+		return nil, err
+	} else if permErr != nil {
+		return nil, permErr
+	}
+
+	for _, r := range a.m.allRms {
+		if poolManager, ok := r.(rm.ResourcePoolManager); ok {
+			return poolManager, nil
+		}
+	}
+	return nil, status.Error(codes.Unimplemented,
+		"resource pools can only be managed at runtime with the agent resource manager")
+}
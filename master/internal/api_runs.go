@@ -15,6 +15,7 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/db/bunutils"
+	"github.com/determined-ai/determined/master/internal/derivedmetrics"
 	"github.com/determined-ai/determined/master/internal/experiment"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/run"
@@ -108,15 +109,20 @@ func (a *apiServer) SearchRuns(
 		return nil, err
 	}
 
+	derived, err := searchDerivedMetrics(ctx, req.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.Filter != nil {
-		query, err = filterRunQuery(query, req.Filter)
+		query, err = filterRunQuery(query, req.Filter, derived)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if req.Sort != nil {
-		err = sortRuns(req.Sort, query)
+		err = sortRuns(req.Sort, query, derived)
 		if err != nil {
 			return nil, err
 		}
@@ -128,6 +134,11 @@ func (a *apiServer) SearchRuns(
 	if err != nil {
 		return nil, err
 	}
+	for _, r := range runs {
+		if r.DerivedMetrics, err = evalDerivedMetrics(derived, r.SummaryMetrics, r.Hyperparameters); err != nil {
+			return nil, err
+		}
+	}
 	resp.Pagination = pagination
 	resp.Runs = runs
 	return resp, nil
@@ -178,7 +189,7 @@ func getRunsColumns(q *bun.SelectQuery) *bun.SelectQuery {
 		Join("LEFT JOIN workspaces w ON p.workspace_id = w.id")
 }
 
-func sortRuns(sortString *string, runQuery *bun.SelectQuery) error {
+func sortRuns(sortString *string, runQuery *bun.SelectQuery, derived derivedmetrics.Set) error {
 	if sortString == nil {
 		return nil
 	}
@@ -246,6 +257,13 @@ func sortRuns(sortString *string, runQuery *bun.SelectQuery) error {
 			mdtQuery := strings.Join(mdt, "->")
 			queryArgs = append(queryArgs, bun.Safe(sortDirection))
 			runQuery.OrderExpr(fmt.Sprintf(`rm.metadata->%s ?`, mdtQuery), queryArgs...)
+		case strings.HasPrefix(paramDetail[0], derivedmetrics.ColumnPrefix):
+			expr, err := derived.Column(paramDetail[0])
+			if err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			exprSQL, queryArgs := expr.SQL()
+			runQuery.OrderExpr(exprSQL+" ?", append(queryArgs, bun.Safe(sortDirection))...)
 		case strings.Contains(paramDetail[0], "."):
 			metricGroup, metricName, metricQualifier, err := parseMetricsName(paramDetail[0])
 			if err != nil {
@@ -268,12 +286,26 @@ func sortRuns(sortString *string, runQuery *bun.SelectQuery) error {
 	return nil
 }
 
-func filterRunQuery(getQ *bun.SelectQuery, filter *string) (*bun.SelectQuery, error) {
+// filterProjectRunQuery filters runs of a project, which may be filtered by its derived metrics.
+func filterProjectRunQuery(
+	ctx context.Context, getQ *bun.SelectQuery, filter *string, projectID int32,
+) (*bun.SelectQuery, error) {
+	derived, err := searchDerivedMetrics(ctx, &projectID)
+	if err != nil {
+		return nil, err
+	}
+	return filterRunQuery(getQ, filter, derived)
+}
+
+func filterRunQuery(
+	getQ *bun.SelectQuery, filter *string, derived derivedmetrics.Set,
+) (*bun.SelectQuery, error) {
 	var efr experimentFilterRoot
 	err := json.Unmarshal([]byte(*filter), &efr)
 	if err != nil {
 		return nil, err
 	}
+	efr.derived = derived
 	getQ = getQ.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
 		_, err = efr.toSQL(q)
 		return q
@@ -347,7 +379,7 @@ func (a *apiServer) MoveRuns(
 	if req.Filter == nil {
 		getQ = getQ.Where("r.id IN (?)", bun.In(req.RunIds))
 	} else {
-		getQ, err = filterRunQuery(getQ, req.Filter)
+		getQ, err = filterProjectRunQuery(ctx, getQ, req.Filter, req.SourceProjectId)
 		if err != nil {
 			return nil, err
 		}
@@ -562,7 +594,7 @@ func (a *apiServer) KillRuns(ctx context.Context, req *apiv1.KillRunsRequest,
 	if req.Filter == nil {
 		getQ = getQ.Where("r.id IN (?)", bun.In(req.RunIds))
 	} else {
-		getQ, err = filterRunQuery(getQ, req.Filter)
+		getQ, err = filterProjectRunQuery(ctx, getQ, req.Filter, req.ProjectId)
 		if err != nil {
 			return nil, err
 		}
@@ -647,7 +679,7 @@ func (a *apiServer) DeleteRuns(ctx context.Context, req *apiv1.DeleteRunsRequest
 	if req.Filter == nil {
 		getQ = getQ.Where("r.id IN (?)", bun.In(req.RunIds))
 	} else {
-		getQ, err = filterRunQuery(getQ, req.Filter)
+		getQ, err = filterProjectRunQuery(ctx, getQ, req.Filter, req.ProjectId)
 		if err != nil {
 			return nil, err
 		}
@@ -804,7 +836,7 @@ func archiveUnarchiveAction(ctx context.Context, archive bool, runIDs []int32,
 	if filter == nil {
 		query = query.Where("r.id IN (?)", bun.In(runIDs))
 	} else {
-		query, err = filterRunQuery(query, filter)
+		query, err = filterProjectRunQuery(ctx, query, filter, projectID)
 		if err != nil {
 			return nil, err
 		}
@@ -931,7 +963,7 @@ func pauseResumeAction(ctx context.Context, isPause bool, projectID int32,
 	if isRunIDAction {
 		getQ = getQ.Where("r.id IN (?)", bun.In(runIds))
 	} else {
-		getQ, err = filterRunQuery(getQ, filter)
+		getQ, err = filterProjectRunQuery(ctx, getQ, filter, projectID)
 		if err != nil {
 			return nil, err
 		}
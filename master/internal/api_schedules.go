@@ -0,0 +1,394 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/schedules"
+	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/schedulev1"
+	"github.com/determined-ai/determined/proto/pkg/utilv1"
+)
+
+func (a *apiServer) PostSchedule(
+	ctx context.Context, req *apiv1.PostScheduleRequest,
+) (*apiv1.PostScheduleResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "a schedule must have a name")
+	}
+	kind, err := schedules.KindFromProto(req.Kind)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	policy, err := schedules.ConcurrencyPolicyFromProto(req.ConcurrencyPolicy)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := schedules.ParseCron(req.Cron); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	s := &schedules.Schedule{
+		Name:              req.Name,
+		OwnerID:           curUser.ID,
+		Kind:              kind,
+		Cron:              req.Cron,
+		Config:            req.Config,
+		TemplateName:      req.TemplateName,
+		ConcurrencyPolicy: policy,
+		Enabled:           req.Enabled == nil || *req.Enabled,
+	}
+	// Validate the runs the schedule will launch the way launching them would, so that mistakes
+	// surface now rather than at the first firing.
+	switch kind {
+	case schedules.KindExperiment:
+		projectID := int32(model.DefaultProjectID)
+		if req.ProjectId != nil {
+			projectID = *req.ProjectId
+		}
+		p, err := a.GetProjectByID(ctx, projectID, *curUser)
+		if err != nil {
+			return nil, err
+		}
+		if req.WorkspaceId != nil && *req.WorkspaceId != p.WorkspaceId {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"project %d is not in workspace %d", projectID, *req.WorkspaceId)
+		}
+		s.ProjectID = ptrs.Ptr(int(projectID))
+		s.WorkspaceID = int(p.WorkspaceId)
+		if _, err := a.CreateExperiment(ctx, &apiv1.CreateExperimentRequest{
+			ModelDefinition: req.ContextDirectory,
+			Config:          req.Config,
+			ProjectId:       projectID,
+			Template:        req.TemplateName,
+			ValidateOnly:    true,
+		}); err != nil {
+			return nil, err
+		}
+	case schedules.KindCommand:
+		if req.ProjectId != nil {
+			return nil, status.Error(codes.InvalidArgument, "commands are not in projects")
+		}
+		s.WorkspaceID = model.DefaultWorkspaceID
+		if req.WorkspaceId != nil {
+			s.WorkspaceID = int(*req.WorkspaceId)
+		}
+		if err := a.canLaunchScheduledCommand(ctx, curUser, s, req.ContextDirectory); err != nil {
+			return nil, err
+		}
+	}
+	if s.ContextDirectory, err = archive.ToTarGz(filesToArchive(req.ContextDirectory)); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "compressing context directory: %s", err)
+	}
+
+	switch err := schedules.Add(ctx, s); {
+	case errors.Is(err, db.ErrInvalidInput):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, db.ErrDuplicateRecord):
+		return nil, status.Errorf(codes.AlreadyExists,
+			"workspace %d already has a schedule named %s", s.WorkspaceID, s.Name)
+	case err != nil:
+		return nil, errors.Wrapf(err, "error creating schedule %s", req.Name)
+	}
+	pb, err := s.Proto()
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PostScheduleResponse{Schedule: pb}, nil
+}
+
+func (a *apiServer) GetSchedules(
+	ctx context.Context, req *apiv1.GetSchedulesRequest,
+) (*apiv1.GetSchedulesResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var workspaceID *int
+	if req.WorkspaceId != nil {
+		if _, err := a.GetWorkspaceByID(ctx, *req.WorkspaceId, *curUser, false); err != nil {
+			return nil, err
+		}
+		workspaceID = ptrs.Ptr(int(*req.WorkspaceId))
+	}
+	ss, err := schedules.List(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	canView := map[int]bool{}
+	resp := &apiv1.GetSchedulesResponse{Schedules: []*schedulev1.Schedule{}}
+	for _, s := range ss {
+		ok, seen := canView[s.WorkspaceID]
+		if !seen {
+			_, err := a.GetWorkspaceByID(ctx, int32(s.WorkspaceID), *curUser, false)
+			ok = err == nil
+			canView[s.WorkspaceID] = ok
+		}
+		if !ok {
+			continue
+		}
+		pb, err := s.Proto()
+		if err != nil {
+			return nil, err
+		}
+		resp.Schedules = append(resp.Schedules, pb)
+	}
+	return resp, nil
+}
+
+func (a *apiServer) GetSchedule(
+	ctx context.Context, req *apiv1.GetScheduleRequest,
+) (*apiv1.GetScheduleResponse, error) {
+	s, _, err := a.getScheduleAndCheckCanView(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	pb, err := s.Proto()
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.GetScheduleResponse{Schedule: pb}, nil
+}
+
+func (a *apiServer) PatchSchedule(
+	ctx context.Context, req *apiv1.PatchScheduleRequest,
+) (*apiv1.PatchScheduleResponse, error) {
+	s, curUser, err := a.getScheduleAndCheckCanView(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	if err := a.canChangeSchedule(ctx, curUser, s); err != nil {
+		return nil, err
+	}
+
+	p := schedules.Patch{Cron: req.Cron, Enabled: req.Enabled}
+	if req.ConcurrencyPolicy != nil {
+		policy, err := schedules.ConcurrencyPolicyFromProto(*req.ConcurrencyPolicy)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		p.ConcurrencyPolicy = &policy
+	}
+	switch s, err = schedules.Update(ctx, s.ID, p, time.Now()); {
+	case errors.Is(err, db.ErrInvalidInput):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, db.ErrNotFound):
+		return nil, api.NotFoundErrs("schedule", strconv.Itoa(int(req.Id)), true)
+	case err != nil:
+		return nil, errors.Wrapf(err, "error updating schedule %d", req.Id)
+	}
+	pb, err := s.Proto()
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PatchScheduleResponse{Schedule: pb}, nil
+}
+
+func (a *apiServer) DeleteSchedule(
+	ctx context.Context, req *apiv1.DeleteScheduleRequest,
+) (*apiv1.DeleteScheduleResponse, error) {
+	s, curUser, err := a.getScheduleAndCheckCanView(ctx, int(req.Id))
+	if err != nil {
+		return nil, err
+	}
+	if err := a.canChangeSchedule(ctx, curUser, s); err != nil {
+		return nil, err
+	}
+	if err := schedules.Delete(ctx, s.ID); errors.Is(err, db.ErrNotFound) {
+		return nil, api.NotFoundErrs("schedule", strconv.Itoa(int(req.Id)), true)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "error deleting schedule %d", req.Id)
+	}
+	return &apiv1.DeleteScheduleResponse{}, nil
+}
+
+// getScheduleAndCheckCanView returns a schedule if the current user can view its workspace.
+func (a *apiServer) getScheduleAndCheckCanView(
+	ctx context.Context, id int,
+) (*schedules.Schedule, *model.User, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	notFoundErr := api.NotFoundErrs("schedule", strconv.Itoa(id), true)
+	s, err := schedules.ByID(ctx, id)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil, notFoundErr
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if _, err := a.GetWorkspaceByID(ctx, int32(s.WorkspaceID), *curUser, false); err != nil {
+		return nil, nil, notFoundErr
+	}
+	return s, curUser, nil
+}
+
+// canChangeSchedule returns an error unless the current user could launch the runs of a
+// schedule themselves.
+func (a *apiServer) canChangeSchedule(
+	ctx context.Context, curUser *model.User, s *schedules.Schedule,
+) error {
+	switch s.Kind {
+	case schedules.KindExperiment:
+		p, err := a.GetProjectByID(ctx, int32(*s.ProjectID), *curUser)
+		if err != nil {
+			return err
+		}
+		if err := experiment.AuthZProvider.Get().CanCreateExperiment(ctx, *curUser, p); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	case schedules.KindCommand:
+		if err := command.AuthZProvider.Get().CanCreateNSC(
+			ctx, *curUser, model.AccessScopeID(s.WorkspaceID),
+		); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	return nil
+}
+
+// canLaunchScheduledCommand returns an error unless the current user could launch the command of
+// a schedule, following the checks of LaunchCommand.
+func (a *apiServer) canLaunchScheduledCommand(
+	ctx context.Context, curUser *model.User, s *schedules.Schedule, files []*utilv1.File,
+) error {
+	config, err := scheduledCommandConfig(s.Config)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid command config: %s", err)
+	}
+	launchReq, _, err := a.getCommandLaunchParams(ctx, &protoCommandParams{
+		TemplateName: scheduleTemplateName(s),
+		WorkspaceID:  int32(s.WorkspaceID),
+		Config:       config,
+		Files:        files,
+	}, curUser)
+	if err != nil {
+		return api.WrapWithFallbackCode(err, codes.InvalidArgument,
+			"failed to prepare launch params")
+	}
+	return a.isNTSCPermittedToLaunch(ctx, launchReq.Spec, curUser)
+}
+
+// scheduleTemplateName returns the template name of a schedule, or "" if it has none.
+func scheduleTemplateName(s *schedules.Schedule) string {
+	if s.TemplateName == nil {
+		return ""
+	}
+	return *s.TemplateName
+}
+
+// scheduledCommandConfig converts the YAML config of a command schedule to a struct.
+func scheduledCommandConfig(config string) (*structpb.Struct, error) {
+	var m map[string]any
+	if err := yaml.Unmarshal([]byte(config), &m); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(m)
+}
+
+// scheduleLauncher launches the runs of schedules as their owners.
+type scheduleLauncher struct {
+	a *apiServer
+}
+
+// ownerContext returns a context in which the owner of a schedule is the logged in user.
+func (l *scheduleLauncher) ownerContext(
+	ctx context.Context, s *schedules.Schedule,
+) (context.Context, error) {
+	fullOwner, err := user.ByID(ctx, s.OwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("getting owner of schedule %d: %w", s.ID, err)
+	}
+	if !fullOwner.Active {
+		return nil, fmt.Errorf("the owner of schedule %d is deactivated", s.ID)
+	}
+	owner := fullOwner.ToUser()
+	return grpcutil.WithUser(ctx, &owner), nil
+}
+
+func (l *scheduleLauncher) Launch(
+	ctx context.Context, s *schedules.Schedule, contextDirectory []byte,
+) (string, error) {
+	ctx, err := l.ownerContext(ctx, s)
+	if err != nil {
+		return "", err
+	}
+	files, err := archiveToFiles(contextDirectory)
+	if err != nil {
+		return "", fmt.Errorf("decompressing context directory of schedule %d: %w", s.ID, err)
+	}
+	switch s.Kind {
+	case schedules.KindExperiment:
+		resp, err := l.a.CreateExperiment(ctx, &apiv1.CreateExperimentRequest{
+			ModelDefinition: files,
+			Config:          s.Config,
+			ProjectId:       int32(*s.ProjectID),
+			Template:        s.TemplateName,
+			Activate:        true,
+		})
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(resp.Experiment.Id)), nil
+	case schedules.KindCommand:
+		config, err := scheduledCommandConfig(s.Config)
+		if err != nil {
+			return "", fmt.Errorf("invalid command config: %w", err)
+		}
+		resp, err := l.a.LaunchCommand(ctx, &apiv1.LaunchCommandRequest{
+			Config:       config,
+			TemplateName: scheduleTemplateName(s),
+			Files:        files,
+			WorkspaceId:  int32(s.WorkspaceID),
+		})
+		if err != nil {
+			return "", err
+		}
+		return resp.Command.Id, nil
+	default:
+		return "", fmt.Errorf("unsupported schedule kind %s", s.Kind)
+	}
+}
+
+func (l *scheduleLauncher) Kill(ctx context.Context, s *schedules.Schedule, runID string) error {
+	ctx, err := l.ownerContext(ctx, s)
+	if err != nil {
+		return err
+	}
+	switch s.Kind {
+	case schedules.KindExperiment:
+		id, err := strconv.Atoi(runID)
+		if err != nil {
+			return fmt.Errorf("invalid experiment id %q: %w", runID, err)
+		}
+		_, err = l.a.KillExperiment(ctx, &apiv1.KillExperimentRequest{Id: int32(id)})
+		return err
+	case schedules.KindCommand:
+		_, err := l.a.KillCommand(ctx, &apiv1.KillCommandRequest{CommandId: runID})
+		return err
+	default:
+		return fmt.Errorf("unsupported schedule kind %s", s.Kind)
+	}
+}
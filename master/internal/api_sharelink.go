@@ -0,0 +1,340 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/api/apiutils"
+	"github.com/determined-ai/determined/master/internal/authz"
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/proxy"
+	"github.com/determined-ai/determined/master/internal/rbac/audit"
+	"github.com/determined-ai/determined/master/internal/sharelink"
+	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/sharelinkv1"
+)
+
+// canShareTask checks that the user may manage the share links of a task. Only notebooks and
+// TensorBoards can be shared, and sharing them takes permission to kill them.
+func canShareTask(ctx context.Context, curUser model.User, taskID model.TaskID) error {
+	notFound := api.NotFoundErrs("task", string(taskID), true)
+	spec, err := command.IdentifyTask(ctx, taskID)
+	if errors.Is(err, db.ErrNotFound) {
+		return notFound
+	} else if err != nil {
+		return err
+	}
+
+	authZ := command.AuthZProvider.Get()
+	switch spec.TaskType {
+	case model.TaskTypeNotebook:
+		if err := authZ.CanGetNSC(ctx, curUser, spec.WorkspaceID); err != nil {
+			return authz.SubIfUnauthorized(err, notFound)
+		}
+		err = authZ.CanTerminateNSC(ctx, curUser, spec.WorkspaceID)
+	case model.TaskTypeTensorboard:
+		if err := authZ.CanGetTensorboard(
+			ctx, curUser, spec.WorkspaceID, spec.ExperimentIDs, spec.TrialIDs,
+		); err != nil {
+			return authz.SubIfUnauthorized(err, notFound)
+		}
+		err = authZ.CanTerminateTensorboard(ctx, curUser, spec.WorkspaceID)
+	default:
+		return status.Errorf(codes.InvalidArgument,
+			"only notebooks and TensorBoards can be shared, not %s", spec.TaskType)
+	}
+	return apiutils.MapAndFilterErrors(err, nil, nil)
+}
+
+// shareLinkByID returns a share link the user may manage.
+func shareLinkByID(
+	ctx context.Context, curUser model.User, id int32,
+) (*sharelink.ShareLink, error) {
+	l, err := sharelink.ByID(ctx, int(id))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, api.NotFoundErrs("share link", fmt.Sprint(id), true)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := canShareTask(ctx, curUser, l.TaskID); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, api.NotFoundErrs("share link", fmt.Sprint(id), true)
+		}
+		return nil, err
+	}
+	return l, nil
+}
+
+func (a *apiServer) PostShareLink(
+	ctx context.Context, req *apiv1.PostShareLinkRequest,
+) (*apiv1.PostShareLinkResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = audit.SupplyEntityID(ctx, req.TaskId)
+	if err := canShareTask(ctx, *curUser, model.TaskID(req.TaskId)); err != nil {
+		return nil, err
+	}
+
+	mode, err := sharelink.ModeFromProto(req.Mode)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	lifespan := sharelink.DefaultLifespan
+	if req.Lifespan != nil {
+		if lifespan, err = time.ParseDuration(*req.Lifespan); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"failed to parse lifespan %s: %s", *req.Lifespan, err)
+		}
+	}
+	if lifespan <= 0 || lifespan > sharelink.MaxLifespan {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"lifespan must be positive and at most %s", sharelink.MaxLifespan)
+	}
+	for _, username := range req.Usernames {
+		if _, err := user.ByUsername(ctx, username); errors.Is(err, db.ErrNotFound) {
+			return nil, status.Errorf(codes.InvalidArgument, "user %s not found", username)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	l := &sharelink.ShareLink{
+		TaskID:      model.TaskID(req.TaskId),
+		UserID:      curUser.ID,
+		Mode:        mode,
+		Usernames:   req.Usernames,
+		Description: req.Description,
+		ExpiresAt:   time.Now().Add(lifespan).UTC(),
+	}
+	if err := sharelink.Create(ctx, l); err != nil {
+		return nil, err
+	}
+	token, err := sharelink.Token(l)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PostShareLinkResponse{
+		ShareLink: l.Proto(),
+		Token:     token,
+		Path:      sharelink.Path(l.TaskID, token),
+	}, nil
+}
+
+func (a *apiServer) GetShareLinks(
+	ctx context.Context, req *apiv1.GetShareLinksRequest,
+) (*apiv1.GetShareLinksResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := canShareTask(ctx, *curUser, model.TaskID(req.TaskId)); err != nil {
+		return nil, err
+	}
+
+	links, err := sharelink.ListByTask(ctx, model.TaskID(req.TaskId), req.ShowInactive)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetShareLinksResponse{ShareLinks: []*sharelinkv1.ShareLink{}}
+	for _, l := range links {
+		resp.ShareLinks = append(resp.ShareLinks, l.Proto())
+	}
+	return resp, nil
+}
+
+func (a *apiServer) RevokeShareLink(
+	ctx context.Context, req *apiv1.RevokeShareLinkRequest,
+) (*apiv1.RevokeShareLinkResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = audit.SupplyEntityID(ctx, req.ShareLinkId)
+	if _, err := shareLinkByID(ctx, *curUser, req.ShareLinkId); err != nil {
+		return nil, err
+	}
+
+	l, err := sharelink.Revoke(ctx, int(req.ShareLinkId))
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.RevokeShareLinkResponse{ShareLink: l.Proto()}, nil
+}
+
+func (a *apiServer) GetShareLinkAccesses(
+	ctx context.Context, req *apiv1.GetShareLinkAccessesRequest,
+) (*apiv1.GetShareLinkAccessesResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := shareLinkByID(ctx, *curUser, req.ShareLinkId); err != nil {
+		return nil, err
+	}
+	if req.Offset < 0 || req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset and limit must not be negative")
+	}
+
+	accesses, total, err := sharelink.ListAccesses(
+		ctx, int(req.ShareLinkId), int(req.Offset), int(req.Limit))
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetShareLinkAccessesResponse{
+		Accesses: make([]*sharelinkv1.ShareLinkAccess, 0, len(accesses)),
+		Pagination: &apiv1.Pagination{
+			Offset:     req.Offset,
+			Limit:      req.Limit,
+			StartIndex: req.Offset,
+			EndIndex:   req.Offset + int32(len(accesses)),
+			Total:      int32(total),
+		},
+	}
+	for _, access := range accesses {
+		resp.Accesses = append(resp.Accesses, access.Proto())
+	}
+	return resp, nil
+}
+
+// shareLinkTokenFromRequest returns the share link token of a proxied request, from the query
+// parameter of the first request or from the cookie set for the rest.
+func shareLinkTokenFromRequest(r *http.Request) string {
+	if token := r.URL.Query().Get(sharelink.TokenParam); token != "" {
+		return token
+	}
+	if cookie, err := r.Cookie(sharelink.TokenParam); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// shareLinkCookiePath returns the path the share link cookie of a proxied service is scoped to.
+func shareLinkCookiePath(c echo.Context) string {
+	return fmt.Sprintf("/proxy/%s/", c.Param("service"))
+}
+
+// clearShareLinkCookie tells the client to drop the share link cookie of a proxied service.
+func clearShareLinkCookie(c echo.Context) {
+	c.SetCookie(&http.Cookie{
+		Name:     sharelink.TokenParam,
+		Path:     shareLinkCookiePath(c),
+		MaxAge:   -1,
+		Secure:   c.IsTLS(),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// stripShareLinkToken keeps the share link token of a proxied request from the service itself.
+func stripShareLinkToken(req *http.Request) {
+	if query := req.URL.Query(); query.Has(sharelink.TokenParam) {
+		query.Del(sharelink.TokenParam)
+		req.URL.RawQuery = query.Encode()
+	}
+	stripCookies(req, sharelink.TokenParam)
+}
+
+// stripShareLinkCredentials keeps the share link token and the credentials of the visitor of a
+// request authenticated through a share link from the service, which is controlled by the sharer.
+func stripShareLinkCredentials(req *http.Request) {
+	stripShareLinkToken(req)
+	stripCookies(req, grpcutil.AuthCookieName)
+	req.Header.Del("Authorization")
+}
+
+// stripCookies removes the named cookies from a request.
+func stripCookies(req *http.Request, names ...string) {
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if !slices.Contains(names, cookie.Name) {
+			req.AddCookie(cookie)
+		}
+	}
+}
+
+// processShareLinkAuthentication authenticates a proxied request through a share link instead of
+// as a user with access to the task. Every request is recorded, including denied ones. It returns
+// sharelink.ErrInvalidToken without responding if the token is invalid. requester is the user the
+// request is authenticated as, if any.
+func processShareLinkAuthentication(
+	c echo.Context, taskID model.TaskID, token string, requester *model.User,
+) (done bool, err error) {
+	req := c.Request()
+	ctx := req.Context()
+
+	// Share links are anonymous unless limited to named users, who must be logged in.
+	l, err := sharelink.Authenticate(ctx, token, taskID)
+	if err != nil {
+		return true, err
+	}
+	authErr := l.Authorize(requester, req.Method, c.IsWebSocket(), time.Now())
+	access := &sharelink.Access{
+		ShareLinkID: l.ID,
+		RemoteAddr:  c.RealIP(),
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Allowed:     authErr == nil,
+	}
+	if requester != nil {
+		access.UserID = &requester.ID
+		proxy.SetRequestUser(c, requester.Username)
+	}
+	if err := sharelink.RecordAccess(ctx, access); err != nil {
+		return true, fmt.Errorf("recording share link access: %w", err)
+	}
+	switch {
+	case errors.Is(authErr, sharelink.ErrUserNotAllowed) && requester == nil:
+		return true, redirectToLogin(c)
+	case authErr != nil:
+		return true, echo.NewHTTPError(http.StatusForbidden, authErr.Error())
+	}
+
+	// Move the token from the query to a cookie scoped to the service, and keep it and the
+	// visitor's own credentials from the service itself.
+	if req.URL.Query().Has(sharelink.TokenParam) {
+		c.SetCookie(&http.Cookie{
+			Name:     sharelink.TokenParam,
+			Value:    token,
+			Path:     shareLinkCookiePath(c),
+			Expires:  l.ExpiresAt,
+			Secure:   c.IsTLS(),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	stripShareLinkCredentials(req)
+
+	// Notebooks check their own token, which only their owner holds.
+	spec, err := command.IdentifyTask(ctx, taskID)
+	if err != nil {
+		return true, fmt.Errorf("error fetching task metadata: %w", err)
+	}
+	if spec.TaskType == model.TaskTypeNotebook {
+		ownerID, err := command.GetCommandOwnerID(ctx, taskID)
+		if err != nil {
+			return true, fmt.Errorf("error fetching notebook owner: %w", err)
+		}
+		notebookToken, err := db.GenerateNotebookSessionToken(ownerID, taskID)
+		if err != nil {
+			return true, err
+		}
+		req.Header.Set("Authorization", "token "+notebookToken)
+	}
+	return false, nil
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/sharelink"
+)
+
+func TestStripShareLinkCredentials(t *testing.T) {
+	var upstreamReq *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		upstreamReq = r
+	}))
+	defer upstream.Close()
+	target, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/proxy/task/lab?"+sharelink.TokenParam+"=share-token&path=a", nil)
+	req.AddCookie(&http.Cookie{Name: sharelink.TokenParam, Value: "share-token"})
+	req.AddCookie(&http.Cookie{Name: grpcutil.AuthCookieName, Value: "session-token"})
+	req.AddCookie(&http.Cookie{Name: "_xsrf", Value: "xsrf"})
+	req.Header.Set("Authorization", "Bearer session-token")
+
+	stripShareLinkCredentials(req)
+	proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NotNil(t, upstreamReq)
+	require.Empty(t, upstreamReq.Header.Get("Authorization"))
+	_, err = upstreamReq.Cookie(grpcutil.AuthCookieName)
+	require.ErrorIs(t, err, http.ErrNoCookie)
+	_, err = upstreamReq.Cookie(sharelink.TokenParam)
+	require.ErrorIs(t, err, http.ErrNoCookie)
+	require.False(t, upstreamReq.URL.Query().Has(sharelink.TokenParam))
+
+	// Everything else still reaches the service.
+	xsrf, err := upstreamReq.Cookie("_xsrf")
+	require.NoError(t, err)
+	require.Equal(t, "xsrf", xsrf.Value)
+	require.Equal(t, "a", upstreamReq.URL.Query().Get("path"))
+}
@@ -5,21 +5,26 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	petname "github.com/dustinkirkland/golang-petname"
 	"github.com/pkg/errors"
+	sshlib "golang.org/x/crypto/ssh"
 
 	"golang.org/x/exp/maps"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/api/apiutils"
 	"github.com/determined-ai/determined/master/internal/authz"
+	"github.com/determined-ai/determined/master/internal/cluster"
 	"github.com/determined-ai/determined/master/internal/command"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/rbac/audit"
+	"github.com/determined-ai/determined/master/internal/sshca"
 	"github.com/determined-ai/determined/master/pkg/archive"
 	"github.com/determined-ai/determined/master/pkg/check"
 	pkgCommand "github.com/determined-ai/determined/master/pkg/command"
@@ -30,6 +35,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
 	"github.com/determined-ai/determined/master/pkg/ssh"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/taskv1"
 )
 
 const (
@@ -213,11 +219,13 @@ func (a *apiServer) LaunchShell(
 	// Selecting a random port mitigates the risk of multiple processes binding
 	// the same port on an agent in host mode.
 	port := getRandomPort(minSshdPort, maxSshdPort)
-	// Shell authentication happens through SSH keys, instead.
+	// Shell authentication happens through SSH keys, instead. When shells accept certificates, the
+	// proxy also checks that users may still access the shell, so that revoking access takes effect
+	// before their certificates expire.
 	launchReq.Spec.Base.ExtraProxyPorts = append(launchReq.Spec.Base.ExtraProxyPorts, expconf.ProxyPort{
 		RawProxyPort:        port,
 		RawProxyTCP:         ptrs.Ptr(true),
-		RawUnauthenticated:  ptrs.Ptr(true),
+		RawUnauthenticated:  ptrs.Ptr(a.m.sshCA == nil),
 		RawDefaultServiceID: ptrs.Ptr(true),
 	})
 
@@ -259,6 +267,11 @@ func (a *apiServer) LaunchShell(
 	launchReq.Spec.Metadata.PrivateKey = ptrs.Ptr(string(keys.PrivateKey))
 	launchReq.Spec.Metadata.PublicKey = ptrs.Ptr(string(keys.PublicKey))
 	launchReq.Spec.Keys = &keys
+	if a.m.sshCA != nil {
+		// The keypair is only the host key of the shell, and users log in with certificates.
+		launchReq.Spec.Metadata.PrivateKey = ptrs.Ptr("")
+		launchReq.Spec.SSHCertificateAuthorityKey = a.m.sshCA.AuthorizedKey()
+	}
 
 	// Launch a Shell.
 	cmd, err := command.DefaultCmdService.LaunchGenericCommand(
@@ -275,3 +288,81 @@ func (a *apiServer) LaunchShell(
 		Warnings: pkgCommand.LaunchWarningToProto(launchWarnings),
 	}, nil
 }
+
+func (a *apiServer) PostShellSSHCertificate(
+	ctx context.Context, req *apiv1.PostShellSSHCertificateRequest,
+) (*apiv1.PostShellSSHCertificateResponse, error) {
+	if a.m.sshCA == nil {
+		return nil, status.Error(codes.FailedPrecondition,
+			"the SSH certificate authority is not enabled on this cluster")
+	}
+
+	getResponse, err := a.GetShell(ctx, &apiv1.GetShellRequest{ShellId: req.ShellId})
+	if err != nil {
+		return nil, err
+	}
+	if getResponse.Shell.State == taskv1.State_STATE_TERMINATED {
+		return nil, status.Errorf(codes.FailedPrecondition, "shell %s is terminated", req.ShellId)
+	}
+
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = audit.SupplyEntityID(ctx, req.ShellId)
+	cert, err := a.m.sshCA.SignShellKey(
+		ctx, *curUser, model.TaskID(req.ShellId), []byte(req.PublicKey))
+	switch {
+	case errors.Is(err, sshca.ErrInvalidPublicKey):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.PostShellSSHCertificateResponse{
+		Certificate: string(sshlib.MarshalAuthorizedKey(cert)),
+		Principal:   cert.ValidPrincipals[0],
+		ValidBefore: timestamppb.New(time.Unix(int64(cert.ValidBefore), 0)),
+	}, nil
+}
+
+func (a *apiServer) GetSSHCertificates(
+	ctx context.Context, req *apiv1.GetSSHCertificatesRequest,
+) (*apiv1.GetSSHCertificatesResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Users may audit their own certificates, and only admins those of other users.
+	if model.UserID(req.UserId) != curUser.ID {
+		permErr, err := cluster.AuthZProvider.Get().CanUpdateMasterConfig(ctx, curUser)
+		if err != nil {
+			return nil, err
+		} else if permErr != nil {
+			return nil, permErr
+		}
+	}
+	if req.Offset < 0 || req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset and limit must not be negative")
+	}
+
+	certs, total, err := sshca.ListCertificates(ctx, model.UserID(req.UserId),
+		model.TaskID(req.TaskId), int(req.Offset), int(req.Limit))
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetSSHCertificatesResponse{
+		Certificates: make([]*apiv1.SSHCertificate, 0, len(certs)),
+		Pagination: &apiv1.Pagination{
+			Offset:     req.Offset,
+			Limit:      req.Limit,
+			StartIndex: req.Offset,
+			EndIndex:   req.Offset + int32(len(certs)),
+			Total:      int32(total),
+		},
+	}
+	for _, cert := range certs {
+		resp.Certificates = append(resp.Certificates, cert.Proto())
+	}
+	return resp, nil
+}
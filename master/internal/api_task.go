@@ -4,10 +4,12 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/db"
 	expauth "github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/internal/task/watchdog"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/taskv1"
@@ -25,10 +27,13 @@ func (a *apiServer) GetTask(
 	switch err := a.m.db.QueryProto("get_task", t, req.TaskId); {
 	case errors.Is(err, db.ErrNotFound):
 		return nil, api.NotFoundErrs("task", req.TaskId, true)
-	default:
-		return &apiv1.GetTaskResponse{Task: t},
-			errors.Wrapf(err, "error fetching task %s from database", req.TaskId)
+	case err != nil:
+		return nil, errors.Wrapf(err, "error fetching task %s from database", req.TaskId)
+	}
+	if last, ok := watchdog.LastProgress(model.TaskID(req.TaskId)); ok && t.Watchdog != nil {
+		t.Watchdog.LastProgressTime = timestamppb.New(last)
 	}
+	return &apiv1.GetTaskResponse{Task: t}, nil
 }
 
 func (a *apiServer) GetGenericTaskConfig(
@@ -12,6 +12,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
@@ -23,7 +24,9 @@ import (
 	expauth "github.com/determined-ai/determined/master/internal/experiment"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/logpattern"
+	"github.com/determined-ai/determined/master/internal/proxy"
 	"github.com/determined-ai/determined/master/internal/task"
+	"github.com/determined-ai/determined/master/internal/task/watchdog"
 	"github.com/determined-ai/determined/master/internal/webhooks"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/ptrs"
@@ -496,6 +499,7 @@ func (a *apiServer) PostTaskLogs(
 	if err := a.m.taskLogBackend.AddTaskLogs(logs); err != nil {
 		return nil, fmt.Errorf("adding task logs to task log backend: %w", err)
 	}
+	watchdog.RecordProgress(model.TaskID(taskID))
 
 	switch err := webhooks.ScanLogs(ctx, logs, *workspaceID, expID); {
 	case err != nil && errors.Is(err, context.Canceled):
@@ -607,6 +611,31 @@ func (a *apiServer) GetTasks(
 	return &apiv1.GetTasksResponse{AllocationIdToSummary: pbAllocationIDToSummary}, nil
 }
 
+func (a *apiServer) GetTaskProxyConnections(
+	ctx context.Context, req *apiv1.GetTaskProxyConnectionsRequest,
+) (*apiv1.GetTaskProxyConnectionsResponse, error) {
+	if _, _, err := a.canDoActionsOnTask(ctx, model.TaskID(req.TaskId)); err != nil {
+		return nil, err
+	}
+
+	resp := &apiv1.GetTaskProxyConnectionsResponse{Connections: []*apiv1.ProxyConnection{}}
+	for _, conn := range proxy.DefaultProxy.Connections(req.TaskId) {
+		resp.Connections = append(resp.Connections, &apiv1.ProxyConnection{
+			Id:            conn.ID,
+			ServiceId:     conn.ServiceID,
+			Kind:          string(conn.Kind),
+			Username:      conn.Username,
+			RemoteAddr:    conn.RemoteAddr,
+			Method:        conn.Method,
+			Path:          conn.Path,
+			StartTime:     timestamppb.New(conn.StartTime),
+			BytesReceived: conn.BytesReceived,
+			BytesSent:     conn.BytesSent,
+		})
+	}
+	return resp, nil
+}
+
 func (a *apiServer) taskLogs(
 	ctx context.Context, req *apiv1.TaskLogsRequest, res chan api.BatchResult,
 ) {
@@ -22,10 +22,13 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/elasticslots"
 	"github.com/determined-ai/determined/master/internal/experiment"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/otelmetrics"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/internal/task"
+	"github.com/determined-ai/determined/master/internal/task/watchdog"
 	"github.com/determined-ai/determined/master/internal/trials"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
@@ -842,7 +845,7 @@ func (a *apiServer) parseMetricGroupArgs(
 func (a *apiServer) multiTrialSample(trialID int32, metricNames []string,
 	metricGroup model.MetricGroup, maxDatapoints int, startBatches int,
 	endBatches int, timeSeriesFilter *commonv1.PolymorphicFilter,
-	metricIds []string,
+	metricIds []string, method apiv1.DownsampleMethod,
 ) ([]*apiv1.DownsampledMetrics, error) {
 	var startTime time.Time
 	var metrics []*apiv1.DownsampledMetrics
@@ -895,16 +898,31 @@ func (a *apiServer) multiTrialSample(trialID int32, metricNames []string,
 	getDownSampledMetric := func(aMetricNames []string, aMetricGroup model.MetricGroup,
 	) (*apiv1.DownsampledMetrics, error) {
 		var metric apiv1.DownsampledMetrics
+		//nolint:staticcheck // SA1019: backward compatibility
+		metric.Type = aMetricGroup.ToProto()
+		metric.Group = aMetricGroup.ToString()
+
+		if method == apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_BUCKETS {
+			buckets, err := trials.MetricsBuckets(trialID, aMetricNames, startBatches, endBatches,
+				maxDatapoints, *timeSeriesColumn, timeSeriesFilter, aMetricGroup)
+			if err != nil {
+				return nil, errors.Wrapf(err, fmt.Sprintf("error aggregating time series of %s metrics",
+					aMetricGroup))
+			}
+			if len(buckets) > 0 {
+				metric.Data = trials.BucketDataPoints(buckets, trialID, aMetricGroup)
+				return &metric, nil
+			}
+			return nil, nil
+		}
+
 		metricMeasurements, err := trials.MetricsTimeSeries(
 			trialID, startTime, aMetricNames, startBatches, endBatches,
-			maxDatapoints, *timeSeriesColumn, timeSeriesFilter, aMetricGroup)
+			maxDatapoints, *timeSeriesColumn, timeSeriesFilter, aMetricGroup, method)
 		if err != nil {
 			return nil, errors.Wrapf(err, fmt.Sprintf("error fetching time series of %s metrics",
 				aMetricGroup))
 		}
-		//nolint:staticcheck // SA1019: backward compatibility
-		metric.Type = aMetricGroup.ToProto()
-		metric.Group = aMetricGroup.ToString()
 		if len(metricMeasurements) > 0 {
 			if err = a.formatMetrics(&metric, metricMeasurements); err != nil {
 				return nil, err
@@ -974,7 +992,7 @@ func (a *apiServer) CompareTrials(ctx context.Context,
 
 		tsample, err := a.multiTrialSample(trialObj.Id, req.MetricNames, metricGroup,
 			int(req.MaxDatapoints), int(req.StartBatches), int(req.EndBatches),
-			req.TimeSeriesFilter, req.MetricIds)
+			req.TimeSeriesFilter, req.MetricIds, req.DownsampleMethod)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed sampling")
 		}
@@ -987,6 +1005,10 @@ func (a *apiServer) CompareTrials(ctx context.Context,
 func (a *apiServer) GetMetrics(
 	req *apiv1.GetMetricsRequest, resp apiv1.Determined_GetMetricsServer,
 ) error {
+	if req.DownsampleMethod != apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_UNSPECIFIED {
+		return a.streamDownsampledMetrics(resp.Context(), req, resp.Send)
+	}
+
 	sendFunc := func(m []*trialv1.MetricsReport) error {
 		return resp.Send(&apiv1.GetMetricsResponse{Metrics: m})
 	}
@@ -1026,6 +1048,124 @@ func (a *apiServer) GetValidationMetrics(
 	return nil
 }
 
+// streamDownsampledMetrics sends the downsampled reports, or the buckets aggregating them, of
+// each requested trial in its own message.
+func (a *apiServer) streamDownsampledMetrics(ctx context.Context,
+	req *apiv1.GetMetricsRequest, sendFunc func(*apiv1.GetMetricsResponse) error,
+) error {
+	if len(req.TrialIds) == 0 {
+		return status.Error(codes.InvalidArgument, "must specify at least one trialId")
+	}
+	switch req.DownsampleMethod {
+	case apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_LTTB:
+		if req.DownsampleMetric == "" {
+			return status.Error(codes.InvalidArgument,
+				"must specify a downsample metric to downsample with LTTB")
+		}
+	case apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_BUCKETS:
+	default:
+		return status.Errorf(codes.InvalidArgument,
+			"unsupported downsample method %s", req.DownsampleMethod)
+	}
+	if req.MaxDatapoints < 0 {
+		return status.Error(codes.InvalidArgument, "max datapoints must be positive")
+	}
+	maxDatapoints := int(req.MaxDatapoints)
+	if maxDatapoints == 0 {
+		maxDatapoints = 1000
+	}
+	endBatches := req.EndBatches
+	if endBatches <= 0 {
+		endBatches = math.MaxInt32
+	}
+
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+	for _, trialID := range req.TrialIds {
+		if err := trials.CanGetTrialsExperimentAndCheckCanDoAction(ctx, int(trialID), curUser,
+			experiment.AuthZProvider.Get().CanGetExperimentArtifacts); err != nil {
+			return err
+		}
+	}
+
+	mGroup := model.MetricGroup(req.Group)
+	for _, trialID := range req.TrialIds {
+		reports, err := metricsInWindow(ctx, trialID, mGroup, req.StartBatches, endBatches,
+			req.StartTime, req.EndTime)
+		if err != nil {
+			return err
+		}
+
+		var res apiv1.GetMetricsResponse
+		switch req.DownsampleMethod {
+		case apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_LTTB:
+			res.Metrics = trials.LTTBReports(reports, mGroup, req.DownsampleMetric,
+				req.DownsampleAxis, maxDatapoints)
+		case apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_BUCKETS:
+			var lo, hi *float64
+			if req.DownsampleAxis == apiv1.DownsampleAxis_DOWNSAMPLE_AXIS_TIME {
+				if req.StartTime != nil {
+					lo = ptrs.Ptr(float64(req.StartTime.AsTime().UnixNano()) / float64(time.Second))
+				}
+				if req.EndTime != nil {
+					hi = ptrs.Ptr(float64(req.EndTime.AsTime().UnixNano()) / float64(time.Second))
+				}
+			} else {
+				lo = ptrs.Ptr(float64(req.StartBatches))
+				if req.EndBatches > 0 {
+					hi = ptrs.Ptr(float64(req.EndBatches))
+				}
+			}
+			for _, b := range trials.BucketReports(
+				reports, mGroup, req.DownsampleAxis, lo, hi, maxDatapoints,
+			) {
+				res.Buckets = append(res.Buckets, b.Proto(trialID, mGroup))
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := sendFunc(&res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metricsInWindow returns every report of a trial in a metric group made within the given
+// batches and, if set, time window.
+func metricsInWindow(ctx context.Context, trialID int32, mGroup model.MetricGroup,
+	startBatches, endBatches int32, startTime, endTime *timestamppb.Timestamp,
+) ([]*trialv1.MetricsReport, error) {
+	const size = 1000
+
+	var reports []*trialv1.MetricsReport
+	group := mGroup.ToString()
+	key := int(startBatches) - 1
+	for {
+		res, err := db.GetMetrics(ctx, int(trialID), key, size, &group)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			switch {
+			case r.TotalBatches > endBatches:
+			case startTime != nil && r.EndTime.AsTime().Before(startTime.AsTime()):
+			case endTime != nil && r.EndTime.AsTime().After(endTime.AsTime()):
+			default:
+				reports = append(reports, r)
+			}
+		}
+		if len(res) != size {
+			return reports, nil
+		}
+		key = int(res[len(res)-1].TotalBatches)
+	}
+}
+
 func (a *apiServer) streamMetrics(ctx context.Context,
 	trialIDs []int32, sendFunc func(m []*trialv1.MetricsReport) error, metricGroup model.MetricGroup,
 ) error {
@@ -1084,6 +1224,29 @@ func (a *apiServer) streamMetrics(ctx context.Context,
 	return nil
 }
 
+func (a *apiServer) GetTrialResizeEvents(
+	ctx context.Context, req *apiv1.GetTrialResizeEventsRequest,
+) (*apiv1.GetTrialResizeEventsResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := trials.CanGetTrialsExperimentAndCheckCanDoAction(ctx, int(req.TrialId), curUser,
+		experiment.AuthZProvider.Get().CanGetExperimentArtifacts); err != nil {
+		return nil, err
+	}
+
+	events, err := elasticslots.ResizeEvents(ctx, int(req.TrialId))
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetTrialResizeEventsResponse{ResizeEvents: []*trialv1.TrialResizeEvent{}}
+	for _, e := range events {
+		resp.ResizeEvents = append(resp.ResizeEvents, e.Proto())
+	}
+	return resp, nil
+}
+
 func (a *apiServer) GetTrialWorkloads(ctx context.Context, req *apiv1.GetTrialWorkloadsRequest) (
 	*apiv1.GetTrialWorkloadsResponse, error,
 ) {
@@ -1415,6 +1578,8 @@ func (a *apiServer) ReportTrialProgress(
 		return nil, err
 	}
 
+	recordTrialWatchdogProgress(ctx, int(req.TrialId))
+
 	eID, rID, err := a.m.db.TrialExperimentAndRequestID(int(req.TrialId))
 	if err != nil {
 		return nil, err
@@ -1455,6 +1620,10 @@ func (a *apiServer) ReportTrialMetrics(
 		return nil, err
 	}
 	if metricGroup == model.ValidationMetricGroup {
+		if err := addDerivedSearcherMetric(ctx, req.Metrics); err != nil {
+			return nil, err
+		}
+
 		// Notify searcher of validation metrics.
 		eID, rID, err := a.m.db.TrialExperimentAndRequestID(int(req.Metrics.TrialId))
 		if err != nil {
@@ -1473,6 +1642,9 @@ func (a *apiServer) ReportTrialMetrics(
 	if err := a.m.db.AddTrialMetrics(ctx, req.Metrics, metricGroup); err != nil {
 		return nil, err
 	}
+	recordTrialWatchdogProgress(ctx, int(req.Metrics.TrialId))
+	otelmetrics.Report(req.Metrics.TrialId, metricGroup, req.Metrics.GetStepsCompleted(),
+		req.Metrics.Metrics.GetAvgMetrics())
 	return &apiv1.ReportTrialMetricsResponse{}, nil
 }
 
@@ -1532,10 +1704,26 @@ func (a *apiServer) ReportCheckpoint(
 	if err := db.AddCheckpointMetadata(ctx, c, trial.ID); err != nil {
 		return nil, err
 	}
+	watchdog.RecordProgress(task.TaskID)
 
 	return &apiv1.ReportCheckpointResponse{}, nil
 }
 
+// recordTrialWatchdogProgress records progress for the watchdogs of the tasks of a trial.
+func recordTrialWatchdogProgress(ctx context.Context, trialID int) {
+	if !watchdog.Any() {
+		return
+	}
+	ids, err := db.TrialTaskIDsByTrialID(ctx, trialID)
+	if err != nil {
+		log.WithError(err).Warnf("failed to record watchdog progress for trial %d", trialID)
+		return
+	}
+	for _, id := range ids {
+		watchdog.RecordProgress(id.TaskID)
+	}
+}
+
 func checkpointV2FromProtoWithDefaults(p *checkpointv1.Checkpoint) (*model.CheckpointV2, error) {
 	conv := &protoconverter.ProtoConverter{}
 
@@ -292,7 +292,7 @@ func TestMultiTrialSampleSpecialMetrics(t *testing.T) {
 	actualMetrics, err := api.multiTrialSample(int32(trial.ID), []string{},
 		"", maxDataPoints, 0, 10, nil, []string{
 			"mygroup.zgroup_b/me.t r%i]\\c_1",
-		})
+		}, apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_UNSPECIFIED)
 	require.Len(t, actualMetrics, 1)
 	require.NoError(t, err)
 	mygroup := actualMetrics[0]
@@ -317,7 +317,8 @@ func TestMultiTrialSampleMetrics(t *testing.T) {
 		metricIds = append(metricIds, "training."+metricName)
 	}
 	actualTrainingMetrics, err := api.multiTrialSample(int32(trial.ID), trainMetricNames,
-		model.TrainingMetricGroup, maxDataPoints, 0, 10, nil, []string{})
+		model.TrainingMetricGroup, maxDataPoints, 0, 10, nil, []string{},
+		apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_UNSPECIFIED)
 	require.NoError(t, err)
 	require.Len(t, actualTrainingMetrics, 1)
 
@@ -328,7 +329,7 @@ func TestMultiTrialSampleMetrics(t *testing.T) {
 	}
 	actualValidationTrainingMetrics, err := api.multiTrialSample(int32(trial.ID),
 		validationMetricNames, model.ValidationMetricGroup, maxDataPoints,
-		0, 10, nil, []string{})
+		0, 10, nil, []string{}, apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_UNSPECIFIED)
 	require.Len(t, actualValidationTrainingMetrics, 1)
 	require.NoError(t, err)
 
@@ -339,7 +340,7 @@ func TestMultiTrialSampleMetrics(t *testing.T) {
 	}
 	actualGenericTrainingMetrics, err := api.multiTrialSample(int32(trial.ID),
 		genericMetricNames, model.MetricGroup("mygroup"), maxDataPoints,
-		0, 10, nil, []string{})
+		0, 10, nil, []string{}, apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_UNSPECIFIED)
 	require.Len(t, actualGenericTrainingMetrics, 1)
 	require.NoError(t, err)
 
@@ -347,7 +348,8 @@ func TestMultiTrialSampleMetrics(t *testing.T) {
 	require.True(t, isMultiTrialSampleCorrect(expectedValMetrics, actualValidationTrainingMetrics[0]))
 
 	actualAllMetrics, err := api.multiTrialSample(int32(trial.ID), []string{},
-		"", maxDataPoints, 0, 10, nil, metricIds)
+		"", maxDataPoints, 0, 10, nil, metricIds,
+		apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_UNSPECIFIED)
 	require.Len(t, actualAllMetrics, 3)
 	require.NoError(t, err)
 	require.Len(t, actualAllMetrics[1].Data, maxDataPoints) // max datapoints check
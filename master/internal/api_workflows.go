@@ -0,0 +1,339 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/command"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/grpcutil"
+	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/internal/workflow"
+	"github.com/determined-ai/determined/master/pkg/archive"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/utilv1"
+	"github.com/determined-ai/determined/proto/pkg/workflowv1"
+)
+
+func (a *apiServer) CreateWorkflow(
+	ctx context.Context, req *apiv1.CreateWorkflowRequest,
+) (*apiv1.CreateWorkflowResponse, error) {
+	projectID := model.DefaultProjectID
+	if req.ProjectId != nil {
+		projectID = int(*req.ProjectId)
+	}
+	if err := a.canCreateGenericTask(ctx, projectID); err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "a workflow must have a name")
+	}
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &workflow.Workflow{
+		Name:      req.Name,
+		ProjectID: projectID,
+		OwnerID:   curUser.ID,
+		Spec:      workflow.Spec{Parameters: req.Parameters.AsMap()},
+	}
+	for _, n := range req.Nodes {
+		// Catch config errors up front rather than as nodes launch.
+		config := model.DefaultConfigGenericTaskConfig(nil)
+		if err := yaml.UnmarshalStrict(
+			[]byte(n.Config), &config, yaml.DisallowUnknownFields,
+		); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"invalid config of node %s: %s", n.Name, err)
+		}
+		if err := check.Validate(config); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"invalid config of node %s: %s", n.Name, err)
+		}
+		w.Spec.Nodes = append(w.Spec.Nodes, workflow.NodeSpec{
+			Name:       n.Name,
+			DependsOn:  n.DependsOn,
+			Config:     n.Config,
+			MaxRetries: int(n.MaxRetries),
+		})
+	}
+	if w.ContextDirectory, err = archive.ToTarGz(filesToArchive(req.ContextDirectory)); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "compressing context directory: %s", err)
+	}
+
+	switch err := workflow.DefaultManager.Create(ctx, w); {
+	case errors.Is(err, db.ErrInvalidInput):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, errors.Wrapf(err, "error creating workflow %s", req.Name)
+	}
+	pb, err := w.Proto()
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.CreateWorkflowResponse{Workflow: pb}, nil
+}
+
+func (a *apiServer) GetWorkflow(
+	ctx context.Context, req *apiv1.GetWorkflowRequest,
+) (*apiv1.GetWorkflowResponse, error) {
+	w, err := a.getWorkflowAndCheckCanView(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	pb, err := w.Proto()
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.GetWorkflowResponse{Workflow: pb}, nil
+}
+
+func (a *apiServer) GetWorkflows(
+	ctx context.Context, req *apiv1.GetWorkflowsRequest,
+) (*apiv1.GetWorkflowsResponse, error) {
+	if _, _, err := a.getProjectAndCheckCanDoActions(ctx, req.ProjectId); err != nil {
+		return nil, err
+	}
+	ws, err := workflow.ByProject(ctx, int(req.ProjectId))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting workflows of project %d", req.ProjectId)
+	}
+	resp := &apiv1.GetWorkflowsResponse{Workflows: make([]*workflowv1.Workflow, 0, len(ws))}
+	for _, w := range ws {
+		pb, err := w.Proto()
+		if err != nil {
+			return nil, err
+		}
+		resp.Workflows = append(resp.Workflows, pb)
+	}
+	return resp, nil
+}
+
+func (a *apiServer) PauseWorkflow(
+	ctx context.Context, req *apiv1.PauseWorkflowRequest,
+) (*apiv1.PauseWorkflowResponse, error) {
+	if err := a.changeWorkflow(ctx, req.Id, workflow.DefaultManager.Pause); err != nil {
+		return nil, err
+	}
+	return &apiv1.PauseWorkflowResponse{}, nil
+}
+
+func (a *apiServer) ResumeWorkflow(
+	ctx context.Context, req *apiv1.ResumeWorkflowRequest,
+) (*apiv1.ResumeWorkflowResponse, error) {
+	if err := a.changeWorkflow(ctx, req.Id, workflow.DefaultManager.Resume); err != nil {
+		return nil, err
+	}
+	return &apiv1.ResumeWorkflowResponse{}, nil
+}
+
+func (a *apiServer) KillWorkflow(
+	ctx context.Context, req *apiv1.KillWorkflowRequest,
+) (*apiv1.KillWorkflowResponse, error) {
+	if err := a.changeWorkflow(ctx, req.Id, workflow.DefaultManager.Kill); err != nil {
+		return nil, err
+	}
+	return &apiv1.KillWorkflowResponse{}, nil
+}
+
+func (a *apiServer) getWorkflowAndCheckCanView(
+	ctx context.Context, id int32,
+) (*workflow.Workflow, error) {
+	errNotFound := api.NotFoundErrs("workflow", fmt.Sprint(id), true)
+	w, err := workflow.ByID(ctx, int(id))
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, errNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if _, _, err := a.getProjectAndCheckCanDoActions(ctx, int32(w.ProjectID)); err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// changeWorkflow applies a change to a workflow that the current user may create generic tasks
+// in the project of.
+func (a *apiServer) changeWorkflow(
+	ctx context.Context, id int32, change func(context.Context, int) error,
+) error {
+	w, err := a.getWorkflowAndCheckCanView(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := a.canCreateGenericTask(ctx, w.ProjectID); err != nil {
+		return err
+	}
+	switch err := change(ctx, w.ID); {
+	case errors.Is(err, workflow.ErrInvalidState):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return errors.Wrapf(err, "error changing workflow %d", id)
+	}
+	return nil
+}
+
+func (a *apiServer) PostTaskMetadata(
+	ctx context.Context, req *apiv1.PostTaskMetadataRequest,
+) (*apiv1.PostTaskMetadataResponse, error) {
+	workspaceID, err := a.canDoActionsOnGenericTask(ctx, model.TaskID(req.TaskId))
+	if err != nil {
+		return nil, err
+	}
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := command.AuthZProvider.Get().CanCreateGenericTask(
+		ctx, *curUser, workspaceID); err != nil {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	metadata, err := db.MergeTaskMetadata(ctx, model.TaskID(req.TaskId), req.Metadata.AsMap())
+	switch {
+	case errors.Is(err, db.ErrInvalidInput):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, err
+	}
+	pb, err := structpb.NewStruct(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.PostTaskMetadataResponse{Metadata: pb}, nil
+}
+
+func (a *apiServer) GetTaskMetadata(
+	ctx context.Context, req *apiv1.GetTaskMetadataRequest,
+) (*apiv1.GetTaskMetadataResponse, error) {
+	if _, err := a.canDoActionsOnGenericTask(ctx, model.TaskID(req.TaskId)); err != nil {
+		return nil, err
+	}
+	metadata, err := db.GetTaskMetadata(ctx, model.TaskID(req.TaskId))
+	if err != nil {
+		return nil, err
+	}
+	pb, err := structpb.NewStruct(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &apiv1.GetTaskMetadataResponse{Metadata: pb}, nil
+}
+
+// canDoActionsOnGenericTask checks that the current user can view a generic task and returns
+// its workspace.
+func (a *apiServer) canDoActionsOnGenericTask(
+	ctx context.Context, taskID model.TaskID,
+) (model.AccessScopeID, error) {
+	workspaceID, _, err := a.canDoActionsOnTask(ctx, taskID)
+	if err != nil {
+		return 0, err
+	}
+	t, err := db.TaskByID(ctx, taskID)
+	if err != nil {
+		return 0, err
+	}
+	if t.TaskType != model.TaskTypeGeneric {
+		return 0, status.Errorf(codes.InvalidArgument,
+			"task %s is not a generic task, only generic tasks have metadata", taskID)
+	}
+	return *workspaceID, nil
+}
+
+// workflowTasks launches and controls the tasks of the nodes of workflows as generic tasks.
+type workflowTasks struct {
+	a *apiServer
+}
+
+func (t *workflowTasks) Launch(
+	ctx context.Context, w *workflow.Workflow, node workflow.NodeSpec, contextDirectory []byte,
+	env map[string]string,
+) (model.TaskID, error) {
+	fullOwner, err := user.ByID(ctx, w.OwnerID)
+	if err != nil {
+		return "", fmt.Errorf("getting owner of workflow %d: %w", w.ID, err)
+	}
+	if !fullOwner.Active {
+		return "", fmt.Errorf("the owner of workflow %d is deactivated", w.ID)
+	}
+	owner := fullOwner.ToUser()
+	files, err := archiveToFiles(contextDirectory)
+	if err != nil {
+		return "", fmt.Errorf("decompressing context directory of workflow %d: %w", w.ID, err)
+	}
+	// Nodes are launched as the owner of the workflow, who may not be the current user.
+	resp, err := t.a.createGenericTask(grpcutil.WithUser(ctx, &owner),
+		&apiv1.CreateGenericTaskRequest{
+			ContextDirectory: files,
+			Config:           node.Config,
+			ProjectId:        ptrs.Ptr(int32(w.ProjectID)),
+		}, env)
+	if err != nil {
+		return "", err
+	}
+	return model.TaskID(resp.TaskId), nil
+}
+
+func (t *workflowTasks) Kill(ctx context.Context, taskID model.TaskID) error {
+	task, err := db.TaskByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if task.State != nil && *task.State == model.TaskStatePaused {
+		// A paused task has no allocation to kill.
+		return db.KillGenericTask(taskID, time.Now().UTC())
+	}
+	_, err = t.a.KillGenericTask(ctx, &apiv1.KillGenericTaskRequest{TaskId: string(taskID)})
+	return err
+}
+
+func (t *workflowTasks) Pause(ctx context.Context, taskID model.TaskID) error {
+	_, err := t.a.PauseGenericTask(ctx, &apiv1.PauseGenericTaskRequest{TaskId: string(taskID)})
+	return err
+}
+
+func (t *workflowTasks) Unpause(ctx context.Context, taskID model.TaskID) error {
+	_, err := t.a.UnpauseGenericTask(ctx, &apiv1.UnpauseGenericTaskRequest{TaskId: string(taskID)})
+	return err
+}
+
+// archiveToFiles is the inverse of filesToArchive for a gzipped tarball.
+func archiveToFiles(tgz []byte) ([]*utilv1.File, error) {
+	if len(tgz) == 0 {
+		return nil, nil
+	}
+	items, err := archive.FromTarGz(tgz)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*utilv1.File, 0, len(items))
+	for _, item := range items {
+		files = append(files, &utilv1.File{
+			Path:    item.Path,
+			Type:    int32(item.Type),
+			Content: item.Content,
+			Mtime:   item.ModifiedTime.Unix(),
+			Mode:    int32(item.FileMode),
+			Uid:     int32(item.UserID),
+			Gid:     int32(item.GroupID),
+		})
+	}
+	return files, nil
+}
@@ -942,7 +942,7 @@ func TestDeleteWorkspace(t *testing.T) {
 	api, _, ctx := setupAPITest(t, nil, mockRM)
 	api.m.allRms = map[string]rm.ResourceManager{noName: mockRM}
 	// set up command service - required for successful DeleteWorkspaceRequest calls
-	cs, err := command.NewService(api.m.db, api.m.rm)
+	cs, err := command.NewService(api.m.db, api.m.rm, nil)
 	require.NoError(t, err)
 	command.SetDefaultService(cs)
 	// create workspace with namespace binding
@@ -98,6 +98,43 @@ func runCheckpointGCTask(
 		return nil
 	}
 
+	gcSpec := tasks.GCCkptSpec{
+		ExperimentID:       expID,
+		LegacyConfig:       legacyConfig,
+		ToDelete:           deleteCheckpointsStr,
+		CheckpointGlobs:    checkpointGlobs,
+		DeleteTensorboards: deleteTensorboards,
+	}
+
+	// Update checkpoint storage with storageID.
+	if storageID != nil {
+		checkpointStorage, err := storage.Backend(context.TODO(), *storageID)
+		if err != nil {
+			return fmt.Errorf("getting storage id %d in create gc task: %w", *storageID, err)
+		}
+		gcSpec.LegacyConfig.CheckpointStorage = checkpointStorage
+	}
+
+	return runGCTask(
+		rm, pgDB, taskID, jobID, jobSubmissionTime, taskSpec, gcSpec,
+		fmt.Sprintf("Checkpoint GC (Experiment %d)", expID), agentUserGroup, owner, logCtx,
+	)
+}
+
+// runGCTask runs a task that deletes files from checkpoint storage, and waits for it to exit.
+func runGCTask(
+	rm rm.ResourceManager,
+	pgDB *db.PgDB,
+	taskID model.TaskID,
+	jobID model.JobID,
+	jobSubmissionTime time.Time,
+	taskSpec tasks.TaskSpec,
+	gcSpec tasks.GCCkptSpec,
+	name string,
+	agentUserGroup *model.AgentUserGroup,
+	owner *model.User,
+	logCtx logger.Context,
+) error {
 	rp, err := rm.ResolveResourcePool("", -1, 0)
 	if err != nil {
 		return fmt.Errorf("resolving resource pool: %w", err)
@@ -120,24 +157,7 @@ func runCheckpointGCTask(
 	taskSpec.UserSessionToken = userSessionToken
 	taskSpec.AgentUserGroup = agentUserGroup
 	taskSpec.Owner = owner
-
-	gcSpec := tasks.GCCkptSpec{
-		Base:               taskSpec,
-		ExperimentID:       expID,
-		LegacyConfig:       legacyConfig,
-		ToDelete:           deleteCheckpointsStr,
-		CheckpointGlobs:    checkpointGlobs,
-		DeleteTensorboards: deleteTensorboards,
-	}
-
-	// Update checkpoint storage with storageID.
-	if storageID != nil {
-		checkpointStorage, err := storage.Backend(context.TODO(), *storageID)
-		if err != nil {
-			return fmt.Errorf("getting storage id %d in create gc task: %w", *storageID, err)
-		}
-		gcSpec.LegacyConfig.CheckpointStorage = checkpointStorage
-	}
+	gcSpec.Base = taskSpec
 
 	logCtx = logger.MergeContexts(logCtx, logger.Context{
 		"task-id":   taskID,
@@ -177,7 +197,7 @@ func runCheckpointGCTask(
 		JobID:             gcJobID,
 		JobSubmissionTime: jobSubmissionTime,
 		AllocationID:      allocationID,
-		Name:              fmt.Sprintf("Checkpoint GC (Experiment %d)", expID),
+		Name:              name,
 		FittingRequirements: sproto.FittingRequirements{
 			SingleAgent: true,
 		},
@@ -188,3 +208,56 @@ func runCheckpointGCTask(
 	}
 	return <-resultChan
 }
+
+// gcNotebookSnapshot deletes the working directory snapshot of a notebook that has stopped for
+// good from the checkpoint storage it was saved to.
+func (m *Master) gcNotebookSnapshot(
+	taskID model.TaskID, spec tasks.GenericCommandSpec, storageID string,
+) {
+	syslog := logrus.WithField("component", "checkpointgc").WithField("notebook-id", taskID)
+	if spec.SnapshotStorage == nil {
+		syslog.Warnf("not deleting snapshot %s from unknown checkpoint storage", storageID)
+		return
+	}
+	snapshotID, err := uuid.Parse(storageID)
+	if err != nil {
+		syslog.WithError(err).Errorf("not deleting invalid snapshot %s", storageID)
+		return
+	}
+
+	jobID := model.NewJobID()
+	if err := db.AddJob(&model.Job{
+		JobID:   jobID,
+		JobType: model.JobTypeCheckpointGC,
+		OwnerID: &spec.Base.Owner.ID,
+	}); err != nil {
+		syslog.WithError(err).Error("persisting snapshot GC job")
+		return
+	}
+
+	vars := spec.Config.Environment.EnvironmentVariables
+	gcSpec := tasks.GCCkptSpec{
+		LegacyConfig: expconf.LegacyConfig{
+			CheckpointStorage: *spec.SnapshotStorage,
+			Environment: expconf.EnvironmentConfig{
+				RawEnvironmentVariables: &expconf.EnvironmentVariablesMap{
+					RawCPU:  vars.CPU,
+					RawCUDA: vars.CUDA,
+					RawROCM: vars.ROCM,
+				},
+			},
+		},
+		ToDelete:        snapshotID.String(),
+		CheckpointGlobs: []string{fullDeleteGlob},
+		Untracked:       true,
+	}
+	go func() {
+		if err := runGCTask(
+			m.rm, m.db, model.NewTaskID(), jobID, time.Now().UTC(), *m.taskSpec, gcSpec,
+			fmt.Sprintf("Snapshot GC (Notebook %s)", taskID), spec.Base.AgentUserGroup,
+			spec.Base.Owner, logger.Context{"notebook-id": taskID},
+		); err != nil {
+			syslog.WithError(err).Errorf("deleting snapshot %s", snapshotID)
+		}
+	}()
+}
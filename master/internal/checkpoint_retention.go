@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
+
+	"github.com/determined-ai/determined/master/internal/checkpoints"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/internal/workspace"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// scheduleCheckpointRetention enforces the checkpoint retention policies on a schedule, which is a
+// time duration or a cron expression.
+func (m *Master) scheduleCheckpointRetention(schedule string) (gocron.Scheduler, error) {
+	s, err := gocron.NewScheduler(gocron.WithLimitConcurrentJobs(1, gocron.LimitModeReschedule))
+	if err != nil {
+		return nil, err
+	}
+	def := gocron.CronJob(schedule, false)
+	if d, err := time.ParseDuration(schedule); err == nil {
+		def = gocron.DurationJob(d)
+	}
+	if _, err := s.NewJob(def, gocron.NewTask(func() {
+		if err := m.enforceCheckpointRetention(context.Background()); err != nil {
+			log.WithError(err).Error("failed to enforce checkpoint retention policies")
+		}
+	})); err != nil {
+		return nil, fmt.Errorf("scheduling checkpoint retention: %w", err)
+	}
+	s.Start()
+	return s, nil
+}
+
+// enforceCheckpointRetention deletes the checkpoints selected by every enabled retention policy,
+// through the same GC tasks as deleting checkpoints by hand. It returns once they finish.
+func (m *Master) enforceCheckpointRetention(ctx context.Context) error {
+	policies, err := checkpoints.RetentionPolicies(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	byExperiment := map[int]map[uuid.UUID]bool{}
+	for _, p := range policies {
+		if !p.Enabled {
+			continue
+		}
+		candidates, err := checkpoints.RetentionCandidates(ctx, &p)
+		if err != nil {
+			return err
+		}
+		for _, c := range candidates {
+			if byExperiment[c.ExperimentID] == nil {
+				byExperiment[c.ExperimentID] = map[uuid.UUID]bool{}
+			}
+			byExperiment[c.ExperimentID][c.UUID] = true
+		}
+	}
+
+	expIDs := maps.Keys(byExperiment)
+	slices.Sort(expIDs)
+	for _, expID := range expIDs {
+		toDelete := maps.Keys(byExperiment[expID])
+		log.WithField("experiment-id", expID).
+			Infof("deleting %d checkpoints under checkpoint retention policies", len(toDelete))
+		if err := m.gcExperimentCheckpoints(ctx, expID, toDelete); err != nil {
+			log.WithError(err).WithField("experiment-id", expID).
+				Error("failed to delete checkpoints under checkpoint retention policies")
+		}
+	}
+	return nil
+}
+
+// gcExperimentCheckpoints deletes checkpoints of an experiment on behalf of its owner.
+func (m *Master) gcExperimentCheckpoints(ctx context.Context, expID int, toDelete []uuid.UUID) error {
+	exp, err := db.ExperimentByID(ctx, expID)
+	if err != nil {
+		return err
+	}
+	workspaceIDs, err := workspace.WorkspacesIDsByExperimentIDs(ctx, []int{expID})
+	if err != nil {
+		return err
+	}
+	agentUserGroup, err := user.GetAgentUserGroup(ctx, *exp.OwnerID, workspaceIDs[0])
+	if err != nil {
+		return err
+	}
+	owner, err := user.ByID(ctx, *exp.OwnerID)
+	if err != nil {
+		return fmt.Errorf("cannot find user %v who owns experiment: %w", *exp.OwnerID, err)
+	}
+
+	taskSpec := *m.taskSpec
+	return runCheckpointGCForCheckpoints(
+		m.rm, m.db, exp.JobID, exp.StartTime,
+		&taskSpec, exp.ID, exp.Config, toDelete,
+		[]string{fullDeleteGlob}, false, agentUserGroup,
+		&model.User{ID: owner.ID, Username: owner.Username}, nil,
+	)
+}
@@ -0,0 +1,198 @@
+package checkpoints
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/checkpointv1"
+)
+
+// RetentionPolicy corresponds to a row in the "checkpoint_retention_policies" DB table. It deletes
+// the checkpoints of finished experiments that match every rule that is set.
+type RetentionPolicy struct {
+	bun.BaseModel `bun:"table:checkpoint_retention_policies"`
+
+	ID int `bun:"id,pk,autoincrement"`
+	// WorkspaceID is nil for policies that apply to the whole cluster.
+	WorkspaceID        *int          `bun:"workspace_id"`
+	MaxAgeDays         *int          `bun:"max_age_days"`
+	KeepBestPerProject *int          `bun:"keep_best_per_project"`
+	MetricName         *string       `bun:"metric_name"`
+	SmallerIsBetter    *bool         `bun:"smaller_is_better"`
+	Enabled            bool          `bun:"enabled,notnull"`
+	CreatedBy          *model.UserID `bun:"created_by"`
+	CreatedAt          time.Time     `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// Validate checks that the policy has at least one rule and that its rules are in range.
+func (p *RetentionPolicy) Validate() error {
+	if p.MaxAgeDays == nil && p.KeepBestPerProject == nil {
+		return errors.New("a retention policy needs max_age_days or keep_best_per_project")
+	}
+	if p.MaxAgeDays != nil && *p.MaxAgeDays <= 0 {
+		return fmt.Errorf("max_age_days must be positive, got %d", *p.MaxAgeDays)
+	}
+	if p.KeepBestPerProject != nil && *p.KeepBestPerProject < 0 {
+		return fmt.Errorf("keep_best_per_project must not be negative, got %d", *p.KeepBestPerProject)
+	}
+	if p.MetricName != nil && *p.MetricName == "" {
+		return errors.New("metric_name must not be empty")
+	}
+	return nil
+}
+
+// Proto converts a retention policy to its protobuf representation.
+func (p *RetentionPolicy) Proto() *checkpointv1.RetentionPolicy {
+	pb := &checkpointv1.RetentionPolicy{
+		Id:              int32(p.ID),
+		MetricName:      p.MetricName,
+		SmallerIsBetter: p.SmallerIsBetter,
+		Enabled:         p.Enabled,
+		CreatedAt:       timestamppb.New(p.CreatedAt),
+	}
+	if p.WorkspaceID != nil {
+		pb.WorkspaceId = ptrs.Ptr(int32(*p.WorkspaceID))
+	}
+	if p.MaxAgeDays != nil {
+		pb.MaxAgeDays = ptrs.Ptr(int32(*p.MaxAgeDays))
+	}
+	if p.KeepBestPerProject != nil {
+		pb.KeepBestPerProject = ptrs.Ptr(int32(*p.KeepBestPerProject))
+	}
+	return pb
+}
+
+// RetentionCandidate is a checkpoint a retention policy would delete.
+type RetentionCandidate struct {
+	UUID         uuid.UUID `bun:"uuid"`
+	ExperimentID int       `bun:"experiment_id"`
+	ProjectID    int       `bun:"project_id"`
+	ReportTime   time.Time `bun:"report_time"`
+	Size         int64     `bun:"size"`
+}
+
+// Proto converts a retention candidate to its protobuf representation.
+func (c *RetentionCandidate) Proto() *checkpointv1.RetentionCandidate {
+	return &checkpointv1.RetentionCandidate{
+		Uuid:         c.UUID.String(),
+		ExperimentId: int32(c.ExperimentID),
+		ProjectId:    int32(c.ProjectID),
+		ReportTime:   timestamppb.New(c.ReportTime),
+		Size:         c.Size,
+	}
+}
+
+// AddRetentionPolicy persists a new retention policy.
+func AddRetentionPolicy(ctx context.Context, p *RetentionPolicy) error {
+	if _, err := db.Bun().NewInsert().Model(p).Returning("*").Exec(ctx); err != nil {
+		return fmt.Errorf("adding checkpoint retention policy: %w", err)
+	}
+	return nil
+}
+
+// RetentionPolicyByID returns a retention policy, or db.ErrNotFound.
+func RetentionPolicyByID(ctx context.Context, id int) (*RetentionPolicy, error) {
+	var p RetentionPolicy
+	if err := db.Bun().NewSelect().Model(&p).Where("id = ?", id).Scan(ctx); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return &p, nil
+}
+
+// RetentionPolicies returns the retention policies of a workspace, or of every workspace and the
+// cluster if workspaceID is nil.
+func RetentionPolicies(ctx context.Context, workspaceID *int) ([]RetentionPolicy, error) {
+	policies := []RetentionPolicy{}
+	q := db.Bun().NewSelect().Model(&policies).Order("id")
+	if workspaceID != nil {
+		q.Where("workspace_id = ?", *workspaceID)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, fmt.Errorf("getting checkpoint retention policies: %w", err)
+	}
+	return policies, nil
+}
+
+// SetRetentionPolicyEnabled enables or disables a retention policy.
+func SetRetentionPolicyEnabled(ctx context.Context, id int, enabled bool) (*RetentionPolicy, error) {
+	var p RetentionPolicy
+	if err := db.Bun().NewUpdate().Model(&p).
+		Set("enabled = ?", enabled).
+		Where("id = ?", id).
+		Returning("*").
+		Scan(ctx); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return &p, nil
+}
+
+// DeleteRetentionPolicy deletes a retention policy.
+func DeleteRetentionPolicy(ctx context.Context, id int) error {
+	res, err := db.Bun().NewDelete().Model((*RetentionPolicy)(nil)).Where("id = ?", id).Exec(ctx)
+	return db.MustHaveAffectedRows(res, err)
+}
+
+// RetentionCandidates returns the checkpoints a retention policy would delete, oldest first. Only
+// completed checkpoints of finished experiments are considered, and checkpoints registered as model
+// versions or that trials warm start from are never candidates. When ranking the checkpoints of a
+// project, those without a value for the metric rank last and are candidates. Without a metric
+// name, experiments use their own searcher settings, so checkpoints are only ranked against those
+// of experiments of the project with the same searcher metric and smaller_is_better.
+func RetentionCandidates(ctx context.Context, p *RetentionPolicy) ([]RetentionCandidate, error) {
+	candidates := []RetentionCandidate{}
+	if err := db.Bun().NewRaw(`
+WITH ranked AS (
+	SELECT c.uuid, c.report_time, c.size, e.id AS experiment_id, e.project_id, mv.value,
+		rank() OVER (
+			PARTITION BY e.project_id, m.metric, m.sign
+			ORDER BY m.sign * mv.value ASC NULLS LAST, c.id ASC
+		) AS project_rank
+	FROM checkpoints_v2 c
+	JOIN run_id_task_id rt ON c.task_id = rt.task_id
+	JOIN trials t ON rt.run_id = t.id
+	JOIN experiments e ON t.experiment_id = e.id
+	JOIN projects p ON e.project_id = p.id
+	LEFT JOIN validations v ON v.trial_id = t.id
+		AND v.total_batches = (c.metadata->>'steps_completed')::int
+	CROSS JOIN LATERAL (
+		SELECT coalesce(?::text, e.config->'searcher'->>'metric') AS metric,
+			(CASE
+				WHEN coalesce(?::boolean, (e.config->'searcher'->>'smaller_is_better')::boolean, true)
+				THEN 1
+				ELSE -1
+			END) AS sign
+	) m
+	CROSS JOIN LATERAL (
+		SELECT (v.metrics->'validation_metrics'->>m.metric)::float8 AS value
+	) mv
+	WHERE c.state IN (?)
+		AND e.state IN (?)
+		AND (?::int IS NULL OR p.workspace_id = ?::int)
+		AND NOT EXISTS (SELECT 1 FROM trials ws WHERE ws.warm_start_checkpoint_id = c.id)
+		AND NOT EXISTS (SELECT 1 FROM model_versions mv WHERE mv.checkpoint_uuid = c.uuid)
+)
+SELECT uuid, experiment_id, project_id, report_time, coalesce(size, 0) AS size
+FROM ranked
+WHERE (?::int IS NULL OR report_time < now() - make_interval(days => ?::int))
+	AND (?::int IS NULL OR value IS NULL OR project_rank > ?::int)
+ORDER BY report_time, uuid`,
+		p.MetricName, p.SmallerIsBetter,
+		bun.In([]model.State{model.CompletedState, model.PartiallyDeletedState}),
+		bun.In([]model.State{model.CanceledState, model.CompletedState, model.ErrorState}),
+		p.WorkspaceID, p.WorkspaceID,
+		p.MaxAgeDays, p.MaxAgeDays,
+		p.KeepBestPerProject, p.KeepBestPerProject,
+	).Scan(ctx, &candidates); err != nil {
+		return nil, fmt.Errorf("getting checkpoints to delete under retention policy %d: %w", p.ID, err)
+	}
+	return candidates, nil
+}
@@ -0,0 +1,153 @@
+//go:build integration
+// +build integration
+
+package checkpoints
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/commonv1"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+func TestRetentionCandidates(t *testing.T) {
+	ctx := context.Background()
+	pgDB := db.SingleDB()
+	user := db.RequireMockUser(t, pgDB)
+	workspaceID, _ := db.RequireMockWorkspaceID(t, pgDB, "")
+	projectID, _ := db.RequireMockProjectID(t, pgDB, workspaceID, false)
+
+	addCheckpoint := func(exp *model.Experiment, steps int, age time.Duration, okness *float64) uuid.UUID {
+		tr, task := db.RequireMockTrial(t, pgDB, exp)
+		allocation := db.RequireMockAllocation(t, pgDB, task.TaskID)
+		ckpt := db.MockModelCheckpoint(uuid.New(), allocation, db.WithSteps(steps))
+		ckpt.ReportTime = time.Now().UTC().Add(-age)
+		require.NoError(t, db.AddCheckpointMetadata(ctx, &ckpt, tr.ID))
+		if okness != nil {
+			metrics, err := structpb.NewStruct(map[string]any{"okness": *okness})
+			require.NoError(t, err)
+			require.NoError(t, pgDB.AddValidationMetrics(ctx, &trialv1.TrialMetrics{
+				TrialId:        int32(tr.ID),
+				StepsCompleted: ptrs.Ptr(int32(steps)),
+				Metrics:        &commonv1.Metrics{AvgMetrics: metrics},
+			}))
+		}
+		return ckpt.UUID
+	}
+
+	const day = 24 * time.Hour
+	finished := db.RequireMockExperimentParams(t, pgDB, user, db.MockExperimentParams{
+		State: ptrs.Ptr(model.CompletedState),
+	}, projectID)
+	oldWorse := addCheckpoint(finished, 1, 40*day, ptrs.Ptr(0.5))
+	oldBest := addCheckpoint(finished, 2, 40*day, ptrs.Ptr(0.1))
+	recent := addCheckpoint(finished, 3, day, ptrs.Ptr(0.9))
+
+	// The checkpoints of active experiments are never candidates.
+	active := db.RequireMockExperimentProject(t, pgDB, user, projectID)
+	addCheckpoint(active, 1, 40*day, nil)
+
+	candidates := func(p RetentionPolicy) []uuid.UUID {
+		p.WorkspaceID = &workspaceID
+		require.NoError(t, p.Validate())
+		cs, err := RetentionCandidates(ctx, &p)
+		require.NoError(t, err)
+		var ids []uuid.UUID
+		for _, c := range cs {
+			require.Equal(t, finished.ID, c.ExperimentID)
+			require.Equal(t, projectID, c.ProjectID)
+			ids = append(ids, c.UUID)
+		}
+		return ids
+	}
+
+	require.ElementsMatch(t, []uuid.UUID{oldWorse, oldBest}, candidates(RetentionPolicy{
+		MaxAgeDays: ptrs.Ptr(30),
+	}))
+	require.ElementsMatch(t, []uuid.UUID{oldWorse, recent}, candidates(RetentionPolicy{
+		KeepBestPerProject: ptrs.Ptr(1),
+	}))
+	require.ElementsMatch(t, []uuid.UUID{oldWorse}, candidates(RetentionPolicy{
+		MaxAgeDays:         ptrs.Ptr(30),
+		KeepBestPerProject: ptrs.Ptr(1),
+	}))
+	require.ElementsMatch(t, []uuid.UUID{oldWorse, oldBest}, candidates(RetentionPolicy{
+		KeepBestPerProject: ptrs.Ptr(1),
+		MetricName:         ptrs.Ptr("okness"),
+		SmallerIsBetter:    ptrs.Ptr(false),
+	}))
+
+	// Registered checkpoints are never candidates.
+	pmdl, err := db.InsertModel(ctx, uuid.NewString(), "", emptyMetadata, "", "", user.ID, workspaceID)
+	require.NoError(t, err)
+	_, err = db.InsertModelVersion(ctx, pmdl.Id, oldWorse.String(), "registered", "",
+		emptyMetadata, "", "", user.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uuid.UUID{oldBest}, candidates(RetentionPolicy{
+		MaxAgeDays: ptrs.Ptr(30),
+	}))
+}
+
+func TestRetentionCandidatesMixedSearcherMetrics(t *testing.T) {
+	ctx := context.Background()
+	pgDB := db.SingleDB()
+	user := db.RequireMockUser(t, pgDB)
+	workspaceID, _ := db.RequireMockWorkspaceID(t, pgDB, "")
+	projectID, _ := db.RequireMockProjectID(t, pgDB, workspaceID, false)
+
+	addCheckpoint := func(exp *model.Experiment, steps int, metrics map[string]any) uuid.UUID {
+		tr, task := db.RequireMockTrial(t, pgDB, exp)
+		allocation := db.RequireMockAllocation(t, pgDB, task.TaskID)
+		ckpt := db.MockModelCheckpoint(uuid.New(), allocation, db.WithSteps(steps))
+		require.NoError(t, db.AddCheckpointMetadata(ctx, &ckpt, tr.ID))
+		avgMetrics, err := structpb.NewStruct(metrics)
+		require.NoError(t, err)
+		require.NoError(t, pgDB.AddValidationMetrics(ctx, &trialv1.TrialMetrics{
+			TrialId:        int32(tr.ID),
+			StepsCompleted: ptrs.Ptr(int32(steps)),
+			Metrics:        &commonv1.Metrics{AvgMetrics: avgMetrics},
+		}))
+		return ckpt.UUID
+	}
+	finishedExperiment := func(metric string, smallerIsBetter bool) *model.Experiment {
+		exp := db.RequireMockExperimentParams(t, pgDB, user, db.MockExperimentParams{
+			State: ptrs.Ptr(model.CompletedState),
+		}, projectID)
+		_, err := db.Bun().NewUpdate().Table("experiments").
+			Set("config = jsonb_set(jsonb_set(config, '{searcher,metric}', to_jsonb(?::text)), "+
+				"'{searcher,smaller_is_better}', to_jsonb(?::boolean))", metric, smallerIsBetter).
+			Where("id = ?", exp.ID).
+			Exec(ctx)
+		require.NoError(t, err)
+		return exp
+	}
+
+	// Losses and accuracies are not comparable, so each searcher metric keeps its own best.
+	byLoss := finishedExperiment("loss", true)
+	bestLoss := addCheckpoint(byLoss, 1, map[string]any{"loss": 0.5})
+	worseLoss := addCheckpoint(byLoss, 2, map[string]any{"loss": 0.9})
+	byAccuracy := finishedExperiment("accuracy", false)
+	bestAccuracy := addCheckpoint(byAccuracy, 1, map[string]any{"accuracy": 0.2})
+	worseAccuracy := addCheckpoint(byAccuracy, 2, map[string]any{"accuracy": 0.1})
+
+	p := RetentionPolicy{WorkspaceID: &workspaceID, KeepBestPerProject: ptrs.Ptr(1)}
+	require.NoError(t, p.Validate())
+	cs, err := RetentionCandidates(ctx, &p)
+	require.NoError(t, err)
+	var ids []uuid.UUID
+	for _, c := range cs {
+		ids = append(ids, c.UUID)
+	}
+	require.ElementsMatch(t, []uuid.UUID{worseLoss, worseAccuracy}, ids)
+	require.NotContains(t, ids, bestLoss)
+	require.NotContains(t, ids, bestAccuracy)
+}
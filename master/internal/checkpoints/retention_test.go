@@ -0,0 +1,18 @@
+package checkpoints
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	require.Error(t, (&RetentionPolicy{}).Validate())
+	require.NoError(t, (&RetentionPolicy{MaxAgeDays: ptrs.Ptr(30)}).Validate())
+	require.NoError(t, (&RetentionPolicy{KeepBestPerProject: ptrs.Ptr(0)}).Validate())
+	require.Error(t, (&RetentionPolicy{MaxAgeDays: ptrs.Ptr(0)}).Validate())
+	require.Error(t, (&RetentionPolicy{KeepBestPerProject: ptrs.Ptr(-1)}).Validate())
+	require.Error(t, (&RetentionPolicy{MaxAgeDays: ptrs.Ptr(1), MetricName: ptrs.Ptr("")}).Validate())
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
 	"github.com/determined-ai/determined/master/pkg/tasks"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/commandv1"
@@ -44,26 +45,45 @@ var queueStates = []model.AllocationState{
 	model.AllocationStateAssigned,
 }
 
+// SnapshotEnvVar is the environment variable of the snapshot a resumed command restores.
+const SnapshotEnvVar = "DET_NOTEBOOK_SNAPSHOT"
+
+var (
+	// ErrNotSuspendable is returned when suspending a command launched without suspension enabled.
+	ErrNotSuspendable = errors.New("suspension was not enabled when the notebook was launched")
+	// ErrNotRunning is returned when suspending a command that is not running.
+	ErrNotRunning = errors.New("only running notebooks can be suspended")
+	// ErrNotSuspended is returned when resuming a command that is not suspended.
+	ErrNotSuspended = errors.New("only suspended notebooks can be resumed")
+)
+
 // Command is executed in a containerized environment on a Determined cluster.
 // Locking in: Start, OnExit, DeleteIfInWorkspace, ToV1Command/Shell/Notebook/Tensorboard.
 type Command struct {
 	mu sync.Mutex
 
-	db *internaldb.PgDB
-	rm rm.ResourceManager
+	db         *internaldb.PgDB
+	rm         rm.ResourceManager
+	gcSnapshot SnapshotGC
 
 	tasks.GenericCommandSpec
 
-	registeredTime   time.Time
-	taskID           model.TaskID
-	taskType         model.TaskType
-	jobType          model.JobType
-	jobID            model.JobID
-	allocationID     model.AllocationID
-	lastState        task.AllocationState
-	exitStatus       *task.AllocationExited
-	restored         bool
-	contextDirectory []byte // Don't rely on this being set outsides of PreStart non restore case.
+	registeredTime time.Time
+	taskID         model.TaskID
+	taskType       model.TaskType
+	jobType        model.JobType
+	jobID          model.JobID
+	allocationID   model.AllocationID
+	lastState      task.AllocationState
+	exitStatus     *task.AllocationExited
+	restored       bool
+	// suspendedTime is set while the command is suspended, without an allocation.
+	suspendedTime *time.Time
+	// snapshotStorageID is the latest snapshot of the working directory, taken by the allocation
+	// snapshotAllocationID.
+	snapshotStorageID    *string
+	snapshotAllocationID *model.AllocationID
+	contextDirectory     []byte // Don't rely on this being set outsides of PreStart non restore case.
 
 	logCtx logger.Context
 	syslog *logrus.Entry
@@ -78,6 +98,7 @@ type CreateGeneric struct {
 func commandFromSnapshot(
 	db *internaldb.PgDB,
 	rm rm.ResourceManager,
+	gcSnapshot SnapshotGC,
 	snapshot *CommandSnapshot,
 ) (*Command, error) {
 	taskID := snapshot.TaskID
@@ -91,17 +112,22 @@ func commandFromSnapshot(
 	}
 
 	cmd := &Command{
-		db:                 db,
-		rm:                 rm,
-		registeredTime:     snapshot.RegisteredTime,
-		GenericCommandSpec: snapshot.GenericCommandSpec,
-		taskID:             taskID,
-		taskType:           taskType,
-		jobType:            snapshot.Task.Job.JobType,
-		jobID:              jobID,
-		restored:           true,
-		logCtx:             logCtx,
-		syslog:             logrus.WithFields(logrus.Fields{"component": "command"}).WithFields(logCtx.Fields()),
+		db:                   db,
+		rm:                   rm,
+		gcSnapshot:           gcSnapshot,
+		registeredTime:       snapshot.RegisteredTime,
+		GenericCommandSpec:   snapshot.GenericCommandSpec,
+		taskID:               taskID,
+		taskType:             taskType,
+		jobType:              snapshot.Task.Job.JobType,
+		jobID:                jobID,
+		allocationID:         snapshot.AllocationID,
+		restored:             true,
+		suspendedTime:        snapshot.SuspendedTime,
+		snapshotStorageID:    snapshot.SnapshotStorageID,
+		snapshotAllocationID: snapshot.SnapshotAllocationID,
+		logCtx:               logCtx,
+		syslog:               logrus.WithFields(logrus.Fields{"component": "command"}).WithFields(logCtx.Fields()),
 	}
 	return cmd, cmd.Start(context.TODO())
 }
@@ -117,7 +143,9 @@ func (c *Command) Start(ctx context.Context) error {
 	if err := tasklist.GroupPriorityChangeRegistry.Add(c.jobID, priorityChange); err != nil {
 		return err
 	}
-	c.allocationID = model.AllocationID(fmt.Sprintf("%s.%d", c.taskID, 1))
+	if c.allocationID == "" {
+		c.allocationID = model.AllocationID(fmt.Sprintf("%s.%d", c.taskID, 1))
+	}
 
 	if !c.restored {
 		if err := internaldb.Bun().RunInTx(ctx, nil, c.registerJobAndTask); err != nil {
@@ -147,6 +175,24 @@ func (c *Command) Start(ctx context.Context) error {
 		}
 	}
 
+	// Suspended commands wait to be resumed for a new allocation.
+	if c.suspendedTime == nil {
+		if err := c.startAllocation(c.restored); err != nil {
+			return err
+		}
+	}
+
+	// Once the command is persisted to the dbs & allocation starts, register it with the local job service.
+	jobservice.DefaultService.RegisterJob(c.jobID, c)
+
+	if err := c.persist(); err != nil {
+		c.syslog.WithError(err).Warnf("command persist failure")
+	}
+	return nil
+}
+
+// startAllocation starts the command's current allocation, or restores it after a master restart.
+func (c *Command) startAllocation(restore bool) error {
 	var idleWatcherConfig *sproto.IdleTimeoutConfig
 	if c.Config.IdleTimeout != nil && (c.WatchProxyIdleTimeout || c.WatchRunnerIdleTimeout) {
 		idleWatcherConfig = &sproto.IdleTimeoutConfig{
@@ -155,10 +201,20 @@ func (c *Command) Start(ctx context.Context) error {
 			UseRunnerState:  c.WatchRunnerIdleTimeout,
 			TimeoutDuration: time.Duration(*c.Config.IdleTimeout),
 			Debug:           c.Config.Debug,
+			Suspend: c.SnapshotTimeout != nil &&
+				c.Config.NotebookIdleAction == model.NotebookIdleActionSuspend,
 		}
 	}
 
-	err := task.DefaultService.StartAllocation(c.logCtx,
+	var preemption sproto.PreemptionConfig
+	if c.SnapshotTimeout != nil {
+		preemption = sproto.PreemptionConfig{
+			Suspendable:     true,
+			TimeoutDuration: time.Duration(*c.SnapshotTimeout),
+		}
+	}
+
+	return task.DefaultService.StartAllocation(c.logCtx,
 		sproto.AllocateRequest{
 			AllocationID:        c.allocationID,
 			TaskID:              c.taskID,
@@ -166,25 +222,18 @@ func (c *Command) Start(ctx context.Context) error {
 			JobSubmissionTime:   c.registeredTime,
 			IsUserVisible:       true,
 			Name:                c.Config.Description,
+			Workspace:           c.Base.Workspace,
+			Username:            c.Base.Owner.Username,
 			SlotsNeeded:         c.Config.Resources.Slots,
 			ResourcePool:        c.Config.Resources.ResourcePool,
 			FittingRequirements: sproto.FittingRequirements{SingleAgent: true},
+			CapacityType:        c.Config.Resources.CapacityType,
 			ProxyPorts:          sproto.NewProxyPortConfig(c.GenericCommandSpec.ProxyPorts(), c.taskID),
+			Preemption:          preemption,
 			IdleTimeout:         idleWatcherConfig,
-			Restore:             c.restored,
+			Restore:             restore,
 			ProxyTLS:            c.TaskType == model.TaskTypeNotebook,
 		}, c.db, c.rm, c.GenericCommandSpec, c.OnExit)
-	if err != nil {
-		return err
-	}
-
-	// Once the command is persisted to the dbs & allocation starts, register it with the local job service.
-	jobservice.DefaultService.RegisterJob(c.jobID, c)
-
-	if err := c.persist(); err != nil {
-		c.syslog.WithError(err).Warnf("command persist failure")
-	}
-	return nil
 }
 
 // registerJobAndTask registers the command with the job service & adds the command to the job & task dbs.
@@ -223,10 +272,13 @@ func (c *Command) persistAndEvictContextDirectoryFromMemory() error {
 
 func (c *Command) persist() error {
 	snapshot := &CommandSnapshot{
-		TaskID:             c.taskID,
-		RegisteredTime:     c.registeredTime,
-		AllocationID:       c.allocationID,
-		GenericCommandSpec: c.GenericCommandSpec,
+		TaskID:               c.taskID,
+		RegisteredTime:       c.registeredTime,
+		AllocationID:         c.allocationID,
+		GenericCommandSpec:   c.GenericCommandSpec,
+		SuspendedTime:        c.suspendedTime,
+		SnapshotStorageID:    c.snapshotStorageID,
+		SnapshotAllocationID: c.snapshotAllocationID,
 	}
 	_, err := internaldb.Bun().NewInsert().Model(snapshot).
 		On("CONFLICT (task_id) DO UPDATE").
@@ -234,7 +286,8 @@ func (c *Command) persist() error {
 	return err
 }
 
-// OnExit runs when an command's allocation exits. It marks the command task as complete, and unregisters where needed.
+// OnExit runs when an command's allocation exits. It marks the command task as complete, unless
+// the allocation suspended it, and unregisters where needed.
 // OnExit locks ahead of gc -> unregisterCommand.
 func (c *Command) OnExit(ae *task.AllocationExited) {
 	c.mu.Lock()
@@ -242,6 +295,21 @@ func (c *Command) OnExit(ae *task.AllocationExited) {
 
 	c.exitStatus = ae
 
+	// Allocations that snapshot their working directory before exiting were suspended.
+	if c.snapshotAllocationID != nil && *c.snapshotAllocationID == c.allocationID {
+		c.suspendedTime = ptrs.Ptr(time.Now().UTC())
+		if err := c.persist(); err != nil {
+			c.syslog.WithError(err).Error("persisting suspended command")
+		}
+		c.syslog.Infof("suspended with snapshot %s", *c.snapshotStorageID)
+		return
+	}
+	c.complete()
+}
+
+// complete marks the command task as complete and schedules it, and its latest snapshot, for
+// garbage collection.
+func (c *Command) complete() {
 	if err := internaldb.CompleteTask(context.TODO(), c.taskID, time.Now().UTC()); err != nil {
 		c.syslog.WithError(err).Error("marking task complete")
 	}
@@ -255,6 +323,9 @@ func (c *Command) OnExit(ae *task.AllocationExited) {
 				"failure to delete notebook session for task: %v", c.taskID)
 		}
 	}
+	if c.snapshotStorageID != nil && c.gcSnapshot != nil {
+		c.gcSnapshot(c.taskID, c.GenericCommandSpec, *c.snapshotStorageID)
+	}
 
 	go func() {
 		time.Sleep(terminatedDuration)
@@ -314,6 +385,10 @@ func (c *Command) DeleteIfInWorkspace(req *apiv1.DeleteWorkspaceRequest) {
 	defer c.mu.Unlock()
 
 	if c.Metadata.WorkspaceID == model.AccessScopeID(req.Id) {
+		if c.suspendedTime != nil {
+			c.stopSuspended("workspace deleted while suspended")
+			return
+		}
 		err := task.DefaultService.Signal(
 			c.allocationID,
 			task.KillAllocation,
@@ -325,6 +400,107 @@ func (c *Command) DeleteIfInWorkspace(req *apiv1.DeleteWorkspaceRequest) {
 	}
 }
 
+// suspend asks the command to snapshot its working directory and exit, releasing its resources.
+func (c *Command) suspend() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.SnapshotTimeout == nil:
+		return ErrNotSuspendable
+	case c.suspendedTime != nil, c.refreshAllocationState().State != model.AllocationStateRunning:
+		return ErrNotRunning
+	}
+	return task.DefaultService.Signal(c.allocationID, task.SuspendAllocation, "user requested suspend")
+}
+
+// resume starts a new allocation for a suspended command, which restores its latest snapshot.
+func (c *Command) resume(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.suspendedTime == nil {
+		return ErrNotSuspended
+	}
+	specifier, err := c.allocationID.GetAllocationSpecifier()
+	if err != nil {
+		return err
+	}
+	allocationID := model.AllocationID(fmt.Sprintf("%s.%d", c.taskID, specifier+1))
+	err = task.InsertNTSCAllocationWorkspaceRecord(
+		ctx,
+		allocationID,
+		int(c.Metadata.WorkspaceID),
+		c.Base.Workspace,
+	)
+	if err != nil {
+		return fmt.Errorf(
+			"failure while attempting to persist workspace information for NTSC task (%s) allocation: %w",
+			c.taskID,
+			err)
+	}
+
+	c.allocationID = allocationID
+	if c.Base.ExtraEnvVars == nil {
+		c.Base.ExtraEnvVars = map[string]string{}
+	}
+	c.Base.ExtraEnvVars[SnapshotEnvVar] = *c.snapshotStorageID
+	if err := c.startAllocation(false); err != nil {
+		return err
+	}
+	c.suspendedTime = nil
+	c.exitStatus = nil
+	if err := c.persist(); err != nil {
+		c.syslog.WithError(err).Warnf("command persist failure")
+	}
+	return nil
+}
+
+// reportSnapshot records a snapshot of the working directory of the command's current
+// allocation, and stops the allocation now that it is suspended.
+func (c *Command) reportSnapshot(storageID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.SnapshotTimeout == nil:
+		return ErrNotSuspendable
+	case c.suspendedTime != nil, c.exitStatus != nil:
+		return ErrNotRunning
+	}
+	c.snapshotStorageID = &storageID
+	c.snapshotAllocationID = &c.allocationID
+	if err := c.persist(); err != nil {
+		return fmt.Errorf("persisting snapshot: %w", err)
+	}
+	return task.DefaultService.Signal(c.allocationID, task.KillAllocation, "notebook suspended")
+}
+
+// stopIfSuspended stops the command for good if it is suspended, returning whether it was.
+func (c *Command) stopIfSuspended(reason string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.suspendedTime == nil {
+		return false
+	}
+	c.stopSuspended(reason)
+	return true
+}
+
+// stopSuspended stops a suspended command for good, without resuming it.
+func (c *Command) stopSuspended(reason string) {
+	c.suspendedTime = nil
+	c.exitStatus = &task.AllocationExited{
+		Err:        errors.New(reason),
+		FinalState: task.AllocationState{State: model.AllocationStateTerminated},
+	}
+	if err := c.persist(); err != nil {
+		c.syslog.WithError(err).Warnf("command persist failure")
+	}
+	c.complete()
+}
+
 // ToV1Command takes a *Command from the command service registry & returns a *commandv1.Command.
 func (c *Command) ToV1Command() *commandv1.Command {
 	c.mu.Lock()
@@ -368,6 +544,7 @@ func (c *Command) ToV1Notebook() *notebookv1.Notebook {
 		ExitStatus:     c.exitStatus.String(),
 		JobId:          c.jobID.String(),
 		WorkspaceId:    int32(c.GenericCommandSpec.Metadata.WorkspaceID),
+		Suspended:      c.suspendedTime != nil,
 	}
 }
 
@@ -434,6 +611,10 @@ func (c *Command) refreshAllocationState() task.AllocationState {
 	if c.exitStatus != nil {
 		return c.exitStatus.FinalState
 	}
+	if c.suspendedTime != nil {
+		// Commands restored while suspended have no allocation, or exit status, yet.
+		return task.AllocationState{State: model.AllocationStateTerminated}
+	}
 
 	state, err := task.DefaultService.State(c.allocationID)
 	if err != nil {
@@ -4,8 +4,10 @@
 package command
 
 import (
+	"context"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/determined-ai/determined/master/internal/user"
 
@@ -18,6 +20,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/mocks"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
 	"github.com/determined-ai/determined/master/pkg/syncx/queue"
 	"github.com/determined-ai/determined/master/pkg/tasks"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
@@ -111,6 +114,65 @@ func TestNotebookManagerLifecycle(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestNotebookSuspension(t *testing.T) {
+	pgDB := setupTest(t)
+	user.InitService(pgDB, &model.ExternalSessions{})
+
+	// Notebooks launched without suspension enabled can't be suspended.
+	nb := launchNotebook(t, pgDB)
+	_, err := DefaultCmdService.SuspendNotebook(nb.Id)
+	require.ErrorIs(t, err, ErrNotSuspendable)
+
+	var deleted []string
+	DefaultCmdService.gcSnapshot = func(_ model.TaskID, _ tasks.GenericCommandSpec, id string) {
+		deleted = append(deleted, id)
+	}
+	defer func() { DefaultCmdService.gcSnapshot = nil }()
+
+	mockReq := CreateMockGenericReq(t, pgDB)
+	mockReq.Spec.SnapshotTimeout = ptrs.Ptr(model.Duration(time.Minute))
+	cmd, err := DefaultCmdService.LaunchNotebookCommand(mockReq, mockReq.Spec.Base.Owner)
+	require.NoError(t, err)
+	id := cmd.ToV1Notebook().Id
+
+	// Only running notebooks can be suspended, or resumed once suspended.
+	_, err = DefaultCmdService.SuspendNotebook(id)
+	require.ErrorIs(t, err, ErrNotRunning)
+	_, err = DefaultCmdService.ResumeNotebook(context.Background(), id)
+	require.ErrorIs(t, err, ErrNotSuspended)
+
+	// Reporting a snapshot stops the notebook, which suspends it.
+	require.NoError(t, DefaultCmdService.ReportNotebookSnapshot(id, "snapshot"))
+	require.Eventually(t, func() bool {
+		return cmd.ToV1Notebook().Suspended
+	}, 10*time.Second, 10*time.Millisecond)
+	completed, err := db.TaskCompleted(context.Background(), model.TaskID(id))
+	require.NoError(t, err)
+	require.False(t, completed)
+
+	// Resuming the notebook restores the snapshot in a new allocation of the same task.
+	resumed, err := DefaultCmdService.ResumeNotebook(context.Background(), id)
+	require.NoError(t, err)
+	require.False(t, resumed.ToV1Notebook().Suspended)
+	require.Equal(t, model.AllocationID(id+".2"), resumed.allocationID)
+	require.Equal(t, "snapshot", resumed.Base.ExtraEnvVars[SnapshotEnvVar])
+
+	require.Empty(t, deleted)
+
+	// Killing a suspended notebook completes it, and deletes its snapshot.
+	require.NoError(t, DefaultCmdService.ReportNotebookSnapshot(id, "snapshot-2"))
+	require.Eventually(t, func() bool {
+		return cmd.ToV1Notebook().Suspended
+	}, 10*time.Second, 10*time.Millisecond)
+	killed, err := DefaultCmdService.KillNTSC(id, model.TaskTypeNotebook)
+	require.NoError(t, err)
+	require.False(t, killed.ToV1Notebook().Suspended)
+	completed, err = db.TaskCompleted(context.Background(), model.TaskID(id))
+	require.NoError(t, err)
+	require.True(t, completed)
+	require.Equal(t, []string{"snapshot-2"}, deleted)
+}
+
 func TestShellManagerLifecycle(t *testing.T) {
 	db := setupTest(t)
 
@@ -188,7 +250,7 @@ func setupTest(t *testing.T) *db.PgDB {
 	mockRM.On("SetGroupPriority", mock.Anything, mock.Anything).Return(nil)
 	mockRM.On("SmallerValueIsHigherPriority").Return(true, nil)
 
-	cs, _ := NewService(db.SingleDB(), &mockRM)
+	cs, _ := NewService(db.SingleDB(), &mockRM, nil)
 	SetDefaultService(cs)
 
 	jobservice.SetDefaultService(&mockRM)
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
 
 	"github.com/determined-ai/determined/master/internal/api"
 	"github.com/determined-ai/determined/master/internal/db"
@@ -15,28 +16,37 @@ import (
 	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/master/pkg/tasks"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 )
 
 // DefaultCmdService is the global command service singleton.
 var DefaultCmdService *CommandService
 
+// SnapshotGC deletes a snapshot of the working directory of a command that has stopped for good
+// from the checkpoint storage it was saved to. It must not block.
+type SnapshotGC func(taskID model.TaskID, spec tasks.GenericCommandSpec, storageID string)
+
 // CommandService tracks the different NTSC commands in the system.
 type CommandService struct {
-	db       *db.PgDB
-	rm       rm.ResourceManager
-	mu       sync.Mutex
-	commands map[model.TaskID]*Command
-	syslog   *logrus.Entry
+	db         *db.PgDB
+	rm         rm.ResourceManager
+	gcSnapshot SnapshotGC
+	mu         sync.Mutex
+	commands   map[model.TaskID]*Command
+	syslog     *logrus.Entry
 }
 
 // NewService returns a new CommandService.
-func NewService(db *db.PgDB, rm rm.ResourceManager) (*CommandService, error) {
+func NewService(
+	db *db.PgDB, rm rm.ResourceManager, gcSnapshot SnapshotGC,
+) (*CommandService, error) {
 	return &CommandService{
-		db:       db,
-		rm:       rm,
-		commands: make(map[model.TaskID]*Command),
-		syslog:   logrus.WithField("component", "command-service"),
+		db:         db,
+		rm:         rm,
+		gcSnapshot: gcSnapshot,
+		commands:   make(map[model.TaskID]*Command),
+		syslog:     logrus.WithField("component", "command-service"),
 	}, nil
 }
 
@@ -51,7 +61,8 @@ func SetDefaultService(cs *CommandService) {
 	DefaultCmdService = cs
 }
 
-// RestoreAllCommands restores all terminated commands whose end time isn't set.
+// RestoreAllCommands restores all terminated commands whose end time isn't set, and all suspended
+// commands.
 func (cs *CommandService) RestoreAllCommands(
 	ctx context.Context,
 ) error {
@@ -63,8 +74,15 @@ func (cs *CommandService) RestoreAllCommands(
 		Relation("Allocation").
 		Relation("Task").
 		Relation("Task.Job").
-		Where("allocation.end_time IS NULL").
-		Where("allocation.state != ?", model.AllocationStateTerminated).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.Where("allocation.end_time IS NULL").
+					Where("allocation.state != ?", model.AllocationStateTerminated)
+			}).WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.Where("command_snapshot.suspended_time IS NOT NULL").
+					Where("task.end_time IS NULL")
+			})
+		}).
 		Where("task.task_id = command_snapshot.task_id").
 		Where("command_snapshot.generic_task_spec IS NULL").
 		Scan(ctx)
@@ -74,7 +92,7 @@ func (cs *CommandService) RestoreAllCommands(
 	}
 
 	for i := range snapshots {
-		cmd, err := commandFromSnapshot(cs.db, cs.rm, &snapshots[i])
+		cmd, err := commandFromSnapshot(cs.db, cs.rm, cs.gcSnapshot, &snapshots[i])
 		if err != nil {
 			cs.syslog.Errorf("failed to restore from snapshot: %s", err)
 			continue
@@ -106,8 +124,9 @@ func (cs *CommandService) LaunchGenericCommand(
 	}
 
 	cmd := &Command{
-		db: cs.db,
-		rm: cs.rm,
+		db:         cs.db,
+		rm:         cs.rm,
+		gcSnapshot: cs.gcSnapshot,
 
 		GenericCommandSpec: *req.Spec,
 
@@ -155,8 +174,9 @@ func (cs *CommandService) LaunchNotebookCommand(
 	}
 	req.Spec.Base.ExtraEnvVars[model.NotebookSessionEnvVar] = token
 	cmd := &Command{
-		db: cs.db,
-		rm: cs.rm,
+		db:         cs.db,
+		rm:         cs.rm,
+		gcSnapshot: cs.gcSnapshot,
 
 		GenericCommandSpec: *req.Spec,
 
@@ -278,6 +298,10 @@ func (cs *CommandService) KillNTSC(id string, taskType model.TaskType) (*Command
 		return nil, err
 	}
 
+	if c.stopIfSuspended("user requested kill") {
+		return c, nil
+	}
+
 	completed, err := db.TaskCompleted(context.TODO(), tID)
 	if err != nil {
 		return nil, err
@@ -293,6 +317,50 @@ func (cs *CommandService) KillNTSC(id string, taskType model.TaskType) (*Command
 	return c, nil
 }
 
+// SuspendNotebook asks a notebook to snapshot its working directory and release its resources.
+func (cs *CommandService) SuspendNotebook(id string) (*Command, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	c, err := cs.getNTSC(model.TaskID(id), model.TaskTypeNotebook)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.suspend(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ResumeNotebook starts a new allocation for a suspended notebook, which restores its working
+// directory.
+func (cs *CommandService) ResumeNotebook(ctx context.Context, id string) (*Command, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	c, err := cs.getNTSC(model.TaskID(id), model.TaskTypeNotebook)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.resume(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ReportNotebookSnapshot records the snapshot of a suspending notebook's working directory, which
+// completes its suspension.
+func (cs *CommandService) ReportNotebookSnapshot(id string, storageID string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	c, err := cs.getNTSC(model.TaskID(id), model.TaskTypeNotebook)
+	if err != nil {
+		return err
+	}
+	return c.reportSnapshot(storageID)
+}
+
 // GetCommands returns all commands in the command service registry matching the workspace ID.
 func (cs *CommandService) GetCommands(req *apiv1.GetCommandsRequest) (*apiv1.GetCommandsResponse, error) {
 	cs.mu.Lock()
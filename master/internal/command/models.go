@@ -26,6 +26,13 @@ type CommandSnapshot struct {
 	// GenericTaskSpec
 	GenericTaskSpec *tasks.GenericTaskSpec `bun:"generic_task_spec"`
 
+	// SuspendedTime is when the command was suspended, if it is suspended.
+	SuspendedTime *time.Time `bun:"suspended_time"`
+	// SnapshotStorageID is the checkpoint storage id of the latest snapshot of the command's
+	// working directory, taken by the allocation SnapshotAllocationID.
+	SnapshotStorageID    *string             `bun:"snapshot_storage_id"`
+	SnapshotAllocationID *model.AllocationID `bun:"snapshot_allocation_id"`
+
 	// Relations
 	Task       model.Task       `bun:"rel:belongs-to,join:task_id=task_id"`
 	Allocation model.Allocation `bun:"rel:belongs-to,join:allocation_id=allocation_id"`
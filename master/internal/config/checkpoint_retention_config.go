@@ -0,0 +1,32 @@
+package config
+
+import (
+	"errors"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultCheckpointRetentionSchedule is how often checkpoint retention policies are enforced by
+// default.
+const DefaultCheckpointRetentionSchedule = "24h"
+
+// CheckpointRetentionConfig configures the enforcement of checkpoint retention policies.
+type CheckpointRetentionConfig struct {
+	// Schedule is a time duration or cron expression interval to enforce policies on.
+	Schedule *string `json:"schedule"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c CheckpointRetentionConfig) Validate() []error {
+	if c.Schedule == nil {
+		return nil
+	}
+	if _, err := time.ParseDuration(*c.Schedule); err == nil {
+		return nil
+	}
+	if _, err := cron.ParseStandard(*c.Schedule); err != nil {
+		return []error{errors.New("checkpoint retention schedule must be a valid duration or cron expression")}
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/config"
 	"github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
 	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
 )
 
@@ -98,6 +99,22 @@ type DBConfig struct {
 type WebhooksConfig struct {
 	BaseURL    string `json:"base_url"`
 	SigningKey string `json:"signing_key"`
+	// SMTP enables email notifications when set.
+	SMTP *SMTPConfig `json:"smtp"`
+	// MaxConsecutiveFailures is how many events in a row a webhook can fail to deliver before it
+	// is disabled. Zero never disables webhooks.
+	MaxConsecutiveFailures int `json:"max_consecutive_failures"`
+}
+
+// DefaultWebhookMaxConsecutiveFailures is the default of webhooks.max_consecutive_failures.
+const DefaultWebhookMaxConsecutiveFailures = 10
+
+// Validate implements the check.Validatable interface.
+func (w WebhooksConfig) Validate() []error {
+	if w.MaxConsecutiveFailures < 0 {
+		return []error{errors.New("webhooks max_consecutive_failures must not be negative")}
+	}
+	return nil
 }
 
 // IntegrationsConfig stores configs related to integrations like pachyderm.
@@ -119,6 +136,9 @@ func DefaultConfig() *Config {
 		DB:                    *DefaultDBConfig(),
 		TaskContainerDefaults: *model.DefaultTaskContainerDefaults(),
 		TensorBoardTimeout:    5 * 60,
+		NotebookSuspension: NotebookSuspensionConfig{
+			Timeout: model.Duration(DefaultNotebookSuspensionTimeout),
+		},
 		Security: SecurityConfig{
 			DefaultTask: model.AgentUserGroup{
 				UID:   0,
@@ -128,6 +148,9 @@ func DefaultConfig() *Config {
 			},
 			SSH: SSHConfig{
 				KeyType: KeyTypeED25519,
+				CertificateAuthority: SSHCertificateAuthorityConfig{
+					CertificateLifespan: model.Duration(time.Hour),
+				},
 			},
 			AuthZ: *DefaultAuthZConfig(),
 			Token: TokenConfig{
@@ -163,6 +186,15 @@ func DefaultConfig() *Config {
 			SCIMAuthenticationAttribute: "userName",
 			AutoProvisionUsers:          false,
 		},
+		Webhooks: WebhooksConfig{
+			MaxConsecutiveFailures: DefaultWebhookMaxConsecutiveFailures,
+		},
+		HighAvailability: HighAvailabilityConfig{
+			LeaseDuration: DefaultLeaseDuration,
+		},
+		CheckpointRetention: CheckpointRetentionConfig{
+			Schedule: ptrs.Ptr(DefaultCheckpointRetentionSchedule),
+		},
 	}
 }
 
@@ -176,6 +208,7 @@ type Config struct {
 	DB                    DBConfig                          `json:"db"`
 	TensorBoardTimeout    int                               `json:"tensorboard_timeout"`
 	NotebookTimeout       *int                              `json:"notebook_timeout"`
+	NotebookSuspension    NotebookSuspensionConfig          `json:"notebook_suspension"`
 	Security              SecurityConfig                    `json:"security"`
 	CheckpointStorage     expconf.CheckpointStorageConfig   `json:"checkpoint_storage"`
 	TaskContainerDefaults model.TaskContainerDefaultsConfig `json:"task_container_defaults"`
@@ -188,9 +221,11 @@ type Config struct {
 	UICustomization       UICustomizationConfig             `json:"ui_customization"`
 	Logging               model.LoggingConfig               `json:"logging"`
 	RetentionPolicy       model.LogRetentionPolicy          `json:"retention_policy"`
+	CheckpointRetention   CheckpointRetentionConfig         `json:"checkpoint_retention"`
 	Observability         ObservabilityConfig               `json:"observability"`
 	Cache                 CacheConfig                       `json:"cache"`
 	Webhooks              WebhooksConfig                    `json:"webhooks"`
+	HighAvailability      HighAvailabilityConfig            `json:"high_availability"`
 	FeatureSwitches       []string                          `json:"feature_switches"`
 	ReservedPorts         []int                             `json:"reserved_ports"`
 	ResourceConfig
@@ -247,6 +282,21 @@ func (c Config) Printable() ([]byte, error) {
 	if configCopy.Telemetry.SegmentWebUIKey != "" {
 		configCopy.Telemetry.SegmentWebUIKey = hiddenValue
 	}
+	if configCopy.Webhooks.SMTP != nil && configCopy.Webhooks.SMTP.Password != "" {
+		printable := *configCopy.Webhooks.SMTP
+		printable.Password = hiddenValue
+		configCopy.Webhooks.SMTP = &printable
+	}
+	if loki := configCopy.Logging.LokiLoggingConfig; loki != nil {
+		printable := *loki
+		if printable.Security.Password != nil {
+			printable.Security.Password = ptrs.Ptr(hiddenValue)
+		}
+		if printable.Security.BearerToken != nil {
+			printable.Security.BearerToken = ptrs.Ptr(hiddenValue)
+		}
+		configCopy.Logging.LokiLoggingConfig = &printable
+	}
 	if configCopy.TaskContainerDefaults.RegistryAuth != nil {
 		if configCopy.TaskContainerDefaults.RegistryAuth.Password != "" {
 			// RegistryAuth is a pointer, so if we need to hide the password we need to be very
@@ -463,6 +513,7 @@ func (c *Config) Deprecations() (errs []error) {
 			if len(rm.PbsRM.Name) > 0 {
 				errs = append(errs, fmt.Errorf(nameDeprecatedWarning, rm.PbsRM.ClusterName))
 			}
+		case rm.SlurmRM != nil:
 		default:
 			panic(fmt.Sprintf("unknown rm type %+v", r))
 		}
@@ -529,8 +580,45 @@ func (t *TokenConfig) Validate() []error {
 
 // SSHConfig is the configuration setting for SSH.
 type SSHConfig struct {
-	RsaKeySize int    `json:"rsa_key_size"`
-	KeyType    string `json:"key_type"`
+	RsaKeySize           int                           `json:"rsa_key_size"`
+	KeyType              string                        `json:"key_type"`
+	CertificateAuthority SSHCertificateAuthorityConfig `json:"certificate_authority"`
+}
+
+// SSHCertificateAuthorityConfig configures the master to act as an SSH certificate authority,
+// signing short-lived user certificates for shells instead of handing out their private keys.
+type SSHCertificateAuthorityConfig struct {
+	Enabled             bool           `json:"enabled"`
+	CertificateLifespan model.Duration `json:"certificate_lifespan"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c SSHCertificateAuthorityConfig) Validate() []error {
+	var errs []error
+	if c.CertificateLifespan <= 0 {
+		errs = append(errs, errors.New("SSH certificate lifespan must be greater than 0"))
+	}
+	return errs
+}
+
+// DefaultNotebookSuspensionTimeout is how long a suspending notebook has to save its working
+// directory by default.
+const DefaultNotebookSuspensionTimeout = 10 * time.Minute
+
+// NotebookSuspensionConfig configures suspending notebooks, which saves their working directories
+// to checkpoint storage and releases their resources until they are resumed.
+type NotebookSuspensionConfig struct {
+	Enabled bool           `json:"enabled"`
+	Timeout model.Duration `json:"timeout"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c NotebookSuspensionConfig) Validate() []error {
+	var errs []error
+	if c.Timeout <= 0 {
+		errs = append(errs, errors.New("notebook suspension timeout must be greater than 0"))
+	}
+	return errs
 }
 
 // TLSConfig is the configuration for setting up serving over TLS.
@@ -636,6 +724,7 @@ func (i *InternalConfig) Validate() []error {
 // Defaulted to true.
 type ObservabilityConfig struct {
 	EnablePrometheus bool `json:"enable_prometheus"`
+	ProxyAccessLog   bool `json:"proxy_access_log"`
 }
 
 func readPriorityFromScheduler(conf *SchedulerConfig) *int {
@@ -689,7 +778,8 @@ func readRMPreemptionStatus(config *ResourceManagerWithPoolsConfig, rpName strin
 		return config.ResourceManager.AgentRM.Scheduler.GetPreemption()
 	case config.ResourceManager.KubernetesRM != nil,
 		config.ResourceManager.DispatcherRM != nil,
-		config.ResourceManager.PbsRM != nil:
+		config.ResourceManager.PbsRM != nil,
+		config.ResourceManager.SlurmRM != nil:
 		// KubernetesRM priority scheduler with preemption is deprecated as of 0.36.0.
 		return false
 	default:
@@ -583,6 +583,7 @@ func TestPrintableConfig(t *testing.T) {
 	registryAuthSecret := "i_love_cellos"
 	startupScriptSecret := "my_startup_script_secret"
 	containerStartupScriptSecret := "my_container_startup_secret"
+	smtpSecret := "my_smtp_secret"
 
 	raw := fmt.Sprintf(`
 db:
@@ -602,6 +603,13 @@ telemetry:
   segment_master_key: %v
   segment_webui_key: %v
 
+webhooks:
+  smtp:
+    host: smtp.example.com
+    username: determined
+    password: %v
+    from: determined@example.com
+
 task_container_defaults:
   registry_auth:
     username: yo-yo-ma
@@ -623,7 +631,7 @@ additional_resource_managers:
           type: gcp
           startup_script: %v
           container_startup_script: %v
-`, s3Key, s3Secret, masterSecret, webuiSecret, registryAuthSecret, startupScriptSecret,
+`, s3Key, s3Secret, masterSecret, webuiSecret, smtpSecret, registryAuthSecret, startupScriptSecret,
 		containerStartupScriptSecret, startupScriptSecret, containerStartupScriptSecret)
 
 	provConfig := provconfig.DefaultConfig()
@@ -650,6 +658,14 @@ additional_resource_managers:
 			SegmentMasterKey: masterSecret,
 			SegmentWebUIKey:  webuiSecret,
 		},
+		Webhooks: WebhooksConfig{
+			SMTP: &SMTPConfig{
+				Host:     "smtp.example.com",
+				Username: "determined",
+				Password: smtpSecret,
+				From:     "determined@example.com",
+			},
+		},
 		TaskContainerDefaults: model.TaskContainerDefaultsConfig{
 			RegistryAuth: &registry.AuthConfig{
 				Username: "yo-yo-ma",
@@ -702,6 +718,7 @@ additional_resource_managers:
 	assert.Assert(t, !bytes.Contains(printable, []byte(s3Secret)))
 	assert.Assert(t, !bytes.Contains(printable, []byte(masterSecret)))
 	assert.Assert(t, !bytes.Contains(printable, []byte(webuiSecret)))
+	assert.Assert(t, !bytes.Contains(printable, []byte(smtpSecret)))
 	assert.Assert(t, !bytes.Contains(printable, []byte(registryAuthSecret)))
 	assert.Assert(t, !bytes.Contains(printable, []byte(startupScriptSecret)))
 	assert.Assert(t, !bytes.Contains(printable, []byte(containerStartupScriptSecret)))
@@ -88,21 +88,21 @@ func (c DispatcherResourceManagerConfig) Validate() []error {
 		}
 	}
 
-	return c.validateJobProjectSource()
+	return validateJobProjectSource(c.JobProjectSource)
 }
 
-func (c DispatcherResourceManagerConfig) validateJobProjectSource() []error {
+func validateJobProjectSource(source *string) []error {
 	switch {
-	case c.JobProjectSource == nil:
-	case *c.JobProjectSource == Project:
-	case *c.JobProjectSource == Workspace:
-	case *c.JobProjectSource == Label:
-	case strings.HasPrefix(*c.JobProjectSource, LabelPrefix):
+	case source == nil:
+	case *source == Project:
+	case *source == Workspace:
+	case *source == Label:
+	case strings.HasPrefix(*source, LabelPrefix):
 	default:
 		return []error{fmt.Errorf(
 			"invalid job_project_source value: '%s'. "+
 				"Specify one of project, workspace or label[:value]",
-			*c.JobProjectSource)}
+			*source)}
 	}
 	return nil
 }
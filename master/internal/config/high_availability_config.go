@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// DefaultLeaseDuration is how long a leader holds its lease without renewing it by default.
+const DefaultLeaseDuration = model.Duration(15 * time.Second)
+
+// HighAvailabilityConfig configures running several masters against one database, one of which
+// is elected leader while the others wait as standbys.
+type HighAvailabilityConfig struct {
+	Enabled bool `json:"enabled"`
+	// LeaseDuration is how long a leader that stops renewing its lease keeps it, and so how long
+	// a failover takes at most.
+	LeaseDuration model.Duration `json:"lease_duration"`
+	// AdvertisedAddress is the URL standbys forward requests to while this master is the leader.
+	AdvertisedAddress string `json:"advertised_address"`
+}
+
+// Validate implements the check.Validatable interface.
+func (c HighAvailabilityConfig) Validate() []error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	if time.Duration(c.LeaseDuration) < time.Second {
+		errs = append(errs, errors.New("high_availability.lease_duration must be at least 1s"))
+	}
+	if c.AdvertisedAddress == "" {
+		errs = append(errs, errors.New("high_availability.advertised_address must be set"))
+	} else if u, err := url.Parse(c.AdvertisedAddress); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, errors.New("high_availability.advertised_address must be a URL like http://host:port"))
+	}
+	return errs
+}
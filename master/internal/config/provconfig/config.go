@@ -12,6 +12,7 @@ import (
 	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
 	"github.com/determined-ai/determined/master/pkg/union"
 	"github.com/determined-ai/determined/master/version"
 )
@@ -28,19 +29,21 @@ type Config struct {
 	AgentDockerRuntime     string `json:"agent_docker_runtime"`
 	AgentDockerImage       string `json:"agent_docker_image"`
 	// deprecated, no longer in use.
-	AgentFluentImage        string            `json:"agent_fluent_image"`
-	AgentReconnectAttempts  int               `json:"agent_reconnect_attempts"`
-	AgentReconnectBackoff   int               `json:"agent_reconnect_backoff"`
-	AgentConfigFileContents json.RawMessage   `json:"agent_config_file_contents"`
-	AWS                     *AWSClusterConfig `union:"type,aws" json:"-"`
-	GCP                     *GCPClusterConfig `union:"type,gcp" json:"-"`
-	HPC                     *HpcClusterConfig `union:"type,hpc" json:"-"`
-	MaxIdleAgentPeriod      model.Duration    `json:"max_idle_agent_period"`
-	MaxAgentStartingPeriod  model.Duration    `json:"max_agent_starting_period"`
-	MinInstances            int               `json:"min_instances"`
-	MaxInstances            int               `json:"max_instances"`
-	LaunchErrorTimeout      *model.Duration   `json:"launch_error_timeout"`
-	LaunchErrorRetries      int               `json:"launch_error_retries"`
+	AgentFluentImage        string                 `json:"agent_fluent_image"`
+	AgentReconnectAttempts  int                    `json:"agent_reconnect_attempts"`
+	AgentReconnectBackoff   int                    `json:"agent_reconnect_backoff"`
+	AgentConfigFileContents json.RawMessage        `json:"agent_config_file_contents"`
+	AWS                     *AWSClusterConfig      `union:"type,aws" json:"-"`
+	GCP                     *GCPClusterConfig      `union:"type,gcp" json:"-"`
+	HPC                     *HpcClusterConfig      `union:"type,hpc" json:"-"`
+	External                *ExternalClusterConfig `union:"type,external" json:"-"`
+	MaxIdleAgentPeriod      model.Duration         `json:"max_idle_agent_period"`
+	MaxAgentStartingPeriod  model.Duration         `json:"max_agent_starting_period"`
+	MinInstances            int                    `json:"min_instances"`
+	MaxInstances            int                    `json:"max_instances"`
+	ScalingSchedules        []ScalingSchedule      `json:"scaling_schedules"`
+	LaunchErrorTimeout      *model.Duration        `json:"launch_error_timeout"`
+	LaunchErrorRetries      int                    `json:"launch_error_retries"`
 }
 
 // HpcClusterConfig describes the configuration for a HPC cluster managed by Determined.
@@ -96,8 +99,8 @@ func (c Config) Validate() []error {
 		masterURLErr,
 		check.NotEmpty(c.AgentDockerImage, "must configure an agent docker image"),
 		check.False(c.AWS != nil && c.GCP != nil, "must configure only one cluster"),
-		check.False(c.AWS == nil && c.GCP == nil && c.HPC == nil,
-			"must configure aws or gcp or hpc cluster"),
+		check.False(c.AWS == nil && c.GCP == nil && c.HPC == nil && c.External == nil,
+			"must configure aws, gcp, hpc or external cluster"),
 		check.GreaterThan(
 			int64(c.MaxIdleAgentPeriod), int64(0), "max idle agent period must be greater than 0"),
 		check.GreaterThan(
@@ -111,6 +114,19 @@ func (c Config) Validate() []error {
 	return errs
 }
 
+// CapacityType returns the kind of capacity that the provisioner launches, or an empty capacity
+// type if the master cannot tell.
+func (c Config) CapacityType() model.CapacityType {
+	switch {
+	case c.AWS != nil && c.AWS.SpotEnabled, c.GCP != nil && c.GCP.InstanceType.Preemptible:
+		return model.CapacityTypeSpot
+	case c.AWS != nil, c.GCP != nil:
+		return model.CapacityTypeOnDemand
+	default:
+		return ""
+	}
+}
+
 func (c Config) mustParseMasterURL() url.URL {
 	masterURL, err := url.Parse(c.MasterURL)
 	if err != nil {
@@ -163,6 +179,11 @@ func (c Config) Printable() Config {
 	if len(c.ContainerStartupScript) > 0 {
 		c.ContainerStartupScript = hiddenValue
 	}
+	if c.External != nil && c.External.BearerToken != nil {
+		external := *c.External
+		external.BearerToken = ptrs.Ptr(hiddenValue)
+		c.External = &external
+	}
 
 	return c
 }
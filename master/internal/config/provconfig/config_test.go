@@ -13,6 +13,7 @@ import (
 
 	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/device"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/version"
 )
@@ -22,7 +23,7 @@ func TestProvisionerConfigMissingFields(t *testing.T) {
 	err := json.Unmarshal([]byte(`{}`), &config)
 	assert.NilError(t, err)
 	err = check.Validate(&config)
-	require.ErrorContains(t, err, "must configure aws or gcp or hpc cluster")
+	require.ErrorContains(t, err, "must configure aws, gcp, hpc or external cluster")
 	expected := Config{
 		MaxIdleAgentPeriod:     model.Duration(20 * time.Minute),
 		MaxAgentStartingPeriod: model.Duration(20 * time.Minute),
@@ -237,3 +238,43 @@ partition: tesla_queue
 
 	assert.Equal(t, unmarshaled.HPC.Partition, "tesla_queue")
 }
+
+func TestUnmarshalProvisionerConfigWithExternal(t *testing.T) {
+	configRaw := `
+master_url: http://test.master
+agent_docker_image: test_image
+
+type: external
+url: https://provider.internal:8443
+bearer_token: secret
+instance_type:
+  name: bm.gpu.8
+  slots: 8
+`
+	unmarshaled := Config{}
+	err := yaml.Unmarshal([]byte(configRaw), &unmarshaled, yaml.DisallowUnknownFields)
+	assert.NilError(t, err)
+	err = check.Validate(&unmarshaled)
+	assert.NilError(t, err)
+
+	secret := "secret"
+	assert.DeepEqual(t, *unmarshaled.External, ExternalClusterConfig{
+		URL: "https://provider.internal:8443",
+		InstanceType: ExternalInstanceType{
+			InstanceName:  "bm.gpu.8",
+			InstanceSlots: 8,
+			SlotType:      device.CUDA,
+		},
+		BearerToken: &secret,
+		Timeout:     model.Duration(30 * time.Second),
+		Retries:     3,
+	})
+	require.Equal(t, "********", *unmarshaled.Printable().External.BearerToken)
+	require.Equal(t, "secret", *unmarshaled.External.BearerToken)
+
+	unmarshaled.External.URL = "provider.internal"
+	unmarshaled.External.InstanceType.InstanceName = ""
+	err = check.Validate(&unmarshaled)
+	require.ErrorContains(t, err, "external provider url must be an http or https URL")
+	require.ErrorContains(t, err, "external instance type must have a name")
+}
@@ -0,0 +1,97 @@
+package provconfig
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// ExternalClusterConfig describes a cluster whose instances are listed, launched and terminated by
+// an external provider service over HTTP.
+type ExternalClusterConfig struct {
+	// URL is the base URL of the provider service.
+	URL          string               `json:"url"`
+	InstanceType ExternalInstanceType `json:"instance_type"`
+	// BearerToken is sent in the Authorization header of every request, if set.
+	BearerToken *string `json:"bearer_token"`
+	// Timeout bounds each request to the provider service.
+	Timeout model.Duration `json:"timeout"`
+	// Retries is how many times a request that failed with a network error, a 429 or a 5xx
+	// response is retried.
+	Retries int                   `json:"retries"`
+	TLS     model.TLSClientConfig `json:"tls"`
+}
+
+// DefaultExternalClusterConfig returns the default configuration of an external cluster.
+func DefaultExternalClusterConfig() *ExternalClusterConfig {
+	return &ExternalClusterConfig{
+		InstanceType: ExternalInstanceType{SlotType: device.CUDA},
+		Timeout:      model.Duration(30 * time.Second),
+		Retries:      3,
+	}
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *ExternalClusterConfig) UnmarshalJSON(data []byte) error {
+	*c = *DefaultExternalClusterConfig()
+	type DefaultParser *ExternalClusterConfig
+	return json.Unmarshal(data, DefaultParser(c))
+}
+
+// Validate implements the check.Validatable interface.
+func (c ExternalClusterConfig) Validate() []error {
+	u, err := url.Parse(c.URL)
+	validURL := err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+	return []error{
+		check.True(validURL, "external provider url must be an http or https URL"),
+		check.GreaterThan(int64(c.Timeout), int64(0), "external provider timeout must be greater than 0"),
+		check.GreaterThanOrEqualTo(int64(c.Retries), int64(0),
+			"external provider retries must be greater than or equal to 0"),
+	}
+}
+
+// SlotsPerInstance returns the number of slots per instance.
+func (c ExternalClusterConfig) SlotsPerInstance() int {
+	return c.InstanceType.Slots()
+}
+
+// SlotType returns the type of the slot.
+func (c ExternalClusterConfig) SlotType() device.Type {
+	if c.InstanceType.Slots() == 0 {
+		return device.ZeroSlot
+	}
+	return c.InstanceType.SlotType
+}
+
+// ExternalInstanceType describes the instances the provider service launches. The provider service
+// is trusted to launch instances that match it.
+type ExternalInstanceType struct {
+	InstanceName  string      `json:"name"`
+	InstanceSlots int         `json:"slots"`
+	SlotType      device.Type `json:"slot_type"`
+}
+
+// Name returns the name of the instance type.
+func (t ExternalInstanceType) Name() string {
+	return t.InstanceName
+}
+
+// Slots returns the number of slots of the instance type.
+func (t ExternalInstanceType) Slots() int {
+	return t.InstanceSlots
+}
+
+// Validate implements the check.Validatable interface.
+func (t ExternalInstanceType) Validate() []error {
+	return []error{
+		check.NotEmpty(t.InstanceName, "external instance type must have a name"),
+		check.GreaterThanOrEqualTo(int64(t.InstanceSlots), int64(0),
+			"external instance type slots must be greater than or equal to 0"),
+		check.In(string(t.SlotType), []string{string(device.CUDA), string(device.ROCM), string(device.CPU)},
+			"external instance type slot_type must be within [cuda, rocm, cpu]"),
+	}
+}
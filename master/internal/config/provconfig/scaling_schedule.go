@@ -0,0 +1,151 @@
+package provconfig
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const clockLayout = "15:04"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ScalingSchedule overrides the instance bounds of a resource pool during a recurring period.
+type ScalingSchedule struct {
+	// Days are the days of the week the period starts on, such as "mon". Empty means every day.
+	Days []string `json:"days"`
+	// Start and End are the wall clock times, such as "08:30", the period starts and ends at. A
+	// period that ends before it starts ends on the next day. Both empty means the whole day.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// TimeZone is the IANA time zone of the period, such as "Europe/Berlin". Defaults to UTC.
+	TimeZone     string `json:"time_zone"`
+	MinInstances *int   `json:"min_instances"`
+	MaxInstances *int   `json:"max_instances"`
+}
+
+// Validate implements the check.Validatable interface.
+func (s ScalingSchedule) Validate() []error {
+	var errs []error
+	for _, d := range s.Days {
+		if _, ok := weekdays[strings.ToLower(d)]; !ok {
+			errs = append(errs, errors.Errorf("scaling schedule day %q must be within "+
+				"[mon, tue, wed, thu, fri, sat, sun]", d))
+		}
+	}
+	if (s.Start == "") != (s.End == "") {
+		errs = append(errs, errors.New("scaling schedule start and end must be specified together"))
+	} else if s.Start != "" {
+		start, startErr := time.Parse(clockLayout, s.Start)
+		end, endErr := time.Parse(clockLayout, s.End)
+		switch {
+		case startErr != nil:
+			errs = append(errs, errors.Errorf("scaling schedule start %q must be formatted as HH:MM", s.Start))
+		case endErr != nil:
+			errs = append(errs, errors.Errorf("scaling schedule end %q must be formatted as HH:MM", s.End))
+		case start.Equal(end):
+			errs = append(errs, errors.New("scaling schedule start and end must differ"))
+		}
+	}
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		errs = append(errs, errors.Wrapf(err, "invalid scaling schedule time zone %q", s.TimeZone))
+	}
+	if s.MinInstances == nil && s.MaxInstances == nil {
+		errs = append(errs, errors.New("scaling schedule must set min_instances or max_instances"))
+	}
+	if s.MinInstances != nil && *s.MinInstances < 0 {
+		errs = append(errs, errors.New("scaling schedule min_instances must be greater than or equal to 0"))
+	}
+	if s.MaxInstances != nil && *s.MaxInstances < 0 {
+		errs = append(errs, errors.New("scaling schedule max_instances must be greater than or equal to 0"))
+	}
+	if s.MinInstances != nil && s.MaxInstances != nil && *s.MinInstances > *s.MaxInstances {
+		errs = append(errs, errors.New(
+			"scaling schedule max_instances must be greater than or equal to min_instances"))
+	}
+	return errs
+}
+
+// Active returns whether t is within a period of the schedule. It assumes the schedule is valid.
+func (s ScalingSchedule) Active(t time.Time) bool {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return false
+	}
+	t = t.In(loc)
+	if s.Start == "" {
+		return s.onDay(t.Weekday())
+	}
+
+	start, _ := time.Parse(clockLayout, s.Start)
+	end, _ := time.Parse(clockLayout, s.End)
+	minutes := t.Hour()*60 + t.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+	if startMinutes < endMinutes {
+		return startMinutes <= minutes && minutes < endMinutes && s.onDay(t.Weekday())
+	}
+	// The period ends on the next day, so after midnight it belongs to the previous day.
+	if minutes >= startMinutes {
+		return s.onDay(t.Weekday())
+	}
+	return minutes < endMinutes && s.onDay((t.Weekday()+6)%7)
+}
+
+func (s ScalingSchedule) onDay(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, d := range s.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// InstanceBounds returns the min and max instances at time t: those of the first scaling schedule
+// that is active at t, or minInstances and maxInstances for the ones it does not set. The min is
+// lowered to the max if it exceeds it.
+func InstanceBounds(
+	minInstances, maxInstances int, schedules []ScalingSchedule, t time.Time,
+) (int, int) {
+	for _, s := range schedules {
+		if !s.Active(t) {
+			continue
+		}
+		if s.MinInstances != nil {
+			minInstances = *s.MinInstances
+		}
+		if s.MaxInstances != nil {
+			maxInstances = *s.MaxInstances
+		}
+		break
+	}
+	return min(minInstances, maxInstances), maxInstances
+}
+
+// InstanceBounds returns the min and max instances of the pool at time t.
+func (c Config) InstanceBounds(t time.Time) (int, int) {
+	return InstanceBounds(c.MinInstances, c.MaxInstances, c.ScalingSchedules, t)
+}
+
+// PeakMaxInstances returns the most instances the pool may have at any time.
+func (c Config) PeakMaxInstances() int {
+	peak := c.MaxInstances
+	for _, s := range c.ScalingSchedules {
+		if s.MaxInstances != nil {
+			peak = max(peak, *s.MaxInstances)
+		}
+	}
+	return peak
+}
@@ -0,0 +1,89 @@
+package provconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestScalingScheduleActive(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	workHours := ScalingSchedule{
+		Days:     []string{"mon", "tue", "wed", "thu", "fri"},
+		Start:    "08:30",
+		End:      "18:00",
+		TimeZone: "Europe/Berlin",
+	}
+	// 2024-11-11 is a Monday.
+	require.False(t, workHours.Active(time.Date(2024, 11, 11, 8, 29, 0, 0, berlin)))
+	require.True(t, workHours.Active(time.Date(2024, 11, 11, 8, 30, 0, 0, berlin)))
+	require.True(t, workHours.Active(time.Date(2024, 11, 11, 7, 30, 0, 0, time.UTC)))
+	require.False(t, workHours.Active(time.Date(2024, 11, 11, 18, 0, 0, 0, berlin)))
+	require.False(t, workHours.Active(time.Date(2024, 11, 16, 12, 0, 0, 0, berlin)))
+
+	nights := ScalingSchedule{Days: []string{"Fri"}, Start: "22:00", End: "06:00"}
+	require.True(t, nights.Active(time.Date(2024, 11, 15, 23, 0, 0, 0, time.UTC)))
+	require.True(t, nights.Active(time.Date(2024, 11, 16, 5, 59, 0, 0, time.UTC)))
+	require.False(t, nights.Active(time.Date(2024, 11, 16, 6, 0, 0, 0, time.UTC)))
+	require.False(t, nights.Active(time.Date(2024, 11, 15, 5, 0, 0, 0, time.UTC)))
+
+	weekends := ScalingSchedule{Days: []string{"sat", "sun"}}
+	require.True(t, weekends.Active(time.Date(2024, 11, 17, 0, 0, 0, 0, time.UTC)))
+	require.False(t, weekends.Active(time.Date(2024, 11, 18, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestInstanceBounds(t *testing.T) {
+	schedules := []ScalingSchedule{
+		{Days: []string{"mon"}, MinInstances: ptrs.Ptr(8)},
+		{MinInstances: ptrs.Ptr(2), MaxInstances: ptrs.Ptr(3)},
+	}
+	monday := time.Date(2024, 11, 11, 12, 0, 0, 0, time.UTC)
+	tuesday := monday.Add(24 * time.Hour)
+
+	minInstances, maxInstances := InstanceBounds(0, 10, schedules, monday)
+	require.Equal(t, 8, minInstances)
+	require.Equal(t, 10, maxInstances)
+	minInstances, maxInstances = InstanceBounds(0, 10, schedules, tuesday)
+	require.Equal(t, 2, minInstances)
+	require.Equal(t, 3, maxInstances)
+	minInstances, maxInstances = InstanceBounds(1, 5, nil, tuesday)
+	require.Equal(t, 1, minInstances)
+	require.Equal(t, 5, maxInstances)
+	minInstances, maxInstances = InstanceBounds(0, 4, schedules[:1], monday)
+	require.Equal(t, 4, minInstances)
+	require.Equal(t, 4, maxInstances)
+
+	require.Equal(t, 10, Config{MaxInstances: 10, ScalingSchedules: schedules}.PeakMaxInstances())
+	require.Equal(t, 3, Config{MaxInstances: 1, ScalingSchedules: schedules}.PeakMaxInstances())
+}
+
+func TestScalingScheduleValidate(t *testing.T) {
+	var schedule ScalingSchedule
+	require.NoError(t, yaml.Unmarshal([]byte(`
+days: [mon, someday]
+start: "8:30am"
+end: "18:00"
+time_zone: Mars/Olympus_Mons
+min_instances: 4
+max_instances: 2
+`), &schedule, yaml.DisallowUnknownFields))
+	err := check.Validate(schedule)
+	require.ErrorContains(t, err, `scaling schedule day "someday" must be within`)
+	require.ErrorContains(t, err, `scaling schedule start "8:30am" must be formatted as HH:MM`)
+	require.ErrorContains(t, err, `invalid scaling schedule time zone "Mars/Olympus_Mons"`)
+	require.ErrorContains(t, err, "max_instances must be greater than or equal to min_instances")
+
+	require.ErrorContains(t, check.Validate(ScalingSchedule{Start: "08:00"}),
+		"start and end must be specified together")
+	require.ErrorContains(t, check.Validate(ScalingSchedule{}), "must set min_instances or max_instances")
+	require.NoError(t, check.Validate(ScalingSchedule{
+		Start: "22:00", End: "06:00", TimeZone: "America/New_York", MaxInstances: ptrs.Ptr(0),
+	}))
+}
@@ -57,6 +57,14 @@ func (r *ResourceConfig) GetAgentRMConfig() (*ResourceManagerWithPoolsConfig, bo
 	return nil, false
 }
 
+// SetAgentRMResourcePools replaces the resource pools of the agent resource manager, as they are
+// created, patched and deleted at runtime.
+func (r *ResourceConfig) SetAgentRMResourcePools(pools []ResourcePoolConfig) {
+	if r.RootManagerInternal != nil && r.RootManagerInternal.AgentRM != nil {
+		r.RootPoolsInternal = pools
+	}
+}
+
 // GetKubernetesClusterNames gets the list of Kubernetes Cluster names.
 func (r *ResourceConfig) GetKubernetesClusterNames() []string {
 	rms := []string{}
@@ -90,7 +98,8 @@ func (r *ResourceConfig) ResolveResource() error {
 	if r.RootManagerInternal.AgentRM == nil &&
 		r.RootManagerInternal.KubernetesRM == nil &&
 		r.RootManagerInternal.DispatcherRM == nil &&
-		r.RootManagerInternal.PbsRM == nil {
+		r.RootManagerInternal.PbsRM == nil &&
+		r.RootManagerInternal.SlurmRM == nil {
 		r.RootManagerInternal.AgentRM = defaultAgentRM()
 	}
 	for _, c := range r.AdditionalResourceManagersInternal {
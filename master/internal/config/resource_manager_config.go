@@ -23,6 +23,7 @@ type ResourceManagerConfig struct {
 	KubernetesRM *KubernetesResourceManagerConfig `union:"type,kubernetes" json:"-"`
 	DispatcherRM *DispatcherResourceManagerConfig `union:"type,slurm" json:"-"`
 	PbsRM        *DispatcherResourceManagerConfig `union:"type,pbs" json:"-"`
+	SlurmRM      *SlurmResourceManagerConfig      `union:"type,slurm_native" json:"-"`
 }
 
 // ClusterName returns the cluster name associated with the resource manager. If the cluster name
@@ -52,6 +53,9 @@ func (r ResourceManagerConfig) ClusterName() string {
 		}
 		return pbs.ClusterName
 	}
+	if slurm := r.SlurmRM; slurm != nil {
+		return slurm.ClusterName
+	}
 
 	panic(fmt.Sprintf("unknown rm type %+v", r))
 }
@@ -66,6 +70,8 @@ func (r *ResourceManagerConfig) setClusterName(clusterName string) {
 		r.DispatcherRM.ClusterName = clusterName
 	case r.PbsRM != nil:
 		r.PbsRM.ClusterName = clusterName
+	case r.SlurmRM != nil:
+		r.SlurmRM.ClusterName = clusterName
 	default:
 		panic(fmt.Sprintf("unknown rm type %+v", r))
 	}
@@ -88,7 +94,8 @@ func (r *ResourceManagerConfig) UnmarshalJSON(data []byte) error {
 	}
 
 	// Fill in the default config.
-	if r.AgentRM == nil && r.KubernetesRM == nil && r.DispatcherRM == nil && r.PbsRM == nil {
+	if r.AgentRM == nil && r.KubernetesRM == nil && r.DispatcherRM == nil && r.PbsRM == nil &&
+		r.SlurmRM == nil {
 		r.AgentRM = &AgentResourceManagerConfig{
 			Scheduler: &SchedulerConfig{
 				FittingPolicy: defaultFitPolicy,
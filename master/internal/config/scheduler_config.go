@@ -2,6 +2,9 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
 
 	"github.com/determined-ai/determined/master/pkg/check"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -114,7 +117,51 @@ func (s *SchedulerConfig) GetPreemption() bool {
 }
 
 // FairShareSchedulerConfig holds configurations for the fair share scheduler.
-type FairShareSchedulerConfig struct{}
+type FairShareSchedulerConfig struct {
+	// Hierarchical splits the pool across workspaces, then across the users of each workspace and
+	// only then across jobs.
+	Hierarchical     bool               `json:"hierarchical"`
+	WorkspaceWeights map[string]float64 `json:"workspace_weights"`
+	UserWeights      map[string]float64 `json:"user_weights"`
+	// UsageHalfLife, if set, lowers the shares of workspaces and users by their past usage, which
+	// decays by half over this period.
+	UsageHalfLife *model.Duration `json:"usage_half_life"`
+}
+
+// Validate implements the check.Validatable interface.
+func (f FairShareSchedulerConfig) Validate() []error {
+	var errs []error
+	for name, weight := range f.WorkspaceWeights {
+		if weight <= 0 {
+			errs = append(errs, fmt.Errorf("weight of workspace %s must be greater than 0", name))
+		}
+	}
+	for name, weight := range f.UserWeights {
+		if weight <= 0 {
+			errs = append(errs, fmt.Errorf("weight of user %s must be greater than 0", name))
+		}
+	}
+	if f.UsageHalfLife != nil && *f.UsageHalfLife <= 0 {
+		errs = append(errs, errors.New("usage_half_life must be greater than 0"))
+	}
+	return errs
+}
+
+// WorkspaceWeight returns the fair share weight of a workspace, 1 unless configured.
+func (f FairShareSchedulerConfig) WorkspaceWeight(workspace string) float64 {
+	if weight, ok := f.WorkspaceWeights[workspace]; ok {
+		return weight
+	}
+	return 1
+}
+
+// UserWeight returns the fair share weight of a user within a workspace, 1 unless configured.
+func (f FairShareSchedulerConfig) UserWeight(username string) float64 {
+	if weight, ok := f.UserWeights[username]; ok {
+		return weight
+	}
+	return 1
+}
 
 // PrioritySchedulerConfig holds the configurations for the priority scheduler.
 type PrioritySchedulerConfig struct {
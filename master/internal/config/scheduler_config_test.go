@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/stretchr/testify/require"
@@ -33,3 +34,26 @@ resource_pools:
 	require.Equal(t, PriorityScheduling, rm[0].ResourceManager.AgentRM.Scheduler.GetType())
 	require.Equal(t, PriorityScheduling, rp[0].Scheduler.GetType())
 }
+
+func TestFairShareSchedulerConfig(t *testing.T) {
+	var s SchedulerConfig
+	err := yaml.Unmarshal([]byte(`
+type: fair_share
+hierarchical: true
+workspace_weights:
+  research: 3
+user_weights:
+  alice: 0
+usage_half_life: 24h
+`), &s, yaml.DisallowUnknownFields)
+	require.NoError(t, err)
+	require.NotNil(t, s.FairShare)
+	require.True(t, s.FairShare.Hierarchical)
+	require.Equal(t, 3.0, s.FairShare.WorkspaceWeight("research"))
+	require.Equal(t, 1.0, s.FairShare.WorkspaceWeight("other"))
+	require.Equal(t, time.Duration(24*time.Hour), time.Duration(*s.FairShare.UsageHalfLife))
+
+	errs := s.FairShare.Validate()
+	require.Len(t, errs, 1)
+	require.ErrorContains(t, errs[0], "weight of user alice")
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const apptainer = "apptainer"
+
+// SlurmResourceManagerConfig configures the native Slurm resource manager, which submits jobs
+// with the Slurm command line tools on the master host instead of through the HPC launcher.
+type SlurmResourceManagerConfig struct {
+	ClusterName string `json:"cluster_name"`
+	MasterHost  string `json:"master_host"`
+	MasterPort  int    `json:"master_port"`
+	// JobStorageRoot is a directory shared between the master and the compute nodes that holds
+	// the batch script, output and unpacked archives of each job.
+	JobStorageRoot string `json:"job_storage_root"`
+	// SlurmBinDir is the directory containing sbatch, squeue, sacct and scancel. If empty, they
+	// are looked up on the PATH of the master.
+	SlurmBinDir                string         `json:"slurm_bin_dir"`
+	ContainerRunType           string         `json:"container_run_type"`
+	PollInterval               model.Duration `json:"poll_interval"`
+	SlotType                   *device.Type   `json:"slot_type"`
+	TresSupported              bool           `json:"tres_supported"`
+	GresSupported              bool           `json:"gres_supported"`
+	RendezvousNetworkInterface string         `json:"rendezvous_network_interface"`
+	ProxyNetworkInterface      string         `json:"proxy_network_interface"`
+	DefaultAuxResourcePool     string         `json:"default_aux_resource_pool"`
+	DefaultComputeResourcePool string         `json:"default_compute_resource_pool"`
+	JobProjectSource           *string        `json:"job_project_source"`
+
+	Metadata map[string]string `json:"metadata"`
+}
+
+var defaultSlurmResourceManagerConfig = SlurmResourceManagerConfig{
+	MasterPort:       8080,
+	ContainerRunType: singularity,
+	PollInterval:     model.Duration(10 * time.Second),
+	TresSupported:    true,
+	GresSupported:    true,
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *SlurmResourceManagerConfig) UnmarshalJSON(data []byte) error {
+	*c = defaultSlurmResourceManagerConfig
+	type DefaultParser *SlurmResourceManagerConfig
+	return json.Unmarshal(data, DefaultParser(c))
+}
+
+// Validate implements the check.Validatable interface.
+func (c SlurmResourceManagerConfig) Validate() []error {
+	errs := []error{
+		check.NotEmpty(c.ClusterName, "cluster_name is required"),
+		check.NotEmpty(c.MasterHost, "master_host is required"),
+		check.GreaterThan(c.MasterPort, 0, "master_port must be > 0"),
+		check.True(c.PollInterval > 0, "poll_interval must be > 0"),
+	}
+	if !filepath.IsAbs(c.JobStorageRoot) {
+		errs = append(errs, fmt.Errorf("job_storage_root must be an absolute path"))
+	}
+	if c.ContainerRunType != singularity && c.ContainerRunType != apptainer {
+		errs = append(errs, fmt.Errorf(
+			"invalid container_run_type '%s'. Specify one of singularity or apptainer",
+			c.ContainerRunType))
+	}
+	if c.SlotType != nil {
+		switch *c.SlotType {
+		case device.CPU, device.CUDA, device.ROCM:
+		default:
+			errs = append(errs, fmt.Errorf(
+				"invalid slot_type '%s'.  Specify one of cuda, rocm, or cpu", *c.SlotType))
+		}
+	}
+	return append(errs, validateJobProjectSource(c.JobProjectSource)...)
+}
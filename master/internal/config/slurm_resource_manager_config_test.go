@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestSlurmResourceManagerConfig_Defaults(t *testing.T) {
+	var rm ResourceManagerConfig
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"type": "slurm_native",
+		"cluster_name": "hpc",
+		"master_host": "master.example.com",
+		"job_storage_root": "/shared/determined"
+	}`), &rm))
+	require.NotNil(t, rm.SlurmRM)
+	require.Equal(t, "hpc", rm.ClusterName())
+	require.Equal(t, 8080, rm.SlurmRM.MasterPort)
+	require.Equal(t, "singularity", rm.SlurmRM.ContainerRunType)
+	require.Equal(t, model.Duration(10*time.Second), rm.SlurmRM.PollInterval)
+	require.True(t, rm.SlurmRM.TresSupported)
+	require.True(t, rm.SlurmRM.GresSupported)
+}
+
+func TestSlurmResourceManagerConfig_Validate(t *testing.T) {
+	valid := SlurmResourceManagerConfig{
+		ClusterName:      "hpc",
+		MasterHost:       "master.example.com",
+		MasterPort:       8080,
+		JobStorageRoot:   "/shared/determined",
+		ContainerRunType: "apptainer",
+		PollInterval:     model.Duration(time.Second),
+	}
+	tests := []struct {
+		name   string
+		modify func(c *SlurmResourceManagerConfig)
+		want   string
+	}{
+		{
+			name:   "valid",
+			modify: func(c *SlurmResourceManagerConfig) {},
+		},
+		{
+			name:   "missing master_host",
+			modify: func(c *SlurmResourceManagerConfig) { c.MasterHost = "" },
+			want:   "master_host is required",
+		},
+		{
+			name:   "relative job_storage_root",
+			modify: func(c *SlurmResourceManagerConfig) { c.JobStorageRoot = "jobs" },
+			want:   "job_storage_root must be an absolute path",
+		},
+		{
+			name:   "invalid container_run_type",
+			modify: func(c *SlurmResourceManagerConfig) { c.ContainerRunType = "podman" },
+			want: "invalid container_run_type 'podman'. " +
+				"Specify one of singularity or apptainer",
+		},
+		{
+			name:   "zero poll_interval",
+			modify: func(c *SlurmResourceManagerConfig) { c.PollInterval = 0 },
+			want:   "poll_interval must be > 0",
+		},
+		{
+			name: "invalid slot_type",
+			modify: func(c *SlurmResourceManagerConfig) {
+				c.SlotType = ptrs.Ptr(device.Type("tpu"))
+			},
+			want: "invalid slot_type 'tpu'.  Specify one of cuda, rocm, or cpu",
+		},
+		{
+			name: "invalid job_project_source",
+			modify: func(c *SlurmResourceManagerConfig) {
+				c.JobProjectSource = ptrs.Ptr("team")
+			},
+			want: "invalid job_project_source value: 'team'. " +
+				"Specify one of project, workspace or label[:value]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.modify(&c)
+			var errs []error
+			for _, err := range c.Validate() {
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if tt.want == "" {
+				require.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			require.ErrorContains(t, errs[0], tt.want)
+		})
+	}
+}
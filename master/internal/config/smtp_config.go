@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strconv"
+)
+
+// DefaultSMTPPort is the SMTP submission port, used when no port is configured.
+const DefaultSMTPPort = 587
+
+// SMTPConfig configures the SMTP server email notifications are sent through.
+type SMTPConfig struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// StartTLS upgrades the connection to TLS before authenticating. Defaults to true.
+	StartTLS *bool  `json:"starttls"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+// UseStartTLS returns whether the connection is upgraded to TLS.
+func (c SMTPConfig) UseStartTLS() bool {
+	return c.StartTLS == nil || *c.StartTLS
+}
+
+// Address returns the host and port of the SMTP server.
+func (c SMTPConfig) Address() string {
+	port := c.Port
+	if port == 0 {
+		port = DefaultSMTPPort
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+// Validate implements the check.Validatable interface.
+func (c SMTPConfig) Validate() []error {
+	var errs []error
+	if c.Host == "" {
+		errs = append(errs, errors.New("smtp host must be set"))
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("smtp port %d is out of range", c.Port))
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		errs = append(errs, fmt.Errorf("smtp from address %q is invalid: %w", c.From, err))
+	}
+	if c.Password != "" && c.Username == "" {
+		errs = append(errs, errors.New("smtp password provided without a username"))
+	}
+	return errs
+}
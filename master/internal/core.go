@@ -49,9 +49,12 @@ import (
 	"github.com/determined-ai/determined/master/internal/elastic"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/internal/job/jobservice"
+	"github.com/determined-ai/determined/master/internal/leader"
 	"github.com/determined-ai/determined/master/internal/license"
 	"github.com/determined-ai/determined/master/internal/logpattern"
 	"github.com/determined-ai/determined/master/internal/logretention"
+	"github.com/determined-ai/determined/master/internal/loki"
+	"github.com/determined-ai/determined/master/internal/otelmetrics"
 	"github.com/determined-ai/determined/master/internal/plugin/sso"
 	"github.com/determined-ai/determined/master/internal/portregistry"
 	"github.com/determined-ai/determined/master/internal/prom"
@@ -61,9 +64,12 @@ import (
 	"github.com/determined-ai/determined/master/internal/rm/dispatcherrm"
 	"github.com/determined-ai/determined/master/internal/rm/kubernetesrm"
 	"github.com/determined-ai/determined/master/internal/rm/multirm"
+	"github.com/determined-ai/determined/master/internal/rm/slurmrm"
 	"github.com/determined-ai/determined/master/internal/rm/tasklist"
 	"github.com/determined-ai/determined/master/internal/saas/saasprovisioner"
+	"github.com/determined-ai/determined/master/internal/schedules"
 	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/internal/sshca"
 	"github.com/determined-ai/determined/master/internal/stream"
 	"github.com/determined-ai/determined/master/internal/task"
 	"github.com/determined-ai/determined/master/internal/task/tasklogger"
@@ -72,6 +78,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/trials"
 	"github.com/determined-ai/determined/master/internal/user"
 	"github.com/determined-ai/determined/master/internal/webhooks"
+	"github.com/determined-ai/determined/master/internal/workflow"
 	"github.com/determined-ai/determined/master/pkg/aproto"
 	"github.com/determined-ai/determined/master/pkg/etc"
 	"github.com/determined-ai/determined/master/pkg/logger"
@@ -112,6 +119,13 @@ type Master struct {
 
 	trialLogBackend TrialLogBackend
 	taskLogBackend  TaskLogBackend
+
+	// sshCA is only set when shells accept SSH certificates signed by the master.
+	sshCA *sshca.CertificateAuthority
+
+	// elector and standby are only set when the master is highly available.
+	elector *leader.Elector
+	standby *http.Server
 }
 
 // New creates an instance of the Determined master.
@@ -215,6 +229,10 @@ func (m *Master) healthCheck(ctx context.Context) model.HealthCheck {
 	}
 
 	hc.ResourceManagers = m.rm.HealthCheck()
+	if m.elector != nil {
+		hc.Role = model.LeaderRole
+		hc.Leader = m.config.HighAvailability.AdvertisedAddress
+	}
 
 	isHealthy := hc.Database == model.Healthy
 	for _, rm := range hc.ResourceManagers {
@@ -816,6 +834,61 @@ func (m *Master) startServers(ctx context.Context, cert *tls.Certificate, gRPCLo
 	}
 }
 
+// campaignForLeadership forwards requests to the leader until this master is elected, then renews
+// its lease in the background, canceling the context if the lease is lost.
+func (m *Master) campaignForLeadership(ctx context.Context, cancel context.CancelCauseFunc) error {
+	ha := m.config.HighAvailability
+	m.elector = leader.New(m.MasterID, ha.AdvertisedAddress, time.Duration(ha.LeaseDuration))
+
+	cert, err := m.config.Security.TLS.ReadCertificate()
+	if err != nil {
+		return errors.Wrap(err, "failed to read TLS certificate")
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", m.config.Port))
+	if err != nil {
+		return err
+	}
+	var transport http.RoundTripper
+	if cert != nil {
+		// Masters share a certificate, so the leader is trusted even if it is self-signed.
+		roots, pErr := x509.SystemCertPool()
+		if pErr != nil {
+			roots = x509.NewCertPool()
+		}
+		roots.AppendCertsFromPEM(config.GetCertPEM(cert))
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+		transport = t
+
+		listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{*cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
+
+	m.standby = &http.Server{
+		Handler:           leader.NewStandby(m.elector, transport),
+		ReadHeaderTimeout: time.Minute,
+	}
+	go func() {
+		if err := m.standby.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Error("standby server failed")
+		}
+	}()
+
+	log.Infof("waiting to be elected leader, forwarding requests on port %d to the leader", m.config.Port)
+	if err := m.elector.Campaign(ctx); err != nil {
+		return err
+	}
+	go func() {
+		if err := m.elector.Hold(ctx); err != nil {
+			log.WithError(err).Error("stepping down as leader")
+			cancel(err)
+		}
+	}()
+	return nil
+}
+
 func closeWithErrCheck(name string, closer io.Closer) {
 	err := closer.Close()
 	if err != nil {
@@ -917,6 +990,12 @@ func (m *Master) restoreGenericTasks(ctx context.Context) error {
 
 		onAllocationExit := getGenericTaskOnAllocationExit(ctx, taskID, *jobID, logCtx)
 
+		// The workspace name is only informational, so a task is still restored without it.
+		workspaceName, err := genericTaskWorkspaceName(ctx, snapshots[i].GenericTaskSpec)
+		if err != nil {
+			log.WithError(err).Errorf("Could not get the workspace of task %s", taskID)
+		}
+
 		isSingleNode := snapshots[i].GenericTaskSpec.GenericTaskConfig.Resources.IsSingleNode() != nil &&
 			*snapshots[i].GenericTaskSpec.GenericTaskConfig.Resources.IsSingleNode()
 
@@ -931,7 +1010,7 @@ func (m *Master) restoreGenericTasks(ctx context.Context) error {
 			continue
 		}
 
-		err := task.DefaultService.StartAllocation(logCtx,
+		err = task.DefaultService.StartAllocation(logCtx,
 			sproto.AllocateRequest{
 				AllocationID:      snapshots[i].AllocationID,
 				TaskID:            taskID,
@@ -939,11 +1018,15 @@ func (m *Master) restoreGenericTasks(ctx context.Context) error {
 				JobSubmissionTime: snapshots[i].RegisteredTime,
 				IsUserVisible:     true,
 				Name:              fmt.Sprintf("Generic Task %s", taskID),
+				Workspace:         workspaceName,
+				Username:          snapshots[i].GenericTaskSpec.Base.Owner.Username,
 				SlotsNeeded:       *slots,
 				ResourcePool:      *resourcePool,
 				FittingRequirements: sproto.FittingRequirements{
 					SingleAgent: isSingleNode,
 				},
+				CapacityType: model.CapacityTypeOf(
+					snapshots[i].GenericTaskSpec.GenericTaskConfig.Resources.CapacityType()),
 
 				Restore: true,
 			}, m.db, m.rm, snapshots[i].GenericTaskSpec, onAllocationExit)
@@ -1050,6 +1133,21 @@ func (m *Master) checkIfRMDefaultsAreUnbound(rmConfig *config.ResourceManagerCon
 		}
 		return nil
 	}
+	if rmConfig.SlurmRM != nil {
+		if rmConfig.SlurmRM.DefaultComputeResourcePool != "" {
+			err := db.CheckIfRPUnbound(rmConfig.SlurmRM.DefaultComputeResourcePool)
+			if err != nil {
+				return err
+			}
+		}
+		if rmConfig.SlurmRM.DefaultAuxResourcePool != "" {
+			err := db.CheckIfRPUnbound(rmConfig.SlurmRM.DefaultAuxResourcePool)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	return fmt.Errorf("no Resource Manager found")
 }
 
@@ -1100,6 +1198,13 @@ func (m *Master) buildRM(
 			}
 			m.allRms[clusterName] = dispatcherRM
 			return dispatcherRM, nil
+		case config.ResourceManager.SlurmRM != nil:
+			slurmRM, err := slurmrm.New(db, config, cert)
+			if err != nil {
+				return nil, err
+			}
+			m.allRms[clusterName] = slurmRM
+			return slurmRM, nil
 		default:
 			return nil, fmt.Errorf("no expected resource manager config is defined")
 		}
@@ -1211,6 +1316,21 @@ func (m *Master) Run(ctx context.Context, gRPCLogInitDone chan struct{}) error {
 		return errors.Wrap(err, "could not fetch cluster id from database")
 	}
 
+	// Standbys stop here, before loading any state, until they are elected leader.
+	if m.config.HighAvailability.Enabled {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		if err := m.campaignForLeadership(ctx, cancel); err != nil {
+			return fmt.Errorf("campaigning for leadership: %w", err)
+		}
+		defer func() {
+			if err := m.elector.Resign(context.Background()); err != nil {
+				log.WithError(err).Warn("failed to resign leadership")
+			}
+		}()
+	}
+
 	webhookManager, err := webhooks.New(ctx)
 	if err != nil {
 		return fmt.Errorf("initializing webhooks: %w", err)
@@ -1275,6 +1395,14 @@ func (m *Master) Run(ctx context.Context, gRPCLogInitDone chan struct{}) error {
 		}
 		m.trialLogBackend = es
 		m.taskLogBackend = es
+	case m.config.Logging.LokiLoggingConfig != nil:
+		lk, lErr := loki.Setup(*m.config.Logging.LokiLoggingConfig)
+		if lErr != nil {
+			return lErr
+		}
+		// Trial logs are only read from the database, where they were written before task logs.
+		m.trialLogBackend = m.db
+		m.taskLogBackend = lk
 	default:
 		panic("unsupported logging backend")
 	}
@@ -1283,6 +1411,12 @@ func (m *Master) Run(ctx context.Context, gRPCLogInitDone chan struct{}) error {
 	user.InitService(m.db, &m.config.InternalConfig.ExternalSessions)
 	userService := user.GetService()
 
+	if caConfig := m.config.Security.SSH.CertificateAuthority; caConfig.Enabled {
+		if m.sshCA, err = sshca.New(ctx, caConfig); err != nil {
+			return err
+		}
+	}
+
 	proxy.InitProxy(processProxyAuthentication)
 	portregistry.InitPortRegistry(config.GetMasterConfig().ReservedPorts)
 
@@ -1350,6 +1484,19 @@ func (m *Master) Run(ctx context.Context, gRPCLogInitDone chan struct{}) error {
 	if m.config.Telemetry.OtelEnabled {
 		opentelemetry.ConfigureOtel(m.config.Telemetry.OtelExportedOtlpEndpoint, "determined-master")
 		m.echo.Use(otelecho.Middleware("determined-master"))
+
+		if m.config.Telemetry.OtelTrialMetricsEnabled {
+			meterProvider := opentelemetry.ConfigureOtelMetrics(
+				m.config.Telemetry.OtelExportedOtlpEndpoint, "determined-master")
+			if err := otelmetrics.Init(
+				meterProvider,
+				m.config.Telemetry.OtelTrialMetricsQueueSize,
+				time.Duration(m.config.Telemetry.OtelTrialMetricsSeriesTTL),
+			); err != nil {
+				return errors.Wrap(err, "initializing trial metrics exporter")
+			}
+			defer otelmetrics.Deinit()
+		}
 	}
 
 	m.echo.Use(authzAuditLogMiddleware())
@@ -1402,7 +1549,7 @@ func (m *Master) Run(ctx context.Context, gRPCLogInitDone chan struct{}) error {
 	}
 
 	// Wait for all NTSC services to initialize.
-	cs, err := command.NewService(m.db, m.rm)
+	cs, err := command.NewService(m.db, m.rm, m.gcNotebookSnapshot)
 	if err != nil {
 		return fmt.Errorf("initializing command service: %w", err)
 	}
@@ -1430,8 +1577,26 @@ func (m *Master) Run(ctx context.Context, gRPCLogInitDone chan struct{}) error {
 	// This ensures that in the scenario where a cluster fails all open allocations are
 	// set to the last cluster heartbeat when the cluster was running.
 	go updateClusterHeartbeat(ctx, m.db)
+	go m.resizeElasticTrials(ctx)
 	go trials.MarkLostTrialsWorker(ctx)
 
+	// Workflows pick up where they left off once their generic tasks are restored.
+	workflow.DefaultManager = workflow.NewManager(&workflowTasks{a: &apiServer{m: m}})
+	go workflow.DefaultManager.Run(ctx)
+
+	scheduler, err := schedules.NewScheduler(&scheduleLauncher{a: &apiServer{m: m}})
+	if err != nil {
+		return fmt.Errorf("creating schedules scheduler: %w", err)
+	}
+	if err := scheduler.Start(schedules.TickInterval); err != nil {
+		return fmt.Errorf("starting schedules scheduler: %w", err)
+	}
+	defer func() {
+		if err := scheduler.Shutdown(); err != nil {
+			log.WithError(err).Warn("shutting down schedules scheduler")
+		}
+	}()
+
 	// Docs and WebUI.
 	webuiRoot := filepath.Join(m.config.Root, "webui")
 	reactRoot := filepath.Join(webuiRoot, "react")
@@ -1508,6 +1673,8 @@ func (m *Master) Run(ctx context.Context, gRPCLogInitDone chan struct{}) error {
 	experimentsGroup.GET("/:experiment_id/model_def", m.getExperimentModelDefinition)
 	experimentsGroup.GET("/:experiment_id/file/download", m.getExperimentModelFile)
 	experimentsGroup.GET("/:experiment_id/preview_gc", api.Route(m.getExperimentCheckpointsToGC))
+	experimentsGroup.GET("/:experiment_id/export", m.exportExperiment)
+	experimentsGroup.POST("/import", m.importExperiment)
 
 	checkpointsGroup := m.echo.Group("/checkpoints")
 	checkpointsGroup.GET("/:checkpoint_uuid", m.getCheckpoint)
@@ -1593,6 +1760,18 @@ func (m *Master) Run(ctx context.Context, gRPCLogInitDone chan struct{}) error {
 	webhooks.Init()
 	defer webhooks.Deinit()
 
+	if m.config.CheckpointRetention.Schedule != nil {
+		crs, err := m.scheduleCheckpointRetention(*m.config.CheckpointRetention.Schedule)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := crs.Shutdown(); err != nil {
+				log.WithError(err).Warn("shutting down checkpoint retention")
+			}
+		}()
+	}
+
 	if slices.Contains(m.config.FeatureSwitches, "streaming_updates") {
 		ssup := stream.NewSupervisor(m.db.URL)
 		go func() {
@@ -1601,5 +1780,13 @@ func (m *Master) Run(ctx context.Context, gRPCLogInitDone chan struct{}) error {
 		m.echo.GET("/stream", api.WebSocketRoute(ssup.Websocket, m.config.EnableCors))
 	}
 
-	return m.startServers(ctx, cert, gRPCLogInitDone)
+	if m.standby != nil {
+		// Free the port for the leader's own servers.
+		closeWithErrCheck("standby", m.standby)
+	}
+	err = m.startServers(ctx, cert, gRPCLogInitDone)
+	if cause := context.Cause(ctx); errors.Is(cause, leader.ErrLeaseLost) {
+		return cause
+	}
+	return err
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/configpolicy"
 	detContext "github.com/determined-ai/determined/master/internal/context"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/derivedmetrics"
 	expauth "github.com/determined-ai/determined/master/internal/experiment"
 	"github.com/determined-ai/determined/master/internal/project"
 	"github.com/determined-ai/determined/master/internal/rm"
@@ -378,6 +379,14 @@ func (m *Master) parseCreateExperiment(ctx context.Context, req *apiv1.CreateExp
 		return nil, nil, config, nil, nil, errors.Wrap(err, "invalid experiment configuration")
 	}
 
+	// A derived searcher metric must be defined in the project of the experiment.
+	err = derivedmetrics.ValidateSearcherMetric(ctx, int(p.Id), config.Searcher().Metric())
+	if errors.Is(err, db.ErrInvalidInput) {
+		return nil, nil, config, nil, nil, errors.Wrap(err, "invalid experiment configuration")
+	} else if err != nil {
+		return nil, nil, config, nil, nil, err
+	}
+
 	modelBytes := []byte{}
 	var parentID *int
 	if req.ParentId != 0 {
@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	detContext "github.com/determined-ai/determined/master/internal/context"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/expbundle"
+	expauth "github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/pkg/checkpoints/archive"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/projectv1"
+)
+
+//	@Summary	Export an experiment to a bundle in a tar or tgz file.
+//	@Tags		Experiments
+//	@ID			export-experiment
+//	@Accept		json
+//	@Produce	application/x-tar,application/gzip
+//	@Param		experiment_id		path	int		true	"Experiment ID"
+//	@Param		include_checkpoints	query	bool	false	"Include the files of completed checkpoints"
+//	@Success	200					{}		string	""
+//	@Router		/experiments/{experiment_id}/export [get]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) exportExperiment(c echo.Context) error {
+	mimeType := c.Request().Header.Get("Accept")
+	if mimeType == "" || mimeType == "*/*" || mimeType == "application/*" {
+		mimeType = MIMEApplicationXTar
+	}
+	if mimeType != MIMEApplicationXTar && mimeType != MIMEApplicationGZip {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("unsupported media type to export an experiment: '%s'", mimeType))
+	}
+
+	args := struct {
+		ExperimentID       int  `path:"experiment_id"`
+		IncludeCheckpoints bool `query:"include_checkpoints"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	exp, _, err := echoGetExperimentAndCheckCanDoActions(ctx, c, args.ExperimentID,
+		expauth.AuthZProvider.Get().CanGetExperimentArtifacts)
+	if err != nil {
+		return err
+	}
+	if !model.TerminalStates[exp.State] {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("experiment %d is in state %s and can only be exported once terminal",
+				exp.ID, exp.State))
+	}
+
+	ext := "tar"
+	if mimeType == MIMEApplicationGZip {
+		ext = "tar.gz"
+	}
+	c.Response().Header().Set(echo.HeaderContentType, mimeType)
+	c.Response().Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="exp%d_bundle.%s"`, exp.ID, ext))
+
+	// Like checkpoint downloads, delay the first write so that early failures are reported.
+	dw := newDelayWriter(c.Response(), 16*1024)
+	aw, err := archive.NewArchiveWriter(dw, mimeToArchiveType(mimeType))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = expbundle.Export(ctx, aw, exp.ID, expbundle.ExportOptions{
+		ClusterID:              m.ClusterID,
+		IncludeCheckpointFiles: args.IncludeCheckpoints,
+	})
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		return err
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError,
+			fmt.Sprintf("unable to export experiment %d: %s", exp.ID, err))
+	}
+	for _, v := range []io.Closer{aw, dw} {
+		if err := v.Close(); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError,
+				fmt.Sprintf("failed to complete experiment export: %s", err))
+		}
+	}
+	return nil
+}
+
+// importExperimentResponse is the response to importing an experiment bundle.
+type importExperimentResponse struct {
+	// ExperimentID is the ID of the imported experiment.
+	ExperimentID int `json:"experiment_id"`
+	// Created is false if the bundle was imported before.
+	Created bool `json:"created"`
+}
+
+//	@Summary	Import an experiment from a bundle in a tar or tgz file.
+//	@Tags		Experiments
+//	@ID			import-experiment
+//	@Accept		application/x-tar,application/gzip
+//	@Produce	json
+//	@Param		project_id	query	int		false	"Project to import into, by default that of the exported experiment"
+//	@Param		bundle		body	string	true	"The bundle"
+//	@Success	200			{}		string	"The imported experiment ID and whether it was created"
+//	@Router		/experiments/import [post]
+//
+// Read why this line exists on the comment on getAggregatedResourceAllocation in core.go.
+func (m *Master) importExperiment(c echo.Context) error {
+	mimeType := c.Request().Header.Get(echo.HeaderContentType)
+	if mimeType != MIMEApplicationXTar && mimeType != MIMEApplicationGZip {
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+			fmt.Sprintf("unsupported media type to import an experiment: '%s'", mimeType))
+	}
+
+	args := struct {
+		ProjectID int `query:"project_id"`
+	}{}
+	if err := api.BindArgs(&args, c); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	curUser := c.(*detContext.DetContext).MustGetUser()
+	ar, err := archive.NewArchiveReader(c.Request().Body, mimeToArchiveType(mimeType))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	defer ar.Close()
+
+	var errAuthz error
+	res, err := expbundle.Import(ctx, ar, expbundle.ImportOptions{
+		ProjectID:         args.ProjectID,
+		User:              curUser,
+		CheckpointStorage: &m.config.CheckpointStorage,
+		Authorize: func(ctx context.Context, projectID int) error {
+			p := &projectv1.Project{}
+			if err := m.db.QueryProto("get_project", p, projectID); errors.Is(err, db.ErrNotFound) {
+				errAuthz = api.NotFoundErrs("project", strconv.Itoa(projectID), false)
+				return errAuthz
+			} else if err != nil {
+				return err
+			}
+			if err := expauth.AuthZProvider.Get().CanCreateExperiment(ctx, curUser, p); err != nil {
+				errAuthz = echo.NewHTTPError(http.StatusForbidden, err.Error())
+				return errAuthz
+			}
+			return nil
+		},
+	})
+	switch {
+	case errAuthz != nil:
+		return errAuthz
+	case errors.Is(err, db.ErrInvalidInput):
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("unable to import experiment: %s", err))
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError,
+			fmt.Sprintf("unable to import experiment: %s", err))
+	}
+	return c.JSON(http.StatusOK, importExperimentResponse{
+		ExperimentID: res.ExperimentID,
+		Created:      res.Created,
+	})
+}
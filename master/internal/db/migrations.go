@@ -208,21 +208,39 @@ func (db *PgDB) dropDBCode(dbCodeDir string) error {
 	return nil
 }
 
-// This is set in an init in postgres_test_utils.go behind the intg feature flag.
-// For normal usages this won't build. For tests we need to serialize access to
-// run migrations.
-var testOnlyDBLock func(sql *sqlx.DB) (unlock func())
+// migrationsLockID is the advisory lock that serializes migrations.
+const migrationsLockID = 0x33ad0708c9bed25b // Chosen arbitrarily.
+
+// lockMigrations takes the migrations advisory lock, held until unlock is called. Several masters
+// in a highly available deployment, or several processes in integration tests, can be running
+// migrations at once, which can lead to errors because PostgreSQL's CREATE TABLE IF NOT EXISTS is
+// not great with concurrency.
+func lockMigrations(sql *sqlx.DB) (unlock func(), err error) {
+	tx, err := sql.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("starting migrations lock transaction: %w", err)
+	}
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", migrationsLockID); err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("taking migrations lock: %w", err)
+	}
+	return func() {
+		if err := tx.Commit(); err != nil {
+			log.WithError(err).Error("failed to release migrations lock")
+			_ = tx.Rollback()
+		}
+	}, nil
+}
 
 // Migrate runs the migrations from the specified directory URL.
 func (db *PgDB) Migrate(
 	migrationURL string, dbCodeDir string, actions []string,
 ) error {
-	if testOnlyDBLock != nil {
-		// In integration tests, multiple processes can be running this code at once, which can lead to
-		// errors because PostgreSQL's CREATE TABLE IF NOT EXISTS is not great with concurrency.
-		cleanup := testOnlyDBLock(db.sql)
-		defer cleanup()
+	unlock, err := lockMigrations(db.sql)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
 	dbCodeFiles, hash, needToUpdateDBCode, err := db.readDBCodeAndCheckIfDifferent(dbCodeDir)
 	if err != nil {
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -89,6 +90,62 @@ func NonExperimentTasksContextDirectory(ctx context.Context, tID model.TaskID) (
 	return res.ContextDirectory, nil
 }
 
+// MaxTaskMetadataSize is the largest size of the metadata of a task, in bytes of JSON.
+const MaxTaskMetadataSize = 64 * 1024
+
+// taskMetadata corresponds to a row in the "task_metadata" DB table.
+type taskMetadata struct {
+	bun.BaseModel `bun:"table:task_metadata"`
+
+	TaskID   model.TaskID   `bun:"task_id,pk"`
+	Metadata map[string]any `bun:"metadata,type:jsonb,notnull"`
+}
+
+// GetTaskMetadata returns the metadata of a task, which is empty if none was set.
+func GetTaskMetadata(ctx context.Context, tID model.TaskID) (map[string]any, error) {
+	var md taskMetadata
+	switch err := Bun().NewSelect().Model(&md).Where("task_id = ?", tID).Scan(ctx); {
+	case errors.Is(err, sql.ErrNoRows):
+		return map[string]any{}, nil
+	case err != nil:
+		return nil, fmt.Errorf("querying metadata of task %s: %w", tID, err)
+	}
+	if md.Metadata == nil {
+		md.Metadata = map[string]any{}
+	}
+	return md.Metadata, nil
+}
+
+// MergeTaskMetadata merges metadata into the metadata of a task, replacing existing top-level
+// keys, and returns the result. It returns ErrInvalidInput if the result would be larger than
+// MaxTaskMetadataSize.
+func MergeTaskMetadata(
+	ctx context.Context, tID model.TaskID, metadata map[string]any,
+) (map[string]any, error) {
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("encoding metadata of task %s: %w", tID, err)
+	}
+	tooLarge := fmt.Errorf("%w: metadata of task %s would exceed %d bytes",
+		ErrInvalidInput, tID, MaxTaskMetadataSize)
+	if len(b) > MaxTaskMetadataSize {
+		return nil, tooLarge
+	}
+
+	var merged taskMetadata
+	switch err := Bun().NewRaw(`
+INSERT INTO task_metadata (task_id, metadata) VALUES (?, ?::jsonb)
+ON CONFLICT (task_id) DO UPDATE SET metadata = task_metadata.metadata || EXCLUDED.metadata
+WHERE octet_length((task_metadata.metadata || EXCLUDED.metadata)::text) <= ?
+RETURNING task_id, metadata`, tID, string(b), MaxTaskMetadataSize).Scan(ctx, &merged); {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, tooLarge
+	case err != nil:
+		return nil, fmt.Errorf("merging metadata of task %s: %w", tID, MatchSentinelError(err))
+	}
+	return merged.Metadata, nil
+}
+
 // TaskCompleted checks if the end time exists for a task, if so, the task has completed.
 func TaskCompleted(ctx context.Context, tID model.TaskID) (bool, error) {
 	return Bun().NewSelect().Table("tasks").
@@ -64,28 +64,6 @@ type Model struct {
 	WorkspaceID     int           `db:"workspace_id" json:"workspace_id"`
 }
 
-func init() {
-	testOnlyDBLock = func(sql *sqlx.DB) (unlock func()) {
-		tx, err := sql.Beginx()
-		if err != nil {
-			panic(err)
-		}
-
-		const lockID = 0x33ad0708c9bed25b // Chosen arbitrarily.
-		if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", lockID); err != nil {
-			_ = tx.Rollback()
-			panic(err)
-		}
-
-		return func() {
-			if err := tx.Commit(); err != nil {
-				_ = tx.Rollback()
-				panic(err)
-			}
-		}
-	}
-}
-
 // ResolveTestPostgres resolves a connection to a postgres database. To debug tests that use this
 // (or otherwise run the tests outside of the Makefile), make sure to set
 // DET_INTEGRATION_POSTGRES_URL.
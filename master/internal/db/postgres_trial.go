@@ -26,11 +26,18 @@ import (
 
 // AddTrial adds the trial to the database and sets its ID.
 func AddTrial(ctx context.Context, trial *model.Trial, taskID model.TaskID) error {
+	return Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return AddTrialTx(ctx, tx, trial, taskID)
+	})
+}
+
+// AddTrialTx adds the trial to the database in a transaction and sets its ID.
+func AddTrialTx(ctx context.Context, tx bun.Tx, trial *model.Trial, taskID model.TaskID) error {
 	if trial.ID != 0 {
 		return errors.Errorf("error adding a trial with non-zero id %v", trial.ID)
 	}
 
-	err := Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+	err := func() error {
 		run, v2, err := trialToRunAndTrialV2(ctx, tx, trial)
 		if err != nil {
 			return fmt.Errorf("converting trial to run and trialv2: %w", err)
@@ -112,7 +119,7 @@ func AddTrial(ctx context.Context, trial *model.Trial, taskID model.TaskID) erro
 			}
 		}
 		return nil
-	})
+	}()
 	if err != nil {
 		return fmt.Errorf("inserting trial %v: %w", trial, err)
 	}
@@ -833,6 +840,18 @@ func calculateNewSummaryMetrics(
 
 // AddCheckpointMetadata persists metadata for a completed checkpoint to the database.
 func AddCheckpointMetadata(ctx context.Context, m *model.CheckpointV2, runID int) error {
+	err := Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return AddCheckpointMetadataTx(ctx, tx, m, runID)
+	})
+	if err != nil {
+		return fmt.Errorf("error adding checkpoint metadata: %w", err)
+	}
+
+	return nil
+}
+
+// AddCheckpointMetadataTx persists metadata for checkpoint m in a transaction.
+func AddCheckpointMetadataTx(ctx context.Context, tx bun.Tx, m *model.CheckpointV2, runID int) error {
 	if m.ReportTime.IsZero() {
 		m.ReportTime = time.Now().UTC()
 	}
@@ -846,26 +865,19 @@ func AddCheckpointMetadata(ctx context.Context, m *model.CheckpointV2, runID int
 	}
 	m.Size = size
 
-	err := Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		if _, err := tx.NewInsert().Model(m).Exec(ctx); err != nil {
-			return fmt.Errorf("inserting checkpoint model: %w", err)
-		}
-
-		if _, err := tx.NewInsert().Model(&model.RunCheckpoints{
-			RunID:        runID,
-			CheckpointID: m.UUID,
-		}).Exec(ctx); err != nil {
-			return fmt.Errorf("inserting checkpoint run model: %w", err)
-		}
+	if _, err := tx.NewInsert().Model(m).Exec(ctx); err != nil {
+		return fmt.Errorf("inserting checkpoint model: %w", err)
+	}
 
-		if err := UpdateCheckpointSizeTx(ctx, tx, []uuid.UUID{m.UUID}); err != nil {
-			return fmt.Errorf("updating checkpoint size: %w", err)
-		}
+	if _, err := tx.NewInsert().Model(&model.RunCheckpoints{
+		RunID:        runID,
+		CheckpointID: m.UUID,
+	}).Exec(ctx); err != nil {
+		return fmt.Errorf("inserting checkpoint run model: %w", err)
+	}
 
-		return nil
-	})
-	if err != nil {
-		return fmt.Errorf("error adding checkpoint metadata: %w", err)
+	if err := UpdateCheckpointSizeTx(ctx, tx, []uuid.UUID{m.UUID}); err != nil {
+		return fmt.Errorf("updating checkpoint size: %w", err)
 	}
 
 	return nil
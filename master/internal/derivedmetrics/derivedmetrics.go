@@ -0,0 +1,259 @@
+// Package derivedmetrics implements metrics that are defined per project as expressions over the
+// metrics and hyperparameters of its runs.
+package derivedmetrics
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/projectv1"
+)
+
+// ColumnPrefix prefixes the names of derived metrics in columns, filters, sorts and searcher
+// metrics.
+const ColumnPrefix = "derived."
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+
+// DerivedMetric is a named expression over the metrics and hyperparameters of a project.
+type DerivedMetric struct {
+	bun.BaseModel `bun:"table:project_derived_metrics"`
+
+	ID         int       `bun:"id,pk,autoincrement"`
+	ProjectID  int       `bun:"project_id,notnull"`
+	Name       string    `bun:"name,notnull"`
+	Expression string    `bun:"expression,notnull"`
+	CreatedAt  time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// Proto converts a derived metric to its proto representation.
+func (d *DerivedMetric) Proto() *projectv1.DerivedMetric {
+	return &projectv1.DerivedMetric{
+		Id:         int32(d.ID),
+		ProjectId:  int32(d.ProjectID),
+		Name:       d.Name,
+		Expression: d.Expression,
+	}
+}
+
+// Column returns the name of the column of the derived metric.
+func (d *DerivedMetric) Column() string {
+	return ColumnPrefix + d.Name
+}
+
+// ValidateName checks that a name can be used for a derived metric.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid derived metric name %q: names must start with a letter or "+
+			"underscore, contain only letters, digits and underscores and be at most 64 characters",
+			name)
+	}
+	return nil
+}
+
+// Add defines a derived metric in a project. It returns db.ErrDuplicateRecord if a derived
+// metric of the same name exists and db.ErrInvalidInput if the name or expression is invalid.
+func Add(ctx context.Context, projectID int, name, expression string) (*DerivedMetric, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, fmt.Errorf("%w: %s", db.ErrInvalidInput, err)
+	}
+	if _, err := Parse(expression); err != nil {
+		return nil, fmt.Errorf("%w: invalid expression: %s", db.ErrInvalidInput, err)
+	}
+	d := &DerivedMetric{ProjectID: projectID, Name: name, Expression: expression}
+	if _, err := db.Bun().NewInsert().Model(d).Returning("*").Exec(ctx); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return d, nil
+}
+
+// List returns the derived metrics of a project ordered by name.
+func List(ctx context.Context, projectID int) ([]*DerivedMetric, error) {
+	ds := []*DerivedMetric{}
+	if err := db.Bun().NewSelect().Model(&ds).
+		Where("project_id = ?", projectID).
+		Order("name").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Delete deletes a derived metric of a project. It returns db.ErrNotFound if there is none.
+func Delete(ctx context.Context, projectID int, name string) error {
+	return db.MustHaveAffectedRows(db.Bun().NewDelete().Model((*DerivedMetric)(nil)).
+		Where("project_id = ?", projectID).
+		Where("name = ?", name).
+		Exec(ctx))
+}
+
+// ValidateSearcherMetric checks that a searcher metric named `derived.<name>` refers to a derived
+// metric of the project of the experiment. It returns db.ErrInvalidInput if it does not.
+func ValidateSearcherMetric(ctx context.Context, projectID int, metric string) error {
+	name, ok := strings.CutPrefix(metric, ColumnPrefix)
+	if !ok {
+		return nil
+	}
+	exists, err := db.Bun().NewSelect().Model((*DerivedMetric)(nil)).
+		Where("project_id = ?", projectID).
+		Where("name = ?", name).
+		Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("checking derived metric %s: %w", name, err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: searcher metric %s is not a derived metric of project %d",
+			db.ErrInvalidInput, metric, projectID)
+	}
+	return nil
+}
+
+// Set is the parsed derived metrics of a project by name.
+type Set map[string]*Expr
+
+// ByProject returns the parsed derived metrics of a project.
+func ByProject(ctx context.Context, projectID int) (Set, error) {
+	ds, err := List(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return NewSet(ds)
+}
+
+// NewSet parses derived metrics into a Set.
+func NewSet(ds []*DerivedMetric) (Set, error) {
+	s := make(Set, len(ds))
+	for _, d := range ds {
+		e, err := Parse(d.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("parsing derived metric %s: %w", d.Name, err)
+		}
+		s[d.Name] = e
+	}
+	return s, nil
+}
+
+// Column returns the expression of a column named `derived.<name>`.
+func (s Set) Column(column string) (*Expr, error) {
+	name, ok := strings.CutPrefix(column, ColumnPrefix)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a derived metric column", column)
+	}
+	e, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown derived metric %s, derived metrics can only be used "+
+			"when searching within the project that defines them", column)
+	}
+	return e, nil
+}
+
+// Eval evaluates all the derived metrics of the set by name, omitting those that are undefined.
+func (s Set) Eval(v Values) map[string]any {
+	res := make(map[string]any, len(s))
+	for name, e := range s {
+		if x, ok := e.Eval(v); ok {
+			res[name] = x
+		}
+	}
+	return res
+}
+
+// SummaryValues resolves references against the summary metrics and hyperparameters of a run.
+type SummaryValues struct {
+	SummaryMetrics map[string]any
+	Hparams        map[string]any
+}
+
+// Metric implements Values.
+func (s SummaryValues) Metric(group, name, qualifier string) (float64, bool) {
+	g, _ := s.SummaryMetrics[group].(map[string]any)
+	m, _ := g[name].(map[string]any)
+	x, ok := m[qualifier].(float64)
+	return x, ok
+}
+
+// Hparam implements Values.
+func (s SummaryValues) Hparam(path []string) (float64, bool) {
+	return lookupNumber(s.Hparams, path)
+}
+
+// ReportValues resolves references against a single report of metrics of a trial. As a report
+// holds a single value per metric, all qualifiers refer to that value.
+type ReportValues struct {
+	Group   model.MetricGroup
+	Metrics map[string]any
+	Hparams map[string]any
+}
+
+// Metric implements Values.
+func (r ReportValues) Metric(group, name, _ string) (float64, bool) {
+	if group != SummaryGroup(string(r.Group)) {
+		return 0, false
+	}
+	x, ok := r.Metrics[name].(float64)
+	return x, ok
+}
+
+// Hparam implements Values.
+func (r ReportValues) Hparam(path []string) (float64, bool) {
+	return lookupNumber(r.Hparams, path)
+}
+
+func lookupNumber(m map[string]any, path []string) (float64, bool) {
+	for _, p := range path[:len(path)-1] {
+		m, _ = m[p].(map[string]any)
+	}
+	x, ok := m[path[len(path)-1]].(float64)
+	return x, ok
+}
+
+// SearcherMetric is a derived metric used as the searcher metric of the experiment of a trial.
+type SearcherMetric struct {
+	Name string
+	Expr *Expr
+	// Hparams are the hyperparameters of the trial.
+	Hparams map[string]any
+}
+
+// SearcherMetricOfTrial returns the derived metric that the experiment of a trial uses as its
+// searcher metric, or nil if its searcher metric is not a derived metric of its project.
+func SearcherMetricOfTrial(ctx context.Context, trialID int) (*SearcherMetric, error) {
+	var rows []struct {
+		Name       string         `bun:"name"`
+		Expression string         `bun:"expression"`
+		Hparams    map[string]any `bun:"hparams"`
+	}
+	if err := db.Bun().NewSelect().TableExpr("runs r").
+		ColumnExpr("d.name, d.expression, r.hparams").
+		Join("JOIN experiments e ON e.id = r.experiment_id").
+		Join("JOIN project_derived_metrics d ON d.project_id = e.project_id").
+		Where("r.id = ?", trialID).
+		Where("? || d.name = e.config->'searcher'->>'metric'", ColumnPrefix).
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	e, err := Parse(rows[0].Expression)
+	if err != nil {
+		return nil, fmt.Errorf("parsing derived metric %s: %w", rows[0].Name, err)
+	}
+	return &SearcherMetric{Name: rows[0].Name, Expr: e, Hparams: rows[0].Hparams}, nil
+}
+
+// Eval evaluates the searcher metric for a report of validation metrics.
+func (s *SearcherMetric) Eval(metrics map[string]any) (float64, bool) {
+	return s.Expr.Eval(ReportValues{
+		Group:   model.ValidationMetricGroup,
+		Metrics: metrics,
+		Hparams: s.Hparams,
+	})
+}
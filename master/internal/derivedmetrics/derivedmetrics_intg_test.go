@@ -0,0 +1,107 @@
+//go:build integration
+// +build integration
+
+package derivedmetrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestDerivedMetrics(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, etc.SetRootPath(db.RootFromDB))
+	pgDB, closeDB := db.MustResolveTestPostgres(t)
+	defer closeDB()
+	db.MustMigrateTestPostgres(t, pgDB, db.MigrationsFromDB)
+	user := db.RequireMockUser(t, pgDB)
+	exp := db.RequireMockExperiment(t, pgDB, user)
+	trial, _ := db.RequireMockTrial(t, pgDB, exp)
+
+	_, err := db.Bun().NewUpdate().Table("runs").
+		Set("summary_metrics = ?", map[string]any{
+			"validation_metrics": map[string]any{
+				"accuracy":   map[string]any{"last": 0.9, "type": "number"},
+				"latency_ms": map[string]any{"last": 0.2, "type": "number"},
+			},
+		}).
+		Set("hparams = ?", map[string]any{"lr": 0.1, "batch_size": 32}).
+		Where("id = ?", trial.ID).
+		Exec(ctx)
+	require.NoError(t, err)
+
+	score, err := Add(ctx, exp.ProjectID, "score", "validation.accuracy - 0.5 * validation.latency_ms")
+	require.NoError(t, err)
+	require.NotZero(t, score.ID)
+	_, err = Add(ctx, exp.ProjectID, "scale", "hp.lr * hp.batch_size")
+	require.NoError(t, err)
+	_, err = Add(ctx, exp.ProjectID, "ratio", "validation.accuracy / (hp.lr - 0.1)")
+	require.NoError(t, err)
+	_, err = Add(ctx, exp.ProjectID, "overflow", "max(validation.accuracy * 1e308 * 10, 1)")
+	require.NoError(t, err)
+	_, err = Add(ctx, exp.ProjectID, "underflow", "validation.accuracy * 1e-300 * 1e-300")
+	require.NoError(t, err)
+
+	_, err = Add(ctx, exp.ProjectID, "score", "1")
+	require.ErrorIs(t, err, db.ErrDuplicateRecord)
+	_, err = Add(ctx, exp.ProjectID, "bad", "validation.accuracy +")
+	require.ErrorIs(t, err, db.ErrInvalidInput)
+	_, err = Add(ctx, exp.ProjectID, "bad.name", "1")
+	require.ErrorIs(t, err, db.ErrInvalidInput)
+
+	ds, err := List(ctx, exp.ProjectID)
+	require.NoError(t, err)
+	require.Len(t, ds, 5)
+	require.Equal(t, "overflow", ds[0].Name)
+
+	set, err := NewSet(ds)
+	require.NoError(t, err)
+	for name, want := range map[string]*float64{
+		"score":     ptrs.Ptr(0.8),
+		"scale":     ptrs.Ptr(3.2),
+		"ratio":     nil,
+		"overflow":  ptrs.Ptr(1.0),
+		"underflow": ptrs.Ptr(0.0),
+	} {
+		exprSQL, args := set[name].SQL()
+		var got *float64
+		require.NoError(t, db.Bun().NewSelect().TableExpr("runs r").
+			ColumnExpr(exprSQL, args...).
+			Where("r.id = ?", trial.ID).
+			Scan(ctx, &got), name)
+		if want == nil {
+			require.Nil(t, got, name)
+		} else {
+			require.InDelta(t, *want, *got, 1e-9, name)
+		}
+	}
+
+	sm, err := SearcherMetricOfTrial(ctx, trial.ID)
+	require.NoError(t, err)
+	require.Nil(t, sm)
+	_, err = db.Bun().NewUpdate().Table("experiments").
+		Set("config = jsonb_set(config, '{searcher,metric}', ?)", `"derived.score"`).
+		Where("id = ?", exp.ID).
+		Exec(ctx)
+	require.NoError(t, err)
+	sm, err = SearcherMetricOfTrial(ctx, trial.ID)
+	require.NoError(t, err)
+	require.NotNil(t, sm)
+	require.Equal(t, "score", sm.Name)
+	v, ok := sm.Eval(map[string]any{"accuracy": 0.5, "latency_ms": 0.4})
+	require.True(t, ok)
+	require.InDelta(t, 0.3, v, 1e-9)
+
+	require.NoError(t, Delete(ctx, exp.ProjectID, "score"))
+	require.ErrorIs(t, Delete(ctx, exp.ProjectID, "score"), db.ErrNotFound)
+	sm, err = SearcherMetricOfTrial(ctx, trial.ID)
+	require.NoError(t, err)
+	require.Nil(t, sm)
+}
@@ -0,0 +1,638 @@
+package derivedmetrics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxExpressionLength is the maximum length of an expression.
+const MaxExpressionLength = 1024
+
+// MaxExpressionDepth is the maximum nesting depth of operations and function calls in an
+// expression, which bounds the nesting of the subqueries it translates to.
+const MaxExpressionDepth = 32
+
+const (
+	hpPrefix = "hp"
+
+	summaryGroupTraining   = "avg_metrics"
+	summaryGroupValidation = "validation_metrics"
+	defaultQualifier       = "last"
+)
+
+var qualifiers = map[string]bool{"min": true, "max": true, "mean": true, "last": true}
+
+// SummaryGroup maps a metric group as written in expressions, such as `validation`, to the key of
+// the group in the summary metrics of a run.
+func SummaryGroup(group string) string {
+	switch group {
+	case "training":
+		return summaryGroupTraining
+	case "validation":
+		return summaryGroupValidation
+	default:
+		return group
+	}
+}
+
+// Values resolves the references of an expression.
+type Values interface {
+	// Metric returns the value of a summary metric, where group is a summary group key as
+	// returned by SummaryGroup.
+	Metric(group, name, qualifier string) (float64, bool)
+	// Hparam returns the value of a, possibly nested, numeric hyperparameter.
+	Hparam(path []string) (float64, bool)
+}
+
+// Expr is a parsed derived metric expression. It supports numbers, the operators `+ - * /`,
+// parentheses, the functions abs, sqrt, log, min and max, and references to metrics, such as
+// `validation.accuracy` or `training.loss.min`, and to hyperparameters, such as `hp.optimizer.lr`.
+// References with unusual characters are quoted in backticks, as in `validation.top-5`.
+//
+// A metric reference without a min, max, mean or last qualifier refers to the last value. A
+// reference to a missing or non-numeric value, division by zero, results out of the float range
+// and the square root or logarithm of a value out of their domain are undefined and make the whole
+// expression undefined, except that min and max ignore undefined arguments.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses an expression.
+func Parse(src string) (*Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+	if len(src) > MaxExpressionLength {
+		return nil, fmt.Errorf("expression is longer than %d characters", MaxExpressionLength)
+	}
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+	}
+	if depth(root) > MaxExpressionDepth {
+		return nil, fmt.Errorf("expression is nested deeper than %d levels", MaxExpressionDepth)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// SQL translates the expression to a float8 SQL expression over the runs table aliased as `r`.
+// All references and numbers are placeholders bound to the returned arguments so the result can
+// be passed to bun as is.
+func (e *Expr) SQL() (string, []any) {
+	var b sqlBuilder
+	e.root.sql(&b)
+	return b.String(), b.args
+}
+
+// Eval evaluates the expression. It returns false if the result is undefined.
+func (e *Expr) Eval(v Values) (float64, bool) {
+	res, ok := e.root.eval(v)
+	if !ok || math.IsNaN(res) || math.IsInf(res, 0) {
+		return 0, false
+	}
+	return res, true
+}
+
+type sqlBuilder struct {
+	strings.Builder
+	args []any
+}
+
+func (b *sqlBuilder) arg(a any) {
+	b.WriteString("?")
+	b.args = append(b.args, a)
+}
+
+type node interface {
+	sql(b *sqlBuilder)
+	eval(v Values) (float64, bool)
+}
+
+type numberNode float64
+
+func (n numberNode) sql(b *sqlBuilder) {
+	b.WriteString("CAST(")
+	b.arg(float64(n))
+	b.WriteString(" AS float8)")
+}
+
+func (n numberNode) eval(Values) (float64, bool) {
+	return float64(n), true
+}
+
+type metricNode struct {
+	group, name, qualifier string
+}
+
+func (n metricNode) sql(b *sqlBuilder) {
+	b.WriteString("(CASE WHEN jsonb_typeof(r.summary_metrics->")
+	b.arg(n.group)
+	b.WriteString("->")
+	b.arg(n.name)
+	b.WriteString("->")
+	b.arg(n.qualifier)
+	b.WriteString(") = 'number' THEN (r.summary_metrics->")
+	b.arg(n.group)
+	b.WriteString("->")
+	b.arg(n.name)
+	b.WriteString("->>")
+	b.arg(n.qualifier)
+	b.WriteString(")::float8 END)")
+}
+
+func (n metricNode) eval(v Values) (float64, bool) {
+	return v.Metric(n.group, n.name, n.qualifier)
+}
+
+type hparamNode struct {
+	path []string
+}
+
+func (n hparamNode) sql(b *sqlBuilder) {
+	b.WriteString("(CASE WHEN jsonb_typeof(r.hparams")
+	for _, p := range n.path {
+		b.WriteString("->")
+		b.arg(p)
+	}
+	b.WriteString(") = 'number' THEN (r.hparams")
+	for i, p := range n.path {
+		if i == len(n.path)-1 {
+			b.WriteString("->>")
+		} else {
+			b.WriteString("->")
+		}
+		b.arg(p)
+	}
+	b.WriteString(")::float8 END)")
+}
+
+func (n hparamNode) eval(v Values) (float64, bool) {
+	return v.Hparam(n.path)
+}
+
+type negNode struct {
+	x node
+}
+
+func (n negNode) sql(b *sqlBuilder) {
+	b.WriteString("(-")
+	n.x.sql(b)
+	b.WriteString(")")
+}
+
+func (n negNode) eval(v Values) (float64, bool) {
+	x, ok := n.x.eval(v)
+	return -x, ok
+}
+
+type binaryNode struct {
+	op   byte
+	x, y node
+}
+
+// sqlMaxMagnitude is just below the largest float8, leaving room for the 15 significant digits
+// that Postgres keeps when converting float8 to numeric.
+const sqlMaxMagnitude = "1.7976931348623e308"
+
+// sqlUnderflowMagnitude is the magnitude below which products and quotients round to zero, for
+// which Postgres raises an underflow error.
+const sqlUnderflowMagnitude = "2.5e-324"
+
+func (n binaryNode) sql(b *sqlBuilder) {
+	// Postgres raises errors for results out of the float8 range, so check the result in numeric
+	// first to make overflows undefined and underflows zero, as in Eval. The subqueries evaluate
+	// each operand once.
+	op := string(n.op)
+	b.WriteString("(SELECT CASE WHEN abs(v) > " + sqlMaxMagnitude + " THEN NULL")
+	if n.op == '*' || n.op == '/' {
+		b.WriteString(" WHEN abs(v) < " + sqlUnderflowMagnitude + " THEN 0")
+	}
+	b.WriteString(" ELSE x " + op + " y END FROM (SELECT x, y, x::numeric " + op +
+		" y::numeric AS v FROM (SELECT ")
+	n.x.sql(b)
+	b.WriteString(" AS x, ")
+	if n.op == '/' {
+		b.WriteString("NULLIF(")
+		n.y.sql(b)
+		b.WriteString(", 0)")
+	} else {
+		n.y.sql(b)
+	}
+	b.WriteString(" AS y) xy) xyv)")
+}
+
+func (n binaryNode) eval(v Values) (float64, bool) {
+	x, ok := n.x.eval(v)
+	if !ok {
+		return 0, false
+	}
+	y, ok := n.y.eval(v)
+	if !ok {
+		return 0, false
+	}
+	var res float64
+	switch n.op {
+	case '+':
+		res = x + y
+	case '-':
+		res = x - y
+	case '*':
+		res = x * y
+	default:
+		if y == 0 {
+			return 0, false
+		}
+		res = x / y
+	}
+	// Results out of range are undefined, as in the SQL, even if min or max would drop them.
+	if math.IsInf(res, 0) {
+		return 0, false
+	}
+	return res, true
+}
+
+type callNode struct {
+	fn   string
+	args []node
+}
+
+type function struct {
+	minArgs, maxArgs int
+}
+
+var functions = map[string]function{
+	"abs":  {1, 1},
+	"sqrt": {1, 1},
+	"log":  {1, 1},
+	"min":  {2, -1},
+	"max":  {2, -1},
+}
+
+func (n callNode) sql(b *sqlBuilder) {
+	switch n.fn {
+	case "abs":
+		b.WriteString("abs(")
+		n.args[0].sql(b)
+		b.WriteString(")")
+	case "sqrt", "log":
+		// Postgres raises errors outside of the domains, so guard them to be undefined instead.
+		// The subquery evaluates the argument once.
+		guard := "x >= 0 THEN sqrt(x)"
+		if n.fn == "log" {
+			guard = "x > 0 THEN ln(x)"
+		}
+		b.WriteString("(SELECT CASE WHEN " + guard + " END FROM (SELECT ")
+		n.args[0].sql(b)
+		b.WriteString(" AS x) x)")
+	case "min", "max":
+		fn := "LEAST("
+		if n.fn == "max" {
+			fn = "GREATEST("
+		}
+		b.WriteString(fn)
+		for i, a := range n.args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			a.sql(b)
+		}
+		b.WriteString(")")
+	}
+}
+
+func (n callNode) eval(v Values) (float64, bool) {
+	if n.fn == "min" || n.fn == "max" {
+		// Like LEAST and GREATEST, ignore undefined arguments.
+		var res float64
+		found := false
+		for _, a := range n.args {
+			x, ok := a.eval(v)
+			switch {
+			case !ok:
+			case !found, n.fn == "min" && x < res, n.fn == "max" && x > res:
+				res, found = x, true
+			}
+		}
+		return res, found
+	}
+
+	x, ok := n.args[0].eval(v)
+	if !ok {
+		return 0, false
+	}
+	switch n.fn {
+	case "abs":
+		return math.Abs(x), true
+	case "sqrt":
+		if x < 0 {
+			return 0, false
+		}
+		return math.Sqrt(x), true
+	default:
+		if x <= 0 {
+			return 0, false
+		}
+		return math.Log(x), true
+	}
+}
+
+// depth returns the nesting depth of a node.
+func depth(n node) int {
+	var children []node
+	switch n := n.(type) {
+	case negNode:
+		children = []node{n.x}
+	case binaryNode:
+		children = []node{n.x, n.y}
+	case callNode:
+		children = n.args
+	}
+	d := 0
+	for _, c := range children {
+		d = max(d, depth(c))
+	}
+	return d + 1
+}
+
+// resolveRef turns a reference such as `validation.loss.min` or `hp.lr` into a node.
+func resolveRef(ref string) (node, error) {
+	parts := strings.Split(ref, ".")
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid reference %q", ref)
+		}
+	}
+	if len(parts) < 2 {
+		return nil, fmt.Errorf(
+			"invalid reference %q, expected a metric such as validation.%s or a hyperparameter "+
+				"such as hp.%s", ref, ref, ref)
+	}
+	if parts[0] == hpPrefix {
+		return hparamNode{path: parts[1:]}, nil
+	}
+	qualifier := defaultQualifier
+	if last := parts[len(parts)-1]; len(parts) > 2 && qualifiers[last] {
+		qualifier = last
+		parts = parts[:len(parts)-1]
+	}
+	return metricNode{
+		group:     SummaryGroup(parts[0]),
+		name:      strings.Join(parts[1:], "."),
+		qualifier: qualifier,
+	}, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokRef
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r < unicode.MaxASCII && unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || r == '.' || '0' <= r && r <= '9'
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case '0' <= r && r <= '9', r == '.':
+			j := i
+			for j < len(rs) && ('0' <= rs[j] && rs[j] <= '9' || rs[j] == '.') {
+				j++
+			}
+			if j < len(rs) && (rs[j] == 'e' || rs[j] == 'E') {
+				j++
+				if j < len(rs) && (rs[j] == '+' || rs[j] == '-') {
+					j++
+				}
+				for j < len(rs) && '0' <= rs[j] && rs[j] <= '9' {
+					j++
+				}
+			}
+			text := string(rs[i:j])
+			num, err := strconv.ParseFloat(text, 64)
+			if err != nil || math.IsInf(num, 0) {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, i)
+			}
+			toks = append(toks, token{kind: tokNumber, text: text, num: num, pos: i})
+			i = j
+		case isIdentStart(r):
+			j := i
+			for j < len(rs) && isIdentPart(rs[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: string(rs[i:j]), pos: i})
+			i = j
+		case r == '`':
+			j := i + 1
+			for j < len(rs) && rs[j] != '`' {
+				j++
+			}
+			if j == len(rs) {
+				return nil, fmt.Errorf("unterminated quoted reference at position %d", i)
+			}
+			toks = append(toks, token{kind: tokRef, text: string(rs[i+1 : j]), pos: i})
+			i = j + 1
+		case strings.ContainsRune("+-*/(),", r):
+			toks = append(toks, token{kind: tokPunct, text: string(r), pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(rs)}), nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) accept(punct string) bool {
+	if t := p.peek(); t.kind == tokPunct && t.text == punct {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(punct string) error {
+	if !p.accept(punct) {
+		t := p.peek()
+		return fmt.Errorf("expected %q but found %s at position %d", punct, t, t.pos)
+	}
+	return nil
+}
+
+// parseExpr parses `term (('+' | '-') term)*`.
+func (p *parser) parseExpr() (node, error) {
+	x, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.accept("+"):
+			op = '+'
+		case p.accept("-"):
+			op = '-'
+		default:
+			return x, nil
+		}
+		y, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		x = binaryNode{op: op, x: x, y: y}
+	}
+}
+
+// parseTerm parses `unary (('*' | '/') unary)*`.
+func (p *parser) parseTerm() (node, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.accept("*"):
+			op = '*'
+		case p.accept("/"):
+			op = '/'
+		default:
+			return x, nil
+		}
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = binaryNode{op: op, x: x, y: y}
+	}
+}
+
+// parseUnary parses `'-' unary | '+' unary | primary`.
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x: x}, nil
+	}
+	if p.accept("+") {
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses a number, a reference, a function call or a parenthesized expression.
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		return numberNode(t.num), nil
+	case t.kind == tokRef:
+		return resolveRef(t.text)
+	case t.kind == tokIdent && p.peek().kind == tokPunct && p.peek().text == "(":
+		return p.parseCall(t)
+	case t.kind == tokIdent:
+		return resolveRef(t.text)
+	case t.kind == tokPunct && t.text == "(":
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return x, nil
+	default:
+		return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []node
+	for {
+		a, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("wrong number of arguments to %s at position %d", name.text, name.pos)
+	}
+	return callNode{fn: name.text, args: args}, nil
+}
@@ -0,0 +1,227 @@
+package derivedmetrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+var testValues = SummaryValues{
+	SummaryMetrics: map[string]any{
+		"validation_metrics": map[string]any{
+			"accuracy":   map[string]any{"last": 0.9, "min": 0.5, "max": 0.95},
+			"latency_ms": map[string]any{"last": 0.2},
+			"top-5":      map[string]any{"last": 0.99},
+			"name":       map[string]any{"last": "text"},
+		},
+		"avg_metrics": map[string]any{
+			"loss": map[string]any{"last": 2.0, "min": 1.0},
+		},
+		"custom": map[string]any{
+			"a.b": map[string]any{"mean": 4.0},
+		},
+	},
+	Hparams: map[string]any{
+		"lr":         0.1,
+		"batch_size": 32.0,
+		"optimizer":  map[string]any{"momentum": 0.5},
+	},
+}
+
+func TestEval(t *testing.T) {
+	cases := map[string]float64{
+		"validation.accuracy - 0.5 * validation.latency_ms": 0.8,
+		"hp.lr * hp.batch_size":                             3.2,
+		"training.loss.min + training.loss":                 3,
+		"validation.accuracy.max":                           0.95,
+		"`validation.top-5`":                                0.99,
+		"custom.a.b.mean":                                   4,
+		"hp.optimizer.momentum":                             0.5,
+		"-(1 + 2) * 3":                                      -9,
+		"1 - 2 - 3":                                         -4,
+		"8 / 4 / 2":                                         1,
+		"abs(-2) + sqrt(16) + log(1)":                       6,
+		"min(3, 1, 2) + max(1, hp.missing, 5)":              6,
+		"1.5e1 + .5":                                        15.5,
+	}
+	for src, want := range cases {
+		e, err := Parse(src)
+		require.NoError(t, err, src)
+		got, ok := e.Eval(testValues)
+		require.True(t, ok, src)
+		require.InDelta(t, want, got, 1e-9, src)
+	}
+}
+
+func TestEvalUndefined(t *testing.T) {
+	for _, src := range []string{
+		"validation.missing",
+		"validation.name",
+		"hp.optimizer",
+		"hp.missing.deep",
+		"1 / (hp.lr - 0.1)",
+		"sqrt(-1)",
+		"log(0)",
+		"min(hp.missing, validation.missing)",
+		"validation.accuracy + hp.missing",
+	} {
+		e, err := Parse(src)
+		require.NoError(t, err, src)
+		_, ok := e.Eval(testValues)
+		require.False(t, ok, src)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"  ",
+		"accuracy",
+		"validation.",
+		"validation..a",
+		"1 +",
+		"(1",
+		"1)",
+		"1 2",
+		"foo(1)",
+		"abs(1, 2)",
+		"min(1)",
+		"abs()",
+		"`validation.a",
+		"validation.a; DROP TABLE runs",
+		"validation.a = 1",
+		"'a'",
+		"1e999",
+		"1..2",
+		strings.Repeat("1+", MaxExpressionLength),
+		strings.Repeat("sqrt(", MaxExpressionDepth) + "1" + strings.Repeat(")", MaxExpressionDepth),
+		strings.Repeat("1+", MaxExpressionDepth) + "1",
+	} {
+		_, err := Parse(src)
+		require.Error(t, err, src)
+	}
+}
+
+func TestSQL(t *testing.T) {
+	e, err := Parse("validation.accuracy / hp.opt.lr")
+	require.NoError(t, err)
+	sql, args := e.SQL()
+	require.Equal(t, "(SELECT CASE WHEN abs(v) > 1.7976931348623e308 THEN NULL "+
+		"WHEN abs(v) < 2.5e-324 THEN 0 ELSE x / y END "+
+		"FROM (SELECT x, y, x::numeric / y::numeric AS v FROM (SELECT "+
+		"(CASE WHEN jsonb_typeof(r.summary_metrics->?->?->?) = 'number' "+
+		"THEN (r.summary_metrics->?->?->>?)::float8 END) AS x, NULLIF("+
+		"(CASE WHEN jsonb_typeof(r.hparams->?->?) = 'number' THEN (r.hparams->?->>?)::float8 END), 0) "+
+		"AS y) xy) xyv)",
+		sql)
+	require.Equal(t, []any{
+		"validation_metrics", "accuracy", "last", "validation_metrics", "accuracy", "last",
+		"opt", "lr", "opt", "lr",
+	}, args)
+	require.Equal(t, strings.Count(sql, "?"), len(args))
+
+	// Quoted references never end up in the SQL itself.
+	e, err = Parse("`validation.x') OR true; --` * 2")
+	require.NoError(t, err)
+	sql, args = e.SQL()
+	require.NotContains(t, sql, "OR true")
+	require.Contains(t, args, "x') OR true; --")
+	require.Equal(t, strings.Count(sql, "?"), len(args))
+}
+
+func TestSQLFunctions(t *testing.T) {
+	e, err := Parse("max(sqrt(2), log(hp.a), -abs(1))")
+	require.NoError(t, err)
+	sql, args := e.SQL()
+	require.Equal(t, "GREATEST("+
+		"(SELECT CASE WHEN x >= 0 THEN sqrt(x) END FROM (SELECT CAST(? AS float8) AS x) x), "+
+		"(SELECT CASE WHEN x > 0 THEN ln(x) END FROM (SELECT "+
+		"(CASE WHEN jsonb_typeof(r.hparams->?) = 'number' THEN (r.hparams->>?)::float8 END) AS x) x), "+
+		"(-abs(CAST(? AS float8))))", sql)
+	require.Equal(t, []any{2.0, "a", "a", 1.0}, args)
+}
+
+func TestSQLSizeOfNestedExpressions(t *testing.T) {
+	// Every operand is written out once, so the SQL grows linearly with the nesting.
+	n := MaxExpressionDepth - 1
+	for _, src := range []string{
+		strings.Repeat("sqrt(", n) + "hp.a" + strings.Repeat(")", n),
+		strings.Repeat("log(", n) + "hp.a" + strings.Repeat(")", n),
+		strings.Repeat("abs(", n) + "hp.a" + strings.Repeat(")", n),
+		strings.Repeat("(1+", n) + "hp.a" + strings.Repeat(")", n),
+		strings.Repeat("-", n) + "hp.a",
+	} {
+		e, err := Parse(src)
+		require.NoError(t, err, src)
+		sql, args := e.SQL()
+		require.Less(t, len(sql), 200*MaxExpressionDepth, src)
+		require.LessOrEqual(t, len(args), 2*MaxExpressionDepth, src)
+	}
+}
+
+func TestReportValues(t *testing.T) {
+	e, err := Parse("validation.accuracy.min * hp.lr + training.loss")
+	require.NoError(t, err)
+	v := ReportValues{
+		Group:   model.ValidationMetricGroup,
+		Metrics: map[string]any{"accuracy": 0.5},
+		Hparams: map[string]any{"lr": 2.0},
+	}
+	_, ok := e.Eval(v)
+	require.False(t, ok)
+
+	e, err = Parse("validation.accuracy.min * hp.lr")
+	require.NoError(t, err)
+	got, ok := e.Eval(v)
+	require.True(t, ok)
+	require.Equal(t, 1.0, got)
+}
+
+func TestEvalOverflow(t *testing.T) {
+	e, err := Parse("1e308 * 10")
+	require.NoError(t, err)
+	_, ok := e.Eval(testValues)
+	require.False(t, ok)
+
+	// An overflow is undefined where it happens, so min and max ignore it.
+	e, err = Parse("max(validation.accuracy * 1e308 * 10, 1)")
+	require.NoError(t, err)
+	got, ok := e.Eval(testValues)
+	require.True(t, ok)
+	require.Equal(t, 1.0, got)
+
+	e, err = Parse("1e-300 * 1e-300")
+	require.NoError(t, err)
+	got, ok = e.Eval(testValues)
+	require.True(t, ok)
+	require.Equal(t, 0.0, got)
+}
+
+func TestSetColumn(t *testing.T) {
+	s, err := NewSet([]*DerivedMetric{{Name: "score", Expression: "validation.accuracy * 2"}})
+	require.NoError(t, err)
+	e, err := s.Column("derived.score")
+	require.NoError(t, err)
+	require.Equal(t, "validation.accuracy * 2", e.String())
+
+	_, err = s.Column("derived.other")
+	require.Error(t, err)
+	_, err = s.Column("score")
+	require.Error(t, err)
+	_, err = Set(nil).Column("derived.score")
+	require.Error(t, err)
+
+	require.Equal(t, map[string]any{"score": 1.8}, s.Eval(testValues))
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"score", "_a1", "Score_2"} {
+		require.NoError(t, ValidateName(name), name)
+	}
+	for _, name := range []string{"", "1a", "a.b", "a-b", "a b", strings.Repeat("a", 65)} {
+		require.Error(t, ValidateName(name), name)
+	}
+}
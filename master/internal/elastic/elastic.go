@@ -1,8 +1,6 @@
 package elastic
 
 import (
-	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"time"
 
@@ -23,7 +21,7 @@ type Elastic struct {
 
 // Setup sets up a new elasticsearch client with the given configuration.
 func Setup(conf model.ElasticLoggingConfig) (*Elastic, error) {
-	tlsCfg, err := elasticTLSConfig(conf.Security.TLS)
+	tlsCfg, err := conf.Security.TLS.TLSConfig()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to make elastic tls config")
 	}
@@ -73,24 +71,3 @@ func Setup(conf model.ElasticLoggingConfig) (*Elastic, error) {
 		log.WithError(err).Warnf("failed to connect to elastic, trying again in %s", toWait)
 	}
 }
-
-func elasticTLSConfig(conf model.TLSClientConfig) (*tls.Config, error) {
-	if !conf.Enabled {
-		return nil, nil
-	}
-
-	var pool *x509.CertPool
-	if conf.CertBytes != nil {
-		pool = x509.NewCertPool()
-		if !pool.AppendCertsFromPEM(conf.CertBytes) {
-			return nil, errors.New("certificate file contains no certificates")
-		}
-	}
-
-	return &tls.Config{
-		InsecureSkipVerify: conf.SkipVerify, //nolint:gosec
-		MinVersion:         tls.VersionTLS12,
-		RootCAs:            pool,
-		ServerName:         conf.CertificateName,
-	}, nil
-}
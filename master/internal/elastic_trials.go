@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
+
+	"github.com/determined-ai/determined/master/internal/elasticslots"
+	"github.com/determined-ai/determined/master/internal/experiment"
+	"github.com/determined-ai/determined/master/internal/rm"
+)
+
+// elasticResizeInterval is how often elastic trials are resized to fit their resource pools.
+const elasticResizeInterval = time.Minute
+
+// resizeElasticTrials periodically grows elastic trials onto idle slots and shrinks them for
+// waiting jobs, until the context is canceled.
+func (m *Master) resizeElasticTrials(ctx context.Context) {
+	t := time.NewTicker(elasticResizeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+		if err := m.resizeElasticTrialsOnce(); err != nil {
+			log.WithError(err).Warn("failed to resize elastic trials")
+		}
+	}
+}
+
+func (m *Master) resizeElasticTrialsOnce() error {
+	experiments := experiment.ExperimentRegistry.Snapshot()
+	if len(experiments) == 0 {
+		return nil
+	}
+
+	resp, err := m.rm.GetResourcePools()
+	if err != nil {
+		return fmt.Errorf("getting resource pools: %w", err)
+	}
+	pools := map[string]*elasticslots.PoolCapacity{}
+	for _, p := range resp.ResourcePools {
+		jobs, err := m.rm.GetJobQ(rm.ResourcePoolName(p.Name))
+		if err != nil {
+			return fmt.Errorf("getting job queue of resource pool %s: %w", p.Name, err)
+		}
+		c := elasticslots.NewPoolCapacity(int(p.SlotsAvailable-p.SlotsUsed), jobs)
+		// Static pools do not report their agent size, so assume their agents are alike.
+		c.SlotsPerAgent = int(p.SlotsPerAgent)
+		if c.SlotsPerAgent <= 0 && p.NumAgents > 0 && p.SlotsAvailable%p.NumAgents == 0 {
+			c.SlotsPerAgent = int(p.SlotsAvailable / p.NumAgents)
+		}
+		pools[p.Name] = c
+	}
+
+	// Older experiments get the first pick of idle slots.
+	ids := maps.Keys(experiments)
+	slices.Sort(ids)
+	for _, id := range ids {
+		experiments[id].ResizeElasticTrials(pools)
+	}
+	return nil
+}
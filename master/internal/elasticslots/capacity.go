@@ -0,0 +1,78 @@
+// Package elasticslots decides how many slots elastic trials run on. Elastic trials declare a range of
+// slots instead of a fixed slots_per_trial; they grow onto slots their resource pool leaves idle and
+// give them back when other jobs are waiting. A trial is resized by preempting it, so it
+// checkpoints and restarts at the new world size.
+package elasticslots
+
+import (
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// PoolCapacity is the spare capacity of a resource pool. It is shared by the elastic trials in the
+// pool during one pass, so slots handed to one trial are not handed to the next.
+type PoolCapacity struct {
+	// FreeSlots is how many slots are idle.
+	FreeSlots int
+	// SlotsPerAgent is how many slots each agent of the pool has, or 0 if unknown. Trials on more
+	// slots than one agent has must run on a multiple of it.
+	SlotsPerAgent int
+	// waiting is how many slots each queued job is waiting for.
+	waiting map[model.JobID]int
+	// reclaimed is how many slots have been taken back from elastic trials for waiting jobs.
+	reclaimed int
+}
+
+// NewPoolCapacity returns the capacity of a pool with free idle slots and the given job queue.
+func NewPoolCapacity(free int, jobs map[model.JobID]*sproto.RMJobInfo) *PoolCapacity {
+	c := &PoolCapacity{FreeSlots: max(free, 0), waiting: map[model.JobID]int{}}
+	for jobID, j := range jobs {
+		if j.State == sproto.SchedulingStateQueued && j.RequestedSlots > j.AllocatedSlots {
+			c.waiting[jobID] = j.RequestedSlots - j.AllocatedSlots
+		}
+	}
+	return c
+}
+
+// WaitingFor returns how many slots jobs other than jobID are still waiting for.
+func (c *PoolCapacity) WaitingFor(jobID model.JobID) int {
+	waiting := 0
+	for id, slots := range c.waiting {
+		if id != jobID {
+			waiting += slots
+		}
+	}
+	return max(waiting-c.reclaimed, 0)
+}
+
+// Target returns the number of slots a trial of jobID running on current slots should run on, and
+// takes the slots it grows by, or gives back the slots it shrinks by, from the pool.
+func (c *PoolCapacity) Target(cfg expconf.ElasticConfig, jobID model.JobID, current int) int {
+	target := min(max(current, cfg.MinSlots()), cfg.MaxSlots())
+	if waiting := c.WaitingFor(jobID); waiting > 0 {
+		target = max(target-waiting, cfg.MinSlots())
+	} else {
+		target = min(target+c.FreeSlots, cfg.MaxSlots())
+	}
+	target = c.fittable(target, cfg.MinSlots(), current)
+
+	if target > current {
+		c.FreeSlots -= target - current
+	} else {
+		c.reclaimed += current - target
+	}
+	return target
+}
+
+// fittable rounds slots down to a number the trial can be scheduled on, which is at most the slots
+// per agent or a multiple of it, but not below minSlots. It returns fallback if there is none.
+func (c *PoolCapacity) fittable(slots, minSlots, fallback int) int {
+	if c.SlotsPerAgent <= 0 || slots <= c.SlotsPerAgent {
+		return slots
+	}
+	if rounded := slots - slots%c.SlotsPerAgent; rounded >= minSlots {
+		return rounded
+	}
+	return fallback
+}
@@ -0,0 +1,116 @@
+package elasticslots
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+func TestTarget(t *testing.T) {
+	cfg := expconf.ElasticConfig{RawMinSlots: 2, RawMaxSlots: 8, RawCooldown: ptrs.Ptr(0)}
+	self, other := model.JobID("self"), model.JobID("other")
+	queued := func(requested, allocated int) *sproto.RMJobInfo {
+		return &sproto.RMJobInfo{
+			State:          sproto.SchedulingStateQueued,
+			RequestedSlots: requested,
+			AllocatedSlots: allocated,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		free    int
+		jobs    map[model.JobID]*sproto.RMJobInfo
+		current int
+		want    int
+	}{
+		{name: "grows onto idle slots", free: 3, current: 2, want: 5},
+		{name: "grows up to max", free: 16, current: 4, want: 8},
+		{name: "stays without idle slots", free: 0, current: 4, want: 4},
+		{name: "shrinks for waiting jobs", jobs: map[model.JobID]*sproto.RMJobInfo{
+			other: queued(3, 1),
+		}, current: 6, want: 4},
+		{name: "shrinks down to min", jobs: map[model.JobID]*sproto.RMJobInfo{
+			other: queued(16, 0),
+		}, current: 6, want: 2},
+		{name: "ignores its own queued job", free: 2, jobs: map[model.JobID]*sproto.RMJobInfo{
+			self: queued(4, 2),
+		}, current: 2, want: 4},
+		{name: "ignores scheduled jobs", free: 2, jobs: map[model.JobID]*sproto.RMJobInfo{
+			other: {State: sproto.SchedulingStateScheduled, RequestedSlots: 4},
+		}, current: 2, want: 4},
+		{name: "clamps a trial that started out of range", current: 12, want: 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewPoolCapacity(tt.free, tt.jobs)
+			require.Equal(t, tt.want, c.Target(cfg, self, tt.current))
+		})
+	}
+}
+
+func TestTargetSharesCapacity(t *testing.T) {
+	cfg := expconf.ElasticConfig{RawMinSlots: 1, RawMaxSlots: 3}
+	self := model.JobID("self")
+
+	// Idle slots given to one trial are not given to the next.
+	c := NewPoolCapacity(4, nil)
+	require.Equal(t, 3, c.Target(cfg, self, 1))
+	require.Equal(t, 3, c.Target(cfg, self, 1))
+	require.Equal(t, 1, c.Target(cfg, self, 1))
+
+	// Once enough slots are reclaimed for a waiting job, other trials keep theirs.
+	c = NewPoolCapacity(0, map[model.JobID]*sproto.RMJobInfo{
+		"other": {State: sproto.SchedulingStateQueued, RequestedSlots: 3},
+	})
+	require.Equal(t, 1, c.Target(cfg, self, 2))
+	require.Equal(t, 1, c.Target(cfg, self, 3))
+	require.Equal(t, 3, c.Target(cfg, self, 3))
+}
+
+func TestTargetMultiAgentPool(t *testing.T) {
+	cfg := expconf.ElasticConfig{RawMinSlots: 1, RawMaxSlots: 16}
+	self, other := model.JobID("self"), model.JobID("other")
+	waiting := func(slots int) map[model.JobID]*sproto.RMJobInfo {
+		return map[model.JobID]*sproto.RMJobInfo{
+			other: {State: sproto.SchedulingStateQueued, RequestedSlots: slots},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		cfg     expconf.ElasticConfig
+		free    int
+		jobs    map[model.JobID]*sproto.RMJobInfo
+		current int
+		want    int
+	}{
+		{name: "grows within one agent", cfg: cfg, free: 1, current: 2, want: 3},
+		{name: "grows by whole agents", cfg: cfg, free: 5, current: 4, want: 8},
+		{name: "stays without a whole agent to grow by", cfg: cfg, free: 3, current: 4, want: 4},
+		{name: "shrinks an unschedulable trial", cfg: cfg, current: 9, want: 8},
+		{name: "shrinks by whole agents", cfg: cfg, jobs: waiting(3), current: 8, want: 4},
+		{
+			name:    "stays if shrinking by whole agents goes below min",
+			cfg:     expconf.ElasticConfig{RawMinSlots: 6, RawMaxSlots: 16},
+			jobs:    waiting(3),
+			current: 8,
+			want:    8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewPoolCapacity(tt.free, tt.jobs)
+			c.SlotsPerAgent = 4
+			require.Equal(t, tt.want, c.Target(tt.cfg, self, tt.current))
+			if tt.want > tt.current {
+				require.Equal(t, tt.free-(tt.want-tt.current), c.FreeSlots)
+			}
+		})
+	}
+}
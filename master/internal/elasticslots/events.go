@@ -0,0 +1,71 @@
+package elasticslots
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+// ResizeEvent corresponds to a row in the "trial_resize_events" DB table.
+type ResizeEvent struct {
+	bun.BaseModel `bun:"table:trial_resize_events"`
+
+	ID        int       `bun:"id,pk,autoincrement"`
+	TrialID   int       `bun:"trial_id,notnull"`
+	FromSlots int       `bun:"from_slots,notnull"`
+	ToSlots   int       `bun:"to_slots,notnull"`
+	Reason    string    `bun:"reason,notnull"`
+	CreatedAt time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// Proto converts a resize event to its protobuf representation.
+func (e *ResizeEvent) Proto() *trialv1.TrialResizeEvent {
+	return &trialv1.TrialResizeEvent{
+		Id:        int32(e.ID),
+		TrialId:   int32(e.TrialID),
+		FromSlots: int32(e.FromSlots),
+		ToSlots:   int32(e.ToSlots),
+		Reason:    e.Reason,
+		Time:      timestamppb.New(e.CreatedAt),
+	}
+}
+
+// AddResizeEvent records that a trial was resized.
+func AddResizeEvent(ctx context.Context, e *ResizeEvent) error {
+	if _, err := db.Bun().NewInsert().Model(e).Returning("*").Exec(ctx); err != nil {
+		return fmt.Errorf("adding resize event for trial %d: %w", e.TrialID, err)
+	}
+	return nil
+}
+
+// ResizeEvents returns the resizes of a trial, oldest first.
+func ResizeEvents(ctx context.Context, trialID int) ([]ResizeEvent, error) {
+	events := []ResizeEvent{}
+	if err := db.Bun().NewSelect().Model(&events).
+		Where("trial_id = ?", trialID).
+		Order("id").
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("getting resize events for trial %d: %w", trialID, err)
+	}
+	return events, nil
+}
+
+// LatestSlots returns the number of slots a trial was last resized to, or db.ErrNotFound if it was
+// never resized.
+func LatestSlots(ctx context.Context, trialID int) (int, error) {
+	var e ResizeEvent
+	if err := db.Bun().NewSelect().Model(&e).
+		Where("trial_id = ?", trialID).
+		Order("id DESC").
+		Limit(1).
+		Scan(ctx); err != nil {
+		return 0, db.MatchSentinelError(err)
+	}
+	return e.ToSlots, nil
+}
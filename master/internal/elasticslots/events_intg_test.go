@@ -0,0 +1,58 @@
+//go:build integration
+// +build integration
+
+package elasticslots
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+func TestMain(m *testing.M) {
+	pgDB, _, err := db.ResolveTestPostgres()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	err = db.MigrateTestPostgres(pgDB, "file://../../static/migrations", "up")
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestResizeEvents(t *testing.T) {
+	ctx := context.Background()
+	pgDB := db.SingleDB()
+	user := db.RequireMockUser(t, pgDB)
+	exp := db.RequireMockExperiment(t, pgDB, user)
+	trialID := db.RequireMockTrialID(t, pgDB, exp)
+
+	_, err := LatestSlots(ctx, trialID)
+	require.ErrorIs(t, err, db.ErrNotFound)
+
+	require.NoError(t, AddResizeEvent(ctx, &ResizeEvent{
+		TrialID: trialID, FromSlots: 2, ToSlots: 8, Reason: "grow",
+	}))
+	require.NoError(t, AddResizeEvent(ctx, &ResizeEvent{
+		TrialID: trialID, FromSlots: 8, ToSlots: 4, Reason: "shrink",
+	}))
+
+	slots, err := LatestSlots(ctx, trialID)
+	require.NoError(t, err)
+	require.Equal(t, 4, slots)
+
+	events, err := ResizeEvents(ctx, trialID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "grow", events[0].Reason)
+	require.Equal(t, 8, events[1].FromSlots)
+	require.Equal(t, int32(4), events[1].Proto().ToSlots)
+}
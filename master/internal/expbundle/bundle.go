@@ -0,0 +1,162 @@
+// Package expbundle exports experiments to self-contained archives and imports them into
+// another cluster.
+//
+// A bundle is a tar or tgz archive whose files are written, and must be read, in order:
+//
+//	bundle.json                       the Manifest
+//	model_def.tgz                     the model definition
+//	experiment.json                   the ExperimentRecord
+//	trials/<id>/trial.json            a TrialRecord, for each trial
+//	trials/<id>/metrics-<n>.jsonl     the MetricRecords of the trial, in chunks
+//	checkpoints.json                  the CheckpointRecords of all trials
+//	checkpoints/<uuid>/<path>         the checkpoint files, if included
+//
+// IDs in a bundle are those of the source cluster; importing remaps them.
+package expbundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Version is the version of the bundle format written by Export.
+const Version = 1
+
+const (
+	manifestPath    = "bundle.json"
+	modelDefPath    = "model_def.tgz"
+	experimentPath  = "experiment.json"
+	checkpointsPath = "checkpoints.json"
+	trialsDir       = "trials/"
+	checkpointsDir  = "checkpoints/"
+	trialFile       = "trial.json"
+	metricsPrefix   = "metrics-"
+
+	metricsChunkSize = 10000
+)
+
+// Manifest describes a bundle.
+type Manifest struct {
+	Version                 int       `json:"version"`
+	ClusterID               string    `json:"cluster_id"`
+	ExperimentID            int       `json:"experiment_id"`
+	ExportedAt              time.Time `json:"exported_at"`
+	IncludesCheckpointFiles bool      `json:"includes_checkpoint_files"`
+}
+
+// ExternalExperimentID is the external experiment ID an experiment imported from the bundle is
+// created with, which makes importing the same bundle twice a no-op.
+func (m Manifest) ExternalExperimentID() string {
+	return fmt.Sprintf("import:%s:%d", m.ClusterID, m.ExperimentID)
+}
+
+// ExperimentRecord is an experiment in a bundle.
+type ExperimentRecord struct {
+	Config         json.RawMessage `json:"config"`
+	OriginalConfig string          `json:"original_config"`
+	Notes          string          `json:"notes"`
+	State          model.State     `json:"state"`
+	StartTime      time.Time       `json:"start_time"`
+	EndTime        *time.Time      `json:"end_time"`
+	Archived       bool            `json:"archived"`
+	Progress       *float64        `json:"progress"`
+	Unmanaged      bool            `json:"unmanaged"`
+	Owner          string          `json:"owner"`
+	Workspace      string          `json:"workspace"`
+	Project        string          `json:"project"`
+}
+
+// TrialRecord is a trial in a bundle.
+type TrialRecord struct {
+	ID              int              `json:"id"`
+	RequestID       *model.RequestID `json:"request_id"`
+	State           model.State      `json:"state"`
+	StartTime       time.Time        `json:"start_time"`
+	EndTime         *time.Time       `json:"end_time"`
+	HParams         map[string]any   `json:"hparams" bun:"hparams"`
+	Seed            int64            `json:"seed"`
+	TotalBatches    int              `json:"total_batches"`
+	ExternalTrialID *string          `json:"external_trial_id"`
+	RunID           int              `json:"run_id"`
+	Restarts        int              `json:"restarts"`
+	RunnerState     string           `json:"runner_state"`
+	SummaryMetrics  json.RawMessage  `json:"summary_metrics"`
+}
+
+// MetricRecord is a row of reported metrics of a trial in a bundle.
+type MetricRecord struct {
+	TrialRunID    int             `json:"trial_run_id"`
+	EndTime       time.Time       `json:"end_time"`
+	Metrics       json.RawMessage `json:"metrics"`
+	TotalBatches  int             `json:"total_batches"`
+	PartitionType string          `json:"partition_type"`
+	MetricGroup   string          `json:"metric_group"`
+}
+
+// CheckpointRecord is the metadata of a checkpoint in a bundle.
+type CheckpointRecord struct {
+	UUID       uuid.UUID        `json:"uuid"`
+	TrialID    int              `json:"trial_id"`
+	ReportTime time.Time        `json:"report_time"`
+	State      model.State      `json:"state"`
+	Resources  map[string]int64 `json:"resources"`
+	Metadata   map[string]any   `json:"metadata"`
+}
+
+func trialPath(trialID int, name string) string {
+	return trialsDir + strconv.Itoa(trialID) + "/" + name
+}
+
+func metricsPath(trialID int, chunk int) string {
+	return trialPath(trialID, fmt.Sprintf("%s%05d.jsonl", metricsPrefix, chunk))
+}
+
+func checkpointFilePath(id uuid.UUID, file string) string {
+	return checkpointsDir + id.String() + "/" + file
+}
+
+// parseTrialPath returns the source trial ID and the file name of a path under trialsDir.
+func parseTrialPath(p string) (int, string, error) {
+	rest, ok := strings.CutPrefix(p, trialsDir)
+	if !ok {
+		return 0, "", fmt.Errorf("%s is not a trial file", p)
+	}
+	id, name, ok := strings.Cut(rest, "/")
+	if !ok || strings.Contains(name, "/") {
+		return 0, "", fmt.Errorf("%s is not a trial file", p)
+	}
+	trialID, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, "", fmt.Errorf("parsing trial ID of %s: %w", p, err)
+	}
+	return trialID, name, nil
+}
+
+// parseCheckpointFilePath returns the checkpoint UUID and the file path, relative to the
+// checkpoint, of a path under checkpointsDir.
+func parseCheckpointFilePath(p string) (uuid.UUID, string, error) {
+	rest, ok := strings.CutPrefix(p, checkpointsDir)
+	if !ok {
+		return uuid.Nil, "", fmt.Errorf("%s is not a checkpoint file", p)
+	}
+	id, file, ok := strings.Cut(rest, "/")
+	if !ok || file == "" {
+		return uuid.Nil, "", fmt.Errorf("%s is not a checkpoint file", p)
+	}
+	ckptUUID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("parsing checkpoint UUID of %s: %w", p, err)
+	}
+	if file != path.Clean(file) || strings.HasPrefix(file, "../") || path.IsAbs(file) {
+		return uuid.Nil, "", fmt.Errorf("checkpoint file path %s is not clean", p)
+	}
+	return ckptUUID, file, nil
+}
@@ -0,0 +1,39 @@
+package expbundle
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrialPath(t *testing.T) {
+	id, name, err := parseTrialPath(metricsPath(12, 3))
+	require.NoError(t, err)
+	require.Equal(t, 12, id)
+	require.Equal(t, "metrics-00003.jsonl", name)
+
+	for _, p := range []string{"trials/12", "trials/a/trial.json", "trials/1/2/trial.json", "x/1/trial.json"} {
+		_, _, err := parseTrialPath(p)
+		require.Error(t, err, p)
+	}
+}
+
+func TestParseCheckpointFilePath(t *testing.T) {
+	ckptUUID := uuid.New()
+	id, file, err := parseCheckpointFilePath(checkpointFilePath(ckptUUID, "dir/state.pt"))
+	require.NoError(t, err)
+	require.Equal(t, ckptUUID, id)
+	require.Equal(t, "dir/state.pt", file)
+
+	for _, f := range []string{"", "../escape", "dir/../../escape", "/abs", "dir//file"} {
+		_, _, err := parseCheckpointFilePath(checkpointFilePath(ckptUUID, f))
+		require.Error(t, err, f)
+	}
+	_, _, err = parseCheckpointFilePath("checkpoints/not-a-uuid/file")
+	require.Error(t, err)
+}
+
+func TestExternalExperimentID(t *testing.T) {
+	require.Equal(t, "import:abc:7", Manifest{ClusterID: "abc", ExperimentID: 7}.ExternalExperimentID())
+}
@@ -0,0 +1,166 @@
+//go:build integration
+// +build integration
+
+package expbundle
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/checkpoints/archive"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/commonv1"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, etc.SetRootPath(db.RootFromDB))
+	pgDB, closeDB := db.MustResolveTestPostgres(t)
+	defer closeDB()
+	db.MustMigrateTestPostgres(t, pgDB, db.MigrationsFromDB)
+	user := db.RequireMockUser(t, pgDB)
+	exp := db.RequireMockExperiment(t, pgDB, user)
+	trial, task := db.RequireMockTrial(t, pgDB, exp)
+	allocation := db.RequireMockAllocation(t, pgDB, task.TaskID)
+
+	for step := int32(1); step <= 3; step++ {
+		metrics, err := structpb.NewStruct(map[string]any{"loss": float64(10 - step)})
+		require.NoError(t, err)
+		steps := step * 100
+		require.NoError(t, pgDB.AddTrainingMetrics(ctx, &trialv1.TrialMetrics{
+			TrialId:        int32(trial.ID),
+			StepsCompleted: &steps,
+			Metrics:        &commonv1.Metrics{AvgMetrics: metrics},
+		}))
+		require.NoError(t, db.AddTrialValidationMetrics(ctx, uuid.Nil, trial, steps, 10-step, pgDB))
+	}
+	ckpt := db.MockModelCheckpoint(uuid.New(), allocation)
+	require.NoError(t, db.AddCheckpointMetadata(ctx, &ckpt, trial.ID))
+
+	_, err := db.Bun().NewUpdate().Table("experiments").
+		Set("state = ?", model.CompletedState).
+		Set("notes = ?", "some notes").
+		Where("id = ?", exp.ID).Exec(ctx)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	aw, err := archive.NewArchiveWriter(&buf, archive.ArchiveTgz)
+	require.NoError(t, err)
+	require.NoError(t, Export(ctx, aw, exp.ID, ExportOptions{ClusterID: "source"}))
+	require.NoError(t, aw.Close())
+	bundle := buf.Bytes()
+
+	importBundle := func() *ImportResult {
+		ar, err := archive.NewArchiveReader(bytes.NewReader(bundle), archive.ArchiveTgz)
+		require.NoError(t, err)
+		defer ar.Close()
+		res, err := Import(ctx, ar, ImportOptions{User: user})
+		require.NoError(t, err)
+		return res
+	}
+
+	res := importBundle()
+	require.True(t, res.Created)
+	require.NotEqual(t, exp.ID, res.ExperimentID)
+
+	imported, err := db.ExperimentByID(ctx, res.ExperimentID)
+	require.NoError(t, err)
+	require.Equal(t, model.CompletedState, imported.State)
+	require.Equal(t, "some notes", imported.Notes)
+	require.Equal(t, exp.ProjectID, imported.ProjectID)
+	require.Equal(t, user.ID, *imported.OwnerID)
+
+	var trials []struct {
+		ID               int     `bun:"id"`
+		BestValidationID *int    `bun:"best_validation_id"`
+		SearcherMetric   float64 `bun:"searcher_metric_value"`
+		TotalBatches     int     `bun:"total_batches"`
+	}
+	require.NoError(t, db.Bun().NewSelect().Table("trials").
+		Column("id", "best_validation_id", "searcher_metric_value", "total_batches").
+		Where("experiment_id = ?", res.ExperimentID).Scan(ctx, &trials))
+	require.Len(t, trials, 1)
+	require.NotNil(t, trials[0].BestValidationID)
+	require.Equal(t, 7.0, trials[0].SearcherMetric)
+	require.Equal(t, 300, trials[0].TotalBatches)
+
+	for _, group := range []model.MetricGroup{model.TrainingMetricGroup, model.ValidationMetricGroup} {
+		count, err := db.Bun().NewSelect().Table("metrics").
+			Where("trial_id = ?", trials[0].ID).
+			Where("metric_group = ?", group).
+			Count(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 3, count, group)
+	}
+
+	// The checkpoint UUID is taken on this cluster, so the imported checkpoint gets a new one.
+	var ckpts []model.CheckpointV2
+	require.NoError(t, db.Bun().NewSelect().Model(&ckpts).
+		Where("uuid IN (SELECT checkpoint_id FROM run_checkpoints WHERE run_id = ?)", trials[0].ID).
+		Scan(ctx))
+	require.Len(t, ckpts, 1)
+	require.NotEqual(t, ckpt.UUID, ckpts[0].UUID)
+	require.Equal(t, ckpt.Metadata, ckpts[0].Metadata)
+
+	// Importing the same bundle again is a no-op.
+	again := importBundle()
+	require.False(t, again.Created)
+	require.Equal(t, res.ExperimentID, again.ExperimentID)
+
+	// A truncated bundle is invalid input rather than an internal error.
+	ar, err := archive.NewArchiveReader(bytes.NewReader(bundle[:len(bundle)/2]), archive.ArchiveTgz)
+	require.NoError(t, err)
+	defer ar.Close()
+	_, err = Import(ctx, ar, ImportOptions{User: user})
+	require.ErrorIs(t, err, db.ErrInvalidInput)
+}
+
+func TestImportOwner(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, etc.SetRootPath(db.RootFromDB))
+	pgDB, closeDB := db.MustResolveTestPostgres(t)
+	defer closeDB()
+	db.MustMigrateTestPostgres(t, pgDB, db.MigrationsFromDB)
+	requireUser := func(admin bool) model.User {
+		u := model.User{Username: uuid.NewString(), Admin: admin, Active: true}
+		_, err := db.HackAddUser(ctx, &u)
+		require.NoError(t, err)
+		return u
+	}
+	admin := requireUser(true)
+	exp := db.RequireMockExperiment(t, pgDB, admin)
+
+	importAs := func(importer model.User, clusterID string) model.UserID {
+		var buf bytes.Buffer
+		aw, err := archive.NewArchiveWriter(&buf, archive.ArchiveTgz)
+		require.NoError(t, err)
+		require.NoError(t, Export(ctx, aw, exp.ID, ExportOptions{ClusterID: clusterID}))
+		require.NoError(t, aw.Close())
+		ar, err := archive.NewArchiveReader(bytes.NewReader(buf.Bytes()), archive.ArchiveTgz)
+		require.NoError(t, err)
+		defer ar.Close()
+		res, err := Import(ctx, ar, ImportOptions{User: importer})
+		require.NoError(t, err)
+		imported, err := db.ExperimentByID(ctx, res.ExperimentID)
+		require.NoError(t, err)
+		return *imported.OwnerID
+	}
+
+	// The owner in a bundle is not trusted, so a non-admin cannot attribute an import to admin.
+	nonAdmin := requireUser(false)
+	require.Equal(t, nonAdmin.ID, importAs(nonAdmin, "from-non-admin"))
+
+	// Admins may import experiments on behalf of their owners.
+	otherAdmin := requireUser(true)
+	require.Equal(t, admin.ID, importAs(otherAdmin, "from-admin"))
+}
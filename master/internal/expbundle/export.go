@@ -0,0 +1,234 @@
+package expbundle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/storage"
+	"github.com/determined-ai/determined/master/pkg/checkpoints"
+	"github.com/determined-ai/determined/master/pkg/checkpoints/archive"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// ExportOptions configures an export.
+type ExportOptions struct {
+	// ClusterID identifies the exporting cluster.
+	ClusterID string
+	// IncludeCheckpointFiles includes the files of completed checkpoints in the bundle.
+	IncludeCheckpointFiles bool
+}
+
+// Export writes a bundle of the experiment to aw. The experiment must be in a terminal state.
+// aw is not closed.
+func Export(ctx context.Context, aw archive.ArchiveWriter, expID int, opts ExportOptions) error {
+	exp, err := experimentRecordByID(ctx, expID)
+	if err != nil {
+		return err
+	}
+	if !model.TerminalStates[exp.State] {
+		return fmt.Errorf("experiment %d is in state %s and can only be exported once terminal",
+			expID, exp.State)
+	}
+
+	if err := writeJSON(aw, manifestPath, Manifest{
+		Version:                 Version,
+		ClusterID:               opts.ClusterID,
+		ExperimentID:            expID,
+		ExportedAt:              time.Now().UTC(),
+		IncludesCheckpointFiles: opts.IncludeCheckpointFiles,
+	}); err != nil {
+		return err
+	}
+
+	var modelDef []byte
+	if err := db.Bun().NewSelect().Table("experiments").Column("model_definition").
+		Where("id = ?", expID).Scan(ctx, &modelDef); err != nil {
+		return fmt.Errorf("getting model definition of experiment %d: %w", expID, err)
+	}
+	if err := writeFile(aw, modelDefPath, modelDef); err != nil {
+		return err
+	}
+
+	if err := writeJSON(aw, experimentPath, exp); err != nil {
+		return err
+	}
+
+	var trials []*TrialRecord
+	if err := db.Bun().NewSelect().Table("trials").
+		Column("id", "request_id", "state", "start_time", "end_time", "hparams", "seed",
+			"total_batches", "external_trial_id", "run_id", "restarts", "runner_state",
+			"summary_metrics").
+		Where("experiment_id = ?", expID).
+		Order("id").
+		Scan(ctx, &trials); err != nil {
+		return fmt.Errorf("getting trials of experiment %d: %w", expID, err)
+	}
+	for _, t := range trials {
+		if err := writeJSON(aw, trialPath(t.ID, trialFile), t); err != nil {
+			return err
+		}
+		if err := exportMetrics(ctx, aw, t.ID); err != nil {
+			return err
+		}
+	}
+
+	var ckpts []*checkpointRow
+	if err := db.Bun().NewSelect().TableExpr("checkpoints_v2 c").
+		ColumnExpr("c.uuid, rc.run_id AS trial_id, c.report_time, c.state, c.resources").
+		ColumnExpr("c.metadata, c.storage_id").
+		Join("JOIN run_checkpoints rc ON rc.checkpoint_id = c.uuid").
+		Join("JOIN runs r ON r.id = rc.run_id").
+		Where("r.experiment_id = ?", expID).
+		Order("c.report_time", "c.uuid").
+		Scan(ctx, &ckpts); err != nil {
+		return fmt.Errorf("getting checkpoints of experiment %d: %w", expID, err)
+	}
+	records := make([]CheckpointRecord, 0, len(ckpts))
+	for _, c := range ckpts {
+		records = append(records, c.CheckpointRecord)
+	}
+	if err := writeJSON(aw, checkpointsPath, records); err != nil {
+		return err
+	}
+
+	if !opts.IncludeCheckpointFiles {
+		return nil
+	}
+	expConfig, err := expconf.ParseAnyExperimentConfigJSON(exp.Config)
+	if err != nil {
+		return fmt.Errorf("parsing config of experiment %d: %w", expID, err)
+	}
+	for _, c := range ckpts {
+		if c.State != model.CompletedState {
+			continue
+		}
+		storageConfig := ptrs.Ptr(expConfig.CheckpointStorage())
+		if c.StorageID != nil {
+			backend, err := storage.Backend(ctx, *c.StorageID)
+			if err != nil {
+				return err
+			}
+			storageConfig = &backend
+		}
+		if err := exportCheckpointFiles(ctx, aw, c.UUID, storageConfig); err != nil {
+			return fmt.Errorf("exporting files of checkpoint %s: %w", c.UUID, err)
+		}
+	}
+	return nil
+}
+
+type checkpointRow struct {
+	CheckpointRecord
+	StorageID *model.StorageBackendID `bun:"storage_id"`
+}
+
+func experimentRecordByID(ctx context.Context, expID int) (*ExperimentRecord, error) {
+	var exp ExperimentRecord
+	if err := db.Bun().NewSelect().TableExpr("experiments e").
+		ColumnExpr("e.config, e.original_config, e.notes, e.state, e.start_time, e.end_time").
+		ColumnExpr("e.archived, e.progress, e.unmanaged").
+		ColumnExpr("u.username AS owner, w.name AS workspace, p.name AS project").
+		Join("JOIN users u ON u.id = e.owner_id").
+		Join("JOIN projects p ON p.id = e.project_id").
+		Join("JOIN workspaces w ON w.id = p.workspace_id").
+		Where("e.id = ?", expID).
+		Scan(ctx, &exp); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return &exp, nil
+}
+
+func exportMetrics(ctx context.Context, aw archive.ArchiveWriter, trialID int) error {
+	var lastID int64
+	for chunk := 0; ; chunk++ {
+		var rows []struct {
+			ID int64 `bun:"id"`
+			MetricRecord
+		}
+		if err := db.Bun().NewSelect().Table("metrics").
+			Column("id", "trial_run_id", "end_time", "metrics", "total_batches",
+				"partition_type", "metric_group").
+			Where("trial_id = ?", trialID).
+			Where("archived = false").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(metricsChunkSize).
+			Scan(ctx, &rows); err != nil {
+			return fmt.Errorf("getting metrics of trial %d: %w", trialID, err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, r := range rows {
+			if err := enc.Encode(r.MetricRecord); err != nil {
+				return fmt.Errorf("encoding metrics of trial %d: %w", trialID, err)
+			}
+		}
+		if err := writeFile(aw, metricsPath(trialID, chunk), buf.Bytes()); err != nil {
+			return err
+		}
+		if len(rows) < metricsChunkSize {
+			return nil
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+func exportCheckpointFiles(
+	ctx context.Context, aw archive.ArchiveWriter, id uuid.UUID,
+	storageConfig *expconf.CheckpointStorageConfig,
+) error {
+	paw := &checkpointArchiveWriter{ArchiveWriter: aw, id: id}
+	downloader, err := checkpoints.NewDownloader(ctx, nil, id.String(), storageConfig, paw)
+	if err != nil {
+		return err
+	}
+	if err := downloader.Download(ctx); err != nil {
+		return err
+	}
+	return downloader.Close()
+}
+
+// checkpointArchiveWriter writes the files of one checkpoint into the bundle. It is closed by
+// the checkpoint downloader and so must not close the bundle.
+type checkpointArchiveWriter struct {
+	archive.ArchiveWriter
+	id uuid.UUID
+}
+
+func (w *checkpointArchiveWriter) WriteHeader(path string, size int64) error {
+	return w.ArchiveWriter.WriteHeader(checkpointFilePath(w.id, path), size)
+}
+
+func (w *checkpointArchiveWriter) Close() error {
+	return nil
+}
+
+func writeJSON(aw archive.ArchiveWriter, path string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", path, err)
+	}
+	return writeFile(aw, path, b)
+}
+
+func writeFile(aw archive.ArchiveWriter, path string, b []byte) error {
+	if err := aw.WriteHeader(path, int64(len(b))); err != nil {
+		return fmt.Errorf("writing header of %s: %w", path, err)
+	}
+	if _, err := aw.Write(b); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,521 @@
+package expbundle
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/project"
+	"github.com/determined-ai/determined/master/internal/storage"
+	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/pkg/checkpoints"
+	"github.com/determined-ai/determined/master/pkg/checkpoints/archive"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// ImportOptions configures an import.
+type ImportOptions struct {
+	// ProjectID is the project to import the experiment into. If zero, the experiment is
+	// imported into the project of the same workspace and name it was in, if any, or the
+	// default project.
+	ProjectID int
+	// User is the importing user. It owns the experiment unless it is an admin and the owner of
+	// the experiment has a user of the same name on this cluster, since the owner in the bundle
+	// is not to be trusted otherwise.
+	User model.User
+	// CheckpointStorage is where checkpoint files included in the bundle are uploaded to.
+	CheckpointStorage *expconf.CheckpointStorageConfig
+	// Authorize, if set, is called with the destination project before anything is imported.
+	Authorize func(ctx context.Context, projectID int) error
+}
+
+// ImportResult is the result of an import.
+type ImportResult struct {
+	ExperimentID int
+	// Created is false if the bundle was imported before and nothing was imported.
+	Created bool
+}
+
+type importedTrial struct {
+	id     int
+	taskID model.TaskID
+	record TrialRecord
+}
+
+type importer struct {
+	opts     ImportOptions
+	ar       archive.ArchiveReader
+	manifest Manifest
+	config   expconf.ExperimentConfig
+
+	expID     int
+	storageID *model.StorageBackendID
+	trials    map[int]*importedTrial
+	// checkpoints maps the UUIDs of checkpoints in the bundle to their imported UUIDs.
+	checkpoints map[uuid.UUID]uuid.UUID
+	uploaders   map[uuid.UUID]checkpoints.CheckpointUploader
+	// uploads are the checkpoint files uploaded so far, which are deleted if the import fails.
+	uploads []upload
+}
+
+type upload struct {
+	uploader checkpoints.CheckpointUploader
+	path     string
+}
+
+// Import imports the experiment in the bundle read from ar. Importing a bundle that was already
+// imported returns the experiment imported before.
+func Import(ctx context.Context, ar archive.ArchiveReader, opts ImportOptions) (*ImportResult, error) {
+	i := &importer{
+		opts:        opts,
+		ar:          ar,
+		trials:      make(map[int]*importedTrial),
+		checkpoints: make(map[uuid.UUID]uuid.UUID),
+		uploaders:   make(map[uuid.UUID]checkpoints.CheckpointUploader),
+	}
+
+	if err := i.readJSON(manifestPath, &i.manifest); err != nil {
+		return nil, err
+	}
+	switch {
+	case i.manifest.Version < 1 || i.manifest.Version > Version:
+		return nil, invalidf("unsupported bundle version %d", i.manifest.Version)
+	case i.manifest.ClusterID == "":
+		return nil, invalidf("bundle is missing the ID of the cluster it was exported from")
+	case i.manifest.IncludesCheckpointFiles && opts.CheckpointStorage == nil:
+		return nil, invalidf("bundle includes checkpoint files but no checkpoint storage is set")
+	}
+	modelDef, err := i.readFile(modelDefPath)
+	if err != nil {
+		return nil, err
+	}
+	var exp ExperimentRecord
+	if err := i.readJSON(experimentPath, &exp); err != nil {
+		return nil, err
+	}
+
+	dest, err := i.destinationProject(ctx, exp)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Authorize != nil {
+		if err := opts.Authorize(ctx, dest.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if existing, err := i.previousImport(ctx); err != nil || existing != nil {
+		return existing, err
+	}
+
+	if i.config, err = expconf.ParseAnyExperimentConfigJSON(exp.Config); err != nil {
+		return nil, invalidf("parsing experiment config: %w", err)
+	}
+	i.config.SetWorkspace(dest.WorkspaceName)
+	i.config.SetProject(dest.Name)
+	if i.manifest.IncludesCheckpointFiles {
+		i.config.SetCheckpointStorage(*opts.CheckpointStorage)
+	}
+	storageID, err := storage.AddBackend(ctx, ptrs.Ptr(i.config.CheckpointStorage()))
+	if err != nil {
+		return nil, fmt.Errorf("adding checkpoint storage: %w", err)
+	}
+	i.storageID = &storageID
+
+	ownerID := opts.User.ID
+	if opts.User.Admin && exp.Owner != opts.User.Username {
+		switch owner, err := user.ByUsername(ctx, exp.Owner); {
+		case errors.Is(err, db.ErrNotFound):
+		case err != nil:
+			return nil, fmt.Errorf("looking up owner %s: %w", exp.Owner, err)
+		default:
+			ownerID = owner.ID
+		}
+	}
+
+	err = db.Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		e := &model.Experiment{
+			JobID:                model.NewJobID(),
+			State:                exp.State,
+			Notes:                exp.Notes,
+			OriginalConfig:       exp.OriginalConfig,
+			StartTime:            exp.StartTime,
+			EndTime:              exp.EndTime,
+			Archived:             exp.Archived,
+			OwnerID:              &ownerID,
+			ProjectID:            dest.ID,
+			Unmanaged:            exp.Unmanaged,
+			ExternalExperimentID: ptrs.Ptr(i.manifest.ExternalExperimentID()),
+		}
+		if err := db.AddExperimentTx(ctx, tx, e, modelDef, i.config, false); err != nil {
+			return err
+		}
+		i.expID = e.ID
+		if _, err := tx.NewUpdate().Table("experiments").Set("progress = ?", exp.Progress).
+			Where("id = ?", e.ID).Exec(ctx); err != nil {
+			return fmt.Errorf("setting experiment progress: %w", err)
+		}
+
+		for {
+			entry, err := ar.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return invalidf("reading bundle: %w", err)
+			}
+			if err := i.importFile(ctx, tx, e.JobID, entry.Path); err != nil {
+				return err
+			}
+		}
+		return i.finish(ctx, tx)
+	})
+	if err != nil {
+		// Nothing refers to the files of a failed import, so do not leave them in storage.
+		i.deleteUploads(ctx)
+	}
+	if errors.Is(db.MatchSentinelError(err), db.ErrDuplicateRecord) {
+		// The bundle was imported concurrently.
+		if existing, lookupErr := i.previousImport(ctx); lookupErr == nil && existing != nil {
+			return existing, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("importing experiment %d from cluster %s: %w",
+			i.manifest.ExperimentID, i.manifest.ClusterID, err)
+	}
+	return &ImportResult{ExperimentID: i.expID, Created: true}, nil
+}
+
+func (i *importer) destinationProject(ctx context.Context, exp ExperimentRecord) (*model.Project, error) {
+	projectID := i.opts.ProjectID
+	if projectID == 0 {
+		var err error
+		projectID, err = project.ProjectByName(ctx, exp.Workspace, exp.Project)
+		if err != nil {
+			projectID = model.DefaultProjectID
+		}
+	}
+	p, err := project.GetProjectByID(ctx, projectID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, invalidf("project %d not found", projectID)
+		}
+		return nil, fmt.Errorf("getting project %d: %w", projectID, err)
+	}
+	if p.Archived {
+		return nil, invalidf("project %d is archived and cannot add new experiments", projectID)
+	}
+	return p, nil
+}
+
+func (i *importer) previousImport(ctx context.Context) (*ImportResult, error) {
+	e, err := db.ExperimentByExternalIDTx(ctx, db.Bun(), i.manifest.ExternalExperimentID())
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("looking up previous import: %w", err)
+	default:
+		return &ImportResult{ExperimentID: e.ID, Created: false}, nil
+	}
+}
+
+func (i *importer) importFile(ctx context.Context, tx bun.Tx, jobID model.JobID, path string) error {
+	switch {
+	case path == checkpointsPath:
+		var records []CheckpointRecord
+		if err := i.decodeJSON(path, &records); err != nil {
+			return err
+		}
+		return i.importCheckpoints(ctx, tx, records)
+
+	case strings.HasPrefix(path, trialsDir):
+		srcID, name, err := parseTrialPath(path)
+		if err != nil {
+			return invalidf("%w", err)
+		}
+		if name == trialFile {
+			var record TrialRecord
+			if err := i.decodeJSON(path, &record); err != nil {
+				return err
+			}
+			return i.importTrial(ctx, tx, jobID, srcID, record)
+		}
+		if strings.HasPrefix(name, metricsPrefix) {
+			return i.importMetrics(ctx, tx, srcID, path)
+		}
+		return invalidf("unexpected file %s in bundle", path)
+
+	case strings.HasPrefix(path, checkpointsDir):
+		return i.importCheckpointFile(ctx, path)
+
+	default:
+		return invalidf("unexpected file %s in bundle", path)
+	}
+}
+
+func (i *importer) importTrial(
+	ctx context.Context, tx bun.Tx, jobID model.JobID, srcID int, record TrialRecord,
+) error {
+	if _, ok := i.trials[srcID]; ok {
+		return invalidf("trial %d is in the bundle twice", srcID)
+	}
+
+	suffix := model.NewTaskID().String()
+	if record.RequestID != nil {
+		suffix = record.RequestID.String()
+	}
+	// Trial task IDs are prefixed by the experiment ID, see experimentIDFromTrialTaskID.
+	taskID := model.TaskID(fmt.Sprintf("%d.%s", i.expID, suffix))
+	if err := db.AddTaskTx(ctx, tx, &model.Task{
+		TaskID:     taskID,
+		JobID:      &jobID,
+		TaskType:   model.TaskTypeTrial,
+		StartTime:  record.StartTime,
+		EndTime:    record.EndTime,
+		LogVersion: model.CurrentTaskLogVersion,
+	}); err != nil {
+		return fmt.Errorf("adding task of trial %d: %w", srcID, err)
+	}
+
+	t := &model.Trial{
+		RequestID:       record.RequestID,
+		ExperimentID:    i.expID,
+		State:           record.State,
+		StartTime:       record.StartTime,
+		EndTime:         record.EndTime,
+		HParams:         record.HParams,
+		Seed:            record.Seed,
+		TotalBatches:    record.TotalBatches,
+		ExternalTrialID: record.ExternalTrialID,
+		RunID:           record.RunID,
+		Restarts:        record.Restarts,
+		RunnerState:     record.RunnerState,
+	}
+	if err := db.AddTrialTx(ctx, tx, t, taskID); err != nil {
+		return fmt.Errorf("adding trial %d: %w", srcID, err)
+	}
+	i.trials[srcID] = &importedTrial{id: t.ID, taskID: taskID, record: record}
+	return nil
+}
+
+func (i *importer) importMetrics(ctx context.Context, tx bun.Tx, srcID int, path string) error {
+	t, ok := i.trials[srcID]
+	if !ok {
+		return invalidf("metrics %s precede their trial in the bundle", path)
+	}
+
+	type metricsRow struct {
+		bun.BaseModel `bun:"table:metrics"`
+		TrialID       int `bun:"trial_id"`
+		MetricRecord
+	}
+	var rows []metricsRow
+	scanner := bufio.NewScanner(i.ar)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		row := metricsRow{TrialID: t.id}
+		if err := json.Unmarshal(scanner.Bytes(), &row.MetricRecord); err != nil {
+			return invalidf("decoding %s: %w", path, err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return invalidf("reading %s: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if _, err := tx.NewInsert().Model(&rows).Exec(ctx); err != nil {
+		return fmt.Errorf("inserting metrics of trial %d: %w", srcID, err)
+	}
+	return nil
+}
+
+func (i *importer) importCheckpoints(ctx context.Context, tx bun.Tx, records []CheckpointRecord) error {
+	for _, r := range records {
+		t, ok := i.trials[r.TrialID]
+		if !ok {
+			return invalidf("checkpoint %s is of trial %d, which is not in the bundle", r.UUID, r.TrialID)
+		}
+
+		// Keep the UUID unless it is taken, like when importing into the exporting cluster.
+		id := r.UUID
+		exists, err := tx.NewSelect().Table("checkpoints_v2").Where("uuid = ?", id).Exists(ctx)
+		if err != nil {
+			return fmt.Errorf("checking for checkpoint %s: %w", id, err)
+		}
+		if exists {
+			id = uuid.New()
+		}
+
+		if err := db.AddCheckpointMetadataTx(ctx, tx, &model.CheckpointV2{
+			UUID:       id,
+			TaskID:     t.taskID,
+			ReportTime: r.ReportTime,
+			State:      r.State,
+			Resources:  r.Resources,
+			Metadata:   r.Metadata,
+			StorageID:  i.storageID,
+		}, t.id); err != nil {
+			return fmt.Errorf("adding checkpoint %s: %w", r.UUID, err)
+		}
+		i.checkpoints[r.UUID] = id
+	}
+	return nil
+}
+
+func (i *importer) importCheckpointFile(ctx context.Context, path string) error {
+	if !i.manifest.IncludesCheckpointFiles {
+		return invalidf("unexpected checkpoint file %s in bundle", path)
+	}
+	srcUUID, file, err := parseCheckpointFilePath(path)
+	if err != nil {
+		return invalidf("%w", err)
+	}
+	id, ok := i.checkpoints[srcUUID]
+	if !ok {
+		return invalidf("checkpoint file %s precedes the checkpoint's metadata in the bundle", path)
+	}
+
+	uploader, ok := i.uploaders[id]
+	if !ok {
+		if uploader, err = checkpoints.NewUploader(ctx, id.String(), i.opts.CheckpointStorage); err != nil {
+			return fmt.Errorf("creating uploader for checkpoint %s: %w", id, err)
+		}
+		i.uploaders[id] = uploader
+	}
+	// Record the file first, since a failed upload can leave part of it behind.
+	i.uploads = append(i.uploads, upload{uploader: uploader, path: file})
+	if err := uploader.Upload(ctx, file, i.ar); err != nil {
+		return fmt.Errorf("uploading %s: %w", path, err)
+	}
+	return nil
+}
+
+// deleteUploads deletes the checkpoint files uploaded by a failed import.
+func (i *importer) deleteUploads(ctx context.Context) {
+	// The import may have failed because ctx is done, which must not prevent the cleanup.
+	ctx = context.WithoutCancel(ctx)
+	for _, u := range i.uploads {
+		if err := u.uploader.Delete(ctx, u.path); err != nil {
+			log.WithError(err).Warnf("failed to delete checkpoint file %s of failed import of "+
+				"experiment %d from cluster %s", u.path, i.manifest.ExperimentID, i.manifest.ClusterID)
+		}
+	}
+	i.uploads = nil
+}
+
+// finish sets the fields of the imported trials and experiment that are derived from their
+// metrics as they were on the exporting cluster.
+func (i *importer) finish(ctx context.Context, tx bun.Tx) error {
+	for srcID, t := range i.trials {
+		summary := t.record.SummaryMetrics
+		if len(summary) == 0 {
+			summary = json.RawMessage("{}")
+		}
+		if _, err := tx.NewUpdate().Table("runs").
+			Set("summary_metrics = ?", string(summary)).
+			Set("summary_metrics_timestamp = NOW()").
+			Where("id = ?", t.id).
+			Exec(ctx); err != nil {
+			return fmt.Errorf("setting summary metrics of trial %d: %w", srcID, err)
+		}
+
+		if _, err := tx.NewRaw(`
+WITH const AS (
+	SELECT config->'searcher'->>'metric' AS metric_name,
+		CASE WHEN coalesce((config->'searcher'->>'smaller_is_better')::boolean, true)
+		THEN 1 ELSE -1 END AS sign
+	FROM experiments WHERE id = ?0
+), latest_validation AS (
+	SELECT v.id
+	FROM validations v, const
+	WHERE v.trial_id = ?1
+	AND (v.metrics->'validation_metrics'->>const.metric_name) IS NOT NULL
+	ORDER BY v.end_time DESC
+	LIMIT 1
+), best_validation AS (
+	SELECT v.id, (v.metrics->'validation_metrics'->>const.metric_name)::float8 AS value
+	FROM validations v, const
+	WHERE v.trial_id = ?1
+	AND (v.metrics->'validation_metrics'->>const.metric_name) IS NOT NULL
+	ORDER BY const.sign * (v.metrics->'validation_metrics'->>const.metric_name)::float8 ASC
+	LIMIT 1
+)
+UPDATE runs
+SET latest_validation_id = (SELECT id FROM latest_validation),
+	best_validation_id = (SELECT id FROM best_validation),
+	searcher_metric_value = (SELECT value FROM best_validation),
+	searcher_metric_value_signed = (SELECT bv.value * const.sign FROM best_validation bv, const)
+WHERE id = ?1`, i.expID, t.id).Exec(ctx); err != nil {
+			return fmt.Errorf("setting validations of trial %d: %w", srcID, err)
+		}
+	}
+
+	if _, err := tx.NewRaw(`
+UPDATE experiments SET best_trial_id = coalesce((
+	SELECT id FROM runs
+	WHERE experiment_id = ?0 AND searcher_metric_value_signed IS NOT NULL
+	ORDER BY searcher_metric_value_signed ASC
+	LIMIT 1
+), best_trial_id)
+WHERE id = ?0`, i.expID).Exec(ctx); err != nil {
+		return fmt.Errorf("setting best trial: %w", err)
+	}
+	return nil
+}
+
+// readFile reads the next file of the bundle, which must be at path.
+func (i *importer) readFile(path string) ([]byte, error) {
+	entry, err := i.ar.Next()
+	if err == io.EOF {
+		return nil, invalidf("bundle ended before %s", path)
+	} else if err != nil {
+		return nil, invalidf("reading bundle: %w", err)
+	}
+	if entry.Path != path {
+		return nil, invalidf("expected %s in bundle, found %s", path, entry.Path)
+	}
+	b, err := io.ReadAll(i.ar)
+	if err != nil {
+		return nil, invalidf("reading %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// readJSON decodes the next file of the bundle, which must be at path, into v.
+func (i *importer) readJSON(path string, v any) error {
+	b, err := i.readFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return invalidf("decoding %s: %w", path, err)
+	}
+	return nil
+}
+
+// decodeJSON decodes the current file of the bundle into v.
+func (i *importer) decodeJSON(path string, v any) error {
+	if err := json.NewDecoder(i.ar).Decode(v); err != nil {
+		return invalidf("decoding %s: %w", path, err)
+	}
+	return nil
+}
+
+// invalidf formats an error in the bundle itself, as opposed to one in storing it.
+func invalidf(format string, args ...any) error {
+	return fmt.Errorf("%w: %w", db.ErrInvalidInput, fmt.Errorf(format, args...))
+}
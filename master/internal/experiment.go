@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/google/uuid"
@@ -12,6 +13,7 @@ import (
 	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 	"github.com/uptrace/bun"
+	"golang.org/x/exp/maps"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -21,6 +23,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/config"
 	"github.com/determined-ai/determined/master/internal/configpolicy"
 	internaldb "github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/elasticslots"
 	"github.com/determined-ai/determined/master/internal/experiment"
 	"github.com/determined-ai/determined/master/internal/job/jobservice"
 	"github.com/determined-ai/determined/master/internal/rm"
@@ -545,6 +548,27 @@ func (e *internalExperiment) KillExperiment() error {
 	return nil
 }
 
+// ResizeElasticTrials resizes the experiment's trials to fit the spare capacity of their resource
+// pool, if they are elastic.
+func (e *internalExperiment) ResizeElasticTrials(pools map[string]*elasticslots.PoolCapacity) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.activeConfig.Resources().Elastic() == nil {
+		return
+	}
+	c, ok := pools[e.activeConfig.Resources().ResourcePool()]
+	if !ok {
+		return
+	}
+	// Older trials get the first pick of idle slots.
+	trials := maps.Values(e.trials)
+	sort.Slice(trials, func(i, j int) bool { return trials[i].id < trials[j].id })
+	for _, t := range trials {
+		t.MaybeResize(c)
+	}
+}
+
 func (e *internalExperiment) TrialExited(requestID model.RequestID, reason *model.ExitedReason) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
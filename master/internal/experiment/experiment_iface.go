@@ -1,6 +1,7 @@
 package experiment
 
 import (
+	"github.com/determined-ai/determined/master/internal/elasticslots"
 	"github.com/determined-ai/determined/master/internal/rm/tasklist"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -57,4 +58,5 @@ type Experiment interface {
 	PauseExperiment() error
 	CancelExperiment() error
 	KillExperiment() error
+	ResizeElasticTrials(pools map[string]*elasticslots.PoolCapacity)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/uptrace/bun"
 
+	"github.com/determined-ai/determined/master/internal/derivedmetrics"
 	"github.com/determined-ai/determined/proto/pkg/projectv1"
 )
 
@@ -55,6 +56,9 @@ type experimentFilter struct {
 type experimentFilterRoot struct {
 	FilterGroup  experimentFilter
 	ShowArchived bool
+
+	// derived are the derived metrics that fields may refer to.
+	derived derivedmetrics.Set
 }
 
 func (o *operator) toSQL() (string, error) {
@@ -531,6 +535,36 @@ func hpToSQL(c string, filterColumnType *string, filterValue *interface{},
 	return q.Where(queryString, queryArgs...), nil
 }
 
+// derivedMetricToSQL filters on a derived metric of the run aliased as `r`.
+func derivedMetricToSQL(c string, derived derivedmetrics.Set, filterValue *interface{},
+	op *operator, q *bun.SelectQuery, fc *filterConjunction,
+) (*bun.SelectQuery, error) {
+	expr, err := derived.Column(c)
+	if err != nil {
+		return nil, err
+	}
+	oSQL, err := op.toSQL()
+	if err != nil {
+		return nil, err
+	}
+	col, queryArgs := expr.SQL()
+	var queryString string
+	switch *op {
+	case contains, doesNotContain:
+		return nil, fmt.Errorf("operator %v is not supported on derived metric %s", *op, c)
+	case empty, notEmpty:
+		queryArgs = append(queryArgs, bun.Safe(oSQL))
+		queryString = fmt.Sprintf("%s ?", col)
+	default:
+		queryArgs = append(queryArgs, bun.Safe(oSQL), *filterValue)
+		queryString = fmt.Sprintf("%s ? ?", col)
+	}
+	if fc != nil && *fc == or {
+		return q.WhereOr(queryString, queryArgs...), nil
+	}
+	return q.Where(queryString, queryArgs...), nil
+}
+
 func expRunOperatorQuery(o operator, col string, oSQL string, val *interface{}) (string, []interface{}) {
 	var queryArgs []interface{}
 	var queryString string
@@ -556,7 +590,7 @@ func expRunOperatorQuery(o operator, col string, oSQL string, val *interface{})
 }
 
 func (e experimentFilterRoot) toSQL(q *bun.SelectQuery) (*bun.SelectQuery, error) {
-	q, err := e.FilterGroup.toSQL(q, nil)
+	q, err := e.FilterGroup.toSQL(q, nil, e.derived)
 	if err != nil {
 		return nil, err
 	}
@@ -564,7 +598,7 @@ func (e experimentFilterRoot) toSQL(q *bun.SelectQuery) (*bun.SelectQuery, error
 }
 
 func (e experimentFilter) toSQL(q *bun.SelectQuery,
-	c *filterConjunction,
+	c *filterConjunction, derived derivedmetrics.Set,
 ) (*bun.SelectQuery, error) {
 	switch e.Kind {
 	case field:
@@ -657,6 +691,8 @@ func (e experimentFilter) toSQL(q *bun.SelectQuery,
 			return runHpToSQL(e.ColumnName, e.Type, e.Value, e.Operator, q, c)
 		case projectv1.LocationType_LOCATION_TYPE_RUN_METADATA.String():
 			return runMetadataToSQL(e.ColumnName, e.Type, e.Value, e.Operator, q, c)
+		case projectv1.LocationType_LOCATION_TYPE_DERIVED_METRIC.String():
+			return derivedMetricToSQL(e.ColumnName, derived, e.Value, e.Operator, q, c)
 		}
 	case group:
 		var co string
@@ -677,7 +713,7 @@ func (e experimentFilter) toSQL(q *bun.SelectQuery,
 		}
 		for _, c := range e.Children {
 			q = q.WhereGroup(co, func(q *bun.SelectQuery) *bun.SelectQuery {
-				_, err = c.toSQL(q, e.Conjunction)
+				_, err = c.toSQL(q, e.Conjunction, derived)
 				if err != nil {
 					return q
 				}
@@ -36,7 +36,8 @@ const (
 	// AllocationTokenHeader is the header used to pass the allocation token.
 	AllocationTokenHeader = "x-allocation-token"
 	userTokenHeader       = "x-user-token"
-	cookieName            = "auth"
+	// AuthCookieName is the cookie holding the session token of a user in the web UI.
+	AuthCookieName = "auth"
 )
 
 type (
@@ -110,6 +111,12 @@ func getAllocationSessionBun(ctx context.Context) (*model.AllocationSession, err
 	}
 }
 
+// WithUser returns a context in which the given user is the logged in user, for acting on behalf
+// of a user outside of a request.
+func WithUser(ctx context.Context, user *model.User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
 // GetUser returns the currently logged in user.
 func GetUser(ctx context.Context) (*model.User, *model.UserSession, error) {
 	if user, ok := ctx.Value(userContextKey{}).(*model.User); ok {
@@ -260,14 +267,14 @@ func userTokenResponse(_ context.Context, w http.ResponseWriter, resp proto.Mess
 	switch r := resp.(type) {
 	case *apiv1.LoginResponse:
 		http.SetCookie(w, &http.Cookie{
-			Name:    cookieName,
+			Name:    AuthCookieName,
 			Value:   r.Token,
 			Expires: time.Now().Add(user.SessionDuration),
 			Path:    "/",
 		})
 	case *apiv1.LogoutResponse:
 		http.SetCookie(w, &http.Cookie{
-			Name:    cookieName,
+			Name:    AuthCookieName,
 			Value:   "",
 			Expires: time.Unix(0, 0),
 		})
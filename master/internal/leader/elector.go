@@ -0,0 +1,193 @@
+// Package leader elects which of the masters sharing a database is active. The leader holds a lease
+// in the database that it renews periodically; the others are standbys that wait for the lease to
+// expire and forward requests to the leader in the meantime.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+// ErrLeaseLost is returned when the leader could not renew its lease, after which another master
+// may be the leader.
+var ErrLeaseLost = errors.New("lost the leader lease")
+
+// Lease corresponds to the single row of the "master_leader_lease" DB table.
+type Lease struct {
+	bun.BaseModel `bun:"table:master_leader_lease"`
+
+	ID         int       `bun:"id,pk"`
+	Holder     string    `bun:"holder,notnull"`
+	Address    string    `bun:"address,notnull"`
+	Term       int64     `bun:"term,notnull"`
+	AcquiredAt time.Time `bun:"acquired_at,notnull"`
+	RenewedAt  time.Time `bun:"renewed_at,notnull"`
+	ExpiresAt  time.Time `bun:"expires_at,notnull"`
+}
+
+// CurrentLease returns the lease of the current leader, or db.ErrNotFound if there is none.
+func CurrentLease(ctx context.Context) (*Lease, error) {
+	var l Lease
+	if err := db.Bun().NewSelect().Model(&l).
+		Where("id = 1").
+		Where("expires_at > now()").
+		Scan(ctx); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return &l, nil
+}
+
+// Elector campaigns for the lease on behalf of one master and, once it is elected, renews it.
+type Elector struct {
+	holder  string
+	address string
+	ttl     time.Duration
+
+	term       atomic.Int64
+	leader     atomic.Bool
+	acquiredAt time.Time
+}
+
+// New returns an elector for the master identified by holder, reachable at address. A leader that
+// stops renewing its lease keeps it for ttl.
+func New(holder, address string, ttl time.Duration) *Elector {
+	return &Elector{holder: holder, address: address, ttl: ttl}
+}
+
+// IsLeader returns whether this master currently holds the lease.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Term returns the term of the lease this master was last elected for; terms increase with every
+// election.
+func (e *Elector) Term() int64 {
+	return e.term.Load()
+}
+
+// Campaign blocks until this master is elected leader or the context is canceled.
+func (e *Elector) Campaign(ctx context.Context) error {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		elected, err := e.tryAcquire(ctx)
+		switch {
+		case err != nil:
+			log.WithError(err).Warn("failed to campaign for the leader lease")
+		case elected:
+			log.Infof("elected leader for term %d", e.Term())
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Hold renews the lease until the context is canceled or the lease is lost. It steps down before
+// the lease could expire if renewals keep failing, so no two masters ever believe they lead.
+func (e *Elector) Hold(ctx context.Context) error {
+	interval := e.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	deadline := e.acquiredAt.Add(e.ttl)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		switch err := e.renew(ctx); {
+		case errors.Is(err, ErrLeaseLost):
+			e.leader.Store(false)
+			return err
+		case err != nil && ctx.Err() != nil:
+			return nil
+		case err != nil:
+			log.WithError(err).Warn("failed to renew the leader lease")
+			if time.Now().Add(interval).After(deadline) {
+				e.leader.Store(false)
+				return fmt.Errorf("%w: %s", ErrLeaseLost, err)
+			}
+		default:
+			deadline = start.Add(e.ttl)
+		}
+	}
+}
+
+// Resign gives up the lease so a standby can take over without waiting for it to expire.
+func (e *Elector) Resign(ctx context.Context) error {
+	if !e.leader.Swap(false) {
+		return nil
+	}
+	if _, err := db.Bun().NewUpdate().Model((*Lease)(nil)).
+		Set("expires_at = now()").
+		Where("id = 1").
+		Where("holder = ?", e.holder).
+		Where("term = ?", e.Term()).
+		Exec(ctx); err != nil {
+		return fmt.Errorf("resigning the leader lease: %w", err)
+	}
+	log.Infof("resigned leadership for term %d", e.Term())
+	return nil
+}
+
+// tryAcquire takes the lease if it is free or expired, and returns whether it did.
+func (e *Elector) tryAcquire(ctx context.Context) (bool, error) {
+	start := time.Now()
+	var term int64
+	err := db.Bun().NewRaw(`
+INSERT INTO master_leader_lease AS l (id, holder, address, term, acquired_at, renewed_at, expires_at)
+VALUES (1, ?, ?, 1, now(), now(), now() + ? * interval '1 millisecond')
+ON CONFLICT (id) DO UPDATE SET
+	holder = EXCLUDED.holder,
+	address = EXCLUDED.address,
+	term = l.term + 1,
+	acquired_at = EXCLUDED.acquired_at,
+	renewed_at = EXCLUDED.renewed_at,
+	expires_at = EXCLUDED.expires_at
+WHERE l.expires_at <= now()
+RETURNING term`, e.holder, e.address, e.ttl.Milliseconds()).Scan(ctx, &term)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("acquiring the leader lease: %w", err)
+	}
+	e.acquiredAt = start
+	e.term.Store(term)
+	e.leader.Store(true)
+	return true, nil
+}
+
+// renew extends the lease, returning ErrLeaseLost if it expired or another master took it over.
+func (e *Elector) renew(ctx context.Context) error {
+	res, err := db.Bun().NewUpdate().Model((*Lease)(nil)).
+		Set("renewed_at = now()").
+		Set("expires_at = now() + ? * interval '1 millisecond'", e.ttl.Milliseconds()).
+		Where("id = 1").
+		Where("holder = ?", e.holder).
+		Where("term = ?", e.Term()).
+		Where("expires_at > now()").
+		Exec(ctx)
+	if err := db.MustHaveAffectedRows(res, err); errors.Is(err, db.ErrNotFound) {
+		return ErrLeaseLost
+	} else if err != nil {
+		return fmt.Errorf("renewing the leader lease: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+//go:build integration
+
+package leader
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/db"
+)
+
+func TestMain(m *testing.M) {
+	pgDB, _, err := db.ResolveTestPostgres()
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	err = db.MigrateTestPostgres(pgDB, "file://../../static/migrations", "up")
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func clearLease(ctx context.Context, t *testing.T) {
+	_, err := db.Bun().NewDelete().Model((*Lease)(nil)).Where("true").Exec(ctx)
+	require.NoError(t, err)
+}
+
+func TestTwoMasters(t *testing.T) {
+	ctx := context.Background()
+	clearLease(ctx, t)
+	const ttl = 3 * time.Second
+
+	first := New("first", "http://first:8080", ttl)
+	second := New("second", "http://second:8080", ttl)
+
+	// The first master to campaign is elected, and the other waits.
+	require.NoError(t, first.Campaign(ctx))
+	require.True(t, first.IsLeader())
+	elected, err := second.tryAcquire(ctx)
+	require.NoError(t, err)
+	require.False(t, elected)
+
+	l, err := CurrentLease(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "first", l.Holder)
+	require.Equal(t, "http://first:8080", l.Address)
+
+	// The leader keeps the lease while it renews it.
+	holdCtx, stopHolding := context.WithCancel(ctx)
+	held := make(chan error, 1)
+	go func() { held <- first.Hold(holdCtx) }()
+
+	campaignCtx, cancel := context.WithTimeout(ctx, 4*ttl)
+	defer cancel()
+	campaigned := make(chan error, 1)
+	go func() { campaigned <- second.Campaign(campaignCtx) }()
+
+	time.Sleep(ttl + ttl/2)
+	require.True(t, first.IsLeader())
+	require.False(t, second.IsLeader())
+
+	// When the leader stops renewing it, e.g. because it died, the lease expires and the standby
+	// takes over in a new term.
+	stopHolding()
+	require.NoError(t, <-held)
+	require.NoError(t, <-campaigned)
+	require.True(t, second.IsLeader())
+	require.Greater(t, second.Term(), first.Term())
+
+	// The old leader notices it lost the lease as soon as it tries to renew it.
+	require.ErrorIs(t, first.renew(ctx), ErrLeaseLost)
+
+	// Resigning hands the lease over without waiting for it to expire.
+	require.NoError(t, second.Resign(ctx))
+	require.False(t, second.IsLeader())
+	_, err = CurrentLease(ctx)
+	require.ErrorIs(t, err, db.ErrNotFound)
+	elected, err = first.tryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, elected)
+	require.Greater(t, first.Term(), second.Term())
+}
@@ -0,0 +1,122 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// leaseCacheDuration is how long a standby forwards requests to a leader before looking it up again.
+const leaseCacheDuration = time.Second
+
+// Standby serves the requests a master receives until it is elected leader and has started: health
+// checks report its role, and everything else, including agent websockets and proxied tasks, is
+// forwarded to the leader.
+type Standby struct {
+	elector   *Elector
+	transport http.RoundTripper
+
+	// currentLease is replaced in tests.
+	currentLease func(context.Context) (*Lease, error)
+
+	mu        sync.Mutex
+	lease     *Lease
+	leaseErr  error
+	checkedAt time.Time
+}
+
+// NewStandby returns the handler of a master waiting on the elector. Forwarded requests are sent
+// through transport, or http.DefaultTransport if it is nil.
+func NewStandby(e *Elector, transport http.RoundTripper) *Standby {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Standby{elector: e, transport: transport, currentLease: CurrentLease}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Standby) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/health" {
+		s.health(w, r)
+		return
+	}
+	if s.elector.IsLeader() {
+		http.Error(w, "master is starting", http.StatusServiceUnavailable)
+		return
+	}
+
+	l, err := s.leader(r.Context())
+	if errors.Is(err, db.ErrNotFound) {
+		http.Error(w, "no leader master is elected", http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		log.WithError(err).Warn("failed to look up the leader master")
+		http.Error(w, "could not look up the leader master", http.StatusServiceUnavailable)
+		return
+	}
+	target, err := url.Parse(l.Address)
+	if err != nil {
+		log.WithError(err).Errorf("leader master advertised an invalid address %q", l.Address)
+		http.Error(w, "leader master address is invalid", http.StatusBadGateway)
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out.Host = pr.In.Host
+		},
+		Transport: s.transport,
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// health reports the role of the master, and is never healthy so load balancers only send requests
+// to the leader once it has started.
+func (s *Standby) health(w http.ResponseWriter, r *http.Request) {
+	hc := model.HealthCheck{
+		Status:           model.Unhealthy,
+		Database:         model.Healthy,
+		ResourceManagers: []model.ResourceManagerHealth{},
+		Role:             model.StandbyRole,
+	}
+	if s.elector.IsLeader() {
+		hc.Role = model.LeaderRole
+		hc.Leader = s.elector.address
+	} else {
+		switch l, err := s.leader(r.Context()); {
+		case err == nil:
+			hc.Leader = l.Address
+		case !errors.Is(err, db.ErrNotFound):
+			hc.Database = model.Unhealthy
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	if err := json.NewEncoder(w).Encode(hc); err != nil {
+		log.WithError(err).Debug("failed to write standby health check")
+	}
+}
+
+// leader returns the lease of the current leader, looked up at most once every leaseCacheDuration.
+func (s *Standby) leader(ctx context.Context) (*Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.checkedAt) > leaseCacheDuration {
+		s.lease, s.leaseErr = s.currentLease(ctx)
+		s.checkedAt = time.Now()
+	}
+	return s.lease, s.leaseErr
+}
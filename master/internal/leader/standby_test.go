@@ -0,0 +1,74 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestStandby(t *testing.T) {
+	var forwarded []string
+	leaderMaster := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = append(forwarded, r.URL.RequestURI())
+		_, _ = w.Write([]byte("from the leader"))
+	}))
+	defer leaderMaster.Close()
+
+	e := New("standby", "http://standby:8080", time.Minute)
+	s := NewStandby(e, nil)
+	var lease *Lease
+	s.currentLease = func(context.Context) (*Lease, error) {
+		if lease == nil {
+			return nil, db.ErrNotFound
+		}
+		return lease, nil
+	}
+	get := func(path string) (int, string) {
+		s.checkedAt = s.checkedAt.Add(-leaseCacheDuration) // Look the leader up every time.
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		return rec.Code, string(body)
+	}
+	health := func() model.HealthCheck {
+		code, body := get("/health")
+		require.Equal(t, http.StatusServiceUnavailable, code)
+		var hc model.HealthCheck
+		require.NoError(t, json.Unmarshal([]byte(body), &hc))
+		return hc
+	}
+
+	// Without a leader, nothing can be forwarded.
+	require.Equal(t, model.StandbyRole, health().Role)
+	code, _ := get("/api/v1/master")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	// With one, requests are forwarded to it.
+	lease = &Lease{Holder: "leader", Address: leaderMaster.URL}
+	hc := health()
+	require.Equal(t, model.StandbyRole, hc.Role)
+	require.Equal(t, leaderMaster.URL, hc.Leader)
+	code, body := get("/api/v1/master?x=1")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "from the leader", body)
+	require.Equal(t, []string{"/api/v1/master?x=1"}, forwarded)
+
+	// Once elected, the master serves nothing until it has started.
+	e.leader.Store(true)
+	hc = health()
+	require.Equal(t, model.LeaderRole, hc.Role)
+	require.Equal(t, "http://standby:8080", hc.Leader)
+	code, _ = get("/api/v1/master")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Len(t, forwarded, 1)
+}
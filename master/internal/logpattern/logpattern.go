@@ -185,6 +185,33 @@ func addRetryOnDifferentNode(
 	return nil
 }
 
+// ExcludeNodes blocks a task from being scheduled on the given nodes again for a reason other than
+// its logs, such as the task stalling on them.
+func ExcludeNodes(ctx context.Context, taskID model.TaskID, nodeNames []string, reason string) error {
+	for _, nodeName := range nodeNames {
+		m := &retryOnDifferentNode{
+			TaskID:        taskID,
+			NodeName:      nodeName,
+			TriggeringLog: reason,
+		}
+		res, err := db.Bun().NewInsert().Model(m).
+			On("CONFLICT (task_id, node_name, regex) DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("excluding node %s for task %s: %w", nodeName, taskID, err)
+		}
+		if num, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("exclude node rows affected: %w", err)
+		} else if num == 0 {
+			continue
+		}
+
+		tasklogger.Insert(tasklogger.CreateLogFromMaster(taskID, model.LogLevelError,
+			fmt.Sprintf("(%s) therefore will not schedule on %s\n", reason, nodeName)))
+	}
+	return nil
+}
+
 // DontRetryTrigger has information about don't retry policies that have been triggered.
 type DontRetryTrigger struct {
 	Regex         string
@@ -95,6 +95,21 @@ func TestRetryOnDifferentNode(t *testing.T) {
 	require.ElementsMatch(t, []string{"n0", "n1"}, blocked)
 }
 
+func TestExcludeNodes(t *testing.T) {
+	ctx := context.Background()
+
+	user := db.RequireMockUser(t, pgDB)
+	exp := db.RequireMockExperiment(t, pgDB, user)
+	_, task := db.RequireMockTrial(t, pgDB, exp)
+
+	require.NoError(t, ExcludeNodes(ctx, task.TaskID, []string{"n0", "n1"}, "stalled"))
+	require.NoError(t, ExcludeNodes(ctx, task.TaskID, []string{"n0"}, "stalled again"))
+
+	blocked, err := GetBlockedNodes(ctx, task.TaskID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"n0", "n1"}, blocked)
+}
+
 func TestShouldRetry(t *testing.T) {
 	ctx := context.Background()
 
@@ -0,0 +1,229 @@
+// Package loki stores task logs in Grafana Loki. The master pushes each log as a JSON line, labeled
+// with the experiment, trial, task, agent and rank it came from, and reads logs back with LogQL.
+package loki
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// DefaultLookback is how far back reads search for the logs of a task by default. It matches
+	// the default max_query_length of Loki.
+	DefaultLookback = 720 * time.Hour
+	// maxLabelCacheSize bounds the number of tasks whose labels are remembered.
+	maxLabelCacheSize = 10000
+	// setupTries is how many times to check that Loki is ready before giving up.
+	setupTries = 15
+)
+
+// Stream labels set on every log, besides the ones from the config.
+const (
+	experimentIDLabel = "experiment_id"
+	trialIDLabel      = "trial_id"
+	taskIDLabel       = "task_id"
+	agentIDLabel      = "agent_id"
+	rankIDLabel       = "rank_id"
+)
+
+// TaskLabelsFn returns the labels that identify the experiment and trial of a task, if any.
+type TaskLabelsFn func(ctx context.Context, taskID model.TaskID) (map[string]string, error)
+
+// Loki is a client to a Loki server that implements the task log backend.
+type Loki struct {
+	base       *url.URL
+	client     *http.Client
+	conf       model.LokiLoggingConfig
+	lookback   time.Duration
+	taskLabels TaskLabelsFn
+
+	mu          sync.Mutex
+	labelsCache map[model.TaskID]map[string]string
+}
+
+// Setup connects to the Loki server in the configuration, and waits until it is ready.
+func Setup(conf model.LokiLoggingConfig) (*Loki, error) {
+	tlsCfg, err := conf.Security.TLS.TLSConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to make loki tls config")
+	}
+	transport := cleanhttp.DefaultPooledTransport()
+	transport.TLSClientConfig = tlsCfg
+
+	l, err := New(conf, &http.Client{Transport: transport, Timeout: time.Minute}, TrialLabels)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("connecting to loki %s", l.base)
+
+	for numTries := 1; ; numTries++ {
+		err := l.ready(context.Background())
+		if err == nil {
+			log.Info("connected to loki")
+			return l, nil
+		}
+		if numTries >= setupTries {
+			return nil, errors.Wrapf(err, "could not connect to loki after %v tries", numTries)
+		}
+		toWait := 4 * time.Second
+		log.WithError(err).Warnf("failed to connect to loki, trying again in %s", toWait)
+		time.Sleep(toWait)
+	}
+}
+
+// New returns a Loki backend that sends requests through client and labels the logs of each task
+// with taskLabels.
+func New(conf model.LokiLoggingConfig, client *http.Client, taskLabels TaskLabelsFn) (*Loki, error) {
+	base, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid loki url %q", conf.URL)
+	}
+	lookback := time.Duration(conf.Lookback)
+	if lookback == 0 {
+		lookback = DefaultLookback
+	}
+	return &Loki{
+		base:        base,
+		client:      client,
+		conf:        conf,
+		lookback:    lookback,
+		taskLabels:  taskLabels,
+		labelsCache: map[model.TaskID]map[string]string{},
+	}, nil
+}
+
+// TrialLabels labels the logs of trials with their experiment and trial IDs.
+func TrialLabels(ctx context.Context, taskID model.TaskID) (map[string]string, error) {
+	t, err := db.TrialByTaskID(ctx, taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		experimentIDLabel: strconv.Itoa(t.ExperimentID),
+		trialIDLabel:      strconv.Itoa(t.ID),
+	}, nil
+}
+
+// labels returns the stream labels of a log.
+func (l *Loki) labels(ctx context.Context, tl *model.TaskLog) (map[string]string, error) {
+	taskID := model.TaskID(tl.TaskID)
+	l.mu.Lock()
+	taskLabels, ok := l.labelsCache[taskID]
+	l.mu.Unlock()
+	if !ok {
+		var err error
+		if taskLabels, err = l.taskLabels(ctx, taskID); err != nil {
+			return nil, errors.Wrapf(err, "failed to get labels of task %s", taskID)
+		}
+		l.mu.Lock()
+		if len(l.labelsCache) >= maxLabelCacheSize {
+			l.labelsCache = map[model.TaskID]map[string]string{}
+		}
+		l.labelsCache[taskID] = taskLabels
+		l.mu.Unlock()
+	}
+
+	labels := map[string]string{}
+	for k, v := range l.conf.Labels {
+		labels[k] = v
+	}
+	for k, v := range taskLabels {
+		labels[k] = v
+	}
+	labels[taskIDLabel] = tl.TaskID
+	if tl.AgentID != nil {
+		labels[agentIDLabel] = *tl.AgentID
+	}
+	if tl.RankID != nil {
+		labels[rankIDLabel] = strconv.Itoa(*tl.RankID)
+	}
+	return labels, nil
+}
+
+func (l *Loki) ready(ctx context.Context) error {
+	req, err := l.newRequest(ctx, http.MethodGet, "/ready", nil, nil)
+	if err != nil {
+		return err
+	}
+	return l.do(req, nil)
+}
+
+// newRequest builds a request to the Loki API.
+func (l *Loki) newRequest(
+	ctx context.Context, method, path string, query url.Values, body interface{},
+) (*http.Request, error) {
+	u := l.base.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	var r io.Reader
+	if body != nil {
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return nil, errors.Wrap(err, "failed to encode loki request")
+		}
+		r = &buf
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if l.conf.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", l.conf.TenantID)
+	}
+	switch sec := l.conf.Security; {
+	case sec.Username != nil && sec.Password != nil:
+		req.SetBasicAuth(*sec.Username, *sec.Password)
+	case sec.BearerToken != nil:
+		req.Header.Set("Authorization", "Bearer "+*sec.BearerToken)
+	}
+	return req, nil
+}
+
+// do sends a request and decodes its response into resp, unless resp is nil.
+func (l *Loki) do(req *http.Request, resp interface{}) error {
+	res, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			log.WithError(err).Error("error closing loki response body")
+		}
+	}()
+
+	if res.StatusCode > 299 || res.StatusCode < 200 {
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("loki request failed with code %d", res.StatusCode)
+		}
+		return fmt.Errorf("loki request failed with code %d: %s", res.StatusCode, bytes.TrimSpace(b))
+	}
+	if resp == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return errors.Wrap(err, "failed to decode loki response")
+	}
+	return nil
+}
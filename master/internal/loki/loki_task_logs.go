@@ -0,0 +1,425 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+const (
+	// TimeWindowDelay is the time buffer to allow logs to come in before we try to serve them up.
+	// Loki accepts logs out of order within a window, so following logs stays this far behind the
+	// present to not miss logs that arrive late.
+	TimeWindowDelay = 5 * time.Second
+	// maxQueryLimit is the default max_entries_limit_per_query of Loki.
+	maxQueryLimit = 5000
+	// maxDeleteTasks is how many tasks are deleted with each delete request.
+	maxDeleteTasks = 100
+)
+
+// cursor is where a read of task logs left off.
+type cursor struct {
+	// Time is the timestamp of the last logs returned, in nanoseconds.
+	Time int64
+	// Seen are the IDs of the logs returned at Time.
+	Seen map[string]bool
+}
+
+type entry struct {
+	ts  int64
+	id  string
+	log *model.TaskLog
+}
+
+// AddTaskLogs pushes a batch of task logs, grouped into streams by their labels.
+func (l *Loki) AddTaskLogs(logs []*model.TaskLog) error {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	ctx := context.TODO()
+	streams := map[string]*stream{}
+	var order []string
+	for _, tl := range logs {
+		labels, err := l.labels(ctx, tl)
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(tl)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode task log")
+		}
+		ts := time.Now()
+		if tl.Timestamp != nil {
+			ts = *tl.Timestamp
+		}
+
+		key := selector(labels)
+		s, ok := streams[key]
+		if !ok {
+			s = &stream{Stream: labels}
+			streams[key] = s
+			order = append(order, key)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(ts.UnixNano(), 10), string(line)})
+	}
+
+	body := struct {
+		Streams []*stream `json:"streams"`
+	}{}
+	for _, key := range order {
+		body.Streams = append(body.Streams, streams[key])
+	}
+	req, err := l.newRequest(ctx, http.MethodPost, "/loki/api/v1/push", nil, body)
+	if err != nil {
+		return err
+	}
+	if err := l.do(req, nil); err != nil {
+		return errors.Wrap(err, "failed to push task logs")
+	}
+	return nil
+}
+
+// TaskLogsCount returns the number of logs for the given task.
+func (l *Loki) TaskLogsCount(taskID model.TaskID, fs []api.Filter) (int, error) {
+	query, start, end, err := l.logQuery(taskID, fs)
+	if err != nil {
+		return 0, err
+	}
+	if end <= start {
+		return 0, nil
+	}
+	rangeSeconds := (end - start + int64(time.Second) - 1) / int64(time.Second)
+	results, err := l.instantQuery(
+		fmt.Sprintf("sum(count_over_time(%s [%ds]))", query, rangeSeconds), end,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get task log count")
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	count, err := strconv.ParseFloat(results[0].Value, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid task log count %q", results[0].Value)
+	}
+	return int(count), nil
+}
+
+// TaskLogs returns a batch of logs of the task after the state returned with the previous batch,
+// or the first batch if state is nil. The state is returned unchanged when there are no new logs,
+// so it can be passed again to follow the logs of a running task.
+func (l *Loki) TaskLogs(
+	taskID model.TaskID, limit int, fs []api.Filter, order apiv1.OrderBy, state interface{},
+) ([]*model.TaskLog, interface{}, error) {
+	query, start, end, err := l.logQuery(taskID, fs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	desc := order == apiv1.OrderBy_ORDER_BY_DESC
+	c, _ := state.(*cursor)
+	if c != nil {
+		if desc {
+			end = min(end, c.Time+1)
+		} else {
+			start = max(start, c.Time)
+		}
+	}
+	if end <= start {
+		return nil, state, nil
+	}
+
+	direction := "forward"
+	if desc {
+		direction = "backward"
+	}
+	queryLimit := limit
+	if c != nil {
+		queryLimit += len(c.Seen)
+	}
+	params := url.Values{
+		"query":     {query},
+		"start":     {strconv.FormatInt(start, 10)},
+		"end":       {strconv.FormatInt(end, 10)},
+		"limit":     {strconv.Itoa(min(queryLimit, maxQueryLimit))},
+		"direction": {direction},
+	}
+	var resp queryResponse
+	if err := l.get("/loki/api/v1/query_range", params, &resp); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to query task logs")
+	}
+	var streams []streamResult
+	if err := json.Unmarshal(resp.Data.Result, &streams); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode task logs")
+	}
+
+	var entries []entry
+	for _, s := range streams {
+		for _, v := range s.Values {
+			ts, err := strconv.ParseInt(v[0], 10, 64)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid task log timestamp %q", v[0])
+			}
+			var tl model.TaskLog
+			if err := json.Unmarshal([]byte(v[1]), &tl); err != nil {
+				return nil, nil, errors.Wrap(err, "failed to decode task log")
+			}
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(selector(s.Stream)))
+			_, _ = h.Write([]byte(v[1]))
+			id := fmt.Sprintf("%d-%016x", ts, h.Sum64())
+			if c != nil && ts == c.Time && c.Seen[id] {
+				continue
+			}
+			entries = append(entries, entry{ts: ts, id: id, log: &tl})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if desc {
+			a, b = b, a
+		}
+		if a.ts != b.ts {
+			return a.ts < b.ts
+		}
+		return a.id < b.id
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	if len(entries) == 0 {
+		return nil, state, nil
+	}
+
+	next := &cursor{Time: entries[len(entries)-1].ts, Seen: map[string]bool{}}
+	if c != nil && c.Time == next.Time {
+		for id := range c.Seen {
+			next.Seen[id] = true
+		}
+	}
+	logs := make([]*model.TaskLog, 0, len(entries))
+	for _, e := range entries {
+		e.log.StringID = &e.id
+		logs = append(logs, e.log)
+		if e.ts == next.Time {
+			next.Seen[e.id] = true
+		}
+	}
+	return logs, next, nil
+}
+
+// TaskLogsFields returns the unique fields that can be filtered on for the given task.
+func (l *Loki) TaskLogsFields(taskID model.TaskID) (*apiv1.TaskLogsFieldsResponse, error) {
+	now := time.Now().UnixNano()
+	results, err := l.instantQuery(fmt.Sprintf(
+		"count by (allocation_id, agent_id, container_id, rank_id, source, stdtype) "+
+			"(count_over_time(%s | json [%ds]))",
+		selector(map[string]string{taskIDLabel: string(taskID)}), int64(l.lookback/time.Second),
+	), now)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to aggregate task log fields")
+	}
+
+	unique := func(label string) []string {
+		var values []string
+		for _, r := range results {
+			if v := r.Metric[label]; v != "" && !slices.Contains(values, v) {
+				values = append(values, v)
+			}
+		}
+		sort.Strings(values)
+		return values
+	}
+	resp := &apiv1.TaskLogsFieldsResponse{
+		AllocationIds: unique("allocation_id"),
+		AgentIds:      unique(agentIDLabel),
+		ContainerIds:  unique("container_id"),
+		Sources:       unique("source"),
+		Stdtypes:      unique("stdtype"),
+	}
+	for _, v := range unique(rankIDLabel) {
+		rank, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid rank id %q", v)
+		}
+		resp.RankIds = append(resp.RankIds, int32(rank))
+	}
+	slices.Sort(resp.RankIds)
+	return resp, nil
+}
+
+// DeleteTaskLogs requests that Loki delete the logs of the given tasks. Loki only deletes logs
+// when its compactor has deletion enabled, and does so asynchronously.
+func (l *Loki) DeleteTaskLogs(ids []model.TaskID) error {
+	now := time.Now()
+	for len(ids) > 0 {
+		chunk := ids[:min(len(ids), maxDeleteTasks)]
+		ids = ids[len(chunk):]
+		var taskIDs []string
+		for _, id := range chunk {
+			taskIDs = append(taskIDs, regexp.QuoteMeta(string(id)))
+		}
+		params := url.Values{
+			"query": {fmt.Sprintf("{%s=~%s}", taskIDLabel, strconv.Quote(strings.Join(taskIDs, "|")))},
+			"start": {strconv.FormatInt(now.Add(-l.lookback).Unix(), 10)},
+			"end":   {strconv.FormatInt(now.Unix(), 10)},
+		}
+		req, err := l.newRequest(context.TODO(), http.MethodPost, "/loki/api/v1/delete", params, nil)
+		if err != nil {
+			return err
+		}
+		if err := l.do(req, nil); err != nil {
+			return errors.Wrap(err, "failed to delete task logs")
+		}
+	}
+	return nil
+}
+
+// MaxTerminationDelay is the max delay before a consumer can be sure all logs have been recevied.
+// This must be greater than TimeWindowDelay or else following terminates before all logs are
+// delivered.
+func (l *Loki) MaxTerminationDelay() time.Duration {
+	return TimeWindowDelay + time.Second
+}
+
+// logQuery returns the LogQL query for the logs of a task that match the filters, and the range of
+// timestamps to query, in nanoseconds, with an inclusive start and exclusive end.
+func (l *Loki) logQuery(taskID model.TaskID, fs []api.Filter) (string, int64, int64, error) {
+	now := time.Now()
+	start, end := now.Add(-l.lookback).UnixNano(), now.Add(-TimeWindowDelay).UnixNano()
+
+	var query strings.Builder
+	query.WriteString(selector(map[string]string{taskIDLabel: string(taskID)}))
+	query.WriteString(" | json")
+	for _, f := range fs {
+		switch f.Operation {
+		case api.FilterOperationIn, api.FilterOperationInOrNull:
+			values, err := interfaceToSlice(f.Values)
+			if err != nil {
+				return "", 0, 0, fmt.Errorf("invalid filter values: %w", err)
+			}
+			var alternatives []string
+			for _, v := range values {
+				alternatives = append(alternatives, regexp.QuoteMeta(fmt.Sprint(v)))
+			}
+			if f.Operation == api.FilterOperationInOrNull {
+				// Fields that are null are not extracted, and match the empty string.
+				alternatives = append(alternatives, "")
+			}
+			fmt.Fprintf(&query, " | %s=~%s", f.Field, strconv.Quote(strings.Join(alternatives, "|")))
+		case api.FilterOperationGreaterThan, api.FilterOperationLessThanEqual:
+			t, ok := f.Values.(time.Time)
+			if !ok || f.Field != "timestamp" {
+				return "", 0, 0, fmt.Errorf("unsupported filter on %s: %v", f.Field, f.Values)
+			}
+			if f.Operation == api.FilterOperationGreaterThan {
+				start = max(start, t.UnixNano()+1)
+			} else {
+				end = min(end, t.UnixNano()+1)
+			}
+		case api.FilterOperationStringContainment:
+			fmt.Fprintf(&query, " | %s=~%s", f.Field,
+				strconv.Quote("(?is).*"+regexp.QuoteMeta(fmt.Sprint(f.Values))+".*"))
+		case api.FilterOperationRegexContainment:
+			fmt.Fprintf(&query, " | %s=~%s", f.Field, strconv.Quote(fmt.Sprintf("(?s).*(?:%s).*", f.Values)))
+		default:
+			return "", 0, 0, fmt.Errorf("unsupported filter operation: %d", f.Operation)
+		}
+	}
+	return query.String(), start, end, nil
+}
+
+type queryResponse struct {
+	Data struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type streamResult struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type vectorResult struct {
+	Metric map[string]string
+	Value  string
+}
+
+// instantQuery runs a LogQL metric query at time ts, in nanoseconds, and returns its samples.
+func (l *Loki) instantQuery(query string, ts int64) ([]vectorResult, error) {
+	var resp queryResponse
+	if err := l.get("/loki/api/v1/query", url.Values{
+		"query": {query},
+		"time":  {strconv.FormatInt(ts, 10)},
+	}, &resp); err != nil {
+		return nil, err
+	}
+	var samples []struct {
+		Metric map[string]string `json:"metric"`
+		Value  [2]interface{}    `json:"value"`
+	}
+	if err := json.Unmarshal(resp.Data.Result, &samples); err != nil {
+		return nil, errors.Wrap(err, "failed to decode loki samples")
+	}
+	results := make([]vectorResult, 0, len(samples))
+	for _, s := range samples {
+		results = append(results, vectorResult{Metric: s.Metric, Value: fmt.Sprint(s.Value[1])})
+	}
+	return results, nil
+}
+
+func (l *Loki) get(path string, params url.Values, resp interface{}) error {
+	req, err := l.newRequest(context.TODO(), http.MethodGet, path, params, nil)
+	if err != nil {
+		return err
+	}
+	return l.do(req, resp)
+}
+
+// selector returns the LogQL stream selector that matches labels exactly.
+func selector(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	matchers := make([]string, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, name+"="+strconv.Quote(labels[name]))
+	}
+	return "{" + strings.Join(matchers, ", ") + "}"
+}
+
+// interfaceToSlice accepts an interface{} whose underlying type is []T for any T
+// and returns it as type []interface{}.
+func interfaceToSlice(x interface{}) ([]interface{}, error) {
+	var iSlice []interface{}
+	switch reflect.TypeOf(x).Kind() {
+	case reflect.Slice:
+		s := reflect.ValueOf(x)
+		for i := 0; i < s.Len(); i++ {
+			iSlice = append(iSlice, s.Index(i).Interface())
+		}
+	default:
+		return nil, fmt.Errorf("interfaceToSlice only accepts slice, not %T", x)
+	}
+	return iSlice, nil
+}
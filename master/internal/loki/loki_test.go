@@ -0,0 +1,362 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+var taskSelector = regexp.MustCompile(`\{task_id="([^"]*)"\}`)
+
+type fakeStream struct {
+	labels map[string]string
+	values [][2]string
+}
+
+// fakeLoki is an in-process stand-in for the parts of the Loki API the backend uses. It only
+// understands task_id selectors, and ignores the rest of the queries.
+type fakeLoki struct {
+	mu      sync.Mutex
+	streams []*fakeStream
+	queries []string
+	deletes []string
+	headers http.Header
+}
+
+func (f *fakeLoki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.headers = r.Header.Clone()
+
+	switch r.URL.Path {
+	case "/ready":
+	case "/loki/api/v1/push":
+		var body struct {
+			Streams []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][2]string       `json:"values"`
+			} `json:"streams"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, s := range body.Streams {
+			f.streams = append(f.streams, &fakeStream{labels: s.Stream, values: s.Values})
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "/loki/api/v1/query_range":
+		query := r.URL.Query()
+		f.queries = append(f.queries, query.Get("query"))
+		start, _ := strconv.ParseInt(query.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(query.Get("end"), 10, 64)
+		limit, _ := strconv.Atoi(query.Get("limit"))
+
+		type match struct {
+			labels map[string]string
+			value  [2]string
+			ts     int64
+		}
+		var matches []match
+		for _, s := range f.selected(query.Get("query")) {
+			for _, v := range s.values {
+				ts, _ := strconv.ParseInt(v[0], 10, 64)
+				if ts >= start && ts < end {
+					matches = append(matches, match{s.labels, v, ts})
+				}
+			}
+		}
+		backward := query.Get("direction") == "backward"
+		sort.SliceStable(matches, func(i, j int) bool {
+			if backward {
+				return matches[i].ts > matches[j].ts
+			}
+			return matches[i].ts < matches[j].ts
+		})
+		if len(matches) > limit {
+			matches = matches[:limit]
+		}
+		var result []streamResult
+		for _, m := range matches {
+			result = append(result, streamResult{Stream: m.labels, Values: [][2]string{m.value}})
+		}
+		writeResult(w, "streams", result)
+	case "/loki/api/v1/query":
+		query := r.URL.Query().Get("query")
+		f.queries = append(f.queries, query)
+		type sample struct {
+			Metric map[string]string `json:"metric"`
+			Value  [2]interface{}    `json:"value"`
+		}
+		var result []sample
+		if strings.HasPrefix(query, "count by") {
+			for _, s := range f.selected(query) {
+				metric := map[string]string{}
+				for _, v := range s.values {
+					var tl model.TaskLog
+					_ = json.Unmarshal([]byte(v[1]), &tl)
+					metric["allocation_id"] = *tl.AllocationID
+				}
+				metric[agentIDLabel] = s.labels[agentIDLabel]
+				metric[rankIDLabel] = s.labels[rankIDLabel]
+				result = append(result, sample{metric, [2]interface{}{0, strconv.Itoa(len(s.values))}})
+			}
+		} else {
+			count := 0
+			for _, s := range f.selected(query) {
+				count += len(s.values)
+			}
+			result = append(result, sample{map[string]string{}, [2]interface{}{0, strconv.Itoa(count)}})
+		}
+		writeResult(w, "vector", result)
+	case "/loki/api/v1/delete":
+		f.deletes = append(f.deletes, r.URL.Query().Get("query"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeLoki) selected(query string) []*fakeStream {
+	m := taskSelector.FindStringSubmatch(query)
+	if m == nil {
+		return nil
+	}
+	var streams []*fakeStream
+	for _, s := range f.streams {
+		if s.labels[taskIDLabel] == m[1] {
+			streams = append(streams, s)
+		}
+	}
+	return streams
+}
+
+func writeResult(w http.ResponseWriter, resultType string, result interface{}) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": resultType, "result": result},
+	})
+}
+
+func setupLoki(t *testing.T, conf model.LokiLoggingConfig) (*Loki, *fakeLoki) {
+	f := &fakeLoki{}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	conf.URL = srv.URL
+	l, err := New(conf, srv.Client(), func(ctx context.Context, taskID model.TaskID) (map[string]string, error) {
+		if taskID == "trial-task" {
+			return map[string]string{experimentIDLabel: "1", trialIDLabel: "2"}, nil
+		}
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, l.ready(context.Background()))
+	return l, f
+}
+
+func taskLogs(taskID string, n int, at time.Time) []*model.TaskLog {
+	var logs []*model.TaskLog
+	for i := 0; i < n; i++ {
+		logs = append(logs, &model.TaskLog{
+			TaskID:       taskID,
+			AllocationID: ptrs.Ptr(taskID + ".0"),
+			AgentID:      ptrs.Ptr(fmt.Sprintf("agent-%d", i%2)),
+			RankID:       ptrs.Ptr(i % 2),
+			// Pairs of logs share timestamps, to check they are neither lost nor repeated.
+			Timestamp: ptrs.Ptr(at.Add(time.Duration(i/2) * time.Millisecond)),
+			Log:       fmt.Sprintf("line %d\n", i),
+		})
+	}
+	return logs
+}
+
+func logLines(logs []*model.TaskLog) []string {
+	var lines []string
+	for _, l := range logs {
+		lines = append(lines, strings.TrimSpace(l.Log))
+	}
+	return lines
+}
+
+func TestAddTaskLogsLabels(t *testing.T) {
+	l, f := setupLoki(t, model.LokiLoggingConfig{
+		TenantID: "tenant",
+		Labels:   map[string]string{"cluster": "test"},
+		Security: model.LokiSecurityConfig{Username: ptrs.Ptr("user"), Password: ptrs.Ptr("pass")},
+	})
+
+	require.NoError(t, l.AddTaskLogs(append(
+		taskLogs("trial-task", 2, time.Now()),
+		&model.TaskLog{TaskID: "command-task", Log: "no agent\n"},
+	)))
+
+	require.Equal(t, "tenant", f.headers.Get("X-Scope-OrgID"))
+	user, pass, ok := (&http.Request{Header: f.headers}).BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "user", user)
+	require.Equal(t, "pass", pass)
+
+	var labels []map[string]string
+	for _, s := range f.streams {
+		labels = append(labels, s.labels)
+	}
+	require.Equal(t, []map[string]string{
+		{
+			"cluster": "test", experimentIDLabel: "1", trialIDLabel: "2",
+			taskIDLabel: "trial-task", agentIDLabel: "agent-0", rankIDLabel: "0",
+		},
+		{
+			"cluster": "test", experimentIDLabel: "1", trialIDLabel: "2",
+			taskIDLabel: "trial-task", agentIDLabel: "agent-1", rankIDLabel: "1",
+		},
+		{"cluster": "test", taskIDLabel: "command-task"},
+	}, labels)
+}
+
+func TestTaskLogsPagination(t *testing.T) {
+	l, f := setupLoki(t, model.LokiLoggingConfig{
+		Security: model.LokiSecurityConfig{BearerToken: ptrs.Ptr("token")},
+	})
+	at := time.Now().Add(-time.Minute)
+	require.NoError(t, l.AddTaskLogs(taskLogs("task", 7, at)))
+	require.NoError(t, l.AddTaskLogs(taskLogs("other-task", 3, at)))
+	require.Equal(t, "Bearer token", f.headers.Get("Authorization"))
+
+	for _, order := range []apiv1.OrderBy{apiv1.OrderBy_ORDER_BY_ASC, apiv1.OrderBy_ORDER_BY_DESC} {
+		var lines []string
+		var state interface{}
+		for {
+			logs, next, err := l.TaskLogs("task", 2, nil, order, state)
+			require.NoError(t, err)
+			if len(logs) == 0 {
+				require.Equal(t, state, next)
+				break
+			}
+			for _, tl := range logs {
+				require.NotNil(t, tl.StringID)
+			}
+			lines = append(lines, logLines(logs)...)
+			state = next
+		}
+		require.ElementsMatch(t, []string{
+			"line 0", "line 1", "line 2", "line 3", "line 4", "line 5", "line 6",
+		}, lines)
+		// Logs are ordered by timestamp, which pairs of logs share.
+		for i := 1; i < len(lines); i++ {
+			prev, _ := strconv.Atoi(strings.TrimPrefix(lines[i-1], "line "))
+			cur, _ := strconv.Atoi(strings.TrimPrefix(lines[i], "line "))
+			if order == apiv1.OrderBy_ORDER_BY_ASC {
+				require.LessOrEqual(t, prev/2, cur/2)
+			} else {
+				require.GreaterOrEqual(t, prev/2, cur/2)
+			}
+		}
+	}
+
+	count, err := l.TaskLogsCount("task", nil)
+	require.NoError(t, err)
+	require.Equal(t, 7, count)
+}
+
+func TestTaskLogsFollow(t *testing.T) {
+	l, _ := setupLoki(t, model.LokiLoggingConfig{})
+	at := time.Now().Add(-time.Minute)
+	require.NoError(t, l.AddTaskLogs(taskLogs("task", 2, at)))
+
+	logs, state, err := l.TaskLogs("task", 10, nil, apiv1.OrderBy_ORDER_BY_ASC, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"line 0", "line 1"}, logLines(logs))
+
+	logs, next, err := l.TaskLogs("task", 10, nil, apiv1.OrderBy_ORDER_BY_ASC, state)
+	require.NoError(t, err)
+	require.Empty(t, logs)
+	require.Equal(t, state, next)
+
+	// A later log, and one that arrived late with the same timestamp as the last one read.
+	require.NoError(t, l.AddTaskLogs([]*model.TaskLog{
+		{TaskID: "task", Timestamp: ptrs.Ptr(at), Log: "late\n"},
+		{TaskID: "task", Timestamp: ptrs.Ptr(at.Add(time.Second)), Log: "later\n"},
+	}))
+	logs, _, err = l.TaskLogs("task", 10, nil, apiv1.OrderBy_ORDER_BY_ASC, state)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"late", "later"}, logLines(logs))
+
+	// Logs within the time window delay are not served yet.
+	require.NoError(t, l.AddTaskLogs([]*model.TaskLog{
+		{TaskID: "task", Timestamp: ptrs.Ptr(time.Now()), Log: "too new\n"},
+	}))
+	count, err := l.TaskLogsCount("task", nil)
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+	logs, _, err = l.TaskLogs("task", 10, nil, apiv1.OrderBy_ORDER_BY_ASC, nil)
+	require.NoError(t, err)
+	require.NotContains(t, logLines(logs), "too new")
+}
+
+func TestTaskLogsFields(t *testing.T) {
+	l, f := setupLoki(t, model.LokiLoggingConfig{})
+	require.NoError(t, l.AddTaskLogs(taskLogs("task", 4, time.Now())))
+
+	fields, err := l.TaskLogsFields("task")
+	require.NoError(t, err)
+	require.Equal(t, []string{"task.0"}, fields.AllocationIds)
+	require.Equal(t, []string{"agent-0", "agent-1"}, fields.AgentIds)
+	require.Equal(t, []int32{0, 1}, fields.RankIds)
+	require.Contains(t, f.queries[0], `count_over_time({task_id="task"} | json [2592000s])`)
+}
+
+func TestDeleteTaskLogs(t *testing.T) {
+	l, f := setupLoki(t, model.LokiLoggingConfig{})
+	ids := []model.TaskID{"a.b"}
+	for i := 0; i < maxDeleteTasks; i++ {
+		ids = append(ids, model.TaskID(strconv.Itoa(i)))
+	}
+
+	require.NoError(t, l.DeleteTaskLogs(ids))
+	require.Len(t, f.deletes, 2)
+	require.True(t, strings.HasPrefix(f.deletes[0], `{task_id=~"a\\.b|0|1|`), f.deletes[0])
+	require.Equal(t, `{task_id=~"99"}`, f.deletes[1])
+}
+
+func TestLogQuery(t *testing.T) {
+	l, err := New(model.LokiLoggingConfig{URL: "http://loki"}, http.DefaultClient, nil)
+	require.NoError(t, err)
+	since := time.Now().Add(-time.Hour)
+	until := time.Now().Add(-time.Minute)
+
+	query, start, end, err := l.logQuery("task", []api.Filter{
+		{Field: "rank_id", Operation: api.FilterOperationIn, Values: []int32{0, 1}},
+		{Field: "stdtype", Operation: api.FilterOperationInOrNull, Values: []string{"stdout"}},
+		{Field: "timestamp", Operation: api.FilterOperationGreaterThan, Values: since},
+		{Field: "timestamp", Operation: api.FilterOperationLessThanEqual, Values: until},
+		{Field: "log", Operation: api.FilterOperationStringContainment, Values: `a "b".c`},
+		{Field: "log", Operation: api.FilterOperationRegexContainment, Values: `^E\d`},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `{task_id="task"} | json | rank_id=~"0|1" | stdtype=~"stdout|"`+
+		` | log=~"(?is).*a \"b\"\\.c.*" | log=~"(?s).*(?:^E\\d).*"`, query)
+	require.Equal(t, since.UnixNano()+1, start)
+	require.Equal(t, until.UnixNano()+1, end)
+
+	_, _, _, err = l.logQuery("task", []api.Filter{
+		{Field: "rank_id", Operation: api.FilterOperationGreaterThan, Values: 1},
+	})
+	require.ErrorContains(t, err, "unsupported filter")
+}
@@ -0,0 +1,375 @@
+// Package otelmetrics forwards the metrics reported by trials to OpenTelemetry.
+package otelmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+const (
+	// DefaultQueueSize is the number of reports that may wait to be exported when unconfigured.
+	DefaultQueueSize = 10000
+	// DefaultSeriesTTL is how long the series of a trial are exported after its last report when
+	// unconfigured.
+	DefaultSeriesTTL = time.Hour
+
+	meterName = "github.com/determined-ai/determined/master/internal/otelmetrics"
+
+	maxCachedTrials = 10000
+	attributesTTL   = 5 * time.Minute
+	shutdownTimeout = 10 * time.Second
+)
+
+// Attribute keys metrics are exported with.
+const (
+	ExperimentIDKey  = attribute.Key("determined.experiment.id")
+	TrialIDKey       = attribute.Key("determined.trial.id")
+	WorkspaceIDKey   = attribute.Key("determined.workspace.id")
+	WorkspaceNameKey = attribute.Key("determined.workspace.name")
+	LabelsKey        = attribute.Key("determined.experiment.labels")
+	MetricGroupKey   = attribute.Key("determined.metric.group")
+	MetricNameKey    = attribute.Key("determined.metric.name")
+)
+
+var (
+	singletonExporter *Exporter
+	singletonProvider *sdkmetric.MeterProvider
+)
+
+// Init creates an exporter singleton recording to the given meter provider, which it takes
+// ownership of.
+func Init(provider *sdkmetric.MeterProvider, queueSize int, seriesTTL time.Duration) error {
+	e, err := New(provider.Meter(meterName), queueSize, seriesTTL)
+	if err != nil {
+		return err
+	}
+	singletonExporter = e
+	singletonProvider = provider
+	return nil
+}
+
+// Deinit flushes and shuts down the meter provider and stops the exporter singleton, if any.
+func Deinit() {
+	if singletonProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := singletonProvider.Shutdown(ctx); err != nil {
+			log.WithError(err).Warn("failed to shut down trial metrics meter provider")
+		}
+		singletonProvider = nil
+	}
+	if singletonExporter != nil {
+		singletonExporter.Close()
+		singletonExporter = nil
+	}
+}
+
+// Report queues reported metrics for export by the exporter singleton. It never blocks and is a
+// no-op if the exporter is not initialized.
+func Report(trialID int32, group model.MetricGroup, totalBatches int32, metrics *structpb.Struct) {
+	if singletonExporter != nil {
+		singletonExporter.Report(trialID, group, totalBatches, metrics)
+	}
+}
+
+// TrialEnded stops exporting the metrics of a trial through the exporter singleton, if any.
+func TrialEnded(trialID int32) {
+	if singletonExporter != nil {
+		singletonExporter.TrialEnded(trialID)
+	}
+}
+
+type report struct {
+	trialID      int32
+	group        model.MetricGroup
+	totalBatches int32
+	metrics      *structpb.Struct
+}
+
+type trialAttributes struct {
+	ExperimentID  int             `bun:"experiment_id"`
+	Labels        json.RawMessage `bun:"labels"`
+	WorkspaceID   int             `bun:"workspace_id"`
+	WorkspaceName string          `bun:"workspace_name"`
+}
+
+type cachedAttributes struct {
+	attrs     []attribute.KeyValue
+	fetchedAt time.Time
+}
+
+type metricKey struct {
+	group model.MetricGroup
+	name  string
+}
+
+// trialSeries is the last reported values of a trial, observed on every collection until the
+// trial ends or stops reporting.
+type trialSeries struct {
+	attrs      []attribute.KeyValue
+	batches    map[model.MetricGroup]int64
+	values     map[metricKey]float64
+	reportedAt time.Time
+}
+
+// Exporter records reported trial metrics as OpenTelemetry gauges. Reports are queued and
+// recorded by a background worker so that reporting never waits on the export; when the queue
+// is full, reports are dropped and counted. The gauges are observed from the last reported
+// values, so a trial's series stop being exported once it ends, or once it has not reported for
+// the series TTL, since the end of unmanaged and detached trials may never be seen.
+type Exporter struct {
+	log       *log.Entry
+	queue     chan report
+	seriesTTL time.Duration
+	lookup    func(ctx context.Context, trialID int32) (*trialAttributes, error)
+
+	value        metric.Float64ObservableGauge
+	batches      metric.Int64ObservableGauge
+	dropped      metric.Int64Counter
+	registration metric.Registration
+
+	// cache is only accessed by the worker.
+	cache map[int32]cachedAttributes
+
+	mu     sync.Mutex
+	series map[int32]*trialSeries
+	// ended holds when recently ended trials ended, so that reports still queued for them are
+	// not recorded.
+	ended map[int32]time.Time
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+// New creates an exporter recording to the given meter and starts its worker.
+func New(meter metric.Meter, queueSize int, seriesTTL time.Duration) (*Exporter, error) {
+	return newExporter(meter, queueSize, seriesTTL, lookupTrialAttributes)
+}
+
+func newExporter(
+	meter metric.Meter, queueSize int, seriesTTL time.Duration,
+	lookup func(ctx context.Context, trialID int32) (*trialAttributes, error),
+) (*Exporter, error) {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	if seriesTTL <= 0 {
+		seriesTTL = DefaultSeriesTTL
+	}
+
+	value, err := meter.Float64ObservableGauge("determined.trial.metric",
+		metric.WithDescription("The last reported value of a trial metric."))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating trial metric gauge")
+	}
+	batches, err := meter.Int64ObservableGauge("determined.trial.total_batches",
+		metric.WithDescription("The total batches at the last metrics report of a trial."))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating total batches gauge")
+	}
+	dropped, err := meter.Int64Counter("determined.trial.metric_reports.dropped",
+		metric.WithDescription("The number of metrics reports dropped because the export "+
+			"queue was full."))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating dropped reports counter")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background()) // Exporter-lifetime scoped context.
+	e := &Exporter{
+		log:       log.WithField("component", "otel-trial-metrics"),
+		queue:     make(chan report, queueSize),
+		seriesTTL: seriesTTL,
+		lookup:    lookup,
+		value:     value,
+		batches:   batches,
+		dropped:   dropped,
+		cache:     make(map[int32]cachedAttributes),
+		series:    make(map[int32]*trialSeries),
+		ended:     make(map[int32]time.Time),
+		cancel:    cancel,
+	}
+	e.registration, err = meter.RegisterCallback(e.observe, value, batches)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "registering trial metrics callback")
+	}
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.run(ctx)
+	}()
+	return e, nil
+}
+
+// Report queues reported metrics for export, dropping them if the queue is full. metrics must
+// not be modified afterwards.
+func (e *Exporter) Report(
+	trialID int32, group model.MetricGroup, totalBatches int32, metrics *structpb.Struct,
+) {
+	select {
+	case e.queue <- report{
+		trialID:      trialID,
+		group:        group,
+		totalBatches: totalBatches,
+		metrics:      metrics,
+	}:
+	default:
+		e.dropped.Add(context.Background(), 1,
+			metric.WithAttributes(MetricGroupKey.String(group.ToString())))
+	}
+}
+
+// TrialEnded stops exporting the metrics of a trial.
+func (e *Exporter) TrialEnded(trialID int32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.series, trialID)
+	now := time.Now()
+	for id, t := range e.ended {
+		if now.Sub(t) > attributesTTL {
+			delete(e.ended, id)
+		}
+	}
+	e.ended[trialID] = now
+}
+
+// Close stops the worker and the observation of the gauges. Reports still queued are discarded.
+func (e *Exporter) Close() {
+	e.cancel()
+	e.wg.Wait()
+	if err := e.registration.Unregister(); err != nil {
+		e.log.WithError(err).Debug("failed to unregister trial metrics callback")
+	}
+}
+
+func (e *Exporter) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r := <-e.queue:
+			if err := e.record(ctx, r); err != nil {
+				e.log.WithError(err).Debugf("failed to export metrics of trial %d", r.trialID)
+			}
+		}
+	}
+}
+
+func (e *Exporter) record(ctx context.Context, r report) error {
+	trialAttrs, err := e.attributes(ctx, r.trialID)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.ended[r.trialID]; ok {
+		return nil
+	}
+	s, ok := e.series[r.trialID]
+	if !ok {
+		s = &trialSeries{
+			batches: make(map[model.MetricGroup]int64),
+			values:  make(map[metricKey]float64),
+		}
+		e.series[r.trialID] = s
+	}
+	s.attrs = trialAttrs
+	s.reportedAt = time.Now()
+	s.batches[r.group] = int64(r.totalBatches)
+	for name, v := range r.metrics.GetFields() {
+		n, ok := v.GetKind().(*structpb.Value_NumberValue)
+		if !ok || math.IsNaN(n.NumberValue) || math.IsInf(n.NumberValue, 0) {
+			continue
+		}
+		s.values[metricKey{group: r.group, name: name}] = n.NumberValue
+	}
+	return nil
+}
+
+// observe observes the last reported values of the trials that have not ended, and evicts the
+// series of trials that have not reported within the series TTL.
+func (e *Exporter) observe(_ context.Context, o metric.Observer) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	for id, s := range e.series {
+		if now.Sub(s.reportedAt) > e.seriesTTL {
+			delete(e.series, id)
+			continue
+		}
+		attrs := s.attrs[:len(s.attrs):len(s.attrs)]
+		for group, batches := range s.batches {
+			o.ObserveInt64(e.batches, batches, metric.WithAttributes(
+				append(attrs, MetricGroupKey.String(group.ToString()))...))
+		}
+		for k, v := range s.values {
+			o.ObserveFloat64(e.value, v, metric.WithAttributes(append(attrs,
+				MetricGroupKey.String(k.group.ToString()), MetricNameKey.String(k.name))...))
+		}
+	}
+	return nil
+}
+
+// attributes returns the attributes the metrics of a trial are exported with, looking them up
+// if they are not cached or stale.
+func (e *Exporter) attributes(ctx context.Context, trialID int32) ([]attribute.KeyValue, error) {
+	if c, ok := e.cache[trialID]; ok && time.Since(c.fetchedAt) < attributesTTL {
+		return c.attrs[:len(c.attrs):len(c.attrs)], nil
+	}
+
+	t, err := e.lookup(ctx, trialID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "looking up attributes of trial %d", trialID)
+	}
+	var labels []string
+	if len(t.Labels) > 0 {
+		if err := json.Unmarshal(t.Labels, &labels); err != nil {
+			return nil, errors.Wrapf(err, "parsing labels of trial %d", trialID)
+		}
+	}
+	attrs := []attribute.KeyValue{
+		ExperimentIDKey.Int(t.ExperimentID),
+		TrialIDKey.Int(int(trialID)),
+		WorkspaceIDKey.Int(t.WorkspaceID),
+		WorkspaceNameKey.String(t.WorkspaceName),
+		LabelsKey.StringSlice(labels),
+	}
+
+	if len(e.cache) >= maxCachedTrials {
+		e.cache = make(map[int32]cachedAttributes)
+	}
+	e.cache[trialID] = cachedAttributes{attrs: attrs, fetchedAt: time.Now()}
+	return attrs[:len(attrs):len(attrs)], nil
+}
+
+func lookupTrialAttributes(ctx context.Context, trialID int32) (*trialAttributes, error) {
+	var t trialAttributes
+	if err := db.Bun().NewSelect().
+		TableExpr("trials t").
+		ColumnExpr("t.experiment_id").
+		ColumnExpr("e.config->'labels' AS labels").
+		ColumnExpr("w.id AS workspace_id").
+		ColumnExpr("w.name AS workspace_name").
+		Join("JOIN experiments e ON e.id = t.experiment_id").
+		Join("JOIN projects p ON p.id = e.project_id").
+		Join("JOIN workspaces w ON w.id = p.workspace_id").
+		Where("t.id = ?", trialID).
+		Scan(ctx, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,183 @@
+package otelmetrics
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func newTestExporter(
+	t *testing.T, queueSize int, seriesTTL time.Duration,
+	lookup func(context.Context, int32) (*trialAttributes, error),
+) (*Exporter, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	e, err := newExporter(provider.Meter(meterName), queueSize, seriesTTL, lookup)
+	require.NoError(t, err)
+	t.Cleanup(e.Close)
+	return e, reader
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	out := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			out[m.Name] = m.Data
+		}
+	}
+	return out
+}
+
+func TestExporterRecordsMetrics(t *testing.T) {
+	lookups := 0
+	e, reader := newTestExporter(t, 0, 0, func(_ context.Context, trialID int32) (*trialAttributes, error) {
+		lookups++
+		require.Equal(t, int32(7), trialID)
+		return &trialAttributes{
+			ExperimentID:  3,
+			Labels:        json.RawMessage(`["a", "b"]`),
+			WorkspaceID:   1,
+			WorkspaceName: "Uncategorized",
+		}, nil
+	})
+
+	for i := 1; i <= 2; i++ {
+		metrics, err := structpb.NewStruct(map[string]any{
+			"loss":  float64(i),
+			"notes": "not a number",
+		})
+		require.NoError(t, err)
+		e.Report(7, model.ValidationMetricGroup, int32(i*100), metrics)
+	}
+
+	require.Eventually(t, func() bool {
+		gauge, ok := collect(t, reader)["determined.trial.total_batches"].(metricdata.Gauge[int64])
+		return ok && len(gauge.DataPoints) == 1 && gauge.DataPoints[0].Value == 200
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, lookups)
+
+	data := collect(t, reader)
+	gauge, ok := data["determined.trial.metric"].(metricdata.Gauge[float64])
+	require.True(t, ok)
+	require.Len(t, gauge.DataPoints, 1)
+	point := gauge.DataPoints[0]
+	require.Equal(t, 2.0, point.Value)
+	require.Equal(t, attribute.NewSet(
+		ExperimentIDKey.Int(3),
+		TrialIDKey.Int(7),
+		WorkspaceIDKey.Int(1),
+		WorkspaceNameKey.String("Uncategorized"),
+		LabelsKey.StringSlice([]string{"a", "b"}),
+		MetricGroupKey.String("validation"),
+		MetricNameKey.String("loss"),
+	), point.Attributes)
+}
+
+func TestExporterDropsEndedTrials(t *testing.T) {
+	e, reader := newTestExporter(t, 0, 0, func(context.Context, int32) (*trialAttributes, error) {
+		return &trialAttributes{}, nil
+	})
+	metrics, err := structpb.NewStruct(map[string]any{"loss": 1.0})
+	require.NoError(t, err)
+
+	gauges := func() (map[int64]bool, int) {
+		data := collect(t, reader)
+		batches, _ := data["determined.trial.total_batches"].(metricdata.Gauge[int64])
+		trials := map[int64]bool{}
+		for _, p := range batches.DataPoints {
+			id, _ := p.Attributes.Value(TrialIDKey)
+			trials[id.AsInt64()] = true
+		}
+		values, _ := data["determined.trial.metric"].(metricdata.Gauge[float64])
+		return trials, len(values.DataPoints)
+	}
+
+	e.Report(1, model.TrainingMetricGroup, 10, metrics)
+	e.Report(2, model.TrainingMetricGroup, 10, metrics)
+	require.Eventually(t, func() bool {
+		trials, _ := gauges()
+		return len(trials) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Once a trial ends, its series are no longer exported, even if a report was still queued.
+	e.TrialEnded(1)
+	e.Report(1, model.TrainingMetricGroup, 20, metrics)
+	e.Report(2, model.TrainingMetricGroup, 20, metrics)
+	require.Eventually(t, func() bool {
+		batches, ok := collect(t, reader)["determined.trial.total_batches"].(metricdata.Gauge[int64])
+		return ok && len(batches.DataPoints) == 1 && batches.DataPoints[0].Value == 20
+	}, 5*time.Second, 10*time.Millisecond)
+	trials, values := gauges()
+	require.Equal(t, map[int64]bool{2: true}, trials)
+	require.Equal(t, 1, values)
+}
+
+func TestExporterEvictsStaleSeries(t *testing.T) {
+	ttl := 200 * time.Millisecond
+	e, reader := newTestExporter(t, 0, ttl, func(context.Context, int32) (*trialAttributes, error) {
+		return &trialAttributes{}, nil
+	})
+	metrics, err := structpb.NewStruct(map[string]any{"loss": 1.0})
+	require.NoError(t, err)
+	exported := func() int {
+		batches, _ := collect(t, reader)["determined.trial.total_batches"].(metricdata.Gauge[int64])
+		return len(batches.DataPoints)
+	}
+
+	// The end of a trial whose reports stop, such as a detached one, may never be seen, so its
+	// series are evicted once it has not reported for the TTL.
+	e.Report(1, model.TrainingMetricGroup, 10, metrics)
+	require.Eventually(t, func() bool { return exported() == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(ttl)
+	require.Equal(t, 0, exported())
+	e.mu.Lock()
+	require.Empty(t, e.series)
+	e.mu.Unlock()
+
+	// Evicted trials are exported again once they report again.
+	e.Report(1, model.TrainingMetricGroup, 20, metrics)
+	require.Eventually(t, func() bool { return exported() == 1 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestExporterDropsUnderBackpressure(t *testing.T) {
+	block := make(chan struct{})
+	e, reader := newTestExporter(t, 1, 0, func(ctx context.Context, _ int32) (*trialAttributes, error) {
+		select {
+		case <-block:
+		case <-ctx.Done():
+		}
+		return &trialAttributes{}, nil
+	})
+	defer close(block)
+
+	// The first report is picked up by the blocked worker and the second fills the queue, so
+	// reporting must return immediately and drop everything after.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			e.Report(1, model.TrainingMetricGroup, int32(i), &structpb.Struct{})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reporting blocked on a full queue")
+	}
+
+	counter, ok := collect(t, reader)["determined.trial.metric_reports.dropped"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, counter.DataPoints, 1)
+	require.GreaterOrEqual(t, counter.DataPoints[0].Value, int64(8))
+}
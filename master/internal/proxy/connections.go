@@ -0,0 +1,293 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/config"
+)
+
+// ConnectionKind is the protocol of a proxied connection.
+type ConnectionKind string
+
+const (
+	// ConnectionKindHTTP is a plain HTTP request.
+	ConnectionKindHTTP ConnectionKind = "http"
+	// ConnectionKindWebSocket is a WebSocket connection.
+	ConnectionKindWebSocket ConnectionKind = "websocket"
+	// ConnectionKindTCP is TCP tunnelled over a WebSocket connection.
+	ConnectionKindTCP ConnectionKind = "tcp"
+)
+
+// userContextKey is the key of the name of the requesting user in the echo context, set by the
+// ProxyHTTPAuth of the proxy.
+const userContextKey = "proxy-user"
+
+const (
+	promNamespace = "determined"
+	promSubsystem = "proxy"
+)
+
+var (
+	serviceLabels = []string{"service"}
+
+	proxyRequests = prom.NewCounterVec(prom.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promSubsystem,
+		Name:      "requests_total",
+		Help:      "requests and connections proxied to a service, by kind and status code",
+	}, []string{"service", "kind", "code"})
+	proxyReceivedBytes = prom.NewCounterVec(prom.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promSubsystem,
+		Name:      "received_bytes_total",
+		Help:      "bytes received from clients and forwarded to a service",
+	}, serviceLabels)
+	proxySentBytes = prom.NewCounterVec(prom.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promSubsystem,
+		Name:      "sent_bytes_total",
+		Help:      "bytes received from a service and sent to clients",
+	}, serviceLabels)
+	proxyActiveConnections = prom.NewGaugeVec(prom.GaugeOpts{
+		Namespace: promNamespace,
+		Subsystem: promSubsystem,
+		Name:      "active_connections",
+		Help:      "requests and connections to a service currently open through the proxy",
+	}, serviceLabels)
+	proxyRequestDuration = prom.NewHistogramVec(prom.HistogramOpts{
+		Namespace: promNamespace,
+		Subsystem: promSubsystem,
+		Name:      "request_duration_seconds",
+		Help:      "duration of HTTP requests proxied to a service",
+		Buckets:   prom.DefBuckets,
+	}, serviceLabels)
+	proxyUpstreamErrors = prom.NewCounterVec(prom.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promSubsystem,
+		Name:      "upstream_errors_total",
+		Help:      "failures to reach or talk to a service",
+	}, serviceLabels)
+)
+
+func init() {
+	prom.MustRegister(proxyRequests)
+	prom.MustRegister(proxyReceivedBytes)
+	prom.MustRegister(proxySentBytes)
+	prom.MustRegister(proxyActiveConnections)
+	prom.MustRegister(proxyRequestDuration)
+	prom.MustRegister(proxyUpstreamErrors)
+}
+
+// deleteServiceMetrics drops the metrics of a service that is no longer proxied, to keep the
+// number of series bounded. Connections to the service that are still open stop reporting.
+func (p *Proxy) deleteServiceMetrics(serviceID string) {
+	p.connectionsLock.RLock()
+	for _, conn := range p.connections {
+		if conn.ServiceID == serviceID {
+			conn.metrics.Store(false)
+		}
+	}
+	p.connectionsLock.RUnlock()
+
+	labels := prom.Labels{"service": serviceID}
+	proxyRequests.DeletePartialMatch(labels)
+	proxyReceivedBytes.DeletePartialMatch(labels)
+	proxySentBytes.DeletePartialMatch(labels)
+	proxyActiveConnections.DeletePartialMatch(labels)
+	proxyRequestDuration.DeletePartialMatch(labels)
+	proxyUpstreamErrors.DeletePartialMatch(labels)
+}
+
+// SetRequestUser records the name of the user making a proxied request, for the access log and
+// the connections of the service.
+func SetRequestUser(c echo.Context, username string) {
+	c.Set(userContextKey, username)
+}
+
+// Connection is a snapshot of a request or connection open through the proxy.
+type Connection struct {
+	ID            uint64
+	ServiceID     string
+	Kind          ConnectionKind
+	Username      string
+	RemoteAddr    string
+	Method        string
+	Path          string
+	StartTime     time.Time
+	BytesReceived int64
+	BytesSent     int64
+}
+
+// TaskID returns the ID of the task serving the connection.
+func (c Connection) TaskID() string {
+	return taskIDFromServiceID(c.ServiceID)
+}
+
+// taskIDFromServiceID strips the port from the ID of services proxied on other than the default
+// port of a task.
+func taskIDFromServiceID(serviceID string) string {
+	return strings.SplitN(serviceID, ":", 2)[0]
+}
+
+// connection tracks a request or connection while it is open.
+type connection struct {
+	Connection
+	bytesReceived atomic.Int64
+	bytesSent     atomic.Int64
+	upgraded      atomic.Bool
+	metrics       atomic.Bool
+}
+
+func (c *connection) snapshot() Connection {
+	s := c.Connection
+	s.BytesReceived = c.bytesReceived.Load()
+	s.BytesSent = c.bytesSent.Load()
+	return s
+}
+
+func (c *connection) addReceived(n int) {
+	c.bytesReceived.Add(int64(n))
+	if c.metrics.Load() {
+		proxyReceivedBytes.WithLabelValues(c.ServiceID).Add(float64(n))
+	}
+}
+
+func (c *connection) addSent(n int) {
+	c.bytesSent.Add(int64(n))
+	if c.metrics.Load() {
+		proxySentBytes.WithLabelValues(c.ServiceID).Add(float64(n))
+	}
+}
+
+// upstreamError records a failure to reach or talk to the service.
+func (c *connection) upstreamError() {
+	if c.metrics.Load() {
+		proxyUpstreamErrors.WithLabelValues(c.ServiceID).Inc()
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w     io.Writer
+	count func(int)
+}
+
+func (w *countingWriter) Write(buf []byte) (int, error) {
+	n, err := w.w.Write(buf)
+	w.count(n)
+	return n, err
+}
+
+// countingReadCloser counts the bytes read through it.
+type countingReadCloser struct {
+	io.ReadCloser
+	count func(int)
+}
+
+func (r *countingReadCloser) Read(buf []byte) (int, error) {
+	n, err := r.ReadCloser.Read(buf)
+	r.count(n)
+	return n, err
+}
+
+// openConnection starts tracking a request to a service.
+func (p *Proxy) openConnection(c echo.Context, serviceID string, kind ConnectionKind) *connection {
+	username, _ := c.Get(userContextKey).(string)
+	req := c.Request()
+	conn := &connection{
+		Connection: Connection{
+			ID:         p.nextConnectionID.Add(1),
+			ServiceID:  serviceID,
+			Kind:       kind,
+			Username:   username,
+			RemoteAddr: c.RealIP(),
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			StartTime:  time.Now(),
+		},
+	}
+	conn.metrics.Store(config.GetMasterConfig().Observability.EnablePrometheus)
+	if kind == ConnectionKindHTTP && req.Body != nil && req.Body != http.NoBody {
+		req.Body = &countingReadCloser{ReadCloser: req.Body, count: conn.addReceived}
+	}
+
+	p.connectionsLock.Lock()
+	p.connections[conn.ID] = conn
+	p.connectionsLock.Unlock()
+
+	if conn.metrics.Load() {
+		proxyActiveConnections.WithLabelValues(serviceID).Inc()
+	}
+	return conn
+}
+
+// closeConnection stops tracking a request to a service, and records it in the metrics and the
+// access log.
+func (p *Proxy) closeConnection(c echo.Context, conn *connection) {
+	p.connectionsLock.Lock()
+	delete(p.connections, conn.ID)
+	p.connectionsLock.Unlock()
+
+	// Echo counts the bytes of plain HTTP responses, and the proxies count the rest.
+	if conn.Kind == ConnectionKindHTTP {
+		conn.addSent(int(c.Response().Size))
+	}
+	code := c.Response().Status
+	if conn.upgraded.Load() {
+		code = http.StatusSwitchingProtocols
+	}
+	duration := time.Since(conn.StartTime)
+
+	if conn.metrics.Load() {
+		proxyActiveConnections.WithLabelValues(conn.ServiceID).Dec()
+		proxyRequests.WithLabelValues(
+			conn.ServiceID, string(conn.Kind), strconv.Itoa(code),
+		).Inc()
+		if conn.Kind == ConnectionKindHTTP {
+			proxyRequestDuration.WithLabelValues(conn.ServiceID).Observe(duration.Seconds())
+		}
+	}
+
+	if config.GetMasterConfig().Observability.ProxyAccessLog {
+		s := conn.snapshot()
+		p.accessLog.WithFields(logrus.Fields{
+			"user":           s.Username,
+			"task_id":        s.TaskID(),
+			"service_id":     s.ServiceID,
+			"kind":           s.Kind,
+			"remote_addr":    s.RemoteAddr,
+			"method":         s.Method,
+			"path":           s.Path,
+			"status":         code,
+			"duration":       duration.String(),
+			"bytes_received": s.BytesReceived,
+			"bytes_sent":     s.BytesSent,
+		}).Info("proxied request")
+	}
+}
+
+// Connections returns the requests and connections currently open to the services of a task,
+// oldest first.
+func (p *Proxy) Connections(taskID string) []Connection {
+	p.connectionsLock.RLock()
+	defer p.connectionsLock.RUnlock()
+
+	conns := []Connection{}
+	for _, conn := range p.connections {
+		if conn.TaskID() == taskID {
+			conns = append(conns, conn.snapshot())
+		}
+	}
+	sort.Slice(conns, func(i, j int) bool { return conns[i].ID < conns[j].ID })
+	return conns
+}
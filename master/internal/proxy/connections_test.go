@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnections(t *testing.T) {
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.Copy(io.Discard, r.Body)
+		require.NoError(t, err)
+		close(requested)
+		<-release
+		_, err = w.Write([]byte("hello"))
+		require.NoError(t, err)
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	p := &Proxy{
+		HTTPAuth: func(c echo.Context) (bool, error) {
+			SetRequestUser(c, "alice")
+			return false, nil
+		},
+		services:    make(map[string]*Service),
+		syslog:      logrus.WithField("component", "proxy"),
+		accessLog:   logrus.WithField("component", "proxy-access"),
+		connections: make(map[uint64]*connection),
+	}
+	p.Register("task-1:8080", upstreamURL, false, false)
+	defer p.Unregister("task-1:8080")
+
+	e := echo.New()
+	e.Any("/proxy/:service/*", p.NewProxyHandler("service"))
+	master := httptest.NewServer(e)
+	defer master.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		//nolint:noctx
+		resp, err := http.Post(
+			master.URL+"/proxy/task-1:8080/lab", "text/plain", strings.NewReader("ping"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(body))
+	}()
+
+	<-requested
+	conns := p.Connections("task-1")
+	require.Len(t, conns, 1)
+	require.Equal(t, "task-1:8080", conns[0].ServiceID)
+	require.Equal(t, ConnectionKindHTTP, conns[0].Kind)
+	require.Equal(t, "alice", conns[0].Username)
+	require.Equal(t, http.MethodPost, conns[0].Method)
+	require.Equal(t, "/proxy/task-1:8080/lab", conns[0].Path)
+	require.Equal(t, int64(4), conns[0].BytesReceived)
+	require.Empty(t, p.Connections("task-2"))
+	require.Equal(t, 1.0,
+		testutil.ToFloat64(proxyActiveConnections.WithLabelValues("task-1:8080")))
+
+	close(release)
+	<-done
+	require.Eventually(t, func() bool {
+		return len(p.Connections("task-1")) == 0
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, 0.0,
+		testutil.ToFloat64(proxyActiveConnections.WithLabelValues("task-1:8080")))
+	require.Equal(t, 1.0,
+		testutil.ToFloat64(proxyRequests.WithLabelValues("task-1:8080", "http", "200")))
+	require.Equal(t, 5.0, testutil.ToFloat64(proxySentBytes.WithLabelValues("task-1:8080")))
+}
@@ -9,6 +9,7 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-cleanhttp"
@@ -43,10 +44,15 @@ type ProxyHTTPAuth func(echo.Context) (done bool, err error)
 
 // Proxy is an actor that proxies requests to registered services.
 type Proxy struct {
-	lock     sync.RWMutex
-	HTTPAuth ProxyHTTPAuth
-	services map[string]*Service
-	syslog   *logrus.Entry
+	lock      sync.RWMutex
+	HTTPAuth  ProxyHTTPAuth
+	services  map[string]*Service
+	syslog    *logrus.Entry
+	accessLog *logrus.Entry
+
+	connectionsLock  sync.RWMutex
+	connections      map[uint64]*connection
+	nextConnectionID atomic.Uint64
 }
 
 // DefaultProxy is the global proxy singleton.
@@ -60,9 +66,11 @@ func InitProxy(httpAuth ProxyHTTPAuth) {
 		)
 	}
 	DefaultProxy = &Proxy{
-		HTTPAuth: httpAuth,
-		services: make(map[string]*Service),
-		syslog:   logrus.WithField("component", "proxy"),
+		HTTPAuth:    httpAuth,
+		services:    make(map[string]*Service),
+		syslog:      logrus.WithField("component", "proxy"),
+		accessLog:   logrus.WithField("component", "proxy-access"),
+		connections: make(map[uint64]*connection),
 	}
 	err := LoadOrGenCA()
 	if err != nil {
@@ -99,6 +107,7 @@ func (p *Proxy) Unregister(serviceID string) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	delete(p.services, serviceID)
+	p.deleteServiceMetrics(serviceID)
 }
 
 // ClearProxy erases all services from the proxy in case any handlers are still active.
@@ -157,18 +166,33 @@ func (p *Proxy) NewProxyHandler(serviceID string) echo.HandlerFunc {
 			req.Header.Set(echo.HeaderXForwardedFor, c.RealIP())
 		}
 
-		// Proxy the request to the target host.
-		var proxy http.Handler
+		kind := ConnectionKindHTTP
 		switch {
 		case service.ProxyTCP:
-			proxy = newSingleHostReverseTCPOverWebSocketProxy(c, service.URL)
+			kind = ConnectionKindTCP
 		case c.IsWebSocket():
-			proxy = newSingleHostReverseWebSocketProxy(c, service.URL)
+			kind = ConnectionKindWebSocket
+		}
+		conn := p.openConnection(c, serviceName, kind)
+		defer p.closeConnection(c, conn)
+
+		// Proxy the request to the target host.
+		var proxy http.Handler
+		switch kind {
+		case ConnectionKindTCP:
+			proxy = newSingleHostReverseTCPOverWebSocketProxy(c, service.URL, conn)
+		case ConnectionKindWebSocket:
+			proxy = newSingleHostReverseWebSocketProxy(c, service.URL, conn)
 		default:
 			newProxy, err := setUpProxy(service.URL)
 			if err != nil {
 				return err
 			}
+			newProxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+				conn.upstreamError()
+				p.syslog.WithError(err).Debugf("error proxying to %s", serviceName)
+				w.WriteHeader(http.StatusBadGateway)
+			}
 
 			proxy = newProxy
 		}
@@ -45,13 +45,16 @@ func (w *websocketReadWriter) Write(buf []byte) (int, error) {
 	return len(buf), nil
 }
 
-func newSingleHostReverseTCPOverWebSocketProxy(c echo.Context, t *url.URL) http.Handler {
+func newSingleHostReverseTCPOverWebSocketProxy(
+	c echo.Context, t *url.URL, conn *connection,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		dialer := proxy.FromEnvironment()
 
 		// Make sure we can open the connection to the remote host.
 		out, err := dialer.Dial("tcp", t.Host)
 		if err != nil {
+			conn.upstreamError()
 			c.Error(echo.NewHTTPError(http.StatusBadGateway,
 				errors.Errorf("error dialing to %v: %v", t, err)))
 			return
@@ -68,9 +71,10 @@ func newSingleHostReverseTCPOverWebSocketProxy(c echo.Context, t *url.URL) http.
 			return
 		}
 
+		conn.upgraded.Store(true)
 		rw := &websocketReadWriter{ws: ws, buf: new(bytes.Buffer)}
-		copyReqErr := asyncCopy(rw, out)
-		copyResErr := asyncCopy(out, rw)
+		copyReqErr := asyncCopy(&countingWriter{w: rw, count: conn.addSent}, out)
+		copyResErr := asyncCopy(&countingWriter{w: out, count: conn.addReceived}, rw)
 
 		if cerr := <-copyReqErr; cerr != nil {
 			c.Logger().Errorf("error copying request body for %v: %v", t, cerr)
@@ -25,7 +25,9 @@ func connectWithbackoff(tlsConn *tls.Conn) error {
 	return err
 }
 
-func newSingleHostReverseWebSocketProxy(c echo.Context, t *url.URL) http.Handler {
+func newSingleHostReverseWebSocketProxy(
+	c echo.Context, t *url.URL, conn *connection,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		in, _, err := c.Response().Hijack()
 		if err != nil {
@@ -40,6 +42,7 @@ func newSingleHostReverseWebSocketProxy(c echo.Context, t *url.URL) http.Handler
 
 		out, err := net.Dial("tcp", t.Host)
 		if err != nil {
+			conn.upstreamError()
 			c.Error(echo.NewHTTPError(http.StatusBadGateway,
 				errors.Errorf("error dialing to %v: %v", t, err)))
 			return
@@ -81,6 +84,7 @@ func newSingleHostReverseWebSocketProxy(c echo.Context, t *url.URL) http.Handler
 
 			err = connectWithbackoff(tlsConn)
 			if err != nil {
+				conn.upstreamError()
 				c.Error(echo.NewHTTPError(http.StatusBadGateway,
 					errors.Errorf("tls handshake error %v", err)))
 			}
@@ -88,13 +92,15 @@ func newSingleHostReverseWebSocketProxy(c echo.Context, t *url.URL) http.Handler
 
 		err = r.Write(out)
 		if err != nil {
+			conn.upstreamError()
 			c.Error(echo.NewHTTPError(http.StatusBadGateway,
 				errors.Errorf("error copying headers for %v: %v", t, err)))
 			return
 		}
 
-		copyReqErr := asyncCopy(out, in)
-		copyResErr := asyncCopy(in, out)
+		conn.upgraded.Store(true)
+		copyReqErr := asyncCopy(&countingWriter{w: out, count: conn.addReceived}, in)
+		copyResErr := asyncCopy(&countingWriter{w: in, count: conn.addSent}, out)
 		if cerr := <-copyReqErr; cerr != nil {
 			c.Logger().Errorf("error copying request body for %v: %v", t, cerr)
 		}
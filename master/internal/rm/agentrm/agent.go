@@ -633,6 +633,7 @@ func (a *agent) HandleIncomingWebsocketMessage(msg *aproto.MasterMessage) {
 				a.stop(resourcePoolErr)
 				return
 			}
+			a.agentState.setCapacityType(msg.AgentStarted.CapacityType)
 		} else {
 			a.agentStarted(msg.AgentStarted)
 		}
@@ -645,6 +646,8 @@ func (a *agent) HandleIncomingWebsocketMessage(msg *aproto.MasterMessage) {
 		}
 	case msg.ContainerStateChanged != nil:
 		a.containerStateChanged(*msg.ContainerStateChanged)
+	case msg.AgentTerminationNotice != nil:
+		a.terminationNoticed(*msg.AgentTerminationNotice)
 	case msg.ContainerLog != nil:
 		aID, ok := a.agentState.containerAllocation[msg.ContainerLog.ContainerID]
 		if !ok {
@@ -700,6 +703,37 @@ func (a *agent) agentStarted(agentStarted *aproto.AgentStarted) {
 	a.notifyListeners()
 }
 
+// terminationNoticed drains an agent whose instance the cloud provider is about to reclaim, and
+// preempts its allocations so that they can checkpoint before the instance goes away.
+func (a *agent) terminationNoticed(notice aproto.AgentTerminationNotice) {
+	if a.agentState == nil {
+		return
+	}
+	if a.agentState.terminationTime != nil {
+		// Agents keep reporting the notice until the instance is gone.
+		return
+	}
+	a.syslog.Warnf("agent instance will be reclaimed (action: %s) at %s, preempting %d allocations",
+		notice.Action, notice.Time, len(a.agentState.containerAllocation))
+
+	a.agentState.terminationTime = &notice.Time
+	a.agentState.disable(true)
+	a.agentState.patchAllSlotsState(patchAllSlotsState{
+		enabled: &a.agentState.enabled,
+		drain:   &a.agentState.draining,
+	})
+
+	reason := fmt.Sprintf("agent %s will be reclaimed by its cloud provider at %s",
+		a.id, notice.Time.Format(time.RFC3339))
+	for _, aID := range a.agentState.containerAllocation {
+		rmevents.Publish(aID, &sproto.ReleaseResources{
+			Reason:          reason,
+			ForcePreemption: true,
+		})
+	}
+	a.notifyListeners()
+}
+
 func (a *agent) containerStateChanged(sc aproto.ContainerStateChanged) {
 	aID, ok := a.agentState.containerAllocation[sc.Container.ID]
 	if !ok {
@@ -757,6 +791,8 @@ func (a *agent) summarize() model.AgentSummary {
 		result.Enabled = a.agentState.enabled
 		result.Draining = a.agentState.draining
 		result.NumContainers = len(a.agentState.containerAllocation)
+		result.CapacityType = a.agentState.capacityType
+		result.TerminationTime = a.agentState.terminationTime
 	}
 
 	return result
@@ -7,6 +7,7 @@ import (
 	"maps"
 	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -42,6 +43,19 @@ func New(
 	opts *aproto.MasterSetAgentOptions,
 	cert *tls.Certificate,
 ) (*ResourceManager, error) {
+	filePools := make(map[string]bool, len(config.ResourcePools))
+	for _, pool := range config.ResourcePools {
+		filePools[pool.PoolName] = true
+	}
+	if db != nil {
+		pools, err := withStoredResourcePools(context.TODO(), config.ResourcePools)
+		if err != nil {
+			return nil, err
+		}
+		config.ResourcePools = pools
+		setMasterConfigResourcePools(pools)
+	}
+
 	agentService, agentUpdates := newAgentService(config.ResourcePools, opts)
 
 	e.GET("/agents", func(c echo.Context) error {
@@ -51,21 +65,31 @@ func New(
 		return agentService.HandleWebsocketConnection(webSocketRequest{echoCtx: c})
 	})
 
-	return newAgentResourceManager(db, config, cert, agentService, agentUpdates)
+	a, err := newAgentResourceManager(db, config, cert, agentService, agentUpdates)
+	if err != nil {
+		return nil, err
+	}
+	a.filePools = filePools
+	return a, nil
 }
 
 // A ResourceManager manages many resource pools and routing requests for resources to them.
 type ResourceManager struct {
 	syslog *logrus.Entry
 
-	config      *config.AgentResourceManagerConfig
-	poolsConfig []config.ResourcePoolConfig
-	cert        *tls.Certificate
-	db          *db.PgDB
+	config *config.AgentResourceManagerConfig
+	cert   *tls.Certificate
+	db     *db.PgDB
 
 	agentService *agents
 	agentUpdates *queue.Queue[agentUpdatedEvent]
-	pools        map[string]*resourcePool // immutable. cannot be made mutable without significant change.
+
+	// mu guards the resource pools, which can be created, patched and deleted through the API.
+	mu          sync.Mutex
+	poolsConfig []config.ResourcePoolConfig
+	pools       map[string]*resourcePool
+	// filePools are the names of the resource pools defined in the master config file.
+	filePools map[string]bool
 }
 
 func newAgentResourceManager(
@@ -96,7 +120,9 @@ func newAgentResourceManager(
 	go func() {
 		for {
 			update := a.agentUpdates.Get()
+			a.mu.Lock()
 			pool, ok := a.pools[update.resourcePool]
+			a.mu.Unlock()
 			if !ok {
 				a.syslog.Warn("ignoring agent update for unknown pool: %w", update.resourcePool)
 				continue
@@ -247,7 +273,7 @@ func (a *ResourceManager) GetAgents() (*apiv1.GetAgentsResponse, error) {
 // GetAllocationSummaries implements rm.ResourceManager.
 func (a *ResourceManager) GetAllocationSummaries() (map[model.AllocationID]sproto.AllocationSummary, error) {
 	summaries := make(map[model.AllocationID]sproto.AllocationSummary)
-	for _, pool := range a.pools {
+	for _, pool := range a.resourcePools() {
 		rpSummaries := pool.GetAllocationSummaries()
 		maps.Copy(summaries, rpSummaries)
 	}
@@ -296,7 +322,7 @@ func (a *ResourceManager) GetJobQueueStatsRequest(
 		Results: make([]*apiv1.RPQueueStat, 0),
 	}
 
-	for name, pool := range a.pools {
+	for name, pool := range a.resourcePools() {
 		if len(msg.ResourcePools) != 0 && !slices.Contains(msg.ResourcePools, name) {
 			continue
 		}
@@ -313,6 +339,7 @@ func (a *ResourceManager) GetJobQueueStatsRequest(
 			ResourcePool: name,
 			Stats:        stats,
 			Aggregates:   aggregates,
+			FairShares:   pool.GetFairShares(),
 		})
 	}
 
@@ -321,8 +348,9 @@ func (a *ResourceManager) GetJobQueueStatsRequest(
 
 // GetResourcePools implements rm.ResourceManager.
 func (a *ResourceManager) GetResourcePools() (*apiv1.GetResourcePoolsResponse, error) {
-	summaries := make([]*resourcepoolv1.ResourcePool, 0, len(a.poolsConfig))
-	for _, pool := range a.poolsConfig {
+	poolsConfig := a.resourcePoolConfigs()
+	summaries := make([]*resourcepoolv1.ResourcePool, 0, len(poolsConfig))
+	for _, pool := range poolsConfig {
 		summary, err := a.createResourcePoolSummary(pool.PoolName)
 		if err != nil {
 			// Should only raise an error if the resource pool doesn't exist and that can't happen.
@@ -504,7 +532,7 @@ func (a *ResourceManager) TaskContainerDefaults(
 
 	// Iterate through configured pools looking for a TaskContainerDefaults setting.
 	var poolConfigOverrides *model.TaskContainerDefaultsConfig
-	for _, pool := range a.poolsConfig {
+	for _, pool := range a.resourcePoolConfigs() {
 		if resourcePoolName.String() == pool.PoolName {
 			if pool.TaskContainerDefaults != nil {
 				poolConfigOverrides = pool.TaskContainerDefaults
@@ -639,6 +667,8 @@ func (a *ResourceManager) poolByName(name string) (*resourcePool, error) {
 	if name == "" {
 		return nil, errors.New("invalid call: cannot get a resource pool with no name")
 	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	pool, ok := a.pools[name]
 	if !ok {
 		return nil, fmt.Errorf("cannot find resource pool %s", name)
@@ -657,6 +687,8 @@ func (a *ResourceManager) getPoolJobStats(poolConfig config.ResourcePoolConfig)
 func (a *ResourceManager) getResourcePoolConfig(poolName string) (
 	config.ResourcePoolConfig, error,
 ) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	for i := range a.poolsConfig {
 		if a.poolsConfig[i].PoolName == poolName {
 			return a.poolsConfig[i], nil
@@ -709,6 +741,13 @@ func (a *ResourceManager) createResourcePoolSummary(
 				accelerator = pool.Provider.GCP.Accelerator()
 			}
 		}
+		if pool.Provider.External != nil {
+			poolType = resourcepoolv1.ResourcePoolType_RESOURCE_POOL_TYPE_EXTERNAL
+			location = pool.Provider.External.URL
+			instanceType = pool.Provider.External.InstanceType.Name()
+			slotsPerAgent = pool.Provider.External.SlotsPerInstance()
+			slotType = pool.Provider.External.SlotType()
+		}
 	}
 
 	var schedulerType resourcepoolv1.SchedulerType
@@ -751,8 +790,10 @@ func (a *ResourceManager) createResourcePoolSummary(
 		ResourceManagerMetadata:      a.config.Metadata,
 	}
 	if pool.Provider != nil {
-		resp.MinAgents = int32(pool.Provider.MinInstances)
-		resp.MaxAgents = int32(pool.Provider.MaxInstances)
+		// Report the bounds of the scaling schedule that is active now, if any.
+		minInstances, maxInstances := pool.Provider.InstanceBounds(time.Now())
+		resp.MinAgents = int32(minInstances)
+		resp.MaxAgents = int32(maxInstances)
 		resp.MasterUrl = pool.Provider.MasterURL
 		resp.MasterCertName = pool.Provider.MasterCertName
 		resp.StartupScript = pool.Provider.StartupScript
@@ -863,11 +904,25 @@ func (a *ResourceManager) fetchAvgQueuedTime(pool string) (
 
 // mostly for tests.
 func (a *ResourceManager) stop() {
-	for _, pool := range a.pools {
+	for _, pool := range a.resourcePools() {
 		pool.stop()
 	}
 }
 
+// resourcePools returns a snapshot of the resource pools by name.
+func (a *ResourceManager) resourcePools() map[string]*resourcePool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return maps.Clone(a.pools)
+}
+
+// resourcePoolConfigs returns a snapshot of the resource pool configs.
+func (a *ResourceManager) resourcePoolConfigs() []config.ResourcePoolConfig {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.poolsConfig)
+}
+
 // SmallerValueIsHigherPriority returns true if smaller priority values indicate a higher priority level.
 func (a *ResourceManager) SmallerValueIsHigherPriority() (bool, error) {
 	return true, nil
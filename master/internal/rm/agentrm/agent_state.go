@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -51,6 +52,9 @@ type agentState struct {
 	enabled          bool
 	draining         bool
 	uuid             uuid.UUID
+	capacityType     model.CapacityType
+	// terminationTime is when the cloud provider will reclaim the agent, once it has noticed.
+	terminationTime *time.Time
 
 	maxZeroSlotContainers int
 
@@ -208,6 +212,8 @@ func (a *agentState) deepCopy() *agentState {
 		// TODO(ilia): Deepcopy of `slotStates` may be necessary one day.
 		slotStates:       a.slotStates,
 		resourcePoolName: a.resourcePoolName,
+		capacityType:     a.capacityType,
+		terminationTime:  a.terminationTime,
 	}
 
 	return copiedAgent
@@ -244,6 +250,7 @@ func (a *agentState) removeDevice(device device.Device) {
 // agentStarted initializes slots from AgentStarted.Devices.
 func (a *agentState) agentStarted(agentStarted *aproto.AgentStarted) {
 	msg := agentStarted
+	a.setCapacityType(msg.CapacityType)
 	for _, d := range msg.Devices {
 		enabled := slotEnabled{
 			agentEnabled: true,
@@ -258,6 +265,15 @@ func (a *agentState) agentStarted(agentStarted *aproto.AgentStarted) {
 	}
 }
 
+// setCapacityType records the capacity the agent runs on. Agents that do not report it predate
+// capacity types and run on-demand.
+func (a *agentState) setCapacityType(capacityType model.CapacityType) {
+	if capacityType == "" {
+		capacityType = model.CapacityTypeOnDemand
+	}
+	a.capacityType = capacityType
+}
+
 func (a *agentState) checkAgentStartedDevicesMatch(
 	agentStarted *aproto.AgentStarted,
 ) error {
@@ -116,6 +116,13 @@ func (a *agents) list(resourcePoolName string) map[aproto.ID]*agentState {
 	return result
 }
 
+// setPoolConfigs replaces the resource pool configs of agents that connect from now on.
+func (a *agents) setPoolConfigs(poolConfigs []config.ResourcePoolConfig) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.poolConfigs = poolConfigs
+}
+
 func (a *agents) get(id aproto.ID) (*agent, bool) {
 	return a.agents.Load(id)
 }
@@ -26,6 +26,9 @@ type groupState struct {
 	*tasklist.Group
 
 	disabled bool
+	// weight is the fair share weight of the group: the weight of its job, or its share of the
+	// pool under hierarchical fair share.
+	weight float64
 	// slotDemand is the number of slots that the group needs to run all tasks associated with
 	// this group.
 	slotDemand int
@@ -50,13 +53,24 @@ func (g *groupState) String() string {
 }
 
 func (f *fairShare) Schedule(rp *resourcePool) ([]*sproto.AllocateRequest, []model.AllocationID) {
-	return fairshareSchedule(
+	var hierarchy *fairShareHierarchy
+	if conf := rp.config.Scheduler.FairShare; conf != nil && conf.Hierarchical {
+		hierarchy = &fairShareHierarchy{config: conf, usage: rp.fairShareUsage}
+	}
+	toAllocate, toRelease := fairshareSchedule(
 		rp.taskList,
 		rp.groups,
 		rp.agentStatesCache,
 		rp.fittingMethod,
 		rp.config.Scheduler.AllowHeterogeneousFits,
+		hierarchy,
 	)
+	if hierarchy != nil {
+		rp.fairShares = hierarchy.workspaces
+	} else {
+		rp.fairShares = nil
+	}
+	return toAllocate, toRelease
 }
 
 func (f *fairShare) createJobQInfo(
@@ -85,6 +99,7 @@ func fairshareSchedule(
 	agents map[aproto.ID]*agentState,
 	fittingMethod SoftConstraint,
 	allowHeterogeneousAgentFits bool,
+	hierarchy *fairShareHierarchy,
 ) ([]*sproto.AllocateRequest, []model.AllocationID) {
 	allToAllocate := make([]*sproto.AllocateRequest, 0)
 	allToRelease := make([]model.AllocationID, 0)
@@ -108,7 +123,8 @@ func fairshareSchedule(
 	// Fair share allocations are calculated in four parts:
 	// 1) Organize tasks into groups.
 	// 2) Calculate the slot demand of each group.
-	// 3) Allocate slot offers to each group.
+	// 3) Allocate slot offers to each group. Under hierarchical fair share, the pool is split
+	//    across workspaces and users first, and the resulting shares weigh the groups.
 	// 4) Get scheduler decisions for each group based on its slot demand.
 
 	// TODO (sidneyw): temporarily we partition the cluster by agent label as a
@@ -121,6 +137,9 @@ func fairshareSchedule(
 		taskList, groups, capacity, agents, fittingMethod, allowHeterogeneousAgentFits,
 	)
 
+	if hierarchy != nil {
+		hierarchy.split(groupStates, capacity)
+	}
 	allocateSlotOffers(groupStates, capacity)
 	toAllocate, toRelease := assignTasks(
 		agents,
@@ -192,6 +211,7 @@ func calculateGroupStates(
 	}
 	for _, state := range states {
 		check.Panic(check.True(state.Group != nil, "the group of a task must not be nil"))
+		state.weight = state.Weight
 		for _, req := range state.reqs {
 			state.slotDemand += req.SlotsNeeded
 			switch {
@@ -223,7 +243,7 @@ func getTotalWeight(states []*groupState) float64 {
 	total := 0.0
 	for _, state := range states {
 		if !state.disabled && state.offered < state.slotDemand {
-			total += state.Weight
+			total += state.weight
 		}
 	}
 	return total
@@ -303,7 +323,7 @@ func allocateSlotOffers(states []*groupState, capacity int) {
 			}
 			calculatedFairShare := mathx.Max(
 				1,
-				int(float64(startCapacity)*state.Weight/totalWeight),
+				int(float64(startCapacity)*state.weight/totalWeight),
 			)
 
 			progressMade = true
@@ -0,0 +1,254 @@
+package agentrm
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/rm/tasklist"
+	"github.com/determined-ai/determined/proto/pkg/jobv1"
+)
+
+const (
+	// fairShareUsageInterval is how often the usage of a pool is accounted for, and so how often
+	// its shares are reconsidered as past usage decays.
+	fairShareUsageInterval = 30 * time.Second
+	// minFairShareUsage is the usage, in slot-seconds, below which past usage is forgotten.
+	minFairShareUsage = 1e-3
+	// minFairShareWeight keeps groups that get no share of the pool from zeroing weights.
+	minFairShareWeight = 1e-6
+)
+
+type fairShareUser struct {
+	workspace string
+	username  string
+}
+
+// fairShareUsage is the past usage of a resource pool by workspaces and by the users of each
+// workspace, in slot-seconds decayed by half every half-life.
+type fairShareUsage struct {
+	updated    time.Time
+	workspaces map[string]float64
+	users      map[fairShareUser]float64
+}
+
+func newFairShareUsage() *fairShareUsage {
+	return &fairShareUsage{
+		workspaces: make(map[string]float64),
+		users:      make(map[fairShareUser]float64),
+	}
+}
+
+// update decays the usage over the time since the last update, and adds the slot-seconds of the
+// allocations that ran meanwhile.
+func (u *fairShareUsage) update(taskList *tasklist.TaskList, halfLife time.Duration, now time.Time) {
+	elapsed := now.Sub(u.updated)
+	first := u.updated.IsZero()
+	u.updated = now
+	if first || elapsed <= 0 {
+		return
+	}
+
+	decay := math.Exp2(-elapsed.Seconds() / halfLife.Seconds())
+	for workspace, usage := range u.workspaces {
+		if usage *= decay; usage < minFairShareUsage {
+			delete(u.workspaces, workspace)
+		} else {
+			u.workspaces[workspace] = usage
+		}
+	}
+	for user, usage := range u.users {
+		if usage *= decay; usage < minFairShareUsage {
+			delete(u.users, user)
+		} else {
+			u.users[user] = usage
+		}
+	}
+
+	for it := taskList.Iterator(); it.Next(); {
+		req := it.Value()
+		if req.SlotsNeeded == 0 || taskList.Allocation(req.AllocationID) == nil {
+			continue
+		}
+		used := float64(req.SlotsNeeded) * elapsed.Seconds()
+		u.workspaces[req.Workspace] += used
+		u.users[fairShareUser{workspace: req.Workspace, username: req.Username}] += used
+	}
+}
+
+// fairShareNode is a workspace, or a user within a workspace, sharing a resource pool.
+type fairShareNode struct {
+	name   string
+	weight float64
+	// usage is the decayed past usage in slot-seconds.
+	usage float64
+	// effectiveWeight is the weight lowered by the usage relative to the siblings of the node.
+	effectiveWeight float64
+	slotDemand      int
+	activeSlots     int
+	// share is the number of slots the node is entitled to.
+	share float64
+
+	users  []*fairShareNode
+	groups []*groupState
+}
+
+func (n *fairShareNode) proto() *jobv1.FairShare {
+	pb := &jobv1.FairShare{
+		Name:            n.name,
+		Weight:          n.weight,
+		Usage:           n.usage / time.Hour.Seconds(),
+		EffectiveWeight: n.effectiveWeight,
+		SlotDemand:      int32(n.slotDemand),
+		ActiveSlots:     int32(n.activeSlots),
+		Share:           n.share,
+		Users:           make([]*jobv1.FairShare, 0, len(n.users)),
+	}
+	for _, user := range n.users {
+		pb.Users = append(pb.Users, user.proto())
+	}
+	return pb
+}
+
+// fairShareHierarchy splits a resource pool across workspaces, then across the users of each
+// workspace, and then across the jobs of each user.
+type fairShareHierarchy struct {
+	config *config.FairShareSchedulerConfig
+	usage  *fairShareUsage
+
+	// workspaces is the hierarchy from the last split.
+	workspaces []*fairShareNode
+}
+
+// split computes the share of the pool of each workspace and user, and sets the weight of each
+// group to the share of its job so that the fair share of the groups follows the hierarchy.
+func (h *fairShareHierarchy) split(states []*groupState, capacity int) {
+	workspaces := make(map[string]*fairShareNode)
+	users := make(map[fairShareUser]*fairShareNode)
+	h.workspaces = nil
+	for _, state := range states {
+		// All the tasks of a group belong to the same job, and so the same workspace and user.
+		req := state.reqs[0]
+		workspace, ok := workspaces[req.Workspace]
+		if !ok {
+			workspace = &fairShareNode{
+				name:   req.Workspace,
+				weight: h.config.WorkspaceWeight(req.Workspace),
+			}
+			if h.usage != nil {
+				workspace.usage = h.usage.workspaces[req.Workspace]
+			}
+			workspaces[req.Workspace] = workspace
+			h.workspaces = append(h.workspaces, workspace)
+		}
+		key := fairShareUser{workspace: req.Workspace, username: req.Username}
+		user, ok := users[key]
+		if !ok {
+			user = &fairShareNode{
+				name:   req.Username,
+				weight: h.config.UserWeight(req.Username),
+			}
+			if h.usage != nil {
+				user.usage = h.usage.users[key]
+			}
+			users[key] = user
+			workspace.users = append(workspace.users, user)
+		}
+		user.groups = append(user.groups, state)
+		user.slotDemand += state.slotDemand
+		user.activeSlots += state.activeSlots
+		workspace.slotDemand += state.slotDemand
+		workspace.activeSlots += state.activeSlots
+	}
+
+	splitFairShare(float64(capacity), h.workspaces)
+	for _, workspace := range h.workspaces {
+		splitFairShare(workspace.share, workspace.users)
+		for _, user := range workspace.users {
+			weights := make([]float64, 0, len(user.groups))
+			demands := make([]int, 0, len(user.groups))
+			for _, state := range user.groups {
+				weights = append(weights, state.Weight)
+				demands = append(demands, state.slotDemand)
+			}
+			for i, share := range maxMinShares(user.share, weights, demands) {
+				user.groups[i].weight = math.Max(share, minFairShareWeight)
+			}
+		}
+	}
+
+	sort.Slice(h.workspaces, func(i, j int) bool {
+		return h.workspaces[i].name < h.workspaces[j].name
+	})
+	for _, workspace := range h.workspaces {
+		sort.Slice(workspace.users, func(i, j int) bool {
+			return workspace.users[i].name < workspace.users[j].name
+		})
+	}
+}
+
+// splitFairShare splits capacity across sibling nodes by their weights, lowered by their past
+// usage. A node whose share of the usage of its siblings is as large as its share of their
+// weights has its weight halved, so that heavy users yield to light ones.
+func splitFairShare(capacity float64, nodes []*fairShareNode) {
+	totalWeight, totalUsage := 0.0, 0.0
+	for _, n := range nodes {
+		totalWeight += n.weight
+		totalUsage += n.usage
+	}
+
+	weights := make([]float64, 0, len(nodes))
+	demands := make([]int, 0, len(nodes))
+	for _, n := range nodes {
+		n.effectiveWeight = n.weight
+		if totalUsage > 0 {
+			n.effectiveWeight *= math.Exp2(-(n.usage / totalUsage) / (n.weight / totalWeight))
+		}
+		weights = append(weights, n.effectiveWeight)
+		demands = append(demands, n.slotDemand)
+	}
+	for i, share := range maxMinShares(capacity, weights, demands) {
+		nodes[i].share = share
+	}
+}
+
+// maxMinShares splits capacity by weight with progressive filling: shares grow in proportion to
+// the weights, and what a share cannot use beyond its demand goes to the others.
+func maxMinShares(capacity float64, weights []float64, demands []int) []float64 {
+	shares := make([]float64, len(weights))
+	active := make([]int, 0, len(weights))
+	for i, demand := range demands {
+		if demand > 0 && weights[i] > 0 {
+			active = append(active, i)
+		}
+	}
+
+	for len(active) > 0 && capacity > 0 {
+		totalWeight := 0.0
+		for _, i := range active {
+			totalWeight += weights[i]
+		}
+
+		// Satisfy every demand below its proportional share, and then split what is left again.
+		remaining := capacity
+		var unsatisfied []int
+		for _, i := range active {
+			if rest := float64(demands[i]) - shares[i]; rest <= capacity*weights[i]/totalWeight {
+				shares[i] = float64(demands[i])
+				remaining -= rest
+			} else {
+				unsatisfied = append(unsatisfied, i)
+			}
+		}
+		if len(unsatisfied) == len(active) {
+			for _, i := range active {
+				shares[i] += capacity * weights[i] / totalWeight
+			}
+			break
+		}
+		capacity = remaining
+		active = unsatisfied
+	}
+	return shares
+}
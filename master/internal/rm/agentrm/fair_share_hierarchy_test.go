@@ -0,0 +1,168 @@
+package agentrm
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/determined-ai/determined/master/internal/config"
+)
+
+func TestFairShareHierarchical(t *testing.T) {
+	agents := []*MockAgent{
+		{ID: "agent", Slots: 4},
+	}
+	groups := []*MockGroup{
+		{ID: "group1", Weight: 1},
+		{ID: "group2", Weight: 1},
+		{ID: "group3", Weight: 1},
+	}
+	tasks := []*MockTask{
+		{ID: "task1", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task2", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task3", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task4", SlotsNeeded: 1, Group: groups[1], Workspace: "b", Username: "bob"},
+		{ID: "task5", SlotsNeeded: 1, Group: groups[1], Workspace: "b", Username: "bob"},
+		{ID: "task6", SlotsNeeded: 1, Group: groups[2], Workspace: "b", Username: "carol"},
+		{ID: "task7", SlotsNeeded: 1, Group: groups[2], Workspace: "b", Username: "carol"},
+	}
+
+	// Flat fair share would split the pool across the three jobs; the hierarchy splits it across
+	// the two workspaces first.
+	expectedToAllocate := []*MockTask{tasks[0], tasks[1], tasks[3], tasks[5]}
+	expectedToRelease := []*MockTask{}
+
+	hierarchy := &fairShareHierarchy{config: &config.FairShareSchedulerConfig{Hierarchical: true}}
+	taskList, groupMap, agentMap := setupSchedulerStates(t, tasks, groups, agents)
+	toAllocate, toRelease := fairshareSchedule(
+		taskList, groupMap, agentMap, BestFit, false, hierarchy)
+	assertEqualToAllocate(t, toAllocate, expectedToAllocate)
+	assertEqualToRelease(t, taskList, toRelease, expectedToRelease)
+
+	assert.Equal(t, len(hierarchy.workspaces), 2)
+	assert.Equal(t, hierarchy.workspaces[0].name, "a")
+	assert.Equal(t, hierarchy.workspaces[0].share, 2.0)
+	assert.Equal(t, len(hierarchy.workspaces[1].users), 2)
+	assert.Equal(t, hierarchy.workspaces[1].users[0].name, "bob")
+	assert.Equal(t, hierarchy.workspaces[1].users[0].share, 1.0)
+}
+
+func TestFairShareHierarchicalWeights(t *testing.T) {
+	agents := []*MockAgent{
+		{ID: "agent", Slots: 4},
+	}
+	groups := []*MockGroup{
+		{ID: "group1", Weight: 1},
+		{ID: "group2", Weight: 1},
+	}
+	tasks := []*MockTask{
+		{ID: "task1", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task2", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task3", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task4", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task5", SlotsNeeded: 1, Group: groups[1], Workspace: "b", Username: "bob"},
+		{ID: "task6", SlotsNeeded: 1, Group: groups[1], Workspace: "b", Username: "bob"},
+	}
+
+	expectedToAllocate := []*MockTask{tasks[0], tasks[1], tasks[2], tasks[4]}
+	expectedToRelease := []*MockTask{}
+
+	hierarchy := &fairShareHierarchy{config: &config.FairShareSchedulerConfig{
+		Hierarchical:     true,
+		WorkspaceWeights: map[string]float64{"a": 3},
+	}}
+	taskList, groupMap, agentMap := setupSchedulerStates(t, tasks, groups, agents)
+	toAllocate, toRelease := fairshareSchedule(
+		taskList, groupMap, agentMap, BestFit, false, hierarchy)
+	assertEqualToAllocate(t, toAllocate, expectedToAllocate)
+	assertEqualToRelease(t, taskList, toRelease, expectedToRelease)
+}
+
+func TestFairShareHierarchicalUsage(t *testing.T) {
+	agents := []*MockAgent{
+		{ID: "agent", Slots: 4},
+	}
+	groups := []*MockGroup{
+		{ID: "group1", Weight: 1},
+		{ID: "group2", Weight: 1},
+	}
+	tasks := []*MockTask{
+		{ID: "task1", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task2", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task3", SlotsNeeded: 1, Group: groups[0], Workspace: "a", Username: "alice"},
+		{ID: "task4", SlotsNeeded: 1, Group: groups[1], Workspace: "a", Username: "bob"},
+		{ID: "task5", SlotsNeeded: 1, Group: groups[1], Workspace: "a", Username: "bob"},
+		{ID: "task6", SlotsNeeded: 1, Group: groups[1], Workspace: "a", Username: "bob"},
+	}
+
+	// Alice has used the pool much more than Bob recently, so Bob gets the larger share.
+	usage := newFairShareUsage()
+	usage.workspaces["a"] = 100
+	usage.users[fairShareUser{workspace: "a", username: "alice"}] = 90
+	usage.users[fairShareUser{workspace: "a", username: "bob"}] = 10
+
+	expectedToAllocate := []*MockTask{tasks[0], tasks[3], tasks[4], tasks[5]}
+	expectedToRelease := []*MockTask{}
+
+	hierarchy := &fairShareHierarchy{
+		config: &config.FairShareSchedulerConfig{Hierarchical: true},
+		usage:  usage,
+	}
+	taskList, groupMap, agentMap := setupSchedulerStates(t, tasks, groups, agents)
+	toAllocate, toRelease := fairshareSchedule(
+		taskList, groupMap, agentMap, BestFit, false, hierarchy)
+	assertEqualToAllocate(t, toAllocate, expectedToAllocate)
+	assertEqualToRelease(t, taskList, toRelease, expectedToRelease)
+}
+
+func TestFairShareUsageDecay(t *testing.T) {
+	agents := []*MockAgent{
+		{ID: "agent", Slots: 4},
+	}
+	tasks := []*MockTask{
+		{
+			ID: "task1", SlotsNeeded: 2, Workspace: "a", Username: "alice",
+			AllocatedAgent: agents[0], ContainerStarted: true,
+		},
+		{ID: "task2", SlotsNeeded: 2, Workspace: "a", Username: "bob"},
+	}
+	taskList, _, _ := setupSchedulerStates(t, tasks, nil, agents)
+
+	usage := newFairShareUsage()
+	start := time.Now()
+	usage.update(taskList, time.Hour, start)
+	assert.Equal(t, len(usage.users), 0)
+
+	// Only allocated tasks count, for the slot-seconds they held.
+	usage.update(taskList, time.Hour, start.Add(time.Hour))
+	alice := fairShareUser{workspace: "a", username: "alice"}
+	assert.Equal(t, usage.users[alice], 2*time.Hour.Seconds())
+	assert.Equal(t, usage.workspaces["a"], 2*time.Hour.Seconds())
+	_, ok := usage.users[fairShareUser{workspace: "a", username: "bob"}]
+	assert.Assert(t, !ok)
+
+	// Past usage halves every half-life.
+	usage.update(taskList, time.Hour, start.Add(2*time.Hour))
+	assert.Equal(t, usage.users[alice], 3*time.Hour.Seconds())
+}
+
+func TestMaxMinShares(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		capacity float64
+		weights  []float64
+		demands  []int
+		expected []float64
+	}{
+		{"equal", 8, []float64{1, 1}, []int{8, 8}, []float64{4, 4}},
+		{"weighted", 8, []float64{3, 1}, []int{8, 8}, []float64{6, 2}},
+		{"small demand", 10, []float64{1, 1, 2}, []int{1, 10, 10}, []float64{1, 3, 6}},
+		{"undersubscribed", 10, []float64{1, 1}, []int{2, 3}, []float64{2, 3}},
+		{"no demand", 4, []float64{1, 1}, []int{0, 8}, []float64{0, 4}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.DeepEqual(t, maxMinShares(tc.capacity, tc.weights, tc.demands), tc.expected)
+		})
+	}
+}
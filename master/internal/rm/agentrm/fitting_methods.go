@@ -17,6 +17,10 @@ func agentPermittedSatisfied(req *sproto.AllocateRequest, agent *agentState) boo
 	return !slices.Contains(req.BlockedNodes, string(agent.id))
 }
 
+func capacityTypeSatisfied(req *sproto.AllocateRequest, agent *agentState) bool {
+	return req.CapacityType == "" || req.CapacityType == agent.capacityType
+}
+
 func slotsSatisfied(req *sproto.AllocateRequest, agent *agentState) bool {
 	return req.SlotsNeeded <= agent.numEmptySlots()
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 func TestIsViable(t *testing.T) {
@@ -503,6 +504,43 @@ func TestFindFitDisallowedNodes(t *testing.T) {
 	assert.Equal(t, fits[0].Agent, agents[0])
 }
 
+func TestFindFitCapacityType(t *testing.T) {
+	agents := []*agentState{
+		newFakeAgentState(t, "agent1", 4, 0, 100, 0),
+		newFakeAgentState(t, "agent2", 4, 0, 100, 0),
+	}
+	agents[0].capacityType = model.CapacityTypeOnDemand
+	agents[1].capacityType = model.CapacityTypeSpot
+	agentsByHandler, _ := byID(agents...)
+
+	task := &sproto.AllocateRequest{
+		AllocationID: "a",
+		SlotsNeeded:  1,
+		TaskID:       "anywhere",
+	}
+	fits := findFits(task, agentsByHandler, BestFit, false)
+	assert.Assert(t, len(fits) == 1)
+
+	task = &sproto.AllocateRequest{
+		CapacityType: model.CapacityTypeSpot,
+		AllocationID: "a",
+		SlotsNeeded:  1,
+		TaskID:       "onSpot",
+	}
+	fits = findFits(task, agentsByHandler, BestFit, false)
+	assert.Assert(t, len(fits) == 1)
+	assert.Equal(t, fits[0].Agent, agents[1])
+
+	task = &sproto.AllocateRequest{
+		CapacityType: model.CapacityTypeOnDemand,
+		AllocationID: "a",
+		SlotsNeeded:  8,
+		TaskID:       "onDemandOnly",
+	}
+	fits = findFits(task, agentsByHandler, BestFit, false)
+	assert.Assert(t, len(fits) == 0)
+}
+
 func byID(
 	handlers ...*agentState,
 ) (map[aproto.ID]*agentState, []*agentState) {
@@ -32,6 +32,8 @@ type MockTask struct {
 	// Any test that set this to false is half wrong. It is used as a proxy to oversubscribe agents.
 	ContainerStarted  bool
 	JobSubmissionTime time.Time
+	Workspace         string
+	Username          string
 
 	BlockedNodes []string
 }
@@ -64,6 +66,8 @@ func MockTaskToAllocateRequest(mockTask *MockTask) *sproto.AllocateRequest {
 			Preemptible: !mockTask.NonPreemptible,
 		},
 		JobSubmissionTime: jobSubmissionTime,
+		Workspace:         mockTask.Workspace,
+		Username:          mockTask.Username,
 		BlockedNodes:      mockTask.BlockedNodes,
 	}
 	return req
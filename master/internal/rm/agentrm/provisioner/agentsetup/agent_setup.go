@@ -28,6 +28,8 @@ type AgentSetupScriptConfig struct {
 	AgentDockerImage             string
 	AgentID                      string
 	ResourcePool                 string
+	CapacityType                 model.CapacityType
+	TerminationNoticeURL         string
 	LogOptions                   string
 	AgentReconnectAttempts       int
 	AgentReconnectBackoff        int
@@ -8,6 +8,7 @@ import (
 
 	"github.com/determined-ai/determined/master/pkg/device"
 	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
 )
 
 func TestAgentSetupScript(t *testing.T) {
@@ -30,6 +31,8 @@ func TestAgentSetupScript(t *testing.T) {
 		AgentNetwork:                 "default",
 		AgentID:                      "test.id",
 		ResourcePool:                 "test-pool",
+		CapacityType:                 model.CapacityTypeSpot,
+		TerminationNoticeURL:         "http://169.254.169.254/latest/meta-data/spot/instance-action",
 		AgentReconnectAttempts:       5,
 		AgentReconnectBackoff:        5,
 	}
@@ -98,6 +101,8 @@ docker run --init --name determined-agent  \
     -e DET_MASTER_PORT="8080" \
     -e DET_SECURITY_TLS_MASTER_CERT_NAME="certname" \
     -e DET_RESOURCE_POOL="test-pool" \
+    -e DET_CAPACITY_TYPE="spot" \
+    -e DET_TERMINATION_NOTICE_URL="http://169.254.169.254/latest/meta-data/spot/instance-action" \
     -e DET_AGENT_RECONNECT_ATTEMPTS="5" \
     -e DET_AGENT_RECONNECT_BACKOFF="5" \
     -v /usr/sbin/shutdown:/usr/sbin/shutdown \
@@ -39,6 +39,10 @@ type awsCluster struct {
 //nolint:lll  // See https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/instancedata-data-retrieval.html
 const ec2InstanceID = `$(curl -q -H "X-aws-ec2-metadata-token: $(curl -q -X PUT "http://169.254.169.254/latest/api/token" -H "X-aws-ec2-metadata-token-ttl-seconds: 21600")"  http://169.254.169.254/latest/meta-data/instance-id)`
 
+// spotInstanceActionURL is where spot instances find out that they are about to be reclaimed.
+// See https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/spot-instance-termination-notices.html
+const spotInstanceActionURL = "http://169.254.169.254/latest/meta-data/spot/instance-action"
+
 // New creates a new AWS cluster.
 func New(
 	resourcePool string, config *provconfig.Config, cert *tls.Certificate,
@@ -98,6 +102,11 @@ func New(
 	masterCertBase64 := base64.StdEncoding.EncodeToString(certBytes)
 	configFileBase64 := base64.StdEncoding.EncodeToString(config.AgentConfigFileContents)
 
+	capacityType, terminationNoticeURL := model.CapacityTypeOnDemand, ""
+	if config.AWS.SpotEnabled {
+		capacityType, terminationNoticeURL = model.CapacityTypeSpot, spotInstanceActionURL
+	}
+
 	cluster := &awsCluster{
 		resourcePool: resourcePool,
 		config:       config.AWS,
@@ -119,6 +128,8 @@ func New(
 			AgentReconnectBackoff:        config.AgentReconnectBackoff,
 			AgentID:                      ec2InstanceID,
 			ResourcePool:                 resourcePool,
+			CapacityType:                 capacityType,
+			TerminationNoticeURL:         terminationNoticeURL,
 			LogOptions:                   config.AWS.BuildDockerLogString(),
 		}),
 		syslog: logrus.WithField("aws-cluster", resourcePool),
@@ -0,0 +1,297 @@
+// Package external provisions agent instances through an external provider service, so agents can
+// be autoscaled on infrastructure the master has no built-in support for.
+//
+// The provider service implements three endpoints under its base URL, each scoped to the resource
+// pool in the request:
+//
+//	GET  /v1/instances?resource_pool=<pool>
+//	  Responds with {"instances": [{"id", "agent_name", "launch_time", "state"}]}, listing the
+//	  instances of the pool that have not been terminated. The state is one of starting, running,
+//	  stopping, stopped or terminating, and the launch time is RFC 3339.
+//	POST /v1/instances
+//	  Launches instances for {"request_id", "resource_pool", "instance_type", "count",
+//	  "agent_setup_script"}. The agent setup script is base64 encoded, and must be run as root
+//	  on each instance, with DET_AGENT_ID set to the agent_name of the instance if it is not the
+//	  hostname. Retries of a request reuse its request_id, so that it can launch instances once.
+//	POST /v1/instances/terminate
+//	  Terminates the instances in {"resource_pool", "instance_ids"}. Unknown instances are ignored.
+//
+// Any 2xx status is a success. Requests that fail with a network error, a 429 or a 5xx status are
+// retried with exponential backoff. If a bearer token is configured, it is sent in the
+// Authorization header of every request.
+package external
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/config/provconfig"
+	"github.com/determined-ai/determined/master/internal/rm/agentrm/provisioner/agentsetup"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// agentID is the agent ID of agents on external instances, which the provider service may set.
+const agentID = `${DET_AGENT_ID:-$(hostname)}`
+
+// initialRetryWait is how long to wait before the first retry of a failed request.
+const initialRetryWait = time.Second
+
+var instanceStates = map[string]model.InstanceState{
+	"starting":    model.Starting,
+	"running":     model.Running,
+	"stopping":    model.Stopping,
+	"stopped":     model.Stopped,
+	"terminating": model.Terminating,
+}
+
+// externalCluster delegates the instances of a resource pool to a provider service.
+type externalCluster struct {
+	config       *provconfig.ExternalClusterConfig
+	resourcePool string
+	base         *url.URL
+	client       *http.Client
+	retryWait    time.Duration
+	setupScript  string
+
+	syslog *logrus.Entry
+}
+
+type instance struct {
+	ID         string    `json:"id"`
+	AgentName  string    `json:"agent_name"`
+	LaunchTime time.Time `json:"launch_time"`
+	State      string    `json:"state"`
+}
+
+type listResponse struct {
+	Instances []instance `json:"instances"`
+}
+
+type launchRequest struct {
+	RequestID        string `json:"request_id"`
+	ResourcePool     string `json:"resource_pool"`
+	InstanceType     string `json:"instance_type"`
+	Count            int    `json:"count"`
+	AgentSetupScript string `json:"agent_setup_script"`
+}
+
+type terminateRequest struct {
+	ResourcePool string   `json:"resource_pool"`
+	InstanceIDs  []string `json:"instance_ids"`
+}
+
+// New creates a new external cluster.
+func New(
+	resourcePool string, config *provconfig.Config, cert *tls.Certificate,
+) (agentsetup.Provider, error) {
+	base, err := url.Parse(config.External.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse external provider url")
+	}
+	masterURL, err := url.Parse(config.MasterURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse master url")
+	}
+
+	tlsConf := config.External.TLS
+	if err := tlsConf.Resolve(); err != nil {
+		return nil, errors.Wrap(err, "failed to read external provider certificate")
+	}
+	transport := cleanhttp.DefaultPooledTransport()
+	if transport.TLSClientConfig, err = tlsConf.TLSConfig(); err != nil {
+		return nil, err
+	}
+
+	var certBytes []byte
+	if masterURL.Scheme == agentsetup.SecureScheme && cert != nil {
+		for _, c := range cert.Certificate {
+			certBytes = append(certBytes, pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: c,
+			})...)
+		}
+	}
+	script := agentsetup.MustMakeAgentSetupScript(agentsetup.AgentSetupScriptConfig{
+		MasterHost:                   masterURL.Hostname(),
+		MasterPort:                   masterURL.Port(),
+		MasterCertName:               config.MasterCertName,
+		StartupScriptBase64:          base64.StdEncoding.EncodeToString([]byte(config.StartupScript)),
+		ContainerStartupScriptBase64: base64.StdEncoding.EncodeToString([]byte(config.ContainerStartupScript)),
+		MasterCertBase64:             base64.StdEncoding.EncodeToString(certBytes),
+		ConfigFileBase64:             base64.StdEncoding.EncodeToString(config.AgentConfigFileContents),
+		SlotType:                     config.External.SlotType(),
+		AgentDockerRuntime:           config.AgentDockerRuntime,
+		AgentNetwork:                 config.AgentDockerNetwork,
+		AgentDockerImage:             config.AgentDockerImage,
+		AgentReconnectAttempts:       config.AgentReconnectAttempts,
+		AgentReconnectBackoff:        config.AgentReconnectBackoff,
+		AgentID:                      agentID,
+		ResourcePool:                 resourcePool,
+	})
+
+	return &externalCluster{
+		config:       config.External,
+		resourcePool: resourcePool,
+		base:         base,
+		client:       &http.Client{Transport: transport, Timeout: time.Duration(config.External.Timeout)},
+		retryWait:    initialRetryWait,
+		setupScript:  base64.StdEncoding.EncodeToString(script),
+		syslog:       logrus.WithField("external-cluster", resourcePool),
+	}, nil
+}
+
+func (c *externalCluster) InstanceType() model.InstanceType {
+	return c.config.InstanceType
+}
+
+func (c *externalCluster) SlotsPerInstance() int {
+	return c.config.SlotsPerInstance()
+}
+
+func (c *externalCluster) List() ([]*model.Instance, error) {
+	var resp listResponse
+	query := url.Values{"resource_pool": {c.resourcePool}}
+	if err := c.do(http.MethodGet, "/v1/instances", query, nil, &resp); err != nil {
+		return nil, errors.Wrap(err, "cannot list external instances")
+	}
+
+	instances := make([]*model.Instance, 0, len(resp.Instances))
+	for _, inst := range resp.Instances {
+		state, ok := instanceStates[strings.ToLower(inst.State)]
+		if !ok {
+			c.syslog.Errorf("unknown instance state %q for instance %v", inst.State, inst.ID)
+			state = model.Unknown
+		}
+		agentName := inst.AgentName
+		if agentName == "" {
+			agentName = inst.ID
+		}
+		instances = append(instances, &model.Instance{
+			ID:         inst.ID,
+			LaunchTime: inst.LaunchTime,
+			AgentName:  agentName,
+			State:      state,
+		})
+	}
+	return instances, nil
+}
+
+func (c *externalCluster) Launch(instanceNum int) error {
+	if instanceNum <= 0 {
+		return nil
+	}
+
+	req := launchRequest{
+		RequestID:        uuid.New().String(),
+		ResourcePool:     c.resourcePool,
+		InstanceType:     c.config.InstanceType.Name(),
+		Count:            instanceNum,
+		AgentSetupScript: c.setupScript,
+	}
+	var resp listResponse
+	if err := c.do(http.MethodPost, "/v1/instances", nil, req, &resp); err != nil {
+		c.syslog.WithError(err).Error("cannot launch external instances")
+		return err
+	}
+	c.syslog.Infof("launched %d/%d external instances", len(resp.Instances), instanceNum)
+	return nil
+}
+
+func (c *externalCluster) Terminate(instanceIDs []string) {
+	if len(instanceIDs) == 0 {
+		return
+	}
+
+	req := terminateRequest{ResourcePool: c.resourcePool, InstanceIDs: instanceIDs}
+	if err := c.do(http.MethodPost, "/v1/instances/terminate", nil, req, nil); err != nil {
+		c.syslog.WithError(err).Error("cannot terminate external instances")
+		return
+	}
+	c.syslog.Infof("terminated %d external instances: %s", len(instanceIDs), strings.Join(instanceIDs, ", "))
+}
+
+// do sends a request to the provider service, retrying it if it may succeed later, and decodes the
+// response into resp, unless resp is nil or the response is empty.
+func (c *externalCluster) do(method, path string, query url.Values, body, resp interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+	}
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		retryable, err := c.doOnce(method, path, query, payload, resp)
+		if err == nil || !retryable || attempt >= c.config.Retries {
+			return err
+		}
+		c.syslog.WithError(err).Warnf("request to external provider failed, retrying in %s", wait)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+func (c *externalCluster) doOnce(
+	method, path string, query url.Values, payload []byte, resp interface{},
+) (retryable bool, err error) {
+	u := c.base.JoinPath(path)
+	u.RawQuery = query.Encode()
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(context.Background(), method, u.String(), body)
+	if err != nil {
+		return false, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.config.BearerToken != nil {
+		req.Header.Set("Authorization", "Bearer "+*c.config.BearerToken)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			c.syslog.WithError(err).Error("error closing external provider response body")
+		}
+	}()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return true, errors.Wrap(err, "failed to read response")
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		retryable := res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+		return retryable, fmt.Errorf("%s %s failed with code %d: %s",
+			method, path, res.StatusCode, bytes.TrimSpace(b))
+	}
+	if resp == nil || len(bytes.TrimSpace(b)) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(b, resp); err != nil {
+		return false, errors.Wrap(err, "failed to decode response")
+	}
+	return false, nil
+}
@@ -0,0 +1,205 @@
+package external
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/config/provconfig"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+const testToken = "token"
+
+// fakeProvider is a reference implementation of the provider service contract, which keeps its
+// instances in memory. Its next failures requests fail with a 503, and its next lostResponses
+// requests are handled but still respond with a 503, as if the response were lost.
+type fakeProvider struct {
+	mu            sync.Mutex
+	instances     map[string]map[string]instance
+	launched      map[string]bool
+	scripts       []string
+	failures      int
+	lostResponses int
+	requests      int
+}
+
+func newFakeProvider() *fakeProvider {
+	return &fakeProvider{instances: map[string]map[string]instance{}, launched: map[string]bool{}}
+}
+
+func (f *fakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if f.failures > 0 {
+		f.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if f.lostResponses > 0 {
+		f.lostResponses--
+		f.handle(httptest.NewRecorder(), r)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	f.handle(w, r)
+}
+
+func (f *fakeProvider) handle(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/instances":
+		pool := f.instances[r.URL.Query().Get("resource_pool")]
+		resp := listResponse{Instances: []instance{}}
+		for _, inst := range pool {
+			resp.Instances = append(resp.Instances, inst)
+		}
+		sort.Slice(resp.Instances, func(i, j int) bool { return resp.Instances[i].ID < resp.Instances[j].ID })
+		_ = json.NewEncoder(w).Encode(resp)
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/instances":
+		var req launchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Count <= 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		resp := listResponse{}
+		if !f.launched[req.RequestID] {
+			f.launched[req.RequestID] = true
+			f.scripts = append(f.scripts, req.AgentSetupScript)
+			if f.instances[req.ResourcePool] == nil {
+				f.instances[req.ResourcePool] = map[string]instance{}
+			}
+			for i := 0; i < req.Count; i++ {
+				id := fmt.Sprintf("%s-%d", req.ResourcePool, len(f.instances[req.ResourcePool]))
+				inst := instance{ID: id, AgentName: id, LaunchTime: time.Now().UTC(), State: "starting"}
+				f.instances[req.ResourcePool][id] = inst
+				resp.Instances = append(resp.Instances, inst)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/instances/terminate":
+		var req terminateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		for _, id := range req.InstanceIDs {
+			delete(f.instances[req.ResourcePool], id)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestCluster(t *testing.T, f *fakeProvider, retries int) *externalCluster {
+	require.NoError(t, etc.SetRootPath("../../../../../static/srv/"))
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	conf := provconfig.DefaultConfig()
+	conf.MasterURL = "http://master.internal:8080"
+	conf.External = provconfig.DefaultExternalClusterConfig()
+	conf.External.URL = srv.URL
+	conf.External.BearerToken = ptrs.Ptr(testToken)
+	conf.External.Retries = retries
+	conf.External.InstanceType = provconfig.ExternalInstanceType{
+		InstanceName: "bm.gpu.2", InstanceSlots: 2, SlotType: "cuda",
+	}
+
+	p, err := New("pool", conf, nil)
+	require.NoError(t, err)
+	c := p.(*externalCluster)
+	c.retryWait = time.Millisecond
+	return c
+}
+
+func TestExternalCluster(t *testing.T) {
+	f := newFakeProvider()
+	c := newTestCluster(t, f, 0)
+	require.Equal(t, "bm.gpu.2", c.InstanceType().Name())
+	require.Equal(t, 2, c.SlotsPerInstance())
+
+	instances, err := c.List()
+	require.NoError(t, err)
+	require.Empty(t, instances)
+
+	require.NoError(t, c.Launch(0))
+	require.NoError(t, c.Launch(3))
+	instances, err = c.List()
+	require.NoError(t, err)
+	require.Len(t, instances, 3)
+	for i, inst := range instances {
+		require.Equal(t, fmt.Sprintf("pool-%d", i), inst.ID)
+		require.Equal(t, inst.ID, inst.AgentName)
+		require.Equal(t, model.Starting, inst.State)
+	}
+
+	require.Len(t, f.scripts, 1)
+	script, err := base64.StdEncoding.DecodeString(f.scripts[0])
+	require.NoError(t, err)
+	require.Contains(t, string(script), `DET_AGENT_ID="${DET_AGENT_ID:-$(hostname)}"`)
+	require.Contains(t, string(script), "master.internal")
+
+	c.Terminate([]string{"pool-0", "pool-2"})
+	instances, err = c.List()
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	require.Equal(t, "pool-1", instances[0].ID)
+}
+
+func TestExternalClusterRetries(t *testing.T) {
+	f := newFakeProvider()
+	c := newTestCluster(t, f, 2)
+
+	// Retries of a launch are idempotent.
+	f.failures = 1
+	f.lostResponses = 1
+	require.NoError(t, c.Launch(1))
+	require.Equal(t, 3, f.requests)
+	instances, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+
+	f.failures = 3
+	_, err = c.List()
+	require.ErrorContains(t, err, "failed with code 503")
+
+	// Client errors are not retried.
+	f.requests = 0
+	c.config.BearerToken = ptrs.Ptr("wrong")
+	_, err = c.List()
+	require.ErrorContains(t, err, "failed with code 401")
+	require.Equal(t, 1, f.requests)
+}
+
+func TestExternalClusterTimeout(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	c := newTestCluster(t, newFakeProvider(), 0)
+	c.base.Host = strings.TrimPrefix(srv.URL, "http://")
+	c.client.Timeout = 10 * time.Millisecond
+	_, err := c.List()
+	require.ErrorContains(t, err, "Client.Timeout exceeded")
+}
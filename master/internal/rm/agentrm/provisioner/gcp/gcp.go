@@ -41,6 +41,10 @@ func init() {
 	petname.NonDeterministicMode()
 }
 
+// preemptedURL is where preemptible instances find out that they are being preempted.
+// See https://cloud.google.com/compute/docs/instances/create-use-preemptible.
+const preemptedURL = "http://metadata.google.internal/computeMetadata/v1/instance/preempted"
+
 // New creates a new GCP cluster.
 func New(
 	resourcePool string, config *provconfig.Config, cert *tls.Certificate,
@@ -86,6 +90,11 @@ func New(
 	}
 	masterCertBase64 := base64.StdEncoding.EncodeToString(certBytes)
 
+	capacityType, terminationNoticeURL := model.CapacityTypeOnDemand, ""
+	if config.GCP.InstanceType.Preemptible {
+		capacityType, terminationNoticeURL = model.CapacityTypeSpot, preemptedURL
+	}
+
 	startupScript := string(agentsetup.MustMakeAgentSetupScript(agentsetup.AgentSetupScriptConfig{
 		MasterHost:                   masterURL.Hostname(),
 		MasterPort:                   masterURL.Port(),
@@ -101,8 +110,10 @@ func New(
 		MasterCertBase64:             masterCertBase64,
 		AgentID: `$(curl "http://metadata.google.internal/computeMetadata/v1/instance/` +
 			`name" -H "Metadata-Flavor: Google")`,
-		ResourcePool: resourcePool,
-		LogOptions:   config.GCP.BuildDockerLogString(),
+		ResourcePool:         resourcePool,
+		CapacityType:         capacityType,
+		TerminationNoticeURL: terminationNoticeURL,
+		LogOptions:           config.GCP.BuildDockerLogString(),
 	}))
 
 	cluster := &gcpCluster{
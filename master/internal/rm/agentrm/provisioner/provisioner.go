@@ -14,6 +14,7 @@ import (
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/rm/agentrm/provisioner/agentsetup"
 	"github.com/determined-ai/determined/master/internal/rm/agentrm/provisioner/aws"
+	"github.com/determined-ai/determined/master/internal/rm/agentrm/provisioner/external"
 	"github.com/determined-ai/determined/master/internal/rm/agentrm/provisioner/gcp"
 	"github.com/determined-ai/determined/master/internal/rm/agentrm/provisioner/scaledecider"
 	"github.com/determined-ai/determined/master/internal/sproto"
@@ -48,6 +49,7 @@ type Provisioner struct {
 	scaleDecider     *scaledecider.ScaleDecider
 	telemetryLimiter *rate.Limiter
 	launchErr        *errInfo.StickyError
+	stop             chan struct{}
 
 	syslog *logrus.Entry
 }
@@ -71,6 +73,11 @@ func New(
 		if cluster, err = gcp.New(resourcePool, config, cert); err != nil {
 			return nil, errors.Wrap(err, "cannot create a GCP cluster")
 		}
+	case config.External != nil:
+		var err error
+		if cluster, err = external.New(resourcePool, config, cert); err != nil {
+			return nil, errors.Wrap(err, "cannot create an external cluster")
+		}
 	}
 
 	var launchErrorTimeout time.Duration
@@ -87,25 +94,51 @@ func New(
 			maxDisconnectPeriod,
 			config.MinInstances,
 			config.MaxInstances,
+			config.ScalingSchedules,
 			db,
 		),
 		telemetryLimiter: rate.NewLimiter(rate.Every(telemetryCooldown), 1),
 		launchErr:        errInfo.NewStickyError(launchErrorTimeout, config.LaunchErrorRetries),
+		stop:             make(chan struct{}),
 
 		syslog: logrus.WithField("component", "provisioner").
 			WithField("resource-pool", resourcePool),
 	}, nil
 }
 
-// Run starts the provisioner loop.
+// Run starts the provisioner loop, which runs until Stop is called.
 func (p *Provisioner) Run() {
 	for {
 		// Cooldown period before the provisioner starts.
-		time.Sleep(actionCooldown)
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(actionCooldown):
+		}
 		p.Provision()
 	}
 }
 
+// Stop stops the provisioner loop. It leaves the instances the provisioner launched running. It
+// must be called at most once.
+func (p *Provisioner) Stop() {
+	close(p.stop)
+}
+
+// UpdateConfig applies the instance limits and agent periods of config to the provisioner.
+func (p *Provisioner) UpdateConfig(config *provconfig.Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.scaleDecider.UpdateConfig(
+		time.Duration(config.MaxIdleAgentPeriod),
+		time.Duration(config.MaxAgentStartingPeriod),
+		config.MinInstances,
+		config.MaxInstances,
+		config.ScalingSchedules,
+	)
+}
+
 // UpdateScalingInfo updates the scaling info for the provisioner.
 func (p *Provisioner) UpdateScalingInfo(info *sproto.ScalingInfo) {
 	p.mu.Lock()
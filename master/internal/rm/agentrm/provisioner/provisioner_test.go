@@ -70,13 +70,16 @@ func newMockEnvironment(t *testing.T, setup *mockConfig) (*mockEnvironment, *Pro
 			setup.maxDisconnectPeriod,
 			setup.MinInstances,
 			setup.MaxInstances,
+			setup.ScalingSchedules,
 			nil,
 		),
 		telemetryLimiter: rate.NewLimiter(rate.Every(telemetryCooldown), 1),
 		launchErr:        errInfo.NewStickyError(launchErrorTimeout, setup.LaunchErrorRetries),
+		stop:             make(chan struct{}),
 		syslog:           logrus.WithField("test-provisioner", "default"),
 	}
 	go p.Run()
+	t.Cleanup(p.Stop)
 
 	environment := mockEnvironment{
 		cluster:     cluster,
@@ -242,6 +245,31 @@ func TestProvisionerScaleUpNotPastMax(t *testing.T) {
 	})
 }
 
+func TestProvisionerUpdateConfig(t *testing.T) {
+	setup := &mockConfig{
+		maxDisconnectPeriod: 5 * time.Minute,
+		instanceType: TestInstanceType{
+			NameString: "test.instanceType",
+			NumSlots:   4,
+		},
+		Config: &provconfig.Config{
+			MaxInstances: 1,
+		},
+		initInstances: []*model.Instance{},
+	}
+	mock, _ := newMockEnvironment(t, setup)
+	mock.provisioner.UpdateConfig(&provconfig.Config{MaxInstances: 3})
+	mock.provisioner.UpdateScalingInfo(&sproto.ScalingInfo{DesiredNewInstances: 4})
+	mock.provisioner.Provision()
+	assert.DeepEqual(t, mock.cluster.history, []mockFuncCall{
+		newMockFuncCall("list"),
+		newMockFuncCall("launch", TestInstanceType{
+			NameString: "test.instanceType",
+			NumSlots:   4,
+		}, 3),
+	})
+}
+
 func TestProvisionerScaleDown(t *testing.T) {
 	setup := &mockConfig{
 		maxDisconnectPeriod: 5 * time.Minute,
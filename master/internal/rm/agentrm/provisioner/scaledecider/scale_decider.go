@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/determined-ai/determined/master/internal/config/provconfig"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/mathx"
@@ -32,6 +33,7 @@ type ScaleDecider struct {
 	maxDisconnectPeriod time.Duration
 	minInstanceNum      int
 	maxInstanceNum      int
+	scalingSchedules    []provconfig.ScalingSchedule
 
 	instanceSnapshot       map[string]*model.Instance
 	connectedAgentSnapshot map[string]sproto.AgentSummary
@@ -58,6 +60,7 @@ func New(
 	maxDisconnectPeriod time.Duration,
 	minInstanceNum int,
 	maxInstanceNum int,
+	scalingSchedules []provconfig.ScalingSchedule,
 	db db.DB,
 ) *ScaleDecider {
 	return &ScaleDecider{
@@ -66,6 +69,7 @@ func New(
 		maxDisconnectPeriod:    maxDisconnectPeriod,
 		minInstanceNum:         minInstanceNum,
 		maxInstanceNum:         maxInstanceNum,
+		scalingSchedules:       scalingSchedules,
 		instanceSnapshot:       make(map[string]*model.Instance),
 		connectedAgentSnapshot: make(map[string]sproto.AgentSummary),
 		idleAgentSnapshot:      make(map[string]sproto.AgentSummary),
@@ -261,6 +265,7 @@ func (s *ScaleDecider) FindInstancesToTerminate() sproto.TerminateDecision {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	minInstanceNum, maxInstanceNum := s.instanceBounds()
 	toTerminate := make(map[string]string)
 
 	// Terminate stopped instances and find idle and disconnected instances.
@@ -277,7 +282,7 @@ func (s *ScaleDecider) FindInstancesToTerminate() sproto.TerminateDecision {
 
 	// Terminate instances that are idle for a long time.
 	for id := range s.longIdle {
-		if len(s.instances)-len(toTerminate) <= s.minInstanceNum {
+		if len(s.instances)-len(toTerminate) <= minInstanceNum {
 			break
 		}
 		toTerminate[id] = sproto.TerminateLongIdleInstances
@@ -286,28 +291,40 @@ func (s *ScaleDecider) FindInstancesToTerminate() sproto.TerminateDecision {
 
 	// Terminate instances to keep the number of instances less than the desired size.
 	// We start by terminating unfulfilled spot requests, then idle instances, then
-	// disconnected instances, then the most recently provisioned instances
+	// disconnected instances, then starting instances, then the most recently provisioned
+	// instances
 	for id := range s.pending {
-		if len(s.instances)-len(toTerminate) <= s.maxInstanceNum {
+		if len(s.instances)-len(toTerminate) <= maxInstanceNum {
 			break
 		}
 		toTerminate[id] = sproto.InstanceNumberExceedsMaximum
 		delete(s.pending, id)
 	}
 	for id := range s.idle {
-		if len(s.instances)-len(toTerminate) <= s.maxInstanceNum {
+		if len(s.instances)-len(toTerminate) <= maxInstanceNum {
 			break
 		}
 		toTerminate[id] = sproto.InstanceNumberExceedsMaximum
 		delete(s.idle, id)
 	}
 	for id := range s.disconnected {
-		if len(s.instances)-len(toTerminate) <= s.maxInstanceNum {
+		if len(s.instances)-len(toTerminate) <= maxInstanceNum {
 			break
 		}
 		toTerminate[id] = sproto.InstanceNumberExceedsMaximum
 		delete(s.disconnected, id)
 	}
+	for id := range s.recentlyLaunched {
+		if len(s.instances)-len(toTerminate) <= maxInstanceNum {
+			break
+		}
+		if _, ok := toTerminate[id]; !ok {
+			toTerminate[id] = sproto.InstanceNumberExceedsMaximum
+		}
+	}
+	// A scaling schedule only lowers the maximum for instances that are not busy, so that the
+	// work on busy instances finishes and they are terminated once idle.
+	busyMaxInstanceNum := mathx.Max(maxInstanceNum, s.maxInstanceNum)
 	instances := make([]*model.Instance, 0, len(s.instances))
 	for _, inst := range s.instances {
 		instances = append(instances, inst)
@@ -315,7 +332,7 @@ func (s *ScaleDecider) FindInstancesToTerminate() sproto.TerminateDecision {
 	sort.Slice(instances, func(i, j int) bool {
 		return instances[i].LaunchTime.After(instances[j].LaunchTime)
 	})
-	for i := 0; i < len(instances) && len(instances)-len(toTerminate) > s.maxInstanceNum; i++ {
+	for i := 0; i < len(instances) && len(instances)-len(toTerminate) > busyMaxInstanceNum; i++ {
 		toTerminate[instances[i].ID] = sproto.InstanceNumberExceedsMaximum
 	}
 
@@ -333,9 +350,32 @@ func (s *ScaleDecider) CalculateNumInstancesToLaunch() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	minInstanceNum, maxInstanceNum := s.instanceBounds()
 	return mathx.Max(0, mathx.Clamp(
-		s.minInstanceNum-len(s.instances),
+		minInstanceNum-len(s.instances),
 		s.desiredNewInstances-len(s.recentlyLaunched),
-		s.maxInstanceNum-len(s.instances),
+		maxInstanceNum-len(s.instances),
 	))
 }
+
+// UpdateConfig replaces the agent periods and instance limits of the scale decider.
+func (s *ScaleDecider) UpdateConfig(
+	maxIdlePeriod, maxStartingPeriod time.Duration,
+	minInstanceNum, maxInstanceNum int,
+	scalingSchedules []provconfig.ScalingSchedule,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxIdlePeriod = maxIdlePeriod
+	s.maxStartingPeriod = maxStartingPeriod
+	s.minInstanceNum = minInstanceNum
+	s.maxInstanceNum = maxInstanceNum
+	s.scalingSchedules = scalingSchedules
+}
+
+// instanceBounds returns the min and max number of instances, as set by the scaling schedule that
+// is active now, if any.
+func (s *ScaleDecider) instanceBounds() (int, int) {
+	return provconfig.InstanceBounds(s.minInstanceNum, s.maxInstanceNum, s.scalingSchedules, time.Now())
+}
@@ -8,9 +8,11 @@ import (
 	"github.com/stretchr/testify/mock"
 	"gotest.tools/assert"
 
+	"github.com/determined-ai/determined/master/internal/config/provconfig"
 	"github.com/determined-ai/determined/master/internal/mocks"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
 )
 
 func newInstanceIDSet(instanceIDs []string) map[string]bool {
@@ -220,6 +222,70 @@ func TestFindInstancesToTerminate(t *testing.T) {
 			},
 			toTerminate: []string{"long disconnected"},
 		},
+		{
+			name: "keep long idle above min instance num of active scaling schedule",
+			scaleDecider: ScaleDecider{
+				instances: map[string]*model.Instance{"long idle": {}},
+				longIdle:  map[string]bool{"long idle": true},
+				scalingSchedules: []provconfig.ScalingSchedule{
+					{MinInstances: ptrs.Ptr(1)},
+				},
+				maxInstanceNum: 10,
+			},
+			toTerminate: []string{},
+		},
+		{
+			name: "terminate idle above max instance num of active scaling schedule",
+			scaleDecider: ScaleDecider{
+				instances: map[string]*model.Instance{"idle": {}},
+				idle:      map[string]time.Time{"idle": time.Now()},
+				scalingSchedules: []provconfig.ScalingSchedule{
+					{MaxInstances: ptrs.Ptr(0)},
+				},
+				maxInstanceNum: 10,
+			},
+			toTerminate: []string{"idle"},
+		},
+		{
+			name: "keep busy instances above max instance num of active scaling schedule",
+			scaleDecider: ScaleDecider{
+				instances: map[string]*model.Instance{
+					"busy": {ID: "busy", LaunchTime: time.Now().Add(-time.Hour)},
+					"most recent busy": {
+						ID:         "most recent busy",
+						LaunchTime: time.Now().Add(-time.Minute),
+					},
+					"idle":     {ID: "idle", LaunchTime: time.Now().Add(-time.Hour)},
+					"pending":  {ID: "pending", LaunchTime: time.Now()},
+					"starting": {ID: "starting", LaunchTime: time.Now()},
+				},
+				idle:             map[string]time.Time{"idle": time.Now()},
+				pending:          map[string]bool{"pending": true},
+				recentlyLaunched: map[string]bool{"pending": true, "starting": true},
+				scalingSchedules: []provconfig.ScalingSchedule{
+					{MaxInstances: ptrs.Ptr(0)},
+				},
+				maxInstanceNum: 10,
+			},
+			toTerminate: []string{"idle", "pending", "starting"},
+		},
+		{
+			name: "terminate busy instances above max instance num",
+			scaleDecider: ScaleDecider{
+				instances: map[string]*model.Instance{
+					"busy": {ID: "busy", LaunchTime: time.Now().Add(-time.Hour)},
+					"most recent busy": {
+						ID:         "most recent busy",
+						LaunchTime: time.Now().Add(-time.Minute),
+					},
+				},
+				scalingSchedules: []provconfig.ScalingSchedule{
+					{MaxInstances: ptrs.Ptr(0)},
+				},
+				maxInstanceNum: 1,
+			},
+			toTerminate: []string{"most recent busy"},
+		},
 		{
 			name: "terminate instances until below the maximum",
 			scaleDecider: ScaleDecider{
@@ -402,6 +468,29 @@ func TestCalculateNumInstancesToLaunch(t *testing.T) {
 			},
 			numToLaunch: 0,
 		},
+		{
+			name: "keep above min instance num of active scaling schedule",
+			scaleDecider: ScaleDecider{
+				maxStartingPeriod: time.Minute,
+				maxInstanceNum:    2,
+				scalingSchedules: []provconfig.ScalingSchedule{
+					{MinInstances: ptrs.Ptr(4), MaxInstances: ptrs.Ptr(8)},
+				},
+			},
+			numToLaunch: 4,
+		},
+		{
+			name: "keep under max instance num of active scaling schedule",
+			scaleDecider: ScaleDecider{
+				maxStartingPeriod: time.Minute,
+				maxInstanceNum:    10,
+				scalingSchedules: []provconfig.ScalingSchedule{
+					{MaxInstances: ptrs.Ptr(1)},
+				},
+				desiredNewInstances: 4,
+			},
+			numToLaunch: 1,
+		},
 	}
 
 	for idx := range tcs {
@@ -26,6 +26,9 @@ func Setup(
 	if config.GCP != nil {
 		syslog.Info("connecting to GCP")
 	}
+	if config.External != nil {
+		syslog.Infof("connecting to external provider %s", config.External.URL)
+	}
 	provisioner, err := New(resourcePool, config, cert, db)
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating provisioner")
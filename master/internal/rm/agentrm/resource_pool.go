@@ -48,8 +48,14 @@ type resourcePool struct {
 	queuePositions   tasklist.JobSortState // secondary sort key based on job submission time
 	scalingInfo      *sproto.ScalingInfo
 
+	// fairShareUsage is the past usage of the pool under hierarchical fair share, and fairShares
+	// the shares of the pool from the last scheduling pass.
+	fairShareUsage *fairShareUsage
+	fairShares     []*fairShareNode
+
 	reschedule      bool
 	rescheduleTimer *time.Timer
+	stopped         bool
 
 	// Track notifyOnStop for testing purposes.
 	saveNotifications bool
@@ -84,6 +90,7 @@ func newResourcePool(
 		groups:         make(map[model.JobID]*tasklist.Group),
 		queuePositions: tasklist.InitializeJobSortState(false),
 		scalingInfo:    &sproto.ScalingInfo{},
+		fairShareUsage: newFairShareUsage(),
 
 		reschedule: false,
 		db:         db,
@@ -348,6 +355,9 @@ func (rp *resourcePool) getOrCreateGroup(jobID model.JobID) *tasklist.Group {
 func (rp *resourcePool) schedulerTick() {
 	rp.mu.Lock()
 	defer rp.mu.Unlock()
+	if rp.stopped {
+		return
+	}
 
 	if rp.provisioner != nil {
 		if err := rp.provisioner.LaunchError(); err != rp.provisionerError {
@@ -357,6 +367,14 @@ func (rp *resourcePool) schedulerTick() {
 			}
 		}
 	}
+	if conf := rp.config.Scheduler.FairShare; conf != nil && conf.Hierarchical &&
+		conf.UsageHalfLife != nil {
+		// Shares change as past usage decays, even when nothing else does.
+		if rp.reschedule || time.Since(rp.fairShareUsage.updated) >= fairShareUsageInterval {
+			rp.fairShareUsage.update(rp.taskList, time.Duration(*conf.UsageHalfLife), time.Now())
+			rp.reschedule = true
+		}
+	}
 	if rp.reschedule {
 		rp.syslog.Trace("scheduling")
 		rp.agentStatesCache = rp.agentService.list(rp.config.PoolName)
@@ -493,8 +511,13 @@ func (rp *resourcePool) sendScalingInfo() {
 }
 
 func (rp *resourcePool) updateScalingInfo() bool {
+	var capacityType model.CapacityType
+	if rp.config.Provider != nil {
+		capacityType = rp.config.Provider.CapacityType()
+	}
 	desiredInstanceNum := calculateDesiredNewAgentNum(
 		rp.taskList, rp.groups, rp.slotsPerInstance, rp.config.MaxAuxContainersPerAgent,
+		capacityType,
 	)
 	agents := make(map[string]sproto.AgentSummary)
 	for _, agentState := range rp.agentStatesCache {
@@ -630,7 +653,7 @@ func (rp *resourcePool) CapacityCheck(msg sproto.CapacityCheck) (sproto.Capacity
 			}
 		}
 	case rp.config.Provider.AWS != nil:
-		totalSlots = rp.config.Provider.MaxInstances * rp.config.Provider.AWS.SlotsPerInstance()
+		totalSlots = rp.config.Provider.PeakMaxInstances() * rp.config.Provider.AWS.SlotsPerInstance()
 
 		for id, a := range rp.agentStatesCache {
 			if blockedNodeSet.Contains(string(id)) {
@@ -638,7 +661,15 @@ func (rp *resourcePool) CapacityCheck(msg sproto.CapacityCheck) (sproto.Capacity
 			}
 		}
 	case rp.config.Provider.GCP != nil:
-		totalSlots = rp.config.Provider.MaxInstances * rp.config.Provider.GCP.SlotsPerInstance()
+		totalSlots = rp.config.Provider.PeakMaxInstances() * rp.config.Provider.GCP.SlotsPerInstance()
+
+		for id, a := range rp.agentStatesCache {
+			if blockedNodeSet.Contains(string(id)) {
+				totalSlots -= len(a.slotStates)
+			}
+		}
+	case rp.config.Provider.External != nil:
+		totalSlots = rp.config.Provider.PeakMaxInstances() * rp.config.Provider.External.SlotsPerInstance()
 
 		for id, a := range rp.agentStatesCache {
 			if blockedNodeSet.Contains(string(id)) {
@@ -674,6 +705,18 @@ func (rp *resourcePool) GetJobQStats() *jobv1.QueueStats {
 	return tasklist.JobStats(rp.taskList)
 }
 
+// GetFairShares returns the shares of the workspaces of the pool under hierarchical fair share.
+func (rp *resourcePool) GetFairShares() []*jobv1.FairShare {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	shares := make([]*jobv1.FairShare, 0, len(rp.fairShares))
+	for _, workspace := range rp.fairShares {
+		shares = append(shares, workspace.proto())
+	}
+	return shares
+}
+
 func (rp *resourcePool) GetJobQ() map[model.JobID]*sproto.RMJobInfo {
 	rp.mu.Lock()
 	defer rp.mu.Unlock()
@@ -689,10 +732,52 @@ func (rp *resourcePool) JobStopped(jobID model.JobID) {
 	delete(rp.queuePositions, jobID)
 }
 
-// only for tests, for now.
+// reconfigure replaces the config, scheduler and fitting method of the resource pool, and updates
+// the instance limits of its provisioner.
+func (rp *resourcePool) reconfigure(
+	config *config.ResourcePoolConfig, scheduler Scheduler, fittingMethod SoftConstraint,
+) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.reschedule = true
+
+	rp.config = config
+	rp.scheduler = scheduler
+	rp.fittingMethod = fittingMethod
+	if rp.provisioner != nil {
+		rp.provisioner.UpdateConfig(config.Provider)
+	}
+}
+
+// stopIfIdle stops the resource pool, unless it has tasks or connected agents.
+func (rp *resourcePool) stopIfIdle() error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if n := rp.taskList.Len(); n > 0 {
+		return errors.Errorf("resource pool %s has %d tasks", rp.config.PoolName, n)
+	}
+	if n := len(rp.agentService.list(rp.config.PoolName)); n > 0 {
+		return errors.Errorf("resource pool %s has %d connected agents", rp.config.PoolName, n)
+	}
+	rp.stopLocked()
+	return nil
+}
+
 func (rp *resourcePool) stop() {
 	rp.mu.Lock()
 	defer rp.mu.Unlock()
 
+	rp.stopLocked()
+}
+
+func (rp *resourcePool) stopLocked() {
+	if rp.stopped {
+		return
+	}
+	rp.stopped = true
 	rp.rescheduleTimer.Stop()
+	if rp.provisioner != nil {
+		rp.provisioner.Stop()
+	}
 }
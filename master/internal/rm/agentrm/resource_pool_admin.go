@@ -0,0 +1,328 @@
+package agentrm
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/config/provconfig"
+	internaldb "github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/rm"
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/proto/pkg/resourcepoolv1"
+)
+
+// resourcePoolRow is the config of a resource pool created or patched through the API.
+type resourcePoolRow struct {
+	bun.BaseModel `bun:"table:resource_pools"`
+
+	PoolName  string                    `bun:"pool_name,pk"`
+	Config    config.ResourcePoolConfig `bun:"config"`
+	CreatedAt time.Time                 `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time                 `bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// withStoredResourcePools returns the resource pools of the master config file, with the ones
+// patched through the API replaced by their stored configs, followed by the ones created through
+// the API.
+func withStoredResourcePools(
+	ctx context.Context, filePools []config.ResourcePoolConfig,
+) ([]config.ResourcePoolConfig, error) {
+	var rows []resourcePoolRow
+	if err := internaldb.Bun().NewSelect().Model(&rows).Order("created_at").Scan(ctx); err != nil {
+		return nil, errors.Wrap(err, "loading stored resource pools")
+	}
+	stored := make(map[string]config.ResourcePoolConfig, len(rows))
+	for _, row := range rows {
+		stored[row.PoolName] = row.Config
+	}
+
+	pools := make([]config.ResourcePoolConfig, 0, len(filePools)+len(rows))
+	for _, pool := range filePools {
+		if storedPool, ok := stored[pool.PoolName]; ok {
+			pool = storedPool
+			delete(stored, pool.PoolName)
+		}
+		pools = append(pools, pool)
+	}
+	for _, row := range rows {
+		if _, ok := stored[row.PoolName]; ok {
+			pools = append(pools, row.Config)
+		}
+	}
+	return pools, nil
+}
+
+func setMasterConfigResourcePools(pools []config.ResourcePoolConfig) {
+	config.GetMasterConfig().SetAgentRMResourcePools(pools)
+}
+
+// CreateResourcePool implements rm.ResourcePoolManager.
+func (a *ResourceManager) CreateResourcePool(
+	ctx context.Context, poolConfig config.ResourcePoolConfig,
+) (*resourcepoolv1.ResourcePool, error) {
+	if err := check.Validate(poolConfig); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := a.createResourcePoolLocked(ctx, poolConfig); err != nil {
+		return nil, err
+	}
+	return a.resourcePoolSummary(poolConfig)
+}
+
+func (a *ResourceManager) createResourcePoolLocked(
+	ctx context.Context, poolConfig config.ResourcePoolConfig,
+) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.pools[poolConfig.PoolName]; ok {
+		return status.Errorf(codes.AlreadyExists, "resource pool %s already exists", poolConfig.PoolName)
+	}
+	rp, err := a.createResourcePool(a.db, poolConfig, a.cert)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	row := resourcePoolRow{PoolName: poolConfig.PoolName, Config: poolConfig}
+	if _, err := internaldb.Bun().NewInsert().Model(&row).Exec(ctx); err != nil {
+		rp.stop()
+		return errors.Wrapf(err, "storing resource pool %s", poolConfig.PoolName)
+	}
+
+	a.pools[poolConfig.PoolName] = rp
+	a.setPoolsConfigLocked(append(slices.Clone(a.poolsConfig), poolConfig))
+	return nil
+}
+
+// PatchResourcePool implements rm.ResourcePoolManager. The scheduler, task container defaults and
+// provider instance limits of the pool apply immediately, and its agent settings apply to agents
+// that connect afterwards.
+func (a *ResourceManager) PatchResourcePool(
+	ctx context.Context, name rm.ResourcePoolName, patch []byte,
+) (*resourcepoolv1.ResourcePool, error) {
+	poolConfig, err := a.patchResourcePoolLocked(ctx, name.String(), patch)
+	if err != nil {
+		return nil, err
+	}
+	return a.resourcePoolSummary(poolConfig)
+}
+
+func (a *ResourceManager) patchResourcePoolLocked(
+	ctx context.Context, name string, patch []byte,
+) (config.ResourcePoolConfig, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rp, ok := a.pools[name]
+	if !ok {
+		return config.ResourcePoolConfig{}, status.Errorf(codes.NotFound, "resource pool %s not found", name)
+	}
+	ix := slices.IndexFunc(a.poolsConfig, func(c config.ResourcePoolConfig) bool {
+		return c.PoolName == name
+	})
+	current := a.poolsConfig[ix]
+
+	patched, err := patchResourcePoolConfig(current, patch)
+	if err != nil {
+		return config.ResourcePoolConfig{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := check.Validate(patched); err != nil {
+		return config.ResourcePoolConfig{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := checkLiveUpdatable(current, patched); err != nil {
+		return config.ResourcePoolConfig{}, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	// As in createResourcePool, pools without a scheduler use the global one.
+	applied := patched
+	if applied.Scheduler == nil {
+		applied.Scheduler = a.config.Scheduler
+	}
+	scheduler, err := MakeScheduler(applied.Scheduler)
+	if err != nil {
+		return config.ResourcePoolConfig{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	row := resourcePoolRow{PoolName: name, Config: patched, UpdatedAt: time.Now()}
+	if _, err := internaldb.Bun().NewInsert().Model(&row).
+		On("CONFLICT (pool_name) DO UPDATE").
+		Set("config = EXCLUDED.config").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx); err != nil {
+		return config.ResourcePoolConfig{}, errors.Wrapf(err, "storing resource pool %s", name)
+	}
+
+	rp.reconfigure(&applied, scheduler, MakeFitFunction(applied.Scheduler.FittingPolicy))
+	pools := slices.Clone(a.poolsConfig)
+	pools[ix] = patched
+	a.setPoolsConfigLocked(pools)
+	return patched, nil
+}
+
+// DeleteResourcePool implements rm.ResourcePoolManager. Only resource pools created through the
+// API, which are not a default pool, have no tasks or agents and are bound to no workspaces, can
+// be deleted.
+func (a *ResourceManager) DeleteResourcePool(ctx context.Context, name rm.ResourcePoolName) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rp, ok := a.pools[name.String()]
+	switch {
+	case !ok:
+		return status.Errorf(codes.NotFound, "resource pool %s not found", name)
+	case a.filePools[name.String()]:
+		return status.Errorf(codes.FailedPrecondition,
+			"resource pool %s is defined in the master config file and must be removed from it", name)
+	case name.String() == a.config.DefaultComputeResourcePool,
+		name.String() == a.config.DefaultAuxResourcePool:
+		return status.Errorf(codes.FailedPrecondition, "resource pool %s is a default resource pool", name)
+	}
+
+	bindings, err := internaldb.Bun().NewSelect().Table("rp_workspace_bindings").
+		Where("pool_name = ?", name).
+		Count(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "counting workspaces bound to resource pool %s", name)
+	}
+	if bindings > 0 {
+		return status.Errorf(codes.FailedPrecondition,
+			"resource pool %s is bound to %d workspaces", name, bindings)
+	}
+
+	err = internaldb.Bun().RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().Model((*resourcePoolRow)(nil)).
+			Where("pool_name = ?", name).
+			Exec(ctx); err != nil {
+			return errors.Wrapf(err, "deleting resource pool %s", name)
+		}
+		if err := rp.stopIfIdle(); err != nil {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	delete(a.pools, name.String())
+	a.setPoolsConfigLocked(slices.DeleteFunc(slices.Clone(a.poolsConfig),
+		func(c config.ResourcePoolConfig) bool { return c.PoolName == name.String() }))
+	return nil
+}
+
+// setPoolsConfigLocked replaces the resource pool configs of the resource manager, of its agents
+// and of the master config. The caller must hold a.mu.
+func (a *ResourceManager) setPoolsConfigLocked(pools []config.ResourcePoolConfig) {
+	a.poolsConfig = pools
+	a.agentService.setPoolConfigs(pools)
+	setMasterConfigResourcePools(pools)
+}
+
+func (a *ResourceManager) resourcePoolSummary(
+	poolConfig config.ResourcePoolConfig,
+) (*resourcepoolv1.ResourcePool, error) {
+	summary, err := a.createResourcePoolSummary(poolConfig.PoolName)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Stats, err = a.getPoolJobStats(poolConfig); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// patchResourcePoolConfig applies the JSON merge patch (RFC 7386) patch to poolConfig.
+func patchResourcePoolConfig(
+	poolConfig config.ResourcePoolConfig, patch []byte,
+) (config.ResourcePoolConfig, error) {
+	current, err := json.Marshal(poolConfig)
+	if err != nil {
+		return config.ResourcePoolConfig{}, err
+	}
+	var currentDoc, patchDoc any
+	if err := json.Unmarshal(current, &currentDoc); err != nil {
+		return config.ResourcePoolConfig{}, err
+	}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return config.ResourcePoolConfig{}, errors.Wrap(err, "invalid resource pool patch")
+	}
+	if _, ok := patchDoc.(map[string]any); !ok {
+		return config.ResourcePoolConfig{}, errors.New("resource pool patch must be an object")
+	}
+	merged, err := json.Marshal(mergePatch(currentDoc, patchDoc))
+	if err != nil {
+		return config.ResourcePoolConfig{}, err
+	}
+
+	var patched config.ResourcePoolConfig
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return config.ResourcePoolConfig{}, errors.Wrap(err, "invalid resource pool config")
+	}
+	return patched, nil
+}
+
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = mergePatch(targetObj[k], v)
+	}
+	return targetObj
+}
+
+// checkLiveUpdatable returns an error if patched changes a part of the resource pool config that
+// cannot change while the pool runs: its name, and its provider other than the instance limits
+// and agent periods.
+func checkLiveUpdatable(current, patched config.ResourcePoolConfig) error {
+	if patched.PoolName != current.PoolName {
+		return errors.New("the name of a resource pool cannot be changed")
+	}
+	if (current.Provider == nil) != (patched.Provider == nil) {
+		return errors.New("the provider of a resource pool cannot be added or removed")
+	}
+	if current.Provider == nil {
+		return nil
+	}
+
+	currentProvider, err := providerWithoutLimits(*current.Provider)
+	if err != nil {
+		return err
+	}
+	patchedProvider, err := providerWithoutLimits(*patched.Provider)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(currentProvider, patchedProvider) {
+		return fmt.Errorf("only min_instances, max_instances, scaling_schedules, " +
+			"max_idle_agent_period and max_agent_starting_period of a provider can be changed")
+	}
+	return nil
+}
+
+func providerWithoutLimits(provider provconfig.Config) ([]byte, error) {
+	provider.MinInstances, provider.MaxInstances = 0, 0
+	provider.ScalingSchedules = nil
+	provider.MaxIdleAgentPeriod, provider.MaxAgentStartingPeriod = 0, 0
+	return json.Marshal(provider)
+}
@@ -0,0 +1,124 @@
+//go:build integration
+// +build integration
+
+package agentrm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/rm"
+	"github.com/determined-ai/determined/master/internal/user"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/resourcepoolv1"
+)
+
+func TestResourcePoolAdmin(t *testing.T) {
+	ctx := context.Background()
+	pgDB, closeDB := db.MustResolveTestPostgres(t)
+	defer closeDB()
+	user.InitService(nil, nil)
+	_, err := db.Bun().NewTruncateTable().Table("resource_pools").Exec(ctx)
+	require.NoError(t, err)
+
+	newRM := func() *ResourceManager {
+		conf := &config.ResourceConfig{
+			RootManagerInternal: &config.ResourceManagerConfig{
+				AgentRM: &config.AgentResourceManagerConfig{
+					Scheduler: &config.SchedulerConfig{
+						FairShare:     &config.FairShareSchedulerConfig{},
+						FittingPolicy: best,
+					},
+					DefaultAuxResourcePool:     "default",
+					DefaultComputeResourcePool: "default",
+				},
+			},
+			RootPoolsInternal: []config.ResourcePoolConfig{
+				{PoolName: "default", MaxAuxContainersPerAgent: 100},
+			},
+		}
+		agentRM, err := New(pgDB, echo.New(), conf.ResourceManagers()[0], nil, nil)
+		require.NoError(t, err)
+		t.Cleanup(agentRM.stop)
+		return agentRM
+	}
+	poolNames := func(agentRM *ResourceManager) []string {
+		resp, err := agentRM.GetResourcePools()
+		require.NoError(t, err)
+		var names []string
+		for _, pool := range resp.ResourcePools {
+			names = append(names, pool.Name)
+		}
+		return names
+	}
+
+	agentRM := newRM()
+	pool, err := agentRM.CreateResourcePool(ctx, config.ResourcePoolConfig{
+		PoolName:                 "extra",
+		MaxAuxContainersPerAgent: 100,
+		TaskContainerDefaults:    &model.TaskContainerDefaultsConfig{ShmSizeBytes: 1024},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "extra", pool.Name)
+	require.Equal(t, []string{"default", "extra"}, poolNames(agentRM))
+
+	_, err = agentRM.CreateResourcePool(ctx, config.ResourcePoolConfig{PoolName: "extra"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = agentRM.CreateResourcePool(ctx, config.ResourcePoolConfig{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Patches apply live.
+	pool, err = agentRM.PatchResourcePool(ctx, "extra", []byte(`{
+		"description": "patched",
+		"scheduler": {"type": "priority", "default_priority": 10},
+		"task_container_defaults": {"shm_size_bytes": 2048}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "patched", pool.Description)
+	require.Equal(t, resourcepoolv1.SchedulerType_SCHEDULER_TYPE_PRIORITY, pool.SchedulerType)
+	tcd, err := agentRM.TaskContainerDefaults("extra", model.TaskContainerDefaultsConfig{})
+	require.NoError(t, err)
+	require.Equal(t, int64(2048), tcd.ShmSizeBytes)
+	require.Equal(t, 10, config.DefaultPriorityForPool("extra"))
+
+	_, err = agentRM.PatchResourcePool(ctx, "default", []byte(`{"description": "from the api"}`))
+	require.NoError(t, err)
+	_, err = agentRM.PatchResourcePool(ctx, "extra", []byte(`{"pool_name": "renamed"}`))
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = agentRM.PatchResourcePool(ctx, "extra", []byte(`{"max_aux_containers_per_agent": -1}`))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = agentRM.PatchResourcePool(ctx, "missing", []byte(`{}`))
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// Created and patched pools are restored on restart.
+	agentRM.stop()
+	agentRM = newRM()
+	require.Equal(t, []string{"default", "extra"}, poolNames(agentRM))
+	resp, err := agentRM.GetResourcePools()
+	require.NoError(t, err)
+	require.Equal(t, "from the api", resp.ResourcePools[0].Description)
+	require.Equal(t, "patched", resp.ResourcePools[1].Description)
+
+	// Only idle, unbound pools created through the API can be deleted.
+	require.Equal(t, codes.FailedPrecondition, status.Code(agentRM.DeleteResourcePool(ctx, "default")))
+	workspaceID, _ := db.RequireMockWorkspaceID(t, pgDB, "")
+	require.NoError(t, db.AddRPWorkspaceBindings(ctx, []int32{int32(workspaceID)}, "extra",
+		[]config.ResourcePoolConfig{{PoolName: "extra"}}))
+	require.Equal(t, codes.FailedPrecondition, status.Code(agentRM.DeleteResourcePool(ctx, "extra")))
+	require.NoError(t, db.RemoveRPWorkspaceBindings(ctx, []int32{int32(workspaceID)}, "extra"))
+
+	require.NoError(t, agentRM.DeleteResourcePool(ctx, "extra"))
+	require.Equal(t, []string{"default"}, poolNames(agentRM))
+	require.Error(t, agentRM.ValidateResourcePool(rm.ResourcePoolName("extra")))
+
+	agentRM.stop()
+	agentRM = newRM()
+	require.Equal(t, []string{"default"}, poolNames(agentRM))
+}
@@ -0,0 +1,76 @@
+package agentrm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/config/provconfig"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestPatchResourcePoolConfig(t *testing.T) {
+	current := config.ResourcePoolConfig{
+		PoolName:                 "pool",
+		Description:              "before",
+		MaxAuxContainersPerAgent: 10,
+		Scheduler: &config.SchedulerConfig{
+			FairShare:     &config.FairShareSchedulerConfig{},
+			FittingPolicy: best,
+		},
+		TaskContainerDefaults: &model.TaskContainerDefaultsConfig{ShmSizeBytes: 1024},
+	}
+
+	patched, err := patchResourcePoolConfig(current, []byte(`{
+		"description": "after",
+		"scheduler": null,
+		"task_container_defaults": {"shm_size_bytes": 2048}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "pool", patched.PoolName)
+	require.Equal(t, "after", patched.Description)
+	require.Equal(t, 10, patched.MaxAuxContainersPerAgent)
+	require.Nil(t, patched.Scheduler)
+	require.Equal(t, int64(2048), patched.TaskContainerDefaults.ShmSizeBytes)
+	require.Equal(t, "before", current.Description)
+
+	_, err = patchResourcePoolConfig(current, []byte(`["description"]`))
+	require.ErrorContains(t, err, "must be an object")
+	_, err = patchResourcePoolConfig(current, []byte(`{"max_aux_containers_per_agent": "many"}`))
+	require.ErrorContains(t, err, "invalid resource pool config")
+}
+
+func TestCheckLiveUpdatable(t *testing.T) {
+	provider := provconfig.DefaultConfig()
+	provider.MasterURL = "http://master:8080"
+	provider.External = provconfig.DefaultExternalClusterConfig()
+	provider.External.URL = "http://provider"
+	current := config.ResourcePoolConfig{PoolName: "pool", Provider: provider}
+
+	limits := *provider
+	limits.MinInstances = 2
+	limits.MaxInstances = 20
+	limits.MaxIdleAgentPeriod = model.Duration(time.Hour)
+	limits.ScalingSchedules = []provconfig.ScalingSchedule{{MaxInstances: ptrs.Ptr(1)}}
+	require.NoError(t, checkLiveUpdatable(current, config.ResourcePoolConfig{
+		PoolName: "pool", Description: "changed", Provider: &limits,
+	}))
+
+	external := *provider.External
+	external.URL = "http://other-provider"
+	url := *provider
+	url.External = &external
+	require.ErrorContains(t, checkLiveUpdatable(current, config.ResourcePoolConfig{
+		PoolName: "pool", Provider: &url,
+	}), "only min_instances, max_instances")
+
+	require.ErrorContains(t, checkLiveUpdatable(current, config.ResourcePoolConfig{
+		PoolName: "pool",
+	}), "cannot be added or removed")
+	require.ErrorContains(t, checkLiveUpdatable(current, config.ResourcePoolConfig{
+		PoolName: "renamed", Provider: provider,
+	}), "cannot be changed")
+}
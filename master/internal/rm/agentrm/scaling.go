@@ -7,12 +7,14 @@ import (
 )
 
 // calculateDesiredNewAgentNum calculates the new instances based on pending tasks and
-// slots per instance.
+// slots per instance. Tasks that require a different capacity type than the instances have are
+// ignored, unless the capacity type of the instances is unknown.
 func calculateDesiredNewAgentNum(
 	taskList *tasklist.TaskList,
 	groups map[model.JobID]*tasklist.Group,
 	slotsPerAgent int,
 	maxZeroSlotTasksPerAgent int,
+	capacityType model.CapacityType,
 ) int {
 	slotSum := 0
 	allTasks := 0
@@ -26,6 +28,10 @@ func calculateDesiredNewAgentNum(
 		case taskList.IsScheduled(it.Value().AllocationID):
 			// If a task is already allocated, skip it.
 			continue
+		case capacityType != "" && it.Value().CapacityType != "" &&
+			it.Value().CapacityType != capacityType:
+			// If new instances could never run the task, skip it.
+			continue
 		case it.Value().SlotsNeeded == 0:
 			zeroSlotTasks++
 			allTasks++
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/determined-ai/determined/master/internal/rm/tasklist"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
 
 	"gotest.tools/assert"
 )
@@ -38,7 +40,7 @@ func TestCalculatingDesiredInstanceNum(t *testing.T) {
 	// task 9 is in a group with no max slots and slots needed = 10
 	// The feasible total SlotSum (with maxSlots of each group taken into account) = 26.
 	// ceil(26/5) = 6
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, groupMap, 5, 10), 6)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, groupMap, 5, 10, ""), 6)
 
 	taskList = tasklist.New()
 
@@ -46,96 +48,114 @@ func TestCalculatingDesiredInstanceNum(t *testing.T) {
 
 	forceAddTask(t, taskList, "task1", 1, 1)
 	forceAddTask(t, taskList, "task2", 0, 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2, ""), 1)
 
 	// Test more one-slot allocated and pending tasks.
 	forceAddTask(t, taskList, "task3", 0, 1)
 	forceAddTask(t, taskList, "task4", 1, 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2, ""), 1)
 
 	// Test existing task got allocated/preempted.
 	forceSetTaskAllocations(t, taskList, "task3", 1)
 	forceSetTaskAllocations(t, taskList, "task4", 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2, ""), 1)
 
 	// Test zero slot tasks.
 	forceAddTask(t, taskList, "task5", 0, 0)
 	forceAddTask(t, taskList, "task6", 1, 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2), 2)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2, ""), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2, ""), 1)
 
 	// Test distributed training tasks.
 	forceAddTask(t, taskList, "task7", 0, 4)
 	forceAddTask(t, taskList, "task8", 1, 4)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100), 6)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100), 3)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0), 6)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0), 3)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1), 6)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1), 3)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2), 6)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2), 3)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100, ""), 6)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100, ""), 3)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0, ""), 6)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0, ""), 3)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1, ""), 6)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1, ""), 3)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2, ""), 6)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2, ""), 3)
 
 	// Test unschedulable distributed training tasks.
 	forceAddTask(t, taskList, "task9", 0, 3)
 	forceAddTask(t, taskList, "task10", 1, 3)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100), 9)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100), 3)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0), 0)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0), 9)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0), 3)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1), 9)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1), 3)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2), 1)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2), 9)
-	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2), 3)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 100, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 100, ""), 9)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 100, ""), 3)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 0, ""), 0)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 0, ""), 9)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0, ""), 3)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 1, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 1, ""), 9)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 1, ""), 3)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 0, 2, ""), 1)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 1, 2, ""), 9)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 2, ""), 3)
+}
+
+func TestCalculatingDesiredInstanceNumCapacityType(t *testing.T) {
+	taskList := tasklist.New()
+	taskList.AddTask(&sproto.AllocateRequest{
+		AllocationID: "onSpot", JobID: "onSpot", SlotsNeeded: 2, CapacityType: model.CapacityTypeSpot,
+	})
+	taskList.AddTask(&sproto.AllocateRequest{
+		AllocationID: "onDemand", JobID: "onDemand", SlotsNeeded: 2,
+		CapacityType: model.CapacityTypeOnDemand,
+	})
+	taskList.AddTask(&sproto.AllocateRequest{
+		AllocationID: "anywhere", JobID: "anywhere", SlotsNeeded: 2,
+	})
+
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0, ""), 3)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0, model.CapacityTypeSpot), 2)
+	assert.Equal(t, calculateDesiredNewAgentNum(taskList, nil, 2, 0, model.CapacityTypeOnDemand), 2)
 }
@@ -280,9 +280,9 @@ func TestJobStats(t *testing.T) {
 		expectedStats *jobv1.QueueStats,
 	) {
 		taskList, groupMap, agentMap := setupSchedulerStates(t, tasks, groups, agents)
-		toAllocate, _ := fairshareSchedule(taskList, groupMap, agentMap, BestFit, false)
+		toAllocate, _ := fairshareSchedule(taskList, groupMap, agentMap, BestFit, false, nil)
 		AllocateTasks(toAllocate, agentMap, taskList)
-		fairshareSchedule(taskList, groupMap, agentMap, BestFit, false)
+		fairshareSchedule(taskList, groupMap, agentMap, BestFit, false, nil)
 
 		assertStatsEqual(t, tasklist.JobStats(taskList), expectedStats)
 	}
@@ -361,9 +361,9 @@ func TestJobOrder(t *testing.T) {
 		agents []*MockAgent,
 	) map[model.JobID]*sproto.RMJobInfo {
 		taskList, groupMap, agentMap := setupSchedulerStates(t, tasks, groups, agents)
-		toAllocate, _ := fairshareSchedule(taskList, groupMap, agentMap, BestFit, false)
+		toAllocate, _ := fairshareSchedule(taskList, groupMap, agentMap, BestFit, false, nil)
 		AllocateTasks(toAllocate, agentMap, taskList)
-		fairshareSchedule(taskList, groupMap, agentMap, BestFit, false)
+		fairshareSchedule(taskList, groupMap, agentMap, BestFit, false, nil)
 		f := fairShare{}
 		return f.JobQInfo(&resourcePool{taskList: taskList, groups: groupMap})
 	}
@@ -1,13 +1,17 @@
 package rm
 
 import (
+	"context"
+
 	"google.golang.org/protobuf/proto"
 
+	"github.com/determined-ai/determined/master/internal/config"
 	"github.com/determined-ai/determined/master/internal/sproto"
 	"github.com/determined-ai/determined/master/pkg/command"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/proto/pkg/apiv1"
 	"github.com/determined-ai/determined/proto/pkg/jobv1"
+	"github.com/determined-ai/determined/proto/pkg/resourcepoolv1"
 )
 
 // ResourceManager is an interface for a resource manager, which can allocate and manage resources.
@@ -64,6 +68,17 @@ type ResourceManager interface {
 	SetResourceQuota(int, string, string) error
 }
 
+// ResourcePoolManager is implemented by resource managers whose resource pools can be created,
+// patched and deleted at runtime.
+type ResourcePoolManager interface {
+	CreateResourcePool(context.Context, config.ResourcePoolConfig) (*resourcepoolv1.ResourcePool, error)
+	// PatchResourcePool applies a JSON merge patch to the config of a resource pool.
+	PatchResourcePool(
+		ctx context.Context, name ResourcePoolName, patch []byte,
+	) (*resourcepoolv1.ResourcePool, error)
+	DeleteResourcePool(context.Context, ResourcePoolName) error
+}
+
 // ResourcePoolName holds the name of the resource pool, and describes the input/output
 // of several ResourceManager methods.
 type ResourcePoolName string
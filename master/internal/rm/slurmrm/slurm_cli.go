@@ -0,0 +1,154 @@
+package slurmrm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// commandRunner runs a Slurm command line tool and returns its standard output. It is the seam
+// that lets tests replace the real tools with fakes.
+type commandRunner interface {
+	run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// execRunner runs the Slurm tools installed on the master host.
+type execRunner struct {
+	// binDir is the directory containing the tools; if empty, they are looked up on the PATH.
+	binDir string
+}
+
+func (r execRunner) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if r.binDir != "" {
+		name = filepath.Join(r.binDir, name)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// jobState is the state of a Slurm job as reported by squeue or sacct.
+type jobState struct {
+	state    string
+	reason   string
+	exitCode int
+	signal   int
+}
+
+// Slurm job states, see JOB STATE CODES in squeue(1).
+const (
+	statePending     = "PENDING"
+	stateRunning     = "RUNNING"
+	stateCompleted   = "COMPLETED"
+	stateCancelled   = "CANCELLED"
+	stateFailed      = "FAILED"
+	stateTimeout     = "TIMEOUT"
+	stateNodeFail    = "NODE_FAIL"
+	statePreempted   = "PREEMPTED"
+	stateOutOfMemory = "OUT_OF_MEMORY"
+	stateBootFail    = "BOOT_FAIL"
+	stateDeadline    = "DEADLINE"
+)
+
+// terminal returns true if the job will not run again.
+func (s jobState) terminal() bool {
+	switch s.state {
+	case stateCompleted, stateCancelled, stateFailed, stateTimeout, stateNodeFail, statePreempted,
+		stateOutOfMemory, stateBootFail, stateDeadline, "SPECIAL_EXIT", "REVOKED":
+		return true
+	default:
+		return false
+	}
+}
+
+// started returns true if the job has been given nodes.
+func (s jobState) started() bool {
+	switch s.state {
+	case stateRunning, "COMPLETING", "STAGE_OUT", "SIGNALING", "RESIZING", "SUSPENDED":
+		return true
+	default:
+		return s.terminal()
+	}
+}
+
+// slurmCLI wraps the Slurm tools used by the resource manager.
+type slurmCLI struct {
+	runner commandRunner
+}
+
+// submit submits the batch script at scriptPath and returns the ID of the new job.
+func (c slurmCLI) submit(ctx context.Context, scriptPath string) (string, error) {
+	out, err := c.runner.run(ctx, "sbatch", "--parsable", scriptPath)
+	if err != nil {
+		return "", err
+	}
+	// The output is "jobid" or "jobid;cluster" on federated clusters.
+	jobID, _, _ := strings.Cut(strings.TrimSpace(string(out)), ";")
+	if _, err := strconv.ParseUint(jobID, 10, 64); err != nil {
+		return "", fmt.Errorf("unexpected sbatch output %q", out)
+	}
+	return jobID, nil
+}
+
+// queueStates returns the states of the given jobs known to squeue. Jobs that have left the
+// queue are missing from the result.
+func (c slurmCLI) queueStates(ctx context.Context, jobIDs []string) (map[string]jobState, error) {
+	out, err := c.runner.run(ctx, "squeue", "--noheader", "--states=all",
+		"--format=%i|%T|%r", "--jobs="+strings.Join(jobIDs, ","))
+	if err != nil {
+		// squeue fails outright if it knows none of the jobs any more.
+		if strings.Contains(err.Error(), "Invalid job id") {
+			return map[string]jobState{}, nil
+		}
+		return nil, err
+	}
+	states := make(map[string]jobState)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) < 3 {
+			continue
+		}
+		states[fields[0]] = jobState{state: fields[1], reason: fields[2]}
+	}
+	return states, nil
+}
+
+// accountingStates returns the states and exit codes of the given jobs recorded by sacct.
+func (c slurmCLI) accountingStates(
+	ctx context.Context, jobIDs []string,
+) (map[string]jobState, error) {
+	out, err := c.runner.run(ctx, "sacct", "--noheader", "--parsable2", "--allocations",
+		"--format=JobID,State,ExitCode", "--jobs="+strings.Join(jobIDs, ","))
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]jobState)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) < 3 {
+			continue
+		}
+		// Cancelled jobs are reported as "CANCELLED by <uid>".
+		state := jobState{state: strings.Fields(fields[1] + " ")[0]}
+		code, signal, _ := strings.Cut(fields[2], ":")
+		state.exitCode, _ = strconv.Atoi(code)
+		state.signal, _ = strconv.Atoi(signal)
+		states[fields[0]] = state
+	}
+	return states, nil
+}
+
+// cancel cancels the given job.
+func (c slurmCLI) cancel(ctx context.Context, jobID string) error {
+	_, err := c.runner.run(ctx, "scancel", jobID)
+	return err
+}
@@ -0,0 +1,92 @@
+package slurmrm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeShim installs a fake Slurm tool in dir that records its arguments in <name>.args and runs
+// the given shell snippet.
+func writeShim(t *testing.T, dir, name, body string) {
+	script := "#!/bin/sh\necho \"$@\" >> " + filepath.Join(dir, name+".args") + "\n" + body + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0o700)) //nolint:gosec
+}
+
+func shimArgs(t *testing.T, dir, name string) []string {
+	out, err := os.ReadFile(filepath.Join(dir, name+".args")) //nolint:gosec
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(out)), "\n")
+}
+
+func TestSlurmCLI(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cli := slurmCLI{runner: execRunner{binDir: dir}}
+
+	writeShim(t, dir, "sbatch", "echo '1234;cluster'")
+	jobID, err := cli.submit(ctx, "/jobs/1/job.sh")
+	require.NoError(t, err)
+	require.Equal(t, "1234", jobID)
+	require.Equal(t, []string{"--parsable /jobs/1/job.sh"}, shimArgs(t, dir, "sbatch"))
+
+	writeShim(t, dir, "sbatch", "echo 'sbatch: error: invalid partition' >&2; exit 1")
+	_, err = cli.submit(ctx, "/jobs/1/job.sh")
+	require.ErrorContains(t, err, "invalid partition")
+
+	writeShim(t, dir, "squeue", "printf '1234|RUNNING|None\\n1235|PENDING|Priority\\n'")
+	states, err := cli.queueStates(ctx, []string{"1234", "1235"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]jobState{
+		"1234": {state: stateRunning, reason: "None"},
+		"1235": {state: statePending, reason: "Priority"},
+	}, states)
+	require.Equal(t,
+		[]string{"--noheader --states=all --format=%i|%T|%r --jobs=1234,1235"},
+		shimArgs(t, dir, "squeue"))
+
+	writeShim(t, dir, "squeue",
+		"echo 'slurm_load_jobs error: Invalid job id specified' >&2; exit 1")
+	states, err = cli.queueStates(ctx, []string{"1234"})
+	require.NoError(t, err)
+	require.Empty(t, states)
+
+	writeShim(t, dir, "sacct",
+		"printf '1234|COMPLETED|0:0\\n1235|CANCELLED by 1000|0:15\\n1236|FAILED|2:0\\n'")
+	states, err = cli.accountingStates(ctx, []string{"1234", "1235", "1236"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]jobState{
+		"1234": {state: stateCompleted},
+		"1235": {state: stateCancelled, signal: 15},
+		"1236": {state: stateFailed, exitCode: 2},
+	}, states)
+
+	writeShim(t, dir, "scancel", "")
+	require.NoError(t, cli.cancel(ctx, "1234"))
+	require.Equal(t, []string{"1234"}, shimArgs(t, dir, "scancel"))
+}
+
+func TestJobState(t *testing.T) {
+	for _, tt := range []struct {
+		state    string
+		terminal bool
+		started  bool
+	}{
+		{state: statePending},
+		{state: "CONFIGURING"},
+		{state: stateRunning, started: true},
+		{state: "COMPLETING", started: true},
+		{state: stateCompleted, terminal: true, started: true},
+		{state: stateCancelled, terminal: true, started: true},
+		{state: statePreempted, terminal: true, started: true},
+		{state: stateOutOfMemory, terminal: true, started: true},
+	} {
+		s := jobState{state: tt.state}
+		require.Equal(t, tt.terminal, s.terminal(), tt.state)
+		require.Equal(t, tt.started, s.started(), tt.state)
+	}
+}
@@ -0,0 +1,905 @@
+package slurmrm
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/determined-ai/determined/master/internal/api"
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/rm"
+	"github.com/determined-ai/determined/master/internal/rm/rmerrors"
+	"github.com/determined-ai/determined/master/internal/rm/rmevents"
+	"github.com/determined-ai/determined/master/internal/rm/rmutils"
+	"github.com/determined-ai/determined/master/internal/rm/tasklist"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/command"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/jobv1"
+	"github.com/determined-ai/determined/proto/pkg/resourcepoolv1"
+)
+
+const (
+	defaultPoolName = "default"
+	// How many consecutive polls a job may be unknown to both squeue and sacct before it is
+	// considered lost.
+	maxMissingPolls = 3
+	// How many lines of the job output are shown in the task logs when a job fails.
+	outputTailLines = 20
+	// Timeout of a single invocation of a Slurm command.
+	commandTimeout = time.Minute
+)
+
+var errNotSupportedOnSlurm = fmt.Errorf("%w on Slurm clusters", rmerrors.ErrNotSupported)
+
+// SlurmResourceManager runs tasks as Slurm batch jobs using the Slurm command line tools
+// available on the master host, without the HPC launcher. Slurm does all the scheduling: every
+// allocation is handed resources right away, and its job waits in the Slurm queue instead.
+type SlurmResourceManager struct {
+	// system dependencies.
+	syslog *logrus.Entry
+	db     *db.PgDB
+	cli    slurmCLI
+
+	// static configuration.
+	rmConfig        *config.SlurmResourceManagerConfig
+	poolConfig      []config.ResourcePoolConfig
+	poolPartitions  map[string]string
+	masterTLSConfig model.TLSClientConfig
+
+	// mutable state, guarded by mu.
+	mu      sync.Mutex
+	reqList *tasklist.TaskList
+	groups  map[model.JobID]*tasklist.Group
+	jobs    map[model.AllocationID]*slurmJob
+}
+
+// slurmJob tracks the Slurm job backing an allocation.
+type slurmJob struct {
+	allocationID model.AllocationID
+	resourcesID  sproto.ResourcesID
+	jobDir       string
+	// jobID is empty until sbatch returns.
+	jobID        string
+	state        jobState
+	killed       bool
+	done         bool
+	missingPolls int
+
+	totalContainers   int
+	runningContainers map[int32]string
+}
+
+func (j *slurmJob) allContainersRunning() bool {
+	return len(j.runningContainers) > 0 && len(j.runningContainers) == j.totalContainers
+}
+
+// New returns a new native Slurm resource manager.
+func New(
+	db *db.PgDB,
+	cfg *config.ResourceManagerWithPoolsConfig,
+	cert *tls.Certificate,
+) (*SlurmResourceManager, error) {
+	tlsConfig, err := model.MakeTLSConfig(cert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up TLS config: %w", err)
+	}
+	rmConfig := cfg.ResourceManager.SlurmRM
+	if err := os.MkdirAll(rmConfig.JobStorageRoot, 0o700); err != nil {
+		return nil, fmt.Errorf("creating job_storage_root: %w", err)
+	}
+
+	m := newSlurmResourceManager(db, rmConfig, cfg.ResourcePools,
+		execRunner{binDir: rmConfig.SlurmBinDir})
+	m.masterTLSConfig = tlsConfig
+
+	m.syslog.Info("starting native slurm resource manager")
+	go m.periodicallyPollJobs()
+	return m, nil
+}
+
+func newSlurmResourceManager(
+	db *db.PgDB,
+	rmConfig *config.SlurmResourceManagerConfig,
+	poolConfig []config.ResourcePoolConfig,
+	runner commandRunner,
+) *SlurmResourceManager {
+	// Each resource pool submits to the partition named after it, unless it names another one
+	// with an hpc provider. Without any pools, jobs go to the default partition of the cluster.
+	poolPartitions := make(map[string]string)
+	for _, pool := range poolConfig {
+		poolPartitions[pool.PoolName] = pool.PoolName
+		if pool.Provider != nil && pool.Provider.HPC != nil {
+			poolPartitions[pool.PoolName] = pool.Provider.HPC.Partition
+		}
+	}
+	if len(poolConfig) == 0 {
+		poolConfig = []config.ResourcePoolConfig{{PoolName: defaultPoolName}}
+		poolPartitions[defaultPoolName] = ""
+	}
+
+	return &SlurmResourceManager{
+		syslog: logrus.WithField("component", "slurmrm"),
+		db:     db,
+		cli:    slurmCLI{runner: runner},
+
+		rmConfig:       rmConfig,
+		poolConfig:     poolConfig,
+		poolPartitions: poolPartitions,
+
+		reqList: tasklist.New(),
+		groups:  make(map[model.JobID]*tasklist.Group),
+		jobs:    make(map[model.AllocationID]*slurmJob),
+	}
+}
+
+// Allocate implements rm.ResourceManager. Resources are assigned immediately, since the request
+// is queued by Slurm once the allocation starts them.
+func (m *SlurmResourceManager) Allocate(
+	msg sproto.AllocateRequest,
+) (*sproto.ResourcesSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub := rmevents.Subscribe(msg.AllocationID)
+	m.getOrCreateGroup(msg.JobID)
+	req := &msg
+	m.reqList.AddTask(req)
+	m.syslog.WithField("name", req.Name).
+		WithField("allocation-id", req.AllocationID).
+		Info("resources are requested")
+	m.assignResources(req)
+	return sub, nil
+}
+
+func (m *SlurmResourceManager) assignResources(req *sproto.AllocateRequest) {
+	var restored *db.Dispatch
+	if req.Restore && m.db != nil {
+		dispatches, err := db.ListDispatchesByAllocationID(context.TODO(), req.AllocationID)
+		if err != nil {
+			m.syslog.WithField("allocation-id", req.AllocationID).
+				WithError(err).Error("failed to retrieve slurm jobs")
+		} else if len(dispatches) > 0 {
+			restored = dispatches[0]
+		}
+	}
+
+	rID := sproto.ResourcesID(uuid.NewString())
+	if restored != nil {
+		rID = restored.ResourceID
+	}
+	assigned := sproto.ResourcesAllocated{
+		ID: req.AllocationID,
+		Resources: sproto.ResourceList{
+			rID: &slurmResources{id: rID, req: req, rm: m},
+		},
+	}
+	m.reqList.AddAllocationRaw(req.AllocationID, &assigned)
+	rmevents.Publish(req.AllocationID, assigned.Clone())
+
+	if !req.Restore {
+		m.syslog.WithField("allocation-id", req.AllocationID).Info("resources assigned")
+		return
+	}
+	if restored == nil {
+		m.syslog.WithField("allocation-id", req.AllocationID).
+			Info("restore request with no slurm job found, failing the allocation")
+		m.publishStopped(req.AllocationID, rID, sproto.NewResourcesFailure(
+			sproto.ResourcesAborted, "Unable to locate Slurm job on restart.", nil))
+		return
+	}
+	m.syslog.WithField("allocation-id", req.AllocationID).
+		WithField("slurm-job-id", restored.DispatchID).
+		Info("reconnecting to slurm job")
+	m.jobs[req.AllocationID] = &slurmJob{
+		allocationID:      req.AllocationID,
+		resourcesID:       rID,
+		jobDir:            m.jobDir(req.AllocationID),
+		jobID:             restored.DispatchID,
+		runningContainers: make(map[int32]string),
+	}
+}
+
+// Release implements rm.ResourceManager.
+func (m *SlurmResourceManager) Release(msg sproto.ResourcesReleased) {
+	if msg.ResourcesID != nil {
+		// Partial releases do not apply, since Slurm owns the resources of the job.
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if job, ok := m.jobs[msg.AllocationID]; ok {
+		// Never leave a job behind in the Slurm queue once its allocation is gone.
+		if job.jobID != "" && !job.done {
+			go m.cancelJob(job.jobID)
+		}
+		delete(m.jobs, msg.AllocationID)
+	}
+	if req := m.reqList.RemoveTaskByID(msg.AllocationID); req != nil {
+		m.syslog.WithField("name", req.Name).
+			WithField("allocation-id", msg.AllocationID).
+			Info("resources are released")
+	}
+	rmevents.Publish(msg.AllocationID, sproto.ResourcesReleasedEvent{})
+}
+
+// startJob submits the Slurm job for the resources in the background.
+func (m *SlurmResourceManager) startJob(r *slurmResources, spec tasks.TaskSpec) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := &slurmJob{
+		allocationID:      r.req.AllocationID,
+		resourcesID:       r.id,
+		jobDir:            m.jobDir(r.req.AllocationID),
+		runningContainers: make(map[int32]string),
+	}
+	m.jobs[r.req.AllocationID] = job
+	go m.submitJob(job, r.req, spec)
+}
+
+func (m *SlurmResourceManager) submitJob(
+	job *slurmJob, req *sproto.AllocateRequest, spec tasks.TaskSpec,
+) {
+	log := m.syslog.WithField("allocation-id", req.AllocationID)
+	log.WithField("description", spec.Description).Info("received request to launch job")
+
+	slotType := device.CPU
+	// Checkpoint GC tasks request zero slots and always run on CPUs.
+	if req.SlotsNeeded > 0 {
+		slotType = device.CUDA
+		if m.rmConfig.SlotType != nil {
+			slotType = *m.rmConfig.SlotType
+		}
+	}
+
+	m.mu.Lock()
+	disabledNodes := append([]string{}, req.BlockedNodes...)
+	m.mu.Unlock()
+
+	script, err := spec.ToSlurmBatchScript(log, tasks.SlurmBatchScriptOptions{
+		AllocationID:     string(req.AllocationID),
+		JobDir:           job.jobDir,
+		TLSEnabled:       m.masterTLSConfig.Enabled,
+		MasterHost:       m.rmConfig.MasterHost,
+		MasterPort:       m.rmConfig.MasterPort,
+		CertificateName:  m.masterTLSConfig.CertificateName,
+		NumSlots:         req.SlotsNeeded,
+		SlotType:         slotType,
+		Partition:        m.poolPartitions[req.ResourcePool],
+		TresSupported:    m.rmConfig.TresSupported,
+		GresSupported:    m.rmConfig.GresSupported,
+		ContainerRunType: m.rmConfig.ContainerRunType,
+		JobProjectSource: m.rmConfig.JobProjectSource,
+		ExcludedNodes:    disabledNodes,
+	})
+	if err != nil {
+		m.failJob(job, err, "unable to create the batch script")
+		return
+	}
+	if err := os.MkdirAll(job.jobDir, 0o700); err != nil {
+		m.failJob(job, err, "unable to create the job directory")
+		return
+	}
+	scriptPath := filepath.Join(job.jobDir, "job.sh")
+	if err := os.WriteFile(scriptPath, []byte(script), 0o600); err != nil {
+		m.failJob(job, err, "unable to write the batch script")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	jobID, err := m.cli.submit(ctx, scriptPath)
+	if err != nil {
+		m.failJob(job, err, "unable to submit the slurm job")
+		return
+	}
+	log = log.WithField("slurm-job-id", jobID)
+	log.Info("slurm job submitted")
+
+	if m.db != nil {
+		if err := db.InsertDispatch(context.TODO(), &db.Dispatch{
+			DispatchID:   jobID,
+			ResourceID:   job.resourcesID,
+			AllocationID: job.allocationID,
+		}); err != nil {
+			log.WithError(err).Error("failed to persist slurm job")
+		}
+	}
+
+	m.mu.Lock()
+	job.jobID = jobID
+	killed := job.killed
+	m.mu.Unlock()
+
+	msg := "Slurm job ID: " + jobID
+	rmevents.Publish(job.allocationID, &sproto.ContainerLog{AuxMessage: &msg})
+	if killed {
+		m.cancelJob(jobID)
+	}
+}
+
+// failJob fails an allocation whose job could not be submitted.
+func (m *SlurmResourceManager) failJob(job *slurmJob, err error, msg string) {
+	m.syslog.WithField("allocation-id", job.allocationID).WithError(err).Error(msg)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.done = true
+	m.publishStopped(job.allocationID, job.resourcesID, sproto.NewResourcesFailure(
+		sproto.ResourcesFailed, fmt.Sprintf("%s: %s", msg, err), nil))
+}
+
+// killJob cancels the Slurm job for the resources, or makes sure it is canceled as soon as it has
+// been submitted.
+func (m *SlurmResourceManager) killJob(allocationID model.AllocationID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[allocationID]
+	if !ok {
+		m.syslog.WithField("allocation-id", allocationID).Info("no slurm job to terminate")
+		return
+	}
+	job.killed = true
+	if job.jobID != "" && !job.done {
+		go m.cancelJob(job.jobID)
+	}
+}
+
+func (m *SlurmResourceManager) cancelJob(jobID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	log := m.syslog.WithField("slurm-job-id", jobID)
+	log.Info("canceling slurm job")
+	if err := m.cli.cancel(ctx, jobID); err != nil {
+		log.WithError(err).Error("failed to cancel slurm job")
+	}
+}
+
+func (m *SlurmResourceManager) periodicallyPollJobs() {
+	t := time.NewTicker(time.Duration(m.rmConfig.PollInterval))
+	defer t.Stop()
+	for range t.C {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		if err := m.pollJobs(ctx); err != nil {
+			m.syslog.WithError(err).Warn("failed to poll slurm jobs")
+		}
+		cancel()
+	}
+}
+
+// pollJobs refreshes the state of all submitted jobs from squeue, falling back to sacct for jobs
+// that have left the queue, and propagates any changes to their allocations.
+func (m *SlurmResourceManager) pollJobs(ctx context.Context) error {
+	m.mu.Lock()
+	var jobIDs []string
+	for _, job := range m.jobs {
+		if job.jobID != "" && !job.done {
+			jobIDs = append(jobIDs, job.jobID)
+		}
+	}
+	m.mu.Unlock()
+	if len(jobIDs) == 0 {
+		return nil
+	}
+
+	states, err := m.cli.queueStates(ctx, jobIDs)
+	if err != nil {
+		return err
+	}
+	var finished []string
+	for _, jobID := range jobIDs {
+		if state, ok := states[jobID]; !ok || state.terminal() {
+			finished = append(finished, jobID)
+		}
+	}
+	if len(finished) > 0 {
+		accounted, err := m.cli.accountingStates(ctx, finished)
+		if err != nil {
+			return err
+		}
+		for _, jobID := range finished {
+			if state, ok := accounted[jobID]; ok {
+				states[jobID] = state
+			} else {
+				delete(states, jobID)
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if job.jobID == "" || job.done {
+			continue
+		}
+		state, ok := states[job.jobID]
+		if !ok {
+			job.missingPolls++
+			if job.missingPolls >= maxMissingPolls {
+				m.finishJob(job, jobState{state: stateFailed}, fmt.Sprintf(
+					"Slurm job %s is no longer known to squeue or sacct", job.jobID))
+			}
+			continue
+		}
+		job.missingPolls = 0
+		m.updateJob(job, state)
+	}
+	return nil
+}
+
+// updateJob propagates a newly observed state of the job to its allocation.
+func (m *SlurmResourceManager) updateJob(job *slurmJob, state jobState) {
+	if state.terminal() {
+		m.finishJob(job, state, "")
+		return
+	}
+	if state == job.state {
+		return
+	}
+	job.state = state
+
+	if req, ok := m.reqList.TaskByID(job.allocationID); ok {
+		req.State = sproto.SchedulingStateQueued
+		if state.started() {
+			req.State = sproto.SchedulingStateScheduled
+		}
+	}
+	m.publishState(job)
+}
+
+func (m *SlurmResourceManager) publishState(job *slurmJob) {
+	// Slurm has no notion of pulling images, so report the job as pulling until all of its
+	// containers have told us that they run.
+	resourcesState := sproto.Assigned
+	switch {
+	case job.state.started() && job.allContainersRunning():
+		resourcesState = sproto.Running
+	case job.state.started():
+		resourcesState = sproto.Pulling
+	}
+	rmevents.Publish(job.allocationID, &sproto.ResourcesStateChanged{
+		ResourcesID:      job.resourcesID,
+		ResourcesState:   resourcesState,
+		ResourcesStarted: &sproto.ResourcesStarted{},
+	})
+}
+
+// finishJob terminates the allocation of a job that reached a terminal state.
+func (m *SlurmResourceManager) finishJob(job *slurmJob, state jobState, msg string) {
+	log := m.syslog.WithField("allocation-id", job.allocationID).
+		WithField("slurm-job-id", job.jobID)
+	log.WithField("state", state.state).
+		Infof("slurm job exited with exit code %d", state.exitCode)
+	job.state = state
+	job.done = true
+
+	var failure *sproto.ResourcesFailedError
+	switch {
+	case job.killed:
+	case state.state == stateCompleted && state.exitCode == 0:
+	default:
+		if msg == "" {
+			msg = fmt.Sprintf("Slurm job %s ended in state %s", job.jobID, state.state)
+		}
+		var code *sproto.ExitCode
+		if state.exitCode > 0 {
+			code = ptrs.Ptr(sproto.ExitCode(state.exitCode))
+		}
+		failure = sproto.NewResourcesFailure(sproto.ResourcesFailed, msg, code)
+
+		if tail := m.outputTail(job); tail != "" {
+			rmevents.Publish(job.allocationID, &sproto.ContainerLog{
+				AuxMessage: &tail,
+				Level:      ptrs.Ptr("ERROR"),
+			})
+		}
+	}
+	m.publishStopped(job.allocationID, job.resourcesID, failure)
+
+	if m.db != nil {
+		if _, err := db.DeleteDispatch(context.TODO(), job.jobID); err != nil {
+			log.WithError(err).Error("failed to delete slurm job")
+		}
+	}
+	// Keep the job directory around for troubleshooting when debugging.
+	if m.syslog.Logger.Level < logrus.DebugLevel {
+		if err := os.RemoveAll(job.jobDir); err != nil {
+			log.WithError(err).Warn("failed to remove job directory")
+		}
+	}
+}
+
+// outputTail returns the last lines written by the batch script, which hold the errors of jobs
+// that failed before their containers started shipping logs.
+func (m *SlurmResourceManager) outputTail(job *slurmJob) string {
+	output, err := os.ReadFile(filepath.Join(job.jobDir, "slurm-"+job.jobID+".out"))
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) > outputTailLines {
+		lines = lines[len(lines)-outputTailLines:]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func (m *SlurmResourceManager) publishStopped(
+	allocationID model.AllocationID, rID sproto.ResourcesID, failure *sproto.ResourcesFailedError,
+) {
+	rmevents.Publish(allocationID, &sproto.ResourcesStateChanged{
+		ResourcesID:      rID,
+		ResourcesState:   sproto.Terminated,
+		ResourcesStopped: &sproto.ResourcesStopped{Failure: failure},
+	})
+}
+
+func (m *SlurmResourceManager) jobDir(allocationID model.AllocationID) string {
+	return filepath.Join(m.rmConfig.JobStorageRoot, string(allocationID))
+}
+
+// NotifyContainerRunning implements rm.ResourceManager.
+func (m *SlurmResourceManager) NotifyContainerRunning(msg sproto.NotifyContainerRunning) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[msg.AllocationID]
+	if !ok {
+		m.syslog.WithField("allocation-id", msg.AllocationID).
+			Warn("NotifyContainerRunning did not find an active slurm job")
+		return nil
+	}
+	wasRunning := job.allContainersRunning()
+	job.totalContainers = int(msg.NumPeers)
+	job.runningContainers[msg.Rank] = msg.NodeName
+	if job.totalContainers > 1 {
+		started := fmt.Sprintf("%d out of %d containers running",
+			len(job.runningContainers), job.totalContainers)
+		rmevents.Publish(job.allocationID, &sproto.ContainerLog{AuxMessage: &started})
+	}
+	if !wasRunning && job.allContainersRunning() && job.state.started() && !job.done {
+		m.publishState(job)
+	}
+	return nil
+}
+
+func (m *SlurmResourceManager) getOrCreateGroup(jobID model.JobID) *tasklist.Group {
+	if g, ok := m.groups[jobID]; ok {
+		return g
+	}
+
+	priority := config.KubernetesDefaultPriority
+	g := &tasklist.Group{JobID: jobID, Weight: 1, Priority: &priority}
+	m.groups[jobID] = g
+	tasklist.GroupPriorityChangeRegistry.OnDelete(jobID, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.groups, jobID)
+	})
+	return g
+}
+
+// GetAllocationSummaries implements rm.ResourceManager.
+func (m *SlurmResourceManager) GetAllocationSummaries() (
+	map[model.AllocationID]sproto.AllocationSummary, error,
+) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reqList.TaskSummaries(m.groups, "slurm"), nil
+}
+
+// ValidateResources implements rm.ResourceManager.
+func (*SlurmResourceManager) ValidateResources(
+	sproto.ValidateResourcesRequest,
+) ([]command.LaunchWarning, error) {
+	return nil, nil
+}
+
+// DeleteJob implements rm.ResourceManager. Job directories are removed when jobs finish.
+func (*SlurmResourceManager) DeleteJob(sproto.DeleteJob) (sproto.DeleteJobResponse, error) {
+	return sproto.EmptyDeleteJobResponse(), nil
+}
+
+// SetGroupMaxSlots implements rm.ResourceManager.
+func (m *SlurmResourceManager) SetGroupMaxSlots(msg sproto.SetGroupMaxSlots) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getOrCreateGroup(msg.JobID).MaxSlots = msg.MaxSlots
+}
+
+// SetGroupWeight implements rm.ResourceManager.
+func (*SlurmResourceManager) SetGroupWeight(sproto.SetGroupWeight) error {
+	return rmerrors.UnsupportedError("set group weight unsupported in the slurm RM")
+}
+
+// SetGroupPriority implements rm.ResourceManager.
+func (*SlurmResourceManager) SetGroupPriority(sproto.SetGroupPriority) error {
+	return rmerrors.UnsupportedError("set group priority unsupported in the slurm RM")
+}
+
+// IsReattachableOnlyAfterStarted implements rm.ResourceManager.
+func (*SlurmResourceManager) IsReattachableOnlyAfterStarted() bool {
+	return false
+}
+
+// SmallerValueIsHigherPriority implements rm.ResourceManager.
+func (*SlurmResourceManager) SmallerValueIsHigherPriority() (bool, error) {
+	return false, fmt.Errorf("priority not implemented")
+}
+
+// GetResourcePools implements rm.ResourceManager.
+func (m *SlurmResourceManager) GetResourcePools() (*apiv1.GetResourcePoolsResponse, error) {
+	slotType := device.CUDA
+	if m.rmConfig.SlotType != nil {
+		slotType = *m.rmConfig.SlotType
+	}
+	defaultCompute, _ := m.GetDefaultComputeResourcePool()
+	defaultAux, _ := m.GetDefaultAuxResourcePool()
+
+	var pools []*resourcepoolv1.ResourcePool
+	for _, pool := range m.poolConfig {
+		description := pool.Description
+		if description == "" {
+			description = "Slurm-managed pool of resources"
+		}
+		pools = append(pools, &resourcepoolv1.ResourcePool{
+			Name:                    pool.PoolName,
+			Description:             description,
+			Type:                    resourcepoolv1.ResourcePoolType_RESOURCE_POOL_TYPE_STATIC,
+			SlotType:                slotType.Proto(),
+			DefaultComputePool:      pool.PoolName == defaultCompute.String(),
+			DefaultAuxPool:          pool.PoolName == defaultAux.String(),
+			Preemptible:             true,
+			SchedulerType:           resourcepoolv1.SchedulerType_SCHEDULER_TYPE_SLURM,
+			SchedulerFittingPolicy:  resourcepoolv1.FittingPolicy_FITTING_POLICY_SLURM,
+			Details:                 &resourcepoolv1.ResourcePoolDetail{},
+			ClusterName:             m.rmConfig.ClusterName,
+			ResourceManagerMetadata: m.rmConfig.Metadata,
+		})
+	}
+	return &apiv1.GetResourcePoolsResponse{ResourcePools: pools}, nil
+}
+
+// GetDefaultComputeResourcePool implements rm.ResourceManager.
+func (m *SlurmResourceManager) GetDefaultComputeResourcePool() (rm.ResourcePoolName, error) {
+	if m.rmConfig.DefaultComputeResourcePool != "" {
+		return rm.ResourcePoolName(m.rmConfig.DefaultComputeResourcePool), nil
+	}
+	return rm.ResourcePoolName(m.poolConfig[0].PoolName), nil
+}
+
+// GetDefaultAuxResourcePool implements rm.ResourceManager.
+func (m *SlurmResourceManager) GetDefaultAuxResourcePool() (rm.ResourcePoolName, error) {
+	if m.rmConfig.DefaultAuxResourcePool != "" {
+		return rm.ResourcePoolName(m.rmConfig.DefaultAuxResourcePool), nil
+	}
+	return rm.ResourcePoolName(m.poolConfig[0].PoolName), nil
+}
+
+// ValidateResourcePool implements rm.ResourceManager.
+func (m *SlurmResourceManager) ValidateResourcePool(name rm.ResourcePoolName) error {
+	if _, ok := m.poolPartitions[name.String()]; !ok {
+		return fmt.Errorf("resource pool not found: %s", name)
+	}
+	return nil
+}
+
+// ResolveResourcePool implements rm.ResourceManager.
+func (m *SlurmResourceManager) ResolveResourcePool(
+	name rm.ResourcePoolName, workspace, slots int,
+) (rm.ResourcePoolName, error) {
+	ctx := context.TODO()
+	defaultComputePool, defaultAuxPool, err := db.GetDefaultPoolsForWorkspace(ctx, workspace)
+	if err != nil {
+		return "", err
+	}
+
+	// If the resource pool isn't set, fill in the default at creation time.
+	if name == "" && slots == 0 {
+		if defaultAuxPool == "" {
+			name, _ = m.GetDefaultAuxResourcePool()
+		} else {
+			name = rm.ResourcePoolName(defaultAuxPool)
+		}
+	}
+	if name == "" && slots >= 0 {
+		if defaultComputePool == "" {
+			name, _ = m.GetDefaultComputeResourcePool()
+		} else {
+			name = rm.ResourcePoolName(defaultComputePool)
+		}
+	}
+
+	resp, err := m.GetResourcePools()
+	if err != nil {
+		return "", err
+	}
+	poolNames, _, err := db.ReadRPsAvailableToWorkspace(
+		ctx, int32(workspace), 0, -1, rmutils.ResourcePoolsToConfig(resp.ResourcePools))
+	if err != nil {
+		return "", err
+	}
+	for _, poolName := range poolNames {
+		if name.String() == poolName {
+			return name, m.ValidateResourcePool(name)
+		}
+	}
+	return "", fmt.Errorf(
+		"resource pool %s does not exist or is not available to workspace id %d",
+		name, workspace)
+}
+
+// TaskContainerDefaults implements rm.ResourceManager.
+func (m *SlurmResourceManager) TaskContainerDefaults(
+	resourcePoolName rm.ResourcePoolName,
+	defaultConfig model.TaskContainerDefaultsConfig,
+) (model.TaskContainerDefaultsConfig, error) {
+	for _, pool := range m.poolConfig {
+		if pool.PoolName == resourcePoolName.String() && pool.TaskContainerDefaults != nil {
+			return defaultConfig.Merge(*pool.TaskContainerDefaults)
+		}
+	}
+	return defaultConfig, nil
+}
+
+// GetJobQ implements rm.ResourceManager.
+func (m *SlurmResourceManager) GetJobQ(
+	rpName rm.ResourcePoolName,
+) (map[model.JobID]*sproto.RMJobInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rpName == "" {
+		rpName, _ = m.GetDefaultComputeResourcePool()
+	}
+	var reqs []*sproto.AllocateRequest
+	for it := m.reqList.Iterator(); it.Next(); {
+		if it.Value().ResourcePool == rpName.String() {
+			reqs = append(reqs, it.Value())
+		}
+	}
+	return tasklist.ReduceToJobQInfo(reqs), nil
+}
+
+// GetJobQueueStatsRequest implements rm.ResourceManager.
+func (m *SlurmResourceManager) GetJobQueueStatsRequest(
+	msg *apiv1.GetJobQueueStatsRequest,
+) (*apiv1.GetJobQueueStatsResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	resourcePools := msg.ResourcePools
+	if len(resourcePools) == 0 {
+		for _, pool := range m.poolConfig {
+			resourcePools = append(resourcePools, pool.PoolName)
+		}
+	}
+	var resp apiv1.GetJobQueueStatsResponse
+	for _, resourcePool := range resourcePools {
+		resp.Results = append(resp.Results, &apiv1.RPQueueStat{
+			Stats:        tasklist.JobStatsByPool(m.reqList, resourcePool),
+			ResourcePool: resourcePool,
+		})
+	}
+	return &resp, nil
+}
+
+// RecoverJobPosition implements rm.ResourceManager.
+func (m *SlurmResourceManager) RecoverJobPosition(sproto.RecoverJobPosition) {
+	m.syslog.Warn("move job unsupported in the slurm RM")
+}
+
+// GetExternalJobs implements rm.ResourceManager.
+func (*SlurmResourceManager) GetExternalJobs(rm.ResourcePoolName) ([]*jobv1.Job, error) {
+	return nil, rmerrors.ErrNotSupported
+}
+
+// HealthCheck checks that the Slurm controller answers squeue.
+func (m *SlurmResourceManager) HealthCheck() []model.ResourceManagerHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	health := model.Healthy
+	if _, err := m.cli.runner.run(ctx, "squeue", "--noheader", "--jobs=0"); err != nil &&
+		!strings.Contains(err.Error(), "Invalid job id") {
+		m.syslog.WithError(err).Error("slurm resource manager marked as unhealthy")
+		health = model.Unhealthy
+	}
+	return []model.ResourceManagerHealth{{ClusterName: m.rmConfig.ClusterName, Status: health}}
+}
+
+// GetAgents implements rm.ResourceManager. Compute nodes are not tracked as agents.
+func (*SlurmResourceManager) GetAgents() (*apiv1.GetAgentsResponse, error) {
+	return &apiv1.GetAgentsResponse{}, nil
+}
+
+// GetAgent implements rm.ResourceManager.
+func (*SlurmResourceManager) GetAgent(msg *apiv1.GetAgentRequest) (*apiv1.GetAgentResponse, error) {
+	return nil, api.NotFoundErrs("agent", msg.AgentId, true)
+}
+
+// EnableAgent is unsupported.
+func (*SlurmResourceManager) EnableAgent(*apiv1.EnableAgentRequest) (*apiv1.EnableAgentResponse, error) {
+	return nil, errNotSupportedOnSlurm
+}
+
+// DisableAgent is unsupported.
+func (*SlurmResourceManager) DisableAgent(
+	*apiv1.DisableAgentRequest,
+) (*apiv1.DisableAgentResponse, error) {
+	return nil, errNotSupportedOnSlurm
+}
+
+// GetSlots is unsupported.
+func (*SlurmResourceManager) GetSlots(*apiv1.GetSlotsRequest) (*apiv1.GetSlotsResponse, error) {
+	return nil, errNotSupportedOnSlurm
+}
+
+// GetSlot is unsupported.
+func (*SlurmResourceManager) GetSlot(*apiv1.GetSlotRequest) (*apiv1.GetSlotResponse, error) {
+	return nil, errNotSupportedOnSlurm
+}
+
+// EnableSlot is unsupported.
+func (*SlurmResourceManager) EnableSlot(*apiv1.EnableSlotRequest) (*apiv1.EnableSlotResponse, error) {
+	return nil, errNotSupportedOnSlurm
+}
+
+// DisableSlot is unsupported.
+func (*SlurmResourceManager) DisableSlot(*apiv1.DisableSlotRequest) (*apiv1.DisableSlotResponse, error) {
+	return nil, errNotSupportedOnSlurm
+}
+
+// DefaultNamespace is unsupported.
+func (*SlurmResourceManager) DefaultNamespace(string) (*string, error) {
+	return nil, status.Error(codes.NotFound, rmerrors.ErrNotSupported.Error())
+}
+
+// VerifyNamespaceExists is unsupported.
+func (*SlurmResourceManager) VerifyNamespaceExists(string, string) error {
+	return rmerrors.ErrNotSupported
+}
+
+// CreateNamespace is unsupported.
+func (*SlurmResourceManager) CreateNamespace(string, string, bool) error {
+	return rmerrors.ErrNotSupported
+}
+
+// DeleteNamespace is a no-op, since it is only called to clean up after deleted workspaces.
+func (*SlurmResourceManager) DeleteNamespace(string) error {
+	return nil
+}
+
+// RemoveEmptyNamespace is unsupported.
+func (*SlurmResourceManager) RemoveEmptyNamespace(string, string) error {
+	return rmerrors.ErrNotSupported
+}
+
+// GetNamespaceResourceQuota is unsupported.
+func (*SlurmResourceManager) GetNamespaceResourceQuota(string, string) (*float64, error) {
+	return nil, status.Error(codes.NotFound, rmerrors.ErrNotSupported.Error())
+}
+
+// SetResourceQuota is unsupported.
+func (*SlurmResourceManager) SetResourceQuota(int, string, string) error {
+	return rmerrors.ErrNotSupported
+}
@@ -0,0 +1,308 @@
+package slurmrm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/config/provconfig"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+	"github.com/determined-ai/determined/proto/pkg/resourcepoolv1"
+)
+
+// fakeRunner stands in for the Slurm tools, answering each command from a canned output.
+type fakeRunner struct {
+	mu      sync.Mutex
+	outputs map[string]string
+	calls   []string
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{outputs: map[string]string{"sbatch": "42"}}
+}
+
+func (f *fakeRunner) set(name, output string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.outputs[name] = output
+}
+
+func (f *fakeRunner) called(name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []string
+	for _, c := range f.calls {
+		if strings.HasPrefix(c, name+" ") {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (f *fakeRunner) run(_ context.Context, name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	output, ok := f.outputs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: command not found", name)
+	}
+	return []byte(output), nil
+}
+
+func newTestRM(t *testing.T, pools []config.ResourcePoolConfig) (*SlurmResourceManager, *fakeRunner) {
+	require.NoError(t, etc.SetRootPath("../../../static/srv/"))
+	runner := newFakeRunner()
+	m := newSlurmResourceManager(nil, &config.SlurmResourceManagerConfig{
+		ClusterName:      "slurm",
+		MasterHost:       "master",
+		MasterPort:       8080,
+		JobStorageRoot:   t.TempDir(),
+		ContainerRunType: "singularity",
+		TresSupported:    true,
+		GresSupported:    true,
+	}, pools, runner)
+	return m, runner
+}
+
+func testTaskSpec() tasks.TaskSpec {
+	image := "determinedai/environments:cuda"
+	return tasks.TaskSpec{
+		AgentUserGroup: &model.AgentUserGroup{User: "determined", Group: "determined"},
+		WorkDir:        tasks.DefaultWorkDir,
+		Environment: expconf.EnvironmentConfigV0{
+			RawImage:                &expconf.EnvironmentImageMapV0{RawCPU: &image, RawCUDA: &image},
+			RawEnvironmentVariables: &expconf.EnvironmentVariablesMap{},
+			RawProxyPorts:           &expconf.ProxyPortsConfigV0{},
+			RawPodSpec:              &expconf.PodSpec{},
+		},
+		ResourcesConfig: schemas.WithDefaults(expconf.ResourcesConfig{}),
+		ExtraEnvVars:    map[string]string{},
+	}
+}
+
+// nextEvent returns the next event of the subscription, failing the test if there is none.
+func nextEvent(t *testing.T, sub *sproto.ResourcesSubscription) sproto.ResourcesEvent {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ev, err := sub.GetWithContext(ctx)
+	require.NoError(t, err)
+	return ev
+}
+
+func requireState(
+	t *testing.T, sub *sproto.ResourcesSubscription, state sproto.ResourcesState,
+) *sproto.ResourcesStateChanged {
+	ev := nextEvent(t, sub)
+	changed, ok := ev.(*sproto.ResourcesStateChanged)
+	require.True(t, ok, "unexpected event %T", ev)
+	require.Equal(t, state, changed.ResourcesState)
+	return changed
+}
+
+func requireLog(t *testing.T, sub *sproto.ResourcesSubscription) string {
+	ev := nextEvent(t, sub)
+	log, ok := ev.(*sproto.ContainerLog)
+	require.True(t, ok, "unexpected event %T", ev)
+	return *log.AuxMessage
+}
+
+// allocateAndStart allocates resources for a new task and submits its Slurm job.
+func allocateAndStart(
+	t *testing.T, m *SlurmResourceManager, slots int,
+) (*sproto.AllocateRequest, *sproto.ResourcesSubscription) {
+	req := &sproto.AllocateRequest{
+		AllocationID:  model.AllocationID(fmt.Sprintf("alloc-%d", time.Now().UnixNano())),
+		TaskID:        "task",
+		JobID:         "job",
+		IsUserVisible: true,
+		Name:          "test",
+		SlotsNeeded:   slots,
+		ResourcePool:  defaultPoolName,
+	}
+	sub, err := m.Allocate(*req)
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+
+	allocated, ok := nextEvent(t, sub).(*sproto.ResourcesAllocated)
+	require.True(t, ok)
+	require.Len(t, allocated.Resources, 1)
+	for _, r := range allocated.Resources {
+		require.NoError(t, r.Start(nil, testTaskSpec(), sproto.ResourcesRuntimeInfo{Token: "t"}))
+	}
+	require.Equal(t, "Slurm job ID: 42", requireLog(t, sub))
+	return req, sub
+}
+
+func TestSlurmJobLifecycle(t *testing.T) {
+	m, runner := newTestRM(t, nil)
+	req, sub := allocateAndStart(t, m, 2)
+
+	jobDir := filepath.Join(m.rmConfig.JobStorageRoot, string(req.AllocationID))
+	script, err := os.ReadFile(filepath.Join(jobDir, "job.sh")) //nolint:gosec
+	require.NoError(t, err)
+	require.Contains(t, string(script), "#SBATCH --gpus=2\n")
+	require.Equal(t, []string{"sbatch --parsable " + filepath.Join(jobDir, "job.sh")},
+		runner.called("sbatch"))
+
+	runner.set("squeue", "42|PENDING|Priority\n")
+	require.NoError(t, m.pollJobs(context.Background()))
+	requireState(t, sub, sproto.Assigned)
+	q, err := m.GetJobQ("")
+	require.NoError(t, err)
+	require.Equal(t, sproto.SchedulingStateQueued, q["job"].State)
+
+	// Unchanged states are not published again.
+	require.NoError(t, m.pollJobs(context.Background()))
+	require.Zero(t, sub.Len())
+
+	runner.set("squeue", "42|RUNNING|None\n")
+	require.NoError(t, m.pollJobs(context.Background()))
+	requireState(t, sub, sproto.Pulling)
+
+	require.NoError(t, m.NotifyContainerRunning(sproto.NotifyContainerRunning{
+		AllocationID: req.AllocationID, Rank: 0, NumPeers: 2, NodeName: "node1",
+	}))
+	require.Equal(t, "1 out of 2 containers running", requireLog(t, sub))
+	require.NoError(t, m.NotifyContainerRunning(sproto.NotifyContainerRunning{
+		AllocationID: req.AllocationID, Rank: 1, NumPeers: 2, NodeName: "node2",
+	}))
+	require.Equal(t, "2 out of 2 containers running", requireLog(t, sub))
+	requireState(t, sub, sproto.Running)
+
+	// Once the job leaves the queue, its exit code comes from the accounting records.
+	require.NoError(t, os.WriteFile(filepath.Join(jobDir, "slurm-42.out"),
+		[]byte("FATAL: image not found\n"), 0o600))
+	runner.set("squeue", "")
+	runner.set("sacct", "42|FAILED|3:0\n")
+	require.NoError(t, m.pollJobs(context.Background()))
+	require.Equal(t, "FATAL: image not found", requireLog(t, sub))
+	stopped := requireState(t, sub, sproto.Terminated).ResourcesStopped
+	require.NotNil(t, stopped.Failure)
+	require.Equal(t, sproto.ResourcesFailed, stopped.Failure.FailureType)
+	require.Equal(t, sproto.ExitCode(3), *stopped.Failure.ExitCode)
+	require.Contains(t, stopped.Failure.ErrMsg, "FAILED")
+	require.NoDirExists(t, jobDir)
+
+	m.Release(sproto.ResourcesReleased{AllocationID: req.AllocationID})
+	require.Equal(t, sproto.ResourcesReleasedEvent{}, nextEvent(t, sub))
+	require.Empty(t, runner.called("scancel"))
+	summaries, err := m.GetAllocationSummaries()
+	require.NoError(t, err)
+	require.Empty(t, summaries)
+}
+
+func TestSlurmJobCompleted(t *testing.T) {
+	m, runner := newTestRM(t, nil)
+	_, sub := allocateAndStart(t, m, 0)
+
+	runner.set("squeue", "42|COMPLETED|None\n")
+	runner.set("sacct", "42|COMPLETED|0:0\n")
+	require.NoError(t, m.pollJobs(context.Background()))
+	stopped := requireState(t, sub, sproto.Terminated).ResourcesStopped
+	require.Nil(t, stopped.Failure)
+}
+
+func TestSlurmJobKilled(t *testing.T) {
+	m, runner := newTestRM(t, nil)
+	runner.set("scancel", "")
+	req, sub := allocateAndStart(t, m, 1)
+
+	m.killJob(req.AllocationID)
+	require.Eventually(t, func() bool {
+		return len(runner.called("scancel")) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"scancel 42"}, runner.called("scancel"))
+
+	runner.set("squeue", "")
+	runner.set("sacct", "42|CANCELLED by 1000|0:15\n")
+	require.NoError(t, m.pollJobs(context.Background()))
+	stopped := requireState(t, sub, sproto.Terminated).ResourcesStopped
+	require.Nil(t, stopped.Failure)
+}
+
+func TestSlurmJobReleasedWhileQueued(t *testing.T) {
+	m, runner := newTestRM(t, nil)
+	runner.set("scancel", "")
+	req, sub := allocateAndStart(t, m, 1)
+
+	m.Release(sproto.ResourcesReleased{AllocationID: req.AllocationID})
+	require.Equal(t, sproto.ResourcesReleasedEvent{}, nextEvent(t, sub))
+	require.Eventually(t, func() bool {
+		return len(runner.called("scancel")) == 1
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+func TestSlurmJobLost(t *testing.T) {
+	m, runner := newTestRM(t, nil)
+	_, sub := allocateAndStart(t, m, 1)
+
+	runner.set("squeue", "")
+	runner.set("sacct", "")
+	for i := 0; i < maxMissingPolls; i++ {
+		require.Zero(t, sub.Len())
+		require.NoError(t, m.pollJobs(context.Background()))
+	}
+	stopped := requireState(t, sub, sproto.Terminated).ResourcesStopped
+	require.Contains(t, stopped.Failure.ErrMsg, "no longer known")
+}
+
+func TestSlurmSubmitFailure(t *testing.T) {
+	m, runner := newTestRM(t, nil)
+	runner.mu.Lock()
+	delete(runner.outputs, "sbatch")
+	runner.mu.Unlock()
+
+	sub, err := m.Allocate(sproto.AllocateRequest{AllocationID: "alloc", JobID: "job"})
+	require.NoError(t, err)
+	defer sub.Close()
+	allocated, ok := nextEvent(t, sub).(*sproto.ResourcesAllocated)
+	require.True(t, ok)
+	for _, r := range allocated.Resources {
+		require.NoError(t, r.Start(nil, testTaskSpec(), sproto.ResourcesRuntimeInfo{}))
+	}
+	stopped := requireState(t, sub, sproto.Terminated).ResourcesStopped
+	require.Contains(t, stopped.Failure.ErrMsg, "unable to submit the slurm job")
+}
+
+func TestSlurmResourcePools(t *testing.T) {
+	m, _ := newTestRM(t, nil)
+	resp, err := m.GetResourcePools()
+	require.NoError(t, err)
+	require.Len(t, resp.ResourcePools, 1)
+	pool := resp.ResourcePools[0]
+	require.Equal(t, defaultPoolName, pool.Name)
+	require.True(t, pool.DefaultComputePool)
+	require.True(t, pool.DefaultAuxPool)
+	require.Equal(t, resourcepoolv1.SchedulerType_SCHEDULER_TYPE_SLURM, pool.SchedulerType)
+	require.Equal(t, "", m.poolPartitions[defaultPoolName])
+
+	m, _ = newTestRM(t, []config.ResourcePoolConfig{
+		{PoolName: "gpu"},
+		{PoolName: "cpu", Provider: &provconfig.Config{HPC: &provconfig.HpcClusterConfig{
+			Partition: "batch",
+		}}},
+	})
+	m.rmConfig.DefaultAuxResourcePool = "cpu"
+	resp, err = m.GetResourcePools()
+	require.NoError(t, err)
+	require.Len(t, resp.ResourcePools, 2)
+	require.True(t, resp.ResourcePools[0].DefaultComputePool)
+	require.True(t, resp.ResourcePools[1].DefaultAuxPool)
+	require.Equal(t, map[string]string{"gpu": "gpu", "cpu": "batch"}, m.poolPartitions)
+	require.NoError(t, m.ValidateResourcePool("cpu"))
+	require.Error(t, m.ValidateResourcePool(defaultPoolName))
+}
@@ -0,0 +1,56 @@
+package slurmrm
+
+import (
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/aproto"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/logger"
+	"github.com/determined-ai/determined/master/pkg/tasks"
+)
+
+// slurmResources are the resources of an allocation, backed by a single Slurm job.
+type slurmResources struct {
+	id  sproto.ResourcesID
+	req *sproto.AllocateRequest
+	rm  *SlurmResourceManager
+}
+
+// Summary summarizes a container allocation.
+func (r *slurmResources) Summary() sproto.ResourcesSummary {
+	return sproto.ResourcesSummary{
+		ResourcesID:   r.id,
+		ResourcesType: sproto.ResourcesTypeSlurmJob,
+		AllocationID:  r.req.AllocationID,
+		AgentDevices:  map[aproto.ID][]device.Device{},
+	}
+}
+
+// Start submits a Slurm job for the provided task spec.
+func (r *slurmResources) Start(
+	_ logger.Context, spec tasks.TaskSpec, rri sproto.ResourcesRuntimeInfo,
+) error {
+	spec.ResourcesID = string(r.id)
+	spec.AllocationID = string(r.req.AllocationID)
+	spec.AllocationSessionToken = rri.Token
+	spec.TaskID = string(r.req.TaskID)
+	spec.UseHostMode = rri.IsMultiAgent
+
+	if spec.LoggingFields == nil {
+		spec.LoggingFields = map[string]string{}
+	}
+	spec.LoggingFields["allocation_id"] = spec.AllocationID
+	spec.LoggingFields["task_id"] = spec.TaskID
+	if spec.ExtraEnvVars == nil {
+		spec.ExtraEnvVars = map[string]string{}
+	}
+	spec.ExtraEnvVars[sproto.ResourcesTypeEnvVar] = string(sproto.ResourcesTypeSlurmJob)
+	spec.ExtraEnvVars[sproto.SlurmRendezvousIfaceEnvVar] = r.rm.rmConfig.RendezvousNetworkInterface
+	spec.ExtraEnvVars[sproto.SlurmProxyIfaceEnvVar] = r.rm.rmConfig.ProxyNetworkInterface
+	r.rm.startJob(r, spec)
+	return nil
+}
+
+// Kill cancels the Slurm job.
+func (r *slurmResources) Kill(_ logger.Context) {
+	r.rm.killJob(r.req.AllocationID)
+}
@@ -0,0 +1,253 @@
+package schedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// TickInterval is how often the scheduler looks for due schedules.
+const TickInterval = 15 * time.Second
+
+var syslog = logrus.WithField("component", "schedules")
+
+// Launcher launches and kills the runs of schedules on behalf of their owners.
+type Launcher interface {
+	// Launch launches a run of a schedule and returns its experiment id or command id.
+	Launch(ctx context.Context, s *Schedule, contextDirectory []byte) (string, error)
+	// Kill kills a run of a schedule.
+	Kill(ctx context.Context, s *Schedule, runID string) error
+}
+
+// Scheduler fires due schedules. Firings are claimed in the database before their runs are
+// launched, so a firing is never launched twice, even across master restarts or by two
+// schedulers; a master that crashes between the two drops the firing instead.
+type Scheduler struct {
+	sched    gocron.Scheduler
+	launcher Launcher
+}
+
+// NewScheduler creates a new scheduler that launches runs with the given launcher.
+func NewScheduler(launcher Launcher, opts ...gocron.SchedulerOption) (*Scheduler, error) {
+	opts = append([]gocron.SchedulerOption{
+		gocron.WithLimitConcurrentJobs(1, gocron.LimitModeReschedule),
+	}, opts...)
+	s, err := gocron.NewScheduler(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Scheduler{sched: s, launcher: launcher}, nil
+}
+
+// Start fires due schedules every interval until the scheduler is shut down.
+func (s *Scheduler) Start(interval time.Duration) error {
+	task := gocron.NewTask(func() {
+		if err := s.Tick(context.Background(), time.Now()); err != nil {
+			syslog.WithError(err).Error("failed to fire schedules")
+		}
+	})
+	if _, err := s.sched.NewJob(gocron.DurationJob(interval), task); err != nil {
+		return fmt.Errorf("scheduling schedules: %w", err)
+	}
+	s.sched.Start()
+	return nil
+}
+
+// Shutdown stops the internal gocron.Scheduler.
+func (s *Scheduler) Shutdown() error {
+	return s.sched.Shutdown()
+}
+
+// Tick fires the schedules that are due at the given time, and launches the queued runs of those
+// whose previous run ended.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) error {
+	ss, err := enabled(ctx)
+	if err != nil {
+		return fmt.Errorf("getting schedules: %w", err)
+	}
+	for _, sc := range ss {
+		if err := s.fire(ctx, sc, now); err != nil {
+			syslog.WithError(err).WithField("schedule-id", sc.ID).Error("failed to fire schedule")
+		}
+	}
+	return nil
+}
+
+// action is what a schedule does on a tick.
+type action int
+
+const (
+	actionNone action = iota
+	actionLaunch
+	actionReplace
+)
+
+// decision is what a schedule does on a tick and the state it is left in.
+type decision struct {
+	action       action
+	lastFireTime *time.Time
+	queuedRuns   int
+}
+
+// latestDue returns the latest cron time of a schedule after base and at or before now. Missed
+// cron times before it are coalesced into it.
+func latestDue(sched cron.Schedule, base, now time.Time) (time.Time, bool) {
+	due := sched.Next(base.UTC())
+	if due.After(now) {
+		return time.Time{}, false
+	}
+	for next := sched.Next(due); !next.After(now); next = sched.Next(next) {
+		due = next
+	}
+	return due, true
+}
+
+// decide returns what a schedule does at the given time, given whether its previous run is
+// still active.
+func decide(s *Schedule, sched cron.Schedule, now time.Time, active bool) decision {
+	d := decision{lastFireTime: s.LastFireTime, queuedRuns: s.QueuedRuns}
+	due, ok := latestDue(sched, s.base(), now)
+	switch {
+	case !ok && !active && s.QueuedRuns > 0:
+		d.action = actionLaunch
+		d.queuedRuns--
+	case !ok:
+	case !active:
+		d.lastFireTime = &due
+		d.action = actionLaunch
+	default:
+		d.lastFireTime = &due
+		switch s.ConcurrencyPolicy {
+		case PolicySkip:
+		case PolicyQueue:
+			d.queuedRuns = min(d.queuedRuns+1, MaxQueuedRuns)
+		case PolicyReplace:
+			d.action = actionReplace
+		}
+	}
+	return d
+}
+
+// fire fires a schedule if it is due.
+func (s *Scheduler) fire(ctx context.Context, sc *Schedule, now time.Time) error {
+	sched, err := ParseCron(sc.Cron)
+	if err != nil {
+		return err
+	}
+	active, err := runActive(ctx, sc)
+	if err != nil {
+		return fmt.Errorf("checking the previous run: %w", err)
+	}
+	d := decide(sc, sched, now, active)
+	if d.lastFireTime == sc.LastFireTime && d.queuedRuns == sc.QueuedRuns {
+		return nil
+	}
+	if claimed, err := claim(ctx, sc, d); err != nil || !claimed {
+		return err
+	}
+
+	log := syslog.WithField("schedule-id", sc.ID)
+	if d.action == actionNone {
+		log.WithField("queued-runs", d.queuedRuns).Info("previous run still active, not launching")
+		return nil
+	}
+	if d.action == actionReplace {
+		if err := s.launcher.Kill(ctx, sc, *sc.LastRunID); err != nil {
+			log.WithError(err).Warnf("failed to kill previous run %s", *sc.LastRunID)
+		}
+	}
+	runID, err := s.launch(ctx, sc)
+	if err != nil {
+		log.WithError(err).Warn("failed to launch run")
+		return recordRun(ctx, sc.ID, sc.LastRunID, err)
+	}
+	log.Infof("launched run %s", runID)
+	return recordRun(ctx, sc.ID, &runID, nil)
+}
+
+func (s *Scheduler) launch(ctx context.Context, sc *Schedule) (string, error) {
+	contextDir, err := contextDirectory(ctx, sc.ID)
+	if err != nil {
+		return "", fmt.Errorf("getting context directory: %w", err)
+	}
+	return s.launcher.Launch(ctx, sc, contextDir)
+}
+
+// claim moves a schedule to the state of a decision if no one else did first, and returns
+// whether it did.
+func claim(ctx context.Context, s *Schedule, d decision) (bool, error) {
+	res, err := db.Bun().NewUpdate().Model((*Schedule)(nil)).
+		Set("last_fire_time = ?", d.lastFireTime).
+		Set("queued_runs = ?", d.queuedRuns).
+		Where("id = ?", s.ID).
+		Where("enabled").
+		Where("last_fire_time IS NOT DISTINCT FROM ?", s.LastFireTime).
+		Where("queued_runs = ?", s.QueuedRuns).
+		Where("last_run_id IS NOT DISTINCT FROM ?", s.LastRunID).
+		Exec(ctx)
+	if err := db.MustHaveAffectedRows(res, err); errors.Is(err, db.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("claiming firing: %w", err)
+	}
+	return true, nil
+}
+
+// recordRun records the latest run of a schedule, or why it could not be launched.
+func recordRun(ctx context.Context, id int, runID *string, launchErr error) error {
+	var msg *string
+	if launchErr != nil {
+		s := launchErr.Error()
+		msg = &s
+	}
+	if _, err := db.Bun().NewUpdate().Model((*Schedule)(nil)).
+		Set("last_run_id = ?", runID).
+		Set("last_error = ?", msg).
+		Where("id = ?", id).
+		Exec(ctx); err != nil {
+		return fmt.Errorf("recording run: %w", err)
+	}
+	return nil
+}
+
+// runActive returns whether the latest run of a schedule is still active. Runs that no longer
+// exist are not.
+func runActive(ctx context.Context, s *Schedule) (bool, error) {
+	if s.LastRunID == nil {
+		return false, nil
+	}
+	switch s.Kind {
+	case KindExperiment:
+		id, err := strconv.Atoi(*s.LastRunID)
+		if err != nil {
+			return false, fmt.Errorf("invalid experiment id %q: %w", *s.LastRunID, err)
+		}
+		var state model.State
+		err = db.Bun().NewSelect().Table("experiments").
+			Column("state").
+			Where("id = ?", id).
+			Scan(ctx, &state)
+		if errors.Is(db.MatchSentinelError(err), db.ErrNotFound) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		return !model.TerminalStates[state], nil
+	case KindCommand:
+		return db.Bun().NewSelect().Table("tasks").
+			Where("task_id = ?", *s.LastRunID).
+			Where("end_time IS NULL").
+			Exists(ctx)
+	default:
+		return false, fmt.Errorf("unsupported schedule kind %s", s.Kind)
+	}
+}
@@ -0,0 +1,84 @@
+package schedules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, spec := range []string{"*/5 * * * *", "@daily", "@every 1h", "CRON_TZ=Asia/Tokyo 0 9 * * 1-5"} {
+		_, err := ParseCron(spec)
+		require.NoError(t, err, spec)
+	}
+	for _, spec := range []string{"", "* * *", "61 * * * *", "@every 30s", "@sometimes"} {
+		_, err := ParseCron(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestLatestDue(t *testing.T) {
+	sched, err := ParseCron("0 * * * *")
+	require.NoError(t, err)
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	_, ok := latestDue(sched, base, base.Add(59*time.Minute))
+	require.False(t, ok)
+
+	due, ok := latestDue(sched, base, base.Add(time.Hour))
+	require.True(t, ok)
+	require.Equal(t, base.Add(time.Hour), due)
+
+	// Missed firings are coalesced into the latest one.
+	due, ok = latestDue(sched, base, base.Add(5*time.Hour+30*time.Minute))
+	require.True(t, ok)
+	require.Equal(t, base.Add(5*time.Hour), due)
+}
+
+func TestDecide(t *testing.T) {
+	sched, err := ParseCron("0 * * * *")
+	require.NoError(t, err)
+	last := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	notDue, due := last.Add(30*time.Minute), last.Add(90*time.Minute)
+	newSchedule := func(p ConcurrencyPolicy, queued int) *Schedule {
+		return &Schedule{ConcurrencyPolicy: p, LastFireTime: &last, QueuedRuns: queued}
+	}
+
+	d := decide(newSchedule(PolicySkip, 0), sched, notDue, false)
+	require.Equal(t, actionNone, d.action)
+	require.Equal(t, &last, d.lastFireTime)
+
+	d = decide(newSchedule(PolicySkip, 0), sched, due, false)
+	require.Equal(t, actionLaunch, d.action)
+	require.Equal(t, last.Add(time.Hour), *d.lastFireTime)
+
+	d = decide(newSchedule(PolicySkip, 0), sched, due, true)
+	require.Equal(t, actionNone, d.action)
+	require.Equal(t, last.Add(time.Hour), *d.lastFireTime)
+	require.Equal(t, 0, d.queuedRuns)
+
+	d = decide(newSchedule(PolicyReplace, 0), sched, due, true)
+	require.Equal(t, actionReplace, d.action)
+
+	d = decide(newSchedule(PolicyQueue, 1), sched, due, true)
+	require.Equal(t, actionNone, d.action)
+	require.Equal(t, 2, d.queuedRuns)
+
+	d = decide(newSchedule(PolicyQueue, MaxQueuedRuns), sched, due, true)
+	require.Equal(t, MaxQueuedRuns, d.queuedRuns)
+
+	// Queued runs launch once the previous run ends, even if the schedule is not due.
+	d = decide(newSchedule(PolicyQueue, 2), sched, notDue, false)
+	require.Equal(t, actionLaunch, d.action)
+	require.Equal(t, 1, d.queuedRuns)
+	require.Equal(t, &last, d.lastFireTime)
+
+	d = decide(newSchedule(PolicyQueue, 2), sched, notDue, true)
+	require.Equal(t, actionNone, d.action)
+	require.Equal(t, 2, d.queuedRuns)
+
+	// Schedules that never fired are due after they were created.
+	d = decide(&Schedule{ConcurrencyPolicy: PolicySkip, CreatedAt: last}, sched, due, false)
+	require.Equal(t, actionLaunch, d.action)
+}
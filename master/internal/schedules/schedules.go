@@ -0,0 +1,313 @@
+// Package schedules implements schedules: experiments and commands launched on a cron spec, on
+// behalf of the owner of the schedule.
+package schedules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/uptrace/bun"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/schedulev1"
+)
+
+const (
+	// MinInterval is the shortest interval between firings of an "@every" schedule.
+	MinInterval = time.Minute
+	// MaxQueuedRuns is the most firings a schedule with the queue policy holds on to.
+	MaxQueuedRuns = 10
+)
+
+// Kind is the kind of work a schedule launches.
+type Kind string
+
+const (
+	// KindExperiment schedules launch experiments.
+	KindExperiment Kind = "EXPERIMENT"
+	// KindCommand schedules launch commands.
+	KindCommand Kind = "COMMAND"
+)
+
+// Proto converts a kind to its proto representation.
+func (k Kind) Proto() schedulev1.Kind {
+	return schedulev1.Kind(schedulev1.Kind_value["KIND_"+string(k)])
+}
+
+// KindFromProto converts a proto kind, returning an error for unspecified kinds.
+func KindFromProto(k schedulev1.Kind) (Kind, error) {
+	switch k {
+	case schedulev1.Kind_KIND_EXPERIMENT:
+		return KindExperiment, nil
+	case schedulev1.Kind_KIND_COMMAND:
+		return KindCommand, nil
+	default:
+		return "", fmt.Errorf("unsupported schedule kind %s", k)
+	}
+}
+
+// ConcurrencyPolicy is what a schedule does when it fires while its previous run is active.
+type ConcurrencyPolicy string
+
+const (
+	// PolicySkip skips the firing.
+	PolicySkip ConcurrencyPolicy = "SKIP"
+	// PolicyQueue launches a run once the previous run ends.
+	PolicyQueue ConcurrencyPolicy = "QUEUE"
+	// PolicyReplace kills the previous run and launches a new one.
+	PolicyReplace ConcurrencyPolicy = "REPLACE"
+)
+
+// Proto converts a concurrency policy to its proto representation.
+func (p ConcurrencyPolicy) Proto() schedulev1.ConcurrencyPolicy {
+	return schedulev1.ConcurrencyPolicy(
+		schedulev1.ConcurrencyPolicy_value["CONCURRENCY_POLICY_"+string(p)])
+}
+
+// ConcurrencyPolicyFromProto converts a proto concurrency policy, returning an error for
+// unspecified policies.
+func ConcurrencyPolicyFromProto(p schedulev1.ConcurrencyPolicy) (ConcurrencyPolicy, error) {
+	switch p {
+	case schedulev1.ConcurrencyPolicy_CONCURRENCY_POLICY_SKIP:
+		return PolicySkip, nil
+	case schedulev1.ConcurrencyPolicy_CONCURRENCY_POLICY_QUEUE:
+		return PolicyQueue, nil
+	case schedulev1.ConcurrencyPolicy_CONCURRENCY_POLICY_REPLACE:
+		return PolicyReplace, nil
+	default:
+		return "", fmt.Errorf("unsupported concurrency policy %s", p)
+	}
+}
+
+// ParseCron parses a standard five field cron spec or a descriptor such as "@daily". Specs are
+// in UTC unless prefixed with "CRON_TZ=<zone>".
+func ParseCron(spec string) (cron.Schedule, error) {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+	}
+	if every, ok := sched.(cron.ConstantDelaySchedule); ok && every.Delay < MinInterval {
+		return nil, fmt.Errorf("cron spec %q fires more often than every %s", spec, MinInterval)
+	}
+	return sched, nil
+}
+
+// Schedule corresponds to a row in the "schedules" DB table.
+type Schedule struct {
+	bun.BaseModel `bun:"table:schedules"`
+
+	ID          int          `bun:"id,pk,autoincrement"`
+	Name        string       `bun:"name,notnull"`
+	OwnerID     model.UserID `bun:"owner_id,notnull"`
+	WorkspaceID int          `bun:"workspace_id,notnull"`
+	// ProjectID is the project experiments are launched in. Commands have none.
+	ProjectID    *int    `bun:"project_id"`
+	Kind         Kind    `bun:"kind,notnull"`
+	Cron         string  `bun:"cron,notnull"`
+	Config       string  `bun:"config,notnull"`
+	TemplateName *string `bun:"template_name"`
+	// ContextDirectory is the gzipped tarball of the model definition or command files. It is only
+	// loaded to launch runs.
+	ContextDirectory  []byte            `bun:"context_directory,notnull"`
+	ConcurrencyPolicy ConcurrencyPolicy `bun:"concurrency_policy,notnull"`
+	Enabled           bool              `bun:"enabled,notnull"`
+	CreatedAt         time.Time         `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	// LastFireTime is the cron time of the latest firing. Firings at or before it never happen
+	// again, which is what keeps restarts from firing twice.
+	LastFireTime *time.Time `bun:"last_fire_time"`
+	// LastRunID is the experiment id or command id of the latest run.
+	LastRunID  *string `bun:"last_run_id"`
+	QueuedRuns int     `bun:"queued_runs,notnull"`
+	LastError  *string `bun:"last_error"`
+}
+
+// base returns the time firings of the schedule are due after.
+func (s *Schedule) base() time.Time {
+	if s.LastFireTime != nil {
+		return *s.LastFireTime
+	}
+	return s.CreatedAt
+}
+
+// NextFireTime returns the next cron time the schedule fires at, or nil if it is disabled.
+func (s *Schedule) NextFireTime() (*time.Time, error) {
+	if !s.Enabled {
+		return nil, nil
+	}
+	sched, err := ParseCron(s.Cron)
+	if err != nil {
+		return nil, err
+	}
+	next := sched.Next(s.base().UTC())
+	return &next, nil
+}
+
+// Proto converts a schedule to its proto representation.
+func (s *Schedule) Proto() (*schedulev1.Schedule, error) {
+	pb := &schedulev1.Schedule{
+		Id:                int32(s.ID),
+		Name:              s.Name,
+		UserId:            int32(s.OwnerID),
+		WorkspaceId:       int32(s.WorkspaceID),
+		Kind:              s.Kind.Proto(),
+		Cron:              s.Cron,
+		Config:            s.Config,
+		TemplateName:      s.TemplateName,
+		ConcurrencyPolicy: s.ConcurrencyPolicy.Proto(),
+		Enabled:           s.Enabled,
+		CreatedAt:         timestamppb.New(s.CreatedAt),
+		LastRunId:         s.LastRunID,
+		QueuedRuns:        int32(s.QueuedRuns),
+		LastError:         s.LastError,
+	}
+	if s.ProjectID != nil {
+		pb.ProjectId = ptrs.Ptr(int32(*s.ProjectID))
+	}
+	if s.LastFireTime != nil {
+		pb.LastFireTime = timestamppb.New(*s.LastFireTime)
+	}
+	next, err := s.NextFireTime()
+	if err != nil {
+		return nil, fmt.Errorf("schedule %d: %w", s.ID, err)
+	}
+	if next != nil {
+		pb.NextFireTime = timestamppb.New(*next)
+	}
+	return pb, nil
+}
+
+// Add persists a new schedule. It returns db.ErrInvalidInput if its cron spec is invalid and
+// db.ErrDuplicateRecord if its workspace has a schedule of the same name.
+func Add(ctx context.Context, s *Schedule) error {
+	if _, err := ParseCron(s.Cron); err != nil {
+		return fmt.Errorf("%w: %w", db.ErrInvalidInput, err)
+	}
+	if s.ContextDirectory == nil {
+		s.ContextDirectory = []byte{}
+	}
+	if _, err := db.Bun().NewInsert().Model(s).
+		Returning("id, created_at").
+		Exec(ctx); err != nil {
+		return fmt.Errorf("persisting schedule: %w", db.MatchSentinelError(err))
+	}
+	return nil
+}
+
+// ByID returns a schedule without its context directory. It returns db.ErrNotFound if there is
+// no such schedule.
+func ByID(ctx context.Context, id int) (*Schedule, error) {
+	var s Schedule
+	if err := db.Bun().NewSelect().Model(&s).
+		ExcludeColumn("context_directory").
+		Where("id = ?", id).
+		Scan(ctx); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return &s, nil
+}
+
+// List returns the schedules, or those of a workspace, without their context directories.
+func List(ctx context.Context, workspaceID *int) ([]*Schedule, error) {
+	ss := []*Schedule{}
+	q := db.Bun().NewSelect().Model(&ss).
+		ExcludeColumn("context_directory").
+		Order("id")
+	if workspaceID != nil {
+		q.Where("workspace_id = ?", *workspaceID)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// Patch is a change to a schedule. Nil fields are left alone.
+type Patch struct {
+	Cron              *string
+	ConcurrencyPolicy *ConcurrencyPolicy
+	Enabled           *bool
+}
+
+// Update applies a patch to a schedule and returns the schedule. Changing the cron spec or
+// enabling the schedule restarts it from now, so that the firings it missed are not made up.
+func Update(ctx context.Context, id int, p Patch, now time.Time) (*Schedule, error) {
+	if p.Cron != nil {
+		if _, err := ParseCron(*p.Cron); err != nil {
+			return nil, fmt.Errorf("%w: %w", db.ErrInvalidInput, err)
+		}
+	}
+	var s Schedule
+	err := db.Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := tx.NewSelect().Model(&s).
+			ExcludeColumn("context_directory").
+			Where("id = ?", id).
+			For("UPDATE").
+			Scan(ctx); err != nil {
+			return db.MatchSentinelError(err)
+		}
+		restart := false
+		if p.Cron != nil && *p.Cron != s.Cron {
+			s.Cron = *p.Cron
+			restart = true
+		}
+		if p.Enabled != nil && *p.Enabled != s.Enabled {
+			s.Enabled = *p.Enabled
+			restart = s.Enabled
+		}
+		if p.ConcurrencyPolicy != nil {
+			s.ConcurrencyPolicy = *p.ConcurrencyPolicy
+		}
+		if restart {
+			s.LastFireTime = &now
+		}
+		if _, err := tx.NewUpdate().Model(&s).
+			Column("cron", "enabled", "concurrency_policy", "last_fire_time").
+			WherePK().
+			Exec(ctx); err != nil {
+			return fmt.Errorf("updating schedule %d: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Delete deletes a schedule. Its runs are left alone. It returns db.ErrNotFound if there is no
+// such schedule.
+func Delete(ctx context.Context, id int) error {
+	return db.MustHaveAffectedRows(db.Bun().NewDelete().Model((*Schedule)(nil)).
+		Where("id = ?", id).
+		Exec(ctx))
+}
+
+// enabled returns the enabled schedules, without their context directories.
+func enabled(ctx context.Context) ([]*Schedule, error) {
+	var ss []*Schedule
+	if err := db.Bun().NewSelect().Model(&ss).
+		ExcludeColumn("context_directory").
+		Where("enabled").
+		Order("id").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// contextDirectory returns the context directory of a schedule.
+func contextDirectory(ctx context.Context, id int) ([]byte, error) {
+	var b []byte
+	if err := db.Bun().NewSelect().Model((*Schedule)(nil)).
+		Column("context_directory").
+		Where("id = ?", id).
+		Scan(ctx, &b); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return b, nil
+}
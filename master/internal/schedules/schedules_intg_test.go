@@ -0,0 +1,160 @@
+//go:build integration
+// +build integration
+
+package schedules
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+type fakeLauncher struct {
+	t    *testing.T
+	pgDB *db.PgDB
+	user model.User
+
+	fail     bool
+	launched []string
+	killed   []string
+}
+
+func (f *fakeLauncher) Launch(
+	ctx context.Context, s *Schedule, contextDirectory []byte,
+) (string, error) {
+	if f.fail {
+		return "", errors.New("no slots")
+	}
+	task := db.RequireMockTask(f.t, f.pgDB, &f.user.ID)
+	f.launched = append(f.launched, string(task.TaskID))
+	return string(task.TaskID), nil
+}
+
+func (f *fakeLauncher) Kill(ctx context.Context, s *Schedule, runID string) error {
+	f.killed = append(f.killed, runID)
+	return endTask(runID)
+}
+
+func endTask(taskID string) error {
+	_, err := db.Bun().NewUpdate().Table("tasks").
+		Set("end_time = ?", time.Now()).
+		Where("task_id = ?", taskID).
+		Exec(context.Background())
+	return err
+}
+
+func TestSchedules(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, etc.SetRootPath(db.RootFromDB))
+	pgDB, closeDB := db.MustResolveTestPostgres(t)
+	defer closeDB()
+	db.MustMigrateTestPostgres(t, pgDB, db.MigrationsFromDB)
+	user := db.RequireMockUser(t, pgDB)
+	workspaceID, _ := db.RequireMockWorkspaceID(t, pgDB, "")
+
+	f := &fakeLauncher{t: t, pgDB: pgDB, user: user}
+	scheduler, err := NewScheduler(f)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, Add(ctx, &Schedule{
+		Name: "bad", OwnerID: user.ID, WorkspaceID: workspaceID, Kind: KindCommand,
+		Cron: "@every 1s", ConcurrencyPolicy: PolicySkip, Enabled: true,
+	}), db.ErrInvalidInput)
+
+	s := &Schedule{
+		Name:              "hourly",
+		OwnerID:           user.ID,
+		WorkspaceID:       workspaceID,
+		Kind:              KindCommand,
+		Cron:              "0 * * * *",
+		Config:            "entrypoint: echo hi",
+		ConcurrencyPolicy: PolicyQueue,
+		Enabled:           true,
+	}
+	require.NoError(t, Add(ctx, s))
+	dup := *s
+	dup.ID = 0
+	require.ErrorIs(t, Add(ctx, &dup), db.ErrDuplicateRecord)
+
+	// Fire once, as if an hour went by, and once more at the same time, as a restarted master
+	// or a second scheduler would; the second tick finds nothing due.
+	now := s.CreatedAt.Add(time.Hour)
+	require.NoError(t, scheduler.Tick(ctx, now))
+	require.NoError(t, scheduler.Tick(ctx, now))
+	require.Len(t, f.launched, 1)
+	s, err = ByID(ctx, s.ID)
+	require.NoError(t, err)
+	require.Equal(t, f.launched[0], *s.LastRunID)
+	require.Nil(t, s.LastError)
+
+	// The run is still active, so the next firings queue.
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, scheduler.Tick(ctx, now))
+	s, err = ByID(ctx, s.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, s.QueuedRuns)
+	require.Len(t, f.launched, 1)
+
+	require.NoError(t, endTask(f.launched[0]))
+	require.NoError(t, scheduler.Tick(ctx, now))
+	s, err = ByID(ctx, s.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, s.QueuedRuns)
+	require.Len(t, f.launched, 2)
+
+	// Replace kills the active run.
+	replace := PolicyReplace
+	_, err = Update(ctx, s.ID, Patch{ConcurrencyPolicy: &replace}, now)
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+	require.NoError(t, scheduler.Tick(ctx, now))
+	require.Equal(t, []string{f.launched[1]}, f.killed)
+	require.Len(t, f.launched, 3)
+
+	// Launch failures are recorded and the firing is not retried.
+	require.NoError(t, endTask(f.launched[2]))
+	f.fail = true
+	now = now.Add(time.Hour)
+	require.NoError(t, scheduler.Tick(ctx, now))
+	s, err = ByID(ctx, s.ID)
+	require.NoError(t, err)
+	require.Equal(t, "no slots", *s.LastError)
+	f.fail = false
+	require.NoError(t, scheduler.Tick(ctx, now))
+	require.Len(t, f.launched, 3)
+
+	// Disabled schedules do not fire, and do not make up missed firings once enabled again.
+	_, err = Update(ctx, s.ID, Patch{Enabled: ptrs.Ptr(false)}, now)
+	require.NoError(t, err)
+	now = now.Add(3 * time.Hour)
+	require.NoError(t, scheduler.Tick(ctx, now))
+	require.Len(t, f.launched, 3)
+	s, err = Update(ctx, s.ID, Patch{Enabled: ptrs.Ptr(true)}, now)
+	require.NoError(t, err)
+	require.NoError(t, scheduler.Tick(ctx, now))
+	require.Len(t, f.launched, 3)
+	next, err := s.NextFireTime()
+	require.NoError(t, err)
+	require.True(t, next.After(now))
+
+	ss, err := List(ctx, &workspaceID)
+	require.NoError(t, err)
+	require.Len(t, ss, 1)
+	pb, err := ss[0].Proto()
+	require.NoError(t, err)
+	require.Equal(t, s.Name, pb.Name)
+
+	require.NoError(t, Delete(ctx, s.ID))
+	require.ErrorIs(t, Delete(ctx, s.ID), db.ErrNotFound)
+	_, err = ByID(ctx, s.ID)
+	require.ErrorIs(t, err, db.ErrNotFound)
+}
@@ -0,0 +1,98 @@
+package sharelink
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// Create inserts a share link, filling in its id and creation time.
+func Create(ctx context.Context, l *ShareLink) error {
+	_, err := db.Bun().NewInsert().Model(l).Returning("id, created_at").Exec(ctx)
+	return err
+}
+
+// ByID returns the share link with the given id, or db.ErrNotFound.
+func ByID(ctx context.Context, id int) (*ShareLink, error) {
+	var l ShareLink
+	err := db.Bun().NewSelect().Model(&l).Where("id = ?", id).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, db.ErrNotFound
+	}
+	return &l, err
+}
+
+// ListByTask returns the share links of a task, most recently created first, optionally including
+// revoked and expired ones.
+func ListByTask(
+	ctx context.Context, taskID model.TaskID, showInactive bool,
+) ([]*ShareLink, error) {
+	links := []*ShareLink{}
+	query := db.Bun().NewSelect().Model(&links).
+		Where("task_id = ?", taskID).
+		Order("created_at DESC", "id DESC")
+	if !showInactive {
+		query = query.Where("revoked_at IS NULL").Where("expires_at > now()")
+	}
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// Revoke revokes the share link with the given id, returning it. Revoking a revoked share link
+// keeps its original revocation time.
+func Revoke(ctx context.Context, id int) (*ShareLink, error) {
+	var l ShareLink
+	err := db.Bun().NewUpdate().Model(&l).
+		Set("revoked_at = coalesce(revoked_at, ?)", time.Now().UTC()).
+		Where("id = ?", id).
+		Returning("*").
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, db.ErrNotFound
+	}
+	return &l, err
+}
+
+// Authenticate looks up the share link of a token for the given task. Inactive share links are
+// returned too, so that attempts to use them can be recorded; check them with Authorize.
+func Authenticate(ctx context.Context, token string, taskID model.TaskID) (*ShareLink, error) {
+	id, err := parseToken(token, taskID)
+	if err != nil {
+		return nil, err
+	}
+	l, err := ByID(ctx, id)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	return l, err
+}
+
+// RecordAccess records a request through a share link.
+func RecordAccess(ctx context.Context, a *Access) error {
+	_, err := db.Bun().NewInsert().Model(a).Returning("id, accessed_at").Exec(ctx)
+	return err
+}
+
+// ListAccesses returns the requests through a share link, most recent first, along with their
+// total number.
+func ListAccesses(ctx context.Context, shareLinkID, offset, limit int) ([]*Access, int, error) {
+	query := db.Bun().NewSelect().Model((*Access)(nil)).Where("share_link_id = ?", shareLinkID)
+	total, err := query.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	accesses := []*Access{}
+	err = db.PaginateBun(query, "accessed_at", db.SortDirectionDesc, offset, limit).
+		Scan(ctx, &accesses)
+	if err != nil {
+		return nil, 0, err
+	}
+	return accesses, total, nil
+}
@@ -0,0 +1,91 @@
+//go:build integration
+// +build integration
+
+package sharelink
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/etc"
+)
+
+func TestShareLinks(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, etc.SetRootPath(db.RootFromDB))
+	pgDB, closeDB := db.MustResolveTestPostgres(t)
+	defer closeDB()
+	db.MustMigrateTestPostgres(t, pgDB, db.MigrationsFromDB)
+	require.NoError(t, db.InitAuthKeys())
+
+	user := db.RequireMockUser(t, pgDB)
+	task := db.RequireMockTask(t, pgDB, &user.ID)
+	otherTask := db.RequireMockTask(t, pgDB, &user.ID)
+
+	l := &ShareLink{
+		TaskID:    task.TaskID,
+		UserID:    user.ID,
+		Mode:      ModeReadOnly,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, Create(ctx, l))
+	require.NotZero(t, l.ID)
+	token, err := Token(l)
+	require.NoError(t, err)
+
+	authenticated, err := Authenticate(ctx, token, task.TaskID)
+	require.NoError(t, err)
+	require.Equal(t, l.ID, authenticated.ID)
+	require.NoError(t, authenticated.Authorize(nil, http.MethodGet, false, time.Now()))
+	_, err = Authenticate(ctx, token, otherTask.TaskID)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	links, err := ListByTask(ctx, task.TaskID, false)
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+
+	for _, allowed := range []bool{true, false, true} {
+		require.NoError(t, RecordAccess(ctx, &Access{
+			ShareLinkID: l.ID,
+			UserID:      &user.ID,
+			RemoteAddr:  "127.0.0.1",
+			Method:      http.MethodGet,
+			Path:        "/proxy/" + string(task.TaskID) + "/",
+			Allowed:     allowed,
+		}))
+	}
+	accesses, total, err := ListAccesses(ctx, l.ID, 0, 2)
+	require.NoError(t, err)
+	require.Equal(t, 3, total)
+	require.Len(t, accesses, 2)
+	require.Equal(t, user.ID, *accesses[0].UserID)
+
+	// Revoking a link keeps its token from authorizing requests, and keeps the link for auditing.
+	revoked, err := Revoke(ctx, l.ID)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	authenticated, err = Authenticate(ctx, token, task.TaskID)
+	require.NoError(t, err)
+	require.ErrorIs(t,
+		authenticated.Authorize(nil, http.MethodGet, false, time.Now()), ErrInactive)
+
+	links, err = ListByTask(ctx, task.TaskID, false)
+	require.NoError(t, err)
+	require.Empty(t, links)
+	links, err = ListByTask(ctx, task.TaskID, true)
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+
+	again, err := Revoke(ctx, l.ID)
+	require.NoError(t, err)
+	require.Equal(t, revoked.RevokedAt.Unix(), again.RevokedAt.Unix())
+
+	_, err = Revoke(ctx, l.ID+1000)
+	require.ErrorIs(t, err, db.ErrNotFound)
+}
@@ -0,0 +1,212 @@
+// Package sharelink implements share links: signed, expiring tokens that let people use a notebook
+// or TensorBoard served through the proxy without permissions on its workspace.
+package sharelink
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/o1egl/paseto"
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/sharelinkv1"
+)
+
+const (
+	// DefaultLifespan is how long share links last unless asked otherwise.
+	DefaultLifespan = 24 * time.Hour
+	// MaxLifespan is the longest a share link may last.
+	MaxLifespan = 30 * 24 * time.Hour
+
+	// TokenParam is the query parameter that carries share link tokens to the proxy. The proxy
+	// moves the token to a cookie of the same name scoped to the shared service, so that requests
+	// the service makes for its own pages and assets carry it too.
+	TokenParam = "det_share_token"
+)
+
+var (
+	// ErrInvalidToken is returned for tokens that were not signed by the master or do not match
+	// the requested service.
+	ErrInvalidToken = errors.New("invalid share link token")
+	// ErrInactive is returned for share links that are revoked or expired.
+	ErrInactive = errors.New("share link is revoked or expired")
+	// ErrUserNotAllowed is returned when the share link is limited to named users and the
+	// requester is not one of them.
+	ErrUserNotAllowed = errors.New("share link is not shared with this user")
+	// ErrReadOnly is returned for requests that would modify the service through a read-only
+	// share link.
+	ErrReadOnly = errors.New("share link is read-only")
+)
+
+// Mode is what the holder of a share link may do with the shared service.
+type Mode string
+
+const (
+	// ModeReadOnly share links only allow safe HTTP methods and no WebSockets.
+	ModeReadOnly Mode = "READ_ONLY"
+	// ModeInteract share links allow using the service like its owner.
+	ModeInteract Mode = "INTERACT"
+)
+
+// Proto converts a mode to its proto representation.
+func (m Mode) Proto() sharelinkv1.Mode {
+	return sharelinkv1.Mode(sharelinkv1.Mode_value["MODE_"+string(m)])
+}
+
+// ModeFromProto converts a proto mode, returning an error for unspecified modes.
+func ModeFromProto(m sharelinkv1.Mode) (Mode, error) {
+	switch m {
+	case sharelinkv1.Mode_MODE_READ_ONLY:
+		return ModeReadOnly, nil
+	case sharelinkv1.Mode_MODE_INTERACT:
+		return ModeInteract, nil
+	default:
+		return "", fmt.Errorf("unsupported share link mode %s", m)
+	}
+}
+
+// ShareLink corresponds to a row in the "proxy_share_links" DB table.
+type ShareLink struct {
+	bun.BaseModel `bun:"table:proxy_share_links"`
+
+	ID     int          `bun:"id,pk,autoincrement"`
+	TaskID model.TaskID `bun:"task_id,notnull"`
+	// UserID is the user that created the share link.
+	UserID model.UserID `bun:"user_id,notnull"`
+	Mode   Mode         `bun:"mode,notnull"`
+	// Usernames are the users allowed to use the share link. Anyone holding it may when empty.
+	Usernames   []string   `bun:"usernames,array"`
+	Description string     `bun:"description,notnull"`
+	CreatedAt   time.Time  `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	ExpiresAt   time.Time  `bun:"expires_at,notnull"`
+	RevokedAt   *time.Time `bun:"revoked_at"`
+}
+
+// Proto converts the share link to its proto representation.
+func (l *ShareLink) Proto() *sharelinkv1.ShareLink {
+	pb := &sharelinkv1.ShareLink{
+		Id:          int32(l.ID),
+		TaskId:      string(l.TaskID),
+		UserId:      int32(l.UserID),
+		Mode:        l.Mode.Proto(),
+		Usernames:   l.Usernames,
+		Description: l.Description,
+		CreatedAt:   protoutils.ToTimestamp(l.CreatedAt),
+		ExpiresAt:   protoutils.ToTimestamp(l.ExpiresAt),
+	}
+	if pb.Usernames == nil {
+		pb.Usernames = []string{}
+	}
+	if l.RevokedAt != nil {
+		pb.RevokedAt = protoutils.ToTimestamp(*l.RevokedAt)
+	}
+	return pb
+}
+
+// Active returns whether the share link may be used at the given time.
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// Authorize returns why the given request through the share link should be denied, or nil if it
+// may be proxied. The requester is the logged in user, if any.
+func (l *ShareLink) Authorize(
+	requester *model.User, method string, webSocket bool, now time.Time,
+) error {
+	if !l.Active(now) {
+		return ErrInactive
+	}
+	if len(l.Usernames) > 0 &&
+		(requester == nil || !requester.Active || !slices.Contains(l.Usernames, requester.Username)) {
+		return ErrUserNotAllowed
+	}
+	if l.Mode == ModeReadOnly && (webSocket || !safeMethod(method)) {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// Access corresponds to a row in the "proxy_share_link_accesses" DB table.
+type Access struct {
+	bun.BaseModel `bun:"table:proxy_share_link_accesses"`
+
+	ID          int64         `bun:"id,pk,autoincrement"`
+	ShareLinkID int           `bun:"share_link_id,notnull"`
+	UserID      *model.UserID `bun:"user_id"`
+	RemoteAddr  string        `bun:"remote_addr,notnull"`
+	Method      string        `bun:"method,notnull"`
+	Path        string        `bun:"path,notnull"`
+	Allowed     bool          `bun:"allowed,notnull"`
+	AccessedAt  time.Time     `bun:"accessed_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// Proto converts the access to its proto representation.
+func (a *Access) Proto() *sharelinkv1.ShareLinkAccess {
+	pb := &sharelinkv1.ShareLinkAccess{
+		Id:          a.ID,
+		ShareLinkId: int32(a.ShareLinkID),
+		RemoteAddr:  a.RemoteAddr,
+		Method:      a.Method,
+		Path:        a.Path,
+		Allowed:     a.Allowed,
+		AccessedAt:  protoutils.ToTimestamp(a.AccessedAt),
+	}
+	if a.UserID != nil {
+		userID := int32(*a.UserID)
+		pb.UserId = &userID
+	}
+	return pb
+}
+
+// claims are the signed contents of a share link token. The share link row stays the source of
+// truth for whether the token may be used, so that links can be revoked.
+type claims struct {
+	ShareLinkID int          `json:"share_link_id"`
+	TaskID      model.TaskID `json:"task_id"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+}
+
+// Token returns the signed token of the share link.
+func Token(l *ShareLink) (string, error) {
+	token, err := paseto.NewV2().Sign(db.GetTokenKeys().PrivateKey, claims{
+		ShareLinkID: l.ID,
+		TaskID:      l.TaskID,
+		ExpiresAt:   l.ExpiresAt,
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("signing share link token: %w", err)
+	}
+	return token, nil
+}
+
+// parseToken verifies a share link token for the given task, returning the id of its share link.
+func parseToken(token string, taskID model.TaskID) (int, error) {
+	var c claims
+	if err := paseto.NewV2().Verify(token, db.GetTokenKeys().PublicKey, &c, nil); err != nil {
+		return 0, ErrInvalidToken
+	}
+	if c.TaskID != taskID {
+		return 0, ErrInvalidToken
+	}
+	return c.ShareLinkID, nil
+}
+
+// Path returns the path on the master that opens the shared service with the given token.
+func Path(taskID model.TaskID, token string) string {
+	return fmt.Sprintf("/proxy/%s/?%s=%s", taskID, TokenParam, token)
+}
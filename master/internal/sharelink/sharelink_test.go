@@ -0,0 +1,128 @@
+package sharelink
+
+import (
+	"crypto/ed25519"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/sharelinkv1"
+)
+
+func TestModeProto(t *testing.T) {
+	for _, mode := range []Mode{ModeReadOnly, ModeInteract} {
+		converted, err := ModeFromProto(mode.Proto())
+		require.NoError(t, err)
+		require.Equal(t, mode, converted)
+	}
+	_, err := ModeFromProto(sharelinkv1.Mode_MODE_UNSPECIFIED)
+	require.Error(t, err)
+}
+
+func TestAuthorize(t *testing.T) {
+	now := time.Now()
+	alice := &model.User{ID: 1, Username: "alice", Active: true}
+	bob := &model.User{ID: 2, Username: "bob", Active: true}
+
+	cases := []struct {
+		name      string
+		link      ShareLink
+		requester *model.User
+		method    string
+		webSocket bool
+		err       error
+	}{
+		{
+			name:   "anonymous read",
+			link:   ShareLink{Mode: ModeReadOnly, ExpiresAt: now.Add(time.Hour)},
+			method: http.MethodGet,
+		},
+		{
+			name:   "read-only post",
+			link:   ShareLink{Mode: ModeReadOnly, ExpiresAt: now.Add(time.Hour)},
+			method: http.MethodPost,
+			err:    ErrReadOnly,
+		},
+		{
+			name:      "read-only websocket",
+			link:      ShareLink{Mode: ModeReadOnly, ExpiresAt: now.Add(time.Hour)},
+			method:    http.MethodGet,
+			webSocket: true,
+			err:       ErrReadOnly,
+		},
+		{
+			name:      "interact websocket",
+			link:      ShareLink{Mode: ModeInteract, ExpiresAt: now.Add(time.Hour)},
+			method:    http.MethodGet,
+			webSocket: true,
+		},
+		{
+			name:   "expired",
+			link:   ShareLink{Mode: ModeInteract, ExpiresAt: now.Add(-time.Second)},
+			method: http.MethodGet,
+			err:    ErrInactive,
+		},
+		{
+			name: "revoked",
+			link: ShareLink{
+				Mode: ModeInteract, ExpiresAt: now.Add(time.Hour), RevokedAt: &now,
+			},
+			method: http.MethodGet,
+			err:    ErrInactive,
+		},
+		{
+			name: "named user",
+			link: ShareLink{
+				Mode: ModeInteract, ExpiresAt: now.Add(time.Hour), Usernames: []string{"alice"},
+			},
+			requester: alice,
+			method:    http.MethodPost,
+		},
+		{
+			name: "other user",
+			link: ShareLink{
+				Mode: ModeInteract, ExpiresAt: now.Add(time.Hour), Usernames: []string{"alice"},
+			},
+			requester: bob,
+			method:    http.MethodGet,
+			err:       ErrUserNotAllowed,
+		},
+		{
+			name: "named user anonymous",
+			link: ShareLink{
+				Mode: ModeInteract, ExpiresAt: now.Add(time.Hour), Usernames: []string{"alice"},
+			},
+			method: http.MethodGet,
+			err:    ErrUserNotAllowed,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.link.Authorize(tc.requester, tc.method, tc.webSocket, now)
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	db.SetTokenKeys(&model.AuthTokenKeypair{PublicKey: publicKey, PrivateKey: privateKey})
+
+	l := &ShareLink{ID: 3, TaskID: "task", ExpiresAt: time.Now().Add(time.Hour)}
+	token, err := Token(l)
+	require.NoError(t, err)
+
+	id, err := parseToken(token, "task")
+	require.NoError(t, err)
+	require.Equal(t, 3, id)
+
+	_, err = parseToken(token, "other-task")
+	require.ErrorIs(t, err, ErrInvalidToken)
+	_, err = parseToken(token+"x", "task")
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
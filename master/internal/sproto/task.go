@@ -34,15 +34,21 @@ type (
 		IsUserVisible bool
 		State         SchedulingState
 		Name          string
+		// Workspace and Username are who the allocation is for, to share resource pools fairly.
+		Workspace string
+		Username  string
 
 		// Resource configuration.
 		SlotsNeeded         int
 		ResourcePool        string
 		FittingRequirements FittingRequirements
+		// CapacityType, if set, restricts the allocation to agents on that kind of capacity.
+		CapacityType model.CapacityType
 
 		// Behavioral configuration.
 		Preemption  PreemptionConfig
 		IdleTimeout *IdleTimeoutConfig
+		Watchdog    *WatchdogConfig
 		ProxyPorts  []*ProxyPortConfig
 		Restore     bool
 		ProxyTLS    bool
@@ -60,11 +66,22 @@ type (
 		UseRunnerState  bool
 		TimeoutDuration time.Duration
 		Debug           bool
+		// Suspend, if set, suspends the task on idle timeout instead of terminating it.
+		Suspend bool
+	}
+
+	// WatchdogConfig configures what happens when an allocation makes no progress.
+	WatchdogConfig struct {
+		TimeoutDuration time.Duration
+		Action          expconf.WatchdogAction
 	}
 
 	// PreemptionConfig configures task preemption.
 	PreemptionConfig struct {
-		Preemptible     bool
+		Preemptible bool
+		// Suspendable tasks watch for preemption to save their state when they are suspended,
+		// but the scheduler never preempts them.
+		Suspendable     bool
 		TimeoutDuration time.Duration
 	}
 
@@ -115,6 +132,19 @@ type (
 	}
 )
 
+// NewWatchdogConfig returns the watchdog configuration of an allocation from the watchdog of its
+// task's config, or nil if it has none. The action defaults to notifying.
+func NewWatchdogConfig(cfg *expconf.WatchdogConfig) *WatchdogConfig {
+	if cfg == nil {
+		return nil
+	}
+	action := expconf.WatchdogActionNotify
+	if cfg.RawAction != nil {
+		action = *cfg.RawAction
+	}
+	return &WatchdogConfig{TimeoutDuration: cfg.TimeoutDuration(), Action: action}
+}
+
 // ResourcesEvent describes a change in status or state of an allocation's resources.
 type ResourcesEvent interface{ ResourcesEvent() }
 
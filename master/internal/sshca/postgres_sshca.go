@@ -0,0 +1,109 @@
+package sshca
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/protoutils"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// ErrInvalidPublicKey is returned when asked to sign something that is not an SSH public key.
+var ErrInvalidPublicKey = errors.New("invalid SSH public key")
+
+// authorityKeypair is the keypair of the certificate authority.
+type authorityKeypair struct {
+	bun.BaseModel `bun:"table:ssh_certificate_authority"`
+	PublicKey     ed25519.PublicKey  `bun:"public_key"`
+	PrivateKey    ed25519.PrivateKey `bun:"private_key"`
+}
+
+// Certificate is the audit record of an issued SSH certificate.
+type Certificate struct {
+	bun.BaseModel `bun:"table:ssh_certificates"`
+
+	Serial               int64        `bun:"serial,pk"`
+	KeyID                string       `bun:"key_id"`
+	UserID               model.UserID `bun:"user_id"`
+	TaskID               model.TaskID `bun:"task_id"`
+	Principals           []string     `bun:"principals,array"`
+	PublicKeyFingerprint string       `bun:"public_key_fingerprint"`
+	ValidAfter           time.Time    `bun:"valid_after"`
+	ValidBefore          time.Time    `bun:"valid_before"`
+	CreatedAt            time.Time    `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// Proto converts the certificate to its protobuf representation.
+func (c *Certificate) Proto() *apiv1.SSHCertificate {
+	return &apiv1.SSHCertificate{
+		Serial:               c.Serial,
+		KeyId:                c.KeyID,
+		UserId:               int32(c.UserID),
+		TaskId:               string(c.TaskID),
+		Principals:           c.Principals,
+		PublicKeyFingerprint: c.PublicKeyFingerprint,
+		ValidAfter:           protoutils.ToTimestamp(c.ValidAfter),
+		ValidBefore:          protoutils.ToTimestamp(c.ValidBefore),
+		CreatedAt:            protoutils.ToTimestamp(c.CreatedAt),
+	}
+}
+
+// getOrCreateKey returns the private key of the certificate authority, generating and storing
+// one if the cluster has none yet.
+func getOrCreateKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	var keypair authorityKeypair
+	switch err := db.Bun().NewSelect().Model(&keypair).Limit(1).Scan(ctx); {
+	case err == nil:
+		return keypair.PrivateKey, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	keypair = authorityKeypair{PublicKey: publicKey, PrivateKey: privateKey}
+	if _, err := db.Bun().NewInsert().Model(&keypair).Exec(ctx); err != nil {
+		return nil, err
+	}
+	return privateKey, nil
+}
+
+func insertCertificate(ctx context.Context, c *Certificate) error {
+	_, err := db.Bun().NewInsert().Model(c).Returning("created_at").Exec(ctx)
+	return err
+}
+
+// ListCertificates returns the issued certificates, most recent first, optionally limited to the
+// given user and task, along with the total number of matching certificates.
+func ListCertificates(
+	ctx context.Context, userID model.UserID, taskID model.TaskID, offset, limit int,
+) ([]*Certificate, int, error) {
+	query := db.Bun().NewSelect().Model((*Certificate)(nil))
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if taskID != "" {
+		query = query.Where("task_id = ?", taskID)
+	}
+	total, err := query.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var certs []*Certificate
+	err = db.PaginateBun(query, "created_at", db.SortDirectionDesc, offset, limit).
+		Scan(ctx, &certs)
+	if err != nil {
+		return nil, 0, err
+	}
+	return certs, total, nil
+}
@@ -0,0 +1,133 @@
+// Package sshca implements the SSH certificate authority of the master, which signs short-lived
+// user certificates granting access to the SSH servers of shells.
+package sshca
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/pkg/model"
+	pkgssh "github.com/determined-ai/determined/master/pkg/ssh"
+)
+
+// Allow for some clock skew between the master and the containers checking certificates.
+const clockSkew = time.Minute
+
+// The extensions granted to users by their certificates, matching what ssh-keygen grants by
+// default.
+var certificateExtensions = map[string]string{
+	"permit-X11-forwarding":   "",
+	"permit-agent-forwarding": "",
+	"permit-port-forwarding":  "",
+	"permit-pty":              "",
+	"permit-user-rc":          "",
+}
+
+// CertificateAuthority signs SSH user certificates.
+type CertificateAuthority struct {
+	signer   ssh.Signer
+	lifespan time.Duration
+}
+
+// New returns the certificate authority of the cluster, creating its key on first use.
+func New(ctx context.Context, conf config.SSHCertificateAuthorityConfig) (
+	*CertificateAuthority, error,
+) {
+	key, err := getOrCreateKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading SSH certificate authority key: %w", err)
+	}
+	return newCertificateAuthority(key, time.Duration(conf.CertificateLifespan))
+}
+
+func newCertificateAuthority(
+	key ed25519.PrivateKey, lifespan time.Duration,
+) (*CertificateAuthority, error) {
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &CertificateAuthority{signer: signer, lifespan: lifespan}, nil
+}
+
+// AuthorizedKey returns the public key of the certificate authority in authorized_keys format.
+func (ca *CertificateAuthority) AuthorizedKey() []byte {
+	return ssh.MarshalAuthorizedKey(ca.signer.PublicKey())
+}
+
+// SignShellKey signs a certificate for the given public key, in authorized_keys format, that
+// lets the user log in to the SSH server of the given task until the certificate expires. Every
+// issued certificate is recorded for auditing.
+func (ca *CertificateAuthority) SignShellKey(
+	ctx context.Context, user model.User, taskID model.TaskID, authorizedKey []byte,
+) (*ssh.Certificate, error) {
+	cert, record, err := ca.sign(user, taskID, authorizedKey, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := insertCertificate(ctx, record); err != nil {
+		return nil, fmt.Errorf("recording SSH certificate: %w", err)
+	}
+	return cert, nil
+}
+
+func (ca *CertificateAuthority) sign(
+	user model.User, taskID model.TaskID, authorizedKey []byte, now time.Time,
+) (*ssh.Certificate, *Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(authorizedKey) //nolint:dogsled
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	if _, ok := pub.(*ssh.Certificate); ok {
+		return nil, nil, fmt.Errorf("%w: certificates cannot be signed", ErrInvalidPublicKey)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	principal := pkgssh.TaskPrincipal(string(taskID))
+	validAfter := now.Add(-clockSkew).Truncate(time.Second)
+	validBefore := now.Add(ca.lifespan).Truncate(time.Second)
+	cert := &ssh.Certificate{
+		Key:             pub,
+		Serial:          serial,
+		CertType:        ssh.UserCert,
+		KeyId:           fmt.Sprintf("%s@%s", user.Username, taskID),
+		ValidPrincipals: []string{principal},
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validBefore.Unix()),
+		Permissions:     ssh.Permissions{Extensions: certificateExtensions},
+	}
+	if err := cert.SignCert(rand.Reader, ca.signer); err != nil {
+		return nil, nil, fmt.Errorf("signing SSH certificate: %w", err)
+	}
+
+	return cert, &Certificate{
+		Serial:               int64(serial),
+		KeyID:                cert.KeyId,
+		UserID:               user.ID,
+		TaskID:               taskID,
+		Principals:           cert.ValidPrincipals,
+		PublicKeyFingerprint: ssh.FingerprintSHA256(pub),
+		ValidAfter:           validAfter,
+		ValidBefore:          validBefore,
+	}, nil
+}
+
+// randomSerial returns a random certificate serial that fits in a postgres bigint.
+func randomSerial() (uint64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, fmt.Errorf("generating SSH certificate serial: %w", err)
+	}
+	return binary.BigEndian.Uint64(b[:]) & math.MaxInt64, nil
+}
@@ -0,0 +1,81 @@
+package sshca
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/pkg/model"
+	pkgssh "github.com/determined-ai/determined/master/pkg/ssh"
+)
+
+func TestSign(t *testing.T) {
+	_, caKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	ca, err := newCertificateAuthority(caKey, time.Hour)
+	require.NoError(t, err)
+
+	keys, err := pkgssh.GenerateKey(config.SSHConfig{KeyType: config.KeyTypeED25519})
+	require.NoError(t, err)
+	userKey, err := ssh.ParsePrivateKey(keys.PrivateKey)
+	require.NoError(t, err)
+
+	user := model.User{ID: 7, Username: "alice"}
+	taskID := model.TaskID("shell-task")
+	now := time.Now()
+	cert, record, err := ca.sign(user, taskID, keys.PublicKey, now)
+	require.NoError(t, err)
+
+	principal := pkgssh.TaskPrincipal(string(taskID))
+	require.Equal(t, []string{principal}, cert.ValidPrincipals)
+	require.Equal(t, "alice@shell-task", cert.KeyId)
+	require.Equal(t, user.ID, record.UserID)
+	require.Equal(t, taskID, record.TaskID)
+	require.Equal(t, int64(cert.Serial), record.Serial)
+	require.GreaterOrEqual(t, record.Serial, int64(0))
+	require.Equal(t, ssh.FingerprintSHA256(userKey.PublicKey()), record.PublicKeyFingerprint)
+
+	// A server trusting the certificate authority accepts the certificate for the task only.
+	checker := ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return string(auth.Marshal()) == string(ca.signer.PublicKey().Marshal())
+		},
+		Clock: func() time.Time { return now },
+	}
+	_, err = checker.Authenticate(connMetadata{user: principal}, cert)
+	require.NoError(t, err)
+	_, err = checker.Authenticate(connMetadata{user: pkgssh.TaskPrincipal("other")}, cert)
+	require.Error(t, err)
+
+	checker.Clock = func() time.Time { return now.Add(2 * time.Hour) }
+	_, err = checker.Authenticate(connMetadata{user: principal}, cert)
+	require.Error(t, err)
+}
+
+func TestSignInvalidKey(t *testing.T) {
+	_, caKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	ca, err := newCertificateAuthority(caKey, time.Hour)
+	require.NoError(t, err)
+
+	_, _, err = ca.sign(model.User{}, "task", []byte("not a key"), time.Now())
+	require.ErrorIs(t, err, ErrInvalidPublicKey)
+
+	keys, err := pkgssh.GenerateKey(config.SSHConfig{KeyType: config.KeyTypeED25519})
+	require.NoError(t, err)
+	cert, _, err := ca.sign(model.User{}, "task", keys.PublicKey, time.Now())
+	require.NoError(t, err)
+	_, _, err = ca.sign(model.User{}, "task", ssh.MarshalAuthorizedKey(cert), time.Now())
+	require.ErrorIs(t, err, ErrInvalidPublicKey)
+}
+
+type connMetadata struct {
+	ssh.ConnMetadata
+	user string
+}
+
+func (c connMetadata) User() string { return c.user }
@@ -15,6 +15,7 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/cluster"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/logpattern"
 	"github.com/determined-ai/determined/master/internal/portregistry"
 	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/internal/proxy"
@@ -24,11 +25,13 @@ import (
 	"github.com/determined-ai/determined/master/internal/task/preemptible"
 	"github.com/determined-ai/determined/master/internal/task/tasklogger"
 	"github.com/determined-ai/determined/master/internal/task/taskmodel"
+	"github.com/determined-ai/determined/master/internal/task/watchdog"
 	"github.com/determined-ai/determined/master/internal/telemetry"
 	"github.com/determined-ai/determined/master/pkg/cproto"
 	detLogger "github.com/determined-ai/determined/master/pkg/logger"
 	"github.com/determined-ai/determined/master/pkg/model"
 	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
 	"github.com/determined-ai/determined/master/pkg/syncx/waitgroupx"
 	"github.com/determined-ai/determined/master/pkg/tasks"
 )
@@ -43,6 +46,9 @@ const (
 	KillAllocation AllocationSignal = "kill"
 	// TerminateAllocation is the signal to kill an allocation; analogous to SIGTERM.
 	TerminateAllocation AllocationSignal = "terminate"
+	// SuspendAllocation is the signal to gracefully stop a suspendable allocation, which saves its
+	// state on preemption; other allocations are killed.
+	SuspendAllocation AllocationSignal = "suspend"
 )
 
 // AllocationState requests allocation state. A copy is filled and returned.
@@ -127,6 +133,8 @@ type allocation struct {
 	// We send a kill when we terminate a task forcibly. we terminate forcibly when a container
 	// exits non zero. we don't need to send all these kills, so this exists.
 	killCooldown *time.Time
+	// Tracks why the watchdog killed the allocation, if it did.
+	stallErr error
 	// tracks if we have finished termination.
 	exited *AllocationExited
 
@@ -140,6 +148,7 @@ type allocation struct {
 	logCtx          detLogger.Context
 	restored        bool
 	portsRegistered bool
+	watchdogStarted bool
 
 	closers []func()
 
@@ -318,6 +327,8 @@ func (a *allocation) Signal(sig AllocationSignal, reason string) {
 		a.tryExitOrKill(reason)
 	case TerminateAllocation:
 		a.tryExitOrTerminate(reason, false)
+	case SuspendAllocation:
+		a.tryExitOrTerminate(reason, a.req.Preemption.Suspendable)
 	}
 }
 
@@ -344,6 +355,7 @@ func (a *allocation) SetProxyAddress(ctx context.Context, address string) error
 // SendContainerLog sends a container log, enriched with metadata from the allocation.
 func (a *allocation) SendContainerLog(log *sproto.ContainerLog) {
 	a.sendTaskLog(log.ToTaskLog())
+	watchdog.RecordProgress(a.req.TaskID)
 }
 
 // SetWaiting moves the allocation to the waiting state if it has not progressed past it yet.
@@ -640,7 +652,7 @@ func (a *allocation) resourcesAllocated(msg *sproto.ResourcesAllocated) error {
 		return errors.Wrap(err, "recording task queued stats")
 	}
 
-	if a.req.Preemption.Preemptible {
+	if a.req.Preemption.Preemptible || a.req.Preemption.Suspendable {
 		preemptible.Register(a.req.AllocationID.String())
 		a.closers = append(a.closers, func() {
 			preemptible.Unregister(a.req.AllocationID.String())
@@ -649,6 +661,11 @@ func (a *allocation) resourcesAllocated(msg *sproto.ResourcesAllocated) error {
 
 	if cfg := a.req.IdleTimeout; cfg != nil {
 		idle.Register(*cfg, func(ctx context.Context, err error) {
+			if cfg.Suspend {
+				a.syslog.WithError(err).Infof("suspending %s due to inactivity", a.req.Name)
+				a.Signal(SuspendAllocation, err.Error())
+				return
+			}
 			a.syslog.WithError(err).Infof("killing %s due to inactivity", a.req.Name)
 			a.Signal(TerminateAllocation, err.Error())
 		})
@@ -663,6 +680,7 @@ func (a *allocation) resourcesAllocated(msg *sproto.ResourcesAllocated) error {
 		}
 		a.portsRegistered = true
 		if a.getModelState() == model.AllocationStateRunning {
+			a.startWatchdog()
 			// Restore proxies.
 			if len(a.req.ProxyPorts) > 0 {
 				for _, r := range a.resources {
@@ -773,6 +791,7 @@ func (a *allocation) resourcesStateChanged(msg *sproto.ResourcesStateChanged) {
 		a.sendTaskLog(&model.TaskLog{
 			Log: fmt.Sprintf("Resources for %s have started", a.req.Name),
 		})
+		a.startWatchdog()
 
 		prom.AssociateAllocationTask(a.req.AllocationID, a.req.TaskID, a.req.Name, a.req.JobID)
 		prom.AddAllocationResources(a.resources[msg.ResourcesID].Summary(), msg.ResourcesStarted)
@@ -1151,9 +1170,13 @@ func (a *allocation) calculateExitStatus(reason string) (
 	exitErr error,
 ) {
 	switch {
+	case a.stallErr != nil:
+		return fmt.Sprintf("allocation killed by watchdog: %s", a.stallErr), false, logrus.ErrorLevel,
+			a.stallErr
 	case a.killedWhileRunning:
 		return fmt.Sprintf("allocation killed after %s", reason), false, logrus.InfoLevel, nil
-	case a.req.Preemption.Preemptible && preemptible.Acknowledged(a.req.AllocationID.String()):
+	case (a.req.Preemption.Preemptible || a.req.Preemption.Suspendable) &&
+		preemptible.Acknowledged(a.req.AllocationID.String()):
 		return fmt.Sprintf("allocation preempted after %s", reason), false, logrus.InfoLevel, nil
 	case a.exitErr == nil && len(a.resources.exited()) > 0:
 		return fmt.Sprintf("allocation stopped early after %s", reason), true, logrus.InfoLevel, nil
@@ -1186,6 +1209,62 @@ func (a *allocation) calculateExitStatus(reason string) (
 	}
 }
 
+// startWatchdog starts watching the allocation for progress, once it is running, if it has a
+// watchdog.
+func (a *allocation) startWatchdog() {
+	cfg := a.req.Watchdog
+	if cfg == nil || a.watchdogStarted {
+		return
+	}
+	a.watchdogStarted = true
+
+	watchdog.Register(a.req.TaskID, *cfg, func(ctx context.Context, err error) {
+		a.stalled(ctx, *cfg, err)
+	})
+	a.closers = append(a.closers, func() {
+		watchdog.Unregister(a.req.TaskID)
+	})
+	if err := watchdog.Persist(context.TODO(), a.req.TaskID, *cfg); err != nil {
+		a.syslog.WithError(err).Error("failed to persist watchdog")
+	}
+}
+
+// stalled handles the watchdog tripping because the allocation made no progress.
+func (a *allocation) stalled(ctx context.Context, cfg sproto.WatchdogConfig, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.exited != nil {
+		return
+	}
+
+	a.syslog.WithError(err).Warnf("%s is stalled, taking watchdog action %s", a.req.Name, cfg.Action)
+	a.sendTaskLog(&model.TaskLog{
+		Level: ptrs.Ptr(model.LogLevelError),
+		Log:   fmt.Sprintf("%s is stalled (%s), watchdog action: %s", a.req.Name, err, cfg.Action),
+	})
+	if err := watchdog.PersistTrip(ctx, a.req.TaskID, time.Now()); err != nil {
+		a.syslog.WithError(err).Error("failed to persist watchdog trip")
+	}
+
+	switch cfg.Action {
+	case expconf.WatchdogActionNotify:
+		return
+	case expconf.WatchdogActionExcludeNode:
+		var nodes []string
+		for _, r := range a.resources {
+			for agentID := range r.Summary().AgentDevices {
+				nodes = append(nodes, string(agentID))
+			}
+		}
+		reason := fmt.Sprintf("stalled: %s", err)
+		if err := logpattern.ExcludeNodes(ctx, a.req.TaskID, nodes, reason); err != nil {
+			a.syslog.WithError(err).Error("failed to exclude nodes of stalled allocation")
+		}
+	}
+	a.stallErr = err
+	a.tryExitOrKill(err.Error())
+}
+
 // markResourcesStarted persists start information.
 func (a *allocation) markResourcesStarted() {
 	a.model.StartTime = ptrs.Ptr(time.Now().UTC().Truncate(time.Millisecond))
@@ -0,0 +1,84 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/syncx/mapx"
+)
+
+var watchers = mapx.New[model.TaskID, *Watcher]()
+
+// Register a watchdog for the running allocation of a task to the default service. The trip
+// function is called each time the task makes no progress for longer than the timeout, until
+// Unregister is called.
+func Register(taskID model.TaskID, cfg sproto.WatchdogConfig, trip TripFn) {
+	if w, ok := watchers.Load(taskID); ok {
+		w.Close()
+	}
+	watchers.Store(taskID, New(string(taskID), cfg, trip))
+}
+
+// Unregister removes the watchdog of a task from the service.
+func Unregister(taskID model.TaskID) {
+	w, ok := watchers.Delete(taskID)
+	if !ok {
+		return
+	}
+	w.Close()
+}
+
+// RecordProgress records progress for a task, if it is watched.
+func RecordProgress(taskID model.TaskID) {
+	w, ok := watchers.Load(taskID)
+	if !ok {
+		return
+	}
+	w.RecordProgress(time.Now())
+}
+
+// LastProgress returns when a task last made progress, if it is watched.
+func LastProgress(taskID model.TaskID) (time.Time, bool) {
+	w, ok := watchers.Load(taskID)
+	if !ok {
+		return time.Time{}, false
+	}
+	return w.LastProgress(), true
+}
+
+// Any returns whether any task is watched, so callers can skip looking up the tasks to record
+// progress for.
+func Any() bool {
+	return watchers.Len() > 0
+}
+
+// Persist records the watchdog configuration of a task in its state, keeping the count of times
+// it tripped.
+func Persist(ctx context.Context, taskID model.TaskID, cfg sproto.WatchdogConfig) error {
+	if _, err := db.Bun().NewUpdate().Table("tasks").
+		Set("watchdog = COALESCE(watchdog, '{}'::jsonb) || "+
+			"jsonb_build_object('timeout_seconds', ?::int, 'action', ?::text)",
+			int(cfg.TimeoutDuration.Seconds()), cfg.Action).
+		Where("task_id = ?", taskID).
+		Exec(ctx); err != nil {
+		return fmt.Errorf("persisting watchdog of task %s: %w", taskID, err)
+	}
+	return nil
+}
+
+// PersistTrip records that the watchdog of a task tripped in its state.
+func PersistTrip(ctx context.Context, taskID model.TaskID, at time.Time) error {
+	if _, err := db.Bun().NewUpdate().Table("tasks").
+		Set("watchdog = COALESCE(watchdog, '{}'::jsonb) || jsonb_build_object("+
+			"'trips', COALESCE((watchdog->>'trips')::int, 0) + 1, 'tripped_time', ?::text)",
+			at.UTC().Format(time.RFC3339Nano)).
+		Where("task_id = ?", taskID).
+		Exec(ctx); err != nil {
+		return fmt.Errorf("persisting watchdog trip of task %s: %w", taskID, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Package watchdog watches allocations for progress, to handle allocations that hang without
+// exiting, such as those stuck in a collective or a dataloader.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/syncx/waitgroupx"
+)
+
+var syslog = log.WithField("component", "watchdog")
+
+// ErrStalled indicates that, according to the Watcher configuration, the task made no progress.
+var ErrStalled = fmt.Errorf("task is stalled")
+
+// TickInterval is the interval at which to check for progress.
+var TickInterval = 5 * time.Second
+
+// TripFn is called when the task makes no progress for longer than the timeout.
+type TripFn func(context.Context, error)
+
+// Watcher watches the progress of a task and trips when it makes none for longer than the timeout.
+// After tripping, it starts over as if the task just made progress.
+type Watcher struct {
+	// System dependencies.
+	syslog *log.Entry
+
+	// Configuration.
+	cfg  sproto.WatchdogConfig
+	trip TripFn
+
+	// Mutable internal state.
+	mu           sync.Mutex
+	wg           waitgroupx.Group
+	lastProgress time.Time
+}
+
+// New creates a new watchdog that counts the task as having made progress now. The trip function
+// can be called until Close is called.
+func New(id string, cfg sproto.WatchdogConfig, trip TripFn) *Watcher {
+	w := &Watcher{
+		syslog:       syslog.WithField("id", id),
+		cfg:          cfg,
+		trip:         trip,
+		wg:           waitgroupx.WithContext(context.Background()),
+		lastProgress: time.Now(),
+	}
+
+	w.wg.Go(w.run)
+
+	return w
+}
+
+// RecordProgress notes the progress to delay tripping.
+func (w *Watcher) RecordProgress(instant time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if instant.After(w.lastProgress) {
+		w.lastProgress = instant
+	}
+}
+
+// LastProgress returns when the task last made progress.
+func (w *Watcher) LastProgress() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastProgress
+}
+
+// Close stops the watchdog. It does not wait for a trip in progress, so that it can be closed
+// while holding locks the trip function takes.
+func (w *Watcher) Close() {
+	w.wg.Cancel()
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	t := time.NewTicker(TickInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+
+		w.tick(ctx, time.Now())
+	}
+}
+
+func (w *Watcher) tick(ctx context.Context, now time.Time) {
+	w.mu.Lock()
+	lastProgress := w.lastProgress
+	stalled := now.After(lastProgress.Add(w.cfg.TimeoutDuration))
+	if stalled {
+		w.lastProgress = now
+	}
+	w.mu.Unlock()
+
+	w.syslog.WithField("lastProgress", lastProgress.Format(time.RFC3339)).
+		Debugf("watchdog ticked")
+	if stalled && ctx.Err() == nil {
+		w.trip(ctx, fmt.Errorf(
+			"no progress for more than %s: %w", w.cfg.TimeoutDuration.Round(time.Second), ErrStalled,
+		))
+	}
+}
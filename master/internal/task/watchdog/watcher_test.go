@@ -0,0 +1,71 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/sproto"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+func TestWatcherTick(t *testing.T) {
+	var trips []error
+	w := &Watcher{
+		syslog: syslog,
+		cfg:    sproto.WatchdogConfig{TimeoutDuration: time.Minute},
+		trip: func(_ context.Context, err error) {
+			trips = append(trips, err)
+		},
+	}
+	start := time.Now()
+	w.lastProgress = start
+	ctx := context.Background()
+
+	w.tick(ctx, start.Add(30*time.Second))
+	require.Empty(t, trips)
+
+	w.RecordProgress(start.Add(45 * time.Second))
+	w.tick(ctx, start.Add(90*time.Second))
+	require.Empty(t, trips)
+
+	// Progress older than the latest is ignored.
+	w.RecordProgress(start)
+	w.tick(ctx, start.Add(2*time.Minute))
+	require.Len(t, trips, 1)
+	require.True(t, errors.Is(trips[0], ErrStalled))
+
+	// After tripping, the watchdog waits a full timeout before tripping again.
+	require.Equal(t, start.Add(2*time.Minute), w.LastProgress())
+	w.tick(ctx, start.Add(150*time.Second))
+	require.Len(t, trips, 1)
+	w.tick(ctx, start.Add(3*time.Minute+time.Second))
+	require.Len(t, trips, 2)
+}
+
+func TestWatchdogService(t *testing.T) {
+	TickInterval = 10 * time.Millisecond
+	var trips atomic.Int32
+	taskID := model.NewTaskID()
+	require.False(t, Any())
+
+	Register(taskID, sproto.WatchdogConfig{TimeoutDuration: 50 * time.Millisecond},
+		func(context.Context, error) {
+			trips.Add(1)
+		})
+	require.True(t, Any())
+	RecordProgress(taskID)
+	_, ok := LastProgress(taskID)
+	require.True(t, ok)
+
+	require.Eventually(t, func() bool { return trips.Load() > 0 }, 5*time.Second, TickInterval)
+
+	Unregister(taskID)
+	require.False(t, Any())
+	_, ok = LastProgress(taskID)
+	require.False(t, ok)
+}
@@ -14,8 +14,10 @@ import (
 
 	"github.com/determined-ai/determined/master/internal/config"
 	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/internal/elasticslots"
 	"github.com/determined-ai/determined/master/internal/experiment"
 	"github.com/determined-ai/determined/master/internal/logpattern"
+	"github.com/determined-ai/determined/master/internal/otelmetrics"
 	"github.com/determined-ai/determined/master/internal/prom"
 	"github.com/determined-ai/determined/master/internal/rm"
 	"github.com/determined-ai/determined/master/internal/sproto"
@@ -94,6 +96,8 @@ type trial struct {
 
 	// a ref to the current allocation
 	allocationID *model.AllocationID
+	// resizedAt is when an elastic trial was created or last resized.
+	resizedAt time.Time
 	// a note of the user initated exit reason, if any.
 	userInitiatedExit *model.ExitedReason
 
@@ -131,6 +135,7 @@ func newTrial(
 		experimentID:      experimentID,
 		state:             initialState,
 		searcher:          searcher,
+		resizedAt:         time.Now(),
 
 		db:     pgDB,
 		rm:     rm,
@@ -194,6 +199,9 @@ func (t *trial) exit(reason *model.ExitedReason) {
 	if err := t.close(); err != nil {
 		t.syslog.WithError(err).Error("error closing trial")
 	}
+	if t.idSet {
+		otelmetrics.TrialEnded(int32(t.id))
+	}
 	go t.exitCallback(t.searcher.Create.RequestID, reason)
 }
 
@@ -266,6 +274,51 @@ func (t *trial) PatchRP(rp string) {
 	}
 }
 
+// MaybeResize resizes an elastic trial to the number of slots its resource pool can spare. The
+// allocation is preempted like in PatchRP, so the trial checkpoints and restarts at the new size.
+func (t *trial) MaybeResize(c *elasticslots.PoolCapacity) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cfg := t.config.Resources().Elastic()
+	if cfg == nil || t.state != model.ActiveState || t.allocationID == nil ||
+		time.Since(t.resizedAt) < cfg.CooldownDuration() {
+		return
+	}
+	current := t.config.Resources().SlotsPerTrial()
+	target := c.Target(*cfg, t.jobID, current)
+	if target == current {
+		return
+	}
+
+	reason := fmt.Sprintf("growing elastic trial from %d to %d idle slots", current, target)
+	if target < current {
+		reason = fmt.Sprintf("shrinking elastic trial from %d to %d slots for waiting jobs", current, target)
+	}
+	t.syslog.Info(reason)
+	if err := elasticslots.AddResizeEvent(context.TODO(), &elasticslots.ResizeEvent{
+		TrialID:   t.id,
+		FromSlots: current,
+		ToSlots:   target,
+		Reason:    reason,
+	}); err != nil {
+		t.syslog.WithError(err).Warn("could not record elastic trial resize")
+		return
+	}
+	t.setSlots(target)
+	t.resizedAt = time.Now()
+
+	if err := task.DefaultService.Signal(*t.allocationID, task.TerminateAllocation, reason); err != nil {
+		t.syslog.WithError(err).Warn("could not preempt allocation to resize elastic trial")
+	}
+}
+
+func (t *trial) setSlots(slots int) {
+	resources := t.config.Resources()
+	resources.SetSlotsPerTrial(slots)
+	t.config.SetResources(resources)
+}
+
 func (t *trial) SetUserInitiatedEarlyExit(req experiment.UserInitiatedEarlyTrialExit) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -333,6 +386,17 @@ func (t *trial) recover() error {
 	}
 	t.runID = runID
 	t.restarts = restarts
+
+	if t.config.Resources().Elastic() != nil {
+		slots, err := elasticslots.LatestSlots(context.TODO(), t.id)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+		case err != nil:
+			return errors.Wrap(err, "restoring elastic trial slots")
+		default:
+			t.setSlots(slots)
+		}
+	}
 	return nil
 }
 
@@ -417,6 +481,8 @@ func (t *trial) maybeAllocateTask() error {
 			RequestTime:       time.Now().UTC(),
 			IsUserVisible:     true,
 			Name:              name,
+			Workspace:         t.taskSpec.Workspace,
+			Username:          t.ownerUsername(),
 			SlotsNeeded:       t.config.Resources().SlotsPerTrial(),
 			ResourcePool:      t.config.Resources().ResourcePool(),
 			FittingRequirements: sproto.FittingRequirements{
@@ -426,11 +492,13 @@ func (t *trial) maybeAllocateTask() error {
 				Preemptible:     true,
 				TimeoutDuration: time.Duration(preemptionTimeout) * time.Second,
 			},
-			Restore: true,
+			Watchdog: sproto.NewWatchdogConfig(t.config.RawWatchdog),
+			Restore:  true,
 			ProxyPorts: sproto.NewProxyPortConfig(
 				tasks.TrialSpecProxyPorts(t.taskSpec, t.config), t.taskID),
 
 			BlockedNodes: blockedNodes,
+			CapacityType: t.capacityType(),
 		}
 		t.syslog.
 			WithField("allocation-id", ar.AllocationID).
@@ -464,6 +532,8 @@ func (t *trial) maybeAllocateTask() error {
 		JobSubmissionTime: t.jobSubmissionTime,
 		IsUserVisible:     true,
 		Name:              name,
+		Workspace:         t.taskSpec.Workspace,
+		Username:          t.ownerUsername(),
 
 		SlotsNeeded:  t.config.Resources().SlotsPerTrial(),
 		ResourcePool: t.config.Resources().ResourcePool(),
@@ -475,9 +545,11 @@ func (t *trial) maybeAllocateTask() error {
 			Preemptible:     true,
 			TimeoutDuration: time.Duration(preemptionTimeout) * time.Second,
 		},
+		Watchdog:   sproto.NewWatchdogConfig(t.config.RawWatchdog),
 		ProxyPorts: sproto.NewProxyPortConfig(tasks.TrialSpecProxyPorts(t.taskSpec, t.config), t.taskID),
 
 		BlockedNodes: blockedNodes,
+		CapacityType: t.capacityType(),
 	}
 
 	t.syslog.
@@ -521,6 +593,19 @@ func (t *trial) addTask(ctx context.Context) error {
 	})
 }
 
+// capacityType returns the kind of capacity the trial is restricted to, if any.
+func (t *trial) capacityType() model.CapacityType {
+	return model.CapacityTypeOf(t.config.Resources().CapacityType())
+}
+
+// ownerUsername returns the name of the user that owns the experiment of the trial.
+func (t *trial) ownerUsername() string {
+	if t.taskSpec.Owner == nil {
+		return ""
+	}
+	return t.taskSpec.Owner.Username
+}
+
 func (t *trial) buildTaskSpecifier() (*tasks.TrialSpec, error) {
 	if err := t.db.UpdateTrialFields(t.id, nil, t.runID, 0); err != nil {
 		return nil, errors.Wrap(err, "failed to save trial run ID")
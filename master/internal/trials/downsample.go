@@ -0,0 +1,413 @@
+package trials
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+// sample is a single point of a metric series as seen by the downsamplers. x is the position of
+// the point on the axis the series is laid out on.
+type sample struct {
+	x       float64
+	batches uint
+	time    time.Time
+	values  map[string]any
+}
+
+// MetricsBucket holds the aggregates of the metric reports of a trial that fall into one bucket
+// of a downsampled series.
+type MetricsBucket struct {
+	StartBatches uint
+	EndBatches   uint
+	StartTime    time.Time
+	EndTime      time.Time
+	Count        int
+	Min          map[string]float64
+	Max          map[string]float64
+	Mean         map[string]float64
+}
+
+// Proto converts the bucket to its API representation.
+func (b MetricsBucket) Proto(trialID int32, group model.MetricGroup) *apiv1.MetricsBucket {
+	toStruct := func(m map[string]float64) *structpb.Struct {
+		fields := make(map[string]*structpb.Value, len(m))
+		for k, v := range m {
+			fields[k] = metricValueProto(v)
+		}
+		return &structpb.Struct{Fields: fields}
+	}
+	return &apiv1.MetricsBucket{
+		TrialId:      trialID,
+		Group:        group.ToString(),
+		StartBatches: int32(b.StartBatches),
+		EndBatches:   int32(b.EndBatches),
+		StartTime:    timestamppb.New(b.StartTime),
+		EndTime:      timestamppb.New(b.EndTime),
+		Count:        int32(b.Count),
+		Min:          toStruct(b.Min),
+		Max:          toStruct(b.Max),
+		Mean:         toStruct(b.Mean),
+	}
+}
+
+// metricValueProto converts a metric value to a protobuf value. Non-finite values are encoded as
+// strings the way they are stored in Postgres, since they cannot be represented in JSON.
+func metricValueProto(v float64) *structpb.Value {
+	switch {
+	case math.IsNaN(v):
+		return structpb.NewStringValue(db.NaNPostgresString)
+	case math.IsInf(v, 1):
+		return structpb.NewStringValue(db.InfPostgresString)
+	case math.IsInf(v, -1):
+		return structpb.NewStringValue(db.NegInfPostgresString)
+	default:
+		return structpb.NewNumberValue(v)
+	}
+}
+
+// metricValueToFloat converts a reported metric value to a float, accounting for the way
+// non-finite values are stored in Postgres. It returns false for non-numeric values.
+func metricValueToFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		switch v {
+		case db.NaNPostgresString:
+			return math.NaN(), true
+		case db.InfPostgresString:
+			return math.Inf(1), true
+		case db.NegInfPostgresString:
+			return math.Inf(-1), true
+		}
+	}
+	return 0, false
+}
+
+// axisValue converts a value of the column a series is ordered by to a position on the axis.
+func axisValue(v any) (float64, bool) {
+	if t, ok := v.(time.Time); ok {
+		return timeToAxis(t), true
+	}
+	return metricValueToFloat(v)
+}
+
+func timeToAxis(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// lttb returns the indices of at most threshold points of the series (xs, ys) picked by the
+// Largest-Triangle-Three-Buckets algorithm. xs must be sorted. The first and last points are
+// always kept. Within a bucket, a point with a non-finite value is preferred over any other so
+// that divergences are never hidden by downsampling.
+func lttb(xs, ys []float64, threshold int) []int {
+	n := len(xs)
+	if threshold >= n || n <= 2 {
+		idxs := make([]int, n)
+		for i := range idxs {
+			idxs[i] = i
+		}
+		return idxs
+	}
+	switch {
+	case threshold <= 0:
+		return nil
+	case threshold == 1:
+		return []int{0}
+	case threshold == 2:
+		return []int{0, n - 1}
+	}
+
+	idxs := make([]int, 0, threshold)
+	idxs = append(idxs, 0)
+
+	// anchorY is the value of the last kept point, or the last finite value kept before it if
+	// it is not finite, so that a single NaN does not poison the areas of every later bucket.
+	anchor, anchorY := 0, ys[0]
+	if !isFinite(anchorY) {
+		anchorY = 0
+	}
+
+	every := float64(n-2) / float64(threshold-2)
+	for i := 0; i < threshold-2; i++ {
+		// The average point of the next bucket is the third vertex of the triangles.
+		avgStart := int(math.Floor(float64(i+1)*every)) + 1
+		avgEnd := min(int(math.Floor(float64(i+2)*every))+1, n)
+		var avgX, avgY float64
+		count := 0
+		for j := avgStart; j < avgEnd; j++ {
+			if isFinite(ys[j]) {
+				avgX += xs[j]
+				avgY += ys[j]
+				count++
+			}
+		}
+		if count > 0 {
+			avgX /= float64(count)
+			avgY /= float64(count)
+		} else {
+			avgX, avgY = xs[avgEnd-1], anchorY
+		}
+
+		rangeStart := int(math.Floor(float64(i)*every)) + 1
+		rangeEnd := int(math.Floor(float64(i+1)*every)) + 1
+		next, maxArea := rangeStart, -1.0
+		for j := rangeStart; j < rangeEnd; j++ {
+			if !isFinite(ys[j]) {
+				next = j
+				break
+			}
+			area := math.Abs((xs[anchor]-avgX)*(ys[j]-anchorY)-(xs[anchor]-xs[j])*(avgY-anchorY)) / 2
+			if area > maxArea {
+				next, maxArea = j, area
+			}
+		}
+
+		idxs = append(idxs, next)
+		anchor = next
+		if isFinite(ys[next]) {
+			anchorY = ys[next]
+		}
+	}
+	return append(idxs, n-1)
+}
+
+// lttbSamples downsamples samples to roughly threshold points with LTTB. Each metric in
+// metricNames keeps an equal share of the points among the samples it is reported in, and the
+// result is the union of the points kept for every metric, in order.
+func lttbSamples(samples []sample, metricNames []string, threshold int) []int {
+	if len(metricNames) == 0 || threshold >= len(samples) {
+		idxs := make([]int, len(samples))
+		for i := range idxs {
+			idxs[i] = i
+		}
+		return idxs
+	}
+
+	perMetric := max(threshold/len(metricNames), 3)
+	keep := map[int]bool{}
+	for _, name := range metricNames {
+		var rows []int
+		var xs, ys []float64
+		for i, s := range samples {
+			if y, ok := metricValueToFloat(s.values[name]); ok {
+				rows = append(rows, i)
+				xs = append(xs, s.x)
+				ys = append(ys, y)
+			}
+		}
+		for _, j := range lttb(xs, ys, perMetric) {
+			keep[rows[j]] = true
+		}
+	}
+
+	idxs := make([]int, 0, len(keep))
+	for i := range keep {
+		idxs = append(idxs, i)
+	}
+	sort.Ints(idxs)
+	return idxs
+}
+
+// bucketSamples splits [lo, hi] into numBuckets buckets of equal width and aggregates the
+// numeric metrics of the samples in each of them. Samples outside of the range are ignored and
+// empty buckets are omitted. Non-finite values are included, so a NaN propagates to the mean of
+// its bucket.
+func bucketSamples(samples []sample, lo, hi float64, numBuckets int) []MetricsBucket {
+	if numBuckets <= 0 || hi < lo {
+		return nil
+	}
+
+	width := (hi - lo) / float64(numBuckets)
+	buckets := make([]*MetricsBucket, numBuckets)
+	sums := make([]map[string]float64, numBuckets)
+	counts := make([]map[string]int, numBuckets)
+	for _, s := range samples {
+		if s.x < lo || s.x > hi || math.IsNaN(s.x) {
+			continue
+		}
+		i := 0
+		if width > 0 {
+			i = min(int((s.x-lo)/width), numBuckets-1)
+		}
+
+		b := buckets[i]
+		if b == nil {
+			b = &MetricsBucket{
+				StartBatches: s.batches,
+				EndBatches:   s.batches,
+				StartTime:    s.time,
+				EndTime:      s.time,
+				Min:          map[string]float64{},
+				Max:          map[string]float64{},
+				Mean:         map[string]float64{},
+			}
+			buckets[i] = b
+			sums[i] = map[string]float64{}
+			counts[i] = map[string]int{}
+		}
+		b.Count++
+		b.StartBatches = min(b.StartBatches, s.batches)
+		b.EndBatches = max(b.EndBatches, s.batches)
+		if s.time.Before(b.StartTime) {
+			b.StartTime = s.time
+		}
+		if s.time.After(b.EndTime) {
+			b.EndTime = s.time
+		}
+
+		for name, v := range s.values {
+			f, ok := metricValueToFloat(v)
+			if !ok {
+				continue
+			}
+			if _, seen := b.Min[name]; seen {
+				b.Min[name] = math.Min(b.Min[name], f)
+				b.Max[name] = math.Max(b.Max[name], f)
+			} else {
+				b.Min[name], b.Max[name] = f, f
+			}
+			sums[i][name] += f
+			counts[i][name]++
+		}
+	}
+
+	var out []MetricsBucket
+	for i, b := range buckets {
+		if b == nil {
+			continue
+		}
+		for name, sum := range sums[i] {
+			b.Mean[name] = sum / float64(counts[i][name])
+		}
+		out = append(out, *b)
+	}
+	return out
+}
+
+// samplesRange returns the smallest and largest x of the samples.
+func samplesRange(samples []sample) (lo float64, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, s := range samples {
+		lo, hi = math.Min(lo, s.x), math.Max(hi, s.x)
+	}
+	return lo, hi
+}
+
+// reportsToSamples converts metric reports to samples laid out on the given axis. Only the
+// averaged metrics of each report are considered.
+func reportsToSamples(
+	reports []*trialv1.MetricsReport, group model.MetricGroup, axis apiv1.DownsampleAxis,
+) []sample {
+	path := model.TrialMetricsJSONPath(group == model.ValidationMetricGroup)
+	samples := make([]sample, 0, len(reports))
+	for _, r := range reports {
+		s := sample{
+			batches: uint(r.TotalBatches),
+			time:    r.EndTime.AsTime(),
+			values:  r.Metrics.GetFields()[path].GetStructValue().AsMap(),
+		}
+		if axis == apiv1.DownsampleAxis_DOWNSAMPLE_AXIS_TIME {
+			s.x = timeToAxis(s.time)
+		} else {
+			s.x = float64(s.batches)
+		}
+		samples = append(samples, s)
+	}
+	return samples
+}
+
+// LTTBReports downsamples the reports of a single trial to at most threshold reports, keeping
+// the shape of the given metric with Largest-Triangle-Three-Buckets. Reports must be sorted along
+// the axis. Reports that do not include the metric are dropped.
+func LTTBReports(
+	reports []*trialv1.MetricsReport, group model.MetricGroup, metricName string,
+	axis apiv1.DownsampleAxis, threshold int,
+) []*trialv1.MetricsReport {
+	reports = slices.Clone(reports)
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reportAxisValue(reports[i], axis) < reportAxisValue(reports[j], axis)
+	})
+	samples := reportsToSamples(reports, group, axis)
+
+	var rows []int
+	var xs, ys []float64
+	for i, s := range samples {
+		if y, ok := metricValueToFloat(s.values[metricName]); ok {
+			rows = append(rows, i)
+			xs = append(xs, s.x)
+			ys = append(ys, y)
+		}
+	}
+
+	var out []*trialv1.MetricsReport
+	for _, j := range lttb(xs, ys, threshold) {
+		out = append(out, reports[rows[j]])
+	}
+	return out
+}
+
+func reportAxisValue(r *trialv1.MetricsReport, axis apiv1.DownsampleAxis) float64 {
+	if axis == apiv1.DownsampleAxis_DOWNSAMPLE_AXIS_TIME {
+		return timeToAxis(r.EndTime.AsTime())
+	}
+	return float64(r.TotalBatches)
+}
+
+// BucketReports aggregates the reports of a single trial into at most numBuckets buckets of equal
+// width along the given axis. lo and hi bound the range that is split into buckets, and default
+// to the range of the reports when nil.
+func BucketReports(
+	reports []*trialv1.MetricsReport, group model.MetricGroup, axis apiv1.DownsampleAxis,
+	lo, hi *float64, numBuckets int,
+) []MetricsBucket {
+	samples := reportsToSamples(reports, group, axis)
+	dataLo, dataHi := samplesRange(samples)
+	if lo == nil {
+		lo = &dataLo
+	}
+	if hi == nil {
+		hi = &dataHi
+	}
+	return bucketSamples(samples, *lo, *hi, numBuckets)
+}
+
+// BucketDataPoints converts buckets of a time series into data points. The values of each data
+// point are the means of its bucket, and it is placed at the end of its bucket.
+func BucketDataPoints(
+	buckets []MetricsBucket, trialID int32, group model.MetricGroup,
+) []*apiv1.DataPoint {
+	points := make([]*apiv1.DataPoint, 0, len(buckets))
+	for _, b := range buckets {
+		bucket := b.Proto(trialID, group)
+		points = append(points, &apiv1.DataPoint{
+			Batches: int32(b.EndBatches),
+			Values:  bucket.Mean,
+			Time:    timestamppb.New(b.EndTime),
+			Bucket:  bucket,
+		})
+	}
+	return points
+}
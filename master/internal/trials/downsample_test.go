@@ -0,0 +1,174 @@
+package trials
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+func TestLTTB(t *testing.T) {
+	xs := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	ys := []float64{0, 0, 10, 0, 0, 0, -10, 0, 0, 0}
+
+	// The spikes are the points that form the largest triangles in their buckets.
+	require.Equal(t, []int{0, 2, 6, 9}, lttb(xs, ys, 4))
+
+	// Downsampling is deterministic.
+	for i := 0; i < 10; i++ {
+		require.Equal(t, lttb(xs, ys, 5), lttb(xs, ys, 5))
+	}
+
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, lttb(xs, ys, 10))
+	require.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, lttb(xs, ys, 100))
+	require.Equal(t, []int{0, 9}, lttb(xs, ys, 2))
+	require.Equal(t, []int{0}, lttb(xs, ys, 1))
+	require.Empty(t, lttb(xs, ys, 0))
+}
+
+func TestLTTBKeepsNonFinite(t *testing.T) {
+	xs := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	ys := []float64{0, 0, 10, 0, math.NaN(), 0, 0, 0, 0, 0}
+
+	idxs := lttb(xs, ys, 4)
+	require.Len(t, idxs, 4)
+	require.Contains(t, idxs, 4)
+
+	ys[4] = 0
+	ys[7] = math.Inf(1)
+	require.Contains(t, lttb(xs, ys, 4), 7)
+}
+
+func TestBucketSamples(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	var samples []sample
+	for i := 1; i <= 10; i++ {
+		samples = append(samples, sample{
+			x:       float64(i * 10),
+			batches: uint(i * 10),
+			time:    start.Add(time.Duration(i) * time.Minute),
+			values:  map[string]any{"loss": float64(i), "name": "ignored"},
+		})
+	}
+
+	buckets := bucketSamples(samples, 0, 100, 4)
+	require.Len(t, buckets, 4)
+
+	require.Equal(t, MetricsBucket{
+		StartBatches: 10,
+		EndBatches:   20,
+		StartTime:    start.Add(time.Minute),
+		EndTime:      start.Add(2 * time.Minute),
+		Count:        2,
+		Min:          map[string]float64{"loss": 1},
+		Max:          map[string]float64{"loss": 2},
+		Mean:         map[string]float64{"loss": 1.5},
+	}, buckets[0])
+	require.Equal(t, 2, buckets[1].Count)
+	require.Equal(t, 3.5, buckets[1].Mean["loss"])
+	require.Equal(t, 3, buckets[2].Count)
+	// The last point is included in the last bucket.
+	require.Equal(t, uint(100), buckets[3].EndBatches)
+	require.Equal(t, 9.0, buckets[3].Mean["loss"])
+
+	// Empty buckets are omitted and points out of the range are ignored.
+	buckets = bucketSamples(samples, 0, 1000, 10)
+	require.Len(t, buckets, 2)
+	require.Equal(t, 9, buckets[0].Count)
+	buckets = bucketSamples(samples, 15, 55, 2)
+	require.Len(t, buckets, 2)
+	require.Equal(t, uint(20), buckets[0].StartBatches)
+	require.Equal(t, uint(50), buckets[1].EndBatches)
+
+	samples[0].values["loss"] = db.NaNPostgresString
+	buckets = bucketSamples(samples, 0, 100, 4)
+	require.True(t, math.IsNaN(buckets[0].Mean["loss"]))
+	require.True(t, math.IsNaN(buckets[0].Max["loss"]))
+	require.Equal(t, 3.5, buckets[1].Mean["loss"])
+}
+
+func TestBucketProtoNonFinite(t *testing.T) {
+	b := MetricsBucket{
+		Count: 2,
+		Min:   map[string]float64{"loss": math.Inf(-1), "acc": 0.5},
+		Max:   map[string]float64{"loss": math.Inf(1), "acc": 0.5},
+		Mean:  map[string]float64{"loss": math.NaN(), "acc": 0.5},
+	}
+	bucket := b.Proto(1, model.TrainingMetricGroup)
+	require.Equal(t, db.NegInfPostgresString, bucket.Min.Fields["loss"].GetStringValue())
+	require.Equal(t, db.InfPostgresString, bucket.Max.Fields["loss"].GetStringValue())
+	require.Equal(t, db.NaNPostgresString, bucket.Mean.Fields["loss"].GetStringValue())
+	require.Equal(t, 0.5, bucket.Mean.Fields["acc"].GetNumberValue())
+
+	points := BucketDataPoints([]MetricsBucket{b}, 1, model.TrainingMetricGroup)
+	for _, m := range []proto.Message{bucket, points[0]} {
+		out, err := protojson.Marshal(m)
+		require.NoError(t, err)
+		require.Contains(t, string(out), `"NaN"`)
+	}
+}
+
+func TestReportsDownsampling(t *testing.T) {
+	var reports []*trialv1.MetricsReport
+	for i := 1; i <= 100; i++ {
+		loss := 1 / float64(i)
+		if i == 50 {
+			loss = 5
+		}
+		metrics, err := structpb.NewStruct(map[string]any{
+			"avg_metrics": map[string]any{"loss": loss},
+		})
+		require.NoError(t, err)
+		reports = append(reports, &trialv1.MetricsReport{
+			TrialId:      1,
+			TotalBatches: int32(i),
+			EndTime:      timestamppb.New(time.Unix(int64(i), 0)),
+			Metrics:      metrics,
+		})
+	}
+	// Reports out of order are sorted along the axis.
+	reports[10], reports[20] = reports[20], reports[10]
+
+	sampled := LTTBReports(reports, model.TrainingMetricGroup, "loss",
+		apiv1.DownsampleAxis_DOWNSAMPLE_AXIS_BATCHES, 10)
+	require.Len(t, sampled, 10)
+	require.Equal(t, int32(1), sampled[0].TotalBatches)
+	require.Equal(t, int32(100), sampled[9].TotalBatches)
+	var batches []int32
+	for i, r := range sampled {
+		if i > 0 {
+			require.Greater(t, r.TotalBatches, sampled[i-1].TotalBatches)
+		}
+		batches = append(batches, r.TotalBatches)
+	}
+	require.Contains(t, batches, int32(50))
+
+	require.Empty(t, LTTBReports(reports, model.TrainingMetricGroup, "accuracy",
+		apiv1.DownsampleAxis_DOWNSAMPLE_AXIS_BATCHES, 10))
+
+	buckets := BucketReports(reports, model.TrainingMetricGroup,
+		apiv1.DownsampleAxis_DOWNSAMPLE_AXIS_TIME, nil, nil, 3)
+	require.Len(t, buckets, 3)
+	count := 0
+	for _, b := range buckets {
+		count += b.Count
+	}
+	require.Equal(t, 100, count)
+	require.Equal(t, 5.0, buckets[1].Max["loss"])
+
+	points := BucketDataPoints(buckets, 1, model.TrainingMetricGroup)
+	require.Len(t, points, 3)
+	require.Equal(t, int32(buckets[2].EndBatches), points[2].Batches)
+	require.Equal(t, buckets[2].Mean["loss"], points[2].Values.AsMap()["loss"])
+	require.Equal(t, "training", points[2].Bucket.Group)
+}
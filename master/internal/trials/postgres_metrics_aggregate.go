@@ -0,0 +1,137 @@
+package trials
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+)
+
+// DefaultAggregatePercentiles are the percentiles computed across trials when none are requested.
+var DefaultAggregatePercentiles = []float64{25, 75}
+
+type metricsAggregateRow struct {
+	GroupValue  *string   `bun:"group_value"`
+	Step        float64   `bun:"step"`
+	TrialCount  int32     `bun:"trial_count"`
+	Mean        float64   `bun:"mean"`
+	Median      float64   `bun:"median"`
+	Stddev      float64   `bun:"stddev"`
+	Min         float64   `bun:"min"`
+	Max         float64   `bun:"max"`
+	Percentiles []float64 `bun:"percentiles,array"`
+}
+
+// ExperimentMetricsAggregate aggregates a metric across the trials of an experiment at each
+// step along the given alignment, optionally separately for each value of a hyperparameter.
+// Each trial contributes a single value per step, the mean of its reports at that step, and
+// non-numeric values are ignored. percentiles are between 0 and 100.
+func ExperimentMetricsAggregate(ctx context.Context, experimentID int32, metricName string,
+	group model.MetricGroup, alignBy apiv1.MetricsAlignment, percentiles []float64,
+	groupByHParam string,
+) ([]*apiv1.MetricsAggregateSeries, error) {
+	path := model.TrialMetricsJSONPath(group == model.ValidationMetricGroup)
+
+	stepExpr, stepArgs := "total_batches", []any{}
+	if alignBy == apiv1.MetricsAlignment_METRICS_ALIGNMENT_EPOCHS {
+		// "epoch" is the legacy name of "epochs", see metricsTimeSeries.
+		stepExpr = `CASE
+			WHEN jsonb_typeof(metrics->?->'epochs') = 'number' THEN (metrics->?->>'epochs')::float8
+			WHEN jsonb_typeof(metrics->?->'epoch') = 'number' THEN (metrics->?->>'epoch')::float8
+		END`
+		stepArgs = []any{path, path, path, path}
+	}
+
+	perTrial := db.BunSelectMetricsQuery(group, false).
+		Table("metrics").
+		Column("trial_id").
+		ColumnExpr(stepExpr+" AS step", stepArgs...).
+		ColumnExpr("avg((metrics->?->>?)::float8) AS value", path, metricName).
+		Where("trial_id IN (SELECT id FROM trials WHERE experiment_id = ?)", experimentID).
+		Where("jsonb_typeof(metrics->?->?) = 'number'", path, metricName).
+		Where(stepExpr+" IS NOT NULL", stepArgs...).
+		GroupExpr("trial_id, step")
+
+	fractions := make([]float64, len(percentiles))
+	for i, p := range percentiles {
+		fractions[i] = p / 100
+	}
+
+	query := db.Bun().NewSelect().
+		With("per_trial", perTrial).
+		TableExpr("per_trial").
+		ColumnExpr("per_trial.step AS step").
+		ColumnExpr("count(*) AS trial_count").
+		ColumnExpr("avg(value) AS mean").
+		ColumnExpr("percentile_cont(0.5) WITHIN GROUP (ORDER BY value) AS median").
+		ColumnExpr("COALESCE(stddev_samp(value), 0) AS stddev").
+		ColumnExpr("min(value) AS min").
+		ColumnExpr("max(value) AS max").
+		ColumnExpr("percentile_cont(?::float8[]) WITHIN GROUP (ORDER BY value) AS percentiles",
+			pgdialect.Array(fractions)).
+		GroupExpr("per_trial.step").
+		OrderExpr("group_value ASC NULLS FIRST, step ASC")
+	if groupByHParam != "" {
+		query.
+			Join("JOIN trials t ON t.id = per_trial.trial_id").
+			ColumnExpr("(t.hparams #> ?::text[])::text AS group_value",
+				pgdialect.Array(strings.Split(groupByHParam, "."))).
+			GroupExpr("group_value")
+	} else {
+		query.ColumnExpr("NULL::text AS group_value")
+	}
+
+	var rows []metricsAggregateRow
+	if err := query.Scan(ctx, &rows); err != nil {
+		return nil, errors.Wrapf(err, "error aggregating metric %s.%s of experiment %d",
+			group, metricName, experimentID)
+	}
+
+	var series []*apiv1.MetricsAggregateSeries
+	for i, r := range rows {
+		if i == 0 || !equalGroupValues(r.GroupValue, rows[i-1].GroupValue) {
+			value, err := groupValueToProto(r.GroupValue)
+			if err != nil {
+				return nil, err
+			}
+			series = append(series, &apiv1.MetricsAggregateSeries{HparamValue: value})
+		}
+		s := series[len(series)-1]
+		s.Steps = append(s.Steps, &apiv1.MetricsAggregateStep{
+			Step:        r.Step,
+			TrialCount:  r.TrialCount,
+			Mean:        r.Mean,
+			Median:      r.Median,
+			Stddev:      r.Stddev,
+			Min:         r.Min,
+			Max:         r.Max,
+			Percentiles: r.Percentiles,
+		})
+	}
+	return series, nil
+}
+
+func equalGroupValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func groupValueToProto(v *string) (*structpb.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	var value any
+	if err := json.Unmarshal([]byte(*v), &value); err != nil {
+		return nil, errors.Wrapf(err, "error parsing hyperparameter value %s", *v)
+	}
+	return structpb.NewValue(value)
+}
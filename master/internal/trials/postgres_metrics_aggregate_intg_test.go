@@ -0,0 +1,114 @@
+//go:build integration
+// +build integration
+
+package trials
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/apiv1"
+	"github.com/determined-ai/determined/proto/pkg/commonv1"
+	"github.com/determined-ai/determined/proto/pkg/trialv1"
+)
+
+func TestExperimentMetricsAggregate(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, etc.SetRootPath(db.RootFromDB))
+	pgDB, closeDB := db.MustResolveTestPostgres(t)
+	defer closeDB()
+	db.MustMigrateTestPostgres(t, pgDB, db.MigrationsFromDB)
+	user := db.RequireMockUser(t, pgDB)
+	exp := db.RequireMockExperiment(t, pgDB, user)
+
+	// Three trials with losses that are offset by the trial index at every step, the last of
+	// which uses a different optimizer and reports a non-numeric value at the last step.
+	for i := 0; i < 3; i++ {
+		optimizer := "adam"
+		if i == 2 {
+			optimizer = "sgd"
+		}
+		task := db.RequireMockTask(t, pgDB, exp.OwnerID)
+		rqID := model.NewRequestID(rand.Reader)
+		trial := model.Trial{
+			RequestID:    &rqID,
+			ExperimentID: exp.ID,
+			State:        model.ActiveState,
+			StartTime:    time.Now(),
+			HParams: model.JSONObj{
+				"global_batch_size": 1,
+				"optimizer":         map[string]any{"type": optimizer},
+			},
+		}
+		require.NoError(t, db.AddTrial(ctx, &trial, task.TaskID))
+
+		for step := int32(1); step <= 3; step++ {
+			var loss any = float64(step*10 + int32(i))
+			if i == 2 && step == 3 {
+				loss = "diverged"
+			}
+			metrics, err := structpb.NewStruct(map[string]any{
+				"loss":   loss,
+				"epochs": float64(step) / 2,
+			})
+			require.NoError(t, err)
+			steps := step * 100
+			require.NoError(t, pgDB.AddTrialMetrics(ctx, &trialv1.TrialMetrics{
+				TrialId:        int32(trial.ID),
+				StepsCompleted: &steps,
+				Metrics:        &commonv1.Metrics{AvgMetrics: metrics},
+			}, model.TrainingMetricGroup))
+		}
+	}
+
+	series, err := ExperimentMetricsAggregate(ctx, int32(exp.ID), "loss",
+		model.TrainingMetricGroup, apiv1.MetricsAlignment_METRICS_ALIGNMENT_UNSPECIFIED,
+		[]float64{0, 50, 100}, "")
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	require.Nil(t, series[0].HparamValue)
+	steps := series[0].Steps
+	require.Len(t, steps, 3)
+	require.Equal(t, &apiv1.MetricsAggregateStep{
+		Step:        100,
+		TrialCount:  3,
+		Mean:        11,
+		Median:      11,
+		Stddev:      1,
+		Min:         10,
+		Max:         12,
+		Percentiles: []float64{10, 11, 12},
+	}, steps[0])
+	require.Equal(t, float64(300), steps[2].Step)
+	require.Equal(t, int32(2), steps[2].TrialCount)
+	require.Equal(t, 30.5, steps[2].Mean)
+
+	series, err = ExperimentMetricsAggregate(ctx, int32(exp.ID), "loss",
+		model.TrainingMetricGroup, apiv1.MetricsAlignment_METRICS_ALIGNMENT_EPOCHS,
+		nil, "optimizer.type")
+	require.NoError(t, err)
+	require.Len(t, series, 2)
+	require.Equal(t, "adam", series[0].HparamValue.GetStringValue())
+	require.Len(t, series[0].Steps, 3)
+	require.Equal(t, 0.5, series[0].Steps[0].Step)
+	require.Equal(t, int32(2), series[0].Steps[0].TrialCount)
+	require.Equal(t, 10.5, series[0].Steps[0].Mean)
+	require.Equal(t, "sgd", series[1].HparamValue.GetStringValue())
+	require.Len(t, series[1].Steps, 2)
+	require.Equal(t, float64(0), series[1].Steps[0].Stddev)
+	require.Empty(t, series[1].Steps[0].Percentiles)
+
+	series, err = ExperimentMetricsAggregate(ctx, int32(exp.ID), "accuracy",
+		model.TrainingMetricGroup, apiv1.MetricsAlignment_METRICS_ALIGNMENT_BATCHES, nil, "")
+	require.NoError(t, err)
+	require.Empty(t, series)
+}
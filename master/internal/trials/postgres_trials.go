@@ -3,6 +3,7 @@ package trials
 import (
 	"context"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -70,13 +71,69 @@ func generateMetricToColumn(metric string) string {
 }
 
 // MetricsTimeSeries returns a time-series of the specified metric in the specified
-// trial.
+// trial, downsampled to at most maxDatapoints points with the given method.
 func MetricsTimeSeries(trialID int32, startTime time.Time,
 	metricNames []string,
 	startBatches int, endBatches int,
 	maxDatapoints int, timeSeriesColumn string,
-	timeSeriesFilter *commonv1.PolymorphicFilter, metricGroup model.MetricGroup) (
+	timeSeriesFilter *commonv1.PolymorphicFilter, metricGroup model.MetricGroup,
+	method apiv1.DownsampleMethod) (
 	metricMeasurements []db.MetricMeasurements, err error,
+) {
+	metricMeasurements, samples, err := metricsTimeSeries(trialID, startTime, metricNames,
+		startBatches, endBatches, maxDatapoints, timeSeriesColumn, timeSeriesFilter, metricGroup,
+		method == apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_UNSPECIFIED)
+	if err != nil {
+		return nil, err
+	}
+	if method != apiv1.DownsampleMethod_DOWNSAMPLE_METHOD_LTTB {
+		return metricMeasurements, nil
+	}
+
+	idxs := lttbSamples(samples, metricNames, maxDatapoints)
+	downsampled := make([]db.MetricMeasurements, 0, len(idxs))
+	for _, i := range idxs {
+		downsampled = append(downsampled, metricMeasurements[i])
+	}
+	return downsampled, nil
+}
+
+// MetricsBuckets aggregates a time-series of the specified metrics in the specified trial
+// into at most maxBuckets buckets of equal width along the time series column.
+func MetricsBuckets(trialID int32, metricNames []string,
+	startBatches int, endBatches int,
+	maxBuckets int, timeSeriesColumn string,
+	timeSeriesFilter *commonv1.PolymorphicFilter, metricGroup model.MetricGroup,
+) ([]MetricsBucket, error) {
+	_, samples, err := metricsTimeSeries(trialID, time.Time{}, metricNames,
+		startBatches, endBatches, maxBuckets, timeSeriesColumn, timeSeriesFilter, metricGroup, false)
+	if err != nil {
+		return nil, err
+	}
+
+	lo, hi := samplesRange(samples)
+	if timeSeriesFilter == nil && timeSeriesColumn == batches {
+		// Lay the buckets out over the requested window, falling back to the range of the data
+		// when the window is open-ended.
+		lo = float64(startBatches)
+		if endBatches < math.MaxInt32 {
+			hi = float64(endBatches)
+		}
+	}
+	return bucketSamples(samples, lo, hi, maxBuckets), nil
+}
+
+// metricsTimeSeries fetches a time-series of the specified metrics in the specified trial, along
+// with the samples the downsamplers work on. When sampled is set, at most maxDatapoints
+// randomly chosen points are returned. Otherwise every point is returned and points that are
+// missing the time series column are dropped.
+func metricsTimeSeries(trialID int32, startTime time.Time,
+	metricNames []string,
+	startBatches int, endBatches int,
+	maxDatapoints int, timeSeriesColumn string,
+	timeSeriesFilter *commonv1.PolymorphicFilter, metricGroup model.MetricGroup,
+	sampled bool) (
+	metricMeasurements []db.MetricMeasurements, samples []sample, err error,
 ) {
 	var queryColumn, orderColumn string
 	metricToColumnMap := newSafeMetricToColumnMap()
@@ -103,7 +160,7 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 		ColumnExpr("summary_metrics->? AS metrics", model.TrialSummaryMetricsJSONPath(metricGroup)).
 		Where("id = ?", trialID).
 		Scan(context.TODO(), &summaryMetrics); err != nil {
-		return nil, fmt.Errorf("getting summary metrics for trial %d: %w", trialID, err)
+		return nil, nil, fmt.Errorf("getting summary metrics for trial %d: %w", trialID, err)
 	}
 
 	for _, metricName := range append(metricNames, "epoch", "epochs") {
@@ -126,8 +183,10 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 			metricName, bun.Safe(cast), bun.Ident(metricToColumnMap.LookupOrAdd(metricName)))
 	}
 
-	subq = subq.Where("trial_id = ?", trialID).OrderExpr("random()").
-		Limit(maxDatapoints)
+	subq = subq.Where("trial_id = ?", trialID)
+	if sampled {
+		subq = subq.OrderExpr("random()").Limit(maxDatapoints)
+	}
 	switch timeSeriesFilter {
 	case nil:
 		orderColumn = batches
@@ -138,7 +197,7 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 		orderColumn = metricToColumnMap.LookupOrAdd(timeSeriesColumn)
 		subq, err = db.ApplyPolymorphicFilter(subq, queryColumn, timeSeriesFilter)
 		if err != nil {
-			return metricMeasurements, errors.Wrapf(err, "failed to get metrics to sample for experiment")
+			return nil, nil, errors.Wrapf(err, "failed to get metrics to sample for experiment")
 		}
 	}
 
@@ -147,7 +206,7 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 	err = db.Bun().NewSelect().TableExpr("(?) as downsample", subq).
 		OrderExpr(orderColumn).Scan(context.TODO(), &results)
 	if err != nil {
-		return metricMeasurements, errors.Wrapf(err, "failed to get metrics to sample for experiment")
+		return nil, nil, errors.Wrapf(err, "failed to get metrics to sample for experiment")
 	}
 
 	selectMetrics := map[string]string{}
@@ -157,6 +216,11 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 	}
 
 	for i := range results {
+		x, hasX := axisValue(results[i][orderColumn])
+		if !sampled && !hasX {
+			continue
+		}
+
 		valuesMap := make(map[string]interface{})
 		for mName, mVal := range results[i] {
 			if selectMetrics[mName] != "" {
@@ -169,14 +233,14 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 		if results[i]["epochs"] != nil {
 			e, ok := results[i]["epochs"].(float64)
 			if !ok {
-				return nil, fmt.Errorf(
+				return nil, nil, fmt.Errorf(
 					"metric 'epochs' has nonnumeric value reported value='%v'", results[i]["epochs"])
 			}
 			epochs = &e
 		} else if results[i]["epoch"] != nil {
 			e, ok := results[i]["epoch"].(float64)
 			if !ok {
-				return nil, fmt.Errorf(
+				return nil, nil, fmt.Errorf(
 					"metric 'epoch' has nonnumeric value reported value='%v'", results[i]["epoch"])
 			}
 			epochs = &e
@@ -196,8 +260,14 @@ func MetricsTimeSeries(trialID int32, startTime time.Time,
 		}
 
 		metricMeasurements = append(metricMeasurements, metricM)
+		samples = append(samples, sample{
+			x:       x,
+			batches: metricM.Batches,
+			time:    metricM.Time,
+			values:  metricM.Values,
+		})
 	}
-	return metricMeasurements, nil
+	return metricMeasurements, samples, nil
 }
 
 // CreateTrialSourceInfo creates a TrialSourceInfo object, which allows us to keep
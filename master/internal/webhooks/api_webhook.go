@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -17,6 +18,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/determined-ai/determined/master/internal/api"
+	conf "github.com/determined-ai/determined/master/internal/config"
 	"github.com/determined-ai/determined/master/internal/db"
 	"github.com/determined-ai/determined/master/internal/grpcutil"
 	"github.com/determined-ai/determined/master/pkg/model"
@@ -90,6 +92,19 @@ func (a *WebhooksAPIServer) PostWebhook(
 			"valid url required",
 		)
 	}
+	switch {
+	case req.Webhook.WebhookType == webhookv1.WebhookType_WEBHOOK_TYPE_TEMPLATED &&
+		req.Webhook.Template == nil:
+		return nil, status.Error(codes.InvalidArgument, "templated webhooks require a template")
+	case req.Webhook.WebhookType == webhookv1.WebhookType_WEBHOOK_TYPE_TEMPLATED:
+		if err := validateTemplate(
+			*req.Webhook.Template, TriggersFromProto(req.Webhook.Triggers),
+		); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid template: %s", err)
+		}
+	case req.Webhook.Template != nil:
+		return nil, status.Error(codes.InvalidArgument, "only templated webhooks have a template")
+	}
 
 	for _, t := range req.Webhook.Triggers {
 		if t.TriggerType == webhookv1.TriggerType_TRIGGER_TYPE_TASK_LOG {
@@ -120,7 +135,12 @@ func (a *WebhooksAPIServer) PostWebhook(
 		}
 	}
 
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
+	}
 	w := WebhookFromProto(req.Webhook)
+	w.CreatedBy = &curUser.ID
 	if err := AddWebhook(ctx, &w); err != nil {
 		return nil, err
 	}
@@ -159,13 +179,53 @@ func (a *WebhooksAPIServer) TestWebhook(
 	eventID := uuid.New()
 	log.Infof("creating webhook payload for event %v", eventID)
 
+	p, err := generateTestPayload(webhook)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"failed to create webhook payload for event %v error: %v", eventID, err)
+	}
+	if req.PreviewOnly {
+		return &apiv1.TestWebhookResponse{Preview: string(p)}, nil
+	}
+
 	var tReq *http.Request
-	switch webhook.WebhookType {
+	if webhook.WebhookType == WebhookTypeSlack {
+		tReq, err = http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewBuffer(p))
+	} else {
+		tReq, err = generateWebhookRequest(ctx, webhook.URL, p, time.Now().Unix())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"failed to create webhook request for event %v error : %v ", eventID, err)
+	}
+
+	log.Infof("creating webhook request for event %v", eventID)
+	c := cleanhttp.DefaultClient()
+	resp, err := c.Do(tReq)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"error sending webhook request for event %v error: %v", eventID, err)
+	}
+	defer func() {
+		if err = resp.Body.Close(); err != nil {
+			log.WithError(err).Error("unable to close response body")
+		}
+	}()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"received error from webhook server for event %v error: %v ", eventID, resp.StatusCode)
+	}
+	return &apiv1.TestWebhookResponse{Completed: true, Preview: string(p)}, nil
+}
+
+// generateTestPayload returns the body of the test event of a webhook.
+func generateTestPayload(w *Webhook) ([]byte, error) {
+	switch w.WebhookType {
 	case WebhookTypeDefault:
-		t := time.Now().Unix()
-		p, perr := json.Marshal(EventPayload{
+		return json.Marshal(EventPayload{
 			ID:        uuid.New(),
-			Timestamp: t,
+			Timestamp: time.Now().Unix(),
 			Type:      TriggerTypeStateChange,
 			Condition: Condition{
 				State: "COMPLETED",
@@ -174,18 +234,8 @@ func (a *WebhooksAPIServer) TestWebhook(
 				TestData: ptrs.Ptr("test"),
 			},
 		})
-		if perr != nil {
-			return nil, err
-		}
-
-		tr, rerr := generateWebhookRequest(ctx, webhook.URL, p, t)
-		if rerr != nil {
-			return nil, status.Errorf(codes.InvalidArgument,
-				"failed to create webhook request for event %v error : %v ", eventID, err)
-		}
-		tReq = tr
 	case WebhookTypeSlack:
-		slackMessage, serr := json.Marshal(SlackMessageBody{
+		return json.Marshal(SlackMessageBody{
 			Blocks: []SlackBlock{
 				{
 					Text: SlackField{
@@ -196,43 +246,19 @@ func (a *WebhooksAPIServer) TestWebhook(
 				},
 			},
 		})
-		if serr != nil {
-			return nil, err
+	case WebhookTypeTeams:
+		return card{Title: "test"}.teams()
+	case WebhookTypeDiscord:
+		return card{Title: "test"}.discord()
+	case WebhookTypeTemplated:
+		t := TriggerTypeStateChange
+		if len(w.Triggers) > 0 {
+			t = w.Triggers[0].TriggerType
 		}
-
-		tr, rerr := http.NewRequestWithContext(
-			ctx,
-			http.MethodPost,
-			webhook.URL,
-			bytes.NewBuffer(slackMessage),
-		)
-		if rerr != nil {
-			return nil, status.Errorf(codes.InvalidArgument,
-				"failed to create webhook request for event %v error : %v ", eventID, err)
-		}
-		tReq = tr
+		return marshalEventPayload(w, sampleEventPayload(t))
 	default:
-		panic("Unknown webhook type")
+		return nil, fmt.Errorf("unknown webhook type %s", w.WebhookType)
 	}
-
-	log.Infof("creating webhook request for event %v", eventID)
-	c := cleanhttp.DefaultClient()
-	resp, err := c.Do(tReq)
-	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument,
-			"error sending webhook request for event %v error: %v", eventID, err)
-	}
-	defer func() {
-		if err = resp.Body.Close(); err != nil {
-			log.WithError(err).Error("unable to close response body")
-		}
-	}()
-
-	if resp.StatusCode >= http.StatusBadRequest {
-		return nil, status.Errorf(codes.InvalidArgument,
-			"received error from webhook server for event %v error: %v ", eventID, resp.StatusCode)
-	}
-	return &apiv1.TestWebhookResponse{}, nil
 }
 
 // PostWebhookEventData handles data for custom trigger.
@@ -272,6 +298,9 @@ func (a *WebhooksAPIServer) PatchWebhook(
 	if err := authorizeEditRequest(ctx, webhook.Proto().WorkspaceId); err != nil {
 		return nil, err
 	}
+	if req.Webhook == nil || (req.Webhook.Url == "" && req.Webhook.Enabled == nil) {
+		return nil, status.Error(codes.InvalidArgument, "url or enabled required")
+	}
 
 	err = UpdateWebhook(
 		ctx,
@@ -286,3 +315,163 @@ func (a *WebhooksAPIServer) PatchWebhook(
 	}
 	return &apiv1.PatchWebhookResponse{}, nil
 }
+
+// GetWebhookDeliveries returns the delivery attempts and dead-lettered events of a webhook.
+func (a *WebhooksAPIServer) GetWebhookDeliveries(
+	ctx context.Context, req *apiv1.GetWebhookDeliveriesRequest,
+) (*apiv1.GetWebhookDeliveriesResponse, error) {
+	webhook, err := GetWebhook(ctx, int(req.WebhookId))
+	if errors.Is(db.MatchSentinelError(err), db.ErrNotFound) {
+		return nil, api.NotFoundErrs("webhook", strconv.Itoa(int(req.WebhookId)), true)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := authorizeEditRequest(ctx, webhook.Proto().WorkspaceId); err != nil {
+		return nil, err
+	}
+	if req.Offset < 0 || req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset and limit must not be negative")
+	}
+
+	attempts, dead, err := getWebhookDeliveries(
+		ctx, webhook.ID, int(req.Offset), int(req.Limit))
+	if err != nil {
+		return nil, err
+	}
+	resp := &apiv1.GetWebhookDeliveriesResponse{
+		Deliveries:  make([]*webhookv1.WebhookDelivery, len(attempts)),
+		DeadLetters: make([]*webhookv1.WebhookDeadLetter, len(dead)),
+	}
+	for i := range attempts {
+		resp.Deliveries[i] = attempts[i].Proto()
+	}
+	for i := range dead {
+		resp.DeadLetters[i] = dead[i].Proto()
+	}
+	return resp, nil
+}
+
+// RedeliverWebhookEvent queues a dead-lettered event again.
+func (a *WebhooksAPIServer) RedeliverWebhookEvent(
+	ctx context.Context, req *apiv1.RedeliverWebhookEventRequest,
+) (*apiv1.RedeliverWebhookEventResponse, error) {
+	notFound := api.NotFoundErrs("dead-lettered event", strconv.Itoa(int(req.EventId)), true)
+	d, err := getDeadLetter(ctx, WebhookEventID(req.EventId))
+	if errors.Is(err, db.ErrNotFound) || (err == nil && d.WebhookID == nil) {
+		return nil, notFound
+	} else if err != nil {
+		return nil, err
+	}
+	webhook, err := GetWebhook(ctx, int(*d.WebhookID))
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeEditRequest(ctx, webhook.Proto().WorkspaceId); err != nil {
+		return nil, err
+	}
+
+	switch err := redeliverEvent(ctx, d.EventID); {
+	case errors.Is(err, db.ErrNotFound):
+		return nil, notFound
+	case errors.Is(err, db.ErrInvalidInput):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, err
+	}
+	return &apiv1.RedeliverWebhookEventResponse{}, nil
+}
+
+// GetEmailNotificationSettings returns the email notifications the current user opted in to.
+func (a *WebhooksAPIServer) GetEmailNotificationSettings(
+	ctx context.Context, req *apiv1.GetEmailNotificationSettingsRequest,
+) (*apiv1.GetEmailNotificationSettingsResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
+	}
+
+	s, err := GetEmailNotificationSettings(ctx, curUser.ID)
+	if errors.Is(err, db.ErrNotFound) {
+		return &apiv1.GetEmailNotificationSettingsResponse{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &apiv1.GetEmailNotificationSettingsResponse{Settings: s.Proto()}, nil
+}
+
+// PutEmailNotificationSettings opts the current user in to email notifications.
+func (a *WebhooksAPIServer) PutEmailNotificationSettings(
+	ctx context.Context, req *apiv1.PutEmailNotificationSettingsRequest,
+) (*apiv1.PutEmailNotificationSettingsResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
+	}
+	if conf.GetMasterConfig().Webhooks.SMTP == nil {
+		return nil, status.Error(codes.FailedPrecondition, ErrEmailDisabled.Error())
+	}
+	if req.Settings == nil {
+		return nil, status.Error(codes.InvalidArgument, "settings must be set")
+	}
+
+	s := EmailNotificationSettingsFromProto(curUser.ID, req.Settings)
+	if err := s.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := PutEmailNotificationSettings(ctx, s); err != nil {
+		return nil, err
+	}
+	return &apiv1.PutEmailNotificationSettingsResponse{Settings: s.Proto()}, nil
+}
+
+// DeleteEmailNotificationSettings opts the current user out of email notifications.
+func (a *WebhooksAPIServer) DeleteEmailNotificationSettings(
+	ctx context.Context, req *apiv1.DeleteEmailNotificationSettingsRequest,
+) (*apiv1.DeleteEmailNotificationSettingsResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
+	}
+
+	err = DeleteEmailNotificationSettings(ctx, curUser.ID)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, api.NotFoundErrs("email notification settings", curUser.Username, true)
+	} else if err != nil {
+		return nil, err
+	}
+	return &apiv1.DeleteEmailNotificationSettingsResponse{}, nil
+}
+
+// TestEmailNotification sends a test email to the current user, synchronously so that SMTP
+// errors are returned.
+func (a *WebhooksAPIServer) TestEmailNotification(
+	ctx context.Context, req *apiv1.TestEmailNotificationRequest,
+) (*apiv1.TestEmailNotificationResponse, error) {
+	curUser, _, err := grpcutil.GetUser(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get the user: %s", err)
+	}
+	smtpConfig := conf.GetMasterConfig().Webhooks.SMTP
+	if smtpConfig == nil {
+		return nil, status.Error(codes.FailedPrecondition, ErrEmailDisabled.Error())
+	}
+
+	s, err := GetEmailNotificationSettings(ctx, curUser.ID)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, api.NotFoundErrs("email notification settings", curUser.Username, true)
+	} else if err != nil {
+		return nil, err
+	}
+
+	msg, err := card{
+		Title: "Test notification from Determined",
+		Text:  "Email notifications are set up.",
+	}.email(smtpConfig.From, s.Email)
+	if err != nil {
+		return nil, err
+	}
+	if err := sendEmail(ctx, smtpConfig, s.Email, msg); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "sending test email: %s", err)
+	}
+	return &apiv1.TestEmailNotificationResponse{}, nil
+}
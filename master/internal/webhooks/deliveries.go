@@ -0,0 +1,298 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	conf "github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/webhookv1"
+)
+
+const (
+	// responseBodyLimit is how much of the response body of a delivery attempt is kept.
+	responseBodyLimit = 1024
+	// DefaultDeliveriesLimit is how many delivery attempts are returned by default.
+	DefaultDeliveriesLimit = 100
+)
+
+// errWebhookDisabled is why the events of disabled webhooks are dead-lettered.
+var errWebhookDisabled = errors.New("webhook is disabled")
+
+// deliveryAttempt corresponds to a row in the "webhook_delivery_attempts" DB table.
+type deliveryAttempt struct {
+	bun.BaseModel `bun:"table:webhook_delivery_attempts"`
+
+	ID           int64          `bun:"id,pk,autoincrement"`
+	EventID      WebhookEventID `bun:"event_id,notnull"`
+	WebhookID    *WebhookID     `bun:"webhook_id"`
+	URL          string         `bun:"url,notnull"`
+	Attempt      int            `bun:"attempt,notnull"`
+	StatusCode   *int           `bun:"status_code"`
+	LatencyMS    int            `bun:"latency_ms,notnull"`
+	ResponseBody *string        `bun:"response_body"`
+	Error        *string        `bun:"error"`
+	AttemptedAt  time.Time      `bun:"attempted_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// Proto converts a delivery attempt to its protobuf representation.
+func (a *deliveryAttempt) Proto() *webhookv1.WebhookDelivery {
+	pb := &webhookv1.WebhookDelivery{
+		Id:          a.ID,
+		EventId:     int32(a.EventID),
+		Attempt:     int32(a.Attempt),
+		LatencyMs:   int32(a.LatencyMS),
+		Error:       a.Error,
+		AttemptedAt: timestamppb.New(a.AttemptedAt),
+	}
+	if a.StatusCode != nil {
+		pb.StatusCode = ptrs.Ptr(int32(*a.StatusCode))
+	}
+	if a.ResponseBody != nil {
+		pb.ResponseBody = *a.ResponseBody
+	}
+	return pb
+}
+
+// deadLetter corresponds to a row in the "webhook_dead_letters" DB table.
+type deadLetter struct {
+	bun.BaseModel `bun:"table:webhook_dead_letters"`
+
+	EventID   WebhookEventID `bun:"event_id,pk"`
+	WebhookID *WebhookID     `bun:"webhook_id"`
+	URL       string         `bun:"url,notnull"`
+	Payload   []byte         `bun:"payload,notnull"`
+	LastError string         `bun:"last_error,notnull"`
+	FailedAt  time.Time      `bun:"failed_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// Proto converts a dead-lettered event to its protobuf representation.
+func (d *deadLetter) Proto() *webhookv1.WebhookDeadLetter {
+	return &webhookv1.WebhookDeadLetter{
+		EventId:   int32(d.EventID),
+		LastError: d.LastError,
+		FailedAt:  timestamppb.New(d.FailedAt),
+	}
+}
+
+// webhookDisabled returns whether the webhook of an event is disabled. Events of deleted webhooks
+// and emails are not.
+func webhookDisabled(ctx context.Context, e Event) (bool, error) {
+	if e.WebhookID == nil {
+		return false, nil
+	}
+	var disabled bool
+	err := db.Bun().NewSelect().Table("webhooks").
+		Column("disabled").
+		Where("id = ?", *e.WebhookID).
+		Scan(ctx, &disabled)
+	if errors.Is(db.MatchSentinelError(err), db.ErrNotFound) {
+		return false, nil
+	}
+	return disabled, err
+}
+
+// recordAttempt persists an attempt to deliver an event.
+func recordAttempt(
+	ctx context.Context, e Event, attempt int, latency time.Duration,
+	statusCode *int, body []byte, deliverErr error,
+) error {
+	a := &deliveryAttempt{
+		EventID:    e.ID,
+		WebhookID:  e.WebhookID,
+		URL:        e.URL,
+		Attempt:    attempt,
+		StatusCode: statusCode,
+		LatencyMS:  int(latency.Milliseconds()),
+	}
+	if len(body) > 0 {
+		a.ResponseBody = ptrs.Ptr(truncate(string(body), responseBodyLimit))
+	}
+	if deliverErr != nil {
+		a.Error = ptrs.Ptr(deliverErr.Error())
+	}
+	if _, err := db.Bun().NewInsert().Model(a).Exec(ctx); err != nil {
+		return fmt.Errorf("recording delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// deadLetterEvent keeps an event that could not be delivered so it can be redelivered.
+func deadLetterEvent(ctx context.Context, e Event, lastErr error) error {
+	if _, err := db.Bun().NewInsert().Model(&deadLetter{
+		EventID:   e.ID,
+		WebhookID: e.WebhookID,
+		URL:       e.URL,
+		Payload:   e.Payload,
+		LastError: lastErr.Error(),
+	}).On("CONFLICT (event_id) DO NOTHING").Exec(ctx); err != nil {
+		return fmt.Errorf("dead-lettering event %d: %w", e.ID, err)
+	}
+	return nil
+}
+
+// recordDeliveryResult counts the consecutive failures of the webhook of an event, and disables
+// the webhook, notifying its owner, once they reach webhooks.max_consecutive_failures.
+func recordDeliveryResult(ctx context.Context, e Event, delivered bool) error {
+	if e.WebhookID == nil {
+		return nil
+	}
+	if delivered {
+		if _, err := db.Bun().NewUpdate().Model((*Webhook)(nil)).
+			Set("consecutive_failures = 0").
+			Where("id = ?", *e.WebhookID).
+			Where("consecutive_failures > 0").
+			Exec(ctx); err != nil {
+			return fmt.Errorf("resetting consecutive failures: %w", err)
+		}
+		return nil
+	}
+
+	var w Webhook
+	if err := db.Bun().NewUpdate().Model(&w).
+		Set("consecutive_failures = consecutive_failures + 1").
+		Where("id = ?", *e.WebhookID).
+		Returning("*").
+		Scan(ctx); errors.Is(db.MatchSentinelError(err), db.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("counting consecutive failures: %w", err)
+	}
+
+	maxFailures := conf.GetMasterConfig().Webhooks.MaxConsecutiveFailures
+	if w.Disabled || maxFailures <= 0 || w.ConsecutiveFailures < maxFailures {
+		return nil
+	}
+	res, err := db.Bun().NewUpdate().Model((*Webhook)(nil)).
+		Set("disabled = true").
+		Where("id = ?", w.ID).
+		Where("NOT disabled").
+		Exec(ctx)
+	if err := db.MustHaveAffectedRows(res, err); errors.Is(err, db.ErrNotFound) {
+		return nil // Someone else disabled it first.
+	} else if err != nil {
+		return fmt.Errorf("disabling webhook: %w", err)
+	}
+	w.Disabled = true
+	return notifyWebhookDisabled(ctx, &w)
+}
+
+// notifyWebhookDisabled logs that a webhook was disabled, and emails its owner if they opted in
+// to email notifications.
+func notifyWebhookDisabled(ctx context.Context, w *Webhook) error {
+	log.WithField("webhook-id", w.ID).Warnf(
+		"disabled webhook %q after %d consecutive failed deliveries", w.Name, w.ConsecutiveFailures)
+
+	if w.CreatedBy == nil || conf.GetMasterConfig().Webhooks.SMTP == nil {
+		return nil
+	}
+	s, err := GetEmailNotificationSettings(ctx, *w.CreatedBy)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	c := card{
+		Title: fmt.Sprintf("Webhook %s was disabled", w.Name),
+		Text: "The webhook was disabled because its events could not be delivered. " +
+			"Fix it, enable it again and redeliver its dead-lettered events.",
+		Level: cardFailure,
+		Facts: []fact{
+			{Name: "Webhook ID", Value: strconv.Itoa(int(w.ID))},
+			{Name: "URL", Value: w.URL},
+			{Name: "Consecutive failures", Value: strconv.Itoa(w.ConsecutiveFailures)},
+		},
+	}
+	ev, err := emailEvent(s.Email, c)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Bun().NewInsert().Model(&ev).Exec(ctx); err != nil {
+		return fmt.Errorf("queueing webhook disabled email: %w", err)
+	}
+	singletonShipper.Wake()
+	return nil
+}
+
+// getWebhookDeliveries returns the delivery attempts and dead-lettered events of a webhook, newest
+// first.
+func getWebhookDeliveries(
+	ctx context.Context, webhookID WebhookID, offset, limit int,
+) ([]deliveryAttempt, []deadLetter, error) {
+	if limit <= 0 {
+		limit = DefaultDeliveriesLimit
+	}
+	var attempts []deliveryAttempt
+	if err := db.Bun().NewSelect().Model(&attempts).
+		Where("webhook_id = ?", webhookID).
+		Order("attempted_at DESC", "id DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(ctx); err != nil {
+		return nil, nil, fmt.Errorf("getting delivery attempts: %w", err)
+	}
+	var dead []deadLetter
+	if err := db.Bun().NewSelect().Model(&dead).
+		ExcludeColumn("payload").
+		Where("webhook_id = ?", webhookID).
+		Order("failed_at DESC").
+		Scan(ctx); err != nil {
+		return nil, nil, fmt.Errorf("getting dead-lettered events: %w", err)
+	}
+	return attempts, dead, nil
+}
+
+// getDeadLetter returns a dead-lettered event without its payload.
+func getDeadLetter(ctx context.Context, eventID WebhookEventID) (*deadLetter, error) {
+	var d deadLetter
+	if err := db.Bun().NewSelect().Model(&d).
+		ExcludeColumn("payload").
+		Where("event_id = ?", eventID).
+		Scan(ctx); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return &d, nil
+}
+
+// redeliverEvent queues a dead-lettered event again, to the current URL of its webhook.
+func redeliverEvent(ctx context.Context, eventID WebhookEventID) error {
+	if err := db.Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var d deadLetter
+		if err := tx.NewDelete().Model(&d).
+			Where("event_id = ?", eventID).
+			Returning("*").
+			Scan(ctx); err != nil {
+			return db.MatchSentinelError(err)
+		}
+
+		e := Event{URL: d.URL, Payload: d.Payload, WebhookID: d.WebhookID}
+		if d.WebhookID != nil {
+			var w Webhook
+			if err := tx.NewSelect().Model(&w).Where("id = ?", *d.WebhookID).Scan(ctx); err != nil {
+				return fmt.Errorf("getting webhook %d: %w", *d.WebhookID, err)
+			}
+			if w.Disabled {
+				return fmt.Errorf("%w: webhook %d is disabled", db.ErrInvalidInput, w.ID)
+			}
+			e.URL = w.URL
+		}
+		if _, err := tx.NewInsert().Model(&e).Exec(ctx); err != nil {
+			return fmt.Errorf("queueing event: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	singletonShipper.Wake()
+	return nil
+}
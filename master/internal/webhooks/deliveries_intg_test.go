@@ -0,0 +1,108 @@
+//go:build integration
+
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/proto/pkg/webhookv1"
+)
+
+func TestWebhookDeliveries(t *testing.T) {
+	ctx := context.Background()
+	clearWebhooksTables(ctx, t)
+	singletonShipper = &shipper{wake: make(chan<- struct{})} // mock shipper
+
+	originalConfig := config.GetMasterConfig().Webhooks
+	defer func() {
+		config.GetMasterConfig().Webhooks = originalConfig
+	}()
+	config.GetMasterConfig().Webhooks.MaxConsecutiveFailures = 2
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("no such channel"))
+	}))
+	defer failing.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer working.Close()
+
+	wh := mockWebhook()
+	wh.URL = failing.URL
+	require.NoError(t, AddWebhook(ctx, wh))
+
+	queue := func() Event {
+		e := Event{URL: wh.URL, Payload: []byte(`{}`), WebhookID: &wh.ID}
+		_, err := db.Bun().NewInsert().Model(&e).Exec(ctx)
+		require.NoError(t, err)
+		return e
+	}
+	getWebhook := func() *Webhook {
+		w, err := GetWebhook(ctx, int(wh.ID))
+		require.NoError(t, err)
+		return w
+	}
+	worker := newWorker(0)
+
+	// Client errors are not retried; the event is dead-lettered.
+	e0 := queue()
+	worker.deliverWithRetries(ctx, e0)
+	attempts, dead, err := getWebhookDeliveries(ctx, wh.ID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	require.Equal(t, http.StatusBadRequest, *attempts[0].StatusCode)
+	require.Equal(t, "no such channel", *attempts[0].ResponseBody)
+	require.Len(t, dead, 1)
+	require.Equal(t, e0.ID, dead[0].EventID)
+	require.Equal(t, 1, getWebhook().ConsecutiveFailures)
+	require.False(t, getWebhook().Disabled)
+
+	// The webhook is disabled after two consecutive failures.
+	e1 := queue()
+	worker.deliverWithRetries(ctx, e1)
+	require.True(t, getWebhook().Disabled)
+
+	// Events of disabled webhooks are dead-lettered without being sent.
+	e2 := queue()
+	worker.deliverWithRetries(ctx, e2)
+	attempts, dead, err = getWebhookDeliveries(ctx, wh.ID, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	require.Len(t, dead, 3)
+	require.ErrorIs(t, redeliverEvent(ctx, e0.ID), db.ErrInvalidInput)
+
+	// Fixing and enabling the webhook allows redelivering its events to the new URL.
+	require.NoError(t, UpdateWebhook(ctx, int32(wh.ID), &webhookv1.PatchWebhook{
+		Url: working.URL, Enabled: ptrs.Ptr(true),
+	}))
+	require.Zero(t, getWebhook().ConsecutiveFailures)
+	require.NoError(t, redeliverEvent(ctx, e0.ID))
+	require.ErrorIs(t, redeliverEvent(ctx, e0.ID), db.ErrNotFound)
+	require.Equal(t, 1, countEventsForURL(ctx, t, working.URL))
+
+	b, err := dequeueEvents(ctx, 10)
+	require.NoError(t, err)
+	var redelivered *Event
+	for i, e := range b.events {
+		if e.URL == working.URL {
+			redelivered = &b.events[i]
+		}
+	}
+	require.NotNil(t, redelivered)
+	worker.deliverWithRetries(ctx, *redelivered)
+	require.NoError(t, b.commit())
+	attempts, dead, err = getWebhookDeliveries(ctx, wh.ID, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	require.Equal(t, http.StatusOK, *attempts[0].StatusCode)
+	require.Nil(t, attempts[0].Error)
+	require.Len(t, dead, 2)
+}
@@ -0,0 +1,370 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	back "github.com/cenkalti/backoff/v4"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	conf "github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+	"github.com/determined-ai/determined/proto/pkg/webhookv1"
+)
+
+// mailtoScheme prefixes the URL of queued events that are emails. The payload of such events is
+// the whole message, rendered when the event was queued.
+const mailtoScheme = "mailto:"
+
+// smtpTimeout bounds a whole SMTP conversation.
+const smtpTimeout = 30 * time.Second
+
+// ErrEmailDisabled is returned when email notifications are used without an SMTP server.
+var ErrEmailDisabled = errors.New("email notifications require webhooks.smtp in the master config")
+
+// EmailNotificationSettings are the email notifications a user opted in to for their own
+// experiments.
+type EmailNotificationSettings struct {
+	bun.BaseModel `bun:"table:email_notification_settings"`
+
+	UserID           model.UserID `bun:"user_id,pk"`
+	Email            string       `bun:"email,notnull"`
+	ExperimentStates []string     `bun:"experiment_states,array"`
+	TaskLogRegex     *string      `bun:"task_log_regex"`
+}
+
+type emailTaskLogNotification struct {
+	bun.BaseModel `bun:"table:email_task_log_notifications"`
+
+	TaskID model.TaskID `bun:"task_id"`
+	UserID model.UserID `bun:"user_id"`
+}
+
+// EmailNotificationSettingsFromProto returns the settings of a user from their proto.
+func EmailNotificationSettingsFromProto(
+	userID model.UserID, s *webhookv1.EmailNotificationSettings,
+) *EmailNotificationSettings {
+	return &EmailNotificationSettings{
+		UserID:           userID,
+		Email:            s.Email,
+		ExperimentStates: s.ExperimentStates,
+		TaskLogRegex:     s.TaskLogRegex,
+	}
+}
+
+// Proto returns the proto of the settings.
+func (s *EmailNotificationSettings) Proto() *webhookv1.EmailNotificationSettings {
+	states := s.ExperimentStates
+	if states == nil {
+		states = []string{}
+	}
+	return &webhookv1.EmailNotificationSettings{
+		Email:            s.Email,
+		ExperimentStates: states,
+		TaskLogRegex:     s.TaskLogRegex,
+	}
+}
+
+// Validate checks the address, states and regex of the settings. It normalizes the address to
+// its bare form, dropping any display name, since that is what is sent as the SMTP recipient.
+func (s *EmailNotificationSettings) Validate() error {
+	addr, err := mail.ParseAddress(s.Email)
+	if err != nil {
+		return fmt.Errorf("invalid email %q: %w", s.Email, err)
+	}
+	s.Email = addr.Address
+	for _, state := range s.ExperimentStates {
+		if _, ok := model.ExperimentTransitions[model.State(state)]; !ok {
+			return fmt.Errorf("invalid experiment state %q", state)
+		}
+	}
+	if s.TaskLogRegex != nil {
+		if _, err := regexp.Compile(*s.TaskLogRegex); err != nil {
+			return fmt.Errorf("invalid task log regex: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetEmailNotificationSettings returns the settings of a user, or db.ErrNotFound if they did
+// not opt in.
+func GetEmailNotificationSettings(
+	ctx context.Context, userID model.UserID,
+) (*EmailNotificationSettings, error) {
+	var s EmailNotificationSettings
+	if err := db.Bun().NewSelect().Model(&s).Where("user_id = ?", userID).Scan(ctx); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return &s, nil
+}
+
+// emailTemplateData is what the email templates are rendered from.
+type emailTemplateData struct {
+	card
+}
+
+var emailTextTemplate = template.Must(template.New("email.txt").Parse(
+	`{{.Title}}
+
+{{if .Text}}{{.Text}}
+
+{{end}}{{range .Facts}}{{.Name}}: {{.Value}}
+{{end}}{{if .URL}}
+View it at {{.URL}}
+{{end}}`))
+
+var emailHTMLTemplate = htmltemplate.Must(htmltemplate.New("email.html").Parse(
+	`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<h2 style="color: {{.Color}};">{{.Title}}</h2>
+{{if .Text}}<p>{{.Text}}</p>
+{{end}}{{if .Facts}}<table cellpadding="4">
+{{range .Facts}}<tr><th align="left">{{.Name}}</th><td><code>{{.Value}}</code></td></tr>
+{{end}}</table>
+{{end}}{{if .URL}}<p><a href="{{.URL}}">View in Determined</a></p>
+{{end}}</body>
+</html>
+`))
+
+// Color is the heading color of an email.
+func (d emailTemplateData) Color() string {
+	return map[cardLevel]string{
+		cardInfo: "#009BDE", cardSuccess: "#13B670", cardFailure: "#DD5040",
+	}[d.Level]
+}
+
+// email returns the message of a card, as a multipart text and HTML email.
+func (c card) email(from, to string) ([]byte, error) {
+	data := emailTemplateData{card: c}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	headers := []struct{ key, value string }{
+		{"From", from},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", c.Title)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@determined>", uuid.New())},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary())},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h.key, h.value)
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		execute     func(*quotedprintable.Writer) error
+	}{
+		{"text/plain", func(w *quotedprintable.Writer) error { return emailTextTemplate.Execute(w, data) }},
+		{"text/html", func(w *quotedprintable.Writer) error { return emailHTMLTemplate.Execute(w, data) }},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if err := part.execute(qw); err != nil {
+			return nil, fmt.Errorf("rendering %s email: %w", part.contentType, err)
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// emailEvent returns the queued event that emails a card to an address.
+func emailEvent(to string, c card) (Event, error) {
+	smtpConfig := conf.GetMasterConfig().Webhooks.SMTP
+	if smtpConfig == nil {
+		return Event{}, ErrEmailDisabled
+	}
+	msg, err := c.email(smtpConfig.From, to)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{URL: mailtoScheme + to, Payload: msg}, nil
+}
+
+// sendEmail sends a message through an SMTP server. Errors the server will keep returning are
+// permanent.
+func sendEmail(ctx context.Context, cfg *conf.SMTPConfig, to string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", cfg.Address())
+	if err != nil {
+		return fmt.Errorf("connecting to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			_ = conn.Close()
+			return err
+		}
+	}
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("greeting smtp server: %w", err)
+	}
+	defer c.Close()
+
+	if cfg.UseStartTLS() {
+		if err := c.StartTLS(&tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return back.Permanent(fmt.Errorf("starting tls: %w", err))
+		}
+	}
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return smtpError("authenticating", err)
+		}
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return back.Permanent(err)
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return smtpError("sending sender", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return smtpError("sending recipient", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return smtpError("starting message", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError("sending message", err)
+	}
+	return c.Quit()
+}
+
+// smtpError wraps an error of an SMTP command, which is permanent if the server rejected it with
+// a 5xx reply.
+func smtpError(action string, err error) error {
+	err = fmt.Errorf("%s: %w", action, err)
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) && tpErr.Code >= 500 {
+		return back.Permanent(err)
+	}
+	return err
+}
+
+// deliverEmail sends a queued email.
+func deliverEmail(ctx context.Context, to string, msg []byte) error {
+	smtpConfig := conf.GetMasterConfig().Webhooks.SMTP
+	if smtpConfig == nil {
+		return back.Permanent(ErrEmailDisabled)
+	}
+	return sendEmail(ctx, smtpConfig, to, msg)
+}
+
+// experimentEmailEvents returns the emails to send the owner of an experiment that changed state,
+// if they opted in to the state.
+func experimentEmailEvents(
+	ctx context.Context, e model.Experiment, activeConfig expconf.ExperimentConfig,
+) ([]Event, error) {
+	if conf.GetMasterConfig().Webhooks.SMTP == nil || e.OwnerID == nil {
+		return nil, nil
+	}
+	var ss []EmailNotificationSettings
+	if err := db.Bun().NewSelect().Model(&ss).
+		Where("user_id = ?", *e.OwnerID).
+		Where("? = ANY(experiment_states)", e.State).
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("getting email notification settings: %w", err)
+	}
+	var es []Event
+	for _, s := range ss {
+		ev, err := emailEvent(s.Email, experimentCard(e, activeConfig, nil, nil))
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, ev)
+	}
+	return es, nil
+}
+
+// addTaskLogEmailEvent emails a user that a log of their task matched their regex, once per task.
+func addTaskLogEmailEvent(
+	ctx context.Context, taskID model.TaskID, userID model.UserID,
+	nodeName, regex, triggeringLog string,
+) error {
+	s, err := GetEmailNotificationSettings(ctx, userID)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	c, err := taskLogCard(ctx, taskID, nodeName, regex, triggeringLog)
+	if err != nil {
+		return err
+	}
+	c.Text = "A log matched the regex of your email notifications"
+	ev, err := emailEvent(s.Email, c)
+	if err != nil {
+		return err
+	}
+
+	needToWake := false
+	if err := db.Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewInsert().Model(&emailTaskLogNotification{TaskID: taskID, UserID: userID}).
+			On("CONFLICT (task_id, user_id) DO NOTHING").Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("inserting task log email notification: %w", err)
+		}
+		if rowsAffected, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("getting rows affected for task log email notifications: %w", err)
+		} else if rowsAffected == 0 {
+			return nil
+		}
+		if _, err := tx.NewInsert().Model(&ev).Exec(ctx); err != nil {
+			return fmt.Errorf("inserting task log email event: %w", err)
+		}
+		needToWake = true
+		return nil
+	}); err != nil {
+		return fmt.Errorf("adding task log email event: %w", err)
+	}
+
+	if needToWake {
+		singletonShipper.Wake()
+	}
+	return nil
+}
+
+// isEmail returns the address of a queued event that is an email.
+func isEmail(e Event) (string, bool) {
+	return strings.CutPrefix(e.URL, mailtoScheme)
+}
@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	back "github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/require"
+
+	conf "github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+// smtpSink is a local SMTP server that keeps the messages it receives.
+type smtpSink struct {
+	l           net.Listener
+	rejectRcpts map[string]bool
+
+	mu       sync.Mutex
+	auths    []string
+	messages map[string][]string
+}
+
+func newSMTPSink(t *testing.T, rejectRcpts ...string) *smtpSink {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpSink{l: l, rejectRcpts: map[string]bool{}, messages: map[string][]string{}}
+	for _, rcpt := range rejectRcpts {
+		s.rejectRcpts[rcpt] = true
+	}
+	t.Cleanup(func() { require.NoError(t, l.Close()) })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) config(t *testing.T) *conf.SMTPConfig {
+	host, port, err := net.SplitHostPort(s.l.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return &conf.SMTPConfig{
+		Host:     host,
+		Port:     portNum,
+		StartTLS: ptrs.Ptr(false),
+		Username: "determined",
+		Password: "hunter2",
+		From:     "Determined <determined@example.com>",
+	}
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+	reply("220 sink ready")
+	var rcpts []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO":
+			reply("250-sink")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.mu.Lock()
+			s.auths = append(s.auths, cmd)
+			s.mu.Unlock()
+			reply("235 ok")
+		case "MAIL":
+			rcpts = nil
+			reply("250 ok")
+		case "RCPT":
+			rcpt := strings.Trim(strings.TrimPrefix(cmd, "RCPT TO:"), "<>")
+			if s.rejectRcpts[rcpt] {
+				reply("550 no such user")
+				continue
+			}
+			rcpts = append(rcpts, rcpt)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.mu.Lock()
+			for _, rcpt := range rcpts {
+				s.messages[rcpt] = append(s.messages[rcpt], msg.String())
+			}
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSendEmail(t *testing.T) {
+	ctx := context.Background()
+	sink := newSMTPSink(t, "bob@example.com")
+	cfg := sink.config(t)
+
+	c := card{
+		Title: "exp (#1) é",
+		Text:  "Your experiment completed successfully",
+		URL:   "http://det/experiments/1",
+		Level: cardSuccess,
+		Facts: []fact{{Name: "Log", Value: "<script>alert(1)</script>"}},
+	}
+	msg, err := c.email(cfg.From, "alice@example.com")
+	require.NoError(t, err)
+	require.NoError(t, sendEmail(ctx, cfg, "alice@example.com", msg))
+	sink.mu.Lock()
+	require.Len(t, sink.auths, 1)
+	require.Len(t, sink.messages["alice@example.com"], 1)
+	received := sink.messages["alice@example.com"][0]
+	sink.mu.Unlock()
+
+	parsed, err := mail.ReadMessage(strings.NewReader(received))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, c.Title, subject)
+	require.Equal(t, "alice@example.com", parsed.Header.Get("To"))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+	parts := map[string]string{}
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		require.NoError(t, err)
+		parts[strings.SplitN(p.Header.Get("Content-Type"), ";", 2)[0]] = string(body)
+	}
+	require.Contains(t, parts["text/plain"], "Log: <script>alert(1)</script>")
+	require.Contains(t, parts["text/plain"], "View it at http://det/experiments/1")
+	require.Contains(t, parts["text/html"], "&lt;script&gt;alert(1)&lt;/script&gt;")
+	require.Contains(t, parts["text/html"], `<a href="http://det/experiments/1">`)
+
+	// Rejected recipients are not retried.
+	err = sendEmail(ctx, cfg, "bob@example.com", msg)
+	var permanent *back.PermanentError
+	require.ErrorAs(t, err, &permanent)
+}
+
+func TestEmailNotificationSettingsValidate(t *testing.T) {
+	s := EmailNotificationSettings{
+		Email:            "alice@example.com",
+		ExperimentStates: []string{string(model.CompletedState), string(model.ErrorState)},
+		TaskLogRegex:     ptrs.Ptr("(?i)out of memory"),
+	}
+	require.NoError(t, s.Validate())
+	require.Equal(t, "alice@example.com", s.Email)
+
+	named := s
+	named.Email = "Alice Liddell <alice@example.com>"
+	require.NoError(t, named.Validate())
+	require.Equal(t, "alice@example.com", named.Email)
+
+	bad := s
+	bad.Email = "alice"
+	require.Error(t, bad.Validate())
+
+	bad = s
+	bad.ExperimentStates = []string{"DONE"}
+	require.Error(t, bad.Validate())
+
+	bad = s
+	bad.TaskLogRegex = ptrs.Ptr("(")
+	require.Error(t, bad.Validate())
+}
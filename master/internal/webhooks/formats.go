@@ -0,0 +1,361 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	conf "github.com/determined-ai/determined/master/internal/config"
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+// Discord rejects embeds with longer titles and field values.
+const (
+	discordTitleLimit = 256
+	discordValueLimit = 1024
+)
+
+// cardLevel is how good the news of a card is, which decides its color.
+type cardLevel int
+
+const (
+	cardInfo cardLevel = iota
+	cardSuccess
+	cardFailure
+)
+
+// card is a chat message for an event, independent of the chat service it is delivered to.
+type card struct {
+	Title string
+	Text  string
+	URL   string
+	Level cardLevel
+	Facts []fact
+}
+
+type fact struct {
+	Name  string
+	Value string
+}
+
+// TeamsMessageBody corresponds to a Microsoft Teams message with an Adaptive Card attachment.
+type TeamsMessageBody struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+// TeamsAttachment corresponds to an attachment of a Microsoft Teams message.
+type TeamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+// AdaptiveCard corresponds to an Adaptive Card.
+type AdaptiveCard struct {
+	Schema  string            `json:"$schema"`
+	Type    string            `json:"type"`
+	Version string            `json:"version"`
+	Body    []AdaptiveElement `json:"body"`
+	Actions []AdaptiveAction  `json:"actions,omitempty"`
+}
+
+// AdaptiveElement corresponds to a TextBlock or FactSet element of an Adaptive Card.
+type AdaptiveElement struct {
+	Type   string         `json:"type"`
+	Text   string         `json:"text,omitempty"`
+	Size   string         `json:"size,omitempty"`
+	Weight string         `json:"weight,omitempty"`
+	Color  string         `json:"color,omitempty"`
+	Wrap   bool           `json:"wrap,omitempty"`
+	Facts  []AdaptiveFact `json:"facts,omitempty"`
+}
+
+// AdaptiveFact corresponds to a fact of a FactSet element.
+type AdaptiveFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// AdaptiveAction corresponds to an Action.OpenUrl action of an Adaptive Card.
+type AdaptiveAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// DiscordMessageBody corresponds to a Discord webhook message.
+type DiscordMessageBody struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []DiscordEmbed `json:"embeds,omitempty"`
+}
+
+// DiscordEmbed corresponds to an embed of a Discord message.
+type DiscordEmbed struct {
+	Title       string         `json:"title,omitempty"`
+	Description string         `json:"description,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Color       int            `json:"color,omitempty"`
+	Fields      []DiscordField `json:"fields,omitempty"`
+}
+
+// DiscordField corresponds to a field of a Discord embed.
+type DiscordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+func (c card) teams() ([]byte, error) {
+	color := map[cardLevel]string{cardInfo: "accent", cardSuccess: "good", cardFailure: "attention"}
+	body := []AdaptiveElement{
+		{
+			Type: "TextBlock", Text: c.Title, Size: "medium", Weight: "bolder",
+			Color: color[c.Level], Wrap: true,
+		},
+	}
+	if c.Text != "" {
+		body = append(body, AdaptiveElement{Type: "TextBlock", Text: c.Text, Wrap: true})
+	}
+	if len(c.Facts) > 0 {
+		facts := make([]AdaptiveFact, len(c.Facts))
+		for i, f := range c.Facts {
+			facts[i] = AdaptiveFact{Title: f.Name, Value: f.Value}
+		}
+		body = append(body, AdaptiveElement{Type: "FactSet", Facts: facts})
+	}
+	var actions []AdaptiveAction
+	if c.URL != "" {
+		actions = append(actions, AdaptiveAction{Type: "Action.OpenUrl", Title: "View", URL: c.URL})
+	}
+
+	message, err := json.Marshal(TeamsMessageBody{
+		Type: "message",
+		Attachments: []TeamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: AdaptiveCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body:    body,
+				Actions: actions,
+			},
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating teams payload: %w", err)
+	}
+	return message, nil
+}
+
+func (c card) discord() ([]byte, error) {
+	color := map[cardLevel]int{cardInfo: 0x009BDE, cardSuccess: 0x13B670, cardFailure: 0xDD5040}
+	fields := make([]DiscordField, len(c.Facts))
+	for i, f := range c.Facts {
+		fields[i] = DiscordField{Name: f.Name, Value: truncate(f.Value, discordValueLimit), Inline: true}
+	}
+
+	message, err := json.Marshal(DiscordMessageBody{
+		Embeds: []DiscordEmbed{{
+			Title:       truncate(c.Title, discordTitleLimit),
+			Description: c.Text,
+			URL:         c.URL,
+			Color:       color[c.Level],
+			Fields:      fields,
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating discord payload: %w", err)
+	}
+	return message, nil
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// experimentCard returns the card of an experiment event.
+func experimentCard(
+	e model.Experiment, activeConfig expconf.ExperimentConfig,
+	eventData *CustomTriggerData, trialID *int,
+) card {
+	c := card{Title: fmt.Sprintf("%s (#%d)", activeConfig.Name(), e.ID)}
+	switch e.State {
+	case model.CompletedState:
+		c.Text = "Your experiment completed successfully"
+		c.Level = cardSuccess
+	case model.ErrorState:
+		c.Text = "Your experiment has stopped with errors"
+		c.Level = cardFailure
+	default:
+		c.Text = fmt.Sprintf("The status of your experiment is %s", e.State)
+	}
+	if baseURL := conf.GetMasterConfig().Webhooks.BaseURL; baseURL != "" {
+		c.URL = fmt.Sprintf("%s/det/experiments/%d/overview", baseURL, e.ID)
+	}
+
+	endTime := time.Now()
+	if e.EndTime != nil {
+		endTime = *e.EndTime
+	}
+	hours, m := math.Modf(endTime.Sub(e.StartTime).Hours())
+	c.Facts = append(c.Facts,
+		fact{Name: "Status", Value: string(e.State)},
+		fact{Name: "Duration", Value: fmt.Sprintf("%vh %vmin", hours, int(m*60))},
+	)
+	if w := activeConfig.Workspace(); w != "" {
+		c.Facts = append(c.Facts, fact{Name: "Workspace", Value: w})
+	}
+	if p := activeConfig.Project(); p != "" {
+		c.Facts = append(c.Facts, fact{Name: "Project", Value: p})
+	}
+	if trialID != nil && *trialID > 0 {
+		c.Facts = append(c.Facts, fact{Name: "Trial ID", Value: strconv.Itoa(*trialID)})
+	}
+	if eventData != nil {
+		c.Facts = append(c.Facts,
+			fact{Name: "Level", Value: eventData.Level},
+			fact{Name: "Title", Value: eventData.Title},
+			fact{Name: "Description", Value: eventData.Description},
+		)
+	}
+	return c
+}
+
+// taskLogCard returns the card of a task log matching the regex of a trigger.
+func taskLogCard(
+	ctx context.Context, taskID model.TaskID, nodeName, regex, triggeringLog string,
+) (card, error) {
+	task, err := db.TaskByID(ctx, taskID)
+	if err != nil {
+		return card{}, err
+	}
+
+	c := card{
+		Text:  "A log matched the regex of a webhook trigger",
+		Level: cardFailure,
+		Facts: []fact{
+			{Name: "Node", Value: nodeName},
+			{Name: "Log", Value: triggeringLog},
+			{Name: "Regex", Value: regex},
+		},
+	}
+	if task.TaskType == model.TaskTypeTrial {
+		trial, err := db.TrialByTaskID(ctx, taskID)
+		if err != nil {
+			return card{}, err
+		}
+		c.Title = fmt.Sprintf("Experiment %d, trial %d", trial.ExperimentID, trial.ID)
+		if baseURL := conf.GetMasterConfig().Webhooks.BaseURL; baseURL != "" {
+			c.URL = fmt.Sprintf("%s/det/experiments/%d/trials/%d/logs",
+				baseURL, trial.ExperimentID, trial.ID)
+		}
+	} else {
+		c.Title = fmt.Sprintf("Task %s (%s)", taskID, task.TaskType)
+	}
+	return c, nil
+}
+
+// templateFuncs are the functions available to webhook templates, besides the builtins.
+var templateFuncs = template.FuncMap{
+	// json renders a value as JSON, to safely embed strings and objects in a JSON template.
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// renderTemplate renders the body of a templated webhook from an event. The body must be JSON.
+func renderTemplate(text string, p EventPayload) ([]byte, error) {
+	t, err := template.New("webhook").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, p); err != nil {
+		return nil, fmt.Errorf("rendering template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template rendered invalid JSON: %s", truncate(buf.String(), 200))
+	}
+	return buf.Bytes(), nil
+}
+
+// sampleEventPayload is the event of a trigger type templates are validated and previewed with.
+func sampleEventPayload(t TriggerType) EventPayload {
+	p := EventPayload{
+		ID:        uuid.New(),
+		Type:      t,
+		Timestamp: time.Now().Unix(),
+		Condition: Condition{State: model.CompletedState},
+		Data: EventData{
+			TestData: ptrs.Ptr("test"),
+			Experiment: &ExperimentPayload{
+				ID:            1,
+				State:         model.CompletedState,
+				Name:          expconf.Name{RawString: ptrs.Ptr("test-experiment")},
+				Duration:      60,
+				ResourcePool:  "default",
+				SlotsPerTrial: 1,
+				WorkspaceName: "Uncategorized",
+				ProjectName:   "Uncategorized",
+			},
+		},
+	}
+	switch t {
+	case TriggerTypeTaskLog:
+		p.Condition = Condition{Regex: "test"}
+		p.Data.Experiment = nil
+		p.Data.TaskLog = &TaskLogPayload{
+			TaskID:        model.TaskID("test-task"),
+			NodeName:      "test-node",
+			TriggeringLog: "test log",
+		}
+	case TriggerTypeCustom:
+		p.Data.CustomData = &CustomTriggerData{
+			Title:       "test",
+			Description: "test",
+			Level:       model.LogLevelInfo,
+		}
+	}
+	return p
+}
+
+// validateTemplate checks that a template renders JSON from the sample event of each trigger
+// type it can be sent for.
+func validateTemplate(text string, triggers Triggers) error {
+	seen := make(map[TriggerType]bool)
+	for _, t := range triggers {
+		if seen[t.TriggerType] {
+			continue
+		}
+		seen[t.TriggerType] = true
+		if _, err := renderTemplate(text, sampleEventPayload(t.TriggerType)); err != nil {
+			return fmt.Errorf("%s event: %w", t.TriggerType, err)
+		}
+	}
+	return nil
+}
+
+// marshalEventPayload returns the body of a default or templated webhook for an event.
+func marshalEventPayload(w *Webhook, p EventPayload) ([]byte, error) {
+	if w.WebhookType != WebhookTypeTemplated {
+		return json.Marshal(p)
+	}
+	if w.Template == nil {
+		return nil, fmt.Errorf("templated webhook %d has no template", w.ID)
+	}
+	return renderTemplate(*w.Template, p)
+}
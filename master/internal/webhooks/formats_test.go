@@ -0,0 +1,95 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	p := sampleEventPayload(TriggerTypeStateChange)
+
+	b, err := renderTemplate(
+		`{"summary": {{ json .Data.Experiment.Name }}, "state": "{{ .Condition.State }}"}`, p)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"summary": "test-experiment", "state": "COMPLETED"}`, string(b))
+
+	for tmpl, msg := range map[string]string{
+		`{"summary": {{ .Data.Experiment.Name }`:   "parsing template",
+		`{"summary": {{ .Data.Nope }}}`:            "rendering template",
+		`{"summary": {{ .Data.Experiment.Name }}}`: "invalid JSON",
+	} {
+		_, err := renderTemplate(tmpl, p)
+		require.ErrorContains(t, err, msg, tmpl)
+	}
+
+	_, err = marshalEventPayload(&Webhook{WebhookType: WebhookTypeTemplated}, p)
+	require.ErrorContains(t, err, "no template")
+	b, err = marshalEventPayload(&Webhook{WebhookType: WebhookTypeTemplated, Template: ptrs.Ptr(
+		`{"id": "{{ .ID }}"}`)}, p)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": "`+p.ID.String()+`"}`, string(b))
+}
+
+func TestValidateTemplate(t *testing.T) {
+	triggers := func(ts ...TriggerType) Triggers {
+		var out Triggers
+		for _, t := range ts {
+			out = append(out, &Trigger{TriggerType: t})
+		}
+		return out
+	}
+
+	taskLog := `{"text": {{ json .Data.TaskLog.TriggeringLog }}, "regex": {{ json .Condition.Regex }}}`
+	require.NoError(t, validateTemplate(taskLog, triggers(TriggerTypeTaskLog)))
+	require.ErrorContains(t,
+		validateTemplate(taskLog, triggers(TriggerTypeTaskLog, TriggerTypeStateChange)),
+		string(TriggerTypeStateChange))
+
+	custom := `{"title": {{ json .Data.CustomData.Title }}, "level": {{ json .Data.CustomData.Level }}}`
+	require.NoError(t, validateTemplate(custom, triggers(TriggerTypeCustom)))
+	require.ErrorContains(t,
+		validateTemplate(custom, triggers(TriggerTypeStateChange)),
+		string(TriggerTypeStateChange))
+
+	// Experiment fields are missing from task log events.
+	experiment := `{"name": {{ json .Data.Experiment.Name }}}`
+	require.NoError(t, validateTemplate(experiment,
+		triggers(TriggerTypeStateChange, TriggerTypeCustom)))
+	require.ErrorContains(t,
+		validateTemplate(experiment, triggers(TriggerTypeCustom, TriggerTypeTaskLog)),
+		string(TriggerTypeTaskLog))
+}
+
+func TestCards(t *testing.T) {
+	c := card{
+		Title: "title",
+		Text:  "text",
+		URL:   "http://localhost:8080/det/experiments/1/overview",
+		Level: cardFailure,
+		Facts: []fact{{Name: "Log", Value: strings.Repeat("x", 2000)}},
+	}
+
+	b, err := c.teams()
+	require.NoError(t, err)
+	var teams TeamsMessageBody
+	require.NoError(t, json.Unmarshal(b, &teams))
+	require.Len(t, teams.Attachments, 1)
+	card := teams.Attachments[0].Content
+	require.Equal(t, "AdaptiveCard", card.Type)
+	require.Equal(t, "attention", card.Body[0].Color)
+	require.Equal(t, "FactSet", card.Body[2].Type)
+	require.Equal(t, c.URL, card.Actions[0].URL)
+
+	b, err = c.discord()
+	require.NoError(t, err)
+	var discord DiscordMessageBody
+	require.NoError(t, json.Unmarshal(b, &discord))
+	require.Len(t, discord.Embeds, 1)
+	require.Equal(t, 0xDD5040, discord.Embeds[0].Color)
+	require.Len(t, []rune(discord.Embeds[0].Fields[0].Value), discordValueLimit)
+}
@@ -39,6 +39,8 @@ type WebhookManager struct {
 	mu                 sync.RWMutex
 	regexToTriggers    map[string]regexTriggers
 	expToWebhookConfig map[int]*expconf.WebhooksConfigV0
+	emailRegexes       map[model.UserID]*regexp.Regexp
+	expToOwner         map[int]*model.UserID
 }
 
 // CustomTriggerData is the data for custom trigger.
@@ -57,13 +59,27 @@ func New(ctx context.Context) (*WebhookManager, error) {
 		return nil, fmt.Errorf("querying task logs triggers: %w", err)
 	}
 
+	var emailSettings []*EmailNotificationSettings
+	if err := db.Bun().NewSelect().Model(&emailSettings).
+		Where("task_log_regex IS NOT NULL").
+		Scan(ctx); err != nil {
+		return nil, fmt.Errorf("querying task log email notifications: %w", err)
+	}
+
 	m := &WebhookManager{
 		regexToTriggers:    make(map[string]regexTriggers),
 		expToWebhookConfig: make(map[int]*expconf.WebhooksConfigV0),
+		emailRegexes:       make(map[model.UserID]*regexp.Regexp),
+		expToOwner:         make(map[int]*model.UserID),
 	}
 	if err := m.addTriggers(triggers); err != nil {
 		return nil, fmt.Errorf("adding each trigger: %w", err)
 	}
+	for _, s := range emailSettings {
+		if err := m.setEmailRegex(s.UserID, s.TaskLogRegex); err != nil {
+			return nil, fmt.Errorf("adding task log email notification: %w", err)
+		}
+	}
 
 	return m, nil
 }
@@ -143,6 +159,47 @@ func (l *WebhookManager) editTriggers(ts []*Trigger) error {
 	return nil
 }
 
+// setEmailRegex sets the regex a user is emailed for when a log of their experiments matches it.
+func (l *WebhookManager) setEmailRegex(userID model.UserID, regex *string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if regex == nil {
+		delete(l.emailRegexes, userID)
+		return nil
+	}
+	compiled, err := regexp.Compile(*regex)
+	if err != nil {
+		return fmt.Errorf("compiling regex %s: %w", *regex, err)
+	}
+	l.emailRegexes[userID] = compiled
+	return nil
+}
+
+// getExperimentOwner returns the owner of an experiment if any user wants task log emails.
+func (l *WebhookManager) getExperimentOwner(ctx context.Context, expID *int) (*model.UserID, error) {
+	if expID == nil {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.emailRegexes) == 0 {
+		return nil, nil
+	}
+	if owner, ok := l.expToOwner[*expID]; ok {
+		return owner, nil
+	}
+	var owner *model.UserID
+	err := db.Bun().NewSelect().Table("experiments").Column("owner_id").Where("id = ?", *expID).Scan(ctx, &owner)
+	if err != nil {
+		return nil, err
+	}
+	l.expToOwner[*expID] = owner
+	return owner, nil
+}
+
 func (l *WebhookManager) getWebhookConfig(ctx context.Context, expID *int) (*expconf.WebhooksConfigV0, error) {
 	if expID == nil {
 		return nil, nil
@@ -213,6 +270,10 @@ func (l *WebhookManager) scanLogs(
 	if err != nil {
 		return err
 	}
+	owner, err := l.getExperimentOwner(ctx, expID)
+	if err != nil {
+		return err
+	}
 
 	if config != nil && config.Exclude {
 		return nil
@@ -221,11 +282,24 @@ func (l *WebhookManager) scanLogs(
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 
+	var emailRegex *regexp.Regexp
+	if owner != nil {
+		emailRegex = l.emailRegexes[*owner]
+	}
+
 	for _, log := range logs {
 		if log.AgentID == nil {
 			return fmt.Errorf("AgentID must be non nil to trigger webhooks in logs")
 		}
 
+		if emailRegex != nil && !logpattern.ExpconfigCompiledRegex.MatchString(log.Log) &&
+			emailRegex.MatchString(log.Log) {
+			if err := addTaskLogEmailEvent(ctx, model.TaskID(log.TaskID), *owner,
+				*log.AgentID, emailRegex.String(), log.Log); err != nil {
+				return err
+			}
+		}
+
 		for _, cacheItem := range l.regexToTriggers {
 			// One of the trial logs prints expconf which has the regex pattern.
 			// We skip monitoring this line.
@@ -342,7 +416,7 @@ func handleCustomTriggerData(ctx context.Context, data CustomTriggerData, experi
 				continue
 			}
 			err = generateEventForCustomTrigger(
-				ctx, &es, webhook.Triggers, webhook.WebhookType, webhook.URL, m.Experiment, activeConfig, data, trialID)
+				ctx, &es, webhook, m.Experiment, activeConfig, data, trialID)
 			if err != nil {
 				return fmt.Errorf("error genrating event for webhook with ID %d %+v: %w", webhookID, webhook, err)
 			}
@@ -358,7 +432,7 @@ func handleCustomTriggerData(ctx context.Context, data CustomTriggerData, experi
 				continue
 			}
 			err = generateEventForCustomTrigger(
-				ctx, &es, webhook.Triggers, webhook.WebhookType, webhook.URL, m.Experiment, activeConfig, data, trialID)
+				ctx, &es, webhook, m.Experiment, activeConfig, data, trialID)
 			if err != nil {
 				return fmt.Errorf("error genrating event %s %+v: %w", webhookName, webhook, err)
 			}
@@ -380,25 +454,23 @@ func handleCustomTriggerData(ctx context.Context, data CustomTriggerData, experi
 func generateEventForCustomTrigger(
 	ctx context.Context,
 	es *[]Event,
-	triggers Triggers,
-	webhookType WebhookType,
-	webhookURL string,
+	webhook *Webhook,
 	e model.Experiment,
 	activeConfig expconf.ExperimentConfig,
 	data CustomTriggerData,
 	trialID *int,
 ) error {
-	for _, t := range triggers {
+	for _, t := range webhook.Triggers {
 		if t.TriggerType != TriggerTypeCustom {
 			continue
 		}
 		p, err := generateEventPayload(
-			ctx, webhookType, e, activeConfig, e.State, TriggerTypeCustom, &data, trialID,
+			ctx, webhook, e, activeConfig, e.State, TriggerTypeCustom, &data, trialID,
 		)
 		if err != nil {
 			return fmt.Errorf("error generating event payload: %w", err)
 		}
-		*es = append(*es, Event{Payload: p, URL: webhookURL})
+		*es = append(*es, Event{Payload: p, URL: webhook.URL, WebhookID: &webhook.ID})
 	}
 	return nil
 }
@@ -471,6 +543,11 @@ func ReportExperimentStateChanged(
 		}
 	}()
 
+	es, err := experimentEmailEvents(ctx, e, activeConfig)
+	if err != nil {
+		return err
+	}
+
 	var ts []Trigger
 	switch err := db.Bun().NewSelect().Model(&ts).Relation("Webhook").
 		Where("trigger_type = ?", TriggerTypeStateChange).
@@ -478,7 +555,7 @@ func ReportExperimentStateChanged(
 		Scan(ctx); {
 	case err != nil:
 		return err
-	case len(ts) == 0:
+	case len(ts) == 0 && len(es) == 0:
 		return nil
 	}
 
@@ -491,18 +568,17 @@ func ReportExperimentStateChanged(
 		webhookConfig = activeConfig.Integrations().Webhooks
 	}
 
-	var es []Event
 	for _, t := range ts {
 		if !matchWebhook(&t, webhookConfig, workspaceID, ptrs.Ptr(e.ID)) {
 			continue
 		}
 		p, err := generateEventPayload(
-			ctx, t.Webhook.WebhookType, e, activeConfig, e.State, TriggerTypeStateChange, nil, nil,
+			ctx, t.Webhook, e, activeConfig, e.State, TriggerTypeStateChange, nil, nil,
 		)
 		if err != nil {
 			return fmt.Errorf("error generating event payload: %w", err)
 		}
-		es = append(es, Event{Payload: p, URL: t.Webhook.URL})
+		es = append(es, Event{Payload: p, URL: t.Webhook.URL, WebhookID: &t.Webhook.ID})
 	}
 	if len(es) == 0 {
 		return nil
@@ -532,7 +608,7 @@ func addTaskLogEvent(ctx context.Context,
 	}
 
 	p, err := generateTaskLogPayload(
-		ctx, taskID, nodeName, regex, triggeringLog, trigger.Webhook.WebhookType)
+		ctx, taskID, nodeName, regex, triggeringLog, trigger.Webhook)
 	if err != nil {
 		return fmt.Errorf("generating task logs event: %w", err)
 	}
@@ -553,8 +629,9 @@ func addTaskLogEvent(ctx context.Context,
 		}
 
 		if _, err := db.Bun().NewInsert().Model(&Event{
-			Payload: p,
-			URL:     trigger.Webhook.URL,
+			Payload:   p,
+			URL:       trigger.Webhook.URL,
+			WebhookID: &trigger.Webhook.ID,
 		}).Exec(ctx); err != nil {
 			return fmt.Errorf("inserting task logs event trigger: %w", err)
 		}
@@ -578,11 +655,11 @@ func generateTaskLogPayload(
 	nodeName,
 	regex,
 	triggeringLog string,
-	wt WebhookType,
+	w *Webhook,
 ) ([]byte, error) {
-	switch wt {
-	case WebhookTypeDefault:
-		p, err := json.Marshal(EventPayload{
+	switch w.WebhookType {
+	case WebhookTypeDefault, WebhookTypeTemplated:
+		p, err := marshalEventPayload(w, EventPayload{
 			ID:        uuid.New(),
 			Type:      TriggerTypeTaskLog,
 			Timestamp: time.Now().Unix(),
@@ -610,8 +687,19 @@ func generateTaskLogPayload(
 		}
 		return p, nil
 
+	case WebhookTypeTeams, WebhookTypeDiscord:
+		c, err := taskLogCard(ctx, taskID, nodeName, regex, triggeringLog)
+		if err != nil {
+			return nil, err
+		}
+		if w.WebhookType == WebhookTypeTeams {
+			return c.teams()
+		}
+		return c.discord()
+
 	default:
-		return nil, fmt.Errorf("unknown webhook type %+v while generating log pattern payload", wt)
+		return nil, fmt.Errorf(
+			"unknown webhook type %+v while generating log pattern payload", w.WebhookType)
 	}
 }
 
@@ -675,20 +763,20 @@ func generateLogPatternSlackPayload(
 
 func generateEventPayload(
 	ctx context.Context,
-	wt WebhookType,
+	w *Webhook,
 	e model.Experiment,
 	activeConfig expconf.ExperimentConfig,
 	expState model.State,
 	tT TriggerType,
 	eventData *CustomTriggerData, trialID *int,
 ) ([]byte, error) {
-	switch wt {
-	case WebhookTypeDefault:
+	switch w.WebhookType {
+	case WebhookTypeDefault, WebhookTypeTemplated:
 		experiment := experimentToWebhookPayload(e, activeConfig)
 		if trialID != nil && *trialID > 0 {
 			experiment.TrialID = *trialID
 		}
-		pJSON, err := json.Marshal(EventPayload{
+		pJSON, err := marshalEventPayload(w, EventPayload{
 			ID:        uuid.New(),
 			Type:      tT,
 			Timestamp: time.Now().Unix(),
@@ -710,8 +798,12 @@ func generateEventPayload(
 			return nil, err
 		}
 		return slackJSON, nil
+	case WebhookTypeTeams:
+		return experimentCard(e, activeConfig, eventData, trialID).teams()
+	case WebhookTypeDiscord:
+		return experimentCard(e, activeConfig, eventData, trialID).discord()
 	default:
-		panic(fmt.Errorf("unknown webhook type: %+v", wt))
+		panic(fmt.Errorf("unknown webhook type: %+v", w.WebhookType))
 	}
 }
 
@@ -939,14 +1031,23 @@ func (l *WebhookManager) updateWebhook(
 	}
 
 	err = db.Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		_, err := tx.NewUpdate().Table("webhooks").
-			Set("url = ?", p.Url).
-			Where("id = ?", webhookID).
-			Exec(ctx)
-		if err != nil {
+		q := tx.NewUpdate().Table("webhooks").Where("id = ?", webhookID)
+		if p.Url != "" {
+			q.Set("url = ?", p.Url)
+		}
+		if p.Enabled != nil {
+			q.Set("disabled = ?", !*p.Enabled)
+			if *p.Enabled {
+				q.Set("consecutive_failures = 0")
+			}
+		}
+		if _, err := q.Exec(ctx); err != nil {
 			return fmt.Errorf("updating webhook %d: %w", webhookID, err)
 		}
 
+		if p.Url == "" {
+			return nil
+		}
 		for _, t := range ts {
 			t.Webhook.URL = p.Url
 		}
@@ -961,3 +1062,27 @@ func (l *WebhookManager) updateWebhook(
 
 	return nil
 }
+
+func (l *WebhookManager) putEmailNotificationSettings(
+	ctx context.Context, s *EmailNotificationSettings,
+) error {
+	if _, err := db.Bun().NewInsert().Model(s).
+		On("CONFLICT (user_id) DO UPDATE").
+		Set("email = EXCLUDED.email").
+		Set("experiment_states = EXCLUDED.experiment_states").
+		Set("task_log_regex = EXCLUDED.task_log_regex").
+		Exec(ctx); err != nil {
+		return fmt.Errorf("upserting email notification settings: %w", err)
+	}
+	return l.setEmailRegex(s.UserID, s.TaskLogRegex)
+}
+
+func (l *WebhookManager) deleteEmailNotificationSettings(ctx context.Context, userID model.UserID) error {
+	res, err := db.Bun().NewDelete().Model((*EmailNotificationSettings)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err := db.MustHaveAffectedRows(res, err); err != nil {
+		return err
+	}
+	return l.setEmailRegex(userID, nil)
+}
@@ -370,7 +370,7 @@ func testGenerateTaskLogPayloadTest(
 		}
 
 		payload, err := generateTaskLogPayload(
-			ctx, task.TaskID, "nodeA", "regexa", "trigA", webhookType)
+			ctx, task.TaskID, "nodeA", "regexa", "trigA", &Webhook{WebhookType: webhookType})
 		require.NoError(t, err)
 
 		if webhookType == WebhookTypeDefault {
@@ -865,3 +865,68 @@ func countEventsForURL(ctx context.Context, t *testing.T, url string) int {
 
 	return c
 }
+
+func TestEmailNotifications(t *testing.T) {
+	ctx := context.Background()
+	clearWebhooksTables(ctx, t)
+	singletonShipper = &shipper{wake: make(chan<- struct{})} // mock shipper
+
+	originalConfig := config.GetMasterConfig().Webhooks
+	defer func() {
+		config.GetMasterConfig().Webhooks = originalConfig
+	}()
+	config.GetMasterConfig().Webhooks.SMTP = &config.SMTPConfig{
+		Host: "localhost",
+		From: "determined@example.com",
+	}
+
+	manager, err := New(ctx)
+	require.NoError(t, err)
+
+	user := db.RequireMockUser(t, pgDB)
+	exp := db.RequireMockExperiment(t, pgDB, user)
+	email := uuid.NewString() + "@example.com"
+	s := &EmailNotificationSettings{
+		UserID:           user.ID,
+		Email:            email,
+		ExperimentStates: []string{string(model.CompletedState)},
+		TaskLogRegex:     ptrs.Ptr("out of memory"),
+	}
+	require.NoError(t, manager.putEmailNotificationSettings(ctx, s))
+	got, err := GetEmailNotificationSettings(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, s.ExperimentStates, got.ExperimentStates)
+
+	var expConfig expconf.ExperimentConfig
+	expConfig = schemas.WithDefaults(expConfig)
+
+	exp.State = model.CanceledState
+	require.NoError(t, ReportExperimentStateChanged(ctx, *exp, expConfig))
+	require.Zero(t, countEventsForURL(ctx, t, mailtoScheme+email))
+	exp.State = model.CompletedState
+	require.NoError(t, ReportExperimentStateChanged(ctx, *exp, expConfig))
+	require.Equal(t, 1, countEventsForURL(ctx, t, mailtoScheme+email))
+
+	// Task log emails are sent once per task.
+	clearWebhooksEvent(ctx, t)
+	task := db.RequireMockTask(t, pgDB, &user.ID)
+	logs := []*model.TaskLog{
+		{TaskID: string(task.TaskID), AgentID: ptrs.Ptr("test"), Log: "CUDA out of memory"},
+		{TaskID: string(task.TaskID), AgentID: ptrs.Ptr("test"), Log: "fine"},
+	}
+	require.NoError(t, manager.scanLogs(ctx, logs, 0, nil))
+	require.Zero(t, countEventsForURL(ctx, t, mailtoScheme+email))
+	require.NoError(t, manager.scanLogs(ctx, logs, 0, ptrs.Ptr(exp.ID)))
+	require.NoError(t, manager.scanLogs(ctx, logs, 0, ptrs.Ptr(exp.ID)))
+	require.Equal(t, 1, countEventsForURL(ctx, t, mailtoScheme+email))
+
+	// Opting out stops both.
+	clearWebhooksEvent(ctx, t)
+	require.NoError(t, manager.deleteEmailNotificationSettings(ctx, user.ID))
+	require.ErrorIs(t, manager.deleteEmailNotificationSettings(ctx, user.ID), db.ErrNotFound)
+	require.NoError(t, ReportExperimentStateChanged(ctx, *exp, expConfig))
+	task = db.RequireMockTask(t, pgDB, &user.ID)
+	logs[0].TaskID = string(task.TaskID)
+	require.NoError(t, manager.scanLogs(ctx, logs, 0, ptrs.Ptr(exp.ID)))
+	require.Zero(t, countEventsForURL(ctx, t, mailtoScheme+email))
+}
@@ -155,12 +155,7 @@ func (w *worker) shipBatch(ctx context.Context) (int, error) {
 		wg.Add(1)
 		go func(e Event) {
 			defer wg.Done()
-			if err := back.Retry(
-				func() error { return w.deliver(ctx, e) },
-				backoff(),
-			); err != nil {
-				w.log.WithError(err).Error("failed to deliver webhook")
-			}
+			w.deliverWithRetries(ctx, e)
 		}(e)
 	}
 	wg.Wait()
@@ -181,29 +176,82 @@ func backoff() back.BackOff {
 	return back.WithMaxRetries(bf, backoffAttempts)
 }
 
-func (w *worker) deliver(ctx context.Context, e Event) error {
+// deliverWithRetries delivers an event, retrying with backoff, and dead-letters it if it could not
+// be delivered. Events of disabled webhooks are dead-lettered right away.
+func (w *worker) deliverWithRetries(ctx context.Context, e Event) {
+	log := w.log.WithField("event-id", e.ID)
+
+	disabled, err := webhookDisabled(ctx, e)
+	if err != nil {
+		log.WithError(err).Warn("failed to check whether webhook is disabled")
+	}
+	if disabled {
+		if err := deadLetterEvent(ctx, e, errWebhookDisabled); err != nil {
+			log.WithError(err).Error("failed to dead-letter event")
+		}
+		return
+	}
+
+	attempt := 0
+	err = back.Retry(func() error {
+		attempt++
+		return w.deliver(ctx, e, attempt)
+	}, backoff())
+	if ctx.Err() != nil {
+		return // The batch is rolled back and the event delivered again later.
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to deliver webhook")
+		if err := deadLetterEvent(ctx, e, err); err != nil {
+			log.WithError(err).Error("failed to dead-letter event")
+		}
+	}
+	if err := recordDeliveryResult(ctx, e, err == nil); err != nil {
+		log.WithError(err).Warn("failed to record delivery result")
+	}
+}
+
+func (w *worker) deliver(ctx context.Context, e Event, attempt int) error {
+	start := time.Now()
+	statusCode, body, err := w.send(ctx, e)
+	if rErr := recordAttempt(ctx, e, attempt, time.Since(start), statusCode, body, err); rErr != nil {
+		w.log.WithError(rErr).Warn("failed to record delivery attempt")
+	}
+	return err
+}
+
+// send sends an event, returning the status code and start of the body of the response, if any.
+func (w *worker) send(ctx context.Context, e Event) (*int, []byte, error) {
+	if to, ok := isEmail(e); ok {
+		return nil, nil, deliverEmail(ctx, to, e.Payload)
+	}
+
 	req, err := generateWebhookRequest(ctx, e.URL, e.Payload, time.Now().Unix())
 	if err != nil {
-		return err
+		return nil, nil, back.Permanent(err)
 	}
 
 	resp, err := w.cl.Do(req)
 	if err != nil {
-		return fmt.Errorf("sending webhook request: %w", err)
+		return nil, nil, fmt.Errorf("sending webhook request: %w", err)
 	}
 	defer func() {
 		if err = resp.Body.Close(); err != nil {
 			w.log.WithError(err).Warn("failed to close response body")
 		}
 	}()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit))
+	if err != nil {
+		w.log.WithError(err).Warn("failed to read response body")
+	}
 
 	switch {
 	case resp.StatusCode >= 500: //nolint: usestdlibvars
-		return fmt.Errorf("request returned %v: %w", resp.StatusCode, err)
+		return &resp.StatusCode, body, fmt.Errorf("request returned %v", resp.StatusCode)
 	case resp.StatusCode >= 400: //nolint: usestdlibvars
-		return back.Permanent(fmt.Errorf("request returned %v: %w", resp.StatusCode, err))
+		return &resp.StatusCode, body, back.Permanent(fmt.Errorf("request returned %v", resp.StatusCode))
 	default:
-		return nil
+		return &resp.StatusCode, body, nil
 	}
 }
 
@@ -55,3 +55,23 @@ func UpdateWebhook(ctx context.Context, id int32, p *webhookv1.PatchWebhook) err
 
 	return defaultManager.updateWebhook(ctx, id, p)
 }
+
+// PutEmailNotificationSettings opts a user in to email notifications, or updates their settings.
+func PutEmailNotificationSettings(ctx context.Context, s *EmailNotificationSettings) error {
+	if defaultManager == nil {
+		log.Error("webhook manager is uninitialized")
+		return nil
+	}
+
+	return defaultManager.putEmailNotificationSettings(ctx, s)
+}
+
+// DeleteEmailNotificationSettings opts a user out of email notifications.
+func DeleteEmailNotificationSettings(ctx context.Context, userID model.UserID) error {
+	if defaultManager == nil {
+		log.Error("webhook manager is uninitialized")
+		return nil
+	}
+
+	return defaultManager.deleteEmailNotificationSettings(ctx, userID)
+}
@@ -35,6 +35,12 @@ type Webhook struct {
 	Mode        WebhookMode `bun:"mode,notnull"`
 	WorkspaceID *int32      `bun:"workspace_id"`
 	Name        string      `bun:"name,notnull"`
+	// Template is the text/template the body of a templated webhook is rendered from.
+	Template  *string       `bun:"template"`
+	CreatedBy *model.UserID `bun:"created_by"`
+	// Disabled webhooks have their events dead-lettered without being sent.
+	Disabled            bool `bun:"disabled,notnull"`
+	ConsecutiveFailures int  `bun:"consecutive_failures,notnull"`
 
 	Triggers Triggers `bun:"rel:has-many,join:id=webhook_id"`
 }
@@ -52,6 +58,7 @@ func WebhookFromProto(w *webhookv1.Webhook) Webhook {
 		Name:        w.Name,
 		WorkspaceID: workspaceID,
 		Mode:        WebhookModeFromProto(w.Mode),
+		Template:    w.Template,
 	}
 }
 
@@ -69,6 +76,10 @@ func (w *Webhook) Proto() *webhookv1.Webhook {
 		Name:        w.Name,
 		Mode:        w.Mode.Proto(),
 		WorkspaceId: workspaceID,
+		Template:    w.Template,
+
+		Disabled:            w.Disabled,
+		ConsecutiveFailures: int32(w.ConsecutiveFailures),
 	}
 }
 
@@ -166,6 +177,15 @@ const (
 
 	// WebhookTypeSlack represents a slack webhook.
 	WebhookTypeSlack WebhookType = "SLACK"
+
+	// WebhookTypeTeams represents a Microsoft Teams webhook.
+	WebhookTypeTeams WebhookType = "TEAMS"
+
+	// WebhookTypeDiscord represents a Discord webhook.
+	WebhookTypeDiscord WebhookType = "DISCORD"
+
+	// WebhookTypeTemplated represents a webhook whose body is rendered from a template.
+	WebhookTypeTemplated WebhookType = "TEMPLATED"
 )
 
 const (
@@ -193,6 +213,12 @@ func WebhookTypeFromProto(w webhookv1.WebhookType) WebhookType {
 		return WebhookTypeDefault
 	case webhookv1.WebhookType_WEBHOOK_TYPE_SLACK:
 		return WebhookTypeSlack
+	case webhookv1.WebhookType_WEBHOOK_TYPE_TEAMS:
+		return WebhookTypeTeams
+	case webhookv1.WebhookType_WEBHOOK_TYPE_DISCORD:
+		return WebhookTypeDiscord
+	case webhookv1.WebhookType_WEBHOOK_TYPE_TEMPLATED:
+		return WebhookTypeTemplated
 	default:
 		// TODO(???): prob don't panic
 		panic(fmt.Errorf("missing mapping for webhook type %s to SQL", w))
@@ -223,6 +249,12 @@ func (w WebhookType) Proto() webhookv1.WebhookType {
 		return webhookv1.WebhookType_WEBHOOK_TYPE_DEFAULT
 	case WebhookTypeSlack:
 		return webhookv1.WebhookType_WEBHOOK_TYPE_SLACK
+	case WebhookTypeTeams:
+		return webhookv1.WebhookType_WEBHOOK_TYPE_TEAMS
+	case WebhookTypeDiscord:
+		return webhookv1.WebhookType_WEBHOOK_TYPE_DISCORD
+	case WebhookTypeTemplated:
+		return webhookv1.WebhookType_WEBHOOK_TYPE_TEMPLATED
 	default:
 		return webhookv1.WebhookType_WEBHOOK_TYPE_UNSPECIFIED
 	}
@@ -285,6 +317,8 @@ type Event struct {
 	ID      WebhookEventID `bun:"id,pk,autoincrement"`
 	URL     string         `bun:"url,notnull"`
 	Payload []byte         `bun:"payload,notnull"`
+	// WebhookID is the webhook the event is for, unset for emails.
+	WebhookID *WebhookID `bun:"webhook_id"`
 }
 
 // SlackMessageBody corresponds to an entire message as a Slack Block.
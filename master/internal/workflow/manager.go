@@ -0,0 +1,401 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+)
+
+// ReconcileInterval is how often the manager checks the tasks of active workflows.
+const ReconcileInterval = 10 * time.Second
+
+// ErrInvalidState is returned when a workflow cannot be paused, resumed or killed in its
+// current state.
+var ErrInvalidState = errors.New("invalid workflow state")
+
+// The environment variables that pass a workflow to the tasks of its nodes.
+const (
+	EnvWorkflowID = "DET_WORKFLOW_ID"
+	EnvNode       = "DET_WORKFLOW_NODE"
+	EnvParameters = "DET_WORKFLOW_PARAMETERS"
+	// EnvInputs holds the task metadata of the nodes a node depends on, by node name.
+	EnvInputs = "DET_WORKFLOW_INPUTS"
+)
+
+// Tasks launches and controls the generic tasks of the nodes of workflows.
+type Tasks interface {
+	// Launch launches the task of a node with the workflow context directory and extra
+	// environment variables.
+	Launch(
+		ctx context.Context, w *Workflow, node NodeSpec, contextDirectory []byte,
+		env map[string]string,
+	) (model.TaskID, error)
+	Kill(ctx context.Context, taskID model.TaskID) error
+	Pause(ctx context.Context, taskID model.TaskID) error
+	Unpause(ctx context.Context, taskID model.TaskID) error
+}
+
+// DefaultManager is the manager of the workflows of the master.
+var DefaultManager *Manager
+
+// Manager launches the nodes of workflows as their dependencies complete. All of its state is in
+// the database, so workflows carry on across master restarts.
+type Manager struct {
+	// mu serializes changes to workflows so that a node is never launched twice.
+	mu    sync.Mutex
+	tasks Tasks
+}
+
+// NewManager returns a manager that launches and controls tasks with tasks.
+func NewManager(tasks Tasks) *Manager {
+	return &Manager{tasks: tasks}
+}
+
+// Create validates and persists a workflow, then launches the nodes that have no dependencies.
+// It returns db.ErrInvalidInput if the spec is invalid.
+func (m *Manager) Create(ctx context.Context, w *Workflow) error {
+	if err := w.Spec.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", db.ErrInvalidInput, err)
+	}
+	if err := Insert(ctx, w); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.reconcile(ctx, w)
+}
+
+// Reconcile updates a workflow with the states of the tasks of its nodes, launching nodes whose
+// dependencies completed, and returns it.
+func (m *Manager) Reconcile(ctx context.Context, id int) (*Workflow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, err := ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.reconcile(ctx, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Pause stops launching nodes of an active workflow and pauses the tasks of its running nodes.
+func (m *Manager) Pause(ctx context.Context, id int) error {
+	return m.transition(ctx, id, []State{StateActive}, StatePaused,
+		func(n *Node) error {
+			if n.State != NodeStateRunning {
+				return nil
+			}
+			return m.tasks.Pause(ctx, *n.TaskID)
+		})
+}
+
+// Resume unpauses the paused tasks of a paused workflow and resumes launching its nodes.
+func (m *Manager) Resume(ctx context.Context, id int) error {
+	return m.transition(ctx, id, []State{StatePaused}, StateActive,
+		func(n *Node) error {
+			if n.State != NodeStatePaused {
+				return nil
+			}
+			return m.tasks.Unpause(ctx, *n.TaskID)
+		})
+}
+
+// Kill kills the tasks of the running and paused nodes of a workflow and cancels its pending
+// nodes.
+func (m *Manager) Kill(ctx context.Context, id int) error {
+	return m.transition(ctx, id, []State{StateActive, StatePaused}, StateStopping,
+		func(n *Node) error {
+			if n.State != NodeStateRunning && n.State != NodeStatePaused {
+				return nil
+			}
+			return m.tasks.Kill(ctx, *n.TaskID)
+		})
+}
+
+// transition moves a workflow from one of the states from to the state to, then applies
+// toTask to each of its nodes. The workflow is persisted in its new state before any task is
+// touched so that nodes are not launched in the meantime.
+func (m *Manager) transition(
+	ctx context.Context, id int, from []State, to State, toTask func(*Node) error,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, err := ByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := m.reconcile(ctx, w); err != nil {
+		return err
+	}
+	allowed := false
+	for _, s := range from {
+		allowed = allowed || w.State == s
+	}
+	if !allowed {
+		return fmt.Errorf("%w: workflow %d is %s", ErrInvalidState, id, w.State)
+	}
+
+	w.State = to
+	if err := save(ctx, w, w.Nodes); err != nil {
+		return err
+	}
+	var errs []error
+	for _, n := range w.Nodes {
+		if n.TaskID == nil {
+			continue
+		}
+		if err := toTask(n); err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", n.Name, err))
+		}
+	}
+	if err := m.reconcile(ctx, w); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// ReconcileAll reconciles all workflows that are not in a terminal state.
+func (m *Manager) ReconcileAll(ctx context.Context) error {
+	ids, err := nonTerminalIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("getting workflows: %w", err)
+	}
+	for _, id := range ids {
+		if _, err := m.Reconcile(ctx, id); err != nil {
+			log.WithError(err).Errorf("error reconciling workflow %d", id)
+		}
+	}
+	return nil
+}
+
+// Run reconciles all workflows periodically until the context is canceled.
+func (m *Manager) Run(ctx context.Context) {
+	t := time.NewTicker(ReconcileInterval)
+	defer t.Stop()
+	for {
+		if err := m.ReconcileAll(ctx); err != nil {
+			log.WithError(err).Error("error reconciling workflows")
+		}
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) reconcile(ctx context.Context, w *Workflow) error {
+	if w.State.Terminal() {
+		return nil
+	}
+	prevState := w.State
+	prev := make(map[string]Node, len(w.Nodes))
+	var taskIDs []model.TaskID
+	for _, n := range w.Nodes {
+		prev[n.Name] = *n
+		if !n.State.Terminal() && n.TaskID != nil {
+			taskIDs = append(taskIDs, *n.TaskID)
+		}
+	}
+	states, err := taskStates(ctx, taskIDs)
+	if err != nil {
+		return fmt.Errorf("getting tasks of workflow %d: %w", w.ID, err)
+	}
+
+	var contextDir []byte
+	for launch := advance(w, states, time.Now().UTC()); len(launch) > 0; launch = advance(
+		w, states, time.Now().UTC(),
+	) {
+		if contextDir == nil {
+			if contextDir, err = contextDirectory(ctx, w.ID); err != nil {
+				return fmt.Errorf("getting context directory of workflow %d: %w", w.ID, err)
+			}
+		}
+		for _, name := range launch {
+			// Persist each launch right away so that a failure to persist the rest of the
+			// workflow cannot launch the node again.
+			n := m.launch(ctx, w, name, contextDir)
+			if err := save(ctx, w, []*Node{n}); err != nil {
+				return err
+			}
+		}
+	}
+
+	var changed []*Node
+	for _, n := range w.Nodes {
+		if p := prev[n.Name]; !nodeEqual(&p, n) {
+			changed = append(changed, n)
+		}
+	}
+	if w.State == prevState && len(changed) == 0 {
+		return nil
+	}
+	return save(ctx, w, changed)
+}
+
+func nodeEqual(a, b *Node) bool {
+	return a.State == b.State && a.Attempts == b.Attempts &&
+		equalPtr(a.TaskID, b.TaskID) && equalPtr(a.ErrorMessage, b.ErrorMessage)
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// launch launches the task of a node, marking the node as failed if it cannot be launched, and
+// returns the node.
+func (m *Manager) launch(ctx context.Context, w *Workflow, name string, contextDir []byte) *Node {
+	n := w.Node(name)
+	spec, _ := w.Spec.Node(name)
+	n.Attempts++
+
+	taskID, err := func() (model.TaskID, error) {
+		env, err := m.env(ctx, w, spec)
+		if err != nil {
+			return "", err
+		}
+		return m.tasks.Launch(ctx, w, spec, contextDir, env)
+	}()
+	if err != nil {
+		log.WithError(err).Warnf("error launching node %s of workflow %d", name, w.ID)
+		n.State = NodeStateError
+		n.ErrorMessage = ptrs.Ptr(err.Error())
+		return n
+	}
+	n.TaskID = &taskID
+	n.State = NodeStateRunning
+	n.ErrorMessage = nil
+	return n
+}
+
+// env returns the environment variables of the task of a node.
+func (m *Manager) env(ctx context.Context, w *Workflow, spec NodeSpec) (map[string]string, error) {
+	params, err := json.Marshal(w.Spec.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("encoding parameters: %w", err)
+	}
+	inputs := make(map[string]map[string]any, len(spec.DependsOn))
+	for _, d := range spec.DependsOn {
+		md, err := db.GetTaskMetadata(ctx, *w.Node(d).TaskID)
+		if err != nil {
+			return nil, fmt.Errorf("getting metadata of node %s: %w", d, err)
+		}
+		inputs[d] = md
+	}
+	inputsJSON, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, fmt.Errorf("encoding inputs: %w", err)
+	}
+	return map[string]string{
+		EnvWorkflowID: strconv.Itoa(w.ID),
+		EnvNode:       spec.Name,
+		EnvParameters: string(params),
+		EnvInputs:     string(inputsJSON),
+	}, nil
+}
+
+// advance updates the nodes of a workflow with the states of their tasks and returns the names
+// of the nodes to launch, which are the pending nodes whose dependencies all completed and the
+// failed nodes with retries left. Nodes that depend on a node that did not complete are skipped,
+// but other branches of the workflow carry on. Once every node is done, the workflow ends.
+func advance(w *Workflow, states map[model.TaskID]model.TaskState, now time.Time) []string {
+	if w.State.Terminal() {
+		return nil
+	}
+	order, err := w.Spec.order()
+	if err != nil {
+		// Specs are validated before being persisted.
+		panic(err)
+	}
+
+	var launch []string
+	for _, name := range order {
+		n := w.Node(name)
+		spec, _ := w.Spec.Node(name)
+		switch n.State {
+		case NodeStateRunning, NodeStatePaused:
+			state, ok := states[*n.TaskID]
+			if !ok {
+				continue
+			}
+			switch state {
+			case model.TaskStateCompleted:
+				n.State = NodeStateCompleted
+			case model.TaskStateCanceled, model.TaskStateStoppingCanceled:
+				n.State = NodeStateCanceled
+			case model.TaskStateError:
+				switch {
+				case w.State == StateStopping:
+					n.State = NodeStateCanceled
+				case n.Attempts > spec.MaxRetries:
+					n.State = NodeStateError
+				case w.State == StateActive:
+					launch = append(launch, name)
+				}
+			case model.TaskStatePaused, model.TaskStateStoppingPaused:
+				n.State = NodeStatePaused
+			default:
+				n.State = NodeStateRunning
+			}
+		case NodeStatePending:
+			switch w.State {
+			case StateStopping:
+				n.State = NodeStateCanceled
+			case StateActive:
+				ready := true
+				for _, d := range spec.DependsOn {
+					switch w.Node(d).State {
+					case NodeStateCompleted:
+					case NodeStateError, NodeStateCanceled, NodeStateSkipped:
+						n.State = NodeStateSkipped
+						ready = false
+					default:
+						ready = false
+					}
+				}
+				if ready {
+					launch = append(launch, name)
+				}
+			}
+		}
+	}
+	if len(launch) > 0 {
+		return launch
+	}
+
+	allCompleted := true
+	for _, n := range w.Nodes {
+		if !n.State.Terminal() {
+			return nil
+		}
+		allCompleted = allCompleted && n.State == NodeStateCompleted
+	}
+	switch {
+	case w.State == StateStopping:
+		w.State = StateCanceled
+	case allCompleted:
+		w.State = StateCompleted
+	default:
+		w.State = StateError
+	}
+	w.EndTime = &now
+	return nil
+}
@@ -0,0 +1,170 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// newTestWorkflow returns an active workflow of prep -> (train-a, train-b) -> eval.
+func newTestWorkflow() *Workflow {
+	w := &Workflow{
+		State: StateActive,
+		Spec: Spec{Nodes: []NodeSpec{
+			node("prep"), node("train-a", "prep"), node("train-b", "prep"),
+			node("eval", "train-a", "train-b"),
+		}},
+	}
+	for _, n := range w.Spec.Nodes {
+		w.Nodes = append(w.Nodes, &Node{Name: n.Name, State: NodeStatePending})
+	}
+	return w
+}
+
+// start marks nodes as running tasks named after them.
+func start(w *Workflow, names ...string) {
+	for _, name := range names {
+		n := w.Node(name)
+		n.TaskID = (*model.TaskID)(&n.Name)
+		n.State = NodeStateRunning
+		n.Attempts++
+	}
+}
+
+func nodeStates(w *Workflow) map[string]NodeState {
+	res := map[string]NodeState{}
+	for _, n := range w.Nodes {
+		res[n.Name] = n.State
+	}
+	return res
+}
+
+func TestAdvance(t *testing.T) {
+	now := time.Now()
+	w := newTestWorkflow()
+	require.Equal(t, []string{"prep"}, advance(w, nil, now))
+
+	start(w, "prep")
+	require.Empty(t, advance(w, map[model.TaskID]model.TaskState{"prep": model.TaskStateActive}, now))
+	require.Equal(t, NodeStateRunning, w.Node("prep").State)
+
+	launch := advance(w, map[model.TaskID]model.TaskState{"prep": model.TaskStateCompleted}, now)
+	require.ElementsMatch(t, []string{"train-a", "train-b"}, launch)
+	require.Equal(t, NodeStateCompleted, w.Node("prep").State)
+
+	start(w, "train-a", "train-b")
+	require.Empty(t, advance(w, map[model.TaskID]model.TaskState{
+		"train-a": model.TaskStateCompleted,
+		"train-b": model.TaskStateActive,
+	}, now))
+
+	require.Equal(t, []string{"eval"}, advance(w, map[model.TaskID]model.TaskState{
+		"train-b": model.TaskStateCompleted,
+	}, now))
+
+	start(w, "eval")
+	require.Empty(t, advance(w, map[model.TaskID]model.TaskState{
+		"eval": model.TaskStateCompleted,
+	}, now))
+	require.Equal(t, StateCompleted, w.State)
+	require.Equal(t, &now, w.EndTime)
+}
+
+func TestAdvanceRetry(t *testing.T) {
+	now := time.Now()
+	w := newTestWorkflow()
+	w.Spec.Nodes[0].MaxRetries = 1
+	start(w, "prep")
+
+	failed := map[model.TaskID]model.TaskState{"prep": model.TaskStateError}
+	require.Equal(t, []string{"prep"}, advance(w, failed, now))
+
+	// A paused workflow retries the node once resumed.
+	w.State = StatePaused
+	require.Empty(t, advance(w, failed, now))
+	require.Equal(t, NodeStateRunning, w.Node("prep").State)
+	w.State = StateActive
+
+	start(w, "prep")
+	require.Equal(t, 2, w.Node("prep").Attempts)
+	require.Empty(t, advance(w, failed, now))
+	require.Equal(t, map[string]NodeState{
+		"prep":    NodeStateError,
+		"train-a": NodeStateSkipped,
+		"train-b": NodeStateSkipped,
+		"eval":    NodeStateSkipped,
+	}, nodeStates(w))
+	require.Equal(t, StateError, w.State)
+}
+
+func TestAdvanceFailedBranch(t *testing.T) {
+	now := time.Now()
+	w := newTestWorkflow()
+	w.Node("prep").State = NodeStateCompleted
+	start(w, "train-a", "train-b")
+
+	// The other branch carries on after a failure.
+	require.Empty(t, advance(w, map[model.TaskID]model.TaskState{
+		"train-a": model.TaskStateError,
+		"train-b": model.TaskStateActive,
+	}, now))
+	require.Equal(t, NodeStateSkipped, w.Node("eval").State)
+	require.Equal(t, StateActive, w.State)
+
+	require.Empty(t, advance(w, map[model.TaskID]model.TaskState{
+		"train-b": model.TaskStateCompleted,
+	}, now))
+	require.Equal(t, NodeStateCompleted, w.Node("train-b").State)
+	require.Equal(t, StateError, w.State)
+}
+
+func TestAdvancePauseAndKill(t *testing.T) {
+	now := time.Now()
+	w := newTestWorkflow()
+	w.Node("prep").State = NodeStateCompleted
+	start(w, "train-a", "train-b")
+
+	w.State = StatePaused
+	require.Empty(t, advance(w, map[model.TaskID]model.TaskState{
+		"train-a": model.TaskStateStoppingPaused,
+		"train-b": model.TaskStateCompleted,
+	}, now))
+	require.Equal(t, NodeStatePaused, w.Node("train-a").State)
+	require.Equal(t, NodeStatePending, w.Node("eval").State)
+	require.Equal(t, StatePaused, w.State)
+
+	w.State = StateStopping
+	require.Empty(t, advance(w, map[model.TaskID]model.TaskState{
+		"train-a": model.TaskStateStoppingCanceled,
+	}, now))
+	require.Equal(t, map[string]NodeState{
+		"prep":    NodeStateCompleted,
+		"train-a": NodeStateCanceled,
+		"train-b": NodeStateCompleted,
+		"eval":    NodeStateCanceled,
+	}, nodeStates(w))
+	require.Equal(t, StateCanceled, w.State)
+}
+
+func TestAdvanceKillRunning(t *testing.T) {
+	now := time.Now()
+	w := newTestWorkflow()
+	w.Spec.Nodes[0].MaxRetries = 3
+	start(w, "prep")
+	w.State = StateStopping
+
+	require.Empty(t, advance(w, map[model.TaskID]model.TaskState{
+		"prep": model.TaskStateActive,
+	}, now))
+	require.Equal(t, StateStopping, w.State)
+
+	// Killed tasks may fail rather than be canceled, which is not retried.
+	require.Empty(t, advance(w, map[model.TaskID]model.TaskState{
+		"prep": model.TaskStateError,
+	}, now))
+	require.Equal(t, NodeStateCanceled, w.Node("prep").State)
+	require.Equal(t, StateCanceled, w.State)
+}
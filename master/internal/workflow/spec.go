@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxNodes is the largest number of nodes a workflow can have.
+const MaxNodes = 256
+
+var nodeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// NodeSpec is the specification of a node of a workflow.
+type NodeSpec struct {
+	Name       string   `json:"name"`
+	DependsOn  []string `json:"depends_on,omitempty"`
+	Config     string   `json:"config"`
+	MaxRetries int      `json:"max_retries,omitempty"`
+}
+
+// Spec is the specification of a workflow: generic task nodes and the dependencies between them,
+// along with parameters passed to every node.
+type Spec struct {
+	Nodes      []NodeSpec     `json:"nodes"`
+	Parameters map[string]any `json:"parameters,omitempty"`
+}
+
+// Node returns the specification of the node with the given name.
+func (s *Spec) Node(name string) (NodeSpec, bool) {
+	for _, n := range s.Nodes {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return NodeSpec{}, false
+}
+
+// Validate checks that node names are unique, that dependencies refer to other nodes of the
+// workflow and that the dependencies have no cycles. It does not validate node configs.
+func (s *Spec) Validate() error {
+	if len(s.Nodes) == 0 {
+		return fmt.Errorf("a workflow must have at least one node")
+	}
+	if len(s.Nodes) > MaxNodes {
+		return fmt.Errorf("a workflow can have at most %d nodes, got %d", MaxNodes, len(s.Nodes))
+	}
+	names := make(map[string]bool, len(s.Nodes))
+	for _, n := range s.Nodes {
+		if !nodeNamePattern.MatchString(n.Name) {
+			return fmt.Errorf("invalid node name %q: names must start with a letter or digit, "+
+				"contain only letters, digits, '_', '.' and '-' and be at most 128 characters", n.Name)
+		}
+		if names[n.Name] {
+			return fmt.Errorf("duplicate node name %q", n.Name)
+		}
+		names[n.Name] = true
+		if strings.TrimSpace(n.Config) == "" {
+			return fmt.Errorf("node %q has no config", n.Name)
+		}
+		if n.MaxRetries < 0 {
+			return fmt.Errorf("node %q has negative max_retries", n.Name)
+		}
+	}
+	for _, n := range s.Nodes {
+		seen := make(map[string]bool, len(n.DependsOn))
+		for _, d := range n.DependsOn {
+			switch {
+			case d == n.Name:
+				return fmt.Errorf("node %q depends on itself", n.Name)
+			case !names[d]:
+				return fmt.Errorf("node %q depends on unknown node %q", n.Name, d)
+			case seen[d]:
+				return fmt.Errorf("node %q depends on %q more than once", n.Name, d)
+			}
+			seen[d] = true
+		}
+	}
+	if _, err := s.order(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// order returns the names of the nodes in an order where every node comes after its
+// dependencies, keeping the order of the spec where possible.
+func (s *Spec) order() ([]string, error) {
+	remaining := make(map[string]int, len(s.Nodes))
+	dependents := make(map[string][]string, len(s.Nodes))
+	for _, n := range s.Nodes {
+		remaining[n.Name] = len(n.DependsOn)
+		for _, d := range n.DependsOn {
+			dependents[d] = append(dependents[d], n.Name)
+		}
+	}
+
+	order := make([]string, 0, len(s.Nodes))
+	done := make(map[string]bool, len(s.Nodes))
+	for len(order) < len(s.Nodes) {
+		progressed := false
+		for _, n := range s.Nodes {
+			if done[n.Name] || remaining[n.Name] > 0 {
+				continue
+			}
+			done[n.Name] = true
+			progressed = true
+			order = append(order, n.Name)
+			for _, d := range dependents[n.Name] {
+				remaining[d]--
+			}
+		}
+		if !progressed {
+			var cycle []string
+			for _, n := range s.Nodes {
+				if !done[n.Name] {
+					cycle = append(cycle, n.Name)
+				}
+			}
+			return nil, fmt.Errorf("nodes %s are part of or depend on a dependency cycle",
+				strings.Join(cycle, ", "))
+		}
+	}
+	return order, nil
+}
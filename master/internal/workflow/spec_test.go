@@ -0,0 +1,59 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func node(name string, deps ...string) NodeSpec {
+	return NodeSpec{Name: name, DependsOn: deps, Config: "entrypoint: echo " + name}
+}
+
+func TestSpecValidate(t *testing.T) {
+	valid := Spec{Nodes: []NodeSpec{
+		node("prep"), node("train-a", "prep"), node("train-b", "prep"),
+		node("eval", "train-a", "train-b"),
+	}}
+	require.NoError(t, valid.Validate())
+
+	cases := map[string]Spec{
+		"no nodes":        {},
+		"duplicate names": {Nodes: []NodeSpec{node("a"), node("a")}},
+		"bad name":        {Nodes: []NodeSpec{node("a b")}},
+		"no config":       {Nodes: []NodeSpec{{Name: "a"}}},
+		"negative retries": {Nodes: []NodeSpec{
+			{Name: "a", Config: "entrypoint: x", MaxRetries: -1},
+		}},
+		"self dependency":    {Nodes: []NodeSpec{node("a", "a")}},
+		"unknown dependency": {Nodes: []NodeSpec{node("a", "b")}},
+		"repeated dependency": {Nodes: []NodeSpec{
+			node("a"), node("b", "a", "a"),
+		}},
+		"cycle": {Nodes: []NodeSpec{
+			node("a"), node("b", "a", "d"), node("c", "b"), node("d", "c"),
+		}},
+	}
+	for name, s := range cases {
+		require.Error(t, s.Validate(), name)
+	}
+}
+
+func TestSpecOrder(t *testing.T) {
+	s := Spec{Nodes: []NodeSpec{
+		node("eval", "train-a", "train-b"), node("train-b", "prep"), node("prep"),
+		node("train-a", "prep"), node("report"),
+	}}
+	order, err := s.order()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"prep", "report", "train-a", "train-b", "eval"}, order)
+	pos := map[string]int{}
+	for i, name := range order {
+		pos[name] = i
+	}
+	for _, n := range s.Nodes {
+		for _, d := range n.DependsOn {
+			require.Less(t, pos[d], pos[n.Name], "%s depends on %s", n.Name, d)
+		}
+	}
+}
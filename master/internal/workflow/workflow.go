@@ -0,0 +1,279 @@
+// Package workflow implements workflows: generic tasks launched as the nodes of a dependency
+// graph, where each node runs once all the nodes it depends on complete.
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/proto/pkg/workflowv1"
+)
+
+// State is the state of a workflow.
+type State string
+
+const (
+	// StateActive means that nodes are launched as their dependencies complete.
+	StateActive State = "ACTIVE"
+	// StatePaused means that no nodes are launched and the running nodes are paused.
+	StatePaused State = "PAUSED"
+	// StateStopping means that the workflow was killed and its nodes are being killed.
+	StateStopping State = "STOPPING"
+	// StateCompleted means that all nodes completed.
+	StateCompleted State = "COMPLETED"
+	// StateError means that a node failed and no more nodes can run.
+	StateError State = "ERROR"
+	// StateCanceled means that the workflow was killed.
+	StateCanceled State = "CANCELED"
+)
+
+// Terminal returns whether a workflow in this state will never change state again.
+func (s State) Terminal() bool {
+	return s == StateCompleted || s == StateError || s == StateCanceled
+}
+
+// Proto converts a workflow state to its proto representation.
+func (s State) Proto() workflowv1.State {
+	return workflowv1.State(workflowv1.State_value["STATE_"+string(s)])
+}
+
+// NodeState is the state of a node of a workflow.
+type NodeState string
+
+const (
+	// NodeStatePending means that the node is waiting for its dependencies.
+	NodeStatePending NodeState = "PENDING"
+	// NodeStateRunning means that the task of the node is running.
+	NodeStateRunning NodeState = "RUNNING"
+	// NodeStatePaused means that the task of the node is paused.
+	NodeStatePaused NodeState = "PAUSED"
+	// NodeStateCompleted means that the task of the node completed.
+	NodeStateCompleted NodeState = "COMPLETED"
+	// NodeStateError means that the task of the node failed and has no retries left, or that it
+	// could not be launched.
+	NodeStateError NodeState = "ERROR"
+	// NodeStateCanceled means that the task of the node was killed.
+	NodeStateCanceled NodeState = "CANCELED"
+	// NodeStateSkipped means that the node never ran because a dependency did not complete.
+	NodeStateSkipped NodeState = "SKIPPED"
+)
+
+// Terminal returns whether a node in this state will never change state again.
+func (s NodeState) Terminal() bool {
+	switch s {
+	case NodeStateCompleted, NodeStateError, NodeStateCanceled, NodeStateSkipped:
+		return true
+	default:
+		return false
+	}
+}
+
+// Proto converts a node state to its proto representation.
+func (s NodeState) Proto() workflowv1.NodeState {
+	return workflowv1.NodeState(workflowv1.NodeState_value["NODE_STATE_"+string(s)])
+}
+
+// Workflow corresponds to a row in the "workflows" DB table.
+type Workflow struct {
+	bun.BaseModel `bun:"table:workflows"`
+
+	ID        int          `bun:"id,pk,autoincrement"`
+	Name      string       `bun:"name,notnull"`
+	ProjectID int          `bun:"project_id,notnull"`
+	OwnerID   model.UserID `bun:"owner_id,notnull"`
+	Spec      Spec         `bun:"spec,type:jsonb,notnull"`
+	// ContextDirectory is the gzipped tarball shared by all nodes. It is only loaded to launch
+	// nodes.
+	ContextDirectory []byte     `bun:"context_directory,notnull"`
+	State            State      `bun:"state,notnull"`
+	StartTime        time.Time  `bun:"start_time,nullzero,notnull,default:current_timestamp"`
+	EndTime          *time.Time `bun:"end_time"`
+
+	Nodes []*Node `bun:"rel:has-many,join:id=workflow_id"`
+}
+
+// Node corresponds to a row in the "workflow_nodes" DB table.
+type Node struct {
+	bun.BaseModel `bun:"table:workflow_nodes"`
+
+	WorkflowID int    `bun:"workflow_id,pk"`
+	Name       string `bun:"name,pk"`
+	// TaskID is the task of the latest attempt of the node.
+	TaskID       *model.TaskID `bun:"task_id"`
+	Attempts     int           `bun:"attempts,notnull"`
+	State        NodeState     `bun:"state,notnull"`
+	ErrorMessage *string       `bun:"error_message"`
+}
+
+// Node returns the node with the given name.
+func (w *Workflow) Node(name string) *Node {
+	for _, n := range w.Nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// Proto converts a workflow to its proto representation.
+func (w *Workflow) Proto() (*workflowv1.Workflow, error) {
+	params, err := structpb.NewStruct(w.Spec.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("converting parameters of workflow %d: %w", w.ID, err)
+	}
+	pb := &workflowv1.Workflow{
+		Id:         int32(w.ID),
+		Name:       w.Name,
+		ProjectId:  int32(w.ProjectID),
+		UserId:     int32(w.OwnerID),
+		State:      w.State.Proto(),
+		StartTime:  timestamppb.New(w.StartTime),
+		Parameters: params,
+	}
+	if w.EndTime != nil {
+		pb.EndTime = timestamppb.New(*w.EndTime)
+	}
+	for _, spec := range w.Spec.Nodes {
+		n := w.Node(spec.Name)
+		if n == nil {
+			return nil, fmt.Errorf("workflow %d has no node %s", w.ID, spec.Name)
+		}
+		pb.Nodes = append(pb.Nodes, &workflowv1.Node{
+			Name:         n.Name,
+			State:        n.State.Proto(),
+			TaskId:       (*string)(n.TaskID),
+			Attempts:     int32(n.Attempts),
+			ErrorMessage: n.ErrorMessage,
+			DependsOn:    append([]string{}, spec.DependsOn...),
+		})
+	}
+	return pb, nil
+}
+
+// Insert persists a new workflow and its nodes, all pending.
+func Insert(ctx context.Context, w *Workflow) error {
+	w.State = StateActive
+	if w.ContextDirectory == nil {
+		w.ContextDirectory = []byte{}
+	}
+	w.Nodes = make([]*Node, 0, len(w.Spec.Nodes))
+	return db.Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(w).Returning("id, start_time").Exec(ctx); err != nil {
+			return fmt.Errorf("persisting workflow: %w", db.MatchSentinelError(err))
+		}
+		for _, spec := range w.Spec.Nodes {
+			w.Nodes = append(w.Nodes, &Node{
+				WorkflowID: w.ID,
+				Name:       spec.Name,
+				State:      NodeStatePending,
+			})
+		}
+		if _, err := tx.NewInsert().Model(&w.Nodes).Exec(ctx); err != nil {
+			return fmt.Errorf("persisting nodes of workflow %d: %w", w.ID, err)
+		}
+		return nil
+	})
+}
+
+// ByID returns a workflow and its nodes, without its context directory. It returns
+// db.ErrNotFound if there is no such workflow.
+func ByID(ctx context.Context, id int) (*Workflow, error) {
+	var w Workflow
+	if err := db.Bun().NewSelect().Model(&w).
+		ExcludeColumn("context_directory").
+		Relation("Nodes").
+		Where("workflow.id = ?", id).
+		Scan(ctx); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return &w, nil
+}
+
+// ByProject returns the workflows of a project and their nodes, newest first.
+func ByProject(ctx context.Context, projectID int) ([]*Workflow, error) {
+	ws := []*Workflow{}
+	if err := db.Bun().NewSelect().Model(&ws).
+		ExcludeColumn("context_directory").
+		Relation("Nodes").
+		Where("workflow.project_id = ?", projectID).
+		Order("workflow.id DESC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// nonTerminalIDs returns the ids of the workflows that are not in a terminal state.
+func nonTerminalIDs(ctx context.Context) ([]int, error) {
+	var ids []int
+	if err := db.Bun().NewSelect().Model((*Workflow)(nil)).
+		Column("id").
+		Where("state NOT IN (?)", bun.In([]State{StateCompleted, StateError, StateCanceled})).
+		Order("id").
+		Scan(ctx, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// contextDirectory returns the context directory of a workflow.
+func contextDirectory(ctx context.Context, id int) ([]byte, error) {
+	var b []byte
+	if err := db.Bun().NewSelect().Model((*Workflow)(nil)).
+		Column("context_directory").
+		Where("id = ?", id).
+		Scan(ctx, &b); err != nil {
+		return nil, db.MatchSentinelError(err)
+	}
+	return b, nil
+}
+
+// taskStates returns the states of the given tasks.
+func taskStates(ctx context.Context, taskIDs []model.TaskID) (map[model.TaskID]model.TaskState, error) {
+	states := make(map[model.TaskID]model.TaskState, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return states, nil
+	}
+	var rows []struct {
+		TaskID model.TaskID    `bun:"task_id"`
+		State  model.TaskState `bun:"task_state"`
+	}
+	if err := db.Bun().NewSelect().Table("tasks").
+		Column("task_id", "task_state").
+		Where("task_id IN (?)", bun.In(taskIDs)).
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		states[r.TaskID] = r.State
+	}
+	return states, nil
+}
+
+// save persists the state of a workflow and the given nodes of it.
+func save(ctx context.Context, w *Workflow, nodes []*Node) error {
+	return db.Bun().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().Model(w).
+			Column("state", "end_time").
+			WherePK().
+			Exec(ctx); err != nil {
+			return fmt.Errorf("updating workflow %d: %w", w.ID, err)
+		}
+		for _, n := range nodes {
+			if _, err := tx.NewUpdate().Model(n).
+				Column("task_id", "attempts", "state", "error_message").
+				WherePK().
+				Exec(ctx); err != nil {
+				return fmt.Errorf("updating node %s of workflow %d: %w", n.Name, w.ID, err)
+			}
+		}
+		return nil
+	})
+}
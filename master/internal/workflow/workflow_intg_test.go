@@ -0,0 +1,174 @@
+//go:build integration
+// +build integration
+
+package workflow
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/internal/db"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+type fakeTasks struct {
+	t    *testing.T
+	pgDB *db.PgDB
+	user model.User
+
+	envs     map[string]map[string]string
+	tasks    map[string]model.TaskID
+	killed   []model.TaskID
+	paused   []model.TaskID
+	unpaused []model.TaskID
+}
+
+func (f *fakeTasks) Launch(
+	ctx context.Context, w *Workflow, node NodeSpec, contextDirectory []byte,
+	env map[string]string,
+) (model.TaskID, error) {
+	task := db.RequireMockTask(f.t, f.pgDB, &f.user.ID)
+	setTaskState(f.t, task.TaskID, model.TaskStateActive)
+	f.envs[node.Name] = env
+	f.tasks[node.Name] = task.TaskID
+	return task.TaskID, nil
+}
+
+func (f *fakeTasks) Kill(ctx context.Context, taskID model.TaskID) error {
+	f.killed = append(f.killed, taskID)
+	return nil
+}
+
+func (f *fakeTasks) Pause(ctx context.Context, taskID model.TaskID) error {
+	f.paused = append(f.paused, taskID)
+	return nil
+}
+
+func (f *fakeTasks) Unpause(ctx context.Context, taskID model.TaskID) error {
+	f.unpaused = append(f.unpaused, taskID)
+	return nil
+}
+
+func setTaskState(t *testing.T, taskID model.TaskID, state model.TaskState) {
+	_, err := db.Bun().NewUpdate().Table("tasks").
+		Set("task_state = ?", state).
+		Where("task_id = ?", taskID).
+		Exec(context.Background())
+	require.NoError(t, err)
+}
+
+func TestWorkflow(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, etc.SetRootPath(db.RootFromDB))
+	pgDB, closeDB := db.MustResolveTestPostgres(t)
+	defer closeDB()
+	db.MustMigrateTestPostgres(t, pgDB, db.MigrationsFromDB)
+	user := db.RequireMockUser(t, pgDB)
+	workspaceID, _ := db.RequireMockWorkspaceID(t, pgDB, "")
+	projectID, _ := db.RequireMockProjectID(t, pgDB, workspaceID, false)
+
+	f := &fakeTasks{
+		t: t, pgDB: pgDB, user: user,
+		envs: map[string]map[string]string{}, tasks: map[string]model.TaskID{},
+	}
+	m := NewManager(f)
+
+	require.ErrorIs(t, m.Create(ctx, &Workflow{
+		Name: "cyclic", ProjectID: projectID, OwnerID: user.ID,
+		Spec: Spec{Nodes: []NodeSpec{node("a", "b"), node("b", "a")}},
+	}), db.ErrInvalidInput)
+
+	train := node("train", "prep")
+	train.MaxRetries = 1
+	w := &Workflow{
+		Name:      "pipeline",
+		ProjectID: projectID,
+		OwnerID:   user.ID,
+		Spec: Spec{
+			Nodes:      []NodeSpec{node("prep"), train, node("eval", "train")},
+			Parameters: map[string]any{"lr": 0.1},
+		},
+	}
+	require.NoError(t, m.Create(ctx, w))
+	require.Len(t, f.tasks, 1)
+	require.Equal(t, map[string]string{
+		EnvWorkflowID: strconv.Itoa(w.ID),
+		EnvNode:       "prep",
+		EnvParameters: `{"lr":0.1}`,
+		EnvInputs:     `{}`,
+	}, f.envs["prep"])
+
+	// Nodes pass on outputs through task metadata.
+	_, err := db.MergeTaskMetadata(ctx, f.tasks["prep"], map[string]any{"path": "s3://a"})
+	require.NoError(t, err)
+	md, err := db.MergeTaskMetadata(ctx, f.tasks["prep"], map[string]any{"rows": 3.0})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"path": "s3://a", "rows": 3.0}, md)
+	_, err = db.MergeTaskMetadata(ctx, f.tasks["prep"], map[string]any{
+		"big": strings.Repeat("x", db.MaxTaskMetadataSize),
+	})
+	require.ErrorIs(t, err, db.ErrInvalidInput)
+	md, err = db.GetTaskMetadata(ctx, f.tasks["prep"])
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"path": "s3://a", "rows": 3.0}, md)
+
+	// State lives in the database, so a new manager carries on where the last one left off.
+	m = NewManager(f)
+	setTaskState(t, f.tasks["prep"], model.TaskStateCompleted)
+	w, err = m.Reconcile(ctx, w.ID)
+	require.NoError(t, err)
+	require.Equal(t, NodeStateCompleted, w.Node("prep").State)
+	require.Equal(t, NodeStateRunning, w.Node("train").State)
+	require.Equal(t, `{"prep":{"path":"s3://a","rows":3}}`, f.envs["train"][EnvInputs])
+
+	// Failed nodes are retried.
+	firstTrain := f.tasks["train"]
+	setTaskState(t, firstTrain, model.TaskStateError)
+	w, err = m.Reconcile(ctx, w.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, firstTrain, f.tasks["train"])
+	require.Equal(t, 2, w.Node("train").Attempts)
+	require.Equal(t, f.tasks["train"], *w.Node("train").TaskID)
+
+	require.NoError(t, m.Pause(ctx, w.ID))
+	require.Equal(t, []model.TaskID{f.tasks["train"]}, f.paused)
+	require.ErrorIs(t, m.Pause(ctx, w.ID), ErrInvalidState)
+	setTaskState(t, f.tasks["train"], model.TaskStatePaused)
+
+	require.NoError(t, m.Resume(ctx, w.ID))
+	require.Equal(t, []model.TaskID{f.tasks["train"]}, f.unpaused)
+	setTaskState(t, f.tasks["train"], model.TaskStateActive)
+
+	require.NoError(t, m.Kill(ctx, w.ID))
+	require.Equal(t, []model.TaskID{f.tasks["train"]}, f.killed)
+	w, err = ByID(ctx, w.ID)
+	require.NoError(t, err)
+	require.Equal(t, StateStopping, w.State)
+	require.Equal(t, NodeStateCanceled, w.Node("eval").State)
+
+	setTaskState(t, f.tasks["train"], model.TaskStateCanceled)
+	require.NoError(t, m.ReconcileAll(ctx))
+	w, err = ByID(ctx, w.ID)
+	require.NoError(t, err)
+	require.Equal(t, StateCanceled, w.State)
+	require.NotNil(t, w.EndTime)
+	require.ErrorIs(t, m.Resume(ctx, w.ID), ErrInvalidState)
+
+	ws, err := ByProject(ctx, projectID)
+	require.NoError(t, err)
+	require.Len(t, ws, 1)
+	pb, err := ws[0].Proto()
+	require.NoError(t, err)
+	require.Len(t, pb.Nodes, 3)
+	require.Equal(t, "prep", pb.Nodes[0].Name)
+	require.Equal(t, []string{"train"}, pb.Nodes[2].DependsOn)
+
+	_, err = ByID(ctx, -1)
+	require.ErrorIs(t, err, db.ErrNotFound)
+}
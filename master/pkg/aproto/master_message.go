@@ -50,6 +50,9 @@ type MasterMessage struct {
 	ContainerStateChanged *ContainerStateChanged
 	ContainerLog          *ContainerLog
 	ContainerStatsRecord  *ContainerStatsRecord
+	// AgentTerminationNotice notifies the master that the cloud provider is about to reclaim the
+	// instance of the agent.
+	AgentTerminationNotice *AgentTerminationNotice
 }
 
 // ContainerReattach is a struct describing containers that can be reattached.
@@ -81,6 +84,15 @@ type AgentStarted struct {
 	Devices              []device.Device
 	ContainersReattached []ContainerReattachAck
 	ResourcePoolName     string
+	CapacityType         model.CapacityType
+}
+
+// AgentTerminationNotice notifies the master that the instance of the agent will be reclaimed.
+type AgentTerminationNotice struct {
+	// Action is what the cloud provider will do to the instance, e.g. "terminate" or "stop".
+	Action string
+	// Time is when the instance will be reclaimed.
+	Time time.Time
 }
 
 // ContainerStateChanged notifies the master that the agent transitioned the container state.
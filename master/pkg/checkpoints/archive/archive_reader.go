@@ -0,0 +1,63 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// ArchiveReader defines an interface to read the files of an archive in order.
+type ArchiveReader interface {
+	// Next advances to the next file in the archive and returns its entry. It returns io.EOF at
+	// the end of the archive.
+	Next() (*FileEntry, error)
+	// Read reads the contents of the current file.
+	Read(b []byte) (int, error)
+	Close() error
+}
+
+// NewArchiveReader returns a new ArchiveReader for archiveType that reads from r. Zip archives
+// can not be read sequentially and are not supported.
+func NewArchiveReader(r io.Reader, archiveType ArchiveType) (ArchiveReader, error) {
+	switch archiveType {
+	case ArchiveTar:
+		return newTarArchiveReader(r, nil), nil
+
+	case ArchiveTgz:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return newTarArchiveReader(gz, []io.Closer{gz}), nil
+
+	default:
+		return nil, fmt.Errorf(
+			"archive type must be %s or %s. received %s", ArchiveTar, ArchiveTgz, archiveType)
+	}
+}
+
+type tarArchiveReader struct {
+	archiveClosers
+	tr *tar.Reader
+}
+
+func newTarArchiveReader(r io.Reader, closers []io.Closer) *tarArchiveReader {
+	return &tarArchiveReader{archiveClosers{closers}, tar.NewReader(r)}
+}
+
+func (ar *tarArchiveReader) Next() (*FileEntry, error) {
+	for {
+		hdr, err := ar.tr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			return &FileEntry{Path: hdr.Name, Size: hdr.Size}, nil
+		}
+	}
+}
+
+func (ar *tarArchiveReader) Read(b []byte) (int, error) {
+	return ar.tr.Read(b)
+}
@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveReaderRoundTrip(t *testing.T) {
+	for _, archiveType := range []ArchiveType{ArchiveTar, ArchiveTgz} {
+		t.Run(string(archiveType), func(t *testing.T) {
+			var buf bytes.Buffer
+			aw, err := NewArchiveWriter(&buf, archiveType)
+			require.NoError(t, err)
+			files := map[string]string{
+				"foo":         "bar",
+				"dir/":        "",
+				"dir/nested":  "contents",
+				"dir/empty":   "",
+				"another.txt": "hello",
+			}
+			order := []string{"foo", "dir/", "dir/nested", "dir/empty", "another.txt"}
+			for _, path := range order {
+				require.NoError(t, aw.WriteHeader(path, int64(len(files[path]))))
+				_, err := aw.Write([]byte(files[path]))
+				require.NoError(t, err)
+			}
+			require.NoError(t, aw.Close())
+
+			ar, err := NewArchiveReader(&buf, archiveType)
+			require.NoError(t, err)
+			var paths []string
+			for {
+				entry, err := ar.Next()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				paths = append(paths, entry.Path)
+
+				content, err := io.ReadAll(ar)
+				require.NoError(t, err)
+				require.Equal(t, files[entry.Path], string(content))
+				require.Equal(t, int64(len(content)), entry.Size)
+			}
+			require.NoError(t, ar.Close())
+			// Directories are skipped.
+			require.Equal(t, []string{"foo", "dir/nested", "dir/empty", "another.txt"}, paths)
+		})
+	}
+
+	_, err := NewArchiveReader(&bytes.Buffer{}, ArchiveZip)
+	require.Error(t, err)
+}
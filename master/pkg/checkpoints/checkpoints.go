@@ -74,6 +74,58 @@ func NewDownloader(
 	}
 }
 
+// CheckpointUploader defines the interface for uploading the files of a checkpoint.
+type CheckpointUploader interface {
+	// Upload stores the contents of r as the file at path, relative to the checkpoint.
+	Upload(ctx context.Context, path string, r io.Reader) error
+	// Delete removes the file at path, relative to the checkpoint, that was uploaded before.
+	Delete(ctx context.Context, path string) error
+}
+
+// NewUploader returns a new CheckpointUploader that stores the files of checkpoint id.
+func NewUploader(
+	ctx context.Context,
+	id string,
+	storageConfig *expconf.CheckpointStorageConfig,
+) (CheckpointUploader, error) {
+	idPrefix := func(prefix string) string {
+		prefix = strings.TrimRight(prefix, "/")
+		return prefix + "/" + id
+	}
+	idPrefixRef := func(prefixRef *string) string {
+		prefix := ""
+		if prefixRef != nil {
+			prefix = *prefixRef
+		}
+		return idPrefix(prefix)
+	}
+
+	switch storage := storageConfig.GetUnionMember().(type) {
+	case expconf.S3Config:
+		prefix := idPrefixRef(storage.Prefix())
+		return s3.NewS3Uploader(ctx, storage.Bucket(), prefix, storage.EndpointURL())
+
+	case expconf.GCSConfig:
+		prefix := idPrefixRef(storage.Prefix())
+		return gcs.NewGCSUploader(ctx, storage.Bucket(), prefix)
+
+	case expconf.SharedFSConfig:
+		pathPrefix, err := storage.PathInContainerOrHost()
+		if err != nil {
+			return nil, err
+		}
+		return local.NewLocalUploader(idPrefix(pathPrefix)), nil
+
+	case expconf.DirectoryConfig:
+		return local.NewLocalUploader(idPrefix(storage.ContainerPath())), nil
+
+	default:
+		return nil,
+			fmt.Errorf("checkpoint upload via master is not supported for %s",
+				storageConfig2Str(storage))
+	}
+}
+
 func storageConfig2Str(config any) string {
 	switch config.(type) {
 	case expconf.AzureConfig:
@@ -2,6 +2,8 @@ package gcs
 
 import (
 	"context"
+	"errors"
+	"io"
 	"strings"
 
 	"cloud.google.com/go/storage"
@@ -131,3 +133,45 @@ func NewGCSDownloader(
 		buffer: make([]byte, DefaultDownloadPartSize),
 	}, nil
 }
+
+// GCSUploader implements uploading the files of a checkpoint to GCS.
+type GCSUploader struct {
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+// Upload uploads a file of the checkpoint.
+func (u *GCSUploader) Upload(ctx context.Context, path string, r io.Reader) error {
+	w := u.bucket.Object(u.prefix + path).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Delete deletes a file of the checkpoint.
+func (u *GCSUploader) Delete(ctx context.Context, path string) error {
+	err := u.bucket.Object(u.prefix + path).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+// NewGCSUploader returns a new GCSUploader.
+func NewGCSUploader(ctx context.Context, bucket string, prefix string) (*GCSUploader, error) {
+	prefix = strings.TrimLeft(prefix, "/")
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GCSUploader{
+		bucket: client.Bucket(bucket),
+		prefix: prefix,
+	}, nil
+}
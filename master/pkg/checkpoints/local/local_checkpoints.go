@@ -2,6 +2,7 @@ package local
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -125,3 +126,54 @@ func NewLocalDownloader(aw archive.ArchiveWriter, prefix string) (*LocalDownload
 		buffer: make([]byte, DefaultDownloadPartSize),
 	}, nil
 }
+
+// LocalUploader implements uploading the files of a checkpoint to the local filesystem.
+type LocalUploader struct {
+	prefix string
+}
+
+// Upload writes a file of the checkpoint.
+func (u *LocalUploader) Upload(ctx context.Context, path string, r io.Reader) error {
+	if !filepath.IsLocal(path) {
+		return errors.Errorf("checkpoint file path %s is not local to the checkpoint", path)
+	}
+	dst := filepath.Join(u.prefix, path)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil { //nolint: gosec
+		return err
+	}
+	f, err := os.Create(dst) //nolint: gosec
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Delete removes a file of the checkpoint, and the directories of the checkpoint it leaves empty.
+func (u *LocalUploader) Delete(ctx context.Context, path string) error {
+	if !filepath.IsLocal(path) {
+		return errors.Errorf("checkpoint file path %s is not local to the checkpoint", path)
+	}
+	dst := filepath.Join(u.prefix, path)
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for dir := filepath.Dir(dst); dir != filepath.Dir(u.prefix); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			// The directory still holds other files.
+			break
+		}
+	}
+	return nil
+}
+
+// NewLocalUploader returns a new LocalUploader.
+func NewLocalUploader(prefix string) *LocalUploader {
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return &LocalUploader{prefix: filepath.Clean(prefix)}
+}
@@ -109,6 +109,73 @@ func NewS3Downloader(
 		prefix += "/"
 	}
 
+	sess, err := newSession(ctx, bucket, endpointURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Downloader{
+		aw:     aw,
+		client: s3.New(sess),
+		downloader: s3manager.NewDownloader(sess, func(d *s3manager.Downloader) {
+			d.Concurrency = 1 // Setting concurrency to 1 to use seqWriterAt
+		}),
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+// S3Uploader implements uploading the files of a checkpoint to S3.
+type S3Uploader struct {
+	uploader *s3manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+// Upload uploads a file of the checkpoint.
+func (u *S3Uploader) Upload(ctx context.Context, path string, r io.Reader) error {
+	_, err := u.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: &u.bucket,
+		Key:    ptrs.Ptr(u.prefix + path),
+		Body:   r,
+	})
+	return err
+}
+
+// Delete deletes a file of the checkpoint.
+func (u *S3Uploader) Delete(ctx context.Context, path string) error {
+	_, err := u.uploader.S3.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: &u.bucket,
+		Key:    ptrs.Ptr(u.prefix + path),
+	})
+	return err
+}
+
+// NewS3Uploader returns a new S3Uploader.
+func NewS3Uploader(
+	ctx context.Context,
+	bucket string,
+	prefix string,
+	endpointURL *string,
+) (*S3Uploader, error) {
+	prefix = strings.TrimLeft(prefix, "/")
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	sess, err := newSession(ctx, bucket, endpointURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Uploader{
+		uploader: s3manager.NewUploader(sess),
+		bucket:   bucket,
+		prefix:   prefix,
+	}, nil
+}
+
+func newSession(ctx context.Context, bucket string, endpointURL *string) (*session.Session, error) {
 	// We do not pass in credentials explicitly. Instead, we reply on
 	// the existing AWS credentials.
 	var endpointFormat *string
@@ -132,20 +199,7 @@ func NewS3Downloader(
 		awsConfig.S3ForcePathStyle = aws.Bool(true)
 	}
 
-	sess, err := session.NewSession(awsConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	return &S3Downloader{
-		aw:     aw,
-		client: s3.New(sess),
-		downloader: s3manager.NewDownloader(sess, func(d *s3manager.Downloader) {
-			d.Concurrency = 1 // Setting concurrency to 1 to use seqWriterAt
-		}),
-		bucket: bucket,
-		prefix: prefix,
-	}, nil
+	return session.NewSession(awsConfig)
 }
 
 // GetS3BucketRegion returns the region name of the specified bucket.
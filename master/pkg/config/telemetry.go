@@ -1,5 +1,10 @@
 package config
 
+import (
+	"github.com/determined-ai/determined/master/pkg/check"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
 // TelemetryConfig is the configuration for telemetry.
 type TelemetryConfig struct {
 	Enabled                  bool   `json:"enabled"`
@@ -8,4 +13,23 @@ type TelemetryConfig struct {
 	OtelExportedOtlpEndpoint string `json:"otel_endpoint"`
 	SegmentWebUIKey          string `json:"segment_webui_key"`
 	ClusterID                string `json:"cluster_id"`
+
+	// OtelTrialMetricsEnabled forwards reported trial metrics to the OpenTelemetry endpoint.
+	OtelTrialMetricsEnabled bool `json:"otel_trial_metrics_enabled"`
+	// OtelTrialMetricsQueueSize bounds the number of reports waiting to be exported; reports
+	// beyond it are dropped.
+	OtelTrialMetricsQueueSize int `json:"otel_trial_metrics_queue_size"`
+	// OtelTrialMetricsSeriesTTL is how long the series of a trial are exported after its last
+	// report, for trials whose end the master never sees.
+	OtelTrialMetricsSeriesTTL model.Duration `json:"otel_trial_metrics_series_ttl"`
+}
+
+// Validate implements the check.Validatable interface.
+func (t TelemetryConfig) Validate() []error {
+	return []error{
+		check.GreaterThanOrEqualTo(t.OtelTrialMetricsQueueSize, 0,
+			"otel_trial_metrics_queue_size must be non-negative"),
+		check.GreaterThanOrEqualTo(int64(t.OtelTrialMetricsSeriesTTL), 0,
+			"otel_trial_metrics_series_ttl must be non-negative"),
+	}
 }
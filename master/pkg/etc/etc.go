@@ -28,6 +28,9 @@ const (
 	NotebookEntrypointResource = "notebook-entrypoint.sh"
 	// NotebookIdleCheckResource is the script to check if a notebook is idle.
 	NotebookIdleCheckResource = "check_idle.py"
+	// NotebookSnapshotResource is the script to snapshot and restore the working directory of a
+	// notebook.
+	NotebookSnapshotResource = "notebook_snapshot.py"
 	// TaskCheckReadyLogsResource is the script to parse logs to check if a task is ready.
 	TaskCheckReadyLogsResource = "check_ready_logs.py"
 	// TaskShipLogsShellResource is the shell script to call the python script to ship logs.
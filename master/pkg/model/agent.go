@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
+
 	"github.com/determined-ai/determined/master/pkg/cproto"
 	"github.com/determined-ai/determined/master/pkg/device"
 	"github.com/determined-ai/determined/master/pkg/protoutils"
@@ -11,6 +13,25 @@ import (
 	"github.com/determined-ai/determined/proto/pkg/containerv1"
 )
 
+// CapacityType is the kind of cloud capacity an agent runs on.
+type CapacityType string
+
+const (
+	// CapacityTypeOnDemand is capacity that is not reclaimed by the cloud provider.
+	CapacityTypeOnDemand CapacityType = "on_demand"
+	// CapacityTypeSpot is spot or preemptible capacity, which the cloud provider may reclaim with
+	// short notice.
+	CapacityTypeSpot CapacityType = "spot"
+)
+
+// CapacityTypeOf returns the capacity type of an optional config value, empty if unset.
+func CapacityTypeOf(capacityType *string) CapacityType {
+	if capacityType == nil {
+		return ""
+	}
+	return CapacityType(*capacityType)
+}
+
 // AgentSummary summarizes the state on an agent.
 type AgentSummary struct {
 	ID             string       `json:"id"`
@@ -22,6 +43,9 @@ type AgentSummary struct {
 	Enabled        bool         `json:"enabled"`
 	Draining       bool         `json:"draining"`
 	Version        string       `json:"version"`
+	CapacityType   CapacityType `json:"capacity_type"`
+	// TerminationTime is when the cloud provider will reclaim the agent, if it has been noticed.
+	TerminationTime *time.Time `json:"termination_time"`
 }
 
 type slotStats map[string]*agentv1.DeviceStats
@@ -84,17 +108,24 @@ func (a AgentSummary) ToProto() *agentv1.Agent {
 		}
 	}
 
+	var terminationTime *timestamp.Timestamp
+	if a.TerminationTime != nil {
+		terminationTime = protoutils.ToTimestamp(*a.TerminationTime)
+	}
+
 	return &agentv1.Agent{
-		Id:             a.ID,
-		RegisteredTime: protoutils.ToTimestamp(a.RegisteredTime),
-		Slots:          slots,
-		SlotStats:      SummarizeSlots(slots),
-		Containers:     containers,
-		ResourcePools:  a.ResourcePool,
-		Addresses:      a.Addresses,
-		Enabled:        a.Enabled,
-		Draining:       a.Draining,
-		Version:        a.Version,
+		Id:              a.ID,
+		RegisteredTime:  protoutils.ToTimestamp(a.RegisteredTime),
+		Slots:           slots,
+		SlotStats:       SummarizeSlots(slots),
+		Containers:      containers,
+		ResourcePools:   a.ResourcePool,
+		Addresses:       a.Addresses,
+		Enabled:         a.Enabled,
+		Draining:        a.Draining,
+		Version:         a.Version,
+		CapacityType:    string(a.CapacityType),
+		TerminationTime: terminationTime,
 	}
 }
 
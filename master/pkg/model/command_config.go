@@ -15,6 +15,12 @@ const (
 	// NotebookIdleTypeActivity indicates that a notebook should be considered active if any kernel is
 	// running a command or any terminal is inputting or outputting data.
 	NotebookIdleTypeActivity = "activity"
+
+	// NotebookIdleActionKill indicates that an idle notebook should be killed.
+	NotebookIdleActionKill = "kill"
+	// NotebookIdleActionSuspend indicates that an idle notebook should be suspended, saving its
+	// working directory until it is resumed.
+	NotebookIdleActionSuspend = "suspend"
 )
 
 // DefaultConfig is the default configuration used by all
@@ -22,9 +28,10 @@ const (
 // does not specify any configuration options.
 func DefaultConfig(taskContainerDefaults *TaskContainerDefaultsConfig) CommandConfig {
 	out := CommandConfig{
-		Resources:        DefaultResourcesConfig(taskContainerDefaults),
-		Environment:      DefaultEnvConfig(taskContainerDefaults),
-		NotebookIdleType: NotebookIdleTypeKernelsOrTerminals,
+		Resources:          DefaultResourcesConfig(taskContainerDefaults),
+		Environment:        DefaultEnvConfig(taskContainerDefaults),
+		NotebookIdleType:   NotebookIdleTypeKernelsOrTerminals,
+		NotebookIdleAction: NotebookIdleActionKill,
 	}
 
 	if taskContainerDefaults != nil {
@@ -40,18 +47,19 @@ func DefaultConfig(taskContainerDefaults *TaskContainerDefaultsConfig) CommandCo
 // CommandConfig holds the necessary configurations to launch a command task in
 // the cluster.
 type CommandConfig struct {
-	Description      string              `json:"description"`
-	BindMounts       BindMountsConfig    `json:"bind_mounts"`
-	Environment      Environment         `json:"environment"`
-	Resources        ResourcesConfig     `json:"resources"`
-	Entrypoint       []string            `json:"entrypoint"`
-	TensorBoardArgs  []string            `json:"tensorboard_args,omitempty"`
-	IdleTimeout      *Duration           `json:"idle_timeout"`
-	NotebookIdleType string              `json:"notebook_idle_type"`
-	WorkDir          *string             `json:"work_dir"`
-	Debug            bool                `json:"debug"`
-	Pbs              expconf.PbsConfig   `json:"pbs,omitempty"`
-	Slurm            expconf.SlurmConfig `json:"slurm,omitempty"`
+	Description        string              `json:"description"`
+	BindMounts         BindMountsConfig    `json:"bind_mounts"`
+	Environment        Environment         `json:"environment"`
+	Resources          ResourcesConfig     `json:"resources"`
+	Entrypoint         []string            `json:"entrypoint"`
+	TensorBoardArgs    []string            `json:"tensorboard_args,omitempty"`
+	IdleTimeout        *Duration           `json:"idle_timeout"`
+	NotebookIdleType   string              `json:"notebook_idle_type"`
+	NotebookIdleAction string              `json:"notebook_idle_action"`
+	WorkDir            *string             `json:"work_dir"`
+	Debug              bool                `json:"debug"`
+	Pbs                expconf.PbsConfig   `json:"pbs,omitempty"`
+	Slurm              expconf.SlurmConfig `json:"slurm,omitempty"`
 }
 
 // Validate implements the check.Validatable interface.
@@ -68,6 +76,16 @@ func (c *CommandConfig) Validate() []error {
 			},
 			"invalid notebook idle type",
 		),
+		// Configs from before idle actions existed have none, and kill idle notebooks.
+		check.Contains(
+			c.NotebookIdleAction,
+			[]interface{}{
+				"",
+				NotebookIdleActionKill,
+				NotebookIdleActionSuspend,
+			},
+			"invalid notebook idle action",
+		),
 		check.True(c.Resources.IsSingleNode == nil, "resources.is_single_node cannot be set for NTSCs"),
 	}
 }
@@ -8,12 +8,13 @@ import (
 
 func TestConfigValidate(t *testing.T) {
 	type fields struct {
-		Description      string
-		BindMounts       []BindMount
-		Environment      Environment
-		Resources        ResourcesConfig
-		Entrypoint       []string
-		NotebookIdleType string
+		Description        string
+		BindMounts         []BindMount
+		Environment        Environment
+		Resources          ResourcesConfig
+		Entrypoint         []string
+		NotebookIdleType   string
+		NotebookIdleAction string
 	}
 	type testCase struct {
 		name    string
@@ -58,16 +59,42 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "suspend-notebook-idle-action",
+			fields: fields{
+				Resources:   resources,
+				Environment: environment,
+				Entrypoint: []string{
+					"test",
+				},
+				NotebookIdleType:   NotebookIdleTypeActivity,
+				NotebookIdleAction: NotebookIdleActionSuspend,
+			},
+		},
+		{
+			name: "invalid-notebook-idle-action",
+			fields: fields{
+				Resources:   resources,
+				Environment: environment,
+				Entrypoint: []string{
+					"test",
+				},
+				NotebookIdleType:   NotebookIdleTypeActivity,
+				NotebookIdleAction: "pause",
+			},
+			wantErr: true,
+		},
 	}
 	runTestCase := func(t *testing.T, tc testCase) {
 		t.Run(tc.name, func(t *testing.T) {
 			c := &CommandConfig{
-				Description:      tc.fields.Description,
-				BindMounts:       tc.fields.BindMounts,
-				Environment:      tc.fields.Environment,
-				Resources:        tc.fields.Resources,
-				Entrypoint:       tc.fields.Entrypoint,
-				NotebookIdleType: tc.fields.NotebookIdleType,
+				Description:        tc.fields.Description,
+				BindMounts:         tc.fields.BindMounts,
+				Environment:        tc.fields.Environment,
+				Resources:          tc.fields.Resources,
+				Entrypoint:         tc.fields.Entrypoint,
+				NotebookIdleType:   tc.fields.NotebookIdleType,
+				NotebookIdleAction: tc.fields.NotebookIdleAction,
 			}
 			if err := check.Validate(c); (err != nil) != tc.wantErr {
 				t.Errorf("config.Validate() error = %v, wantErr %v", err, tc.wantErr)
@@ -92,6 +92,8 @@ type ResourcesConfig struct {
 	ResourcePool   string       `json:"resource_pool"`
 	Priority       *int         `json:"priority,omitempty"`
 	IsSingleNode   *bool        `json:"is_single_node"`
+	// CapacityType, if set, restricts the task to agents on that kind of capacity.
+	CapacityType CapacityType `json:"capacity_type,omitempty"`
 
 	Devices DevicesConfig `json:"devices"`
 }
@@ -163,6 +165,11 @@ func (r ResourcesConfig) Validate() []error {
 	errs := []error{
 		check.GreaterThanOrEqualTo(r.Slots, 0, "slots must be >= 0"),
 		check.GreaterThan(r.Weight, float64(0), "weight must be > 0"),
+		check.In(
+			string(r.CapacityType),
+			[]string{"", string(CapacityTypeOnDemand), string(CapacityTypeSpot)},
+			"invalid capacity type",
+		),
 	}
 	errs = append(errs, ValidatePrioritySetting(r.Priority)...)
 	return errs
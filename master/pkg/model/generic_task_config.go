@@ -48,13 +48,27 @@ type GenericTaskConfig struct {
 	Pbs               expconf.PbsConfig   `json:"pbs,omitempty"`
 	Slurm             expconf.SlurmConfig `json:"slurm,omitempty"`
 	PreemptionTimeout int                 `json:"preemption_timeout,omitempty"`
+
+	Watchdog *expconf.WatchdogConfig `json:"watchdog,omitempty"`
 }
 
 // Validate implements the check.Validatable interface.
 func (c *GenericTaskConfig) Validate() []error {
-	return []error{
+	errs := []error{
 		check.GreaterThanOrEqualTo(c.Resources.Slots(), 0,
 			"resources.slots must be >= 0"),
 		check.GreaterThan(len(c.Entrypoint), 0, "entrypoint must be non-empty"),
 	}
+	if c.Watchdog != nil {
+		errs = append(errs, check.GreaterThan(c.Watchdog.RawTimeout, 0,
+			"watchdog.timeout must be > 0"))
+		if c.Watchdog.RawAction != nil {
+			errs = append(errs, check.In(string(*c.Watchdog.RawAction), []string{
+				string(expconf.WatchdogActionNotify),
+				string(expconf.WatchdogActionRestart),
+				string(expconf.WatchdogActionExcludeNode),
+			}, "watchdog.action must be one of notify, restart or exclude_node"))
+		}
+	}
+	return errs
 }
@@ -15,8 +15,21 @@ type HealthCheck struct {
 	Status           HealthStatus            `json:"status"`
 	Database         HealthStatus            `json:"database"`
 	ResourceManagers []ResourceManagerHealth `json:"resource_managers"`
+	// Role and Leader are only set when the master is highly available.
+	Role   MasterRole `json:"role,omitempty"`
+	Leader string     `json:"leader,omitempty"`
 }
 
+// MasterRole is whether a highly available master is active.
+type MasterRole string
+
+const (
+	// LeaderRole is the role of the master serving the cluster.
+	LeaderRole MasterRole = "leader"
+	// StandbyRole is the role of the masters waiting to take over from the leader.
+	StandbyRole MasterRole = "standby"
+)
+
 // ResourceManagerHealth is a pair of resource manager name and health status.
 type ResourceManagerHealth struct {
 	ClusterName string       `json:"cluster_name"`
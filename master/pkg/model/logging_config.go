@@ -6,7 +6,9 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
+	"net/url"
 	"os"
+	"regexp"
 	"time"
 
 	"github.com/pkg/errors"
@@ -19,6 +21,7 @@ import (
 type LoggingConfig struct {
 	DefaultLoggingConfig *DefaultLoggingConfig `union:"type,default" json:"-"`
 	ElasticLoggingConfig *ElasticLoggingConfig `union:"type,elastic" json:"-"`
+	LokiLoggingConfig    *LokiLoggingConfig    `union:"type,loki" json:"-"`
 }
 
 // Resolve resolves the parts of the TaskContainerDefaultsConfig that must be evaluated on
@@ -30,6 +33,11 @@ func (c LoggingConfig) Resolve() error {
 			return err
 		}
 	}
+	if c.LokiLoggingConfig != nil {
+		if err := c.LokiLoggingConfig.Resolve(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -84,6 +92,64 @@ func (o *ElasticSecurityConfig) Resolve() error {
 	return o.TLS.Resolve()
 }
 
+// lokiLabelName matches valid Loki label names.
+var lokiLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// LokiLoggingConfig configures logging for tasks using Grafana Loki.
+type LokiLoggingConfig struct {
+	// URL is the base URL of Loki, such as http://loki:3100.
+	URL string `json:"url"`
+	// TenantID is sent as the X-Scope-OrgID header to multi-tenant Loki deployments.
+	TenantID string `json:"tenant_id"`
+	// Labels are added to the stream labels of every log.
+	Labels map[string]string `json:"labels"`
+	// Lookback is how far back reads search for the logs of a task.
+	Lookback Duration           `json:"lookback"`
+	Security LokiSecurityConfig `json:"security"`
+}
+
+// Validate implements the check.Validatable interface.
+func (o LokiLoggingConfig) Validate() []error {
+	var errs []error
+	if u, err := url.Parse(o.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, errors.Errorf("loki url must be an http or https URL, got %q", o.URL))
+	}
+	for name := range o.Labels {
+		if !lokiLabelName.MatchString(name) {
+			errs = append(errs, errors.Errorf("invalid loki label name %q", name))
+		}
+	}
+	if o.Lookback < 0 {
+		errs = append(errs, errors.New("loki lookback must not be negative"))
+	}
+	return errs
+}
+
+// Resolve resolves the configuration.
+func (o *LokiLoggingConfig) Resolve() error {
+	return o.Security.TLS.Resolve()
+}
+
+// LokiSecurityConfig configures security-related options for the Loki logging backend.
+type LokiSecurityConfig struct {
+	Username    *string         `json:"username"`
+	Password    *string         `json:"password"`
+	BearerToken *string         `json:"bearer_token"`
+	TLS         TLSClientConfig `json:"tls"`
+}
+
+// Validate implements the check.Validatable interface.
+func (o LokiSecurityConfig) Validate() []error {
+	var errs []error
+	if (o.Username != nil) != (o.Password != nil) {
+		errs = append(errs, errors.New("username and password must be specified together"))
+	}
+	if o.Username != nil && o.BearerToken != nil {
+		errs = append(errs, errors.New("only one of username and bearer_token may be specified"))
+	}
+	return errs
+}
+
 // LogRetentionPolicy configures the default log retention policy for trials and tasks.
 type LogRetentionPolicy struct {
 	// Days is the default number of days to retain logs for.
@@ -155,6 +221,28 @@ func MakeTLSConfig(cert *tls.Certificate) (TLSClientConfig, error) {
 	}, nil
 }
 
+// TLSConfig returns the tls.Config to connect with, or nil if TLS is not enabled.
+func (t TLSClientConfig) TLSConfig() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	var pool *x509.CertPool
+	if t.CertBytes != nil {
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(t.CertBytes) {
+			return nil, errors.New("certificate file contains no certificates")
+		}
+	}
+
+	return &tls.Config{
+		InsecureSkipVerify: t.SkipVerify, //nolint:gosec
+		MinVersion:         tls.VersionTLS12,
+		RootCAs:            pool,
+		ServerName:         t.CertificateName,
+	}, nil
+}
+
 // Validate implements the check.Validatable interface.
 func (t TLSClientConfig) Validate() []error {
 	var errs []error
@@ -5,9 +5,11 @@ import (
 	"log"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
@@ -16,6 +18,9 @@ import (
 // maintain a single tracer provider.
 var tracer *sdktrace.TracerProvider
 
+// maintain a single meter provider.
+var meter *sdkmetric.MeterProvider
+
 // ConfigureOtel initiates a new tracer and sets it as the default for otel.
 func ConfigureOtel(endpoint string, serviceName string) *sdktrace.TracerProvider {
 	// avoid repeatedly re-creating the tracer.
@@ -49,6 +54,32 @@ func ConfigureOtel(endpoint string, serviceName string) *sdktrace.TracerProvider
 	return tracer
 }
 
+// ConfigureOtelMetrics initiates a new meter provider that periodically exports metrics over OTLP
+// and sets it as the default for otel.
+func ConfigureOtelMetrics(endpoint string, serviceName string) *sdkmetric.MeterProvider {
+	// avoid repeatedly re-creating the meter provider.
+	if meter != nil {
+		return meter
+	}
+
+	exp, err := otlpmetricgrpc.New(context.Background(),
+		otlpmetricgrpc.WithEndpoint(endpoint),
+		otlpmetricgrpc.WithInsecure(),
+	)
+	if err != nil {
+		log.Fatalf("failed to initialize metrics exporter: %v", err)
+	}
+
+	// The periodic reader batches everything recorded since the last export into one request.
+	meter = sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp)),
+		sdkmetric.WithResource(newResource(serviceName)),
+	)
+	otel.SetMeterProvider(meter)
+
+	return meter
+}
+
 func newExporter(ctx context.Context, endpoint string) (*otlptrace.Exporter, error) {
 	opts := []otlptracegrpc.Option{
 		otlptracegrpc.WithEndpoint(endpoint),
@@ -60,14 +91,16 @@ func newExporter(ctx context.Context, endpoint string) (*otlptrace.Exporter, err
 }
 
 func newTraceProvider(exp *otlptrace.Exporter, serviceName string) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(newResource(serviceName)),
+	)
+}
+
+func newResource(serviceName string) *resource.Resource {
 	// The service.name attribute is required.
-	resource := resource.NewWithAttributes(
+	return resource.NewWithAttributes(
 		semconv.SchemaURL,
 		semconv.ServiceNameKey.String(serviceName),
 	)
-
-	return sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp),
-		sdktrace.WithResource(resource),
-	)
 }
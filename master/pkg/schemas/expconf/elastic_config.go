@@ -0,0 +1,21 @@
+package expconf
+
+import "time"
+
+// ElasticConfigV0 lets a trial run on any number of slots between MinSlots and MaxSlots. The
+// master grows a trial when its resource pool has idle slots, and shrinks it back when other jobs
+// are waiting, by checkpointing it and restarting it at the new size.
+//
+//go:generate ../gen.sh
+type ElasticConfigV0 struct {
+	RawMinSlots int `json:"min_slots"`
+	RawMaxSlots int `json:"max_slots"`
+	// Cooldown is how long a trial runs after it starts or is resized before it may be resized
+	// again, in seconds.
+	RawCooldown *int `json:"cooldown"`
+}
+
+// CooldownDuration returns the cooldown as a duration.
+func (e ElasticConfigV0) CooldownDuration() time.Duration {
+	return time.Duration(e.Cooldown()) * time.Second
+}
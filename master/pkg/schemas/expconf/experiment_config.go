@@ -50,6 +50,7 @@ type ExperimentConfigV0 struct {
 	RawSlurmConfig              *SlurmConfigV0              `json:"slurm,omitempty"`
 	RawPbsConfig                *PbsConfigV0                `json:"pbs,omitempty"`
 	RawPreemptionTimeout        *int                        `json:"preemption_timeout"`
+	RawWatchdog                 *WatchdogConfigV0           `json:"watchdog,omitempty"`
 }
 
 // Value implements the driver.Valuer interface.
@@ -214,6 +215,10 @@ type ResourcesConfigV0 struct {
 	RawIsSingleNode   *bool    `json:"is_single_node"`
 
 	RawDevices DevicesConfigV0 `json:"devices"`
+
+	RawElastic *ElasticConfigV0 `json:"elastic,omitempty"`
+
+	RawCapacityType *string `json:"capacity_type,omitempty"`
 }
 
 // OptimizationsConfigV0 is a legacy config value.
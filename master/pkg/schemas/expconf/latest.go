@@ -50,6 +50,8 @@ type (
 	PachydermPachdConfig      = PachydermPachdConfigV0
 	PachydermProxyConfig      = PachydermProxyConfigV0
 	PachydermDatasetConfig    = PachydermDatasetConfigV0
+	WatchdogConfig            = WatchdogConfigV0
+	ElasticConfig             = ElasticConfigV0
 )
 
 // These are EOL searchers, not to be used in new experiments.
@@ -167,6 +167,10 @@ func objectForURL(url string) interface{} {
 		return &TestUnionV0{}
 	case "http://determined.ai/schemas/expconf/v0/log-policies.json":
 		return &LogPoliciesConfigV0{}
+	case "http://determined.ai/schemas/expconf/v0/watchdog.json":
+		return &WatchdogConfigV0{}
+	case "http://determined.ai/schemas/expconf/v0/elastic.json":
+		return &ElasticConfigV0{}
 	default:
 		panic(fmt.Sprintf("No object to match %v, maybe you need to add one?", url))
 	}
@@ -0,0 +1,32 @@
+package expconf
+
+import "time"
+
+// WatchdogAction is what a watchdog does when a task makes no progress.
+type WatchdogAction string
+
+// WatchdogAction values.
+const (
+	// WatchdogActionNotify logs that the task is stalled and leaves it running.
+	WatchdogActionNotify WatchdogAction = "notify"
+	// WatchdogActionRestart kills the task, and restarts it if it can be restarted.
+	WatchdogActionRestart WatchdogAction = "restart"
+	// WatchdogActionExcludeNode kills the task like WatchdogActionRestart, and never schedules it
+	// on the same nodes again.
+	WatchdogActionExcludeNode WatchdogAction = "exclude_node"
+)
+
+// WatchdogConfigV0 configures how a task that stops making progress is handled. Metrics,
+// progress reports, checkpoints and log lines count as progress.
+//
+//go:generate ../gen.sh
+type WatchdogConfigV0 struct {
+	// Timeout is how long the task may go without progress, in seconds.
+	RawTimeout int             `json:"timeout"`
+	RawAction  *WatchdogAction `json:"action"`
+}
+
+// TimeoutDuration returns the timeout as a duration.
+func (w WatchdogConfigV0) TimeoutDuration() time.Duration {
+	return time.Duration(w.RawTimeout) * time.Second
+}
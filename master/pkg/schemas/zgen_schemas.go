@@ -447,6 +447,41 @@ var (
         }
     }
 }
+`)
+	textElasticConfigV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/elastic.json",
+    "title": "ElasticConfig",
+    "additionalProperties": false,
+    "required": [
+        "min_slots",
+        "max_slots"
+    ],
+    "type": "object",
+    "properties": {
+        "min_slots": {
+            "type": "integer",
+            "minimum": 1
+        },
+        "max_slots": {
+            "type": "integer",
+            "minimum": 1
+        },
+        "cooldown": {
+            "type": [
+                "integer",
+                "null"
+            ],
+            "minimum": 0,
+            "default": 600
+        }
+    },
+    "compareProperties": {
+        "type": "a<=b",
+        "a": "min_slots",
+        "b": "max_slots"
+    }
+}
 `)
 	textEnvironmentImageMapV0 = []byte(`{
     "$schema": "http://json-schema.org/draft-07/schema#",
@@ -1003,6 +1038,14 @@ var (
             "default": null,
             "optionalRef": "http://determined.ai/schemas/expconf/v0/tensorboard-storage.json"
         },
+        "watchdog": {
+            "type": [
+                "object",
+                "null"
+            ],
+            "default": null,
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/watchdog.json"
+        },
         "workspace": {
             "type": [
                 "string",
@@ -2025,6 +2068,14 @@ var (
             ],
             "default": null
         },
+        "capacity_type": {
+            "enum": [
+                null,
+                "on_demand",
+                "spot"
+            ],
+            "default": null
+        },
         "devices": {
             "type": [
                 "array",
@@ -2033,6 +2084,14 @@ var (
             "default": [],
             "optionalRef": "http://determined.ai/schemas/expconf/v0/devices.json"
         },
+        "elastic": {
+            "type": [
+                "object",
+                "null"
+            ],
+            "default": null,
+            "optionalRef": "http://determined.ai/schemas/expconf/v0/elastic.json"
+        },
         "is_single_node": {
             "type": [
                 "boolean",
@@ -3414,6 +3473,32 @@ var (
         ]
     }
 }
+`)
+	textWatchdogConfigV0 = []byte(`{
+    "$schema": "http://json-schema.org/draft-07/schema#",
+    "$id": "http://determined.ai/schemas/expconf/v0/watchdog.json",
+    "title": "WatchdogConfig",
+    "additionalProperties": false,
+    "required": [
+        "timeout"
+    ],
+    "type": "object",
+    "properties": {
+        "timeout": {
+            "type": "integer",
+            "minimum": 1
+        },
+        "action": {
+            "enum": [
+                null,
+                "notify",
+                "restart",
+                "exclude_node"
+            ],
+            "default": "notify"
+        }
+    }
+}
 `)
 	textWebhooksConfigV0 = []byte(`{
     "$schema": "http://json-schema.org/draft-07/schema#",
@@ -3471,6 +3556,8 @@ var (
 
 	schemaDirectoryConfigV0 interface{}
 
+	schemaElasticConfigV0 interface{}
+
 	schemaEnvironmentImageMapV0 interface{}
 
 	schemaEnvironmentImageV0 interface{}
@@ -3583,6 +3670,8 @@ var (
 
 	schemaTestUnionV0 interface{}
 
+	schemaWatchdogConfigV0 interface{}
+
 	schemaWebhooksConfigV0 interface{}
 
 	cacheLock sync.RWMutex
@@ -3772,6 +3861,26 @@ func ParsedDirectoryConfigV0() interface{} {
 	return schemaDirectoryConfigV0
 }
 
+func ParsedElasticConfigV0() interface{} {
+	cacheLock.RLock()
+	if schemaElasticConfigV0 != nil {
+		cacheLock.RUnlock()
+		return schemaElasticConfigV0
+	}
+	cacheLock.RUnlock()
+
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if schemaElasticConfigV0 != nil {
+		return schemaElasticConfigV0
+	}
+	err := json.Unmarshal(textElasticConfigV0, &schemaElasticConfigV0)
+	if err != nil {
+		panic("invalid embedded json for ElasticConfigV0")
+	}
+	return schemaElasticConfigV0
+}
+
 func ParsedEnvironmentImageMapV0() interface{} {
 	cacheLock.RLock()
 	if schemaEnvironmentImageMapV0 != nil {
@@ -4892,6 +5001,26 @@ func ParsedTestUnionV0() interface{} {
 	return schemaTestUnionV0
 }
 
+func ParsedWatchdogConfigV0() interface{} {
+	cacheLock.RLock()
+	if schemaWatchdogConfigV0 != nil {
+		cacheLock.RUnlock()
+		return schemaWatchdogConfigV0
+	}
+	cacheLock.RUnlock()
+
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	if schemaWatchdogConfigV0 != nil {
+		return schemaWatchdogConfigV0
+	}
+	err := json.Unmarshal(textWatchdogConfigV0, &schemaWatchdogConfigV0)
+	if err != nil {
+		panic("invalid embedded json for WatchdogConfigV0")
+	}
+	return schemaWatchdogConfigV0
+}
+
 func ParsedWebhooksConfigV0() interface{} {
 	cacheLock.RLock()
 	if schemaWebhooksConfigV0 != nil {
@@ -4945,6 +5074,8 @@ func schemaBytesMap() map[string][]byte {
 	cachedSchemaBytesMap[url] = textDevicesConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/directory.json"
 	cachedSchemaBytesMap[url] = textDirectoryConfigV0
+	url = "http://determined.ai/schemas/expconf/v0/elastic.json"
+	cachedSchemaBytesMap[url] = textElasticConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/environment-image-map.json"
 	cachedSchemaBytesMap[url] = textEnvironmentImageMapV0
 	url = "http://determined.ai/schemas/expconf/v0/environment-image.json"
@@ -5057,6 +5188,8 @@ func schemaBytesMap() map[string][]byte {
 	cachedSchemaBytesMap[url] = textTestUnionBV0
 	url = "http://determined.ai/schemas/expconf/v0/test-union.json"
 	cachedSchemaBytesMap[url] = textTestUnionV0
+	url = "http://determined.ai/schemas/expconf/v0/watchdog.json"
+	cachedSchemaBytesMap[url] = textWatchdogConfigV0
 	url = "http://determined.ai/schemas/expconf/v0/webhooks.json"
 	cachedSchemaBytesMap[url] = textWebhooksConfigV0
 	return cachedSchemaBytesMap
@@ -8,6 +8,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
 
 	"github.com/pkg/errors"
 	sshlib "golang.org/x/crypto/ssh"
@@ -117,3 +118,15 @@ func generateED25519Key() (PrivateAndPublicKeys, error) {
 		PublicKey:  sshlib.MarshalAuthorizedKey(publicKey),
 	}, nil
 }
+
+// TaskPrincipal returns the SSH certificate principal that grants access to the SSH server of the
+// given task.
+func TaskPrincipal(taskID string) string {
+	return "det-task-" + taskID
+}
+
+// CertificateAuthorityAuthorizedKey returns an authorized_keys entry that accepts certificates
+// signed by the given certificate authority for the given principal.
+func CertificateAuthorityAuthorizedKey(caPublicKey []byte, principal string) []byte {
+	return append([]byte(fmt.Sprintf("cert-authority,principals=%q ", principal)), caPublicKey...)
+}
@@ -41,6 +41,20 @@ func ValidateSlurm(slurmOptions []string) []error {
 	errors := validateWlmOptions(wlmSlurm, slurmOptions, forbiddenArgs)
 
 	errors = disallowGresGpuConfiguration(slurmOptions, errors)
+	errors = disallowLineBreaks(slurmOptions, errors)
+	return errors
+}
+
+// disallowLineBreaks adds a validation error for each option containing a line break, which
+// would end the #SBATCH directive of a batch script and inject the rest into the script.
+func disallowLineBreaks(options []string, errors []error) []error {
+	for _, option := range options {
+		err := check.TrueSilent(!strings.ContainsAny(option, "\r\n"),
+			"slurm option %q may not contain line breaks", option)
+		if err != nil {
+			errors = append(errors, err)
+		}
+	}
 	return errors
 }
 
@@ -60,6 +60,12 @@ func TestValidateSlurmOptions(t *testing.T) {
 	testEnvironmentSlurm(t, []string{"--gres=,"})
 	testEnvironmentSlurm(t, []string{"--gres"})
 
+	// Line breaks would inject commands into the batch script
+	testEnvironmentSlurm(t, []string{"--comment=x\nrm -rf ~"},
+		`slurm option "--comment=x\nrm -rf ~" may not contain line breaks`)
+	testEnvironmentSlurm(t, []string{"--nice=7", "--comment=x\r"},
+		"may not contain line breaks")
+
 	var slurmArgs []string
 	testEnvironmentSlurm(t, slurmArgs)
 }
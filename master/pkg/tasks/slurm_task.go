@@ -0,0 +1,272 @@
+package tasks
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/determined-ai/determined/master/pkg/cproto"
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/model"
+)
+
+// SlurmJobRootfs is the directory, relative to the job directory, that the archives of a task
+// submitted by the native Slurm resource manager are unpacked into.
+const SlurmJobRootfs = "rootfs"
+
+// Line length of the base64-encoded archives embedded in a batch script.
+const slurmArchiveLineLength = 76
+
+// SlurmBatchScriptOptions describes how a task is launched as a Slurm batch job by the native
+// Slurm resource manager.
+type SlurmBatchScriptOptions struct {
+	AllocationID string
+	// JobDir is a directory shared by the master and the compute nodes, holding the output of
+	// the job and its unpacked archives.
+	JobDir string
+
+	TLSEnabled      bool
+	MasterHost      string
+	MasterPort      int
+	CertificateName string
+
+	NumSlots         int
+	SlotType         device.Type
+	Partition        string
+	TresSupported    bool
+	GresSupported    bool
+	ContainerRunType string
+	JobProjectSource *string
+	ExcludedNodes    []string
+}
+
+// ToSlurmBatchScript renders the task as a batch script to be submitted with sbatch. The script
+// unpacks the task archives into the job directory and starts one container per node with srun,
+// wrapping the entrypoint with the same dispatcher-wrapper.sh used by the HPC launcher.
+func (t *TaskSpec) ToSlurmBatchScript(
+	syslog *logrus.Entry,
+	opts SlurmBatchScriptOptions,
+) (string, error) {
+	syslog = syslog.WithField("allocation-id", opts.AllocationID)
+
+	image := t.Environment.Image().For(opts.SlotType)
+	if len(image) == 0 {
+		return "", fmt.Errorf("no image is configured for slot_type: %s", opts.SlotType)
+	}
+	if !strings.Contains(image, "://") && !strings.HasPrefix(image, "/") {
+		image = "docker://" + image
+	}
+
+	var sbatchArgs []string
+	if len(opts.ExcludedNodes) > 0 {
+		sbatchArgs = append(sbatchArgs, "--exclude="+strings.Join(opts.ExcludedNodes, ","))
+	}
+	sbatchArgs = append(sbatchArgs, t.SlurmConfig.SbatchArgs()...)
+	if errList := ValidateSlurm(sbatchArgs); len(errList) > 0 {
+		syslog.WithError(errList[0]).Error("Forbidden slurm option specified")
+		return "", errList[0]
+	}
+	_, slurmProj := t.jobAndProjectLabels(opts.JobProjectSource)
+	sbatchArgs = append(sbatchArgs, slurmProj...)
+
+	var binds []string
+	tmpMount := false
+	for _, m := range t.Mounts {
+		if strings.HasPrefix(m.Target, RunDir) {
+			return "", fmt.Errorf("bind_mounts.container_path: %s not supported. "+
+				"Slurm jobs cannot mount under %s", m.Target, RunDir)
+		}
+		bind := m.Source + ":" + m.Target
+		if m.ReadOnly {
+			bind += ":ro"
+		}
+		binds = append(binds, bind)
+		if m.Target == tmp {
+			tmpMount = true
+		}
+	}
+
+	localTmp := "/"
+	allArchives := *getAllArchives(t)
+	archives := append([]cproto.RunArchive{dispatcherArchive(syslog, opts.AllocationID,
+		t.AgentUserGroup, generateRunDeterminedLinkNames(allArchives), localTmp)},
+		allArchives...)
+	encoded, err := encodeArchiveParameters(syslog, opts.AllocationID, archives[0], archives[1:])
+	if err != nil {
+		return "", err
+	}
+	rootfs := path.Join(opts.JobDir, SlurmJobRootfs)
+	for _, dir := range slurmArchiveBindDirs(archives) {
+		binds = append(binds, path.Join(rootfs, dir)+":"+dir)
+	}
+
+	masterScheme := "http"
+	if opts.TLSEnabled {
+		masterScheme = "https"
+	}
+	envVars, err := getEnvVarsForLauncherManifest(
+		syslog, opts.AllocationID,
+		t, masterScheme, opts.MasterHost, opts.MasterPort, opts.CertificateName, tmpMount,
+		opts.SlotType, singularity, localTmp, t.slotsPerNode(false))
+	if err != nil {
+		return "", err
+	}
+
+	// Use the specified workDir if it is user-specified, otherwise dispatcher-wrapper.sh changes
+	// into DET_WORKDIR once it has set up /run/determined.
+	workDir := t.WorkDir
+	if workDir == DefaultWorkDir {
+		workDir = varTmp
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/bash\n")
+	for _, arg := range t.slurmResourceArgs(opts) {
+		fmt.Fprintf(&b, "#SBATCH %s\n", arg)
+	}
+	for _, arg := range removeDuplicates(sbatchArgs) {
+		fmt.Fprintf(&b, "#SBATCH %s\n", arg)
+	}
+	b.WriteString("\nset -e\n\n")
+
+	envKeys := make([]string, 0, len(envVars))
+	for k := range envVars {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		fmt.Fprintf(&b, "export %s=%s\n", k, shellQuote(envVars[k]))
+	}
+
+	fmt.Fprintf(&b, "\nmkdir -p %s\n", shellQuote(rootfs))
+	for i, data := range encoded["Archives"] {
+		fmt.Fprintf(&b, "base64 -d <<'DET_ARCHIVE_%d' | tar -xzf - -C %s\n", i, shellQuote(rootfs))
+		for len(data) > slurmArchiveLineLength {
+			b.WriteString(data[:slurmArchiveLineLength] + "\n")
+			data = data[slurmArchiveLineLength:]
+		}
+		fmt.Fprintf(&b, "%s\nDET_ARCHIVE_%d\n", data, i)
+	}
+
+	runtime := []string{"srun", "--export=ALL", opts.ContainerRunType, "exec", "--writable-tmpfs"}
+	switch opts.SlotType {
+	case device.CUDA:
+		runtime = append(runtime, "--nv")
+	case device.ROCM:
+		runtime = append(runtime, "--rocm")
+	}
+	runtime = append(runtime, "--pwd", workDir)
+	for _, bind := range binds {
+		runtime = append(runtime, "--bind", bind)
+	}
+	runtime = append(runtime, image, determinedLocalFs+"/"+dispatcherEntrypointScriptResource)
+	runtime = append(runtime, t.LogShipperWrappedEntrypoint()...)
+	for i := range runtime {
+		runtime[i] = shellQuote(runtime[i])
+	}
+	fmt.Fprintf(&b, "\n%s\n", strings.Join(runtime, " "))
+
+	return b.String(), nil
+}
+
+// slurmResourceArgs returns the sbatch options requesting the nodes and slots of the job,
+// following the same rules as the resource requirements sent to the HPC launcher.
+func (t *TaskSpec) slurmResourceArgs(opts SlurmBatchScriptOptions) []string {
+	slotsPerNode := t.slotsPerNode(false)
+	haveSlotsPerNode := slotsPerNode != unspecifiedSlotsPerNode
+
+	numNodes := opts.NumSlots
+	effectiveSlotsPerNode := 1
+	if haveSlotsPerNode {
+		numNodes = (opts.NumSlots + slotsPerNode - 1) / slotsPerNode
+		effectiveSlotsPerNode = slotsPerNode
+	}
+	// Commands, Shells, and Notebooks must run on a single node.
+	singleNode := false
+	switch t.TaskType {
+	case model.TaskTypeCommand, model.TaskTypeShell, model.TaskTypeNotebook:
+		singleNode = true
+		numNodes = 1
+	}
+
+	gpus := func(n int) string {
+		if gpuType := t.SlurmConfig.GpuType(); gpuType != nil && *gpuType != "" {
+			return *gpuType + ":" + strconv.Itoa(n)
+		}
+		return strconv.Itoa(n)
+	}
+
+	args := []string{
+		"--job-name=" + ManifestName + "-" + getPayloadName(t),
+		"--output=" + path.Join(opts.JobDir, "slurm-%j.out"),
+		"--chdir=" + opts.JobDir,
+		"--ntasks-per-node=1",
+	}
+	if opts.Partition != "" {
+		args = append(args, "--partition="+opts.Partition)
+	}
+	switch {
+	case opts.SlotType == device.CPU:
+		// Checkpoint GC tasks request zero slots.
+		if opts.NumSlots == 0 {
+			numNodes = 1
+			effectiveSlotsPerNode = 1
+		}
+		args = append(args, "--nodes="+strconv.Itoa(numNodes),
+			"--cpus-per-task="+strconv.Itoa(effectiveSlotsPerNode))
+	case opts.GresSupported && opts.TresSupported:
+		if haveSlotsPerNode {
+			args = append(args, "--nodes="+strconv.Itoa(numNodes),
+				"--gpus-per-node="+gpus(effectiveSlotsPerNode))
+		} else {
+			args = append(args, "--gpus="+gpus(opts.NumSlots))
+			if singleNode {
+				args = append(args, "--nodes=1")
+			}
+		}
+	case opts.GresSupported:
+		args = append(args, "--nodes="+strconv.Itoa(numNodes),
+			"--gres=gpu:"+gpus(effectiveSlotsPerNode))
+	default:
+		// GPUs requested, but neither TRES nor GRES supported.
+		args = append(args, "--nodes="+strconv.Itoa(numNodes))
+	}
+	return args
+}
+
+// slurmArchiveBindDirs returns the container directories the unpacked archives are bound to:
+// /determined_local_fs, plus the two-level directories (such as /run/determined and
+// /opt/determined) that hold the remaining archive content.
+func slurmArchiveBindDirs(archives []cproto.RunArchive) []string {
+	dirs := map[string]bool{}
+	for idx, a := range archives {
+		prefix := ""
+		if idx != 0 && makeLocalVolume(a) {
+			prefix = determinedLocalFs
+		}
+		for _, item := range a.Archive {
+			parts := strings.Split(strings.Trim(path.Join(prefix, a.Path, item.Path), "/"), "/")
+			switch {
+			case "/"+parts[0] == determinedLocalFs:
+				dirs[determinedLocalFs] = true
+			case len(parts) >= 2:
+				dirs["/"+parts[0]+"/"+parts[1]] = true
+			}
+		}
+	}
+	var result []string
+	for dir := range dirs {
+		result = append(result, dir)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// shellQuote quotes s so that it is passed verbatim as a single word by bash.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,201 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/determined-ai/determined/master/pkg/device"
+	"github.com/determined-ai/determined/master/pkg/etc"
+	"github.com/determined-ai/determined/master/pkg/model"
+	"github.com/determined-ai/determined/master/pkg/ptrs"
+	"github.com/determined-ai/determined/master/pkg/schemas"
+	"github.com/determined-ai/determined/master/pkg/schemas/expconf"
+)
+
+func slurmTestTaskSpec(sbatchArgs []string, mounts []mount.Mount) *TaskSpec {
+	image := "determinedai/environments:cuda"
+	return &TaskSpec{
+		AgentUserGroup: aug,
+		WorkDir:        DefaultWorkDir,
+		Environment: expconf.EnvironmentConfigV0{
+			RawImage: &expconf.EnvironmentImageMapV0{
+				RawCPU:  &image,
+				RawCUDA: &image,
+				RawROCM: &image,
+			},
+			RawEnvironmentVariables: &expconf.EnvironmentVariablesMap{},
+			RawProxyPorts:           &expconf.ProxyPortsConfigV0{},
+			RawPodSpec:              &expconf.PodSpec{},
+		},
+		SlurmConfig:     expconf.SlurmConfig{RawSbatchArgs: sbatchArgs},
+		Mounts:          mounts,
+		ResourcesConfig: schemas.WithDefaults(expconf.ResourcesConfig{}),
+	}
+}
+
+func TestTaskSpec_ToSlurmBatchScript(t *testing.T) {
+	syslog := logrus.WithField("component", "slurm_task_test")
+	require.NoError(t, etc.SetRootPath("../../static/srv/"))
+
+	opts := SlurmBatchScriptOptions{
+		AllocationID:     "123456790",
+		JobDir:           "/shared/det/123456790",
+		TLSEnabled:       true,
+		MasterHost:       "masterHost",
+		MasterPort:       8888,
+		CertificateName:  "certName",
+		NumSlots:         4,
+		SlotType:         device.CUDA,
+		Partition:        "gpus",
+		TresSupported:    true,
+		GresSupported:    true,
+		ContainerRunType: "apptainer",
+		ExcludedNodes:    []string{"node1", "node2"},
+	}
+	ts := slurmTestTaskSpec([]string{"--time=01:00:00"}, []mount.Mount{
+		{Source: "/data", Target: "/mnt/data", ReadOnly: true},
+	})
+
+	script, err := ts.ToSlurmBatchScript(syslog, opts)
+	require.NoError(t, err)
+	lines := strings.Split(script, "\n")
+	require.Equal(t, "#!/bin/bash", lines[0])
+	for _, want := range []string{
+		"#SBATCH --output=/shared/det/123456790/slurm-%j.out",
+		"#SBATCH --chdir=/shared/det/123456790",
+		"#SBATCH --partition=gpus",
+		"#SBATCH --gpus=4",
+		"#SBATCH --exclude=node1,node2",
+		"#SBATCH --time=01:00:00",
+		"export DET_MASTER='https://masterHost:8888'",
+		"mkdir -p '/shared/det/123456790/rootfs'",
+		"base64 -d <<'DET_ARCHIVE_0' | tar -xzf - -C '/shared/det/123456790/rootfs'",
+		"DET_ARCHIVE_0",
+	} {
+		require.Contains(t, lines, want)
+	}
+	srun := lines[len(lines)-2]
+	require.True(t, strings.HasPrefix(srun,
+		"'srun' '--export=ALL' 'apptainer' 'exec' '--writable-tmpfs' '--nv' '--pwd' '/var/tmp'"),
+		srun)
+	require.Contains(t, srun, "'--bind' '/data:/mnt/data:ro'")
+	require.Contains(t, srun,
+		"'--bind' '/shared/det/123456790/rootfs/determined_local_fs:/determined_local_fs'")
+	require.Contains(t, srun, "'docker://determinedai/environments:cuda' "+
+		"'/determined_local_fs/dispatcher-wrapper.sh'")
+
+	t.Run("forbidden sbatch option", func(t *testing.T) {
+		_, err := slurmTestTaskSpec([]string{"--gpus=8"}, nil).ToSlurmBatchScript(syslog, opts)
+		require.ErrorContains(t, err, "is not configurable")
+	})
+
+	t.Run("sbatch option with a line break", func(t *testing.T) {
+		_, err := slurmTestTaskSpec([]string{"--comment=x\necho injected"}, nil).
+			ToSlurmBatchScript(syslog, opts)
+		require.ErrorContains(t, err, "may not contain line breaks")
+	})
+
+	t.Run("bind mount under /run/determined", func(t *testing.T) {
+		_, err := slurmTestTaskSpec(nil, []mount.Mount{
+			{Source: "/data", Target: "/run/determined/data"},
+		}).ToSlurmBatchScript(syslog, opts)
+		require.ErrorContains(t, err, "not supported")
+	})
+}
+
+func TestTaskSpec_slurmResourceArgs(t *testing.T) {
+	baseArgs := []string{
+		"--job-name=det-ai",
+		"--output=/jobs/1/slurm-%j.out",
+		"--chdir=/jobs/1",
+		"--ntasks-per-node=1",
+	}
+	tests := []struct {
+		name          string
+		taskType      model.TaskType
+		slotsPerNode  *int
+		gpuType       string
+		numSlots      int
+		slotType      device.Type
+		tresSupported bool
+		gresSupported bool
+		want          []string
+	}{
+		{
+			name:     "CPU",
+			numSlots: 8, slotType: device.CPU,
+			want: []string{"--nodes=8", "--cpus-per-task=1"},
+		},
+		{
+			name:     "CPU with zero slots",
+			numSlots: 0, slotType: device.CPU,
+			want: []string{"--nodes=1", "--cpus-per-task=1"},
+		},
+		{
+			name:     "CPU with slots per node",
+			numSlots: 8, slotType: device.CPU, slotsPerNode: ptrs.Ptr(4),
+			want: []string{"--nodes=2", "--cpus-per-task=4"},
+		},
+		{
+			name:     "TRES",
+			numSlots: 8, slotType: device.CUDA, tresSupported: true, gresSupported: true,
+			want: []string{"--gpus=8"},
+		},
+		{
+			name:     "TRES with gpu type",
+			numSlots: 8, slotType: device.CUDA, tresSupported: true, gresSupported: true,
+			gpuType: "tesla",
+			want:    []string{"--gpus=tesla:8"},
+		},
+		{
+			name:     "TRES with slots per node",
+			numSlots: 8, slotType: device.CUDA, tresSupported: true, gresSupported: true,
+			slotsPerNode: ptrs.Ptr(3),
+			want:         []string{"--nodes=3", "--gpus-per-node=3"},
+		},
+		{
+			name:     "TRES for a notebook",
+			taskType: model.TaskTypeNotebook,
+			numSlots: 2, slotType: device.CUDA, tresSupported: true, gresSupported: true,
+			want: []string{"--gpus=2", "--nodes=1"},
+		},
+		{
+			name:     "GRES only",
+			numSlots: 4, slotType: device.CUDA, gresSupported: true,
+			want: []string{"--nodes=4", "--gres=gpu:1"},
+		},
+		{
+			name:     "GRES only with slots per node",
+			numSlots: 4, slotType: device.CUDA, gresSupported: true, slotsPerNode: ptrs.Ptr(2),
+			want: []string{"--nodes=2", "--gres=gpu:2"},
+		},
+		{
+			name:     "Neither TRES nor GRES",
+			numSlots: 4, slotType: device.CUDA,
+			want: []string{"--nodes=4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := &TaskSpec{
+				TaskType: tt.taskType,
+				SlurmConfig: expconf.SlurmConfig{
+					RawSlotsPerNode: tt.slotsPerNode,
+					RawGpuType:      ptrs.Ptr(tt.gpuType),
+				},
+			}
+			got := ts.slurmResourceArgs(SlurmBatchScriptOptions{
+				JobDir:        "/jobs/1",
+				NumSlots:      tt.numSlots,
+				SlotType:      tt.slotType,
+				TresSupported: tt.tresSupported,
+				GresSupported: tt.gresSupported,
+			})
+			require.Equal(t, append(append([]string{}, baseArgs...), tt.want...), got)
+		})
+	}
+}
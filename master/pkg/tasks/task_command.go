@@ -56,10 +56,19 @@ type GenericCommandSpec struct {
 	Metadata genericCommandSpecMetadata
 
 	Keys *ssh.PrivateAndPublicKeys
+	// SSHCertificateAuthorityKey is the public key of the SSH certificate authority of the master,
+	// if shells accept certificates signed by it instead of the private key in Keys.
+	SSHCertificateAuthorityKey []byte
 
 	WatchProxyIdleTimeout  bool
 	WatchRunnerIdleTimeout bool
 
+	// SnapshotTimeout, if set, lets the command be suspended, and is how long it has to snapshot
+	// its working directory to checkpoint storage when it is.
+	SnapshotTimeout *model.Duration
+	// SnapshotStorage is the checkpoint storage the command's snapshots are saved to.
+	SnapshotStorage *expconf.CheckpointStorageConfig
+
 	TaskType model.TaskType
 }
 
@@ -83,10 +92,15 @@ func (s GenericCommandSpec) ToTaskSpec() TaskSpec {
 	res.ResolveWorkDir()
 
 	if s.Keys != nil {
+		authorizedKeys := s.Keys.PublicKey
+		if s.SSHCertificateAuthorityKey != nil {
+			authorizedKeys = ssh.CertificateAuthorityAuthorizedKey(
+				s.SSHCertificateAuthorityKey, ssh.TaskPrincipal(s.CommandID))
+		}
 		s.AdditionalFiles = append(s.AdditionalFiles, archive.Archive{
 			res.AgentUserGroup.OwnedArchiveItem(sshDir, nil, sshDirMode, tar.TypeDir),
 			res.AgentUserGroup.OwnedArchiveItem(
-				shellAuthorizedKeysFile, s.Keys.PublicKey, 0o644, tar.TypeReg,
+				shellAuthorizedKeysFile, authorizedKeys, 0o644, tar.TypeReg,
 			),
 			res.AgentUserGroup.OwnedArchiveItem(
 				privKeyFile, s.Keys.PrivateKey, privKeyMode, tar.TypeReg,
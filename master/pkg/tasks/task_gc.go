@@ -28,6 +28,9 @@ type GCCkptSpec struct {
 	// and just refresh the state of the checkpoint.
 	CheckpointGlobs    []string
 	DeleteTensorboards bool
+	// Untracked is set when ToDelete are not checkpoints the master has records of, such as
+	// notebook snapshots.
+	Untracked bool
 }
 
 // ToTaskSpec generates a TaskSpec.
@@ -104,6 +107,9 @@ func (g GCCkptSpec) ToTaskSpec() TaskSpec {
 	}
 
 	res.Description = fmt.Sprintf("gc-%d", g.ExperimentID)
+	if g.Untracked {
+		res.Description = fmt.Sprintf("gc-%s", g.ToDelete)
+	}
 
 	// We pass storage-config / delete / globs through a JSON file instead of a JSON string
 	// to avoid reaching any OS limitations on sizes of CLI arguments.
@@ -123,6 +129,10 @@ func (g GCCkptSpec) ToTaskSpec() TaskSpec {
 		res.Entrypoint = append(res.Entrypoint, "--delete-tensorboards")
 	}
 
+	if g.Untracked {
+		res.Entrypoint = append(res.Entrypoint, "--untracked")
+	}
+
 	res.Mounts = ToDockerMounts(g.LegacyConfig.BindMounts, res.WorkDir)
 	if fs := g.LegacyConfig.CheckpointStorage.RawSharedFSConfig; fs != nil {
 		res.Mounts = append(res.Mounts, mount.Mount{
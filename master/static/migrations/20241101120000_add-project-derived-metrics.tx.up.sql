@@ -0,0 +1,8 @@
+CREATE TABLE project_derived_metrics (
+    id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    project_id integer NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    expression text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT current_timestamp,
+    UNIQUE (project_id, name)
+);
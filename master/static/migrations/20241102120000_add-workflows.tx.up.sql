@@ -0,0 +1,28 @@
+CREATE TABLE workflows (
+    id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    name text NOT NULL,
+    project_id integer NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    owner_id integer NOT NULL REFERENCES users(id),
+    spec jsonb NOT NULL,
+    context_directory bytea NOT NULL DEFAULT ''::bytea,
+    state text NOT NULL,
+    start_time timestamptz NOT NULL DEFAULT current_timestamp,
+    end_time timestamptz
+);
+
+CREATE INDEX ix_workflows_state ON workflows(state);
+
+CREATE TABLE workflow_nodes (
+    workflow_id integer NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    task_id text REFERENCES tasks(task_id) ON DELETE SET NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    state text NOT NULL,
+    error_message text,
+    PRIMARY KEY (workflow_id, name)
+);
+
+CREATE TABLE task_metadata (
+    task_id text PRIMARY KEY REFERENCES tasks(task_id) ON DELETE CASCADE,
+    metadata jsonb NOT NULL DEFAULT '{}'::jsonb
+);
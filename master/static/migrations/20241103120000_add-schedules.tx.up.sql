@@ -0,0 +1,22 @@
+CREATE TABLE schedules (
+    id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    name text NOT NULL,
+    owner_id integer NOT NULL REFERENCES users(id),
+    workspace_id integer NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    project_id integer REFERENCES projects(id) ON DELETE CASCADE,
+    kind text NOT NULL,
+    cron text NOT NULL,
+    config text NOT NULL DEFAULT '',
+    template_name text,
+    context_directory bytea NOT NULL DEFAULT ''::bytea,
+    concurrency_policy text NOT NULL,
+    enabled boolean NOT NULL DEFAULT true,
+    created_at timestamptz NOT NULL DEFAULT current_timestamp,
+    -- The cron time of the latest firing, which is never fired again.
+    last_fire_time timestamptz,
+    -- The experiment or command of the latest firing.
+    last_run_id text,
+    queued_runs integer NOT NULL DEFAULT 0,
+    last_error text,
+    UNIQUE (workspace_id, name)
+);
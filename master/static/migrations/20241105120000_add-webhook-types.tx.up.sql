@@ -0,0 +1,5 @@
+ALTER TYPE public.webhook_type ADD VALUE 'TEAMS';
+ALTER TYPE public.webhook_type ADD VALUE 'DISCORD';
+ALTER TYPE public.webhook_type ADD VALUE 'TEMPLATED';
+
+ALTER TABLE webhooks ADD COLUMN template text;
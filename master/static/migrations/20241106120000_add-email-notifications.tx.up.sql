@@ -0,0 +1,12 @@
+CREATE TABLE email_notification_settings (
+    user_id integer PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    email text NOT NULL,
+    experiment_states text[] NOT NULL DEFAULT '{}',
+    task_log_regex text
+);
+
+CREATE TABLE email_task_log_notifications (
+    task_id text NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, user_id)
+);
@@ -0,0 +1,32 @@
+ALTER TABLE webhooks
+    ADD COLUMN created_by integer REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN disabled boolean NOT NULL DEFAULT false,
+    ADD COLUMN consecutive_failures integer NOT NULL DEFAULT 0;
+
+ALTER TABLE webhook_events_queue
+    ADD COLUMN webhook_id integer REFERENCES webhooks(id) ON DELETE SET NULL;
+
+CREATE TABLE webhook_delivery_attempts (
+    id bigserial PRIMARY KEY,
+    event_id integer NOT NULL,
+    webhook_id integer REFERENCES webhooks(id) ON DELETE CASCADE,
+    url text NOT NULL,
+    attempt integer NOT NULL,
+    status_code integer,
+    latency_ms integer NOT NULL,
+    response_body text,
+    error text,
+    attempted_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ix_webhook_delivery_attempts_webhook_id
+    ON webhook_delivery_attempts (webhook_id, attempted_at DESC);
+
+CREATE TABLE webhook_dead_letters (
+    event_id integer PRIMARY KEY,
+    webhook_id integer REFERENCES webhooks(id) ON DELETE CASCADE,
+    url text NOT NULL,
+    payload bytea NOT NULL,
+    last_error text NOT NULL,
+    failed_at timestamptz NOT NULL DEFAULT now()
+);
@@ -0,0 +1,15 @@
+CREATE TABLE checkpoint_retention_policies (
+    id serial PRIMARY KEY,
+    workspace_id integer REFERENCES workspaces(id) ON DELETE CASCADE,
+    max_age_days integer CHECK (max_age_days > 0),
+    keep_best_per_project integer CHECK (keep_best_per_project >= 0),
+    metric_name text,
+    smaller_is_better boolean,
+    enabled boolean NOT NULL DEFAULT true,
+    created_by integer REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CHECK (max_age_days IS NOT NULL OR keep_best_per_project IS NOT NULL)
+);
+
+CREATE INDEX ix_checkpoint_retention_policies_workspace_id
+    ON checkpoint_retention_policies (workspace_id);
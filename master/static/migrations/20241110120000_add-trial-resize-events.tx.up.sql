@@ -0,0 +1,10 @@
+CREATE TABLE trial_resize_events (
+    id serial PRIMARY KEY,
+    trial_id int NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+    from_slots int NOT NULL,
+    to_slots int NOT NULL,
+    reason text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ix_trial_resize_events_trial_id ON trial_resize_events(trial_id);
@@ -0,0 +1,6 @@
+CREATE TABLE resource_pools (
+    pool_name text PRIMARY KEY,
+    config jsonb NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
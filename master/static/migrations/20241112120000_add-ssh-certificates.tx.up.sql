@@ -0,0 +1,19 @@
+CREATE TABLE ssh_certificate_authority (
+    public_key bytea NOT NULL,
+    private_key bytea NOT NULL
+);
+
+CREATE TABLE ssh_certificates (
+    serial bigint PRIMARY KEY,
+    key_id text NOT NULL,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id text NOT NULL,
+    principals text[] NOT NULL,
+    public_key_fingerprint text NOT NULL,
+    valid_after timestamptz NOT NULL,
+    valid_before timestamptz NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ix_ssh_certificates_user_id ON ssh_certificates USING btree (user_id);
+CREATE INDEX ix_ssh_certificates_task_id ON ssh_certificates USING btree (task_id);
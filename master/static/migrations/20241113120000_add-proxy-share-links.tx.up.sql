@@ -0,0 +1,29 @@
+CREATE TABLE proxy_share_links (
+    id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    task_id text NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mode text NOT NULL,
+    -- The users allowed to use the link; anyone holding it may when empty.
+    usernames text[] NOT NULL DEFAULT '{}',
+    description text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT current_timestamp,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz
+);
+
+CREATE INDEX ix_proxy_share_links_task_id ON proxy_share_links USING btree (task_id);
+
+CREATE TABLE proxy_share_link_accesses (
+    id bigint PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    share_link_id integer NOT NULL REFERENCES proxy_share_links(id) ON DELETE CASCADE,
+    -- The logged in user, if any.
+    user_id integer REFERENCES users(id) ON DELETE SET NULL,
+    remote_addr text NOT NULL,
+    method text NOT NULL,
+    path text NOT NULL,
+    allowed boolean NOT NULL,
+    accessed_at timestamptz NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX ix_proxy_share_link_accesses_share_link_id
+    ON proxy_share_link_accesses USING btree (share_link_id, accessed_at DESC);
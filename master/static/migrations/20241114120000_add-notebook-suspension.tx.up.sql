@@ -0,0 +1,4 @@
+ALTER TABLE command_state
+    ADD COLUMN suspended_time timestamptz NULL,
+    ADD COLUMN snapshot_storage_id text NULL,
+    ADD COLUMN snapshot_allocation_id text NULL;
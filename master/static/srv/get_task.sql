@@ -6,6 +6,7 @@ SELECT
     CASE WHEN t.task_state is NULL THEN NULL
     ELSE CONCAT('GENERIC_TASK_STATE_', t.task_state)
     END as task_state,
+    t.watchdog,
     (
         SELECT
             COALESCE(
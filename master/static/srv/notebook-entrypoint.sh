@@ -13,6 +13,12 @@ export SHELL
 
 "$DET_PYTHON_EXECUTABLE" -m determined.exec.prep_container --resources --proxy --download_context_directory
 
+# Suspendable notebooks restore their working directory when resumed, and save it when suspended.
+if [ -f /run/determined/jupyter/snapshot_storage.json ]; then
+    "$DET_PYTHON_EXECUTABLE" /run/determined/jupyter/notebook_snapshot.py restore
+    "$DET_PYTHON_EXECUTABLE" /run/determined/jupyter/notebook_snapshot.py watch &
+fi
+
 STARTUP_HOOK="startup-hook.sh"
 set -x
 test -f "${TCD_STARTUP_HOOK}" && source "${TCD_STARTUP_HOOK}"
@@ -0,0 +1,101 @@
+"""
+Snapshots the working directory of a notebook to checkpoint storage when the notebook is suspended,
+and restores it when the notebook is resumed.
+"""
+
+import argparse
+import json
+import logging
+import os
+import sys
+import time
+import uuid
+from typing import List
+
+from determined.common import api, constants, storage
+from determined.common.api import authentication, bindings, certs
+
+STORAGE_CONFIG_PATH = "/run/determined/jupyter/snapshot_storage.json"
+
+# How long each long poll for the suspension of the notebook lasts.
+POLL_TIMEOUT_SECONDS = 60
+RETRY_INTERVAL_SECONDS = 5
+
+
+def build_storage_manager() -> storage.StorageManager:
+    with open(STORAGE_CONFIG_PATH) as f:
+        config = json.load(f)
+    return storage.build(config, container_path=constants.SHARED_FS_CONTAINER_PATH)
+
+
+def restore() -> None:
+    snapshot = os.environ.get("DET_NOTEBOOK_SNAPSHOT")
+    if not snapshot:
+        return
+
+    logging.info(f"restoring the working directory from snapshot {snapshot}")
+    build_storage_manager().download(snapshot, os.getcwd())
+
+
+def watch() -> None:
+    notebook_id = os.environ["DET_TASK_ID"]
+    allocation_id = os.environ["DET_ALLOCATION_ID"]
+    master_url = api.canonicalize_master_url(os.environ["DET_MASTER"])
+    cert = certs.default_load(master_url)
+    sess = authentication.login_from_task(master_url, cert=cert)
+
+    while True:
+        try:
+            resp = bindings.get_AllocationPreemptionSignal(
+                sess, allocationId=allocation_id, timeoutSeconds=POLL_TIMEOUT_SECONDS
+            )
+        except Exception:
+            logging.warning("ignoring error communicating with master", exc_info=True)
+            time.sleep(RETRY_INTERVAL_SECONDS)
+            continue
+        if resp.preempt:
+            break
+
+    bindings.post_AckAllocationPreemptionSignal(
+        sess,
+        allocationId=allocation_id,
+        body=bindings.v1AckAllocationPreemptionSignalRequest(allocationId=allocation_id),
+    )
+
+    manager = build_storage_manager()
+    snapshot = str(uuid.uuid4())
+    logging.info(f"suspending: saving the working directory to snapshot {snapshot}")
+    manager.upload(os.getcwd(), snapshot)
+
+    # The master stops the notebook once it has the snapshot.
+    bindings.put_ReportNotebookSnapshot(
+        sess,
+        notebookId=notebook_id,
+        body=bindings.v1ReportNotebookSnapshotRequest(notebookId=notebook_id, storageId=snapshot),
+    )
+
+    # The snapshot this notebook was restored from is no longer needed, now that the master has
+    # recorded the new one. Deleting it any earlier would lose the working directory if reporting
+    # the new snapshot failed.
+    previous = os.environ.get("DET_NOTEBOOK_SNAPSHOT")
+    if previous:
+        try:
+            manager.delete(previous, ["**/*"])
+        except Exception:
+            logging.warning(f"failed to delete previous snapshot {previous}", exc_info=True)
+
+def main(argv: List[str]) -> None:
+    logging.basicConfig(level=logging.INFO, format="%(levelname)s: [%(name)s] %(message)s")
+
+    parser = argparse.ArgumentParser(description="Determined notebook snapshots")
+    parser.add_argument("action", choices=["restore", "watch"])
+    args = parser.parse_args(argv)
+
+    if args.action == "restore":
+        restore()
+    else:
+        watch()
+
+
+if __name__ == "__main__":
+    main(sys.argv[1:])
@@ -78,7 +78,10 @@ options="$(
 # unable to edit authorized_keys in place.
 unmodified="/run/determined/ssh/authorized_keys_unmodified"
 modified="/run/determined/ssh/authorized_keys"
-sed -e "s/^/$options /" "$unmodified" >"$modified"
+# Entries trusting a certificate authority already start with options, which the environment
+# options are joined to with no space.
+sed -e '/^cert-authority/{' -e "s/^/$options/" -e 'b' -e '}' -e "s/^/$options /" \
+    "$unmodified" >"$modified"
 # Ensure permissions are restrictive enough for ssh
 chmod 600 "$modified"
 
@@ -30,6 +30,7 @@ export const LOCATION_LABEL_MAP: Record<V1LocationType, string> = {
   [V1LocationType.HYPERPARAMETERS]: 'Hyperparameters',
   [V1LocationType.RUNHYPERPARAMETERS]: 'Hyperparameters',
   [V1LocationType.RUNMETADATA]: 'Metadata',
+  [V1LocationType.DERIVEDMETRIC]: 'Derived Metrics',
   [V1LocationType.UNSPECIFIED]: 'Unspecified',
 } as const;
 
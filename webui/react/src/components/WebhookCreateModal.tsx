@@ -32,6 +32,7 @@ const triggerEvents = [
 
 interface FormInputs {
   regex?: string;
+  template?: string;
   triggerEvents: (typeof triggerEvents)[number][];
   url: string;
   webhookType: V1WebhookType;
@@ -49,6 +50,18 @@ const typeOptions = [
     label: 'Slack',
     value: V1WebhookType.SLACK,
   },
+  {
+    label: 'Microsoft Teams',
+    value: V1WebhookType.TEAMS,
+  },
+  {
+    label: 'Discord',
+    value: V1WebhookType.DISCORD,
+  },
+  {
+    label: 'Templated',
+    value: V1WebhookType.TEMPLATED,
+  },
 ];
 const triggerOptions = [
   {
@@ -83,6 +96,7 @@ const WebhookCreateModalComponent: React.FC<Props> = ({ onSuccess }: Props) => {
   const [form] = Form.useForm<FormInputs>();
   const [disabled, setDisabled] = useState<boolean>(true);
   const triggerEvents = Form.useWatch('triggerEvents', form);
+  const webhookType = Form.useWatch('webhookType', form);
   const f_webhook = useFeature().isOn('webhook_improvement');
   const workspaces = useObservable(workspaceStore.workspaces).getOrElse([]);
   const { canCreateWebhooks } = usePermissions();
@@ -136,6 +150,7 @@ const WebhookCreateModalComponent: React.FC<Props> = ({ onSuccess }: Props) => {
               triggerType: V1TriggerType.EXPERIMENTSTATECHANGE,
             };
           }),
+          template: values.webhookType === V1WebhookType.TEMPLATED ? values.template : undefined,
           url: values.url,
           webhookType: values.webhookType,
           workspaceId: values.workspaceId,
@@ -228,6 +243,17 @@ const WebhookCreateModalComponent: React.FC<Props> = ({ onSuccess }: Props) => {
           rules={[{ message: 'Webhook type is required ', required: true }]}>
           <Select options={typeOptions} placeholder="Select type of Webhook" />
         </Form.Item>
+        {webhookType === V1WebhookType.TEMPLATED && (
+          <Form.Item
+            label="Template"
+            name="template"
+            rules={[{ message: 'Template is required for templated webhooks', required: true }]}>
+            <Input.TextArea
+              placeholder={'{"text": {{ json .Data.Experiment.Name }}}'}
+              rows={6}
+            />
+          </Form.Item>
+        )}
         <Form.Item
           label="Trigger"
           name="triggerEvents"
@@ -109,6 +109,7 @@ export const V1ResourcePoolTypeToLabel: { [key in V1ResourcePoolType]: string }
   [V1ResourcePoolType.GCP]: 'GCP',
   [V1ResourcePoolType.STATIC]: 'Static',
   [V1ResourcePoolType.K8S]: 'Kubernetes',
+  [V1ResourcePoolType.EXTERNAL]: 'External',
 };
 
 export const V1SchedulerTypeToLabel: { [key in V1SchedulerType]: string } = {
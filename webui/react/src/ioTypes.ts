@@ -298,6 +298,7 @@ export const ioLocationType: io.Type<V1LocationType> = io.keyof({
   [V1LocationType.RUN]: null,
   [V1LocationType.RUNHYPERPARAMETERS]: null,
   [V1LocationType.RUNMETADATA]: null,
+  [V1LocationType.DERIVEDMETRIC]: null,
 });
 export const ioColumnType: io.Type<V1ColumnType> = io.keyof({
   [V1ColumnType.DATE]: null,
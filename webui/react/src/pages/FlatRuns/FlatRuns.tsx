@@ -359,6 +359,9 @@ const FlatRuns: React.FC<Props> = ({ projectId, workspaceId, searchId }) => {
           case V1LocationType.CUSTOMMETRIC:
             dataPath = `summaryMetrics.${currentColumn.column}`;
             break;
+          case V1LocationType.DERIVEDMETRIC:
+            dataPath = `derivedMetrics.${currentColumn.column.replace('derived.', '')}`;
+            break;
           case V1LocationType.UNSPECIFIED:
           default:
             break;
@@ -1011,6 +1014,7 @@ const FlatRuns: React.FC<Props> = ({ projectId, workspaceId, searchId }) => {
                 [V1LocationType.VALIDATIONS, V1LocationType.TRAINING, V1LocationType.CUSTOMMETRIC],
                 V1LocationType.RUNHYPERPARAMETERS,
                 V1LocationType.RUNMETADATA,
+                V1LocationType.DERIVEDMETRIC,
               ]}
               onVisibleColumnChange={handleColumnsOrderChange}
             />
@@ -889,6 +889,9 @@ export const mapV1Webhook = (data: Sdk.V1Webhook): types.Webhook => {
         [Sdk.V1WebhookType.UNSPECIFIED]: 'Unspecified',
         [Sdk.V1WebhookType.DEFAULT]: 'Default',
         [Sdk.V1WebhookType.SLACK]: 'Slack',
+        [Sdk.V1WebhookType.TEAMS]: 'Teams',
+        [Sdk.V1WebhookType.DISCORD]: 'Discord',
+        [Sdk.V1WebhookType.TEMPLATED]: 'Templated',
       }[data.webhookType] || 'Unspecified',
     workspaceId: data.workspaceId ?? 0,
   };
@@ -1299,6 +1299,7 @@ export interface FlatRun {
   parentArchived: boolean;
   experiment?: FlatRunExperiment;
   logPolicyMatched?: string;
+  derivedMetrics?: Record<string, number>;
 }
 
 export interface FlatRunExperiment {